@@ -0,0 +1,34 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command kubectl-rsm is a kubectl plugin, invoked as `kubectl rsm`, to inspect ResourceMetricsMonitors, and the series
+// they generate.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/rexagod/resource-state-metrics/internal"
+)
+
+func main() {
+	if err := internal.Plugin(context.Background(), os.Stdout, os.Args[1:]); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
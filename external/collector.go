@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
 	metricsstore "k8s.io/kube-state-metrics/v2/pkg/metrics_store"
 )
 
@@ -14,19 +15,19 @@ type gvkr struct {
 	schema.GroupVersionResource
 }
 type collectors interface {
-	BuildCollector(ctx context.Context, kubeconfig string) *metricsstore.MetricsStore
+	BuildCollector(ctx context.Context, cfg *rest.Config) *metricsstore.MetricsStore
 	GVKR() gvkr
 	Register()
 }
 
 type collectorsType struct {
-	kubeconfig      string
+	cfg             *rest.Config
 	collectors      []collectors
 	builtCollectors []*metricsstore.MetricsStore
 }
 
-func (ct *collectorsType) SetKubeConfig(kubeconfig string) *collectorsType {
-	ct.kubeconfig = kubeconfig
+func (ct *collectorsType) SetConfig(cfg *rest.Config) *collectorsType {
+	ct.cfg = cfg
 
 	return ct
 }
@@ -37,7 +38,7 @@ func (ct *collectorsType) Register(c collectors) {
 
 func (ct *collectorsType) Build(ctx context.Context) {
 	for _, c := range ct.collectors {
-		ct.builtCollectors = append(ct.builtCollectors, c.BuildCollector(ctx, ct.kubeconfig))
+		ct.builtCollectors = append(ct.builtCollectors, c.BuildCollector(ctx, ct.cfg))
 		c.Register()
 	}
 }
@@ -0,0 +1,92 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// responseRecorder records the status and size of the responses written through it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+// WriteHeader records the response's status.
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the response's size, and its status, if not written yet.
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+
+	return n, err
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for http.ResponseController.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// accessLogHandler logs each request served by the given handler, once served.
+func accessLogHandler(logger klog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		logger.Info("Served request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.size,
+			"duration", time.Since(start),
+			"remote", r.RemoteAddr,
+			"userAgent", r.UserAgent(),
+		)
+	})
+}
+
+// slowScrapes warns of expositions rendered for longer than a threshold, e.g., to diagnose scrape timeouts.
+type slowScrapes struct {
+	// threshold is the duration past which renders are reported, or 0 if none are.
+	threshold time.Duration
+	// total counts the renders past the threshold, by the path they were served on.
+	total *prometheus.CounterVec
+}
+
+// observe reports the given request's render, if it took longer than the threshold.
+func (s *slowScrapes) observe(logger klog.Logger, r *http.Request, duration time.Duration) {
+	if s == nil || s.threshold <= 0 || duration <= s.threshold {
+		return
+	}
+	// Patterns, rather than paths, bound the counter's cardinality.
+	s.total.WithLabelValues(r.Pattern).Inc()
+	logger.Info("Slow scrape, consider raising the scrape timeout, or sharding the monitors", "path", r.URL.Path, "duration", duration, "threshold", s.threshold, "remote", r.RemoteAddr)
+}
@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestAccessLogHandler(t *testing.T) {
+	t.Parallel()
+	var logged []string
+	logger := funcr.New(func(_, args string) { logged = append(logged, args) }, funcr.Options{})
+	handler := accessLogHandler(logger, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("foo"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if len(logged) != 1 {
+		t.Fatalf("expected 1 access log, got %d", len(logged))
+	}
+	for _, expected := range []string{`"path"="/metrics"`, `"status"=418`, `"bytes"=3`} {
+		if !strings.Contains(logged[0], expected) {
+			t.Errorf("expected %s in %s", expected, logged[0])
+		}
+	}
+}
+
+func TestSlowScrapes_observe(t *testing.T) {
+	t.Parallel()
+	s := &slowScrapes{
+		threshold: time.Second,
+		total:     prometheus.NewCounterVec(prometheus.CounterOpts{Name: "slow_scrapes_total"}, []string{"path"}),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics/{name}", func(_ http.ResponseWriter, r *http.Request) {
+		logger := funcr.New(func(_, _ string) {}, funcr.Options{})
+		s.observe(logger, r, time.Millisecond)
+		s.observe(logger, r, 2*time.Second)
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics/foo", nil))
+
+	if got := testutil.ToFloat64(s.total.WithLabelValues("/metrics/{name}")); got != 1 {
+		t.Errorf("expected 1 slow scrape, got %v", got)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// aggregation accumulates the series of an aggregated family across objects, keyed by their names and labelsets.
+type aggregation struct {
+	function AggregateType
+	sums     map[string]float64
+	counts   map[string]int
+	// fixedPoint formats the aggregated values in fixed-point notation.
+	fixedPoint bool
+}
+
+// newAggregation returns an empty aggregation for the given function.
+func newAggregation(function AggregateType) *aggregation {
+	return &aggregation{
+		function: function,
+		sums:     map[string]float64{},
+		counts:   map[string]int{},
+	}
+}
+
+// add accumulates the series in the given (rendered) family of an object. Lines that are not series are ignored.
+func (a *aggregation) add(family string) error {
+	for _, series := range strings.Split(family, "\n") {
+		// Label values may contain spaces, but sample values may not.
+		i := strings.LastIndexByte(series, ' ')
+		if i < 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(series[i+1:], 64)
+		if err != nil {
+			continue
+		}
+		a.sums[series[:i]] += value
+		a.counts[series[:i]]++
+	}
+
+	return nil
+}
+
+// writeTo writes out the aggregated series, sorted by their names and labelsets.
+func (a *aggregation) writeTo(writer io.Writer) error {
+	for _, key := range slices.Sorted(maps.Keys(a.counts)) {
+		var value float64
+		switch a.function {
+		case AggregateTypeCount:
+			value = float64(a.counts[key])
+		case AggregateTypeSum:
+			value = a.sums[key]
+		case AggregateTypeAvg:
+			value = a.sums[key] / float64(a.counts[key])
+		case AggregateTypeNone:
+			return fmt.Errorf("no aggregation set for %q", key)
+		}
+		if _, err := fmt.Fprintf(writer, "%s %s\n", key, formatValue(value, a.fixedPoint)); err != nil {
+			return fmt.Errorf("error writing aggregated series: %w", err)
+		}
+	}
+
+	return nil
+}
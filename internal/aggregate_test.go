@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAggregation(t *testing.T) {
+	t.Parallel()
+	families := []string{
+		"foo{namespace=\"bar\",phase=\"Running\"} 1.000000\nfoo{namespace=\"bar\",phase=\"Pending\"} 4.000000\n",
+		"foo{namespace=\"bar\",phase=\"Running\"} 3.000000\n",
+		"foo{namespace=\"baz\",phase=\"Failed\",reason=\"out of memory\"} 2.000000\n",
+		"",
+	}
+	tests := []struct {
+		function AggregateType
+		expected string
+	}{
+		{
+			function: AggregateTypeCount,
+			expected: `foo{namespace="bar",phase="Pending"} 1
+foo{namespace="bar",phase="Running"} 2
+foo{namespace="baz",phase="Failed",reason="out of memory"} 1
+`,
+		},
+		{
+			function: AggregateTypeSum,
+			expected: `foo{namespace="bar",phase="Pending"} 4
+foo{namespace="bar",phase="Running"} 4
+foo{namespace="baz",phase="Failed",reason="out of memory"} 2
+`,
+		},
+		{
+			function: AggregateTypeAvg,
+			expected: `foo{namespace="bar",phase="Pending"} 4
+foo{namespace="bar",phase="Running"} 2
+foo{namespace="baz",phase="Failed",reason="out of memory"} 2
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.function), func(t *testing.T) {
+			t.Parallel()
+			a := newAggregation(tt.function)
+			for _, family := range families {
+				if err := a.add(family); err != nil {
+					t.Fatal(err)
+				}
+			}
+			buffer := &bytes.Buffer{}
+			if err := a.writeTo(buffer); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(buffer.String(), tt.expected); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
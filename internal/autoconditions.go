@@ -0,0 +1,318 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// autoConditionsKey is the key the auto-conditions stores are held under, alongside the monitors' ones. It is not a
+// valid object name, so it does not collide with any monitor's key.
+const autoConditionsKey = "_auto-conditions"
+
+// autoConditions builds a store exposing the status conditions of each CRD's targets, for all CRDs whose schema
+// defines status.conditions, without any monitor configuring them. A store is built for each kind, holding a selected
+// store for each CRD of that kind, in each cluster, so that the kinds' families are exposed once.
+type autoConditions struct {
+	ctx        context.Context
+	logger     klog.Logger
+	clientsets map[string]dynamic.Interface
+	stores     *sync.Map
+	// namespace and labelSelector filter the CRDs' targets.
+	namespace        string
+	labelSelector    string
+	listPageSize     int64
+	storage          storageFactory
+	exposition       ExpositionMode
+	fixedPointValues bool
+	droppedSamples   *prometheus.CounterVec
+
+	mutex sync.Mutex
+	// kinds holds the store built for each kind, by its family name.
+	kinds map[string]*StoreType
+}
+
+// newAutoConditions returns the auto-conditions stores, held under autoConditionsKey in the given stores, for the
+// targets in the given namespace (or all namespaces, if empty), matching the given label selector.
+func newAutoConditions(clientsets map[string]dynamic.Interface, stores *sync.Map, namespace, labelSelector string) *autoConditions {
+	return &autoConditions{
+		logger:        klog.Background(),
+		clientsets:    clientsets,
+		stores:        stores,
+		namespace:     namespace,
+		labelSelector: labelSelector,
+		kinds:         map[string]*StoreType{},
+	}
+}
+
+// start watches the CRDs in each cluster, until the context is cancelled, after which the stores are dropped.
+func (a *autoConditions) start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	a.mutex.Lock()
+	a.ctx = ctx
+	a.logger = klog.FromContext(ctx).WithValues("key", autoConditionsKey)
+	a.mutex.Unlock()
+	for cluster, dynamicClientset := range a.clientsets {
+		selection := &autoConditionsSelection{autoConditions: a, cluster: cluster, dynamicClientset: dynamicClientset}
+		startReflector(ctx, buildLW(ctx, dynamicClientset, metav1.NamespaceAll, "", "", crdGVKR.GroupVersionResource, nil), crdGVKR, selection)
+	}
+	<-ctx.Done()
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.removeLocked(func(types.UID, *StoreType) bool { return true })
+	a.stores.Delete(autoConditionsKey)
+
+	return nil
+}
+
+// conditionsFamilyName returns the name of the family exposing the status conditions of the given kind's targets.
+func conditionsFamilyName(kind string) string {
+	return sanitizeKey(kind) + "_status_condition"
+}
+
+// conditionsFamily returns the family exposing a sample for each status condition of the given kind's targets,
+// labeled with the condition's type and status.
+func conditionsFamily(kind string) *FamilyType {
+	return &FamilyType{
+		Name:     conditionsFamilyName(kind),
+		Help:     fmt.Sprintf("The status conditions of %s objects.", kind),
+		Resolver: ResolverTypeUnstructured,
+		Metrics: []*MetricType{{
+			Value: "1",
+			Expand: []*ExpandLevelType{{
+				Path:        "status.conditions",
+				LabelKeys:   []string{"condition", "status"},
+				LabelValues: []string{"type", "status"},
+			}},
+		}},
+	}
+}
+
+// hasStatusConditions returns whether the schema of the given version of the given CRD defines status.conditions.
+func hasStatusConditions(crd *unstructured.Unstructured, version string) bool {
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, versionI := range versions {
+		crdVersion, ok := versionI.(map[string]interface{})
+		if !ok || crdVersion["name"] != version {
+			continue
+		}
+		_, found, _ := unstructured.NestedFieldNoCopy(crdVersion, "schema", "openAPIV3Schema", "properties", "status", "properties", "conditions")
+
+		return found
+	}
+
+	return false
+}
+
+// add builds a selected store for the given CRD, in the given cluster, under the store of its kind, replacing the
+// existing one if the CRD's targeted version changed, or removes it if the CRD no longer defines status.conditions.
+func (a *autoConditions) add(cluster string, dynamicClientset dynamic.Interface, crd *unstructured.Unstructured) {
+	gvkWithR, ok := selectedGVKR(crd)
+	if !ok || !hasStatusConditions(crd, gvkWithR.GroupVersionKind.Version) {
+		a.remove(crd.GetUID())
+
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	familyName := conditionsFamilyName(gvkWithR.Kind)
+	s, ok := a.kinds[familyName]
+	if !ok {
+		s = newConfiguredStore(a.logger, []*FamilyType{conditionsFamily(gvkWithR.Kind)}, ResolverTypeUnstructured, nil, nil, 0, 0, nil, "", autoConditionsKey)
+		for _, family := range s.Families {
+			family.exposition = a.exposition
+			family.fixedPointValues = a.fixedPointValues
+			family.droppedSamples = a.droppedSamples
+		}
+		s.selected = map[types.UID]*StoreType{}
+		s.filter = newStoreFilter("", nil)
+		s.listPageSize = a.listPageSize
+		s.Kind = gvkWithR.Kind
+		if a.storage != nil {
+			s.storage = func(target string) seriesStorage { return a.storage(path.Join(autoConditionsKey, target)) }
+		}
+		a.kinds[familyName] = s
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if existing, ok := s.selected[crd.GetUID()]; ok {
+		if existing.gvr() == gvkWithR.GroupVersionResource {
+			return
+		}
+		existing.stop()
+		existing.purge()
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	selectedStore := s.newSelectedStore(gvkWithR, cluster)
+	selectedStore.stop = cancel
+	s.selected[crd.GetUID()] = selectedStore
+	startReflector(ctx, buildLW(ctx, dynamicClientset, a.namespace, a.labelSelector, "", gvkWithR.GroupVersionResource, selectedStore), gvkWithR, selectedStore)
+	a.logger.V(2).Info("Selected", "crd", crd.GetName(), "gvr", gvkWithR.GroupVersionResource.String(), "cluster", cluster)
+	a.publish()
+}
+
+// remove drops the selected store built for the CRD of the given UID, if any, along with the store of its kind, once
+// it holds no others.
+func (a *autoConditions) remove(uid types.UID) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.removeLocked(func(candidate types.UID, _ *StoreType) bool { return candidate == uid })
+}
+
+// removeLocked drops the selected stores matching the given predicate, along with the stores of their kinds, once they
+// hold no others. The caller must hold the lock.
+func (a *autoConditions) removeLocked(matches func(uid types.UID, selectedStore *StoreType) bool) {
+	removed := false
+	for familyName, s := range a.kinds {
+		s.mutex.Lock()
+		for uid, selectedStore := range s.selected {
+			if !matches(uid, selectedStore) {
+				continue
+			}
+			selectedStore.stop()
+			selectedStore.purge()
+			delete(s.selected, uid)
+			s.resetDeltas()
+			removed = true
+		}
+		empty := len(s.selected) == 0
+		s.mutex.Unlock()
+		if empty {
+			delete(a.kinds, familyName)
+		}
+	}
+	if removed {
+		a.publish()
+	}
+}
+
+// publish stores the stores of all kinds, sorted by their families' names, under autoConditionsKey. The caller must
+// hold the lock.
+func (a *autoConditions) publish() {
+	familyNames := make([]string, 0, len(a.kinds))
+	for familyName := range a.kinds {
+		familyNames = append(familyNames, familyName)
+	}
+	slices.Sort(familyNames)
+	builtStores := make([]*StoreType, 0, len(familyNames))
+	for _, familyName := range familyNames {
+		builtStores = append(builtStores, a.kinds[familyName])
+	}
+	a.stores.Store(autoConditionsKey, builtStores)
+}
+
+// autoConditionsSelection implements cache.Store for the CRD reflector of the auto-conditions stores, in a cluster.
+type autoConditionsSelection struct {
+	*autoConditions
+	cluster          string
+	dynamicClientset dynamic.Interface
+}
+
+// Ensure autoConditionsSelection implements cache.Store.
+var _ cache.Store = &autoConditionsSelection{}
+
+// Add builds a store for the given CRD, if its schema defines status.conditions.
+func (c *autoConditionsSelection) Add(objectI interface{}) error {
+	crd, err := convertToUnstructured(objectI)
+	if err != nil {
+		return err
+	}
+	c.add(c.cluster, c.dynamicClientset, crd)
+
+	return nil
+}
+
+// Update is called when a CRD is updated, which may change its storage version, or schema.
+func (c *autoConditionsSelection) Update(objectI interface{}) error {
+	return c.Add(objectI)
+}
+
+// Delete removes the store built for the given CRD.
+func (c *autoConditionsSelection) Delete(objectI interface{}) error {
+	crd, err := meta.Accessor(objectI)
+	if err != nil {
+		return fmt.Errorf("error casting object interface: %w", err)
+	}
+	c.remove(crd.GetUID())
+
+	return nil
+}
+
+// Replace is called when the reflector lists all CRDs, and drops the stores for the ones no longer present in the
+// selection's cluster.
+func (c *autoConditionsSelection) Replace(items []interface{}, _ string) error {
+	present := sets.New[types.UID]()
+	for _, item := range items {
+		if err := c.Add(item); err != nil {
+			c.logger.Error(err, "failed to select CRD during replace")
+
+			continue
+		}
+		if crd, err := meta.Accessor(item); err == nil {
+			present.Insert(crd.GetUID())
+		}
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.removeLocked(func(uid types.UID, selectedStore *StoreType) bool {
+		return selectedStore.cluster == c.cluster && !present.Has(uid)
+	})
+
+	return nil
+}
+
+// List is not needed for our use case, so it returns nil.
+func (c *autoConditionsSelection) List() []interface{} { return nil }
+
+// ListKeys is not needed for our use case, so it returns nil.
+func (c *autoConditionsSelection) ListKeys() []string { return nil }
+
+// Get is not needed for our use case, so it returns nil and false.
+func (c *autoConditionsSelection) Get(_ interface{}) (interface{}, bool, error) {
+	return nil, false, nil
+}
+
+// GetByKey is not needed for our use case, so it returns nil and false.
+func (c *autoConditionsSelection) GetByKey(_ string) (interface{}, bool, error) {
+	return nil, false, nil
+}
+
+// Resync is not needed for our use case, so it does nothing and returns nil.
+func (c *autoConditionsSelection) Resync() error { return nil }
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestConditionsFamilyName(t *testing.T) {
+	t.Parallel()
+	for kind, expected := range map[string]string{
+		"Foo":                "foo_status_condition",
+		"CertificateRequest": "certificate_request_status_condition",
+	} {
+		if got := conditionsFamilyName(kind); got != expected {
+			t.Errorf("expected %q for %s, got %q", expected, kind, got)
+		}
+	}
+}
+
+func TestAutoConditions(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conditionsSchema := map[string]interface{}{"openAPIV3Schema": map[string]interface{}{"properties": map[string]interface{}{
+		"status": map[string]interface{}{"properties": map[string]interface{}{"conditions": map[string]interface{}{"type": "array"}}},
+	}}}
+	withConditions := newTestCRD("foos.contoso.com", "contoso.com", "Foo", "foos", nil,
+		map[string]interface{}{"name": "v1", "served": true, "storage": true, "schema": conditionsSchema})
+	withoutConditions := newTestCRD("bars.contoso.com", "contoso.com", "Bar", "bars", nil,
+		map[string]interface{}{"name": "v1", "served": true, "storage": true})
+	newObject := func(kind, name, namespace string) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{Object: map[string]interface{}{"status": map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+		}}}
+		object.SetGroupVersionKind(schema.GroupVersionKind{Group: "contoso.com", Version: "v1", Kind: kind})
+		object.SetNamespace(namespace)
+		object.SetName(name)
+		object.SetUID(types.UID("uid-" + name))
+
+		return object
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crdGVKR.GroupVersionResource:                            "CustomResourceDefinitionList",
+		{Group: "contoso.com", Version: "v1", Resource: "foos"}: "FooList",
+		{Group: "contoso.com", Version: "v1", Resource: "bars"}: "BarList",
+	}, withConditions, withoutConditions, newObject("Foo", "foo", "default"), newObject("Foo", "other", "other"), newObject("Bar", "bar", "default"))
+
+	stores := &sync.Map{}
+	autoConditions := newAutoConditions(map[string]dynamic.Interface{localCluster: client}, stores, "default", "")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = autoConditions.start(ctx)
+	}()
+
+	expositionEventually := func(expected string) {
+		t.Helper()
+		var got string
+		err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+			value, _ := stores.Load(autoConditionsKey)
+			builtStores, _ := value.([]*StoreType)
+			buffer := &bytes.Buffer{}
+			if err := newMetricsWriter(builtStores...).writeStores(buffer); err != nil {
+				return false, err
+			}
+			got = buffer.String()
+
+			return got == expected, nil
+		})
+		if err != nil {
+			t.Fatalf("expected exposition %q, got %q: %v", expected, got, err)
+		}
+	}
+
+	// Only the conditions of the CRDs defining them are exposed, for the objects in the given namespace.
+	expositionEventually(`# HELP kube_customresource_foo_status_condition The status conditions of Foo objects.
+# TYPE kube_customresource_foo_status_condition gauge
+kube_customresource_foo_status_condition{condition="Ready",status="True",group="contoso.com",version="v1",kind="Foo"} 1
+`)
+
+	// Once the CRD goes away, so does its kind's store.
+	if err := client.Resource(crdGVKR.GroupVersionResource).Delete(ctx, withConditions.GetName(), metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expositionEventually("")
+
+	cancel()
+	<-done
+	if _, ok := stores.Load(autoConditionsKey); ok {
+		t.Error("expected the stores to be dropped once stopped")
+	}
+}
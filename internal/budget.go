@@ -0,0 +1,122 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// errMemoryBudgetExceeded is returned for monitors whose stores are not built since the memory budget is exceeded.
+var errMemoryBudgetExceeded = errors.New("memory budget exceeded")
+
+// setMetrics stores the given series for the given object, accounting for their size, along with the ones of the
+// objects evicted by the storage to make room for them, if any, and records the change, and the series it removed, for
+// deltas. The caller must
+// hold the store's lock.
+func (s *StoreType) setMetrics(uid types.UID, metrics []string) {
+	previous, _ := s.metrics.get(uid)
+	s.size += seriesSize(metrics) - seriesSize(previous)
+	for evictedUID, evicted := range s.metrics.set(uid, s.namespaces[uid], metrics) {
+		s.size -= seriesSize(evicted)
+		s.deleted(evictedUID, evicted)
+		s.forget(evictedUID)
+		delete(s.namespaces, evictedUID)
+		delete(s.observed, evictedUID)
+		delete(s.tombstones, evictedUID)
+	}
+	// Series the object no longer has are reported as deleted in deltas.
+	s.removed(removedSeries(previous, metrics))
+	s.changed(uid)
+}
+
+// deleteMetrics drops the series of the given object, accounting for their size, and records their deletion for
+// deltas, along with the object, if cached for scrape time rendering, and its cached resolutions. The caller must
+// hold the store's lock.
+func (s *StoreType) deleteMetrics(uid types.UID) {
+	previous, _ := s.metrics.get(uid)
+	s.size -= seriesSize(previous)
+	s.metrics.remove(uid)
+	s.deleted(uid, previous)
+	s.forget(uid)
+}
+
+// forget drops the given object, if cached for scrape time rendering, and its cached resolutions. The caller must hold
+// the store's lock.
+func (s *StoreType) forget(uid types.UID) {
+	delete(s.objects, uid)
+	delete(s.restored, uid)
+	delete(s.digests, uid)
+	delete(s.clusters, uid)
+	delete(s.names, uid)
+	for _, family := range s.Families {
+		family.resolutions.forget(uid)
+	}
+}
+
+// estimatedSize returns the estimated memory held by the store's series, including the ones of its selected stores,
+// in bytes.
+func (s *StoreType) estimatedSize() int64 {
+	s.mutex.RLock()
+	size := s.size
+	selected := s.selectedStores()
+	s.mutex.RUnlock()
+	for _, selectedStore := range selected {
+		size += selectedStore.estimatedSize()
+	}
+
+	return size
+}
+
+// seriesSize returns the size of the given rendered series, in bytes.
+func seriesSize(metrics []string) int64 {
+	var size int64
+	for _, metric := range metrics {
+		size += int64(len(metric))
+	}
+
+	return size
+}
+
+// memoryBudget bounds the estimated memory held by the series of all stores, across all monitors.
+type memoryBudget struct {
+	stores *sync.Map
+	// limit is the budget, in bytes, or 0 if unbounded.
+	limit int64
+}
+
+// usage returns the estimated memory held by the series of all stores, in bytes.
+func (b *memoryBudget) usage() int64 {
+	var usage int64
+	b.stores.Range(func(_, value any) bool {
+		builtStores, _ := value.([]*StoreType)
+		for _, s := range builtStores {
+			usage += s.estimatedSize()
+		}
+
+		return true
+	})
+
+	return usage
+}
+
+// exceeded reports whether the estimated memory held by the series of all stores exceeds the budget, if any.
+func (b *memoryBudget) exceeded() bool {
+	return b != nil && b.limit > 0 && b.usage() > b.limit
+}
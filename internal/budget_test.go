@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	t.Parallel()
+	s := newStore(klog.Background(), []string{"# HELP kube_customresource_replicas"}, []*FamilyType{
+		{
+			Name:    "replicas",
+			Metrics: []*MetricType{{Value: "spec.replicas"}},
+		},
+	}, ResolverTypeUnstructured, nil, nil, 0, 0)
+	stores := &sync.Map{}
+	stores.Store("default/foo", []*StoreType{s})
+	budget := &memoryBudget{stores: stores, limit: 1}
+	if budget.exceeded() {
+		t.Error("expected the budget not to be exceeded with no series")
+	}
+
+	object := newSyntheticObjects(1)[0]
+	if err := s.Add(object); err != nil {
+		t.Fatal(err)
+	}
+	if budget.usage() == 0 || !budget.exceeded() {
+		t.Errorf("expected the budget to be exceeded, got a usage of %d", budget.usage())
+	}
+
+	// Updates, tombstones, and deletions are accounted for.
+	if err := s.Update(object); err != nil {
+		t.Fatal(err)
+	}
+	added := budget.usage()
+	s.TombstoneRetention.Duration = time.Minute
+	if err := s.Delete(object); err != nil {
+		t.Fatal(err)
+	}
+	if got := budget.usage(); got <= added {
+		t.Errorf("expected tombstoned series to be larger than %d, got %d", added, got)
+	}
+	s.tombstones[object.GetUID()] = time.Now()
+	s.pruneTombstones()
+	if got := budget.usage(); got != 0 {
+		t.Errorf("expected a usage of 0, got %d", got)
+	}
+
+	// Budgets of 0 are never exceeded.
+	if err := s.Add(object); err != nil {
+		t.Fatal(err)
+	}
+	if (&memoryBudget{stores: stores}).exceeded() {
+		t.Error("expected an unbounded budget not to be exceeded")
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -38,13 +40,58 @@ type gvkr struct {
 	schema.GroupVersionResource
 }
 
-// buildStore builds a cache.store for the metrics store.
+// buildStore builds a cache.store for the metrics store, backed by a reflector watching the given namespace (or all
+// namespaces, if empty) in each of the given clusters. Reflectors targeting custom resources are deferred while their
+// CRDs are not established, as reported to the given establishment.
 func buildStore(
 	ctx context.Context,
-	dynamicClientset dynamic.Interface,
+	clientsets map[string]dynamic.Interface,
 	gvkWithR gvkr,
+	watchNamespace string,
+	metricFamilies []*FamilyType,
+	labelSelector, fieldSelector, filter string,
+	tombstoneRetention, ttl time.Duration,
+	listPageSize int64,
+	concurrency ConcurrencyType,
+	storage seriesStorage,
+	resolver ResolverType,
+	labelKeys, labelValues []string,
+	celCostLimit uint64,
+	celTimeout time.Duration,
+	celEvaluations *prometheus.CounterVec,
+	celVariables map[string]interface{},
+	namespace, name string,
+	establishment *establishment,
+) *StoreType {
+	s := newConfiguredStore(klog.FromContext(ctx), metricFamilies, resolver, labelKeys, labelValues, celCostLimit, celTimeout, celEvaluations, namespace, name)
+	s.filter = newStoreFilter(filter, newCELResolver(s.logger, celCostLimit, celTimeout, celEvaluations, celVariables, namespace, name))
+	s.TombstoneRetention = metav1.Duration{Duration: tombstoneRetention}
+	s.TTL = metav1.Duration{Duration: ttl}
+	s.listPageSize = listPageSize
+	s.Concurrency = concurrency
+	if storage != nil {
+		s.useStorage(storage)
+	}
+	s.Group, s.Version, s.Kind, s.Resource = gvkWithR.GroupVersionKind.Group, gvkWithR.GroupVersionKind.Version, gvkWithR.Kind, gvkWithR.Resource
+	for cluster, dynamicClientset := range clientsets {
+		newLW := func(gvr schema.GroupVersionResource) *cache.ListWatch {
+			return buildLW(ctx, dynamicClientset, watchNamespace, labelSelector, fieldSelector, gvr, s)
+		}
+		if isNativeGroup(gvkWithR.GroupVersionResource.Group) {
+			startReflector(ctx, newLW(gvkWithR.GroupVersionResource), gvkWithR, s.forCluster(cluster))
+
+			continue
+		}
+		startGatedReflector(ctx, cluster, dynamicClientset, newLW, gvkWithR, s, establishment)
+	}
+
+	return s
+}
+
+// newConfiguredStore returns a store ready to generate metrics for the given families, without any reflector backing it.
+func newConfiguredStore(
+	logger klog.Logger,
 	metricFamilies []*FamilyType,
-	labelSelector, fieldSelector string,
 	resolver ResolverType,
 	labelKeys, labelValues []string,
 	celCostLimit uint64,
@@ -52,22 +99,33 @@ func buildStore(
 	celEvaluations *prometheus.CounterVec,
 	namespace, name string,
 ) *StoreType {
-	logger := klog.FromContext(ctx)
-	listerwatcher := buildLW(ctx, dynamicClientset, labelSelector, fieldSelector, gvkWithR.GroupVersionResource)
 	headers := buildMetricHeaders(metricFamilies)
 	resolver = ensureResolver(resolver)
-	// Propagate CEL limits, metrics, and RMM identity to all families
-	for _, family := range metricFamilies {
-		family.celCostLimit = celCostLimit
-		family.celTimeout = celTimeout
-		family.celEvaluations = celEvaluations
-		family.managedRMMNamespace = namespace
-		family.managedRMMName = name
+	// Propagate CEL limits, metrics, and RMM identity to copies of the families, compiled by the store thereafter.
+	configuredFamilies := make([]*FamilyType, len(metricFamilies))
+	for i, family := range metricFamilies {
+		configuredFamily := *family
+		configuredFamily.celCostLimit = celCostLimit
+		configuredFamily.celTimeout = celTimeout
+		configuredFamily.celEvaluations = celEvaluations
+		configuredFamily.managedRMMNamespace = namespace
+		configuredFamily.managedRMMName = name
+		configuredFamilies[i] = &configuredFamily
 	}
-	s := newStore(logger, headers, metricFamilies, resolver, labelKeys, labelValues, celCostLimit, celTimeout)
-	startReflector(ctx, listerwatcher, gvkWithR, s)
 
-	return s
+	return newStore(logger, headers, configuredFamilies, resolver, labelKeys, labelValues, celCostLimit, celTimeout)
+}
+
+// newCELResolver returns the CEL resolver for expressions configured at the store level.
+func newCELResolver(
+	logger klog.Logger,
+	celCostLimit uint64,
+	celTimeout time.Duration,
+	celEvaluations *prometheus.CounterVec,
+	celVariables map[string]interface{},
+	namespace, name string,
+) *resolver.CELResolver {
+	return resolver.NewCELResolver(logger, celCostLimit, celTimeout, celEvaluations, namespace, name, "").WithVariables(celVariables)
 }
 
 func buildMetricHeaders(metricFamilies []*FamilyType) []string {
@@ -87,7 +145,7 @@ func ensureResolver(resolver ResolverType) ResolverType {
 	return resolver
 }
 
-func startReflector(ctx context.Context, lw *cache.ListWatch, gvkWithR gvkr, s *StoreType) {
+func startReflector(ctx context.Context, lw *cache.ListWatch, gvkWithR gvkr, s cache.Store) {
 	wrapper := &unstructured.Unstructured{}
 	wrapper.SetGroupVersionKind(gvkWithR.GroupVersionKind)
 
@@ -95,15 +153,17 @@ func startReflector(ctx context.Context, lw *cache.ListWatch, gvkWithR gvkr, s *
 		Name: fmt.Sprintf("%#q reflector", gvkWithR.GroupVersionResource.String()),
 	})
 
-	go reflector.Run(ctx.Done())
+	runGoroutine(s, func() { reflector.Run(ctx.Done()) })
 }
 
 func buildLW(
 	ctx context.Context,
 	dynamicClientset dynamic.Interface,
+	namespace string,
 	labelSelector string,
 	fieldSelector string,
 	gvr schema.GroupVersionResource,
+	s *StoreType,
 ) *cache.ListWatch {
 	lwo := metav1.ListOptions{
 		LabelSelector: labelSelector,
@@ -111,21 +171,59 @@ func buildLW(
 	}
 
 	return &cache.ListWatch{
-		ListFunc: func(_ metav1.ListOptions) (runtime.Object, error) {
-			o, err := dynamicClientset.Resource(gvr).List(ctx, lwo)
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			// Lists are chunked as requested by the reflector's pager, in pages of the store's size, if set.
+			list := func() (runtime.Object, error) {
+				listOptions := lwo
+				listOptions.Limit, listOptions.Continue = s.listLimit(options.Limit), options.Continue
+
+				return dynamicClientset.Resource(gvr).Namespace(namespace).List(ctx, listOptions)
+			}
+			o, err := list()
+			// Most custom resource fields are not selectable, in which case the field selector is applied client-side.
+			if apierrors.IsBadRequest(err) && lwo.FieldSelector != "" && s != nil && s.filter.fallBack(lwo.FieldSelector) {
+				klog.FromContext(ctx).V(1).Info("Field selector rejected, filtering client-side", "gvr", gvr.String(), "fieldSelector", lwo.FieldSelector, "reason", err.Error())
+				lwo.FieldSelector = ""
+				o, err = list()
+			}
 			if err != nil {
+				s.loseWatch()
+				s.recordWatchError(err)
 				err = fmt.Errorf("error listing %s with options %v: %w", gvr.String(), lwo, err)
 			}
 
 			return o, err
 		},
-		WatchFunc: func(_ metav1.ListOptions) (watch.Interface, error) {
-			o, err := dynamicClientset.Resource(gvr).Watch(ctx, lwo)
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			watchOptions := lwo
+			// Streamed lists are requested as watches sending the initial events, if the reflector uses them.
+			if options.SendInitialEvents != nil {
+				watchOptions.SendInitialEvents = options.SendInitialEvents
+				watchOptions.ResourceVersion = options.ResourceVersion
+				watchOptions.ResourceVersionMatch = options.ResourceVersionMatch
+				watchOptions.AllowWatchBookmarks = options.AllowWatchBookmarks
+				watchOptions.TimeoutSeconds = options.TimeoutSeconds
+			}
+			o, err := dynamicClientset.Resource(gvr).Namespace(namespace).Watch(ctx, watchOptions)
 			if err != nil {
-				err = fmt.Errorf("error watching %s with options %v: %w", gvr.String(), lwo, err)
+				s.loseWatch()
+				s.recordWatchError(err)
+
+				return o, fmt.Errorf("error watching %s with options %v: %w", gvr.String(), watchOptions, err)
 			}
 
-			return o, err
+			return bufferWatch(s.recordWatchErrors(o), s), nil
 		},
 	}
 }
+
+// listLimit returns the number of objects to request per page of the store's lists, given the one requested by the
+// reflector's pager, if any. Stores with no page size set list all objects at once, while watchers with no store defer
+// to the pager.
+func (s *StoreType) listLimit(limit int64) int64 {
+	if s == nil || limit == 0 {
+		return limit
+	}
+
+	return s.listPageSize
+}
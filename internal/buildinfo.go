@@ -0,0 +1,98 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	versioncollector "github.com/prometheus/client_golang/prometheus/collectors/version"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/version"
+	"k8s.io/klog/v2"
+)
+
+// versionPath is the main server's path to view the controller's build information on.
+const versionPath = "/version"
+
+// buildInfo is the controller's build information, as served on the versionPath.
+type buildInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Branch    string `json:"branch"`
+	BuildUser string `json:"buildUser"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	GoOS      string `json:"goOS"`
+	GoArch    string `json:"goArch"`
+	Tags      string `json:"tags"`
+}
+
+// newBuildInfo returns the build information the binary was stamped with.
+func newBuildInfo() buildInfo {
+	return buildInfo{
+		Version:   version.Version,
+		Revision:  version.GetRevision(),
+		Branch:    version.Branch,
+		BuildUser: version.BuildUser,
+		BuildDate: version.BuildDate,
+		GoVersion: version.GoVersion,
+		GoOS:      version.GoOS,
+		GoArch:    version.GoArch,
+		Tags:      version.GetTags(),
+	}
+}
+
+// versionHandler serves the controller's build information as JSON.
+func versionHandler(logger klog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(newBuildInfo()); err != nil {
+			logger.Error(err, "error writing build information")
+		}
+	}
+}
+
+// newBuildInfoGatherer returns a gatherer for the build_info metric alone, which the main server exposes along with the
+// resource metrics, for exposition changes to be correlated with the controller's versions without scraping the self
+// server. The same metric is registered in the telemetry registry as well.
+func newBuildInfoGatherer(namespace string) prometheus.Gatherer {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(versioncollector.NewCollector(namespace))
+
+	return registry
+}
+
+// writeBuildInfo writes out the metrics of the given build information gatherer in the given format.
+func writeBuildInfo(writer io.Writer, gatherer prometheus.Gatherer, format expfmt.Format) error {
+	metricFamilies, err := gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("error gathering build information: %w", err)
+	}
+	// The encoder is not closed, as OpenMetrics expositions are terminated once all stores have been written out.
+	encoder := expfmt.NewEncoder(writer, format)
+	for _, metricFamily := range metricFamilies {
+		if err = encoder.Encode(metricFamily); err != nil {
+			return fmt.Errorf("error writing build information: %w", err)
+		}
+	}
+
+	return nil
+}
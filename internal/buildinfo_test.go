@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/version"
+	"k8s.io/klog/v2"
+)
+
+func TestWriteBuildInfo(t *testing.T) {
+	t.Parallel()
+	buffer := &bytes.Buffer{}
+	if err := writeBuildInfo(buffer, newBuildInfoGatherer("resource_state_metrics"), expfmt.NewFormat(expfmt.TypeTextPlain)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{
+		"# TYPE resource_state_metrics_build_info gauge\n",
+		"resource_state_metrics_build_info{",
+		`version="` + version.Version + `"`,
+	} {
+		if !strings.Contains(buffer.String(), expected) {
+			t.Errorf("expected exposition to contain %q, got:\n%s", expected, buffer.String())
+		}
+	}
+}
+
+func TestVersionHandler(t *testing.T) {
+	t.Parallel()
+	recorder := httptest.NewRecorder()
+	versionHandler(klog.Background())(recorder, httptest.NewRequest(http.MethodGet, versionPath, http.NoBody))
+
+	if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected JSON content, got %q", got)
+	}
+	var got buildInfo
+	if err := json.NewDecoder(recorder.Body).Decode(&got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != newBuildInfo() {
+		t.Errorf("expected %+v, got %+v", newBuildInfo(), got)
+	}
+}
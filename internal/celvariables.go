@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+)
+
+// celEnvironment returns the given environment variables, by their (comma-separated) names, for CEL expressions to
+// read through env. Unset variables are left out.
+func celEnvironment(names []string) map[string]interface{} {
+	environment := map[string]interface{}{}
+	for _, name := range strings.Split(strings.Join(names, ","), ",") {
+		if value, ok := os.LookupEnv(name); ok && name != "" {
+			environment[name] = value
+		}
+	}
+
+	return environment
+}
+
+// celVariables returns the variables CEL expressions of the given resource's stores may read, besides the object (o),
+// and the evaluation's timestamp (now): the resource's metadata (rmm), and the allow-listed environment (env).
+func celVariables(resource *v1alpha1.ResourceMetricsMonitor, environment map[string]interface{}) map[string]interface{} {
+	if environment == nil {
+		environment = map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"rmm": map[string]interface{}{
+			"name":        resource.GetName(),
+			"namespace":   resource.GetNamespace(),
+			"uid":         string(resource.GetUID()),
+			"generation":  resource.GetGeneration(),
+			"labels":      stringMap(resource.GetLabels()),
+			"annotations": stringMap(resource.GetAnnotations()),
+		},
+		"env": environment,
+	}
+}
+
+// stringMap returns the given string map as a generic one, as CEL expects.
+func stringMap(m map[string]string) map[string]interface{} {
+	converted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		converted[k] = v
+	}
+
+	return converted
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Tests setting the process-wide environment cannot be run in t.Parallel().
+//
+//nolint:paralleltest
+func TestCELVariables(t *testing.T) {
+	t.Setenv("RSM_TEST_SHARD", "1")
+	t.Setenv("RSM_TEST_SECRET", "foo")
+	environment := celEnvironment([]string{"RSM_TEST_SHARD,RSM_TEST_UNSET"})
+	if diff := cmp.Diff(map[string]interface{}{"RSM_TEST_SHARD": "1"}, environment); diff != "" {
+		t.Errorf("unexpected environment (-want +got):\n%s", diff)
+	}
+
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{
+		Name:      "foo",
+		Namespace: "default",
+		Labels:    map[string]string{"shard": "a"},
+	}}
+	family := &FamilyType{
+		logger:       klog.Background(),
+		Name:         "shard",
+		Resolver:     ResolverTypeCEL,
+		celVariables: celVariables(resource, environment),
+		Metrics: []*MetricType{{
+			LabelKeys:   []string{"rmm", "shard"},
+			LabelValues: []string{"rmm.namespace + '/' + rmm.name", "rmm.labels.shard + env.RSM_TEST_SHARD"},
+			Value:       "has(env.RSM_TEST_SECRET) ? 0 : 1",
+		}},
+	}
+	object := newSyntheticObjects(1)[0]
+
+	expected := "kube_customresource_shard{rmm=\"default/foo\",shard=\"a1\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 1\n"
+	if diff := cmp.Diff(expected, family.buildMetricString(object)); diff != "" {
+		t.Errorf("unexpected exposition (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// certificateReloader serves the TLS certificate, and key, held in the given files, reloading them once either is
+// modified, e.g., as they are rotated by cert-manager, so that servers pick up rotated certificates without restarting.
+type certificateReloader struct {
+	logger            klog.Logger
+	certFile, keyFile string
+
+	mutex       sync.Mutex
+	certificate *tls.Certificate
+	// modified holds the modification times of the certificate, and key, files the certificate was loaded off.
+	modified [2]time.Time
+}
+
+// newCertificateReloader returns a new certificateReloader for the given files, failing if they cannot be loaded.
+func newCertificateReloader(logger klog.Logger, certFile, keyFile string) (*certificateReloader, error) {
+	r := &certificateReloader{logger: logger, certFile: certFile, keyFile: keyFile}
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. The certificate is reloaded if its files were modified since it
+// was last loaded. If that fails, e.g., as only one of them was rotated yet, the previously loaded one is served.
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	certificate, err := r.reload()
+	if err != nil {
+		r.logger.Error(err, "error reloading certificate, serving the previous one", "certFile", r.certFile, "keyFile", r.keyFile)
+
+		return r.certificate, nil
+	}
+
+	return certificate, nil
+}
+
+// reload loads the certificate off its files, unless they were not modified since it was last loaded. The caller must
+// hold the reloader's lock, unless it is not shared yet.
+func (r *certificateReloader) reload() (*tls.Certificate, error) {
+	var modified [2]time.Time
+	for i, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", file, err)
+		}
+		modified[i] = info.ModTime()
+	}
+	if r.certificate != nil && modified == r.modified {
+		return r.certificate, nil
+	}
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading certificate: %w", err)
+	}
+	r.certificate, r.modified = &certificate, modified
+
+	return r.certificate, nil
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+)
+
+func TestCertificateReloader(t *testing.T) {
+	t.Parallel()
+	directory := t.TempDir()
+	certFile, keyFile := filepath.Join(directory, "tls.crt"), filepath.Join(directory, "tls.key")
+	modified := time.Now()
+	write := func(certPEM, keyPEM []byte) {
+		t.Helper()
+		// Modification times are bumped explicitly, as files written in quick succession may share them.
+		modified = modified.Add(time.Second)
+		for file, data := range map[string][]byte{certFile: certPEM, keyFile: keyPEM} {
+			if err := os.WriteFile(file, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(file, modified, modified); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	rotate := func() []byte {
+		t.Helper()
+		certPEM, keyPEM, err := cert.GenerateSelfSignedCertKey("resource-state-metrics", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		write(certPEM, keyPEM)
+
+		return certPEM
+	}
+
+	if _, err := newCertificateReloader(klog.Background(), certFile, keyFile); err == nil {
+		t.Fatal("expected an error for missing files")
+	}
+	rotate()
+	reloader, err := newCertificateReloader(klog.Background(), certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := reloader.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := reloader.GetCertificate(nil); got != first {
+		t.Error("expected the certificate not to be reloaded while its files are not modified")
+	}
+
+	// Rotated certificates are picked up.
+	rotate()
+	second, err := reloader.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(second.Certificate[0], first.Certificate[0]) {
+		t.Error("expected the rotated certificate to be served")
+	}
+
+	// Certificates that fail to load keep the previous one served.
+	write([]byte("invalid"), []byte("invalid"))
+	third, err := reloader.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third != second {
+		t.Error("expected the previous certificate to be served")
+	}
+}
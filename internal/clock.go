@@ -0,0 +1,53 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"sync/atomic"
+	"time"
+
+	clockutil "k8s.io/utils/clock"
+)
+
+// clock is the clock stores' TTLs and tombstones, and the Processed condition's hold, are measured against, if set.
+// Like the controller's options, it is process-wide, and is only replaced to simulate the passage of time in tests.
+var clock atomic.Pointer[clockutil.PassiveClock]
+
+// SetClock sets the clock stores' TTLs and tombstones, and the Processed condition's hold, are measured against, e.g.,
+// to a fake one advanced by tests, or, if nil, resets it to the system's.
+func SetClock(c clockutil.PassiveClock) {
+	if c == nil {
+		clock.Store(nil)
+
+		return
+	}
+	clock.Store(&c)
+}
+
+// clockNow returns the current time, as told by the clock set, if any.
+func clockNow() time.Time {
+	if c := clock.Load(); c != nil {
+		return (*c).Now()
+	}
+
+	return time.Now()
+}
+
+// clockSince returns the time elapsed since the given one, as told by the clock set, if any.
+func clockSince(t time.Time) time.Duration {
+	return clockNow().Sub(t)
+}
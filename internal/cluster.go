@@ -0,0 +1,176 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"sync"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	typedv1alpha1 "github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned/typed/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// ClusterResourceMetricsMonitors share their schema with ResourceMetricsMonitors, and are handled as namespace-less
+// ResourceMetricsMonitors throughout the controller. They are only converted back when talking to the API server.
+
+// monitorInterface is the subset of the typed clients the event handlers need, satisfied by both the
+// ResourceMetricsMonitor client and clusterMonitors.
+type monitorInterface interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1alpha1.ResourceMetricsMonitor, error)
+	Update(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor, opts metav1.UpdateOptions) (*v1alpha1.ResourceMetricsMonitor, error)
+	UpdateStatus(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor, opts metav1.UpdateOptions) (*v1alpha1.ResourceMetricsMonitor, error)
+}
+
+// clusterMonitors adapts the ClusterResourceMetricsMonitor client to monitorInterface.
+type clusterMonitors struct {
+	client typedv1alpha1.ClusterResourceMetricsMonitorInterface
+}
+
+// Ensure clusterMonitors implements monitorInterface.
+var _ monitorInterface = clusterMonitors{}
+
+func (m clusterMonitors) Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1alpha1.ResourceMetricsMonitor, error) {
+	resource, err := m.client.Get(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClusterMonitor(resource), nil
+}
+
+func (m clusterMonitors) Update(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor, opts metav1.UpdateOptions) (*v1alpha1.ResourceMetricsMonitor, error) {
+	updated, err := m.client.Update(ctx, toClusterMonitor(resource), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClusterMonitor(updated), nil
+}
+
+func (m clusterMonitors) UpdateStatus(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor, opts metav1.UpdateOptions) (*v1alpha1.ResourceMetricsMonitor, error) {
+	updated, err := m.client.UpdateStatus(ctx, toClusterMonitor(resource), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromClusterMonitor(updated), nil
+}
+
+// monitors returns the client for the monitors in the given namespace, or for the cluster-scoped ones if empty. In
+// read-only mode, the client drops all updates.
+func (c *Controller) monitors(namespace string) monitorInterface {
+	var client monitorInterface = c.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors(namespace)
+	if namespace == metav1.NamespaceNone {
+		client = clusterMonitors{client: c.rsmClientset.ResourceStateMetricsV1alpha1().ClusterResourceMetricsMonitors()}
+	}
+	if c.readOnly() {
+		return readOnlyMonitors{monitorInterface: client, conditions: c.monitorConditions, logger: klog.Background()}
+	}
+
+	return client
+}
+
+// isClusterMonitor reports whether the given resource stands in for a ClusterResourceMetricsMonitor.
+func isClusterMonitor(resource metav1.Object) bool {
+	return resource.GetNamespace() == metav1.NamespaceNone
+}
+
+// fromClusterMonitor returns the namespace-less ResourceMetricsMonitor standing in for the given
+// ClusterResourceMetricsMonitor.
+func fromClusterMonitor(resource *v1alpha1.ClusterResourceMetricsMonitor) *v1alpha1.ResourceMetricsMonitor {
+	resource = resource.DeepCopy()
+
+	return &v1alpha1.ResourceMetricsMonitor{
+		ObjectMeta: resource.ObjectMeta,
+		Spec:       resource.Spec,
+		Status:     resource.Status,
+	}
+}
+
+// toClusterMonitor returns the ClusterResourceMetricsMonitor the given namespace-less ResourceMetricsMonitor stands in
+// for.
+func toClusterMonitor(resource *v1alpha1.ResourceMetricsMonitor) *v1alpha1.ClusterResourceMetricsMonitor {
+	resource = resource.DeepCopy()
+
+	return &v1alpha1.ClusterResourceMetricsMonitor{
+		ObjectMeta: resource.ObjectMeta,
+		Spec:       resource.Spec,
+		Status:     resource.Status,
+	}
+}
+
+// asMonitor converts ClusterResourceMetricsMonitors received from the informers, leaving everything else as is.
+func asMonitor(obj interface{}) interface{} {
+	if resource, ok := obj.(*v1alpha1.ClusterResourceMetricsMonitor); ok {
+		return fromClusterMonitor(resource)
+	}
+
+	return obj
+}
+
+// clusterMonitorOverrides returns the namespaces ResourceMetricsMonitors target each GVR from. ResourceMetricsMonitors
+// take precedence over ClusterResourceMetricsMonitors targeting the same GVR in their own namespace, so the latter's
+// series for objects in those namespaces are left out.
+func clusterMonitorOverrides(stores *sync.Map) map[schema.GroupVersionResource]sets.Set[string] {
+	overrides := map[schema.GroupVersionResource]sets.Set[string]{}
+	stores.Range(func(key, value any) bool {
+		keyString, _ := key.(string)
+		objectName, err := cache.ParseObjectName(keyString)
+		if err != nil || objectName.Namespace == metav1.NamespaceNone {
+			return true
+		}
+		builtStores, _ := value.([]*StoreType)
+		for _, s := range builtStores {
+			s.mutex.RLock()
+			targets := append([]*StoreType{s}, s.selectedStores()...)
+			s.mutex.RUnlock()
+			for _, target := range targets {
+				// Stores selecting their targets by CRD labels have none of their own.
+				if target.Resource == "" {
+					continue
+				}
+				gvr := target.gvr()
+				if overrides[gvr] == nil {
+					overrides[gvr] = sets.New[string]()
+				}
+				overrides[gvr].Insert(objectName.Namespace)
+			}
+		}
+
+		return true
+	})
+
+	return overrides
+}
+
+// skipOverridden returns the filter leaving out the overridden series of the stores under the given key, or nil if
+// those do not belong to a ClusterResourceMetricsMonitor.
+func skipOverridden(key any, overrides map[schema.GroupVersionResource]sets.Set[string]) func(*StoreType, string) bool {
+	keyString, _ := key.(string)
+	if objectName, err := cache.ParseObjectName(keyString); err != nil || objectName.Namespace != metav1.NamespaceNone {
+		return nil
+	}
+
+	return func(s *StoreType, namespace string) bool {
+		return overrides[s.gvr()].Has(namespace)
+	}
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestController_monitors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	c := &Controller{rsmClientset: fake.NewSimpleClientset(
+		&v1alpha1.ClusterResourceMetricsMonitor{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Spec:       v1alpha1.ResourceMetricsMonitorSpec{Configuration: "stores: []"},
+		},
+	)}
+
+	resource, err := c.monitors(metav1.NamespaceNone).Get(ctx, "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isClusterMonitor(resource) || resource.Spec.Configuration != "stores: []" {
+		t.Fatalf("expected a namespace-less stand-in for the cluster-scoped monitor, got %v", resource)
+	}
+	resource.SetLabels(map[string]string{"foo": "bar"})
+	if _, err = c.monitors(metav1.NamespaceNone).Update(ctx, resource, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.rsmClientset.ResourceStateMetricsV1alpha1().ClusterResourceMetricsMonitors().Get(ctx, "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got.GetLabels(), map[string]string{"foo": "bar"}); diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+func TestSkipOverridden(t *testing.T) {
+	t.Parallel()
+	newStores := func(resource string) []*StoreType {
+		return []*StoreType{{
+			Group:    "apps",
+			Version:  "v1",
+			Resource: resource,
+			headers:  []string{"# HELP foo"},
+			metrics: memoryStorage{
+				"uid1": {"foo{namespace=\"bar\"} 1\n"},
+				"uid2": {"foo{namespace=\"baz\"} 1\n"},
+			},
+			namespaces: map[types.UID]string{"uid1": "bar", "uid2": "baz"},
+		}}
+	}
+	stores := &sync.Map{}
+	stores.Store("cluster", newStores("deployments"))
+	stores.Store("bar/namespaced", newStores("deployments"))
+	stores.Store("baz/namespaced", newStores("statefulsets"))
+	overrides := clusterMonitorOverrides(stores)
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{
+			// Only bar has a namespaced monitor targeting deployments.
+			key:      "cluster",
+			expected: "# HELP foo\nfoo{namespace=\"baz\"} 1\n",
+		},
+		{
+			key:      "bar/namespaced",
+			expected: "# HELP foo\nfoo{namespace=\"bar\"} 1\nfoo{namespace=\"baz\"} 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			t.Parallel()
+			value, _ := stores.Load(tt.key)
+			writer := newMetricsWriter(value.([]*StoreType)...)
+			writer.skip = skipOverridden(tt.key, overrides)
+			buffer := &bytes.Buffer{}
+			if err := writer.writeStores(buffer); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(sortSeries(buffer.String()), tt.expected); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"strings"
+)
+
+// Subcommand is an auxiliary command shipped with the binary, invoked as `resource-state-metrics <name> [args...]`.
+type Subcommand func(ctx context.Context, args []string) error
+
+// subcommands holds all known subcommands, keyed by name.
+var subcommands = map[string]Subcommand{
+	genGoldenSubcommandName: genGolden,
+	installSubcommandName:   install,
+	lintSubcommandName:      lint,
+	rbacSubcommandName:      rbac,
+	uninstallSubcommandName: uninstall,
+}
+
+// LookupSubcommand returns the subcommand registered under the given name, if any.
+func LookupSubcommand(name string) (Subcommand, bool) {
+	subcommand, ok := subcommands[name]
+
+	return subcommand, ok
+}
+
+// stringSliceFlag implements flag.Value for flags that may be repeated.
+type stringSliceFlag []string
+
+// String returns the flag values joined by commas.
+func (s *stringSliceFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+// Set appends the given value to the flag values.
+func (s *stringSliceFlag) Set(value string) error {
+	*s = append(*s, value)
+
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"slices"
+
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	"k8s.io/klog/v2"
+)
+
+// compileFamilies returns the given families compiled for a store, i.e., copies of them with the store's resolver and
+// labels inherited, their metrics' labels merged, their resolvers bound, and the store's logger set, once, on the
+// store's construction. The given families, shared by the stores built off the same configuration, are left as is,
+// and the compiled ones are never mutated while rendering, so objects may be rendered concurrently.
+func compileFamilies(logger klog.Logger, families []*FamilyType, resolverType ResolverType, labelKeys, labelValues []string) []*FamilyType {
+	compiled := make([]*FamilyType, len(families))
+	for i, family := range families {
+		compiled[i] = family.compile(logger, resolverType, labelKeys, labelValues)
+	}
+
+	return compiled
+}
+
+// compile returns a copy of the family compiled for a store with the given logger, resolver, and labels.
+func (f *FamilyType) compile(logger klog.Logger, resolverType ResolverType, labelKeys, labelValues []string) *FamilyType {
+	compiled := *f
+	compiled.logger = logger
+	if compiled.Resolver == ResolverTypeNone {
+		compiled.Resolver = resolverType
+	}
+	compiled.LabelKeys = slices.Concat(f.LabelKeys, labelKeys)
+	compiled.LabelValues = slices.Concat(f.LabelValues, labelValues)
+	compiled.Metrics = make([]*MetricType, 0, len(f.Metrics))
+	for _, metric := range f.Metrics {
+		if metric == nil {
+			continue
+		}
+		compiledMetric := *metric
+		compiledMetric.LabelKeys = slices.Concat(metric.LabelKeys, compiled.LabelKeys)
+		compiledMetric.LabelValues = slices.Concat(metric.LabelValues, compiled.LabelValues)
+		// Unknown resolvers are left unbound, and reported as the metric is rendered.
+		if boundResolver, err := compiled.resolver(metric.Resolver); err == nil {
+			compiledMetric.boundResolver = boundResolver
+		}
+		compiled.Metrics = append(compiled.Metrics, &compiledMetric)
+	}
+
+	return &compiled
+}
+
+// withLogger returns a copy of the (compiled) family, logging with the given logger.
+func (f *FamilyType) withLogger(logger klog.Logger) *FamilyType {
+	family := *f
+	family.logger = logger
+
+	return &family
+}
+
+// metricResolver returns the resolver the given metric of the family is resolved by, i.e., the one bound on
+// compilation, if any.
+func (f *FamilyType) metricResolver(metric *MetricType) (resolver.Resolver, error) {
+	if metric.boundResolver != nil {
+		return metric.boundResolver, nil
+	}
+
+	return f.resolver(metric.Resolver)
+}
@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	"k8s.io/klog/v2"
+)
+
+func TestCompileFamilies(t *testing.T) {
+	t.Parallel()
+	families := []*FamilyType{
+		{
+			Name:        "foo",
+			LabelKeys:   []string{"family"},
+			LabelValues: []string{"metadata.name"},
+			Metrics: []*MetricType{
+				{LabelKeys: []string{"metric"}, LabelValues: []string{"metadata.namespace"}, Value: "spec.replicas"},
+			},
+		},
+	}
+
+	// Stores built off the same configuration compile the same families.
+	var compiled []*FamilyType
+	for range 2 {
+		compiled = compileFamilies(klog.Background(), families, ResolverTypeCEL, []string{"store"}, []string{"metadata.uid"})
+	}
+
+	if got, want := compiled[0].LabelKeys, []string{"family", "store"}; !slices.Equal(got, want) {
+		t.Errorf("expected family label keys %v, got %v", want, got)
+	}
+	if got, want := compiled[0].Metrics[0].LabelKeys, []string{"metric", "family", "store"}; !slices.Equal(got, want) {
+		t.Errorf("expected metric label keys %v, got %v", want, got)
+	}
+	if compiled[0].Resolver != ResolverTypeCEL {
+		t.Errorf("expected the store's resolver to be inherited, got %q", compiled[0].Resolver)
+	}
+	if compiled[0].Metrics[0].boundResolver == nil {
+		t.Error("expected the metric's resolver to be bound")
+	}
+
+	// The configured families are left as is.
+	if families[0].Resolver != ResolverTypeNone || len(families[0].LabelKeys) != 1 || len(families[0].Metrics[0].LabelKeys) != 1 {
+		t.Errorf("expected the configured families to be left as is, got %+v", families[0])
+	}
+	if families[0].Metrics[0].boundResolver != nil {
+		t.Error("expected the configured metrics to be left unbound")
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// maxConcurrency is the maximum number of objects rendered, or shards rendered, at once per store.
+const maxConcurrency = 64
+
+// ConcurrencyType tunes how a store processes its events, for targets seeing hundreds of them per second. Stores
+// process their events one at a time, as delivered, by default.
+type ConcurrencyType struct {
+	// EventBuffer is the number of watch events buffered ahead of the store, absorbing bursts of them while it catches up.
+	EventBuffer int `yaml:"eventBuffer,omitempty"`
+	// MaxConcurrentAdds is the maximum number of objects rendered at once, i.e., across (re)listed objects, or clusters.
+	MaxConcurrentAdds int `yaml:"maxConcurrentAdds,omitempty"`
+	// RenderShards is the number of shards each object's families are split into, rendered concurrently.
+	RenderShards int `yaml:"renderShards,omitempty"`
+}
+
+// validate rejects negative or excessive knobs.
+func (c ConcurrencyType) validate() error {
+	if c.EventBuffer < 0 {
+		return errors.New("eventBuffer: must not be negative")
+	}
+	if c.MaxConcurrentAdds < 0 || c.MaxConcurrentAdds > maxConcurrency {
+		return fmt.Errorf("maxConcurrentAdds: must be between 0 and %d", maxConcurrency)
+	}
+	if c.RenderShards < 0 || c.RenderShards > maxConcurrency {
+		return fmt.Errorf("renderShards: must be between 0 and %d", maxConcurrency)
+	}
+
+	return nil
+}
+
+// eventBuffer returns the number of watch events to buffer ahead of the store. Watchers with no store buffer none.
+func (s *StoreType) eventBuffer() int {
+	if s == nil {
+		return 0
+	}
+
+	return s.Concurrency.EventBuffer
+}
+
+// bufferWatch returns the given watch, relaying its events through a buffer of the given store's size, if any, so the
+// connection is drained while the store is busy rendering. The relaying goroutine is attributed to the store.
+func bufferWatch(w watch.Interface, s *StoreType) watch.Interface {
+	size := s.eventBuffer()
+	if size <= 0 {
+		return w
+	}
+	events := make(chan watch.Event, size)
+	buffered := watch.NewProxyWatcher(events)
+	s.goroutine(func() {
+		defer close(events)
+		defer w.Stop()
+		for {
+			select {
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					return
+				}
+				select {
+				case events <- event:
+				case <-buffered.StopChan():
+					return
+				}
+			case <-buffered.StopChan():
+				return
+			}
+		}
+	})
+
+	return buffered
+}
+
+// forEachConcurrently calls fn for each of the given number of items, with at most the given number of them in flight.
+func forEachConcurrently(items, concurrency int, fn func(i int)) {
+	if concurrency <= 1 || items <= 1 {
+		for i := range items {
+			fn(i)
+		}
+
+		return
+	}
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i := range items {
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+)
+
+func TestStoreType_Replace_concurrency(t *testing.T) {
+	t.Parallel()
+	newTestStore := func(concurrency ConcurrencyType) *StoreType {
+		s := newStore(klog.Background(), nil, []*FamilyType{
+			{Name: "replicas", Metrics: []*MetricType{{Value: "spec.replicas"}}},
+			{Name: "info", Metrics: []*MetricType{{LabelKeys: []string{"name"}, LabelValues: []string{"metadata.name"}, Value: "1"}}},
+			{Name: "namespace", Metrics: []*MetricType{{LabelKeys: []string{"namespace"}, LabelValues: []string{"metadata.namespace"}, Value: "1"}}},
+		}, ResolverTypeUnstructured, nil, nil, 0, 0)
+		s.Concurrency = concurrency
+
+		return s
+	}
+	objects := newSyntheticObjects(100)
+	items := make([]interface{}, len(objects))
+	for i, object := range objects {
+		items[i] = object
+	}
+
+	sequential := newTestStore(ConcurrencyType{})
+	concurrent := newTestStore(ConcurrencyType{MaxConcurrentAdds: 8, RenderShards: 2})
+	for _, s := range []*StoreType{sequential, concurrent} {
+		if err := s.Replace(items, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if diff := cmp.Diff(sequential.metrics, concurrent.metrics); diff != "" {
+		t.Errorf("expected concurrent stores to render the same metrics as sequential ones (-sequential +concurrent):\n%s", diff)
+	}
+}
+
+func TestBufferWatch(t *testing.T) {
+	t.Parallel()
+	upstream := watch.NewFake()
+	s := &StoreType{Concurrency: ConcurrencyType{EventBuffer: 2}}
+	buffered := bufferWatch(upstream, s)
+	if goroutines := s.goroutines.Load(); goroutines != 1 {
+		t.Errorf("expected the relaying goroutine to be attributed to the store, got %d goroutines", goroutines)
+	}
+
+	// Events are drained off the upstream watch while the store is yet to consume them.
+	upstream.Add(newSyntheticObjects(1)[0])
+	upstream.Delete(newSyntheticObjects(1)[0])
+	for _, expected := range []watch.EventType{watch.Added, watch.Deleted} {
+		if event := <-buffered.ResultChan(); event.Type != expected {
+			t.Errorf("expected %q event, got %q", expected, event.Type)
+		}
+	}
+
+	buffered.Stop()
+	for range buffered.ResultChan() {
+	}
+	if !upstream.IsStopped() {
+		t.Error("expected the upstream watch to be stopped along with the buffered one")
+	}
+	if unbuffered := bufferWatch(upstream, &StoreType{}); unbuffered != upstream {
+		t.Error("expected no buffer to return the watch as is")
+	}
+}
@@ -19,13 +19,18 @@ package internal
 import (
 	"context"
 	"fmt"
+	"path"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	utiltrace "k8s.io/utils/trace"
 	"sigs.k8s.io/yaml"
 )
 
@@ -47,10 +52,32 @@ type configuration struct {
 type configurer struct {
 	configuration    configuration
 	dynamicClientset dynamic.Interface
-	resource         *v1alpha1.ResourceMetricsMonitor
-	celCostLimit     uint64
-	celTimeout       time.Duration
-	celEvaluations   *prometheus.CounterVec
+	// clusters holds the dynamic client-set for each federated cluster, if any, by the clusters' names.
+	clusters map[string]dynamic.Interface
+	resource *v1alpha1.ResourceMetricsMonitor
+	// watchNamespace is the namespace the stores are scoped to, or empty for all namespaces.
+	watchNamespace string
+	// establishment, if set, is reported the targets the stores are deferred on until their CRDs are established.
+	establishment *establishment
+	// exposition is the mode the stores' samples are rendered in.
+	exposition ExpositionMode
+	// fixedPointValues formats the stores' sample values in fixed-point notation.
+	fixedPointValues bool
+	// listPageSize is the number of objects the stores list per page, or 0 to list all objects at once.
+	listPageSize int64
+	// storage, if set, returns the storage the stores' series are held in, or in memory otherwise.
+	storage storageFactory
+	// celEnvironment holds the allow-listed environment variables CEL expressions may read through env.
+	celEnvironment map[string]interface{}
+	celCostLimit   uint64
+	celTimeout     time.Duration
+	celEvaluations *prometheus.CounterVec
+	// resolverCacheLookups counts the lookups of the stores' resolution caches by result, if set.
+	resolverCacheLookups *prometheus.CounterVec
+	// lastErrors, if set, holds the most recent errors resolving the families' expressions.
+	lastErrors *lastErrors
+	// droppedSamples counts the samples the families dropped by reason, if set.
+	droppedSamples *prometheus.CounterVec
 }
 
 // Ensure configurer implements configure.
@@ -67,44 +94,354 @@ func newConfigurer(dynamicClientset dynamic.Interface, resource *v1alpha1.Resour
 	}
 }
 
+// parseMonitor parses the configuration of the configurer's resource, along with its preset's, if any.
+func (c *configurer) parseMonitor() error {
+	raw, err := monitorConfiguration(c.resource.Spec)
+	if err != nil {
+		return err
+	}
+
+	return c.parse(raw)
+}
+
 // parse unmarshals the raw YAML configuration.
 func (c *configurer) parse(raw string) error {
 	if err := yaml.Unmarshal([]byte(raw), &c.configuration); err != nil {
 		return fmt.Errorf("error unmarshalling configuration: %w", err)
 	}
+	if err := c.configuration.validate(); err != nil {
+		return err
+	}
+	if err := c.configuration.validateFamilyNames(); err != nil {
+		return err
+	}
+	c.configuration.defaults()
+
+	return nil
+}
+
+// validate rejects empty (null) entries, which would otherwise be dereferenced further down the pipeline.
+func (c configuration) validate() error {
+	for i, store := range c.Stores {
+		if store == nil {
+			return fmt.Errorf("error validating configuration: stores[%d] is empty", i)
+		}
+		if err := store.Credentials.validate(); err != nil {
+			return fmt.Errorf("error validating configuration: stores[%d].credentials: %w", i, err)
+		}
+		if store.TombstoneRetention.Duration < 0 {
+			return fmt.Errorf("error validating configuration: stores[%d].tombstoneRetention: must not be negative", i)
+		}
+		if store.TTL.Duration < 0 {
+			return fmt.Errorf("error validating configuration: stores[%d].ttl: must not be negative", i)
+		}
+		if store.CEL.Timeout.Duration < 0 || store.CEL.Timeout.Duration > maxCELTimeout {
+			return fmt.Errorf("error validating configuration: stores[%d].cel.timeout: must be between 0 and %s", i, maxCELTimeout)
+		}
+		if err := store.Concurrency.validate(); err != nil {
+			return fmt.Errorf("error validating configuration: stores[%d].concurrency.%w", i, err)
+		}
+		for j, generator := range store.Generators {
+			generatorType, argument := parseGenerator(generator)
+			if generatorType == GeneratorTypeAgeSeconds && argument == "" {
+				return fmt.Errorf("error validating configuration: stores[%d].generators[%d]: %q requires a path, e.g., %s(metadata.creationTimestamp)", i, j, generator, GeneratorTypeAgeSeconds)
+			}
+			if generatorType != GeneratorTypeAgeSeconds && argument != "" {
+				return fmt.Errorf("error validating configuration: stores[%d].generators[%d]: %q takes no arguments", i, j, generator)
+			}
+			switch generatorType {
+			case GeneratorTypeScale:
+				if store.Selectors.CRD != "" {
+					return fmt.Errorf("error validating configuration: stores[%d].generators[%d]: %q cannot be used with a CRD selector", i, j, generator)
+				}
+			case GeneratorTypeObservedGeneration, GeneratorTypeAgeSeconds:
+			default:
+				return fmt.Errorf("error validating configuration: stores[%d].generators[%d]: unknown generator %q", i, j, generator)
+			}
+		}
+		if err := validateSources(store); err != nil {
+			return fmt.Errorf("error validating configuration: stores[%d].%w", i, err)
+		}
+		if err := validateThresholds(store); err != nil {
+			return fmt.Errorf("error validating configuration: stores[%d].%w", i, err)
+		}
+		for j, family := range store.Families {
+			if family == nil {
+				return fmt.Errorf("error validating configuration: stores[%d].families[%d] is empty", i, j)
+			}
+			if err := validateHelp(family.Help); err != nil {
+				return fmt.Errorf("error validating configuration: stores[%d].families[%d].help: %w", i, j, err)
+			}
+			switch family.Aggregate {
+			case AggregateTypeNone, AggregateTypeCount, AggregateTypeSum, AggregateTypeAvg:
+			default:
+				return fmt.Errorf("error validating configuration: stores[%d].families[%d].aggregate: unknown aggregation %q", i, j, family.Aggregate)
+			}
+			// Aggregated series are not generated for any single object.
+			if family.CustomMetric && family.Aggregate != AggregateTypeNone {
+				return fmt.Errorf("error validating configuration: stores[%d].families[%d].customMetric: aggregated families cannot be served on the custom metrics API", i, j)
+			}
+			switch family.NaNPolicy {
+			case NaNPolicyNone, NaNPolicyEmit, NaNPolicySkip:
+			default:
+				return fmt.Errorf("error validating configuration: stores[%d].families[%d].nanPolicy: unknown policy %q", i, j, family.NaNPolicy)
+			}
+			switch family.Evaluate {
+			case EvaluationPolicyNone, EvaluationPolicyOnEvent, EvaluationPolicyOnScrape:
+			default:
+				return fmt.Errorf("error validating configuration: stores[%d].families[%d].evaluate: unknown policy %q", i, j, family.Evaluate)
+			}
+			if family.MaxSeriesPerObject < 0 {
+				return fmt.Errorf("error validating configuration: stores[%d].families[%d].maxSeriesPerObject: must not be negative", i, j)
+			}
+			switch family.Type {
+			case FamilyKindNone, FamilyKindGauge:
+			case FamilyKindHistogram:
+				// Only summing histograms across objects preserves their semantics.
+				if family.Aggregate != AggregateTypeNone && family.Aggregate != AggregateTypeSum {
+					return fmt.Errorf("error validating configuration: stores[%d].families[%d].aggregate: histograms can only be summed", i, j)
+				}
+			default:
+				return fmt.Errorf("error validating configuration: stores[%d].families[%d].type: unknown type %q", i, j, family.Type)
+			}
+			for k, metric := range family.Metrics {
+				if metric == nil {
+					return fmt.Errorf("error validating configuration: stores[%d].families[%d].metrics[%d] is empty", i, j, k)
+				}
+				if err := metric.Histogram.validate(family.Type); err != nil {
+					return fmt.Errorf("error validating configuration: stores[%d].families[%d].metrics[%d]: %w", i, j, k, err)
+				}
+				if err := metric.validateLabelTemplates(); err != nil {
+					return fmt.Errorf("error validating configuration: stores[%d].families[%d].metrics[%d]: %w", i, j, k, err)
+				}
+				if err := metric.validateExpand(family.Type); err != nil {
+					return fmt.Errorf("error validating configuration: stores[%d].families[%d].metrics[%d].%w", i, j, k, err)
+				}
+				if err := metric.EachMap.validate(family.Type); err != nil {
+					return fmt.Errorf("error validating configuration: stores[%d].families[%d].metrics[%d].eachMap: %w", i, j, k, err)
+				}
+				if err := metric.Regex.validate(); err != nil {
+					return fmt.Errorf("error validating configuration: stores[%d].families[%d].metrics[%d].regex: %w", i, j, k, err)
+				}
+				if err := metric.Exemplar.validate(family, metric); err != nil {
+					return fmt.Errorf("error validating configuration: stores[%d].families[%d].metrics[%d].exemplar: %w", i, j, k, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateFamilyNames rejects families whose names do not make for valid metric names, as well as ones defined more
+// than once across the stores, since all of them share the same exposition. The linter reports these separately, along
+// with the families defined across monitors.
+func (c configuration) validateFamilyNames() error {
+	defined := map[string]string{}
+	for i, store := range c.Stores {
+		for j, family := range store.Families {
+			field := fmt.Sprintf("stores[%d].families[%d]", i, j)
+			if family.Name == "" {
+				return fmt.Errorf("error validating configuration: %s.name: name is required", field)
+			}
+			if name := kubeCustomResourcePrefix + family.Name; !model.IsValidLegacyMetricName(name) {
+				return fmt.Errorf("error validating configuration: %s.name: %q is not a valid metric name", field, name)
+			}
+			if previous, ok := defined[family.Name]; ok {
+				return fmt.Errorf("error validating configuration: %s.name: duplicate family name %q, previously defined at %s", field, family.Name, previous)
+			}
+			defined[family.Name] = field
+		}
+	}
 
 	return nil
 }
 
 // build constructs the metric stores from the parsed configuration.
 func (c *configurer) build(ctx context.Context, stores *sync.Map) {
+	// Scope the reflectors to the stores' lifetime, so they are stopped once the stores are dropped.
+	ctx, cancel := context.WithCancel(ctx)
+	stores.Store(storesKey(c.resource), c.buildStores(ctx, cancel))
+}
+
+func (c *configurer) buildStores(ctx context.Context, stop context.CancelFunc) []*StoreType {
 	builtStores := make([]*StoreType, 0, len(c.configuration.Stores))
-	for _, cfg := range c.configuration.Stores {
-		s := c.buildStoreFromConfig(ctx, cfg)
+	for i, cfg := range c.configuration.Stores {
+		s := c.buildStoreFromConfig(ctx, cfg, c.storageKey(i))
+		traceStep(ctx, "Built store", utiltrace.Field{Key: "index", Value: i})
+		s.stop = stop
+		s.Thresholds = cfg.Thresholds
+		if c.resource != nil {
+			s.monitorCreated = c.resource.GetCreationTimestamp().Time
+			s.tier = monitorTier(c.resource)
+		}
 		builtStores = append(builtStores, s)
 	}
-	stores.Store(c.resource.GetUID(), builtStores)
+
+	return builtStores
 }
 
-func (c *configurer) buildStoreFromConfig(ctx context.Context, cfg *StoreType) *StoreType {
+// buildStoreFromConfig builds the store for the given configuration, whose series are held in the storage named after
+// the given key, if any.
+func (c *configurer) buildStoreFromConfig(ctx context.Context, cfg *StoreType, storageKey string) *StoreType {
+	cfg.Families = append(cfg.Families, generateFamilies(ctx, c.dynamicClientset, cfg)...)
+	variables := celVariables(c.resource, c.celEnvironment)
+	celCostLimit, celTimeout := c.celLimits(cfg)
+	resolutions := newResolutionCache(c.resolverCacheLookups)
+	for _, family := range cfg.Families {
+		family.exposition = c.exposition
+		family.fixedPointValues = c.fixedPointValues
+		family.celVariables = variables
+		family.resolutions = resolutions
+		family.lastErrors = c.lastErrors
+		family.droppedSamples = c.droppedSamples
+	}
+	if cfg.Selectors.CRD != "" {
+		var storage storageFactory
+		if c.storage != nil {
+			storage = func(target string) seriesStorage { return c.storage(path.Join(storageKey, target)) }
+		}
+
+		return buildCRDSelectedStore(
+			ctx,
+			c.clientsets(cfg),
+			cfg.Selectors.CRD,
+			c.watchNamespace,
+			cfg.Families,
+			cfg.Selectors.Label, cfg.Selectors.Field, cfg.Selectors.Filter,
+			cfg.TombstoneRetention.Duration, cfg.TTL.Duration,
+			c.listPageSize,
+			cfg.Concurrency,
+			storage,
+			cfg.Resolver,
+			cfg.LabelKeys, cfg.LabelValues,
+			celCostLimit,
+			celTimeout,
+			c.celEvaluations,
+			variables,
+			c.resource.GetNamespace(),
+			c.resource.GetName(),
+		)
+	}
+	if len(cfg.Sources) > 0 {
+		var storage storageFactory
+		if c.storage != nil {
+			storage = func(target string) seriesStorage { return c.storage(path.Join(storageKey, target)) }
+		}
+
+		return buildMultiSourceStore(
+			ctx,
+			c.clientsets(cfg),
+			sourceGVKRs(cfg),
+			c.watchNamespace,
+			cfg.Families,
+			cfg.Selectors.Label, cfg.Selectors.Field, cfg.Selectors.Filter,
+			cfg.TombstoneRetention.Duration, cfg.TTL.Duration,
+			c.listPageSize,
+			cfg.Concurrency,
+			storage,
+			cfg.Resolver,
+			cfg.LabelKeys, cfg.LabelValues,
+			celCostLimit,
+			celTimeout,
+			c.celEvaluations,
+			variables,
+			c.resource.GetNamespace(),
+			c.resource.GetName(),
+		)
+	}
 	gvkWithR := buildGVKR(cfg)
+	var storage seriesStorage
+	if c.storage != nil {
+		storage = c.storage(storageKey)
+	}
 
 	return buildStore(
 		ctx,
-		c.dynamicClientset,
+		c.clientsets(cfg),
 		gvkWithR,
+		c.watchNamespace,
 		cfg.Families,
-		cfg.Selectors.Label, cfg.Selectors.Field,
+		cfg.Selectors.Label, cfg.Selectors.Field, cfg.Selectors.Filter,
+		cfg.TombstoneRetention.Duration, cfg.TTL.Duration,
+		c.listPageSize,
+		cfg.Concurrency,
+		storage,
 		cfg.Resolver,
 		cfg.LabelKeys, cfg.LabelValues,
-		c.celCostLimit,
-		c.celTimeout,
+		celCostLimit,
+		celTimeout,
 		c.celEvaluations,
+		variables,
 		c.resource.GetNamespace(),
 		c.resource.GetName(),
+		c.establishment,
 	)
 }
 
+// storageKey returns the key the series of the store of the given index are held under, unique across monitors.
+func (c *configurer) storageKey(i int) string {
+	if c.resource == nil {
+		return strconv.Itoa(i)
+	}
+
+	return path.Join(storesKey(c.resource), strconv.Itoa(i))
+}
+
+// celLimits returns the limits the given store's CEL expressions are evaluated within, i.e., the store's overrides,
+// if any, or the configurer's.
+func (c *configurer) celLimits(cfg *StoreType) (uint64, time.Duration) {
+	celCostLimit, celTimeout := c.celCostLimit, c.celTimeout
+	if cfg.CEL.CostLimit > 0 {
+		celCostLimit = cfg.CEL.CostLimit
+	}
+	if cfg.CEL.Timeout.Duration > 0 {
+		celTimeout = cfg.CEL.Timeout.Duration
+	}
+
+	return celCostLimit, celTimeout
+}
+
+// clientsets returns the dynamic client-sets of the clusters the given store is built for, by the clusters' names, or
+// the one built off the store's credentials, if any.
+func (c *configurer) clientsets(cfg *StoreType) map[string]dynamic.Interface {
+	if cfg.clientset != nil {
+		return map[string]dynamic.Interface{localCluster: cfg.clientset}
+	}
+	if len(c.clusters) > 0 {
+		return c.clusters
+	}
+
+	return map[string]dynamic.Interface{localCluster: c.dynamicClientset}
+}
+
+// dropStores removes the given resource's stores, and stops the reflectors backing them, returning the dropped stores.
+func dropStores(stores *sync.Map, resource *v1alpha1.ResourceMetricsMonitor) []*StoreType {
+	value, ok := stores.LoadAndDelete(storesKey(resource))
+	if !ok {
+		return nil
+	}
+	builtStores, ok := value.([]*StoreType)
+	if !ok {
+		return nil
+	}
+	for _, s := range builtStores {
+		if s.stop != nil {
+			s.stop()
+		}
+		// The dropped stores' series are reported as deleted in deltas, ahead of the ones of the rebuilt stores, if any.
+		dropped.retain(s)
+	}
+
+	return builtStores
+}
+
+// storesKey returns the key the given resource's stores are tracked under. Stores are keyed by the resource's name
+// rather than its UID, since the latter is no longer known once the resource has been deleted.
+func storesKey(resource *v1alpha1.ResourceMetricsMonitor) string {
+	return cache.MetaObjectToName(resource).String()
+}
+
 func buildGVKR(cfg *StoreType) gvkr {
 	return gvkr{
 		GroupVersionKind: schema.GroupVersionKind{
@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+)
+
+func FuzzConfigurer_parse(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"stores: []",
+		"stores: [null]",
+		`stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    labelKeys: ["name"]
+    labelValues: ["metadata.name"]
+    families:
+      - name: "bar_replicas"
+        help: "Replicas"
+        metrics:
+          - labelKeys: ["app"]
+            labelValues: ["metadata.labels.app"]
+            value: "spec.replicas"
+`,
+		`stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    resolver: "cel"
+    families:
+      - name: "bar_conditions"
+        help: "Conditions"
+        metrics:
+          - labelKeys: ["condition"]
+            labelValues: ["o.status.conditions"]
+            value: "size(o.status.conditions)"
+`,
+	} {
+		f.Add(seed)
+	}
+	objects := newSyntheticObjects(1)
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		c := newConfigurer(nil, nil, 0, 0, nil)
+		if err := c.parse(raw); err != nil {
+			return
+		}
+
+		// Any configuration that parses must also make it through generation and rendering.
+		rmm := &v1alpha1.ResourceMetricsMonitor{Spec: v1alpha1.ResourceMetricsMonitorSpec{Configuration: raw}}
+		if _, err := renderExposition(context.Background(), rmm, objects); err != nil {
+			t.Skip(err)
+		}
+	})
+}
+
+func TestConfigurer_celLimits(t *testing.T) {
+	t.Parallel()
+	c := newConfigurer(nil, &v1alpha1.ResourceMetricsMonitor{}, 100, time.Second, nil)
+	if err := c.parse(`stores:
+  - resource: "foos"
+  - resource: "bars"
+    cel:
+      costLimit: 1000
+      timeout: 10s
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range []struct {
+		costLimit uint64
+		timeout   time.Duration
+	}{
+		{costLimit: 100, timeout: time.Second},
+		{costLimit: 1000, timeout: 10 * time.Second},
+	} {
+		costLimit, timeout := c.celLimits(c.configuration.Stores[i])
+		if costLimit != expected.costLimit || timeout != expected.timeout {
+			t.Errorf("stores[%d]: expected limits of %d and %s, got %d and %s", i, expected.costLimit, expected.timeout, costLimit, timeout)
+		}
+	}
+
+	if err := c.parse(`stores: [{resource: "foos", cel: {timeout: 1h}}]`); err == nil {
+		t.Error("expected an error for a timeout beyond the maximum")
+	}
+}
+
+func TestConfigurer_parse_help(t *testing.T) {
+	t.Parallel()
+	c := newConfigurer(nil, nil, 0, 0, nil)
+	if err := c.parse(`stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    families:
+      - name: "bar_replicas"
+        metrics:
+          - value: "spec.replicas"
+      - name: "bar_info"
+        help: "Information"
+        metrics:
+          - value: "1"
+`); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []string{"Value of spec.replicas of contoso.com/v1alpha1 Bar objects.", "Information"} {
+		if got := c.configuration.Stores[0].Families[i].Help; got != expected {
+			t.Errorf("expected help %q, got %q", expected, got)
+		}
+	}
+
+	// Help text that would break the HELP header is rejected.
+	if err := newConfigurer(nil, nil, 0, 0, nil).parse(`stores:
+  - families:
+      - name: "bar_info"
+        help: "Information\nabout bars"
+`); err == nil {
+		t.Error("expected help text with control characters to be rejected")
+	}
+}
+
+func TestConfigurer_parse_familyNames(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		configuration string
+		expected      string
+	}{
+		{
+			name: "invalid name",
+			configuration: `stores:
+  - families:
+      - name: "bar-info"
+`,
+			expected: `error validating configuration: stores[0].families[0].name: "kube_customresource_bar-info" is not a valid metric name`,
+		},
+		{
+			name: "duplicate names across stores",
+			configuration: `stores:
+  - families:
+      - name: "bar_info"
+  - families:
+      - name: "baz_info"
+      - name: "bar_info"
+`,
+			expected: `error validating configuration: stores[1].families[1].name: duplicate family name "bar_info", previously defined at stores[0].families[0]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := newConfigurer(nil, nil, 0, 0, nil).parse(tt.configuration)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("expected error %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
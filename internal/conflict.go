@@ -0,0 +1,174 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// familyConflict is a family of a monitor's configuration conflicting with one of an older monitor.
+type familyConflict struct {
+	family  string
+	monitor string
+	reason  string
+}
+
+func (c familyConflict) String() string {
+	return fmt.Sprintf("family %q conflicts with the one of %s (%s)", c.family, c.monitor, c.reason)
+}
+
+// dropConflicts drops the families of the configuration, of the monitor with the given key, created at the given time,
+// that conflict with the families of older monitors' stores, i.e., the ones defining the same family with a different
+// HELP or TYPE, or with the same labels for the same target, as those make for an inconsistent exposition. Conflicts
+// with newer monitors are left for those to report, once requeued (see newerConflicting).
+func (c configuration) dropConflicts(stores *sync.Map, key string, created time.Time) []familyConflict {
+	var conflicts []familyConflict
+	stores.Range(func(otherKey, value any) bool {
+		otherKeyString, _ := otherKey.(string)
+		builtStores, _ := value.([]*StoreType)
+		if otherKeyString == key || len(builtStores) == 0 || !newer(created, key, builtStores[0].monitorCreated, otherKeyString) {
+			return true
+		}
+		for _, store := range c.Stores {
+			store.Families = slices.DeleteFunc(store.Families, func(family *FamilyType) bool {
+				for _, builtStore := range builtStores {
+					for _, builtFamily := range builtStore.Families {
+						if reason := conflictReason(store, family, builtStore, builtFamily); reason != "" {
+							conflicts = append(conflicts, familyConflict{family: family.Name, monitor: otherKeyString, reason: reason})
+
+							return true
+						}
+					}
+				}
+
+				return false
+			})
+		}
+
+		return true
+	})
+	slices.SortFunc(conflicts, func(a, b familyConflict) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	return conflicts
+}
+
+// newerConflicting returns the keys of the monitors, newer than the one with the given key, created at the given time,
+// whose built stores define families conflicting with the ones of the configuration, sorted. These were built before
+// the given monitor was, and are to be reconciled again for them to drop their conflicting families, so that the oldest
+// monitor's families are exposed regardless of the order monitors are reconciled in.
+func (c configuration) newerConflicting(stores *sync.Map, key string, created time.Time) []string {
+	var keys []string
+	stores.Range(func(otherKey, value any) bool {
+		otherKeyString, _ := otherKey.(string)
+		builtStores, _ := value.([]*StoreType)
+		if otherKeyString == key || len(builtStores) == 0 || !newer(builtStores[0].monitorCreated, otherKeyString, created, key) {
+			return true
+		}
+		for _, builtStore := range builtStores {
+			for _, builtFamily := range builtStore.Families {
+				for _, store := range c.Stores {
+					for _, family := range store.Families {
+						if conflictReason(builtStore, builtFamily, store, family) != "" {
+							keys = append(keys, otherKeyString)
+
+							return true
+						}
+					}
+				}
+			}
+		}
+
+		return true
+	})
+	slices.Sort(keys)
+
+	return keys
+}
+
+// newer reports whether the monitor with the given key, created at the given time, is newer than the other one. Monitors
+// created at the same time are ordered by their keys.
+func newer(created time.Time, key string, otherCreated time.Time, otherKey string) bool {
+	if !created.Equal(otherCreated) {
+		return created.After(otherCreated)
+	}
+
+	return key > otherKey
+}
+
+// conflictReason returns why the given family of the given store conflicts with the given built family of the given
+// built store, if it does.
+func conflictReason(store *StoreType, family *FamilyType, builtStore *StoreType, builtFamily *FamilyType) string {
+	if family.Name != builtFamily.Name {
+		return ""
+	}
+	if family.Help != builtFamily.Help {
+		return fmt.Sprintf("HELP %q differs from %q", family.Help, builtFamily.Help)
+	}
+	if exposedType(family.Type) != exposedType(builtFamily.Type) {
+		return fmt.Sprintf("TYPE %q differs from %q", exposedType(family.Type), exposedType(builtFamily.Type))
+	}
+	// Stores selecting their targets by CRD labels have none of their own to compare.
+	if store.Resource == "" || store.gvr() != builtStore.gvr() {
+		return ""
+	}
+	if labelKeys := familyLabelKeys(store, family); labelKeys.Equal(familyLabelKeys(builtStore, builtFamily)) {
+		return fmt.Sprintf("the same labels %v for %s", sets.List(labelKeys), store.gvr().String())
+	}
+
+	return ""
+}
+
+// exposedType returns the TYPE the given kind of family is exposed as.
+func exposedType(kind FamilyKind) FamilyKind {
+	if kind == FamilyKindNone {
+		return FamilyKindGauge
+	}
+
+	return kind
+}
+
+// familyLabelKeys returns the label keys the given family of the given store labels its series with, whether the family
+// is compiled or not.
+func familyLabelKeys(store *StoreType, family *FamilyType) sets.Set[string] {
+	labelKeys := sets.New(store.LabelKeys...).Insert(family.LabelKeys...)
+	for _, metric := range family.Metrics {
+		labelKeys.Insert(metric.LabelKeys...)
+	}
+
+	return labelKeys
+}
+
+// conflictMessage returns the message reporting the given conflicts, if any.
+func conflictMessage(conflicts []familyConflict) string {
+	if len(conflicts) == 0 {
+		return ""
+	}
+	messages := make([]string, len(conflicts))
+	for i, conflict := range conflicts {
+		messages[i] = conflict.String()
+	}
+
+	return "Dropped conflicting families: " + strings.Join(messages, "; ")
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/klog/v2"
+)
+
+func TestConfiguration_dropConflicts(t *testing.T) {
+	t.Parallel()
+	older := newStore(klog.Background(), nil, []*FamilyType{
+		{Name: "bar_info", Help: "Information", Metrics: []*MetricType{{LabelKeys: []string{"name"}, LabelValues: []string{"metadata.name"}, Value: "1"}}},
+		{Name: "bar_replicas", Help: "Replicas", Metrics: []*MetricType{{Value: "spec.replicas"}}},
+		{Name: "bar_phase", Help: "Phase", Metrics: []*MetricType{{Value: "1"}}},
+	}, ResolverTypeUnstructured, nil, nil, 0, 0)
+	older.Group, older.Version, older.Resource = "contoso.com", "v1alpha1", "bars"
+	older.monitorCreated = time.Now().Add(-time.Hour)
+	stores := &sync.Map{}
+	stores.Store("default/older", []*StoreType{older})
+
+	newConfiguration := func() configuration {
+		c := newConfigurer(nil, nil, 0, 0, nil)
+		if err := c.parse(`stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    families:
+      - name: "bar_info"
+        help: "Information"
+        metrics:
+          - labelKeys: ["name"]
+            labelValues: ["metadata.name"]
+            value: "1"
+      - name: "bar_replicas"
+        help: "Desired replicas"
+        metrics:
+          - value: "spec.replicas"
+      - name: "bar_phase"
+        help: "Phase"
+        metrics:
+          - labelKeys: ["phase"]
+            labelValues: ["status.phase"]
+            value: "1"
+`); err != nil {
+			t.Fatal(err)
+		}
+
+		return c.configuration
+	}
+
+	// Newer monitors drop the families conflicting with older ones.
+	c := newConfiguration()
+	conflicts := c.dropConflicts(stores, "default/newer", time.Now())
+	expected := "Dropped conflicting families: " +
+		`family "bar_info" conflicts with the one of default/older (the same labels [name] for contoso.com/v1alpha1, Resource=bars); ` +
+		`family "bar_replicas" conflicts with the one of default/older (HELP "Desired replicas" differs from "Replicas")`
+	if diff := cmp.Diff(conflictMessage(conflicts), expected); diff != "" {
+		t.Errorf("%s", diff)
+	}
+	if got := len(c.Stores[0].Families); got != 1 || c.Stores[0].Families[0].Name != "bar_phase" {
+		t.Errorf("expected only bar_phase to be kept, got %d families", got)
+	}
+
+	// Older monitors are left for newer ones to report.
+	c = newConfiguration()
+	if conflicts = c.dropConflicts(stores, "default/oldest", time.Now().Add(-2*time.Hour)); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts for an older monitor, got %v", conflicts)
+	}
+
+	// Newer monitors built before older ones are requeued once those are, so they drop their conflicting families.
+	c = newConfiguration()
+	if keys := c.newerConflicting(stores, "default/oldest", time.Now().Add(-2*time.Hour)); !cmp.Equal(keys, []string{"default/older"}) {
+		t.Errorf("expected default/older to be requeued, got %v", keys)
+	}
+	if keys := c.newerConflicting(stores, "default/newer", time.Now()); len(keys) != 0 {
+		t.Errorf("expected no older monitors to be requeued, got %v", keys)
+	}
+}
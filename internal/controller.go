@@ -18,11 +18,11 @@ package internal
 
 import (
 	"context"
+	"crypto/tls"
 	stderrors "errors"
 	"fmt"
-	"net"
+	"net/http"
 	"reflect"
-	"strconv"
 	"sync"
 	"time"
 
@@ -36,70 +36,146 @@ import (
 	clientset "github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned"
 	rsmscheme "github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/rexagod/resource-state-metrics/pkg/generated/informers/externalversions"
+	"go.etcd.io/bbolt"
 	"golang.org/x/time/rate"
-	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
-	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	utiltrace "k8s.io/utils/trace"
 )
 
+// errNamespaceNotWatched is returned for monitors outside the watched namespaces.
+var errNamespaceNotWatched = stderrors.New("namespace is not watched")
+
 type metrics struct {
 	requestDurationVec *prometheus.HistogramVec
 	resourcesMonitored *prometheus.GaugeVec
 	eventsProcessed    *prometheus.CounterVec
 	configParseErrors  *prometheus.CounterVec
 	celEvaluations     *prometheus.CounterVec
+	expositionValid    *prometheus.GaugeVec
+	// resolverCacheLookups counts the lookups of the stores' resolution caches by result.
+	resolverCacheLookups *prometheus.CounterVec
+	// monitorConditions reports the status of the monitors' conditions in read-only mode.
+	monitorConditions *prometheus.GaugeVec
+	// slowScrapes counts the main server's expositions rendered for longer than the slow scrape threshold.
+	slowScrapes *prometheus.CounterVec
+	// lastReconcile reports when each monitor was last reconciled successfully.
+	lastReconcile *prometheus.GaugeVec
+	// monitorStores reports the number of stores built for each monitor.
+	monitorStores *prometheus.GaugeVec
+	// droppedSamples counts the samples the monitors' families dropped, by reason.
+	droppedSamples *prometheus.CounterVec
+	// ksmCollisions reports the number of each monitor's families exposing metrics named alike kube-state-metrics' ones.
+	ksmCollisions *prometheus.GaugeVec
 }
 
 // Controller is the controller implementation for managed resources.
 type Controller struct {
-	kubeclientset      kubernetes.Interface
-	rsmClientset       clientset.Interface
-	dynamicClientset   dynamic.Interface
-	rsmInformerFactory informers.SharedInformerFactory
-	workqueue          workqueue.TypedRateLimitingInterface[[2]string]
-	recorder           record.EventRecorder
-	stores             sync.Map
-	options            *Options
+	kubeclientset    kubernetes.Interface
+	rsmClientset     clientset.Interface
+	dynamicClientset dynamic.Interface
+	// cfg is the client configuration the clientsets were built off, if any.
+	cfg *rest.Config
+	// clusterClientsets holds the dynamic client-set for each federated cluster, if any, by the clusters' names.
+	clusterClientsets map[string]dynamic.Interface
+	// rsmInformerFactories holds an informer factory per watched namespace, or a single one for all namespaces.
+	rsmInformerFactories map[string]informers.SharedInformerFactory
+	workqueue            workqueue.TypedRateLimitingInterface[[2]string]
+	recorder             events.EventRecorder
+	stores               sync.Map
+	options              *Options
+	// registry is the telemetry registry, exposed on the self server.
+	registry *prometheus.Registry
+	// listTransfers, if set, observes the size of the responses to the clients' list requests.
+	listTransfers *ListTransfers
+	// celEnvironment holds the allow-listed environment variables CEL expressions may read through env.
+	celEnvironment map[string]interface{}
+	// ksmMetricNames holds the names of kube-state-metrics' metrics the families' exposed ones may collide with.
+	ksmMetricNames sets.Set[string]
+	// budget bounds the estimated memory held by the series of all stores.
+	budget *memoryBudget
+	// startup holds the monitors observed through the informers' initial lists, until all of them have been.
+	startup startupQueue
+	// registrations holds the event handler registrations, to tell whether the initial lists have been observed.
+	registrations []cache.ResourceEventHandlerRegistration
+	// storage returns the storage the stores' series are held in.
+	storage storageFactory
+	// snapshot, if set, holds the stores' storages to write their series out on shutdown.
+	snapshot *snapshot
+	// crds caches the CRDs, for the monitors' queries to be validated against their targets' schemas on reconciles.
+	crds cache.SharedIndexInformer
+	// warmUp, if set, holds readiness until the monitors observed on startup, and their stores, have warmed up.
+	warmUp *warmUp
+	// lastErrors holds the most recent errors resolving each monitor's expressions, by the monitors' keys, to report
+	// them in their status.
+	lastErrors sync.Map
+	// summarized holds the keys of the monitors whose status reports a non-empty summary.
+	summarized sync.Map
 
 	metrics
 }
 
-// NewController returns a new controller instance.
-func NewController(ctx context.Context, options *Options, kubeClientset kubernetes.Interface, rsmClientset clientset.Interface, dynamicClientset dynamic.Interface) *Controller {
+// NewController returns a new controller instance. Stores are built for the objects in the given federated clusters,
+// if any, instead of in the cluster the controller connects to. The given list transfers, if any, are exposed on the
+// telemetry server.
+func NewController(ctx context.Context, options *Options, cfg *rest.Config, kubeClientset kubernetes.Interface, rsmClientset clientset.Interface, dynamicClientset dynamic.Interface, clusterClientsets map[string]dynamic.Interface, listTransfers *ListTransfers) *Controller {
 	logger := klog.FromContext(ctx)
 	utilruntime.Must(rsmscheme.AddToScheme(scheme.Scheme))
 
-	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster := events.NewBroadcaster(newEventSink(kubeClientset, ptr.Deref(options.EventNamespace, "")))
 	eventBroadcaster.StartStructuredLogging(0)
-	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
-		Interface: kubeClientset.CoreV1().Events(metav1.NamespaceNone),
-	})
-	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: version.ControllerName.String()})
+	// Events are only logged in read-only mode, or if recording them is disabled.
+	if !ptr.Deref(options.ReadOnly, false) && ptr.Deref(options.RecordEvents, true) {
+		if err := eventBroadcaster.StartRecordingToSinkWithContext(ctx); err != nil {
+			logger.Error(err, "error starting to record events")
+		}
+	}
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, version.ControllerName.String())
 
 	ratelimiter := workqueue.NewTypedMaxOfRateLimiter(
 		workqueue.NewTypedItemExponentialFailureRateLimiter[[2]string](5*time.Millisecond, 5*time.Minute),
 		&workqueue.TypedBucketRateLimiter[[2]string]{Limiter: rate.NewLimiter(rate.Limit(50), 300)},
 	)
 
+	// The telemetry registry is set up ahead of the workqueue, so the latter's metrics are registered with it.
+	registry := prometheus.NewRegistry()
+
 	controller := &Controller{
-		kubeclientset:      kubeClientset,
-		rsmClientset:       rsmClientset,
-		dynamicClientset:   dynamicClientset,
-		rsmInformerFactory: informers.NewSharedInformerFactory(rsmClientset, 0),
-		workqueue:          workqueue.NewTypedRateLimitingQueue[[2]string](ratelimiter),
-		recorder:           recorder,
-		options:            options,
+		kubeclientset:        kubeClientset,
+		rsmClientset:         rsmClientset,
+		dynamicClientset:     dynamicClientset,
+		cfg:                  cfg,
+		clusterClientsets:    clusterClientsets,
+		rsmInformerFactories: newRSMInformerFactories(rsmClientset, options.WatchNamespaces),
+		workqueue: workqueue.NewTypedRateLimitingQueueWithConfig[[2]string](ratelimiter, workqueue.TypedRateLimitingQueueConfig[[2]string]{
+			Name:            version.ControllerName.ToSnakeCase(),
+			MetricsProvider: newWorkqueueMetricsProvider(registry),
+		}),
+		recorder:      recorder,
+		options:       options,
+		registry:      registry,
+		listTransfers: listTransfers,
+	}
+	controller.budget = &memoryBudget{stores: &controller.stores, limit: ptr.Deref(options.MemoryBudget, 0)}
+	controller.celEnvironment = celEnvironment(ptr.Deref(options.CELEnvironment, nil))
+	ksmMetricNames, err := loadKSMMetricNames(ptr.Deref(options.KSMCRSConfig, ""))
+	if err != nil {
+		// The configuration was validated along with the options, so this is only logged.
+		logger.Error(err, "error loading kube-state-metrics' configuration, collisions are not checked for")
 	}
+	controller.ksmMetricNames = ksmMetricNames
 
 	controller.registerEventHandlers(logger)
 
@@ -113,14 +189,84 @@ func (c *Controller) Run(ctx context.Context, workers int) error {
 
 	logger := klog.FromContext(ctx)
 	logger.V(1).Info("Starting controller")
+
+	// The disk storage is opened ahead of the stores being built, and held until the controller stops.
+	storageType := StorageType(ptr.Deref(c.options.Storage, string(StorageTypeMemory)))
+	var db *bbolt.DB
+	if storageType == StorageTypeDisk {
+		var err error
+		db, err = openDiskStorage(ptr.Deref(c.options.StoragePath, ""))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := db.Close(); err != nil {
+				logger.Error(err, "error closing the disk storage")
+			}
+		}()
+	}
+	c.storage = newStorageFactory(logger, storageType, ptr.Deref(c.options.StorageMaxObjects, 0), db)
+
+	// Snapshots are redundant with the disk storage, which holds the series across restarts as is.
+	if directory := ptr.Deref(c.options.SnapshotPath, ""); directory != "" && storageType != StorageTypeDisk {
+		var err error
+		c.snapshot, err = readSnapshot(logger, directory)
+		if err != nil {
+			logger.Error(err, "error restoring snapshot, starting without it")
+		}
+		c.storage = c.snapshot.wrap(c.storage)
+	}
+
+	// The CRDs are installed ahead of the informers listing their resources.
+	if ptr.Deref(c.options.InstallCRDs, false) {
+		if ptr.Deref(c.options.ReadOnly, false) {
+			logger.Info("Not installing CRDs in read-only mode")
+		} else if err := installCRDs(ctx, c.dynamicClientset); err != nil {
+			return fmt.Errorf("failed to install CRDs: %w", err)
+		}
+	}
+
 	logger.V(4).Info("Waiting for informer caches to sync")
 
-	c.rsmInformerFactory.Start(ctx.Done())
-	if ok := cache.WaitForCacheSync(ctx.Done(), c.rsmInformerFactory.ResourceStateMetrics().V1alpha1().ResourceMetricsMonitors().Informer().HasSynced); !ok {
-		return stderrors.New("failed to wait for caches to sync")
+	if c.dynamicClientset != nil {
+		c.crds = cache.NewSharedIndexInformer(
+			buildLW(ctx, c.dynamicClientset, metav1.NamespaceAll, "", "", crdGVKR.GroupVersionResource, nil),
+			&unstructured.Unstructured{}, 0, cache.Indexers{},
+		)
+		go c.crds.Run(ctx.Done())
+		if !cache.WaitForCacheSync(ctx.Done(), c.crds.HasSynced) {
+			return stderrors.New("failed to wait for CRD cache to sync")
+		}
 	}
 
-	registry := prometheus.NewRegistry()
+	for _, factory := range c.rsmInformerFactories {
+		factory.Start(ctx.Done())
+		for informerType, ok := range factory.WaitForCacheSync(ctx.Done()) {
+			if !ok {
+				return fmt.Errorf("failed to wait for %v caches to sync", informerType)
+			}
+		}
+	}
+
+	// Build the stores of critical monitors first, once all monitors have been observed.
+	registrationsSynced := make([]cache.InformerSynced, 0, len(c.registrations))
+	for _, registration := range c.registrations {
+		registrationsSynced = append(registrationsSynced, registration.HasSynced)
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), registrationsSynced...) {
+		return stderrors.New("failed to wait for event handlers to sync")
+	}
+	if maxWait := ptr.Deref(c.options.WarmUpMaxWait, 0); maxWait > 0 {
+		c.warmUp = newWarmUp(&c.stores, time.Duration(maxWait)*time.Second)
+	}
+	c.startup.release(func(obj interface{}) {
+		if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil && c.warmUp != nil {
+			c.warmUp.expect(key)
+		}
+		c.enqueue(obj, addEvent)
+	})
+
+	registry := c.registry
 	registry.MustRegister(
 		versioncollector.NewCollector(version.ControllerName.ToSnakeCase()),
 		collectors.NewGoCollector(),
@@ -141,6 +287,18 @@ func (c *Controller) Run(ctx context.Context, workers int) error {
 		Help:      "Information about ResourceMetricsMonitor resources currently being monitored.",
 	}, []string{"namespace", "name"})
 
+	c.lastReconcile = promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "monitor_last_reconcile_timestamp_seconds",
+		Help:      "Unix timestamp of the last successful reconciliation of ResourceMetricsMonitor resources.",
+	}, []string{"namespace", "name"})
+
+	c.monitorStores = promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "monitor_stores",
+		Help:      "Number of stores built for ResourceMetricsMonitor resources.",
+	}, []string{"namespace", "name"})
+
 	c.eventsProcessed = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
 		Namespace: namespace,
 		Name:      "events_processed_total",
@@ -159,55 +317,315 @@ func (c *Controller) Run(ctx context.Context, workers int) error {
 		Help:      "Total number of CEL expression evaluations by result.",
 	}, []string{"namespace", "name", "family", "result"})
 
-	selfAddr := net.JoinHostPort(*c.options.SelfHost, strconv.Itoa(*c.options.SelfPort))
-	mainAddr := net.JoinHostPort(*c.options.MainHost, strconv.Itoa(*c.options.MainPort))
+	c.resolverCacheLookups = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "resolver_cache_lookups_total",
+		Help:      "Total number of lookups of the stores' resolution caches by result, i.e., hit or miss.",
+	}, []string{"result"})
 
-	self := newSelfServer(selfAddr).build(ctx, c.kubeclientset, registry)
-	main := newMainServer(mainAddr, *c.options.Kubeconfig, &c.stores, c.requestDurationVec).build(ctx, c.kubeclientset, registry)
+	c.droppedSamples = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dropped_samples_total",
+		Help:      "Total number of samples dropped by ResourceMetricsMonitors' families, by reason, e.g., unresolved, or invalid, values.",
+	}, []string{"namespace", "name", "family", "reason"})
 
-	logger.V(1).Info("Starting workers")
-	for range workers {
-		go wait.UntilWithContext(ctx, func(ctx context.Context) {
-			for c.processNextWorkItem(ctx) {
-			}
-		}, time.Second)
+	c.ksmCollisions = promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ksm_collisions",
+		Help:      "Number of a ResourceMetricsMonitor's families exposing metrics named alike kube-state-metrics' ones (see --ksm-custom-resource-state-config), renamed or not as per --ksm-collision-prefix.",
+	}, []string{"namespace", "name"})
+
+	c.expositionValid = promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "exposition_valid",
+		Help:      "Whether a ResourceMetricsMonitor's exposition was parseable, as of the last check.",
+	}, []string{"namespace", "name"})
+
+	c.slowScrapes = promauto.With(registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "slow_scrapes_total",
+		Help:      "Total number of the main server's expositions rendered for longer than the slow scrape threshold, by the path they were served on.",
+	}, []string{"path"})
+
+	c.monitorConditions = promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "monitor_condition",
+		Help:      "Status of ResourceMetricsMonitors' conditions in read-only mode, where they are not written to the monitors, 1 if True, 0 otherwise.",
+	}, []string{"namespace", "name", "type"})
+
+	registry.MustRegister(newStoresCollector(&c.stores, namespace))
+	registerRuntimeMetrics(registry, namespace)
+	if c.listTransfers != nil {
+		registry.MustRegister(c.listTransfers.size)
+	}
+
+	promauto.With(registry).NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "memory_budget_exceeded",
+		Help:      "Whether the estimated memory held by the series of all stores exceeds the memory budget, if any.",
+	}, func() float64 {
+		if c.budget.exceeded() {
+			return 1
+		}
+
+		return 0
+	})
+
+	promauto.With(registry).NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stores_synced",
+		Help:      "Number of stores, across all ResourceMetricsMonitors, that have processed their initial lists.",
+	}, func() float64 {
+		synced, _ := storesSynced(&c.stores)
+
+		return float64(synced)
+	})
+	promauto.With(registry).NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stores_total",
+		Help:      "Number of stores across all ResourceMetricsMonitors.",
+	}, func() float64 {
+		_, total := storesSynced(&c.stores)
+
+		return float64(total)
+	})
+
+	authorizer, err := newScrapeAuthorizer(ptr.Deref(c.options.ScrapeAllowedCIDRs, nil), ptr.Deref(c.options.ScrapeBearerTokenFile, ""))
+	if err != nil {
+		return fmt.Errorf("error setting up scrape authorization: %w", err)
+	}
+	selfListeners, err := listen(listenHosts(c.options.SelfHosts), *c.options.SelfPort)
+	if err != nil {
+		return fmt.Errorf("error listening for the telemetry server: %w", err)
 	}
+	mainListeners, err := listen(listenHosts(c.options.MainHosts), *c.options.MainPort)
+	if err != nil {
+		closeListeners(selfListeners)
+
+		return fmt.Errorf("error listening for the main server: %w", err)
+	}
+	selfAddr := selfListeners[0].Addr().String()
+	mainAddr := mainListeners[0].Addr().String()
 
-	go func() {
-		logger.V(1).Info("Starting telemetry server on", "address", selfAddr)
-		if err := self.ListenAndServe(); err != nil {
-			logger.Error(err, "stopping telemetry server")
+	mgr := newManager(logger)
+	if ptr.Deref(c.options.LeaderElection, false) {
+		mgr.leaderElection, err = newLeaderElection(c.kubeclientset, ptr.Deref(c.options.LeaderElectionNamespace, ""))
+		if err != nil {
+			closeListeners(selfListeners)
+			closeListeners(mainListeners)
+
+			return err
 		}
-	}()
-	go func() {
-		logger.V(1).Info("Starting main server on", "address", mainAddr)
-		if err := main.ListenAndServe(); err != nil {
-			logger.Error(err, "stopping main server")
+	}
+	if interval := *c.options.ExpositionCheck; interval > 0 {
+		check := newExpositionCheck(&c.stores, c.expositionValid)
+		mgr.addReadyzCheck(check.ready)
+		mgr.add("exposition check", every(check.run, time.Duration(interval)*time.Second))
+	}
+	var warmUpGates []func() error
+	if c.warmUp != nil {
+		warmUpGates = append(warmUpGates, c.warmUp.ready)
+		mgr.addReadyzCheck(c.warmUp.ready)
+		// Replicas not holding the lease have no monitors to warm up, and only do once they acquire it.
+		if mgr.leaderElection != nil {
+			c.warmUp.standBy()
+			mgr.addLeaderElected("warm up", runnableFunc(func(ctx context.Context) error {
+				c.warmUp.lead()
+				<-ctx.Done()
+
+				return nil
+			}))
 		}
-	}()
+	}
+
+	selfServer := newSelfServer(selfAddr, c.options, mgr.readyzChecks...)
+	selfServer.authorizer = authorizer
+	self := selfServer.build(ctx, c.kubeclientset, registry)
+	resourceServer := newMainServer(mainAddr, c.cfg, &c.stores, c.requestDurationVec, warmUpGates...)
+	resourceServer.accessLog = ptr.Deref(c.options.AccessLog, false)
+	resourceServer.authorizer = authorizer
+	resourceServer.slowScrapes = &slowScrapes{
+		threshold: time.Duration(ptr.Deref(c.options.SlowScrapeThreshold, 0) * float64(time.Second)),
+		total:     c.slowScrapes,
+	}
+	main := resourceServer.build(ctx, c.kubeclientset, registry)
+
+	if interval := ptr.Deref(c.options.ThresholdCheck, 0); interval > 0 {
+		check := newThresholdCheck(logger, &c.stores, c.recorder, c.getMonitor, ptr.Deref(c.options.ThresholdWebhooks, false))
+		mgr.addLeaderElected("threshold check", every(check.run, time.Duration(interval)*time.Second))
+	}
+	if port := ptr.Deref(c.options.CustomMetricsPort, 0); port > 0 {
+		customMetricsListeners, err := listen(listenHosts(c.options.MainHosts), port)
+		if err != nil {
+			closeListeners(selfListeners)
+			closeListeners(mainListeners)
+
+			return fmt.Errorf("error listening for the custom metrics server: %w", err)
+		}
+		customMetrics, err := newCustomMetricsServer(
+			customMetricsListeners[0].Addr().String(),
+			&c.stores,
+			c.kubeclientset,
+			ptr.Deref(c.options.CustomMetricsTLSCert, ""), ptr.Deref(c.options.CustomMetricsTLSKey, ""),
+		).build(ctx, logger)
+		if err != nil {
+			closeListeners(selfListeners)
+			closeListeners(mainListeners)
+			closeListeners(customMetricsListeners)
+
+			return err
+		}
+		// The aggregator only proxies to APIServices over TLS.
+		for i, listener := range customMetricsListeners {
+			customMetricsListeners[i] = tls.NewListener(listener, customMetrics.TLSConfig)
+		}
+		mgr.add("custom metrics server", serverRunnable(logger, "custom metrics", customMetrics, customMetricsListeners, mgr.shutdownTimeout))
+	}
+	if port := ptr.Deref(c.options.DefaultingWebhookPort, 0); port > 0 {
+		webhookListeners, err := listen(listenHosts(c.options.MainHosts), port)
+		if err != nil {
+			closeListeners(selfListeners)
+			closeListeners(mainListeners)
+
+			return fmt.Errorf("error listening for the defaulting webhook: %w", err)
+		}
+		defaulting, err := newDefaultingWebhook(
+			webhookListeners[0].Addr().String(),
+			ptr.Deref(c.options.DefaultingWebhookTLSCert, ""), ptr.Deref(c.options.DefaultingWebhookTLSKey, ""),
+			ptr.Deref(c.options.DefaultingWebhookFamilyPrefix, ""), ptr.Deref(c.options.DefaultingWebhookLabels, ""),
+			ptr.Deref(c.options.CELCostLimit, 0), time.Duration(ptr.Deref(c.options.CELTimeout, 0))*time.Second,
+		)
+		var webhook *http.Server
+		if err == nil {
+			webhook, err = defaulting.build(logger)
+		}
+		if err != nil {
+			closeListeners(selfListeners)
+			closeListeners(mainListeners)
+			closeListeners(webhookListeners)
+
+			return err
+		}
+		for i, listener := range webhookListeners {
+			webhookListeners[i] = tls.NewListener(listener, webhook.TLSConfig)
+		}
+		mgr.add("defaulting webhook", serverRunnable(logger, "defaulting webhook", webhook, webhookListeners, mgr.shutdownTimeout))
+	}
+	if ptr.Deref(c.options.AutoConditions, false) {
+		clientsets := c.clusterClientsets
+		if len(clientsets) == 0 {
+			clientsets = map[string]dynamic.Interface{localCluster: c.dynamicClientset}
+		}
+		autoConditions := newAutoConditions(clientsets, &c.stores, ptr.Deref(c.options.AutoConditionsNamespace, ""), ptr.Deref(c.options.AutoConditionsLabelSelector, ""))
+		autoConditions.exposition = ExpositionMode(ptr.Deref(c.options.ExpositionMode, ""))
+		autoConditions.fixedPointValues = ptr.Deref(c.options.FixedPointValues, false)
+		autoConditions.listPageSize = ptr.Deref(c.options.ListPageSize, 0)
+		autoConditions.storage = c.storage
+		autoConditions.droppedSamples = c.droppedSamples
+		mgr.add("auto conditions", runnableFunc(autoConditions.start))
+	}
+	mgr.addLeaderElected("summary report", every(c.reportSummaries, summaryReportInterval))
+	mgr.addLeaderElected("forbidden check", every(newForbiddenCheck(&c.stores, c.getMonitor, c.emitForbidden).run, forbiddenCheckInterval))
+	if ptr.Deref(c.options.StatusLastErrors, 0) > 0 {
+		mgr.addLeaderElected("last errors report", every(c.reportLastErrors, lastErrorsReportInterval))
+	}
+	// The workers block on the workqueue until it is shut down, so it is shut down as soon as the controller stops,
+	// rather than once the manager gave up waiting on them.
+	mgr.add("workqueue", runnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		c.workqueue.ShutDown()
+
+		return nil
+	}))
+	for i := range workers {
+		mgr.addLeaderElected(fmt.Sprintf("worker %d", i), every(func(ctx context.Context) {
+			for c.processNextWorkItem(ctx) {
+			}
+		}, time.Second))
+	}
+	mgr.add("telemetry server", serverRunnable(logger, "telemetry", self, selfListeners, mgr.shutdownTimeout))
+	mgr.add("main server", serverRunnable(logger, "main", main, mainListeners, mgr.shutdownTimeout))
+	if c.snapshot != nil {
+		mgr.addShutdownHook(func() error {
+			logger.V(1).Info("Writing snapshot")
+			if err := c.snapshot.write(); err != nil {
+				return fmt.Errorf("error writing snapshot: %w", err)
+			}
+
+			return nil
+		})
+	}
 
-	<-ctx.Done()
-	logger.V(1).Info("Shutting down servers")
-	if err := self.Shutdown(ctx); err != nil {
-		logger.Error(err, "error shutting down telemetry server")
+	return mgr.start(ctx)
+}
+
+// newRSMInformerFactories returns an informer factory per given namespace, or a single one for all namespaces if none
+// were given.
+func newRSMInformerFactories(rsmClientset clientset.Interface, namespaces *[]string) map[string]informers.SharedInformerFactory {
+	if namespaces == nil || len(*namespaces) == 0 {
+		return map[string]informers.SharedInformerFactory{
+			metav1.NamespaceAll: informers.NewSharedInformerFactory(rsmClientset, 0),
+		}
 	}
-	if err := main.Shutdown(ctx); err != nil {
-		logger.Error(err, "error shutting down main server")
+	factories := make(map[string]informers.SharedInformerFactory, len(*namespaces))
+	for _, namespace := range *namespaces {
+		factories[namespace] = informers.NewSharedInformerFactoryWithOptions(rsmClientset, 0, informers.WithNamespace(namespace))
+	}
+
+	return factories
+}
+
+// rsmInformerFactory returns the informer factory watching the given namespace, if any.
+func (c *Controller) rsmInformerFactory(namespace string) (informers.SharedInformerFactory, bool) {
+	if factory, ok := c.rsmInformerFactories[metav1.NamespaceAll]; ok {
+		return factory, true
 	}
+	factory, ok := c.rsmInformerFactories[namespace]
 
-	return nil
+	return factory, ok
 }
 
 func (c *Controller) registerEventHandlers(logger klog.Logger) {
-	_, err := c.rsmInformerFactory.ResourceStateMetrics().V1alpha1().ResourceMetricsMonitors().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc:    func(obj interface{}) { c.enqueue(obj, addEvent) },
-		UpdateFunc: c.updateHandler(logger),
+	for _, factory := range c.rsmInformerFactories {
+		registration, err := factory.ResourceStateMetrics().V1alpha1().ResourceMetricsMonitors().Informer().AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+			AddFunc:    c.addHandler,
+			UpdateFunc: c.updateHandler(logger),
+			DeleteFunc: func(obj interface{}) { c.enqueue(obj, deleteEvent) },
+		})
+		if err != nil {
+			logger.Error(err, "error setting up event handlers")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+		c.registrations = append(c.registrations, registration)
+	}
+
+	// ClusterResourceMetricsMonitors are only watched if ResourceMetricsMonitors are, in all namespaces.
+	factory, ok := c.rsmInformerFactories[metav1.NamespaceAll]
+	if !ok {
+		return
+	}
+	updateHandler := c.updateHandler(logger)
+	registration, err := factory.ResourceStateMetrics().V1alpha1().ClusterResourceMetricsMonitors().Informer().AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc:    c.addHandler,
+		UpdateFunc: func(oldI, newI interface{}) { updateHandler(asMonitor(oldI), asMonitor(newI)) },
 		DeleteFunc: func(obj interface{}) { c.enqueue(obj, deleteEvent) },
 	})
 	if err != nil {
 		logger.Error(err, "error setting up event handlers")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
+	c.registrations = append(c.registrations, registration)
+}
+
+// addHandler enqueues added monitors, deferring the ones in the informers' initial lists until all of them have been
+// observed, so that they are processed by their priorities.
+func (c *Controller) addHandler(obj interface{}, isInInitialList bool) {
+	if isInInitialList {
+		c.startup.add(obj)
+
+		return
+	}
+	c.enqueue(obj, addEvent)
 }
 
 func (c *Controller) updateHandler(logger klog.Logger) func(interface{}, interface{}) {
@@ -224,7 +642,11 @@ func (c *Controller) updateHandler(logger klog.Logger) func(interface{}, interfa
 
 			return
 		}
-		if oldResource.ResourceVersion == newResource.ResourceVersion || reflect.DeepEqual(oldResource.Spec, newResource.Spec) {
+		// Besides the spec, the cluster-scoped and paused annotations affect the stores as well, and deletions finalize them.
+		clusterScopedChanged := oldResource.GetAnnotations()[v1alpha1.ClusterScopedAnnotation] != newResource.GetAnnotations()[v1alpha1.ClusterScopedAnnotation]
+		pausedChanged := oldResource.GetAnnotations()[v1alpha1.PausedAnnotation] != newResource.GetAnnotations()[v1alpha1.PausedAnnotation]
+		deleting := newResource.GetDeletionTimestamp() != nil
+		if oldResource.ResourceVersion == newResource.ResourceVersion || (reflect.DeepEqual(oldResource.Spec, newResource.Spec) && !clusterScopedChanged && !pausedChanged && !deleting) {
 			logger.V(10).Info("Skipping event", "[-old +new]", cmp.Diff(oldResource, newResource))
 
 			return
@@ -261,6 +683,9 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
 		c.workqueue.Forget(objectWithEvent)
+		if c.warmUp != nil {
+			c.warmUp.processed(key)
+		}
 		logger.V(4).Info("Synced", "key", key)
 
 		return nil
@@ -278,24 +703,57 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 func (c *Controller) syncHandler(ctx context.Context, key string, event string) error {
 	logger := klog.FromContext(ctx)
 	logger.V(4).Info("Syncing", "key", key, "event", event)
+	// Monitors taking long to reconcile are logged, along with the time each step took.
+	trace := utiltrace.New("Reconcile", utiltrace.Field{Key: "monitor", Value: key}, utiltrace.Field{Key: "event", Value: event})
+	defer logTraceIfLong(trace, time.Duration(ptr.Deref(c.options.SlowReconcileThreshold, 0)*float64(time.Second)))
+	ctx = utiltrace.ContextWithTrace(ctx, trace)
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
 		logger.Error(err, "invalid resource key", "key", key)
 
 		return nil
 	}
-	resource, err := c.rsmInformerFactory.ResourceStateMetrics().V1alpha1().ResourceMetricsMonitors().Lister().ResourceMetricsMonitors(namespace).Get(name)
+	resource, err := c.getMonitor(namespace, name)
+	if stderrors.Is(err, errNamespaceNotWatched) {
+		logger.Error(err, "invalid resource key", "key", key)
+
+		return nil
+	}
 	if err != nil && !errors.IsNotFound(err) {
-		return fmt.Errorf("error getting ResourceMetricsMonitor %q: %w", klog.KRef(namespace, name), err)
+		return fmt.Errorf("error getting monitor %q: %w", klog.KRef(namespace, name), err)
 	}
 	if errors.IsNotFound(err) {
 		resource = &v1alpha1.ResourceMetricsMonitor{}
+		resource.SetNamespace(namespace)
 		resource.SetName(name)
 	}
 
 	return c.handleObject(ctx, resource, event)
 }
 
+// getMonitor returns the given monitor from the informers' caches. Cluster-scoped monitors have no namespace, and are
+// returned as namespace-less ResourceMetricsMonitors.
+func (c *Controller) getMonitor(namespace, name string) (*v1alpha1.ResourceMetricsMonitor, error) {
+	if namespace == metav1.NamespaceNone {
+		factory, ok := c.rsmInformerFactories[metav1.NamespaceAll]
+		if !ok {
+			return nil, errNamespaceNotWatched
+		}
+		resource, err := factory.ResourceStateMetrics().V1alpha1().ClusterResourceMetricsMonitors().Lister().Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		return fromClusterMonitor(resource), nil
+	}
+	factory, ok := c.rsmInformerFactory(namespace)
+	if !ok {
+		return nil, errNamespaceNotWatched
+	}
+
+	return factory.ResourceStateMetrics().V1alpha1().ResourceMetricsMonitors().Lister().ResourceMetricsMonitors(namespace).Get(name)
+}
+
 func (c *Controller) handleObject(ctx context.Context, objectI interface{}, event string) error {
 	logger := klog.FromContext(ctx)
 	if objectI == nil {
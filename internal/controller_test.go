@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+)
+
+func TestNewRSMInformerFactories(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var objects []runtime.Object
+	for _, namespace := range []string{"foo", "bar", "baz"} {
+		objects = append(objects, &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "rmm", Namespace: namespace}})
+	}
+	c := &Controller{rsmInformerFactories: newRSMInformerFactories(fake.NewSimpleClientset(objects...), ptr.To([]string{"foo", "bar"}))}
+
+	for _, factory := range c.rsmInformerFactories {
+		informer := factory.ResourceStateMetrics().V1alpha1().ResourceMetricsMonitors().Informer()
+		factory.Start(ctx.Done())
+		if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+			t.Fatal("failed to sync informer")
+		}
+		if got := len(informer.GetStore().List()); got != 1 {
+			t.Errorf("expected each informer to only observe its own namespace, got %d objects", got)
+		}
+	}
+	for namespace, expected := range map[string]bool{"foo": true, "bar": true, "baz": false} {
+		if _, got := c.rsmInformerFactory(namespace); got != expected {
+			t.Errorf("expected namespace %s to be watched: %t, got %t", namespace, expected, got)
+		}
+	}
+}
+
+func TestController_storesNamespace(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		namespacedStores bool
+		annotations      map[string]string
+		expected         string
+	}{
+		{
+			name:     "cluster-wide by default",
+			expected: metav1.NamespaceAll,
+		},
+		{
+			name:             "namespaced",
+			namespacedStores: true,
+			expected:         "foo",
+		},
+		{
+			name:             "namespaced, but granted cluster scope",
+			namespacedStores: true,
+			annotations:      map[string]string{v1alpha1.ClusterScopedAnnotation: "true"},
+			expected:         metav1.NamespaceAll,
+		},
+		{
+			name:             "namespaced, with an invalid annotation",
+			namespacedStores: true,
+			annotations:      map[string]string{v1alpha1.ClusterScopedAnnotation: "yes"},
+			expected:         "foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := &Controller{options: &Options{NamespacedStores: ptr.To(tt.namespacedStores)}}
+			resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "rmm", Namespace: "foo", Annotations: tt.annotations}}
+			if got := c.storesNamespace(resource); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildLW(t *testing.T) {
+	t.Parallel()
+	gvr := schema.GroupVersionResource{Group: "contoso.com", Version: "v1alpha1", Resource: "bars"}
+	var objects []runtime.Object
+	for _, object := range newSyntheticObjects(20) {
+		objects = append(objects, object)
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "BarList"}, objects...)
+
+	for namespace, expected := range map[string]int{metav1.NamespaceAll: 20, "namespace-0": 2} {
+		list, err := buildLW(context.Background(), client, namespace, "", "", gvr, nil).List(metav1.ListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(list.(*unstructured.UnstructuredList).Items); got != expected {
+			t.Errorf("namespace %q: expected %d objects, got %d", namespace, expected, got)
+		}
+	}
+}
+
+func TestStoreType_listLimit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		store    *StoreType
+		limit    int64
+		expected int64
+	}{
+		{
+			name:     "no store defers to the pager",
+			limit:    500,
+			expected: 500,
+		},
+		{
+			name:     "page size overrides the pager's",
+			store:    &StoreType{listPageSize: 100},
+			limit:    500,
+			expected: 100,
+		},
+		{
+			name:     "no page size lists all objects at once",
+			store:    &StoreType{},
+			limit:    500,
+			expected: 0,
+		},
+		{
+			name:     "unpaginated lists stay unpaginated",
+			store:    &StoreType{listPageSize: 100},
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.store.listLimit(tt.limit); got != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// crdGVKR is the CustomResourceDefinition resource, watched by stores selecting their targets by CRD labels.
+var crdGVKR = gvkr{
+	GroupVersionKind:     schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+	GroupVersionResource: schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"},
+}
+
+// buildCRDSelectedStore builds a store targeting all CRDs matching the given CRD selector, instead of a single GVR. A
+// store is built (and removed) for each CRD as it starts (and stops) matching, in each of the given clusters, backed
+// by a reflector for the CRD's storage version, and the selected stores' metrics are written out along with the
+// returned one's headers.
+func buildCRDSelectedStore(
+	ctx context.Context,
+	clientsets map[string]dynamic.Interface,
+	crdSelector string,
+	watchNamespace string,
+	metricFamilies []*FamilyType,
+	labelSelector, fieldSelector, filter string,
+	tombstoneRetention, ttl time.Duration,
+	listPageSize int64,
+	concurrency ConcurrencyType,
+	storage storageFactory,
+	resolver ResolverType,
+	labelKeys, labelValues []string,
+	celCostLimit uint64,
+	celTimeout time.Duration,
+	celEvaluations *prometheus.CounterVec,
+	celVariables map[string]interface{},
+	namespace, name string,
+) *StoreType {
+	s := newConfiguredStore(klog.FromContext(ctx), metricFamilies, resolver, labelKeys, labelValues, celCostLimit, celTimeout, celEvaluations, namespace, name)
+	s.selected = map[types.UID]*StoreType{}
+	s.filter = newStoreFilter(filter, newCELResolver(s.logger, celCostLimit, celTimeout, celEvaluations, celVariables, namespace, name))
+	s.TombstoneRetention = metav1.Duration{Duration: tombstoneRetention}
+	s.TTL = metav1.Duration{Duration: ttl}
+	s.listPageSize = listPageSize
+	s.Concurrency = concurrency
+	s.storage = storage
+	for cluster, dynamicClientset := range clientsets {
+		selection := &crdSelection{
+			ctx:              ctx,
+			cluster:          cluster,
+			dynamicClientset: dynamicClientset,
+			store:            s,
+			watchNamespace:   watchNamespace,
+			labelSelector:    labelSelector,
+			fieldSelector:    fieldSelector,
+		}
+		startReflector(ctx, buildLW(ctx, dynamicClientset, metav1.NamespaceAll, crdSelector, "", crdGVKR.GroupVersionResource, nil), crdGVKR, selection)
+	}
+
+	return s
+}
+
+// newSelectedStore returns a store for the given CRD-selected target in the given cluster, sharing the given store's
+// configuration.
+func (s *StoreType) newSelectedStore(gvkWithR gvkr, cluster string) *StoreType {
+	// Families are already compiled, and are only copied to log with the target's logger.
+	logger := s.logger.WithValues("gvr", gvkWithR.GroupVersionResource.String())
+	families := make([]*FamilyType, len(s.Families))
+	for i, family := range s.Families {
+		families[i] = family.withLogger(logger)
+	}
+
+	selectedStore := &StoreType{
+		logger:       logger,
+		metrics:      memoryStorage{},
+		namespaces:   map[types.UID]string{},
+		tombstones:   map[types.UID]time.Time{},
+		observed:     map[types.UID]time.Time{},
+		objects:      map[types.UID]scrapedObject{},
+		digests:      map[types.UID]uint64{},
+		revisions:    map[types.UID]uint64{},
+		names:        map[types.UID]string{},
+		clusters:     map[types.UID]string{},
+		referenced:   s.referenced,
+		headers:      s.headers,
+		celCostLimit: s.celCostLimit,
+		celTimeout:   s.celTimeout,
+		Group:        gvkWithR.GroupVersionKind.Group,
+		Version:      gvkWithR.GroupVersionKind.Version,
+		Kind:         gvkWithR.Kind,
+		Resource:     gvkWithR.Resource,
+		Families:     families,
+		Resolver:     s.Resolver,
+		LabelKeys:    s.LabelKeys,
+		LabelValues:  s.LabelValues,
+		filter:       s.filter.forTarget(),
+		cluster:      cluster,
+		listPageSize: s.listPageSize,
+		Concurrency:  s.Concurrency,
+
+		TombstoneRetention: s.TombstoneRetention,
+		TTL:                s.TTL,
+	}
+	// Each target's series are held apart, as are their clusters'.
+	if s.storage != nil {
+		selectedStore.useStorage(s.storage(path.Join(cluster, gvkWithR.GroupVersionResource.String())))
+	}
+
+	return selectedStore
+}
+
+// selectedStores returns the stores built for the CRDs matching the store's CRD selector, or for its sources, if any,
+// sorted by their sources' priorities, and targets. The caller must hold the store's lock.
+func (s *StoreType) selectedStores() []*StoreType {
+	selected := make([]*StoreType, 0, len(s.selected))
+	for _, selectedStore := range s.selected {
+		selected = append(selected, selectedStore)
+	}
+	slices.SortFunc(selected, func(a, b *StoreType) int {
+		return cmp.Or(cmp.Compare(a.sourcePriority, b.sourcePriority), cmp.Compare(a.gvr().String(), b.gvr().String()))
+	})
+
+	return selected
+}
+
+// crdSelection implements cache.Store for the CRD reflector of a CRD-selected store, and keeps the store's selected
+// stores in sync with the matching CRDs.
+type crdSelection struct {
+	ctx context.Context
+	// cluster is the (federated) cluster the CRDs, and the selected stores' objects, come from.
+	cluster          string
+	dynamicClientset dynamic.Interface
+	store            *StoreType
+	watchNamespace   string
+	labelSelector    string
+	fieldSelector    string
+}
+
+// Ensure crdSelection implements cache.Store.
+var _ cache.Store = &crdSelection{}
+
+// Add builds a store for the given CRD, replacing the existing one if the CRD's storage version changed.
+func (c *crdSelection) Add(objectI interface{}) error {
+	crd, err := convertToUnstructured(objectI)
+	if err != nil {
+		return err
+	}
+	gvkWithR, ok := selectedGVKR(crd)
+	if !ok {
+		c.store.logger.V(1).Info("Skipping CRD with no served versions", "crd", crd.GetName())
+
+		return c.Delete(objectI)
+	}
+
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	if existing, ok := c.store.selected[crd.GetUID()]; ok {
+		if existing.gvr() == gvkWithR.GroupVersionResource {
+			return nil
+		}
+		existing.stop()
+		existing.purge()
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	selectedStore := c.store.newSelectedStore(gvkWithR, c.cluster)
+	selectedStore.stop = cancel
+	c.store.selected[crd.GetUID()] = selectedStore
+	startReflector(ctx, buildLW(ctx, c.dynamicClientset, c.watchNamespace, c.labelSelector, c.fieldSelector, gvkWithR.GroupVersionResource, selectedStore), gvkWithR, selectedStore)
+	c.store.logger.V(2).Info("Selected", "crd", crd.GetName(), "gvr", gvkWithR.GroupVersionResource.String(), "cluster", c.cluster)
+
+	return nil
+}
+
+// Update is called when a CRD is updated, which may change its storage version.
+func (c *crdSelection) Update(objectI interface{}) error {
+	return c.Add(objectI)
+}
+
+// Delete removes the store built for the given CRD, and stops the reflector backing it.
+func (c *crdSelection) Delete(objectI interface{}) error {
+	crd, err := meta.Accessor(objectI)
+	if err != nil {
+		return fmt.Errorf("error casting object interface: %w", err)
+	}
+
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	if existing, ok := c.store.selected[crd.GetUID()]; ok {
+		existing.stop()
+		existing.purge()
+		delete(c.store.selected, crd.GetUID())
+		c.store.resetDeltas()
+		c.store.logger.V(2).Info("Unselected", "crd", crd.GetName())
+	}
+
+	return nil
+}
+
+// Replace is called when the reflector lists all matching CRDs, and drops the stores for the ones no longer matching in
+// the selection's cluster.
+func (c *crdSelection) Replace(items []interface{}, _ string) error {
+	matching := sets.New[types.UID]()
+	for _, item := range items {
+		if err := c.Add(item); err != nil {
+			c.store.logger.Error(err, "failed to select CRD during replace")
+
+			continue
+		}
+		if crd, err := meta.Accessor(item); err == nil {
+			matching.Insert(crd.GetUID())
+		}
+	}
+
+	c.store.mutex.Lock()
+	defer c.store.mutex.Unlock()
+
+	for uid, existing := range c.store.selected {
+		if existing.cluster == c.cluster && !matching.Has(uid) {
+			existing.stop()
+			existing.purge()
+			delete(c.store.selected, uid)
+			c.store.resetDeltas()
+		}
+	}
+
+	return nil
+}
+
+// List is not needed for our use case, so it returns nil.
+func (c *crdSelection) List() []interface{} { return nil }
+
+// ListKeys is not needed for our use case, so it returns nil.
+func (c *crdSelection) ListKeys() []string { return nil }
+
+// Get is not needed for our use case, so it returns nil and false.
+func (c *crdSelection) Get(_ interface{}) (interface{}, bool, error) { return nil, false, nil }
+
+// GetByKey is not needed for our use case, so it returns nil and false.
+func (c *crdSelection) GetByKey(_ string) (interface{}, bool, error) { return nil, false, nil }
+
+// Resync is not needed for our use case, so it does nothing and returns nil.
+func (c *crdSelection) Resync() error { return nil }
+
+// selectedGVKR returns the target for the given CRD, i.e., its storage version, or its first served version if the
+// storage version is not served.
+func selectedGVKR(crd *unstructured.Unstructured) (gvkr, bool) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+
+	var version string
+	for _, versionI := range versions {
+		crdVersion, ok := versionI.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(crdVersion, "name")
+		served, _, _ := unstructured.NestedBool(crdVersion, "served")
+		storage, _, _ := unstructured.NestedBool(crdVersion, "storage")
+		if !served {
+			continue
+		}
+		if version == "" || storage {
+			version = name
+		}
+		if storage {
+			break
+		}
+	}
+	if version == "" || kind == "" || plural == "" {
+		return gvkr{}, false
+	}
+
+	return gvkr{
+		GroupVersionKind:     schema.GroupVersionKind{Group: group, Version: version, Kind: kind},
+		GroupVersionResource: schema.GroupVersionResource{Group: group, Version: version, Resource: plural},
+	}, true
+}
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newTestCRD(name, group, kind, plural string, labels map[string]string, versions ...interface{}) *unstructured.Unstructured {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"group":    group,
+			"names":    map[string]interface{}{"kind": kind, "plural": plural},
+			"versions": versions,
+		},
+	}}
+	crd.SetGroupVersionKind(crdGVKR.GroupVersionKind)
+	crd.SetName(name)
+	crd.SetUID(types.UID("uid-" + name))
+	crd.SetLabels(labels)
+
+	return crd
+}
+
+func TestSelectedGVKR(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		versions []interface{}
+		expected string
+	}{
+		{
+			name: "storage version",
+			versions: []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "served": true, "storage": false},
+				map[string]interface{}{"name": "v1", "served": true, "storage": true},
+			},
+			expected: "contoso.com/v1, Resource=foos",
+		},
+		{
+			name: "unserved storage version",
+			versions: []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "served": true, "storage": false},
+				map[string]interface{}{"name": "v1", "served": false, "storage": true},
+			},
+			expected: "contoso.com/v1alpha1, Resource=foos",
+		},
+		{
+			name: "no served versions",
+			versions: []interface{}{
+				map[string]interface{}{"name": "v1", "served": false, "storage": true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := selectedGVKR(newTestCRD("foos.contoso.com", "contoso.com", "Foo", "foos", nil, tt.versions...))
+			if ok != (tt.expected != "") {
+				t.Fatalf("expected a target to be selected: %t, got %t", tt.expected != "", ok)
+			}
+			if ok && got.GroupVersionResource.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got.GroupVersionResource.String())
+			}
+		})
+	}
+}
+
+func TestConfigurer_buildCRDSelectedStore(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	version := map[string]interface{}{"name": "v1", "served": true, "storage": true}
+	selected := newTestCRD("foos.contoso.com", "contoso.com", "Foo", "foos", map[string]string{"operator": "contoso"}, version)
+	unselected := newTestCRD("bars.contoso.com", "contoso.com", "Bar", "bars", nil, version)
+	newObject := func(kind, name string) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}}}
+		object.SetGroupVersionKind(schema.GroupVersionKind{Group: "contoso.com", Version: "v1", Kind: kind})
+		object.SetNamespace("default")
+		object.SetName(name)
+		object.SetUID(types.UID("uid-" + name))
+
+		return object
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crdGVKR.GroupVersionResource:                            "CustomResourceDefinitionList",
+		{Group: "contoso.com", Version: "v1", Resource: "foos"}: "FooList",
+		{Group: "contoso.com", Version: "v1", Resource: "bars"}: "BarList",
+	}, selected, unselected, newObject("Foo", "foo"), newObject("Bar", "bar"))
+
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "selector", Namespace: "default"}}
+	c := newConfigurer(client, resource, 0, 0, nil)
+	if err := c.parse(`stores:
+  - selectors:
+      crd: "operator=contoso"
+    families:
+      - name: "replicas"
+        help: "Replicas"
+        metrics:
+          - labelKeys: ["name"]
+            labelValues: ["metadata.name"]
+            value: "spec.replicas"
+`); err != nil {
+		t.Fatal(err)
+	}
+	stores := &sync.Map{}
+	c.build(ctx, stores)
+	value, _ := stores.Load(storesKey(resource))
+	builtStores, _ := value.([]*StoreType)
+
+	expositionEventually := func(expected string) {
+		t.Helper()
+		var got string
+		err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+			buffer := &bytes.Buffer{}
+			if err := newMetricsWriter(builtStores...).writeStores(buffer); err != nil {
+				return false, err
+			}
+			got = buffer.String()
+
+			return got == expected, nil
+		})
+		if err != nil {
+			t.Fatalf("expected exposition %q, got %q: %v", expected, got, err)
+		}
+	}
+
+	// Only the selected CRD's objects are exposed.
+	expositionEventually(`# HELP kube_customresource_replicas Replicas
+# TYPE kube_customresource_replicas gauge
+kube_customresource_replicas{name="foo",group="contoso.com",version="v1",kind="Foo"} 1
+`)
+
+	// Once the CRD goes away, so do its stores.
+	if err := client.Resource(crdGVKR.GroupVersionResource).Delete(ctx, selected.GetName(), metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expositionEventually(`# HELP kube_customresource_replicas Replicas
+# TYPE kube_customresource_replicas gauge
+`)
+}
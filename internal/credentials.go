@@ -0,0 +1,245 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/utils/ptr"
+)
+
+// defaultKubeconfigSecretKey is the key of the kubeconfig in the secrets stores reference, unless set otherwise.
+const defaultKubeconfigSecretKey = "kubeconfig"
+
+// CredentialsType sets the credentials a store lists and watches its targets with, in place of the controller's, so
+// that tenants' monitors are scoped to their own permissions. Exactly one of ServiceAccount, or KubeconfigSecret, must
+// be set.
+type CredentialsType struct {
+	// ServiceAccount is the name of the service account, in the monitor's namespace, to impersonate.
+	ServiceAccount string `yaml:"serviceAccount,omitempty"`
+	// KubeconfigSecret references the secret, in the monitor's namespace, holding the kubeconfig to connect with.
+	KubeconfigSecret *SecretKeySelectorType `yaml:"kubeconfigSecret,omitempty"`
+	// Namespace is the namespace of the service account, or secret, in place of the monitor's, which cluster-scoped
+	// monitors lack, and so must set. Namespaced monitors may only set their own.
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// SecretKeySelectorType references a key of a secret.
+type SecretKeySelectorType struct {
+	Name string `yaml:"name"`
+	// Key defaults to "kubeconfig".
+	Key string `yaml:"key,omitempty"`
+}
+
+// validate rejects credentials setting none, or both, of their fields, or invalid names.
+func (c *CredentialsType) validate() error {
+	if c == nil {
+		return nil
+	}
+	switch {
+	case c.ServiceAccount == "" && c.KubeconfigSecret == nil:
+		return errors.New("one of serviceAccount, or kubeconfigSecret, must be set")
+	case c.ServiceAccount != "" && c.KubeconfigSecret != nil:
+		return errors.New("only one of serviceAccount, or kubeconfigSecret, may be set")
+	case c.ServiceAccount != "":
+		if errs := validation.IsDNS1123Subdomain(c.ServiceAccount); len(errs) > 0 {
+			return fmt.Errorf("serviceAccount: invalid name %q: %s", c.ServiceAccount, strings.Join(errs, ", "))
+		}
+	default:
+		if errs := validation.IsDNS1123Subdomain(c.KubeconfigSecret.Name); len(errs) > 0 {
+			return fmt.Errorf("kubeconfigSecret.name: invalid name %q: %s", c.KubeconfigSecret.Name, strings.Join(errs, ", "))
+		}
+	}
+	if errs := validation.IsDNS1123Label(c.Namespace); c.Namespace != "" && len(errs) > 0 {
+		return fmt.Errorf("namespace: invalid namespace %q: %s", c.Namespace, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// namespace returns the namespace of the service account, or secret, of the credentials of a monitor in the given
+// namespace, i.e., the monitor's own, or, for cluster-scoped monitors, the one the credentials set.
+func (c *CredentialsType) namespace(monitorNamespace string) (string, error) {
+	switch {
+	case monitorNamespace == "" && c.Namespace == "":
+		return "", errors.New("namespace must be set for cluster-scoped monitors")
+	case monitorNamespace != "" && c.Namespace != "" && c.Namespace != monitorNamespace:
+		return "", fmt.Errorf("namespace must be the monitor's own, %q, if set", monitorNamespace)
+	case monitorNamespace == "":
+		return c.Namespace, nil
+	}
+
+	return monitorNamespace, nil
+}
+
+// config returns the client configuration for the credentials, derived off the given one for the service account to
+// impersonate, or loaded off the referenced secret, for a monitor in the given namespace. Kubeconfigs may only connect
+// to the given configuration's API server, or the given allowed ones, directly.
+func (c *CredentialsType) config(ctx context.Context, kubeClientset kubernetes.Interface, base *rest.Config, allowedServers []string, monitorNamespace string) (*rest.Config, error) {
+	namespace, err := c.namespace(monitorNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if c.ServiceAccount != "" {
+		cfg := rest.CopyConfig(base)
+		// The API server adds the service account's groups itself.
+		cfg.Impersonate = rest.ImpersonationConfig{UserName: fmt.Sprintf("system:serviceaccount:%s:%s", namespace, c.ServiceAccount)}
+
+		return cfg, nil
+	}
+
+	secret, err := kubeClientset.CoreV1().Secrets(namespace).Get(ctx, c.KubeconfigSecret.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting secret %s/%s: %w", namespace, c.KubeconfigSecret.Name, err)
+	}
+	key := c.KubeconfigSecret.Key
+	if key == "" {
+		key = defaultKubeconfigSecretKey
+	}
+	kubeconfig, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s/%s has no %q key", namespace, c.KubeconfigSecret.Name, key)
+	}
+	rawConfig, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("error loading kubeconfig off secret %s/%s: %w", namespace, c.KubeconfigSecret.Name, err)
+	}
+	if err = checkInlineCredentials(rawConfig); err != nil {
+		return nil, fmt.Errorf("kubeconfig in secret %s/%s: %w", namespace, c.KubeconfigSecret.Name, err)
+	}
+	cfg, err := clientcmd.NewDefaultClientConfig(*rawConfig, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading kubeconfig off secret %s/%s: %w", namespace, c.KubeconfigSecret.Name, err)
+	}
+	if err = checkServer(cfg, base, allowedServers); err != nil {
+		return nil, fmt.Errorf("kubeconfig in secret %s/%s: %w", namespace, c.KubeconfigSecret.Name, err)
+	}
+	if base != nil && base.WrapTransport != nil {
+		cfg.Wrap(base.WrapTransport)
+	}
+
+	return cfg, nil
+}
+
+// checkInlineCredentials rejects kubeconfigs setting credential plugins, or referencing files, which would run, or be
+// read, in the controller's context, with its own privileges, so tenants' kubeconfigs must carry their credentials
+// inline. It checks the kubeconfigs before they are loaded, as loading them reads the referenced token files.
+func checkInlineCredentials(config *clientcmdapi.Config) error {
+	for name, authInfo := range config.AuthInfos {
+		switch {
+		case authInfo.Exec != nil, authInfo.AuthProvider != nil:
+			return fmt.Errorf("users[%q]: credential plugins are not allowed", name)
+		case authInfo.TokenFile != "", authInfo.ClientCertificate != "", authInfo.ClientKey != "":
+			return fmt.Errorf("users[%q]: file references are not allowed", name)
+		}
+	}
+	for name, cluster := range config.Clusters {
+		if cluster.CertificateAuthority != "" {
+			return fmt.Errorf("clusters[%q]: file references are not allowed", name)
+		}
+	}
+
+	return nil
+}
+
+// checkServer rejects client configurations connecting to API servers other than the given base configuration's, or
+// the given allowed ones, or through proxies, since tenants would otherwise have the controller send requests, e.g.,
+// to internal endpoints, from within the cluster's network.
+func checkServer(cfg, base *rest.Config, allowedServers []string) error {
+	if cfg.Proxy != nil {
+		return errors.New("proxies are not allowed")
+	}
+	origin, err := serverOrigin(cfg.Host)
+	if err != nil {
+		return fmt.Errorf("invalid server %q: %w", cfg.Host, err)
+	}
+	servers := slices.Clone(allowedServers)
+	if base != nil {
+		servers = append(servers, base.Host)
+	}
+	for _, server := range servers {
+		if allowed, err := serverOrigin(server); err == nil && allowed == origin {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("server %q is not allowed, see --%s", cfg.Host, credentialsAllowedServersFlagName)
+}
+
+// serverOrigin returns the scheme, host, and port of the given API server address, defaulting to HTTPS, and the
+// scheme's port, so that addresses may be compared regardless of how they are spelled.
+func serverOrigin(server string) (string, error) {
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+	if serverURL.Hostname() == "" || (serverURL.Scheme != "https" && serverURL.Scheme != "http") {
+		return "", errors.New("must be an HTTP(S) URL, e.g., https://127.0.0.1:6443")
+	}
+	port := serverURL.Port()
+	if port == "" {
+		port = "443"
+		if serverURL.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	return serverURL.Scheme + "://" + net.JoinHostPort(strings.ToLower(serverURL.Hostname()), port), nil
+}
+
+// resolveCredentials builds the client-sets the given resource's stores setting credentials list and watch their
+// targets with.
+func (c *Controller) resolveCredentials(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor, configuration configuration) error {
+	for i, store := range configuration.Stores {
+		if store.Credentials == nil {
+			continue
+		}
+		if len(c.clusterClientsets) > 0 {
+			return fmt.Errorf("stores[%d].credentials: not supported with federated clusters", i)
+		}
+		if c.cfg == nil {
+			return fmt.Errorf("stores[%d].credentials: no client configuration to derive credentials off", i)
+		}
+		cfg, err := store.Credentials.config(ctx, c.kubeclientset, c.cfg, ptr.Deref(c.options.CredentialsAllowedServers, nil), resource.GetNamespace())
+		if err != nil {
+			return fmt.Errorf("stores[%d].credentials: %w", i, err)
+		}
+		store.clientset, err = dynamic.NewForConfig(cfg)
+		if err != nil {
+			return fmt.Errorf("stores[%d].credentials: error building client-set: %w", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+const testTenantKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: tenant
+  cluster:
+    server: https://tenant.example.com
+contexts:
+- name: tenant
+  context:
+    cluster: tenant
+    user: tenant
+current-context: tenant
+users:
+- name: tenant
+  user:
+`
+
+func TestCredentialsType_Validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		credentials *CredentialsType
+		wantErr     bool
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:        "service account",
+			credentials: &CredentialsType{ServiceAccount: "tenant"},
+		},
+		{
+			name:        "kubeconfig secret",
+			credentials: &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "tenant"}},
+		},
+		{
+			name:        "neither",
+			credentials: &CredentialsType{},
+			wantErr:     true,
+		},
+		{
+			name:        "both",
+			credentials: &CredentialsType{ServiceAccount: "tenant", KubeconfigSecret: &SecretKeySelectorType{Name: "tenant"}},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid service account name",
+			credentials: &CredentialsType{ServiceAccount: "Tenant_SA"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid namespace",
+			credentials: &CredentialsType{ServiceAccount: "tenant", Namespace: "Tenant_NS"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tt.credentials.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCredentialsType_Config(t *testing.T) {
+	t.Parallel()
+	secret := func(name, key, user string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: name},
+			Data:       map[string][]byte{key: []byte(testTenantKubeconfig + user)},
+		}
+	}
+	kubeClientset := kubefake.NewClientset(
+		secret("token", defaultKubeconfigSecretKey, "    token: secret\n"),
+		secret("custom-key", "config", "    token: secret\n"),
+		secret("exec", defaultKubeconfigSecretKey, "    exec:\n      apiVersion: client.authentication.k8s.io/v1\n      command: tenant-credentials\n      interactiveMode: Never\n"),
+		secret("token-file", defaultKubeconfigSecretKey, "    tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token\n"),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "controller-server"},
+			Data:       map[string][]byte{defaultKubeconfigSecretKey: []byte(strings.Replace(testTenantKubeconfig, "https://tenant.example.com", "https://CONTROLLER.example.com:443", 1) + "    token: secret\n")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "internal-server"},
+			Data:       map[string][]byte{defaultKubeconfigSecretKey: []byte(strings.Replace(testTenantKubeconfig, "https://tenant.example.com", "http://169.254.169.254", 1) + "    token: secret\n")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "proxy"},
+			Data:       map[string][]byte{defaultKubeconfigSecretKey: []byte(strings.Replace(testTenantKubeconfig, "    server: https://tenant.example.com\n", "    server: https://tenant.example.com\n    proxy-url: http://proxy.example.com\n", 1) + "    token: secret\n")},
+		},
+	)
+	allowedServers := []string{"https://tenant.example.com:443"}
+	base := &rest.Config{Host: "https://controller.example.com", BearerToken: "controller"}
+	t.Cleanup(func() {
+		if base.Impersonate.UserName != "" {
+			t.Errorf("expected the base configuration to be left as is, got it impersonating %q", base.Impersonate.UserName)
+		}
+	})
+	tests := []struct {
+		name                  string
+		credentials           *CredentialsType
+		clusterScoped         bool
+		expectedHost          string
+		expectedToken         string
+		expectedImpersonation string
+		wantErr               string
+	}{
+		{
+			name:                  "service account",
+			credentials:           &CredentialsType{ServiceAccount: "reader"},
+			expectedHost:          "https://controller.example.com",
+			expectedToken:         "controller",
+			expectedImpersonation: "system:serviceaccount:tenant:reader",
+		},
+		{
+			name:                  "service account of a cluster-scoped monitor",
+			credentials:           &CredentialsType{ServiceAccount: "reader", Namespace: "tenant"},
+			clusterScoped:         true,
+			expectedHost:          "https://controller.example.com",
+			expectedToken:         "controller",
+			expectedImpersonation: "system:serviceaccount:tenant:reader",
+		},
+		{
+			name:          "service account of a cluster-scoped monitor without a namespace",
+			credentials:   &CredentialsType{ServiceAccount: "reader"},
+			clusterScoped: true,
+			wantErr:       "namespace must be set for cluster-scoped monitors",
+		},
+		{
+			name:        "service account in another namespace",
+			credentials: &CredentialsType{ServiceAccount: "reader", Namespace: "other"},
+			wantErr:     `namespace must be the monitor's own, "tenant"`,
+		},
+		{
+			name:          "kubeconfig secret",
+			credentials:   &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "token"}},
+			expectedHost:  "https://tenant.example.com",
+			expectedToken: "secret",
+		},
+		{
+			name:          "kubeconfig secret of a cluster-scoped monitor",
+			credentials:   &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "token"}, Namespace: "tenant"},
+			clusterScoped: true,
+			expectedHost:  "https://tenant.example.com",
+			expectedToken: "secret",
+		},
+		{
+			name:          "kubeconfig secret of a cluster-scoped monitor without a namespace",
+			credentials:   &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "token"}},
+			clusterScoped: true,
+			wantErr:       "namespace must be set for cluster-scoped monitors",
+		},
+		{
+			name:          "kubeconfig secret with a custom key",
+			credentials:   &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "custom-key", Key: "config"}},
+			expectedHost:  "https://tenant.example.com",
+			expectedToken: "secret",
+		},
+		{
+			name:        "missing key",
+			credentials: &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "token", Key: "config"}},
+			wantErr:     `no "config" key`,
+		},
+		{
+			name:        "missing secret",
+			credentials: &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "missing"}},
+			wantErr:     "not found",
+		},
+		{
+			name:        "credential plugin",
+			credentials: &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "exec"}},
+			wantErr:     "credential plugins are not allowed",
+		},
+		{
+			name:          "kubeconfig secret connecting to the controller's server",
+			credentials:   &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "controller-server"}},
+			expectedHost:  "https://CONTROLLER.example.com:443",
+			expectedToken: "secret",
+		},
+		{
+			name:        "kubeconfig secret connecting to a server not allowed",
+			credentials: &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "internal-server"}},
+			wantErr:     `server "http://169.254.169.254" is not allowed`,
+		},
+		{
+			name:        "kubeconfig secret connecting through a proxy",
+			credentials: &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "proxy"}},
+			wantErr:     "proxies are not allowed",
+		},
+		{
+			name:        "file reference",
+			credentials: &CredentialsType{KubeconfigSecret: &SecretKeySelectorType{Name: "token-file"}},
+			wantErr:     "file references are not allowed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			namespace := "tenant"
+			if tt.clusterScoped {
+				namespace = ""
+			}
+			cfg, err := tt.credentials.config(context.Background(), kubeClientset, base, allowedServers, namespace)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error mentioning %q, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Host != tt.expectedHost {
+				t.Errorf("expected host %q, got %q", tt.expectedHost, cfg.Host)
+			}
+			if cfg.BearerToken != tt.expectedToken {
+				t.Errorf("expected token %q, got %q", tt.expectedToken, cfg.BearerToken)
+			}
+			if cfg.Impersonate.UserName != tt.expectedImpersonation {
+				t.Errorf("expected to impersonate %q, got %q", tt.expectedImpersonation, cfg.Impersonate.UserName)
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// customMetricsGroup, and customMetricsVersion, are the group, and version, of the custom metrics API served.
+	customMetricsGroup        = "custom.metrics.k8s.io"
+	customMetricsVersion      = "v1beta2"
+	customMetricsGroupVersion = customMetricsGroup + "/" + customMetricsVersion
+	// customMetricsPath is the path the custom metrics API is served under.
+	customMetricsPath = "/apis/" + customMetricsGroupVersion
+	// customMetricsAnyName is the object name the custom metrics API is requested the metrics of all objects with.
+	customMetricsAnyName = "*"
+)
+
+// customMetricValueList mirrors custom.metrics.k8s.io/v1beta2's MetricValueList, which is not vendored.
+type customMetricValueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []customMetricValue `json:"items"`
+}
+
+// customMetricValue mirrors custom.metrics.k8s.io/v1beta2's MetricValue.
+type customMetricValue struct {
+	metav1.TypeMeta `json:",inline"`
+	DescribedObject corev1.ObjectReference `json:"describedObject"`
+	Metric          customMetricIdentifier `json:"metric"`
+	Timestamp       metav1.Time            `json:"timestamp"`
+	Value           resource.Quantity      `json:"value"`
+}
+
+// customMetricIdentifier mirrors custom.metrics.k8s.io/v1beta2's MetricIdentifier.
+type customMetricIdentifier struct {
+	Name     string                `json:"name"`
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// customMetricsServer serves the series of the families opting into it over the custom metrics API, as metrics of the
+// objects they are generated for, so HorizontalPodAutoscalers may scale on them without an adapter. Only requests the
+// aggregator proxies are served, and only on behalf of users the API server authorizes.
+type customMetricsServer struct {
+	addr          string
+	stores        *sync.Map
+	kubeClientset kubernetes.Interface
+	// certFile and keyFile are the TLS certificate and key to serve with, or empty to generate a self-signed pair.
+	certFile, keyFile string
+
+	authenticator *requestHeaderAuthenticator
+	reviewer      *accessReviewer
+}
+
+// newCustomMetricsServer returns a new customMetricsServer.
+func newCustomMetricsServer(addr string, stores *sync.Map, kubeClientset kubernetes.Interface, certFile, keyFile string) *customMetricsServer {
+	return &customMetricsServer{
+		addr:          addr,
+		stores:        stores,
+		kubeClientset: kubeClientset,
+		certFile:      certFile,
+		keyFile:       keyFile,
+		reviewer:      &accessReviewer{kubeClientset: kubeClientset},
+	}
+}
+
+// build sets up the customMetricsServer. Its listeners are to be wrapped with the returned server's TLS configuration,
+// since the API is registered as an APIService, which the aggregator only proxies to over TLS, authenticating with the
+// client certificate its callers are verified by.
+func (c *customMetricsServer) build(ctx context.Context, logger klog.Logger) (*http.Server, error) {
+	certificate, err := c.certificate()
+	if err != nil {
+		return nil, err
+	}
+	c.authenticator, err = newRequestHeaderAuthenticator(ctx, c.kubeClientset)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up the custom metrics API's authentication: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+customMetricsPath, c.authorized(logger, discoveryAttributes, func(w http.ResponseWriter, _ *http.Request) {
+		c.discovery(logger, w)
+	}))
+	mux.HandleFunc("GET "+customMetricsPath+"/namespaces/{namespace}/{resource}/{name}/{metric}", c.authorized(logger, customMetricAttributes, func(w http.ResponseWriter, r *http.Request) {
+		c.values(logger, w, r, r.PathValue("namespace"))
+	}))
+	mux.HandleFunc("GET "+customMetricsPath+"/{resource}/{name}/{metric}", c.authorized(logger, customMetricAttributes, func(w http.ResponseWriter, r *http.Request) {
+		c.values(logger, w, r, metav1.NamespaceNone)
+	}))
+
+	return &http.Server{
+		ErrorLog:          log.New(os.Stdout, "custom-metrics", log.LstdFlags|log.Lshortfile),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		Addr:              c.addr,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    c.authenticator.clientCAs,
+			MinVersion:   tls.VersionTLS12,
+		},
+	}, nil
+}
+
+// authorized wraps the given handler, serving the requests the aggregator proxied on behalf of users the API server
+// allows the attributes of, and rejecting the others.
+func (c *customMetricsServer) authorized(
+	logger klog.Logger,
+	attributes func(r *http.Request) (*authorizationv1.ResourceAttributes, *authorizationv1.NonResourceAttributes),
+	next http.HandlerFunc,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, groups, err := c.authenticator.authenticate(r)
+		if err != nil {
+			writeCustomMetricsStatus(logger, w, http.StatusUnauthorized, metav1.StatusReasonUnauthorized, err.Error())
+
+			return
+		}
+		resource, nonResource := attributes(r)
+		allowed, err := c.reviewer.allowed(r.Context(), user, groups, resource, nonResource)
+		if err != nil {
+			writeCustomMetricsStatus(logger, w, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error())
+
+			return
+		}
+		if !allowed {
+			writeCustomMetricsStatus(logger, w, http.StatusForbidden, metav1.StatusReasonForbidden, fmt.Sprintf("user %q cannot get %s", user, r.URL.Path))
+
+			return
+		}
+		next(w, r)
+	}
+}
+
+// discoveryAttributes returns the attributes of the given discovery request, which is not of a resource.
+func discoveryAttributes(r *http.Request) (*authorizationv1.ResourceAttributes, *authorizationv1.NonResourceAttributes) {
+	return nil, &authorizationv1.NonResourceAttributes{Path: r.URL.Path, Verb: "get"}
+}
+
+// customMetricAttributes returns the attributes of the given custom metric request, as the API server would, i.e., with
+// the metric as the subresource of the resource, and as a list, if of all objects.
+func customMetricAttributes(r *http.Request) (*authorizationv1.ResourceAttributes, *authorizationv1.NonResourceAttributes) {
+	verb, name := "get", r.PathValue("name")
+	if name == customMetricsAnyName {
+		verb, name = "list", ""
+	}
+
+	return &authorizationv1.ResourceAttributes{
+		Namespace:   r.PathValue("namespace"),
+		Verb:        verb,
+		Group:       customMetricsGroup,
+		Version:     customMetricsVersion,
+		Resource:    r.PathValue("resource"),
+		Subresource: r.PathValue("metric"),
+		Name:        name,
+	}, nil
+}
+
+// certificate returns the certificate to serve with, generating a self-signed one if none was given.
+func (c *customMetricsServer) certificate() (tls.Certificate, error) {
+	if c.certFile != "" || c.keyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("error loading the custom metrics API's certificate: %w", err)
+		}
+
+		return certificate, nil
+	}
+	certPEM, keyPEM, err := cert.GenerateSelfSignedCertKey("resource-state-metrics", nil, nil)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("error generating the custom metrics API's certificate: %w", err)
+	}
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("error loading the custom metrics API's certificate: %w", err)
+	}
+
+	return certificate, nil
+}
+
+// customMetricsStores calls fn with all stores, including the ones selected by CRD-selecting stores, holding the lock
+// of each.
+func customMetricsStores(stores *sync.Map, fn func(s *StoreType)) {
+	stores.Range(func(_, value any) bool {
+		builtStores, _ := value.([]*StoreType)
+		for _, s := range builtStores {
+			s.mutex.RLock()
+			selected := s.selectedStores()
+			fn(s)
+			s.mutex.RUnlock()
+			for _, selectedStore := range selected {
+				selectedStore.mutex.RLock()
+				fn(selectedStore)
+				selectedStore.mutex.RUnlock()
+			}
+		}
+
+		return true
+	})
+}
+
+// discovery lists the metrics served, as the resources of the API.
+func (c *customMetricsServer) discovery(logger klog.Logger, w http.ResponseWriter) {
+	resources := map[string]metav1.APIResource{}
+	customMetricsStores(c.stores, func(s *StoreType) {
+		namespaced := len(s.namespaces) == 0
+		for _, namespace := range s.namespaces {
+			namespaced = namespaced || namespace != metav1.NamespaceNone
+		}
+		for _, family := range s.Families {
+			if !family.CustomMetric {
+				continue
+			}
+			name := s.gvr().GroupResource().String() + "/" + kubeCustomResourcePrefix + family.Name
+			resources[name] = metav1.APIResource{
+				Name:       name,
+				Namespaced: namespaced,
+				Kind:       "MetricValueList",
+				Verbs:      metav1.Verbs{"get"},
+			}
+		}
+	})
+	list := metav1.APIResourceList{
+		TypeMeta:     metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"},
+		GroupVersion: customMetricsGroupVersion,
+		APIResources: make([]metav1.APIResource, 0, len(resources)),
+	}
+	for _, name := range slices.Sorted(maps.Keys(resources)) {
+		list.APIResources = append(list.APIResources, resources[name])
+	}
+	writeCustomMetricsResponse(logger, w, http.StatusOK, list)
+}
+
+// values returns the values of the requested metric, for the requested object, or all objects, in the given namespace.
+func (c *customMetricsServer) values(logger klog.Logger, w http.ResponseWriter, r *http.Request, namespace string) {
+	groupResource, name, metric := r.PathValue("resource"), r.PathValue("name"), r.PathValue("metric")
+	if r.URL.Query().Get("labelSelector") != "" {
+		writeCustomMetricsStatus(logger, w, http.StatusBadRequest, metav1.StatusReasonBadRequest, "selecting objects by their labels is not supported")
+
+		return
+	}
+	metricSelector, err := labels.Parse(r.URL.Query().Get("metricLabelSelector"))
+	if err != nil {
+		writeCustomMetricsStatus(logger, w, http.StatusBadRequest, metav1.StatusReasonBadRequest, fmt.Sprintf("invalid metric label selector: %v", err))
+
+		return
+	}
+
+	served := false
+	now := metav1.Now()
+	list := customMetricValueList{
+		TypeMeta: metav1.TypeMeta{Kind: "MetricValueList", APIVersion: customMetricsGroupVersion},
+		Items:    []customMetricValue{},
+	}
+	customMetricsStores(c.stores, func(s *StoreType) {
+		if s.gvr().GroupResource().String() != groupResource {
+			return
+		}
+		for i, family := range s.Families {
+			if !family.CustomMetric || kubeCustomResourcePrefix+family.Name != metric {
+				continue
+			}
+			served = true
+			for uid, objectName := range s.names {
+				if (name != customMetricsAnyName && objectName != name) || s.namespaces[uid] != namespace {
+					continue
+				}
+				samples, err := s.customMetricSamples(uid, i)
+				if err != nil {
+					logger.Error(err, "error parsing series for the custom metrics API", "metric", metric, "object", objectName)
+
+					continue
+				}
+				for _, sample := range samples {
+					sampleLabels := labels.Set{}
+					for _, label := range sample.labels {
+						sampleLabels[label.GetName()] = label.GetValue()
+					}
+					if !metricSelector.Matches(sampleLabels) || math.IsNaN(sample.value) || math.IsInf(sample.value, 0) {
+						continue
+					}
+					list.Items = append(list.Items, customMetricValue{
+						DescribedObject: corev1.ObjectReference{
+							APIVersion: s.gvr().GroupVersion().String(),
+							Kind:       s.Kind,
+							Namespace:  namespace,
+							Name:       objectName,
+						},
+						Metric:    customMetricIdentifier{Name: metric, Selector: metav1.SetAsLabelSelector(sampleLabels)},
+						Timestamp: now,
+						Value:     *resource.NewMilliQuantity(int64(math.Round(sample.value*1000)), resource.DecimalSI),
+					})
+				}
+			}
+		}
+	})
+	if !served {
+		writeCustomMetricsStatus(logger, w, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("metric %q is not served for %q", metric, groupResource))
+
+		return
+	}
+	slices.SortFunc(list.Items, func(a, b customMetricValue) int {
+		return strings.Compare(a.DescribedObject.Name, b.DescribedObject.Name)
+	})
+	writeCustomMetricsResponse(logger, w, http.StatusOK, list)
+}
+
+// customMetricSamples returns the samples of the given family, for the given object, unless they are no longer served,
+// e.g., since the object was deleted. The caller must hold the store's lock.
+func (s *StoreType) customMetricSamples(uid types.UID, family int) ([]sample, error) {
+	if _, deleted := s.tombstones[uid]; deleted || s.expired(uid) || s.stale(uid) {
+		return nil, nil
+	}
+	metricFamilies, ok := s.metrics.get(uid)
+	if !ok || family >= len(metricFamilies) {
+		return nil, nil
+	}
+	metricFamily := metricFamilies[family]
+	if s.Families[family].onScrape() {
+		metricFamily = s.renderOnScrape(uid, family)
+	}
+	if s.Families[family].hasExemplars() {
+		metricFamily = stripExemplars(metricFamily)
+	}
+	parser := expfmt.TextParser{}
+	parsed, err := parser.TextToMetricFamilies(strings.NewReader(metricFamily))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing series: %w", err)
+	}
+	var samples []sample
+	for _, name := range slices.Sorted(maps.Keys(parsed)) {
+		samples = append(samples, samplesOf(parsed[name])...)
+	}
+
+	return samples, nil
+}
+
+// writeCustomMetricsStatus writes out the given failure as a Status, as the aggregator expects.
+func writeCustomMetricsStatus(logger klog.Logger, w http.ResponseWriter, code int, reason metav1.StatusReason, message string) {
+	writeCustomMetricsResponse(logger, w, code, metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  message,
+		Reason:   reason,
+		Code:     int32(code),
+	})
+}
+
+func writeCustomMetricsResponse(logger klog.Logger, w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error(err, "error writing custom metrics API response")
+	}
+}
@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCustomMetricsServer(t *testing.T) {
+	t.Parallel()
+	var c configuration
+	if err := yaml.UnmarshalStrict([]byte(`stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    families:
+      - name: "replicas"
+        customMetric: true
+        metrics:
+          - value: "spec.replicas"
+      - name: "generation"
+        metrics:
+          - value: "metadata.generation"
+`), &c); err != nil {
+		t.Fatal(err)
+	}
+	cfg := c.Stores[0]
+	s := newStore(klog.Background(), []string{"# HELP kube_customresource_replicas", "# HELP kube_customresource_generation"}, cfg.Families, ResolverTypeUnstructured, nil, nil, 0, 0)
+	s.Group, s.Version, s.Kind, s.Resource = cfg.Group, cfg.Version, cfg.Kind, cfg.Resource
+	for _, object := range newSyntheticObjects(3) {
+		if err := s.Add(object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stores := &sync.Map{}
+	stores.Store("foo/bar", []*StoreType{s})
+	kubeClientset := newTestRequestHeaderClientset(t)
+	var reviewed []authorizationv1.SubjectAccessReviewSpec
+	kubeClientset.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		reviewed = append(reviewed, review.Spec)
+		review.Status.Allowed = review.Spec.User == "hpa"
+
+		return true, review, nil
+	})
+	server, err := newCustomMetricsServer("", stores, kubeClientset, "", "").build(context.Background(), klog.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server.TLSConfig.ClientAuth != tls.RequireAndVerifyClientCert || server.TLSConfig.ClientCAs == nil {
+		t.Errorf("expected client certificates to be required, and verified against the request header CA")
+	}
+	request := func(path, commonName, user string) (int, []byte) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		if commonName != "" {
+			certificate := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate}, VerifiedChains: [][]*x509.Certificate{{certificate}}}
+		}
+		r.Header.Set("X-Remote-User", user)
+		r.Header.Add("X-Remote-Group", "system:authenticated")
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, r)
+
+		return recorder.Code, recorder.Body.Bytes()
+	}
+	get := func(path string) (int, []byte) {
+		t.Helper()
+
+		return request(path, "front-proxy-client", "hpa")
+	}
+
+	// Requests not proxied by the aggregator, or on behalf of users not allowed the metrics, are rejected.
+	for _, tt := range []struct {
+		commonName, user string
+		expected         int
+	}{
+		{expected: http.StatusUnauthorized},
+		{commonName: "mallory", user: "hpa", expected: http.StatusUnauthorized},
+		{commonName: "front-proxy-client", expected: http.StatusUnauthorized},
+		{commonName: "front-proxy-client", user: "mallory", expected: http.StatusForbidden},
+	} {
+		if code, body := request(customMetricsPath, tt.commonName, tt.user); code != tt.expected {
+			t.Errorf("expected status %d for %q on behalf of %q, got %d: %s", tt.expected, tt.commonName, tt.user, code, body)
+		}
+	}
+
+	code, body := get(customMetricsPath)
+	if code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", code, body)
+	}
+	resources := metav1.APIResourceList{}
+	if err := json.Unmarshal(body, &resources); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(resources.APIResources, []metav1.APIResource{{
+		Name:       "bars.contoso.com/kube_customresource_replicas",
+		Namespaced: true,
+		Kind:       "MetricValueList",
+		Verbs:      metav1.Verbs{"get"},
+	}}); diff != "" {
+		t.Errorf("unexpected resources: %s", diff)
+	}
+
+	code, body = get(customMetricsPath + "/namespaces/namespace-2/bars.contoso.com/bar-2/kube_customresource_replicas")
+	if code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", code, body)
+	}
+	values := customMetricValueList{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		t.Fatal(err)
+	}
+	if len(values.Items) != 1 {
+		t.Fatalf("expected a single value, got %d", len(values.Items))
+	}
+	if item := values.Items[0]; item.DescribedObject.Name != "bar-2" || item.DescribedObject.Kind != "Bar" || item.Value.MilliValue() != 2000 {
+		t.Errorf("unexpected value: %+v", item)
+	}
+	if diff := cmp.Diff(&authorizationv1.ResourceAttributes{
+		Namespace:   "namespace-2",
+		Verb:        "get",
+		Group:       customMetricsGroup,
+		Version:     customMetricsVersion,
+		Resource:    "bars.contoso.com",
+		Subresource: "kube_customresource_replicas",
+		Name:        "bar-2",
+	}, reviewed[len(reviewed)-1].ResourceAttributes); diff != "" {
+		t.Errorf("unexpected reviewed attributes (-want +got):\n%s", diff)
+	}
+
+	for path, expected := range map[string]int{
+		customMetricsPath + "/namespaces/namespace-2/bars.contoso.com/bar-2/kube_customresource_generation":                 http.StatusNotFound,
+		customMetricsPath + "/namespaces/namespace-2/bars.contoso.com/*/kube_customresource_replicas?labelSelector=app=bar": http.StatusBadRequest,
+	} {
+		if code, body := get(path); code != expected {
+			t.Errorf("expected status %d for %s, got %d: %s", expected, path, code, body)
+		}
+	}
+}
+
+// newTestRequestHeaderClientset returns a clientset with the request header configuration of an API server whose
+// aggregator authenticates as front-proxy-client.
+func newTestRequestHeaderClientset(t *testing.T) *kubefake.Clientset {
+	t.Helper()
+	clientCA, _, err := cert.GenerateSelfSignedCertKey("front-proxy-ca", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return kubefake.NewClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: extensionAPIServerAuthentication},
+		Data: map[string]string{
+			requestHeaderClientCAKey:        string(clientCA),
+			requestHeaderAllowedNamesKey:    `["front-proxy-client"]`,
+			requestHeaderUsernameHeadersKey: `["X-Remote-User"]`,
+			requestHeaderGroupHeadersKey:    `["X-Remote-Group"]`,
+		},
+	})
+}
+
+func TestNewRequestHeaderAuthenticator(t *testing.T) {
+	t.Parallel()
+	if _, err := newRequestHeaderAuthenticator(context.Background(), kubefake.NewClientset()); err == nil {
+		t.Error("expected an error without the request header configuration")
+	}
+	authenticator, err := newRequestHeaderAuthenticator(context.Background(), newTestRequestHeaderClientset(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"X-Remote-User"}, authenticator.usernameHeaders); diff != "" {
+		t.Errorf("unexpected username headers (-want +got):\n%s", diff)
+	}
+	if !authenticator.allowedNames.Has("front-proxy-client") {
+		t.Errorf("expected front-proxy-client to be allowed, got %v", authenticator.allowedNames)
+	}
+}
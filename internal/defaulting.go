@@ -0,0 +1,460 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	yamlv3 "gopkg.in/yaml.v3"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// defaultingWebhookPath is the path the defaulting webhook is served on.
+	defaultingWebhookPath = "/default"
+
+	// maxAdmissionReviewBytes bounds the size of the AdmissionReviews read, well above the objects' size limit.
+	maxAdmissionReviewBytes = 3 << 20
+)
+
+// defaultingWebhook serves a mutating admission webhook that fills in the defaults of the (Cluster)ResourceMetricsMonitors'
+// configurations, so that they may be kept terse, while the defaults they are processed with are recorded on them.
+// Configurations are only rewritten if any default is filled in, in which case only the defaulted fields change.
+type defaultingWebhook struct {
+	addr string
+	// certFile and keyFile are the TLS certificate and key to serve with, which the API server must trust, reloaded
+	// as they are rotated.
+	certFile, keyFile string
+	// familyPrefix, if set, is prepended to the names of the families lacking it.
+	familyPrefix string
+	// labelKeys and labelPaths are the labels added to the stores not setting them, and the field paths they are
+	// resolved off, sorted by their keys.
+	labelKeys, labelPaths []string
+	// celCostLimit and celTimeout, if set, are the CEL limits set on the stores not setting theirs.
+	celCostLimit uint64
+	celTimeout   time.Duration
+}
+
+// newDefaultingWebhook returns a new defaultingWebhook, defaulting the families' prefix, and the stores' labels, as
+// comma-separated key=path pairs, and CEL limits, to the given ones, if any.
+func newDefaultingWebhook(addr, certFile, keyFile, familyPrefix, labels string, celCostLimit uint64, celTimeout time.Duration) (*defaultingWebhook, error) {
+	labelKeys, labelPaths, err := parseDefaultLabels(labels)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the defaulting webhook's labels: %w", err)
+	}
+
+	return &defaultingWebhook{
+		addr:         addr,
+		certFile:     certFile,
+		keyFile:      keyFile,
+		familyPrefix: familyPrefix,
+		labelKeys:    labelKeys,
+		labelPaths:   labelPaths,
+		celCostLimit: celCostLimit,
+		celTimeout:   celTimeout,
+	}, nil
+}
+
+// build sets up the defaultingWebhook. Its listeners are to be wrapped with the returned server's TLS configuration,
+// since the API server only calls webhooks over TLS.
+func (d *defaultingWebhook) build(logger klog.Logger) (*http.Server, error) {
+	if d.certFile == "" || d.keyFile == "" {
+		return nil, errors.New("the defaulting webhook requires a TLS certificate and key the API server trusts")
+	}
+	certificates, err := newCertificateReloader(logger, d.certFile, d.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the defaulting webhook's certificate: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+defaultingWebhookPath, func(w http.ResponseWriter, r *http.Request) {
+		d.admit(logger, w, r)
+	})
+
+	return &http.Server{
+		ErrorLog:          log.New(os.Stdout, "defaulting-webhook", log.LstdFlags|log.Lshortfile),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		Addr:              d.addr,
+		TLSConfig: &tls.Config{
+			GetCertificate: certificates.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		},
+	}, nil
+}
+
+// admit responds to the given AdmissionReview with a patch filling in the defaults of the monitor's configuration, if
+// any. Monitors are always admitted, as the controller reports on invalid configurations through their status.
+func (d *defaultingWebhook) admit(logger klog.Logger, w http.ResponseWriter, r *http.Request) {
+	review := admissionv1.AdmissionReview{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdmissionReviewBytes)).Decode(&review); err != nil || review.Request == nil {
+		http.Error(w, "invalid AdmissionReview", http.StatusBadRequest)
+
+		return
+	}
+	response := &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	monitor := struct {
+		Spec v1alpha1.ResourceMetricsMonitorSpec `json:"spec"`
+	}{}
+	if err := json.Unmarshal(review.Request.Object.Raw, &monitor); err != nil {
+		response.Warnings = []string{fmt.Sprintf("configuration not defaulted: %v", err)}
+	} else if defaulted, renamed, changed, err := d.defaults(monitor.Spec.Configuration); err != nil {
+		response.Warnings = []string{fmt.Sprintf("configuration not defaulted: %v", err)}
+	} else if changed {
+		operations := []map[string]interface{}{{"op": "replace", "path": "/spec/configuration", "value": defaulted}}
+		for i, test := range monitor.Spec.Tests {
+			for j, expected := range test.Expected {
+				if line := renameSeries(expected, renamed); line != expected {
+					operations = append(operations, map[string]interface{}{"op": "replace", "path": fmt.Sprintf("/spec/tests/%d/expected/%d", i, j), "value": line})
+				}
+			}
+		}
+		patch, err := json.Marshal(operations)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			return
+		}
+		response.Patch = patch
+		response.PatchType = ptr.To(admissionv1.PatchTypeJSONPatch)
+	}
+
+	review.Request = nil
+	review.Response = response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		logger.Error(err, "error writing AdmissionReview")
+	}
+}
+
+// defaults fills in the defaults of the given configuration, i.e., the families' help texts, types, policies,
+// resolvers, and prefix, if any, and the stores' labels, and CEL limits, if any, and reports whether any were filled
+// in, along with the families renamed after the prefix, by their former names. Defaults that are inherited at runtime,
+// e.g., the resolver of stores setting one, are left out, so that they keep following what they are inherited off. The configuration is edited in place, retaining its comments, and key order, though its indentation may be
+// normalized.
+func (d *defaultingWebhook) defaults(raw string) (string, map[string]string, bool, error) {
+	typed := configuration{}
+	if err := yaml.Unmarshal([]byte(raw), &typed); err != nil {
+		return "", nil, false, fmt.Errorf("error unmarshalling configuration: %w", err)
+	}
+	if err := typed.validate(); err != nil {
+		return "", nil, false, err
+	}
+	root := yamlv3.Node{}
+	if err := yamlv3.Unmarshal([]byte(raw), &root); err != nil {
+		return "", nil, false, fmt.Errorf("error unmarshalling configuration: %w", err)
+	}
+	if len(root.Content) == 0 {
+		return raw, nil, false, nil
+	}
+
+	changed := false
+	renamed := map[string]string{}
+	stores := mappingValue(root.Content[0], "stores")
+	for i, store := range nodeItems(stores) {
+		if i >= len(typed.Stores) {
+			break
+		}
+		typedStore := typed.Stores[i]
+		for j, family := range nodeItems(mappingValue(store, "families")) {
+			if j >= len(typedStore.Families) {
+				break
+			}
+			typedFamily := typedStore.Families[j]
+			if d.familyPrefix != "" && typedFamily.Name != "" && !strings.HasPrefix(typedFamily.Name, d.familyPrefix) {
+				renamed[typedFamily.Name] = d.familyPrefix + typedFamily.Name
+				typedFamily.Name = d.familyPrefix + typedFamily.Name
+				changed = setValue(family, "name", typedFamily.Name) || changed
+			}
+			if strings.TrimSpace(typedFamily.Help) == "" {
+				changed = setValue(family, "help", defaultHelp(typedStore, typedFamily)) || changed
+			}
+			changed = setDefault(family, "type", string(FamilyKindGauge)) || changed
+			changed = setDefault(family, "nanPolicy", string(NaNPolicyEmit)) || changed
+			changed = setDefault(family, "evaluate", string(EvaluationPolicyOnEvent)) || changed
+			if typedStore.Resolver == ResolverTypeNone && !metricsSetResolver(typedFamily) {
+				changed = setDefault(family, "resolver", string(ResolverTypeUnstructured)) || changed
+			}
+		}
+		for _, threshold := range nodeItems(mappingValue(store, "thresholds")) {
+			if family := mappingValue(threshold, "family"); family != nil && renamed[family.Value] != "" {
+				changed = setValue(threshold, "family", renamed[family.Value]) || changed
+			}
+		}
+		changed = d.defaultLabels(store, typedStore) || changed
+		changed = d.defaultLimits(store, typedStore) || changed
+	}
+	if !changed {
+		return raw, nil, false, nil
+	}
+	defaulted := bytes.Buffer{}
+	encoder := yamlv3.NewEncoder(&defaulted)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return "", nil, false, fmt.Errorf("error marshalling configuration: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", nil, false, fmt.Errorf("error marshalling configuration: %w", err)
+	}
+
+	return defaulted.String(), renamed, true, nil
+}
+
+// renameSeries renames the series, or metadata, in the given exposition line, as expected by the resources' tests,
+// after the given renamed families, by their former names, so that the tests keep passing once the families are
+// prefixed. Lines of other families are returned as is.
+func renameSeries(line string, renamed map[string]string) string {
+	if len(renamed) == 0 {
+		return line
+	}
+	trimmed := strings.TrimSpace(line)
+	header := ""
+	for _, prefix := range []string{"# HELP ", "# TYPE ", "# UNIT "} {
+		if strings.HasPrefix(trimmed, prefix) {
+			header, trimmed = prefix, strings.TrimPrefix(trimmed, prefix)
+
+			break
+		}
+	}
+	name := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_:"))]
+	for _, suffix := range []string{"", "_bucket", "_sum", "_count"} {
+		family, ok := strings.CutSuffix(strings.TrimPrefix(name, kubeCustomResourcePrefix), suffix)
+		if !ok || !strings.HasPrefix(name, kubeCustomResourcePrefix) || renamed[family] == "" {
+			continue
+		}
+
+		return header + kubeCustomResourcePrefix + renamed[family] + suffix + trimmed[len(name):]
+	}
+
+	return line
+}
+
+// defaultLabels adds the default labels the given store, or any of its families or metrics, does not set to its
+// labels, queried in its resolver's syntax, and reports whether any were added. Stores whose families, or metrics, set
+// resolvers other than the store's are left as is, since the store's labels are resolved by each of them.
+func (d *defaultingWebhook) defaultLabels(store *yamlv3.Node, typedStore *StoreType) bool {
+	resolver, ok := uniformResolver(typedStore)
+	if !ok || len(d.labelKeys) == 0 || len(typedStore.LabelKeys) != len(typedStore.LabelValues) {
+		return false
+	}
+	set := sets.New(typedStore.LabelKeys...)
+	for _, family := range typedStore.Families {
+		set.Insert(family.LabelKeys...)
+		for _, metric := range family.Metrics {
+			set.Insert(metric.LabelKeys...)
+		}
+	}
+	changed := false
+	for i, key := range d.labelKeys {
+		if set.Has(key) {
+			continue
+		}
+		query := d.labelPaths[i]
+		if resolver == ResolverTypeCEL {
+			query = celOptionalPath(query)
+		}
+		appendToSequence(store, "labelKeys", key)
+		appendToSequence(store, "labelValues", query)
+		changed = true
+	}
+
+	return changed
+}
+
+// celOptionalPath returns the CEL expression resolving the given dotted field path off the object, or to an empty string
+// if any of its fields is missing, like the other resolvers do, i.e., selecting the fields optionally, after the
+// metadata, which objects always set, and falling back to an empty string through orValue.
+func celOptionalPath(path string) string {
+	if first, rest, ok := strings.Cut(path, "."); ok && first == "metadata" {
+		return "o.metadata.?" + rest + ".orValue('')"
+	}
+
+	return "o.?" + path + ".orValue('')"
+}
+
+// defaultLimits sets the CEL limits of the given store to the webhook's ones, if any, unless the store sets them, and
+// reports whether any were set, so that the limits the store's expressions are evaluated within are recorded on it.
+func (d *defaultingWebhook) defaultLimits(store *yamlv3.Node, typedStore *StoreType) bool {
+	changed := false
+	if d.celCostLimit > 0 && typedStore.CEL.CostLimit == 0 {
+		changed = setScalar(mappingChild(store, "cel"), "costLimit", "!!int", strconv.FormatUint(d.celCostLimit, 10)) || changed
+	}
+	if d.celTimeout > 0 && typedStore.CEL.Timeout.Duration == 0 {
+		changed = setScalar(mappingChild(store, "cel"), "timeout", "!!str", d.celTimeout.String()) || changed
+	}
+
+	return changed
+}
+
+// uniformResolver returns the resolver all of the given store's metrics are resolved by, if they are all resolved by
+// the same one.
+func uniformResolver(store *StoreType) (ResolverType, bool) {
+	resolver := ensureResolver(store.Resolver)
+	for _, family := range store.Families {
+		if family.Resolver != ResolverTypeNone && family.Resolver != resolver {
+			return "", false
+		}
+		for _, metric := range family.Metrics {
+			if metric != nil && metric.Resolver != ResolverTypeNone && metric.Resolver != resolver {
+				return "", false
+			}
+		}
+	}
+
+	return resolver, true
+}
+
+// defaultLabelPath matches the dotted field paths default labels may be resolved off, which read alike in every
+// resolver's syntax.
+var defaultLabelPath = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// parseDefaultLabels parses the given comma-separated key=path labels, sorted by their keys.
+func parseDefaultLabels(value string) ([]string, []string, error) {
+	set, err := labels.ConvertSelectorToLabelsMap(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := sets.List(sets.KeySet(set))
+	paths := make([]string, len(keys))
+	for i, key := range keys {
+		if !model.LabelName(key).IsValidLegacy() {
+			return nil, nil, fmt.Errorf("invalid label name %q", key)
+		}
+		if !defaultLabelPath.MatchString(set[key]) {
+			return nil, nil, fmt.Errorf("invalid path %q for label %q: must be a dotted field path, e.g., metadata.labels.team", set[key], key)
+		}
+		paths[i] = set[key]
+	}
+
+	return keys, paths, nil
+}
+
+// mappingValue returns the value of the given key in the given mapping node, if any.
+func mappingValue(mapping *yamlv3.Node, key string) *yamlv3.Node {
+	if mapping == nil || mapping.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// nodeItems returns the items of the given sequence node, if any.
+func nodeItems(sequence *yamlv3.Node) []*yamlv3.Node {
+	if sequence == nil || sequence.Kind != yamlv3.SequenceNode {
+		return nil
+	}
+
+	return sequence.Content
+}
+
+// setValue sets the given key of the given mapping node to the given string, appending it if missing, and reports
+// whether it changed.
+func setValue(mapping *yamlv3.Node, key, value string) bool {
+	return setScalar(mapping, key, "!!str", value)
+}
+
+// setScalar sets the given key of the given mapping node to the given scalar, of the given tag, appending it if
+// missing, and reports whether it changed.
+func setScalar(mapping *yamlv3.Node, key, tag, value string) bool {
+	if existing := mappingValue(mapping, key); existing != nil {
+		if existing.Kind == yamlv3.ScalarNode && existing.Tag == tag && existing.Value == value {
+			return false
+		}
+		*existing = yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: tag, Value: value, HeadComment: existing.HeadComment, LineComment: existing.LineComment}
+
+		return true
+	}
+	mapping.Content = append(mapping.Content,
+		&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key},
+		&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: tag, Value: value},
+	)
+
+	return true
+}
+
+// mappingChild returns the mapping under the given key of the given mapping node, adding it if missing, or null.
+func mappingChild(mapping *yamlv3.Node, key string) *yamlv3.Node {
+	if existing := mappingValue(mapping, key); existing != nil {
+		if existing.Kind != yamlv3.MappingNode {
+			*existing = yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map", HeadComment: existing.HeadComment, LineComment: existing.LineComment}
+		}
+
+		return existing
+	}
+	child := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	mapping.Content = append(mapping.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key}, child)
+
+	return child
+}
+
+// setDefault sets the given key of the given mapping node to the given string if it is missing, null, or empty, and
+// reports whether it did.
+func setDefault(mapping *yamlv3.Node, key, value string) bool {
+	if existing := mappingValue(mapping, key); existing != nil && (existing.Kind != yamlv3.ScalarNode || (existing.Tag != "!!null" && existing.Value != "")) {
+		return false
+	}
+
+	return setValue(mapping, key, value)
+}
+
+// appendToSequence appends the given string to the sequence under the given key of the given mapping node, adding the
+// sequence if missing.
+func appendToSequence(mapping *yamlv3.Node, key, value string) {
+	item := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}
+	if sequence := mappingValue(mapping, key); sequence != nil && sequence.Kind == yamlv3.SequenceNode {
+		sequence.Content = append(sequence.Content, item)
+
+		return
+	}
+	mapping.Content = append(mapping.Content,
+		&yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: key},
+		&yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq", Content: []*yamlv3.Node{item}},
+	)
+}
+
+// metricsSetResolver reports whether any of the given family's metrics set their own resolver.
+func metricsSetResolver(family *FamilyType) bool {
+	for _, metric := range family.Metrics {
+		if metric.Resolver != ResolverTypeNone {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,602 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDefaultingWebhook_defaults(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		familyPrefix  string
+		labels        string
+		celCostLimit  uint64
+		celTimeout    time.Duration
+		configuration string
+		expected      string
+		expectedError string
+	}{
+		{
+			name: "terse configuration",
+			configuration: `
+stores:
+- group: apps
+  version: v1
+  kind: Deployment
+  resource: deployments
+  families:
+  - name: replicas
+    metrics:
+    - value: spec.replicas
+`,
+			expected: `
+stores:
+- group: apps
+  version: v1
+  kind: Deployment
+  resource: deployments
+  families:
+  - name: replicas
+    help: Value of spec.replicas of apps/v1 Deployment objects.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: spec.replicas
+`,
+		},
+		{
+			name: "inherited resolver, and limits",
+			configuration: `
+stores:
+- group: apps
+  version: v1
+  kind: Deployment
+  resource: deployments
+  resolver: cel
+  families:
+  - name: replicas
+    type: gauge
+    nanPolicy: skip
+    evaluate: onScrape
+    metrics:
+    - value: o.spec.replicas
+`,
+			expected: `
+stores:
+- group: apps
+  version: v1
+  kind: Deployment
+  resource: deployments
+  resolver: cel
+  families:
+  - name: replicas
+    help: Value of o.spec.replicas of apps/v1 Deployment objects.
+    type: gauge
+    nanPolicy: skip
+    evaluate: onScrape
+    metrics:
+    - value: o.spec.replicas
+`,
+		},
+		{
+			name:         "prefix, along with the thresholds referencing the renamed families",
+			familyPrefix: "acme_",
+			configuration: `
+stores:
+- group: apps
+  version: v1
+  kind: Deployment
+  resource: deployments
+  families:
+  - name: replicas
+    help: Replicas.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: spec.replicas
+  - name: acme_ready
+    help: Ready.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: status.readyReplicas
+  thresholds:
+  - name: surge
+    family: replicas
+    expression: o.value > 10
+`,
+			expected: `
+stores:
+- group: apps
+  version: v1
+  kind: Deployment
+  resource: deployments
+  families:
+  - name: acme_replicas
+    help: Replicas.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: spec.replicas
+  - name: acme_ready
+    help: Ready.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: status.readyReplicas
+  thresholds:
+  - name: surge
+    family: acme_replicas
+    expression: o.value > 10
+`,
+		},
+		{
+			name:   "labels, in each store's resolver's syntax, unless set, or resolved by other resolvers",
+			labels: "team=metadata.labels.team,tier=metadata.labels.tier",
+			configuration: `
+stores:
+- group: apps
+  version: v1
+  kind: Deployment
+  resource: deployments
+  labelKeys: [tier]
+  labelValues: [metadata.annotations.tier]
+  families:
+  - name: replicas
+    help: Replicas.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: spec.replicas
+- group: apps
+  version: v1
+  kind: StatefulSet
+  resource: statefulsets
+  resolver: cel
+  families:
+  - name: statefulset_replicas
+    help: Replicas.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    metrics:
+    - value: o.spec.replicas
+- group: apps
+  version: v1
+  kind: DaemonSet
+  resource: daemonsets
+  resolver: cel
+  families:
+  - name: daemonset_scheduled
+    help: Scheduled.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    metrics:
+    - value: status.currentNumberScheduled
+      resolver: unstructured
+`,
+			expected: `
+stores:
+- group: apps
+  version: v1
+  kind: Deployment
+  resource: deployments
+  labelKeys: [tier, team]
+  labelValues: [metadata.annotations.tier, metadata.labels.team]
+  families:
+  - name: replicas
+    help: Replicas.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: spec.replicas
+- group: apps
+  version: v1
+  kind: StatefulSet
+  resource: statefulsets
+  resolver: cel
+  families:
+  - name: statefulset_replicas
+    help: Replicas.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    metrics:
+    - value: o.spec.replicas
+  labelKeys: [team, tier]
+  labelValues: ["o.metadata.?labels.team.orValue('')", "o.metadata.?labels.tier.orValue('')"]
+- group: apps
+  version: v1
+  kind: DaemonSet
+  resource: daemonsets
+  resolver: cel
+  families:
+  - name: daemonset_scheduled
+    help: Scheduled.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    metrics:
+    - value: status.currentNumberScheduled
+      resolver: unstructured
+`,
+		},
+		{
+			name:         "CEL limits, unless set",
+			celCostLimit: 1000,
+			celTimeout:   5 * time.Second,
+			configuration: `
+stores:
+- group: apps
+  version: v1
+  kind: Deployment
+  resource: deployments
+  families:
+  - name: replicas
+    help: Replicas.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: spec.replicas
+- group: apps
+  version: v1
+  kind: StatefulSet
+  resource: statefulsets
+  cel:
+    timeout: 1s
+  families:
+  - name: statefulset_replicas
+    help: Replicas.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: spec.replicas
+`,
+			expected: `
+stores:
+- group: apps
+  version: v1
+  kind: Deployment
+  resource: deployments
+  families:
+  - name: replicas
+    help: Replicas.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: spec.replicas
+  cel:
+    costLimit: 1000
+    timeout: 5s
+- group: apps
+  version: v1
+  kind: StatefulSet
+  resource: statefulsets
+  cel:
+    timeout: 1s
+    costLimit: 1000
+  families:
+  - name: statefulset_replicas
+    help: Replicas.
+    type: gauge
+    nanPolicy: emit
+    evaluate: onEvent
+    resolver: unstructured
+    metrics:
+    - value: spec.replicas
+`,
+		},
+		{
+			name:          "invalid configuration",
+			configuration: "stores:\n- null\n",
+			expectedError: "stores[0] is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			webhook, err := newDefaultingWebhook("", "", "", tt.familyPrefix, tt.labels, tt.celCostLimit, tt.celTimeout)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, changed, err := webhook.defaults(tt.configuration)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !changed {
+				t.Fatal("expected the configuration to be defaulted")
+			}
+			var gotConfiguration, expectedConfiguration interface{}
+			if err = yaml.Unmarshal([]byte(got), &gotConfiguration); err != nil {
+				t.Fatal(err)
+			}
+			if err = yaml.Unmarshal([]byte(tt.expected), &expectedConfiguration); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(expectedConfiguration, gotConfiguration); diff != "" {
+				t.Errorf("%s", diff)
+			}
+
+			// Defaulting is idempotent.
+			if _, _, changed, err = webhook.defaults(got); err != nil || changed {
+				t.Errorf("expected the defaulted configuration to be left as is, got changed: %t, error: %v", changed, err)
+			}
+		})
+	}
+}
+
+func TestDefaultingWebhook_defaultsRetainsComments(t *testing.T) {
+	t.Parallel()
+	webhook, err := newDefaultingWebhook("", "", "", "", "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configuration := `# Deployments.
+stores:
+  - kind: Deployment # The target.
+    resource: deployments
+    version: v1
+    group: apps
+    families:
+      # The desired replicas.
+      - name: replicas
+        metrics:
+          - value: spec.replicas
+`
+	expected := `# Deployments.
+stores:
+  - kind: Deployment # The target.
+    resource: deployments
+    version: v1
+    group: apps
+    families:
+      # The desired replicas.
+      - name: replicas
+        metrics:
+          - value: spec.replicas
+        help: Value of spec.replicas of apps/v1 Deployment objects.
+        type: gauge
+        nanPolicy: emit
+        evaluate: onEvent
+        resolver: unstructured
+`
+	got, _, changed, err := webhook.defaults(configuration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Fatal("expected the configuration to be defaulted")
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected configuration (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenameSeries(t *testing.T) {
+	t.Parallel()
+	renamed := map[string]string{"replicas": "acme_replicas", "latency": "acme_latency"}
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{
+			name:     "series",
+			line:     `kube_customresource_replicas{name="foo"} 3`,
+			expected: `kube_customresource_acme_replicas{name="foo"} 3`,
+		},
+		{
+			name:     "series without labels",
+			line:     "kube_customresource_replicas 3",
+			expected: "kube_customresource_acme_replicas 3",
+		},
+		{
+			name:     "headers",
+			line:     "# HELP kube_customresource_replicas Replicas.",
+			expected: "# HELP kube_customresource_acme_replicas Replicas.",
+		},
+		{
+			name:     "histogram series",
+			line:     `kube_customresource_latency_bucket{le="+Inf"} 1`,
+			expected: `kube_customresource_acme_latency_bucket{le="+Inf"} 1`,
+		},
+		{
+			name:     "other families",
+			line:     `kube_customresource_replicas_ready{name="foo"} 3`,
+			expected: `kube_customresource_replicas_ready{name="foo"} 3`,
+		},
+		{
+			name:     "other lines",
+			line:     "replicas 3",
+			expected: "replicas 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := renameSeries(tt.line, renamed); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseDefaultLabels(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		value         string
+		expectedKeys  []string
+		expectedPaths []string
+		wantErr       bool
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:          "sorted by keys",
+			value:         "tier=metadata.labels.tier,team=metadata.labels.team",
+			expectedKeys:  []string{"team", "tier"},
+			expectedPaths: []string{"metadata.labels.team", "metadata.labels.tier"},
+		},
+		{
+			name:    "invalid key",
+			value:   "team.name=metadata.labels.team",
+			wantErr: true,
+		},
+		{
+			name:    "invalid path",
+			value:   "team=metadata.labels.team-name",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			keys, paths, err := parseDefaultLabels(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %t, got %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(tt.expectedKeys, keys, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected keys (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedPaths, paths, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected paths (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefaultingWebhook_admit(t *testing.T) {
+	t.Parallel()
+	webhook, err := newDefaultingWebhook("", "", "", "", "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"configuration": "stores:\n- group: apps\n  version: v1\n  kind: Deployment\n  resource: deployments\n  families:\n  - name: replicas\n    help: Replicas.\n    metrics:\n    - value: spec.replicas\n",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := json.Marshal(admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{UID: "uid", Object: runtime.RawExtension{Raw: object}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	webhook.admit(klog.Background(), recorder, httptest.NewRequest(http.MethodPost, defaultingWebhookPath, bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body.String())
+	}
+	review := admissionv1.AdmissionReview{}
+	if err = json.Unmarshal(recorder.Body.Bytes(), &review); err != nil {
+		t.Fatal(err)
+	}
+	if review.Response == nil || review.Response.UID != "uid" || !review.Response.Allowed {
+		t.Fatalf("expected the monitor to be admitted, got %+v", review.Response)
+	}
+	patch := []struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value string `json:"value"`
+	}{}
+	if err = json.Unmarshal(review.Response.Patch, &patch); err != nil {
+		t.Fatal(err)
+	}
+	if len(patch) != 1 || patch[0].Path != "/spec/configuration" || !strings.Contains(patch[0].Value, "resolver: unstructured") {
+		t.Errorf("expected the configuration to be patched with its defaults, got %+v", patch)
+	}
+
+	// Tests expecting the series of prefixed families are rewritten along with them.
+	webhook, err = newDefaultingWebhook("", "", "", "acme_", "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, err = json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"configuration": "stores:\n- group: apps\n  version: v1\n  kind: Deployment\n  resource: deployments\n  families:\n  - name: replicas\n    help: Replicas.\n    metrics:\n    - value: spec.replicas\n",
+			"tests": []map[string]interface{}{{
+				"name":     "replicas",
+				"objects":  "",
+				"expected": []string{"# TYPE kube_customresource_replicas gauge", "kube_customresource_replicas 3"},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err = json.Marshal(admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{UID: "uid", Object: runtime.RawExtension{Raw: object}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder = httptest.NewRecorder()
+	webhook.admit(klog.Background(), recorder, httptest.NewRequest(http.MethodPost, defaultingWebhookPath, bytes.NewReader(body)))
+	review = admissionv1.AdmissionReview{}
+	if err = json.Unmarshal(recorder.Body.Bytes(), &review); err != nil {
+		t.Fatal(err)
+	}
+	if review.Response == nil {
+		t.Fatalf("expected a response, got %s", recorder.Body.String())
+	}
+	patch = patch[:0]
+	if err = json.Unmarshal(review.Response.Patch, &patch); err != nil {
+		t.Fatal(err)
+	}
+	if len(patch) != 3 ||
+		patch[1].Path != "/spec/tests/0/expected/0" || patch[1].Value != "# TYPE kube_customresource_acme_replicas gauge" ||
+		patch[2].Path != "/spec/tests/0/expected/1" || patch[2].Value != "kube_customresource_acme_replicas 3" {
+		t.Errorf("expected the tests to be patched along with the prefixed families, got %+v", patch)
+	}
+
+	// Malformed reviews are rejected.
+	recorder = httptest.NewRecorder()
+	webhook.admit(klog.Background(), recorder, httptest.NewRequest(http.MethodPost, defaultingWebhookPath, strings.NewReader("{")))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, recorder.Code)
+	}
+}
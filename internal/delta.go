@@ -0,0 +1,296 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// deltaPath is the path the series changed since a given revision are exposed on.
+	deltaPath = "/metrics/delta"
+	// deltaSinceParameter is the query parameter carrying the revision deltas are computed since.
+	deltaSinceParameter = "since"
+	// deltaRevisionHeader is the response header carrying the revision a delta was computed at, to poll the next one
+	// since.
+	deltaRevisionHeader = "X-Revision"
+	// deletedPrefix prefixes the series of deleted objects in deltas, as comments.
+	deletedPrefix = "# DELETED "
+	// maxRetainedDeletions bounds the number of deletions each store retains to serve deltas with.
+	maxRetainedDeletions = 256
+	// maxRetainedDroppedStores bounds the number of dropped stores whose series are retained to serve deltas with.
+	maxRetainedDroppedStores = 16
+)
+
+var (
+	// seriesRevision is the revision of the stores' series, incremented every time any object's series change.
+	seriesRevision atomic.Uint64
+	// dropped retains the series of the stores last dropped, e.g., as their monitors were reconciled, or deleted.
+	dropped = &droppedStores{}
+
+	errDeltaUnavailable = errors.New("series changes since the given revision are no longer retained, poll the full " +
+		"exposition since 0 instead")
+)
+
+// deletion holds the series of an object as of its deletion, per family, to be retained for deltas.
+type deletion struct {
+	revision       uint64
+	metricFamilies []string
+}
+
+// droppedStores retains the series of dropped stores, per family, so they are reported as deleted in deltas since
+// earlier revisions, without failing the deltas of all other stores.
+type droppedStores struct {
+	mutex       sync.RWMutex
+	dropped     []deletion
+	deltasSince uint64
+}
+
+// retain records the series of the given store, and its selected stores, if any, as dropped.
+func (d *droppedStores) retain(s *StoreType) {
+	var metricFamilies []string
+	collect := func(s *StoreType) {
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+		_ = s.metrics.forEach(func(_ types.UID, families []string) error {
+			for len(metricFamilies) < len(families) {
+				metricFamilies = append(metricFamilies, "")
+			}
+			for i, family := range families {
+				metricFamilies[i] += family
+			}
+
+			return nil
+		})
+	}
+	collect(s)
+	for _, selectedStore := range s.selectedStores() {
+		collect(selectedStore)
+	}
+	if len(metricFamilies) == 0 {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(d.dropped) == maxRetainedDroppedStores {
+		d.deltasSince = d.dropped[0].revision
+		d.dropped = append(d.dropped[:0], d.dropped[1:]...)
+	}
+	d.dropped = append(d.dropped, deletion{revision: nextRevision(), metricFamilies: metricFamilies})
+}
+
+// servesDeltaSince reports whether the series of all stores dropped since the given revision are retained.
+func (d *droppedStores) servesDeltaSince(since uint64) bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return since >= d.deltasSince
+}
+
+// writeDeletions writes out the series of all stores dropped since the given revision, as comments. They are written
+// ahead of all other series, and without headers, as the rebuilt stores may expose the same families, and series.
+func (d *droppedStores) writeDeletions(writer io.Writer, since uint64) error {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	write := func(series string) error { return writeMetricFamily(writer, series) }
+	for _, droppedStore := range d.dropped {
+		if droppedStore.revision <= since {
+			continue
+		}
+		for _, metricFamily := range droppedStore.metricFamilies {
+			if err := writeDeletedSeries(write, metricFamily); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// deltaWriter writes out the given header before the first series written out, if any, for deltas to only carry the
+// headers of the families any series changed for.
+type deltaWriter struct {
+	io.Writer
+	header  string
+	written bool
+}
+
+func (d *deltaWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 && !d.written {
+		d.written = true
+		if err := writeHeader(d.Writer, d.header); err != nil {
+			return 0, fmt.Errorf("error writing header: %w", err)
+		}
+	}
+
+	return d.Writer.Write(p)
+}
+
+// nextRevision increments, and returns, the stores' series revision.
+func nextRevision() uint64 {
+	return seriesRevision.Add(1)
+}
+
+// changed records that the given object's series changed. The caller must hold the store's lock.
+func (s *StoreType) changed(uid types.UID) {
+	s.revisions[uid] = nextRevision()
+}
+
+// deleted records that the given object's series, i.e., the given ones, were dropped, so they are reported in deltas
+// since earlier revisions. The caller must hold the store's lock.
+func (s *StoreType) deleted(uid types.UID, metricFamilies []string) {
+	delete(s.revisions, uid)
+	s.removed(metricFamilies)
+}
+
+// removed records that the given series, per family, were dropped, so they are reported in deltas since earlier
+// revisions. The caller must hold the store's lock.
+func (s *StoreType) removed(metricFamilies []string) {
+	if len(metricFamilies) == 0 {
+		return
+	}
+	if len(s.deletions) == maxRetainedDeletions {
+		s.deltasSince = s.deletions[0].revision
+		s.deletions = append(s.deletions[:0], s.deletions[1:]...)
+	}
+	s.deletions = append(s.deletions, deletion{revision: nextRevision(), metricFamilies: metricFamilies})
+}
+
+// resetDeltas drops the store's retained deletions, so deltas since earlier revisions are no longer served, e.g., once
+// series were dropped without being recorded as deleted. The caller must hold the store's lock.
+func (s *StoreType) resetDeltas() {
+	s.deletions = nil
+	s.deltasSince = nextRevision()
+}
+
+// servesDeltaSince reports whether the store, and its selected stores, if any, retain the changes to their series
+// since the given revision.
+func (s *StoreType) servesDeltaSince(since uint64) bool {
+	s.mutex.RLock()
+	serves := since >= s.deltasSince
+	selected := s.selectedStores()
+	s.mutex.RUnlock()
+	for _, selectedStore := range selected {
+		serves = serves && selectedStore.servesDeltaSince(since)
+	}
+
+	return serves
+}
+
+// writeDeletions writes out the series of the given family, for all objects deleted since the given revision, as
+// comments.
+func (s *StoreType) writeDeletions(writer func(string) error, family int, since uint64) error {
+	for _, d := range s.deletions {
+		if d.revision <= since || family >= len(d.metricFamilies) {
+			continue
+		}
+		if err := writeDeletedSeries(writer, d.metricFamilies[family]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDeletedSeries writes out each of the given series as a comment.
+func writeDeletedSeries(writer func(string) error, metricFamily string) error {
+	for _, series := range strings.Split(strings.TrimSuffix(metricFamily, "\n"), "\n") {
+		if series == "" {
+			continue
+		}
+		if err := writer(deletedPrefix + series + "\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removedSeries returns the given previous series of an object, per family, that are no longer present among its
+// given current ones, as identified by their names and label sets, or nil if none were removed. Series whose values
+// changed are not considered removed.
+func removedSeries(previous, current []string) []string {
+	var removed []string
+	for i, metricFamily := range previous {
+		present := sets.New[string]()
+		if i < len(current) {
+			for _, series := range strings.Split(current[i], "\n") {
+				present.Insert(seriesIdentity(series))
+			}
+		}
+		builder := &strings.Builder{}
+		for _, series := range strings.Split(strings.TrimSuffix(metricFamily, "\n"), "\n") {
+			if series == "" || present.Has(seriesIdentity(series)) {
+				continue
+			}
+			builder.WriteString(series)
+			builder.WriteByte('\n')
+		}
+		if builder.Len() == 0 {
+			continue
+		}
+		if removed == nil {
+			removed = make([]string, len(previous))
+		}
+		removed[i] = builder.String()
+	}
+
+	return removed
+}
+
+// deltaSince returns the revision deltas are to be computed since, off the given request, and the current one, or an
+// error if no delta can be served since the former.
+func deltaSince(r *http.Request, stores *sync.Map) (since uint64, revision uint64, err error) {
+	revision = seriesRevision.Load()
+	if value := r.URL.Query().Get(deltaSinceParameter); value != "" {
+		since, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid %q parameter: %w", deltaSinceParameter, err)
+		}
+	}
+	// A full exposition is served since 0.
+	if since == 0 {
+		return since, revision, nil
+	}
+
+	// Revisions are not persisted, and so are reset on restarts.
+	available := since <= revision && dropped.servesDeltaSince(since)
+	stores.Range(func(_, value any) bool {
+		builtStores, _ := value.([]*StoreType)
+		for _, s := range builtStores {
+			available = available && s.servesDeltaSince(since)
+		}
+
+		return available
+	})
+	if !available {
+		return 0, 0, errDeltaUnavailable
+	}
+
+	return since, revision, nil
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+func TestMetricsWriter_writeStores_delta(t *testing.T) {
+	t.Parallel()
+	var c configuration
+	if err := yaml.UnmarshalStrict([]byte(`stores:
+  - families:
+      - name: "replicas"
+        metrics:
+          - value: "spec.replicas"
+`), &c); err != nil {
+		t.Fatal(err)
+	}
+	cfg := c.Stores[0]
+	s := newStore(klog.Background(), []string{"# HELP kube_customresource_replicas"}, cfg.Families, ResolverTypeUnstructured, nil, nil, 0, 0)
+	objects := newSyntheticObjects(3)
+	for _, object := range objects {
+		if err := s.Add(object); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	delta := func(since uint64) string {
+		t.Helper()
+		if !s.servesDeltaSince(since) {
+			t.Fatalf("expected a delta to be served since %d", since)
+		}
+		buffer := &bytes.Buffer{}
+		writer := newMetricsWriter(s)
+		writer.since = since
+		if err := writer.writeStores(buffer); err != nil {
+			t.Fatal(err)
+		}
+
+		return buffer.String()
+	}
+	since := seriesRevision.Load()
+	if diff := cmp.Diff(delta(since), ""); diff != "" {
+		t.Errorf("unexpected delta without changes: %s", diff)
+	}
+
+	objects[1].Object["spec"] = map[string]interface{}{"replicas": int64(7)}
+	if err := s.Update(objects[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(objects[2]); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# HELP kube_customresource_replicas\n" +
+		"kube_customresource_replicas{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 7\n" +
+		"# DELETED kube_customresource_replicas{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 2\n"
+	if diff := cmp.Diff(delta(since), expected); diff != "" {
+		t.Errorf("unexpected delta: %s", diff)
+	}
+
+	s.mutex.Lock()
+	s.resetDeltas()
+	s.mutex.Unlock()
+	if s.servesDeltaSince(since) {
+		t.Errorf("expected no delta to be served since %d once reset", since)
+	}
+}
+
+func TestRemovedSeries(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		previous []string
+		current  []string
+		expected []string
+	}{
+		{
+			name:     "value changed",
+			previous: []string{"foo{a=\"1\"} 1\n"},
+			current:  []string{"foo{a=\"1\"} 2\n"},
+		},
+		{
+			name:     "series removed",
+			previous: []string{"foo{a=\"1\"} 1\nfoo{a=\"2\"} 1\n", "bar{a=\"1\"} 1\n"},
+			current:  []string{"foo{a=\"1\"} 1\n", "bar{a=\"1\"} 3\n"},
+			expected: []string{"foo{a=\"2\"} 1\n", ""},
+		},
+		{
+			name:     "family removed",
+			previous: []string{"foo{a=\"1\"} 1\n", "bar{a=\"1\"} 1\n"},
+			current:  []string{"foo{a=\"1\"} 1\n"},
+			expected: []string{"", "bar{a=\"1\"} 1\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if diff := cmp.Diff(removedSeries(tt.previous, tt.current), tt.expected); diff != "" {
+				t.Errorf("unexpected removed series: %s", diff)
+			}
+		})
+	}
+}
+
+func TestDroppedStores(t *testing.T) {
+	t.Parallel()
+	var c configuration
+	if err := yaml.UnmarshalStrict([]byte(`stores:
+  - families:
+      - name: "replicas"
+        metrics:
+          - value: "spec.replicas"
+`), &c); err != nil {
+		t.Fatal(err)
+	}
+	s := newStore(klog.Background(), []string{"# HELP kube_customresource_replicas"}, c.Stores[0].Families, ResolverTypeUnstructured, nil, nil, 0, 0)
+	if err := s.Add(newSyntheticObjects(1)[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &droppedStores{}
+	since := seriesRevision.Load()
+	d.retain(s)
+	buffer := &bytes.Buffer{}
+	if err := d.writeDeletions(buffer, since); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# DELETED kube_customresource_replicas{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 0\n"
+	if diff := cmp.Diff(buffer.String(), expected); diff != "" {
+		t.Errorf("unexpected deletions: %s", diff)
+	}
+	buffer.Reset()
+	if err := d.writeDeletions(buffer, seriesRevision.Load()); err != nil {
+		t.Fatal(err)
+	}
+	if buffer.Len() > 0 {
+		t.Errorf("expected no deletions since the drop, got %q", buffer.String())
+	}
+
+	// Deltas are served since the drops retained, but not since the ones no longer retained.
+	for range maxRetainedDroppedStores - 1 {
+		d.retain(s)
+	}
+	if !d.servesDeltaSince(since) {
+		t.Errorf("expected a delta to be served since %d", since)
+	}
+	d.retain(s)
+	if d.servesDeltaSince(since) {
+		t.Errorf("expected no delta to be served since %d once its drop is no longer retained", since)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import "strings"
+
+// droppedReason represents why a family's sample was dropped.
+type droppedReason string
+
+const (
+	// droppedReasonUnresolved drops samples whose value could not be resolved against the object, e.g., as the field
+	// is not set.
+	droppedReasonUnresolved droppedReason = "unresolved"
+	// droppedReasonInvalidValue drops samples whose resolved value is not a number, or could not be mapped to one.
+	droppedReasonInvalidValue droppedReason = "invalid_value"
+	// droppedReasonLabelMismatch drops samples whose label keys and resolved label values differ in length.
+	droppedReasonLabelMismatch droppedReason = "label_mismatch"
+	// droppedReasonInvalidLabel drops samples whose labels could not be written, e.g., label values that are not valid
+	// UTF-8 in the strict exposition mode.
+	droppedReasonInvalidLabel droppedReason = "invalid_label"
+	// droppedReasonNaN drops samples resolving to NaN, for families skipping them.
+	droppedReasonNaN droppedReason = "nan"
+	// droppedReasonSanitization drops samples whose label keys, once sanitized, are not valid label names, or collide
+	// with one another, or the group, version, and kind labels, e.g., `fooBar` and `foo_bar`.
+	droppedReasonSanitization droppedReason = "sanitization"
+	// droppedReasonDuplicate drops samples whose series an object already generated for the family, keeping the first.
+	droppedReasonDuplicate droppedReason = "duplicate"
+	// droppedReasonCardinalityLimit drops samples beyond the number of series each object may generate for the family.
+	droppedReasonCardinalityLimit droppedReason = "cardinality_limit"
+)
+
+// drop counts a sample of the family dropped for the given reason, if the family counts them.
+func (f *FamilyType) drop(reason droppedReason) {
+	if f.droppedSamples == nil {
+		return
+	}
+	f.droppedSamples.WithLabelValues(f.managedRMMNamespace, f.managedRMMName, f.Name, string(reason)).Inc()
+}
+
+// admit returns the given (rendered) family of an object without the series it already generated, unless the family
+// aggregates them across objects, and without the ones beyond the family's per-object limit, if any, counting both.
+func (f *FamilyType) admit(family string) string {
+	dedup := f.Aggregate == AggregateTypeNone
+	if family == "" || (!dedup && f.MaxSeriesPerObject == 0) {
+		return family
+	}
+	lines := strings.SplitAfter(family, "\n")
+	admitted := lines[:0]
+	seen := make(map[string]struct{}, len(lines))
+	for _, series := range lines {
+		if series == "" {
+			continue
+		}
+		if dedup {
+			identity := seriesIdentity(series)
+			if _, ok := seen[identity]; ok {
+				f.drop(droppedReasonDuplicate)
+
+				continue
+			}
+			seen[identity] = struct{}{}
+		}
+		if f.MaxSeriesPerObject > 0 && len(admitted) >= f.MaxSeriesPerObject {
+			f.drop(droppedReasonCardinalityLimit)
+
+			continue
+		}
+		admitted = append(admitted, series)
+	}
+
+	return strings.Join(admitted, "")
+}
+
+// seriesIdentity returns the given series' name and label set, without its value, or the exemplar following it.
+func seriesIdentity(series string) string {
+	name := strings.IndexAny(series, "{ ")
+	if name < 0 {
+		return series
+	}
+	if series[name] == '{' {
+		if end := labelSetEnd(series); end >= 0 {
+			return series[:end]
+		}
+	}
+
+	return series[:name]
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/klog/v2"
+)
+
+func TestFamilyType_drop(t *testing.T) {
+	t.Parallel()
+	object := newSyntheticObjects(1)[0]
+	object.Object["spec"].(map[string]interface{})["ratio"] = "NaN"
+	droppedSamples := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "dropped_samples_total"}, []string{"namespace", "name", "family", "reason"})
+	family := &FamilyType{
+		logger:              klog.Background(),
+		droppedSamples:      droppedSamples,
+		managedRMMNamespace: "default",
+		managedRMMName:      "rmm",
+		Name:                "replicas",
+		NaNPolicy:           NaNPolicySkip,
+		Metrics: []*MetricType{
+			{Value: "spec.replicas"},
+			{Value: "spec.missing"},
+			{Value: "metadata.name"},
+			{Value: "spec.ratio"},
+			{Value: "spec.replicas", ValueMap: map[string]float64{"1": 1}},
+		},
+	}
+	family.buildMetricString(object)
+
+	for reason, expected := range map[droppedReason]float64{
+		droppedReasonUnresolved:    1,
+		droppedReasonInvalidValue:  2,
+		droppedReasonNaN:           1,
+		droppedReasonLabelMismatch: 0,
+	} {
+		if got := testutil.ToFloat64(droppedSamples.WithLabelValues("default", "rmm", "replicas", string(reason))); got != expected {
+			t.Errorf("expected %v samples dropped as %s, got %v", expected, reason, got)
+		}
+	}
+}
+
+func TestFamilyType_admit(t *testing.T) {
+	t.Parallel()
+	object := newSyntheticObjects(1)[0]
+	droppedSamples := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "dropped_samples_total"}, []string{"namespace", "name", "family", "reason"})
+	family := &FamilyType{
+		logger:              klog.Background(),
+		droppedSamples:      droppedSamples,
+		managedRMMNamespace: "default",
+		managedRMMName:      "rmm",
+		Name:                "replicas",
+		MaxSeriesPerObject:  2,
+		Metrics: []*MetricType{
+			{Value: "spec.replicas"},
+			{Value: "spec.replicas"},
+			{LabelKeys: []string{"fooBar", "foo_bar"}, LabelValues: []string{"metadata.name", "metadata.name"}, Value: "spec.replicas"},
+			{LabelKeys: []string{"kind"}, LabelValues: []string{"metadata.name"}, Value: "spec.replicas"},
+			{LabelKeys: []string{"name"}, LabelValues: []string{"metadata.name"}, Value: "spec.replicas"},
+			{LabelKeys: []string{"namespace"}, LabelValues: []string{"metadata.namespace"}, Value: "spec.replicas"},
+		},
+	}
+	got := family.buildMetricString(object)
+	if lines := strings.Count(got, "\n"); lines != 2 {
+		t.Errorf("expected 2 series to be admitted, got %d:\n%s", lines, got)
+	}
+
+	for reason, expected := range map[droppedReason]float64{
+		droppedReasonSanitization:     2,
+		droppedReasonDuplicate:        1,
+		droppedReasonCardinalityLimit: 1,
+	} {
+		if got := testutil.ToFloat64(droppedSamples.WithLabelValues("default", "rmm", "replicas", string(reason))); got != expected {
+			t.Errorf("expected %v samples dropped as %s, got %v", expected, reason, got)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+// eachMapDefaultLabel is the label holding the entries' keys, unless configured otherwise.
+const eachMapDefaultLabel = "key"
+
+// EachMapType walks a map field of the objects, e.g., status.capacity, exposing a sample for each of its entries,
+// labeled with the entry's key, and valued after the entry's value, much like kube-state-metrics' custom resource state
+// `each: {path: ..., labelFromKey: ...}`.
+type EachMapType struct {
+	// Path is the dot-separated path to the map field.
+	Path string `yaml:"path"`
+	// LabelFromKey is the label holding the entries' keys, and defaults to "key".
+	LabelFromKey string `yaml:"labelFromKey,omitempty"`
+}
+
+// validate checks that the map field's path is set.
+func (e *EachMapType) validate(kind FamilyKind) error {
+	if e == nil {
+		return nil
+	}
+	if e.Path == "" {
+		return errors.New("path must be set")
+	}
+	if kind == FamilyKindHistogram {
+		return errors.New("cannot be used in histogram families")
+	}
+
+	return nil
+}
+
+// buildEachMapString writes a sample for each entry of the metric's map field, in the order of their keys, in place of
+// the metric's value. Entries with composite, or non-numeric, values are skipped.
+func (f *FamilyType) buildEachMapString(builder *strings.Builder, metric *MetricType, u *unstructured.Unstructured, keys, values []string, expanded map[string][]string, logger klog.Logger) error {
+	entries, found, err := unstructured.NestedMap(u.Object, strings.Split(strings.TrimPrefix(metric.EachMap.Path, "."), ".")...)
+	if err != nil {
+		return fmt.Errorf("error resolving map %q: %w", metric.EachMap.Path, err)
+	}
+	if !found {
+		return nil
+	}
+	label := metric.EachMap.LabelFromKey
+	if label == "" {
+		label = eachMapDefaultLabel
+	}
+	for _, key := range slices.Sorted(maps.Keys(entries)) {
+		// Value maps, if any, map the entries' values as is, e.g., {"Ready": 1}.
+		var value string
+		if raw, ok := entries[key].(string); ok && metric.ValueMap != nil {
+			value, err = metric.mapValue(raw)
+		} else {
+			value, err = entryValue(entries[key])
+		}
+		if err != nil {
+			logger.V(1).Error(fmt.Errorf("error resolving entry %q of map %q: %w", key, metric.EachMap.Path, err), "skipping")
+			f.drop(droppedReasonInvalidValue)
+
+			continue
+		}
+		entryKeys, entryValues := append(slices.Clone(keys), sanitizeKey(label)), append(slices.Clone(values), key)
+		sortLabels(entryKeys, entryValues)
+		if err = f.writeMetricSamples(builder, u, entryKeys, entryValues, maps.Clone(expanded), value, logger); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// entryValue returns the given map entry's value, as a number, or as a Kubernetes quantity, e.g., "4Gi", or "500m".
+func entryValue(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case int64, float64, bool:
+		return fmt.Sprintf("%v", value), nil
+	case string:
+		if _, err := parseValue(value); err == nil {
+			return value, nil
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return "", fmt.Errorf("error parsing %q as a number, or a quantity: %w", value, err)
+		}
+
+		return strconv.FormatFloat(quantity.AsApproximateFloat64(), 'g', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v", value)
+	}
+}
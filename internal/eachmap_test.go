@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/klog/v2"
+)
+
+func TestFamilyType_eachMap(t *testing.T) {
+	t.Parallel()
+	object := newSyntheticObjects(1)[0]
+	object.Object["status"].(map[string]interface{})["capacity"] = map[string]interface{}{
+		"cpu":    "500m",
+		"memory": "4Gi",
+		"pods":   int64(110),
+		"nested": map[string]interface{}{"foo": "bar"},
+		"bogus":  "foo",
+	}
+	family := &FamilyType{
+		logger: klog.Background(),
+		Name:   "capacity",
+		Metrics: []*MetricType{
+			{
+				LabelKeys:   []string{"name"},
+				LabelValues: []string{"metadata.name"},
+				EachMap:     &EachMapType{Path: "status.capacity", LabelFromKey: "resource"},
+			},
+			{
+				EachMap: &EachMapType{Path: "status.missing"},
+			},
+		},
+	}
+
+	expected := "kube_customresource_capacity{name=\"bar-0\",resource=\"cpu\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 0.5\n" +
+		"kube_customresource_capacity{name=\"bar-0\",resource=\"memory\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 4.294967296e+09\n" +
+		"kube_customresource_capacity{name=\"bar-0\",resource=\"pods\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 110\n"
+	if diff := cmp.Diff(expected, family.buildMetricString(object)); diff != "" {
+		t.Errorf("unexpected exposition (-want +got):\n%s", diff)
+	}
+}
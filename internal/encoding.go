@@ -0,0 +1,228 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// jsonLinesContentType is the content type of expositions encoded as JSON lines, one object per sample.
+	jsonLinesContentType = "application/jsonl"
+	// jsonLinesAltContentType is the content type some clients request JSON lines by.
+	jsonLinesAltContentType = "application/x-ndjson"
+	// influxContentType is the content type of expositions encoded in the Influx line protocol.
+	influxContentType = "text/plain; charset=utf-8"
+)
+
+// encoder renders the stores' series, as parsed off the Prometheus text format, in another format, so the same store
+// content may be polled by consumers other than Prometheus.
+type encoder interface {
+	// contentType returns the content type of the encoded exposition.
+	contentType() string
+	// encode writes the samples of the given metric families out.
+	encode(writer io.Writer, metricFamilies []*dto.MetricFamily) error
+}
+
+// encoders holds the encoders by the extensions of the paths they are served on, e.g., /metrics.json.
+var encoders = map[string]encoder{
+	"json":   jsonLinesEncoder{},
+	"influx": influxEncoder{},
+}
+
+// scrapeFormat represents the format a scrape negotiated the stores' series in.
+type scrapeFormat struct {
+	// openMetrics reports whether the exposition is OpenMetrics, which, unlike the text format, carries exemplars.
+	openMetrics bool
+	// encoder, if set, re-encodes the exposition, as rendered in the Prometheus text format, instead.
+	encoder encoder
+}
+
+// negotiateEncoder returns the encoder the given request asks for, through the extension of the path it was routed by,
+// e.g., /metrics.json, or, failing that, its Accept header, if any. The Influx line protocol is only served by path, as
+// its content type is not distinct.
+func negotiateEncoder(r *http.Request) encoder {
+	if extension, ok := strings.CutPrefix(r.Pattern, "/metrics."); ok {
+		return encoders[extension]
+	}
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		if mediaType == jsonLinesContentType || mediaType == jsonLinesAltContentType {
+			return encoders["json"]
+		}
+	}
+
+	return nil
+}
+
+// encodeWith renders the exposition the given function writes in the Prometheus text format, in the given encoder's
+// format instead.
+func encodeWith(writer io.Writer, enc encoder, write func(io.Writer) error) error {
+	buffer := &bytes.Buffer{}
+	if err := write(buffer); err != nil {
+		return err
+	}
+	parser := expfmt.TextParser{}
+	parsed, err := parser.TextToMetricFamilies(buffer)
+	if err != nil {
+		return fmt.Errorf("error parsing exposition: %w", err)
+	}
+	metricFamilies := make([]*dto.MetricFamily, 0, len(parsed))
+	for _, name := range slices.Sorted(maps.Keys(parsed)) {
+		metricFamilies = append(metricFamilies, parsed[name])
+	}
+
+	return enc.encode(writer, metricFamilies)
+}
+
+// sample represents a single sample of a metric family, with histograms flattened into their series, as in the
+// Prometheus text format.
+type sample struct {
+	name   string
+	labels []*dto.LabelPair
+	value  float64
+}
+
+// samplesOf returns the samples of the given metric family, in order.
+func samplesOf(metricFamily *dto.MetricFamily) []sample {
+	var samples []sample
+	for _, metric := range metricFamily.GetMetric() {
+		labels := metric.GetLabel()
+		switch {
+		case metric.GetHistogram() != nil:
+			histogram := metric.GetHistogram()
+			for _, bucket := range histogram.GetBucket() {
+				le := &dto.LabelPair{Name: ptr.To(histogramBucketLabel), Value: ptr.To(strconv.FormatFloat(bucket.GetUpperBound(), 'g', -1, 64))}
+				samples = append(samples, sample{name: metricFamily.GetName() + "_bucket", labels: append(slices.Clone(labels), le), value: float64(bucket.GetCumulativeCount())})
+			}
+			samples = append(samples,
+				sample{name: metricFamily.GetName() + "_sum", labels: labels, value: histogram.GetSampleSum()},
+				sample{name: metricFamily.GetName() + "_count", labels: labels, value: float64(histogram.GetSampleCount())},
+			)
+		case metric.GetGauge() != nil:
+			samples = append(samples, sample{name: metricFamily.GetName(), labels: labels, value: metric.GetGauge().GetValue()})
+		case metric.GetCounter() != nil:
+			samples = append(samples, sample{name: metricFamily.GetName(), labels: labels, value: metric.GetCounter().GetValue()})
+		case metric.GetUntyped() != nil:
+			samples = append(samples, sample{name: metricFamily.GetName(), labels: labels, value: metric.GetUntyped().GetValue()})
+		}
+	}
+
+	return samples
+}
+
+// jsonLinesEncoder encodes each sample as a JSON object on its own line, e.g.,
+// {"name":"kube_customresource_replicas","labels":{"name":"foo"},"value":1}. Non-finite values are encoded as strings,
+// i.e., "NaN", "+Inf", or "-Inf", as JSON has no representation for them.
+type jsonLinesEncoder struct{}
+
+// contentType returns the content type of JSON lines.
+func (jsonLinesEncoder) contentType() string {
+	return jsonLinesContentType
+}
+
+// encode writes the samples of the given metric families out as JSON lines.
+func (jsonLinesEncoder) encode(writer io.Writer, metricFamilies []*dto.MetricFamily) error {
+	jsonEncoder := json.NewEncoder(writer)
+	for _, metricFamily := range metricFamilies {
+		for _, s := range samplesOf(metricFamily) {
+			labels := make(map[string]string, len(s.labels))
+			for _, label := range s.labels {
+				labels[label.GetName()] = label.GetValue()
+			}
+			var value interface{} = s.value
+			if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+				value = formatSpecialFloat(s.value)
+			}
+			if err := jsonEncoder.Encode(map[string]interface{}{"name": s.name, "labels": labels, "value": value}); err != nil {
+				return fmt.Errorf("error encoding sample of %q: %w", s.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// influxEncoder encodes each sample as a point in the Influx line protocol, measured by the sample's name, tagged with
+// its labels, and carrying its value in the "value" field, e.g., kube_customresource_replicas,name=foo value=1. Samples
+// with non-finite values, which the protocol has no representation for, and labels with empty values, which it rejects,
+// are left out.
+type influxEncoder struct{}
+
+// contentType returns the content type of the Influx line protocol.
+func (influxEncoder) contentType() string {
+	return influxContentType
+}
+
+// influxEscaper escapes the characters the Influx line protocol delimits measurements, tags, and fields by.
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// encode writes the samples of the given metric families out in the Influx line protocol.
+func (influxEncoder) encode(writer io.Writer, metricFamilies []*dto.MetricFamily) error {
+	for _, metricFamily := range metricFamilies {
+		for _, s := range samplesOf(metricFamily) {
+			if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+				continue
+			}
+			line := strings.Builder{}
+			line.WriteString(influxEscaper.Replace(s.name))
+			labels := slices.Clone(s.labels)
+			slices.SortFunc(labels, func(a, b *dto.LabelPair) int { return strings.Compare(a.GetName(), b.GetName()) })
+			for _, label := range labels {
+				if label.GetValue() == "" {
+					continue
+				}
+				line.WriteString("," + influxEscaper.Replace(label.GetName()) + "=" + influxEscaper.Replace(label.GetValue()))
+			}
+			line.WriteString(" value=" + strconv.FormatFloat(s.value, 'g', -1, 64) + "\n")
+			if _, err := io.WriteString(writer, line.String()); err != nil {
+				return fmt.Errorf("error writing sample of %q: %w", s.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatSpecialFloat formats the given non-finite value as the Prometheus text format does.
+func formatSpecialFloat(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	default:
+		return "-Inf"
+	}
+}
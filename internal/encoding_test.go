@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEncoders(t *testing.T) {
+	t.Parallel()
+	exposition := "# HELP kube_customresource_replicas replicas\n" +
+		"# TYPE kube_customresource_replicas gauge\n" +
+		"kube_customresource_replicas{name=\"foo bar\",namespace=\"\",kind=\"Bar\"} 1\n" +
+		"kube_customresource_replicas{name=\"baz\",namespace=\"default\",kind=\"Bar\"} NaN\n" +
+		"# HELP kube_customresource_duration duration\n" +
+		"# TYPE kube_customresource_duration histogram\n" +
+		"kube_customresource_duration_bucket{name=\"foo\",le=\"1\"} 1\n" +
+		"kube_customresource_duration_bucket{name=\"foo\",le=\"+Inf\"} 2\n" +
+		"kube_customresource_duration_sum{name=\"foo\"} 3.5\n" +
+		"kube_customresource_duration_count{name=\"foo\"} 2\n"
+	tests := []struct {
+		name     string
+		encoder  encoder
+		expected string
+	}{
+		{
+			name:    "JSON lines",
+			encoder: jsonLinesEncoder{},
+			expected: `{"labels":{"le":"1","name":"foo"},"name":"kube_customresource_duration_bucket","value":1}` + "\n" +
+				`{"labels":{"le":"+Inf","name":"foo"},"name":"kube_customresource_duration_bucket","value":2}` + "\n" +
+				`{"labels":{"name":"foo"},"name":"kube_customresource_duration_sum","value":3.5}` + "\n" +
+				`{"labels":{"name":"foo"},"name":"kube_customresource_duration_count","value":2}` + "\n" +
+				`{"labels":{"kind":"Bar","name":"foo bar","namespace":""},"name":"kube_customresource_replicas","value":1}` + "\n" +
+				`{"labels":{"kind":"Bar","name":"baz","namespace":"default"},"name":"kube_customresource_replicas","value":"NaN"}` + "\n",
+		},
+		{
+			name:    "Influx line protocol",
+			encoder: influxEncoder{},
+			expected: "kube_customresource_duration_bucket,le=1,name=foo value=1\n" +
+				"kube_customresource_duration_bucket,le=+Inf,name=foo value=2\n" +
+				"kube_customresource_duration_sum,name=foo value=3.5\n" +
+				"kube_customresource_duration_count,name=foo value=2\n" +
+				"kube_customresource_replicas,kind=Bar,name=foo\\ bar value=1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			buffer := &bytes.Buffer{}
+			err := encodeWith(buffer, tt.encoder, func(writer io.Writer) error {
+				_, err := io.WriteString(writer, exposition)
+
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expected, buffer.String()); diff != "" {
+				t.Errorf("unexpected encoding (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNegotiateEncoder(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		pattern  string
+		accept   string
+		expected encoder
+	}{
+		{name: "Prometheus", pattern: "/metrics", accept: "text/plain"},
+		{name: "JSON lines by path", pattern: "/metrics.json", expected: jsonLinesEncoder{}},
+		{name: "Influx line protocol by path", pattern: "/metrics.influx", expected: influxEncoder{}},
+		{name: "JSON lines by Accept header", pattern: "/metrics/{name}", accept: "application/x-ndjson;q=0.9, text/plain", expected: jsonLinesEncoder{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := &http.Request{Pattern: tt.pattern, Header: http.Header{"Accept": []string{tt.accept}}}
+			if got := negotiateEncoder(r); got != tt.expected {
+				t.Errorf("expected encoder %T, got %T", tt.expected, got)
+			}
+		})
+	}
+}
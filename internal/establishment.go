@@ -0,0 +1,325 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+// establishment tracks the targets a monitor's stores are deferred on, until their CRDs are established, as well as the
+// ones listed in a version other than the configured one, as their CRDs no longer serve it, and reports them whenever
+// they change.
+type establishment struct {
+	mutex    sync.Mutex
+	deferred sets.Set[string]
+	// fallbacks holds the versions targets are listed in, in place of their configured ones, by the targets.
+	fallbacks map[string]string
+	report    func(deferred, fallbacks []string)
+}
+
+// newEstablishment returns an establishment reporting the deferred targets, and the ones falling back to other
+// versions, to the given function.
+func newEstablishment(report func(deferred, fallbacks []string)) *establishment {
+	return &establishment{deferred: sets.New[string](), fallbacks: map[string]string{}, report: report}
+}
+
+// set records whether the given target is established, and the version it is listed in, if other than the configured
+// one, reporting the targets if that changed.
+func (e *establishment) set(target string, established bool, fallback string) {
+	if e == nil {
+		return
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if established != e.deferred.Has(target) && fallback == e.fallbacks[target] {
+		return
+	}
+	if established {
+		e.deferred.Delete(target)
+	} else {
+		e.deferred.Insert(target)
+	}
+	if fallback == "" {
+		delete(e.fallbacks, target)
+	} else {
+		e.fallbacks[target] = fallback
+	}
+	fallbacks := make([]string, 0, len(e.fallbacks))
+	for fallbackTarget, version := range e.fallbacks {
+		fallbacks = append(fallbacks, fallbackTarget+" ("+version+")")
+	}
+	slices.Sort(fallbacks)
+	// Report under the lock, so concurrent changes are reported in order.
+	e.report(sets.List(e.deferred), fallbacks)
+}
+
+// crdGate defers the reflector of a store targeting a custom resource in a single cluster once the resource is not
+// found, i.e., its CRD is not (yet) established, and resumes it as soon as the CRD is established, instead of having
+// the reflector spin on errors in the meantime. If the CRD no longer serves the targeted version, e.g., once it is
+// bumped, the reflector is resumed in the version the CRD prefers instead, with the API server converting the objects.
+type crdGate struct {
+	ctx              context.Context
+	cluster          string
+	dynamicClientset dynamic.Interface
+	store            *StoreType
+	gvkWithR         gvkr
+	// newLW returns the lister-watcher for the given version of the target.
+	newLW         func(gvr schema.GroupVersionResource) *cache.ListWatch
+	establishment *establishment
+	// listed is the version of the target its reflector lists, i.e., the targeted one, unless falling back.
+	listed gvkr
+
+	mutex sync.Mutex
+	// stopTarget stops the target's reflector, while it is running.
+	stopTarget context.CancelFunc
+	// stopCRD stops the CRD's reflector, while the target's reflector is deferred.
+	stopCRD context.CancelFunc
+}
+
+// Ensure crdGate implements cache.Store.
+var _ cache.Store = &crdGate{}
+
+// startGatedReflector starts the reflector for the given store's target in the given cluster, listed and watched
+// through the lister-watchers returned by the given function, gated by the target's CRD.
+func startGatedReflector(
+	ctx context.Context,
+	cluster string,
+	dynamicClientset dynamic.Interface,
+	newLW func(gvr schema.GroupVersionResource) *cache.ListWatch,
+	gvkWithR gvkr,
+	s *StoreType,
+	e *establishment,
+) {
+	g := &crdGate{
+		ctx:              ctx,
+		cluster:          cluster,
+		dynamicClientset: dynamicClientset,
+		store:            s,
+		gvkWithR:         gvkWithR,
+		newLW:            newLW,
+		establishment:    e,
+		listed:           gvkWithR,
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.startTarget()
+}
+
+// crdName returns the name of the target's CRD.
+func (g *crdGate) crdName() string {
+	return g.gvkWithR.GroupVersionResource.GroupResource().String()
+}
+
+// target returns the name the target is reported by, qualified by the cluster, if federated.
+func (g *crdGate) target() string {
+	if g.cluster == localCluster {
+		return g.crdName()
+	}
+
+	return g.cluster + "/" + g.crdName()
+}
+
+// startTarget starts the target's reflector, listing its current version. The caller must hold the gate's lock.
+func (g *crdGate) startTarget() {
+	lw := g.newLW(g.listed.GroupVersionResource)
+	listFunc := lw.ListFunc
+	lw.ListFunc = func(options metav1.ListOptions) (runtime.Object, error) {
+		o, err := listFunc(options)
+		if apierrors.IsNotFound(err) {
+			g.deferTarget()
+		}
+
+		return o, err
+	}
+	ctx, cancel := context.WithCancel(g.ctx)
+	g.stopTarget = cancel
+	startReflector(ctx, lw, g.listed, g.store.forCluster(g.cluster))
+}
+
+// deferTarget stops the target's reflector, and watches its CRD until it is established.
+func (g *crdGate) deferTarget() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.stopCRD != nil {
+		return
+	}
+	if g.stopTarget != nil {
+		g.stopTarget()
+		g.stopTarget = nil
+	}
+	ctx, cancel := context.WithCancel(g.ctx)
+	g.stopCRD = cancel
+	fieldSelector := "metadata.name=" + g.crdName()
+	startReflector(ctx, buildLW(ctx, g.dynamicClientset, metav1.NamespaceAll, "", fieldSelector, crdGVKR.GroupVersionResource, nil), crdGVKR, g)
+	g.store.logger.V(1).Info("Deferred until the target's CRD is established", "crd", g.crdName(), "cluster", g.cluster)
+	g.establishment.set(g.target(), false, "")
+}
+
+// resumeTarget stops watching the target's CRD, and restarts the target's reflector, listing the given version of the
+// target.
+func (g *crdGate) resumeTarget(listed gvkr) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.stopCRD == nil {
+		return
+	}
+	g.stopCRD()
+	g.stopCRD = nil
+	g.listed = listed
+	g.startTarget()
+	var fallback string
+	if listed.GroupVersionResource.Version != g.gvkWithR.GroupVersionResource.Version {
+		fallback = fmt.Sprintf("%s is not served, listing %s", g.gvkWithR.GroupVersionResource.Version, listed.GroupVersionResource.Version)
+		g.store.logger.Info("Falling back to a served version of the target", "crd", g.crdName(), "cluster", g.cluster, "version", g.gvkWithR.GroupVersionResource.Version, "fallback", listed.GroupVersionResource.Version)
+	}
+	g.store.logger.V(1).Info("Resumed as the target's CRD is established", "crd", g.crdName(), "cluster", g.cluster)
+	g.establishment.set(g.target(), true, fallback)
+}
+
+// Add resumes the target's reflector if the given CRD is the target's, and is established.
+func (g *crdGate) Add(objectI interface{}) error {
+	crd, err := convertToUnstructured(objectI)
+	if err != nil {
+		return err
+	}
+	if crd.GetName() != g.crdName() {
+		return nil
+	}
+	if listed, ok := g.served(crd); ok {
+		g.resumeTarget(listed)
+	}
+
+	return nil
+}
+
+// Update is called when the target's CRD is updated, which may establish it.
+func (g *crdGate) Update(objectI interface{}) error {
+	return g.Add(objectI)
+}
+
+// Delete is called when the target's CRD is deleted, in which case the target's reflector remains deferred.
+func (g *crdGate) Delete(_ interface{}) error { return nil }
+
+// Replace is called when the reflector lists the target's CRD, if it exists.
+func (g *crdGate) Replace(items []interface{}, _ string) error {
+	for _, item := range items {
+		if err := g.Add(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// List is not needed for our use case, so it returns nil.
+func (g *crdGate) List() []interface{} { return nil }
+
+// ListKeys is not needed for our use case, so it returns nil.
+func (g *crdGate) ListKeys() []string { return nil }
+
+// Get is not needed for our use case, so it returns nil and false.
+func (g *crdGate) Get(_ interface{}) (interface{}, bool, error) { return nil, false, nil }
+
+// GetByKey is not needed for our use case, so it returns nil and false.
+func (g *crdGate) GetByKey(_ string) (interface{}, bool, error) { return nil, false, nil }
+
+// Resync is not needed for our use case, so it does nothing and returns nil.
+func (g *crdGate) Resync() error { return nil }
+
+// served returns the version of the target to list, if the given CRD is established, i.e., the targeted version, if the
+// CRD serves it, or the one it prefers otherwise, with the objects converted by the API server.
+func (g *crdGate) served(crd *unstructured.Unstructured) (gvkr, bool) {
+	if !g.established(crd) {
+		return gvkr{}, false
+	}
+	if g.serves(crd) {
+		return g.gvkWithR, true
+	}
+
+	return selectedGVKR(crd)
+}
+
+// established reports whether the given CRD is established.
+func (g *crdGate) established(crd *unstructured.Unstructured) bool {
+	return crdEstablished(crd)
+}
+
+// crdEstablished reports whether the given CRD is established.
+func crdEstablished(crd *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	var established bool
+	for _, conditionI := range conditions {
+		condition, ok := conditionI.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Established" && condition["status"] == string(metav1.ConditionTrue) {
+			established = true
+		}
+	}
+
+	return established
+}
+
+// serves reports whether the given CRD serves the target's version.
+func (g *crdGate) serves(crd *unstructured.Unstructured) bool {
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	for _, versionI := range versions {
+		crdVersion, ok := versionI.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(crdVersion, "name")
+		served, _, _ := unstructured.NestedBool(crdVersion, "served")
+		if name == g.gvkWithR.GroupVersionResource.Version && served {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deferredMessage returns a human-readable summary of the given deferred targets, and the ones falling back to other
+// versions.
+func deferredMessage(deferred, fallbacks []string) string {
+	message := "All targeted CRDs are established"
+	if len(deferred) > 0 {
+		message = fmt.Sprintf("Stores deferred until their targeted CRDs are established: %v", deferred)
+	}
+	if len(fallbacks) > 0 {
+		message += fmt.Sprintf("; stores falling back to served versions: %v", fallbacks)
+	}
+
+	return message
+}
@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestCRDGate(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gvr := schema.GroupVersionResource{Group: "contoso.com", Version: "v1alpha1", Resource: "bars"}
+	// The object as served in the version the CRD is bumped to.
+	bumped := newSyntheticObjects(1)[0]
+	bumped.SetAPIVersion("contoso.com/v1")
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crdGVKR.GroupVersionResource:          "CustomResourceDefinitionList",
+		gvr:                                   "BarList",
+		gvr.GroupResource().WithVersion("v1"): "BarList",
+	}, newSyntheticObjects(1)[0], bumped)
+	// The target is not found until its CRD is installed, as is the case with the API server.
+	var installed atomic.Bool
+	client.PrependReactor("list", "bars", func(clienttesting.Action) (bool, runtime.Object, error) {
+		if installed.Load() {
+			return false, nil, nil
+		}
+
+		return true, nil, apierrors.NewNotFound(gvr.GroupResource(), "")
+	})
+
+	var mutex sync.Mutex
+	var reports [][2][]string
+	reported := func(deferred, fallbacks []string) {
+		t.Helper()
+		err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			return len(reports) > 0 && slices.Equal(reports[len(reports)-1][0], deferred) && slices.Equal(reports[len(reports)-1][1], fallbacks), nil
+		})
+		if err != nil {
+			t.Fatalf("expected %v and %v to be reported, got %v: %v", deferred, fallbacks, reports, err)
+		}
+	}
+
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "gated", Namespace: "default"}}
+	c := newConfigurer(client, resource, 0, 0, nil)
+	c.establishment = newEstablishment(func(deferred, fallbacks []string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		reports = append(reports, [2][]string{deferred, fallbacks})
+	})
+	if err := c.parse(`stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    families:
+      - name: "replicas"
+        metrics:
+          - value: "spec.replicas"
+`); err != nil {
+		t.Fatal(err)
+	}
+	stores := &sync.Map{}
+	c.build(ctx, stores)
+	value, _ := stores.Load(storesKey(resource))
+	builtStores, _ := value.([]*StoreType)
+
+	// The store is deferred while its target is not found.
+	reported([]string{"bars.contoso.com"}, []string{})
+
+	// Installing a CRD that is not established does not resume the store.
+	installed.Store(true)
+	crd := newTestCRD("bars.contoso.com", "contoso.com", "Bar", "bars", nil, map[string]interface{}{"name": "v1", "served": true, "storage": true})
+	crd, err := client.Resource(crdGVKR.GroupVersionResource).Create(ctx, crd, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	reported([]string{"bars.contoso.com"}, []string{})
+
+	// Establishing a CRD that no longer serves the target's version resumes the store in the one it does.
+	crd.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Established", "status": "True"}},
+	}
+	if _, err = client.Resource(crdGVKR.GroupVersionResource).Update(ctx, crd, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	reported([]string{}, []string{"bars.contoso.com (v1alpha1 is not served, listing v1)"})
+	expected := "# HELP kube_customresource_replicas Value of spec.replicas of contoso.com/v1alpha1 Bar objects.\n" +
+		"# TYPE kube_customresource_replicas gauge\n" +
+		"kube_customresource_replicas{group=\"contoso.com\",version=\"v1\",kind=\"Bar\"} 0\n"
+	var got string
+	err = wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		buffer := &bytes.Buffer{}
+		if err := newMetricsWriter(builtStores...).writeStores(buffer); err != nil {
+			return false, err
+		}
+		got = buffer.String()
+
+		return got == expected, nil
+	})
+	if err != nil {
+		t.Fatalf("expected exposition %q, got %q: %v", expected, got, err)
+	}
+}
+
+func TestCRDGate_served(t *testing.T) {
+	t.Parallel()
+	target := gvkr{
+		GroupVersionKind:     schema.GroupVersionKind{Group: "contoso.com", Version: "v1alpha1", Kind: "Bar"},
+		GroupVersionResource: schema.GroupVersionResource{Group: "contoso.com", Version: "v1alpha1", Resource: "bars"},
+	}
+	established := map[string]interface{}{
+		"conditions": []interface{}{map[string]interface{}{"type": "Established", "status": "True"}},
+	}
+	tests := []struct {
+		name        string
+		versions    []interface{}
+		established bool
+		expected    string
+		ok          bool
+	}{
+		{
+			name: "targeted version served",
+			versions: []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "served": true, "storage": false},
+				map[string]interface{}{"name": "v1", "served": true, "storage": true},
+			},
+			established: true,
+			expected:    "v1alpha1",
+			ok:          true,
+		},
+		{
+			name: "targeted version not served",
+			versions: []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "served": false, "storage": false},
+				map[string]interface{}{"name": "v1", "served": true, "storage": true},
+			},
+			established: true,
+			expected:    "v1",
+			ok:          true,
+		},
+		{
+			name: "not established",
+			versions: []interface{}{
+				map[string]interface{}{"name": "v1alpha1", "served": true, "storage": true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			crd := newTestCRD("bars.contoso.com", "contoso.com", "Bar", "bars", nil, tt.versions...)
+			if tt.established {
+				crd.Object["status"] = established
+			}
+			g := &crdGate{gvkWithR: target}
+			got, ok := g.served(crd)
+			if ok != tt.ok || got.GroupVersionResource.Version != tt.expected {
+				t.Errorf("expected version %q (%t), got %q (%t)", tt.expected, tt.ok, got.GroupVersionResource.Version, ok)
+			}
+		})
+	}
+}
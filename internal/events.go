@@ -21,15 +21,19 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rexagod/resource-state-metrics/internal/version"
 	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
 )
 
 type eventType int
@@ -47,6 +51,33 @@ func (e eventType) String() string {
 func (c *Controller) handleEvent(ctx context.Context, stores *sync.Map, event string, o metav1.Object) error {
 	logger := klog.FromContext(ctx)
 
+	// The resource no longer exists on the cluster, so there is nothing to validate or report back on.
+	if event == deleteEvent.String() {
+		resource, ok := o.(*v1alpha1.ResourceMetricsMonitor)
+		if !ok {
+			logger.Error(errors.New("failed to cast object to ResourceMetricsMonitor"), "cannot handle event")
+
+			return nil
+		}
+		_ = c.processDelete(stores, resource)
+		c.eventsProcessed.WithLabelValues(resource.GetNamespace(), resource.GetName(), event, "success").Inc()
+
+		return nil
+	}
+
+	// The resource is being deleted, so everything derived from it is cleaned up before its finalizer is removed.
+	if resource, ok := o.(*v1alpha1.ResourceMetricsMonitor); ok && resource.GetDeletionTimestamp() != nil {
+		if err := c.finalize(ctx, stores, resource); err != nil {
+			logger.Error(err, "finalization failed")
+			c.eventsProcessed.WithLabelValues(resource.GetNamespace(), resource.GetName(), event, "failed").Inc()
+
+			return err
+		}
+		c.eventsProcessed.WithLabelValues(resource.GetNamespace(), resource.GetName(), event, "success").Inc()
+
+		return nil
+	}
+
 	resource, err := c.validateAndPrepareResource(ctx, o, event)
 	if err != nil {
 		logger.Error(err, "resource validation and preparation failed")
@@ -54,15 +85,26 @@ func (c *Controller) handleEvent(ctx context.Context, stores *sync.Map, event st
 
 		return nil
 	}
+	traceStep(ctx, "Prepared monitor")
 
 	if err := c.processEvent(ctx, stores, event, resource); err != nil {
 		logger.Error(err, "event processing failed")
 		c.eventsProcessed.WithLabelValues(resource.GetNamespace(), resource.GetName(), event, "failed").Inc()
 
+		// Requeue monitors whose stores were not built due to the memory budget, so they are once the usage drops.
+		if errors.Is(err, errMemoryBudgetExceeded) {
+			return err
+		}
+
 		return nil
 	}
 
-	if _, err := c.emitSuccess(ctx, resource, metav1.ConditionTrue, fmt.Sprintf("Event handler successfully processed event: %s", event)); err != nil {
+	message := fmt.Sprintf("Event handler successfully processed event: %s", event)
+	if warnings := c.schemaWarnings(ctx, resource); len(warnings) > 0 {
+		message += fmt.Sprintf(", with queries not matching the targets' schemas: %s", strings.Join(warnings, "; "))
+	}
+	traceStep(ctx, "Validated queries against the targets' schemas")
+	if _, err := c.emitSuccess(ctx, resource, metav1.ConditionTrue, message); err != nil {
 		logger.Error(fmt.Errorf("failed to emit success on %s: %w", klog.KObj(resource).String(), err), "cannot update the resource")
 		c.eventsProcessed.WithLabelValues(resource.GetNamespace(), resource.GetName(), event, "failed").Inc()
 
@@ -70,6 +112,7 @@ func (c *Controller) handleEvent(ctx context.Context, stores *sync.Map, event st
 	}
 
 	c.eventsProcessed.WithLabelValues(resource.GetNamespace(), resource.GetName(), event, "success").Inc()
+	c.lastReconcile.WithLabelValues(resource.GetNamespace(), resource.GetName()).SetToCurrentTime()
 
 	return nil
 }
@@ -97,7 +140,7 @@ func (c *Controller) validateAndPrepareResource(ctx context.Context, o metav1.Ob
 		return nil, err
 	}
 
-	if updatedResource.Spec.Configuration == "" {
+	if updatedResource.Spec.Configuration == "" && updatedResource.Spec.Preset == "" {
 		logger.Error(errors.New("configuration YAML is empty"), "cannot process the resource")
 		c.emitFailure(ctx, updatedResource, "Configuration YAML is empty")
 
@@ -126,10 +169,40 @@ func (c *Controller) processEvent(ctx context.Context, stores *sync.Map, event s
 func (c *Controller) processAddOrUpdate(ctx context.Context, stores *sync.Map, event string, resource *v1alpha1.ResourceMetricsMonitor) error {
 	logger := klog.FromContext(ctx)
 
-	stores.Delete(resource.GetUID())
+	dropStores(stores, resource)
+
+	paused := resource.GetAnnotations()[v1alpha1.PausedAnnotation] == "true"
+	c.emitPaused(ctx, resource, paused)
+	if paused {
+		logger.V(1).Info("resource is paused, not building its stores", "resource", klog.KObj(resource))
+		c.resourcesMonitored.DeleteLabelValues(resource.GetNamespace(), resource.GetName())
+		c.monitorStores.DeleteLabelValues(resource.GetNamespace(), resource.GetName())
+
+		return nil
+	}
+
+	if c.budget.exceeded() {
+		logger.Error(errMemoryBudgetExceeded, "not building stores", "resource", klog.KObj(resource), "budget", c.budget.limit)
+		c.emitDegraded(ctx, resource, fmt.Sprintf("Memory budget of %d bytes exceeded, stores are not built until the usage drops", c.budget.limit))
+
+		return errMemoryBudgetExceeded
+	}
 
 	configurerInstance := newConfigurer(c.dynamicClientset, resource, *c.options.CELCostLimit, time.Duration(*c.options.CELTimeout)*time.Second, c.celEvaluations)
-	if err := configurerInstance.parse(resource.Spec.Configuration); err != nil {
+	configurerInstance.watchNamespace = c.storesNamespace(resource)
+	configurerInstance.clusters = c.clusterClientsets
+	configurerInstance.exposition = ExpositionMode(*c.options.ExpositionMode)
+	configurerInstance.fixedPointValues = *c.options.FixedPointValues
+	configurerInstance.listPageSize = *c.options.ListPageSize
+	configurerInstance.storage = c.storage
+	configurerInstance.celEnvironment = c.celEnvironment
+	configurerInstance.resolverCacheLookups = c.resolverCacheLookups
+	configurerInstance.lastErrors = newLastErrors(ptr.Deref(c.options.StatusLastErrors, 0))
+	configurerInstance.droppedSamples = c.droppedSamples
+	configurerInstance.establishment = newEstablishment(func(deferred, fallbacks []string) {
+		c.emitEstablishment(ctx, resource, deferred, fallbacks)
+	})
+	if err := configurerInstance.parseMonitor(); err != nil {
 		logger.Error(fmt.Errorf("failed to parse configuration YAML: %w", err), "cannot process the resource")
 		c.emitFailure(ctx, resource, fmt.Sprintf("Failed to parse configuration YAML: %s", err))
 		c.configParseErrors.WithLabelValues(resource.GetNamespace(), resource.GetName()).Inc()
@@ -137,16 +210,103 @@ func (c *Controller) processAddOrUpdate(ctx context.Context, stores *sync.Map, e
 
 		return err
 	}
+	if err := checkNativeResources(configurerInstance.configuration, c.options.NativeResources); err != nil {
+		logger.Error(err, "cannot process the resource")
+		c.emitFailure(ctx, resource, fmt.Sprintf("Failed to validate configuration: %s", err))
+		c.eventsProcessed.WithLabelValues(resource.GetNamespace(), resource.GetName(), event, "failed").Inc()
+
+		return err
+	}
+	if err := checkQuota(stores, storesKey(resource), configurerInstance.configuration, *c.options.MonitorsQuota, *c.options.StoresQuota); err != nil {
+		logger.Error(err, "cannot process the resource")
+		c.recorder.Eventf(resource, nil, corev1.EventTypeWarning, "QuotaExceeded", "Reconcile", "%s", err.Error())
+		c.emitFailure(ctx, resource, fmt.Sprintf("Quota exceeded: %s", err))
+		c.resourcesMonitored.DeleteLabelValues(resource.GetNamespace(), resource.GetName())
+		c.monitorStores.DeleteLabelValues(resource.GetNamespace(), resource.GetName())
+		c.eventsProcessed.WithLabelValues(resource.GetNamespace(), resource.GetName(), event, "failed").Inc()
+
+		return err
+	}
+	if err := c.resolveCredentials(ctx, resource, configurerInstance.configuration); err != nil {
+		logger.Error(err, "cannot process the resource")
+		c.emitFailure(ctx, resource, fmt.Sprintf("Failed to resolve credentials: %s", err))
+		c.eventsProcessed.WithLabelValues(resource.GetNamespace(), resource.GetName(), event, "failed").Inc()
+
+		return err
+	}
+	traceStep(ctx, "Parsed configuration")
+	collisions := configurerInstance.configuration.guardKSMCollisions(c.ksmMetricNames, ptr.Deref(c.options.KSMCollisionPrefix, ""))
+	if len(collisions) > 0 {
+		logger.Info("families collide with kube-state-metrics' metrics", "resource", klog.KObj(resource), "message", ksmCollisionMessage(collisions))
+		// Prefixed families may collide with the configuration's others.
+		if err := configurerInstance.configuration.validateFamilyNames(); err != nil {
+			logger.Error(err, "cannot process the resource")
+			c.emitFailure(ctx, resource, fmt.Sprintf("Failed to prefix families colliding with kube-state-metrics' metrics: %s", err))
+			c.eventsProcessed.WithLabelValues(resource.GetNamespace(), resource.GetName(), event, "failed").Inc()
+
+			return err
+		}
+	}
+	c.ksmCollisions.WithLabelValues(resource.GetNamespace(), resource.GetName()).Set(float64(len(collisions)))
+	c.emitKSMCollision(ctx, resource, ksmCollisionMessage(collisions))
+	conflicts := configurerInstance.configuration.dropConflicts(stores, storesKey(resource), resource.GetCreationTimestamp().Time)
+	if len(conflicts) > 0 {
+		logger.Error(errors.New(conflictMessage(conflicts)), "dropping conflicting families", "resource", klog.KObj(resource))
+	}
+	c.emitConflict(ctx, resource, conflictMessage(conflicts))
+	if len(resource.Spec.Tests) > 0 {
+		c.emitTested(ctx, resource, runTests(ctx, resource))
+		traceStep(ctx, "Ran tests")
+	}
 
 	configurerInstance.build(ctx, stores)
+	traceStep(ctx, "Built stores")
+	// Newer monitors built before this one keep the families conflicting with it, unless reconciled again.
+	for _, key := range configurerInstance.configuration.newerConflicting(stores, storesKey(resource), resource.GetCreationTimestamp().Time) {
+		logger.V(1).Info("Requeuing newer monitor defining conflicting families", "resource", klog.KObj(resource), "monitor", key)
+		c.workqueue.Add([2]string{key, updateEvent.String()})
+	}
+	if configurerInstance.lastErrors != nil {
+		c.lastErrors.Store(storesKey(resource), configurerInstance.lastErrors)
+	}
 	c.resourcesMonitored.WithLabelValues(resource.GetNamespace(), resource.GetName()).Set(1)
+	c.monitorStores.WithLabelValues(resource.GetNamespace(), resource.GetName()).Set(float64(len(configurerInstance.configuration.Stores)))
+
+	// Metrics are being served regardless, so a missing ServiceMonitor should not fail the event, but degrade it.
+	if err := c.reconcileServiceMonitor(ctx, resource); err != nil {
+		logger.Error(err, "cannot generate ServiceMonitor")
+		c.emitDegraded(ctx, resource, fmt.Sprintf("Failed to reconcile ServiceMonitor: %s", err))
+	} else {
+		c.emitDegraded(ctx, resource, "")
+	}
+	traceStep(ctx, "Reconciled ServiceMonitor")
 
 	return nil
 }
 
+// storesNamespace returns the namespace the given resource's stores are scoped to, or empty for all namespaces.
+func (c *Controller) storesNamespace(resource *v1alpha1.ResourceMetricsMonitor) string {
+	if c.options.NamespacedStores == nil || !*c.options.NamespacedStores || resource.GetAnnotations()[v1alpha1.ClusterScopedAnnotation] == "true" {
+		return metav1.NamespaceAll
+	}
+
+	return resource.GetNamespace()
+}
+
 func (c *Controller) processDelete(stores *sync.Map, resource *v1alpha1.ResourceMetricsMonitor) error {
-	stores.Delete(resource.GetUID())
+	// The series of deleted resources are dropped off the storages as well, unlike the ones of updated resources, which
+	// the rebuilt stores are backed by.
+	for _, s := range dropStores(stores, resource) {
+		s.purge()
+	}
 	c.resourcesMonitored.DeleteLabelValues(resource.GetNamespace(), resource.GetName())
+	c.monitorStores.DeleteLabelValues(resource.GetNamespace(), resource.GetName())
+	c.lastReconcile.DeleteLabelValues(resource.GetNamespace(), resource.GetName())
+	c.lastErrors.Delete(storesKey(resource))
+	c.summarized.Delete(storesKey(resource))
+	c.monitorConditions.DeletePartialMatch(prometheus.Labels{"namespace": resource.GetNamespace(), "name": resource.GetName()})
+	c.droppedSamples.DeletePartialMatch(prometheus.Labels{"namespace": resource.GetNamespace(), "name": resource.GetName()})
+	c.ksmCollisions.DeleteLabelValues(resource.GetNamespace(), resource.GetName())
 
 	return nil
 }
@@ -154,18 +314,25 @@ func (c *Controller) processDelete(stores *sync.Map, resource *v1alpha1.Resource
 func (c *Controller) emitSuccess(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, statusBool metav1.ConditionStatus, message string) (*v1alpha1.ResourceMetricsMonitor, error) {
 	kObj := klog.KObj(monitor).String()
 
-	resource, err := c.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors(monitor.GetNamespace()).
-		Get(ctx, monitor.GetName(), metav1.GetOptions{})
+	resource, err := c.monitors(monitor.GetNamespace()).Get(ctx, monitor.GetName(), metav1.GetOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get %s: %w", kObj, err)
 	}
-	resource.Status.Set(resource, metav1.Condition{
-		Type:    v1alpha1.ConditionType[v1alpha1.ConditionTypeProcessed],
-		Status:  statusBool,
-		Message: message,
-	})
-	resource, err = c.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors(resource.GetNamespace()).
-		UpdateStatus(ctx, resource, metav1.UpdateOptions{})
+	// Transitions to False right after the last one are held off, as they are likely reverted once the event is
+	// processed. Transitions to True are not, as nothing would set them again once held off.
+	hold := time.Duration(0)
+	if statusBool == metav1.ConditionFalse {
+		hold = time.Duration(ptr.Deref(c.options.ConditionHold, 0)) * time.Second
+	}
+	if !resource.Status.SetDebounced(resource, metav1.Condition{
+		Type:               v1alpha1.ConditionType[v1alpha1.ConditionTypeProcessed],
+		Status:             statusBool,
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(clockNow()),
+	}, hold) {
+		return resource, nil
+	}
+	resource, err = c.monitors(resource.GetNamespace()).UpdateStatus(ctx, resource, metav1.UpdateOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update the status of %s: %w", kObj, err)
 	}
@@ -176,8 +343,7 @@ func (c *Controller) emitSuccess(ctx context.Context, monitor *v1alpha1.Resource
 func (c *Controller) emitFailure(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, message string) {
 	kObj := klog.KObj(monitor).String()
 
-	resource, err := c.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors(monitor.GetNamespace()).
-		Get(ctx, monitor.GetName(), metav1.GetOptions{})
+	resource, err := c.monitors(monitor.GetNamespace()).Get(ctx, monitor.GetName(), metav1.GetOptions{})
 	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("failed to get %s: %w", kObj, err))
 
@@ -188,19 +354,222 @@ func (c *Controller) emitFailure(ctx context.Context, monitor *v1alpha1.Resource
 		Status:  metav1.ConditionTrue,
 		Message: message,
 	})
-	_, err = c.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors(resource.GetNamespace()).
-		UpdateStatus(ctx, resource, metav1.UpdateOptions{})
+	// Failures are not held off, so the Processed condition does not report on a resource that failed to process.
+	resource.Status.Set(resource, metav1.Condition{
+		Type:               v1alpha1.ConditionType[v1alpha1.ConditionTypeProcessed],
+		Status:             metav1.ConditionFalse,
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(clockNow()),
+	})
+	_, err = c.monitors(resource.GetNamespace()).UpdateStatus(ctx, resource, metav1.UpdateOptions{})
 	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("failed to emit failure on %s: %w", kObj, err))
 	}
 }
 
+// emitDegraded reports whether the given resource is degraded, with the given message, or not, if the message is empty.
+func (c *Controller) emitDegraded(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, message string) {
+	kObj := klog.KObj(monitor).String()
+
+	resource, err := c.monitors(monitor.GetNamespace()).Get(ctx, monitor.GetName(), metav1.GetOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to get %s: %w", kObj, err))
+
+		return
+	}
+	statusBool := metav1.ConditionTrue
+	if message == "" {
+		statusBool = metav1.ConditionFalse
+	}
+	resource.Status.Set(resource, metav1.Condition{
+		Type:    v1alpha1.ConditionType[v1alpha1.ConditionTypeDegraded],
+		Status:  statusBool,
+		Message: message,
+	})
+	_, err = c.monitors(resource.GetNamespace()).UpdateStatus(ctx, resource, metav1.UpdateOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to emit degradation on %s: %w", kObj, err))
+	}
+}
+
+// emitPaused reports whether the given resource's stores are torn down on request.
+func (c *Controller) emitPaused(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, paused bool) {
+	kObj := klog.KObj(monitor).String()
+
+	resource, err := c.monitors(monitor.GetNamespace()).Get(ctx, monitor.GetName(), metav1.GetOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to get %s: %w", kObj, err))
+
+		return
+	}
+	statusBool := metav1.ConditionFalse
+	if paused {
+		statusBool = metav1.ConditionTrue
+	}
+	resource.Status.Set(resource, metav1.Condition{
+		Type:   v1alpha1.ConditionType[v1alpha1.ConditionTypePaused],
+		Status: statusBool,
+	})
+	_, err = c.monitors(resource.GetNamespace()).UpdateStatus(ctx, resource, metav1.UpdateOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to emit pause on %s: %w", kObj, err))
+	}
+}
+
+// emitConflict reports whether the given resource defines families conflicting with the ones of older resources, with
+// the given message, or not, if the message is empty.
+func (c *Controller) emitConflict(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, message string) {
+	kObj := klog.KObj(monitor).String()
+
+	resource, err := c.monitors(monitor.GetNamespace()).Get(ctx, monitor.GetName(), metav1.GetOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to get %s: %w", kObj, err))
+
+		return
+	}
+	statusBool := metav1.ConditionTrue
+	if message == "" {
+		statusBool = metav1.ConditionFalse
+	}
+	resource.Status.Set(resource, metav1.Condition{
+		Type:    v1alpha1.ConditionType[v1alpha1.ConditionTypeConflict],
+		Status:  statusBool,
+		Message: message,
+	})
+	_, err = c.monitors(resource.GetNamespace()).UpdateStatus(ctx, resource, metav1.UpdateOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to emit conflict on %s: %w", kObj, err))
+	}
+}
+
+// emitKSMCollision reports whether the given resource defines families exposing metrics named alike kube-state-metrics'
+// ones, with the given message, empty if it does not.
+func (c *Controller) emitKSMCollision(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, message string) {
+	kObj := klog.KObj(monitor).String()
+	statusBool := metav1.ConditionTrue
+	if message == "" {
+		statusBool = metav1.ConditionFalse
+	}
+	// Monitors are reconciled far more often than their collisions change, so the status is only written on change.
+	if conditionCurrent(monitor, v1alpha1.ConditionType[v1alpha1.ConditionTypeKSMCollision], statusBool, message) {
+		return
+	}
+
+	resource, err := c.monitors(monitor.GetNamespace()).Get(ctx, monitor.GetName(), metav1.GetOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to get %s: %w", kObj, err))
+
+		return
+	}
+	resource.Status.Set(resource, metav1.Condition{
+		Type:    v1alpha1.ConditionType[v1alpha1.ConditionTypeKSMCollision],
+		Status:  statusBool,
+		Message: message,
+	})
+	_, err = c.monitors(resource.GetNamespace()).UpdateStatus(ctx, resource, metav1.UpdateOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to emit kube-state-metrics collision on %s: %w", kObj, err))
+	}
+}
+
+// conditionCurrent reports whether the given monitor already carries the given condition, with the given status, and
+// message, if any, as of its current generation.
+func conditionCurrent(monitor *v1alpha1.ResourceMetricsMonitor, conditionType string, status metav1.ConditionStatus, message string) bool {
+	for _, condition := range monitor.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == status && (message == "" || condition.Message == message) &&
+				condition.ObservedGeneration == monitor.GetGeneration()
+		}
+	}
+
+	return false
+}
+
+// emitForbidden reports whether the given resource's stores are repeatedly forbidden from listing or watching their
+// targets, with the given message, or not, if the message is empty.
+func (c *Controller) emitForbidden(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, message string) {
+	kObj := klog.KObj(monitor).String()
+
+	resource, err := c.monitors(monitor.GetNamespace()).Get(ctx, monitor.GetName(), metav1.GetOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to get %s: %w", kObj, err))
+
+		return
+	}
+	statusBool := metav1.ConditionTrue
+	if message == "" {
+		statusBool = metav1.ConditionFalse
+	}
+	resource.Status.Set(resource, metav1.Condition{
+		Type:    v1alpha1.ConditionType[v1alpha1.ConditionTypeForbidden],
+		Status:  statusBool,
+		Message: message,
+	})
+	_, err = c.monitors(resource.GetNamespace()).UpdateStatus(ctx, resource, metav1.UpdateOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to emit forbidden watches on %s: %w", kObj, err))
+	}
+}
+
+// emitTested reports whether the given resource's tests passed, or which ones failed, given their failures.
+func (c *Controller) emitTested(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, failures map[int]error) {
+	kObj := klog.KObj(monitor).String()
+
+	resource, err := c.monitors(monitor.GetNamespace()).Get(ctx, monitor.GetName(), metav1.GetOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to get %s: %w", kObj, err))
+
+		return
+	}
+	statusBool := metav1.ConditionTrue
+	if len(failures) > 0 {
+		statusBool = metav1.ConditionFalse
+		c.recorder.Eventf(resource, nil, corev1.EventTypeWarning, "TestsFailed", "Test", "%s", testsMessage(monitor, failures))
+	}
+	resource.Status.Set(resource, metav1.Condition{
+		Type:    v1alpha1.ConditionType[v1alpha1.ConditionTypeTested],
+		Status:  statusBool,
+		Message: testsMessage(monitor, failures),
+	})
+	_, err = c.monitors(resource.GetNamespace()).UpdateStatus(ctx, resource, metav1.UpdateOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to emit tests on %s: %w", kObj, err))
+	}
+}
+
+// emitEstablishment reports whether any of the given resource's stores are deferred until their targeted CRDs are
+// established, and which ones fall back to other versions, as their CRDs no longer serve the targeted ones.
+func (c *Controller) emitEstablishment(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, deferred, fallbacks []string) {
+	kObj := klog.KObj(monitor).String()
+	klog.FromContext(ctx).V(1).Info(deferredMessage(deferred, fallbacks), "resource", kObj)
+
+	resource, err := c.monitors(monitor.GetNamespace()).Get(ctx, monitor.GetName(), metav1.GetOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to get %s: %w", kObj, err))
+
+		return
+	}
+	statusBool := metav1.ConditionTrue
+	if len(deferred) > 0 {
+		statusBool = metav1.ConditionFalse
+	}
+	resource.Status.Set(resource, metav1.Condition{
+		Type:    v1alpha1.ConditionType[v1alpha1.ConditionTypeEstablished],
+		Status:  statusBool,
+		Message: deferredMessage(deferred, fallbacks),
+	})
+	_, err = c.monitors(resource.GetNamespace()).UpdateStatus(ctx, resource, metav1.UpdateOptions{})
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to emit establishment on %s: %w", kObj, err))
+	}
+}
+
 func (c *Controller) updateMetadata(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor) error {
 	logger := klog.FromContext(ctx)
 	kObj := klog.KObj(resource).String()
 
-	return wait.PollUntilContextTimeout(ctx, time.Second, time.Minute, false, func(pollCtx context.Context) (bool, error) {
-		gotResource, err := c.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors(resource.GetNamespace()).Get(pollCtx, resource.GetName(), metav1.GetOptions{})
+	return wait.PollUntilContextTimeout(ctx, time.Second, time.Minute, true, func(pollCtx context.Context) (bool, error) {
+		gotResource, err := c.monitors(resource.GetNamespace()).Get(pollCtx, resource.GetName(), metav1.GetOptions{})
 		if err != nil {
 			return false, fmt.Errorf("failed to get %s: %w", kObj, err)
 		}
@@ -210,6 +579,7 @@ func (c *Controller) updateMetadata(ctx context.Context, resource *v1alpha1.Reso
 			resource.Labels = make(map[string]string)
 		}
 		resource.Labels["app.kubernetes.io/managed-by"] = version.ControllerName.String()
+		addFinalizer(resource)
 		revisionSHA := regexp.MustCompile(`revision:\s*(\S+)\)`).FindStringSubmatch(version.Version())
 		if len(revisionSHA) > 1 {
 			resource.Labels["app.kubernetes.io/version"] = revisionSHA[1]
@@ -217,7 +587,7 @@ func (c *Controller) updateMetadata(ctx context.Context, resource *v1alpha1.Reso
 			logger.Error(errors.New("failed to get revision SHA, continuing anyway"), "cannot set version label")
 		}
 
-		resource, err = c.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors(resource.GetNamespace()).Update(pollCtx, resource, metav1.UpdateOptions{})
+		resource, err = c.monitors(resource.GetNamespace()).Update(pollCtx, resource, metav1.UpdateOptions{})
 		if err != nil {
 			return false, fmt.Errorf("failed to update %s: %w", kObj, err)
 		}
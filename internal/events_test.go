@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestController_processDelete_metrics(t *testing.T) {
+	t.Parallel()
+	labelKeys := []string{"namespace", "name"}
+	c := &Controller{metrics: metrics{
+		resourcesMonitored: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "resources_monitored_info"}, labelKeys),
+		monitorConditions:  prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "monitor_condition"}, append(labelKeys, "type")),
+		lastReconcile:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "monitor_last_reconcile_timestamp_seconds"}, labelKeys),
+		monitorStores:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "monitor_stores"}, labelKeys),
+		droppedSamples:     prometheus.NewCounterVec(prometheus.CounterOpts{Name: "dropped_samples_total"}, append(labelKeys, "family", "reason")),
+		ksmCollisions:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "ksm_collisions"}, labelKeys),
+	}}
+	for _, name := range []string{"foo", "bar"} {
+		c.lastReconcile.WithLabelValues("default", name).SetToCurrentTime()
+		c.monitorStores.WithLabelValues("default", name).Set(2)
+	}
+
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"}}
+	if err := c.processDelete(&c.stores, resource); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the deleted monitor's series are dropped.
+	if got := testutil.CollectAndCount(c.lastReconcile); got != 1 {
+		t.Errorf("expected 1 last reconcile series, got %d", got)
+	}
+	if got := testutil.CollectAndCount(c.monitorStores); got != 1 {
+		t.Errorf("expected 1 stores series, got %d", got)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/events"
+)
+
+// eventSink records events about namespaced objects in the given namespace, if any, instead of the one of the object
+// they are about, e.g., to collect all events of the controller in a single namespace. Events are recorded through the
+// events.k8s.io API, which, unlike the core one, allows them to refer to objects in other namespaces, but still
+// requires those about cluster-scoped objects to be recorded in the default namespace.
+type eventSink struct {
+	events.EventSink
+	namespace string
+}
+
+// eventSink implements the EventSink interface.
+var _ events.EventSink = &eventSink{}
+
+// newEventSink returns a sink recording events through the given client-set, in the given namespace, or in the ones of
+// the objects they are about if none is given. Events about cluster-scoped objects are recorded in the default
+// namespace either way.
+func newEventSink(kubeClientset kubernetes.Interface, namespace string) events.EventSink {
+	sink := &events.EventSinkImpl{Interface: kubeClientset.EventsV1()}
+	if namespace == "" {
+		return sink
+	}
+
+	return &eventSink{EventSink: sink, namespace: namespace}
+}
+
+// Create records the given event in the sink's namespace.
+func (s *eventSink) Create(ctx context.Context, event *eventsv1.Event) (*eventsv1.Event, error) {
+	return s.EventSink.Create(ctx, s.namespaced(event))
+}
+
+// Update updates the given event in the sink's namespace.
+func (s *eventSink) Update(ctx context.Context, event *eventsv1.Event) (*eventsv1.Event, error) {
+	return s.EventSink.Update(ctx, s.namespaced(event))
+}
+
+// Patch patches the given event in the sink's namespace.
+func (s *eventSink) Patch(ctx context.Context, event *eventsv1.Event, data []byte) (*eventsv1.Event, error) {
+	return s.EventSink.Patch(ctx, s.namespaced(event), data)
+}
+
+// namespaced returns a copy of the given event in the sink's namespace, leaving the broadcaster's copy as is. Events
+// about cluster-scoped objects are left in the default namespace.
+func (s *eventSink) namespaced(event *eventsv1.Event) *eventsv1.Event {
+	if event.Regarding.Namespace == metav1.NamespaceNone {
+		return event
+	}
+	event = event.DeepCopy()
+	event.Namespace = s.namespace
+
+	return event
+}
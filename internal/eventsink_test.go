@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEventSink(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		namespace string
+		regarding corev1.ObjectReference
+		expected  string
+	}{
+		{name: "object's namespace", regarding: corev1.ObjectReference{Kind: "ResourceMetricsMonitor", Namespace: "default", Name: "rmm"}, expected: "default"},
+		{name: "configured namespace", namespace: "events", regarding: corev1.ObjectReference{Kind: "ResourceMetricsMonitor", Namespace: "default", Name: "rmm"}, expected: "events"},
+		// Events about cluster-scoped objects may only be recorded in the default (or kube-system) namespace.
+		{name: "cluster-scoped object", namespace: "events", regarding: corev1.ObjectReference{Kind: "ClusterResourceMetricsMonitor", Name: "rmm"}, expected: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			client := kubefake.NewClientset()
+			event := &eventsv1.Event{
+				ObjectMeta: metav1.ObjectMeta{Name: "rmm.1", Namespace: "default"},
+				Regarding:  tt.regarding,
+			}
+			if _, err := newEventSink(client, tt.namespace).Create(context.Background(), event); err != nil {
+				t.Fatal(err)
+			}
+			got, err := client.EventsV1().Events(tt.expected).Get(context.Background(), event.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected event in namespace %q, got %v", tt.expected, err)
+			}
+			if got.Regarding != tt.regarding {
+				t.Errorf("expected the event to regard %v, got %v", tt.regarding, got.Regarding)
+			}
+			if event.Namespace != "default" {
+				t.Errorf("expected the recorded event to be left as is, got namespace %q", event.Namespace)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+)
+
+// exemplarMaxLabelSetLength is the maximum combined length, in runes, of an exemplar's label names and values, as per
+// the OpenMetrics specification.
+const exemplarMaxLabelSetLength = 128
+
+// exemplarSeparator separates a sample from its exemplar.
+const exemplarSeparator = " # {"
+
+// ExemplarType attaches an exemplar to the metric's samples, e.g., the ID of the trace that last reconciled the object,
+// as recorded by a traced controller in an annotation or a status field. Exemplars are only exposed to scrapes
+// negotiating OpenMetrics.
+type ExemplarType struct {
+	// TraceID is the query, resolved like the metric's value, the trace_id label of the exemplar is resolved from.
+	TraceID string `yaml:"traceID"`
+	// SpanID is the query the span_id label of the exemplar is resolved from, if any.
+	SpanID string `yaml:"spanID,omitempty"`
+}
+
+// validate checks that the exemplar's trace ID query is set, and that the metric's samples are plain ones that an
+// exemplar may be attached to.
+func (e *ExemplarType) validate(family *FamilyType, metric *MetricType) error {
+	if e == nil {
+		return nil
+	}
+	if e.TraceID == "" {
+		return errors.New("traceID must be set")
+	}
+	if family.Type == FamilyKindHistogram {
+		return errors.New("cannot be used in histogram families")
+	}
+	if family.Aggregate != AggregateTypeNone {
+		return errors.New("cannot be used in aggregated families")
+	}
+	if metric.EachMap != nil || len(metric.Expand) > 0 {
+		return errors.New("cannot be used with eachMap or expand")
+	}
+
+	return nil
+}
+
+// resolve returns the exemplar's label set for the given object, or an empty string if the trace ID does not resolve,
+// or the label set exceeds the length OpenMetrics allows. Queries resolving to themselves, as the unstructured resolver
+// does for missing fields, are considered unresolved, since identifiers are never set literally.
+func (e *ExemplarType) resolve(resolverInstance resolver.Resolver, obj map[string]interface{}) string {
+	traceID := resolverInstance.Resolve(e.TraceID, obj)[e.TraceID]
+	if traceID == "" || traceID == e.TraceID {
+		return ""
+	}
+	keys, values := []string{"trace_id"}, []string{traceID}
+	if e.SpanID != "" {
+		if spanID := resolverInstance.Resolve(e.SpanID, obj)[e.SpanID]; spanID != "" && spanID != e.SpanID {
+			keys, values = append(keys, "span_id"), append(values, spanID)
+		}
+	}
+	length := 0
+	for i := range keys {
+		length += utf8.RuneCountInString(keys[i]) + utf8.RuneCountInString(values[i])
+	}
+	if length > exemplarMaxLabelSetLength {
+		return ""
+	}
+	builder := &strings.Builder{}
+	if err := writeLabels(builder, keys, values); err != nil {
+		return ""
+	}
+
+	return builder.String()
+}
+
+// attachExemplar appends the given exemplar label set to each of the given series, valued after the series' own value.
+func attachExemplar(series, labelSet string) string {
+	if labelSet == "" {
+		return series
+	}
+	builder := &strings.Builder{}
+	for _, line := range strings.SplitAfter(series, "\n") {
+		sample, found := strings.CutSuffix(line, "\n")
+		if !found || sample == "" {
+			builder.WriteString(line)
+
+			continue
+		}
+		value := sample[strings.LastIndexByte(sample, ' ')+1:]
+		builder.WriteString(sample)
+		builder.WriteString(" # ")
+		builder.WriteString(labelSet)
+		builder.WriteByte(' ')
+		builder.WriteString(value)
+		builder.WriteByte('\n')
+	}
+
+	return builder.String()
+}
+
+// stripExemplars removes the exemplars from the given series, for expositions other than OpenMetrics. Exemplars follow
+// the series' label sets, which are always present, and within which the separator may be quoted.
+func stripExemplars(series string) string {
+	if !strings.Contains(series, exemplarSeparator) {
+		return series
+	}
+	builder := &strings.Builder{}
+	for _, line := range strings.SplitAfter(series, "\n") {
+		labelSetEnd := labelSetEnd(line)
+		if labelSetEnd < 0 {
+			builder.WriteString(line)
+
+			continue
+		}
+		sample, exemplar, found := strings.Cut(line[labelSetEnd:], " # ")
+		builder.WriteString(line[:labelSetEnd])
+		builder.WriteString(sample)
+		if found && strings.HasSuffix(exemplar, "\n") {
+			builder.WriteByte('\n')
+		}
+	}
+
+	return builder.String()
+}
+
+// labelSetEnd returns the index past the closing brace of the given series' label set, or -1 if it has none.
+func labelSetEnd(line string) int {
+	opening := strings.IndexByte(line, '{')
+	if opening < 0 {
+		return -1
+	}
+	quoted := false
+	for i := opening + 1; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && quoted:
+			i++
+		case line[i] == '"':
+			quoted = !quoted
+		case line[i] == '}' && !quoted:
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+// hasExemplars reports whether any of the family's metrics attach exemplars to their samples.
+func (f *FamilyType) hasExemplars() bool {
+	for _, metric := range f.Metrics {
+		if metric.Exemplar != nil {
+			return true
+		}
+	}
+
+	return false
+}
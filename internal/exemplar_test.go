@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/klog/v2"
+)
+
+func TestExemplarType_validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		exemplar *ExemplarType
+		family   *FamilyType
+		metric   *MetricType
+		wantErr  bool
+	}{
+		{name: "unset", family: &FamilyType{}, metric: &MetricType{}},
+		{name: "trace ID", exemplar: &ExemplarType{TraceID: "metadata.annotations.trace-id"}, family: &FamilyType{}, metric: &MetricType{}},
+		{name: "no trace ID", exemplar: &ExemplarType{SpanID: "metadata.annotations.span-id"}, family: &FamilyType{}, metric: &MetricType{}, wantErr: true},
+		{name: "histogram", exemplar: &ExemplarType{TraceID: "x"}, family: &FamilyType{Type: FamilyKindHistogram}, metric: &MetricType{}, wantErr: true},
+		{name: "aggregated", exemplar: &ExemplarType{TraceID: "x"}, family: &FamilyType{Aggregate: AggregateTypeSum}, metric: &MetricType{}, wantErr: true},
+		{name: "eachMap", exemplar: &ExemplarType{TraceID: "x"}, family: &FamilyType{}, metric: &MetricType{EachMap: &EachMapType{Path: "status.capacity"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.exemplar.validate(tt.family, tt.metric); (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error: %t, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+func TestFamilyType_exemplar(t *testing.T) {
+	t.Parallel()
+	object := newSyntheticObjects(1)[0]
+	object.SetAnnotations(map[string]string{"trace-id": "4bf92f3577b34da6a3ce929d0e0e4736"})
+	family := &FamilyType{
+		logger: klog.Background(),
+		Name:   "replicas",
+		Metrics: []*MetricType{
+			{
+				Value:    "2",
+				Exemplar: &ExemplarType{TraceID: "metadata.annotations.trace-id", SpanID: "metadata.annotations.span-id"},
+			},
+		},
+		Resolver: ResolverTypeUnstructured,
+	}
+
+	got := family.buildMetricString(object)
+	expected := "kube_customresource_replicas{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 2 # {trace_id=\"4bf92f3577b34da6a3ce929d0e0e4736\"} 2\n"
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected exposition (-want +got):\n%s", diff)
+	}
+	expected = "kube_customresource_replicas{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 2\n"
+	if diff := cmp.Diff(expected, stripExemplars(got)); diff != "" {
+		t.Errorf("unexpected stripped exposition (-want +got):\n%s", diff)
+	}
+
+	// Objects without the annotation produce no exemplars.
+	object.SetAnnotations(nil)
+	if diff := cmp.Diff(expected, family.buildMetricString(object)); diff != "" {
+		t.Errorf("unexpected exposition (-want +got):\n%s", diff)
+	}
+}
+
+func TestStripExemplars(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		series   string
+		expected string
+	}{
+		{series: "", expected: ""},
+		{series: "m{a=\"b\"} 1\n", expected: "m{a=\"b\"} 1\n"},
+		{series: "m{a=\"b\"} 1 # {trace_id=\"t\"} 1\nm{a=\"c\"} 2\n", expected: "m{a=\"b\"} 1\nm{a=\"c\"} 2\n"},
+		{series: "m{a=\"x # {y}\"} 1\n", expected: "m{a=\"x # {y}\"} 1\n"},
+		{series: "m{a=\"x \\\" # {y}\"} 1 # {trace_id=\"t\"} 1\n", expected: "m{a=\"x \\\" # {y}\"} 1\n"},
+	}
+
+	for _, tt := range tests {
+		if got := stripExemplars(tt.series); got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.series, tt.expected, got)
+		}
+	}
+}
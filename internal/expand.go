@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+// ExpandLevelType is a level of a metric's nested expansion, e.g., status.nodePools, and then machines, for
+// status.nodePools[*].machines[*], exposing a sample for each element of the innermost level.
+type ExpandLevelType struct {
+	// Path is the dot-separated path to the level's array, relative to the element of the level above, or to the
+	// object, for the outermost level.
+	Path string `yaml:"path"`
+	// LabelKeys and LabelValues are resolved against each of the level's elements, and label the samples of the
+	// elements below.
+	LabelKeys   []string `yaml:"labelKeys,omitempty"`
+	LabelValues []string `yaml:"labelValues,omitempty"`
+}
+
+// validateExpand checks that the metric's expansion levels, if any, are well-formed.
+func (m *MetricType) validateExpand(kind FamilyKind) error {
+	if len(m.Expand) == 0 {
+		return nil
+	}
+	if kind == FamilyKindHistogram {
+		return errors.New("expand: cannot be used in histogram families")
+	}
+	if m.EachMap != nil {
+		return errors.New("expand: cannot be used with eachMap")
+	}
+	for i, level := range m.Expand {
+		if level == nil || level.Path == "" {
+			return fmt.Errorf("expand[%d].path must be set", i)
+		}
+		if err := validateLabelLengths(level.LabelKeys, level.LabelValues); err != nil {
+			return fmt.Errorf("expand[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// buildExpandedString writes a sample for each element of the metric's innermost expansion level, labeled with the
+// metric's labels, resolved against the object, and the ones of each level, resolved against the element at that level.
+// The metric's value is resolved against the innermost element.
+func (f *FamilyType) buildExpandedString(builder *strings.Builder, metric *MetricType, resolverInstance resolver.Resolver, u *unstructured.Unstructured, keys, values []string, expanded map[string][]string, logger klog.Logger) {
+	f.expandLevel(builder, metric, resolverInstance, u, u.Object, 0, keys, values, expanded, logger)
+}
+
+// expandLevel walks the given level's elements, relative to the given element, writing the samples of the innermost
+// level's elements. Elements that are not objects are skipped.
+func (f *FamilyType) expandLevel(builder *strings.Builder, metric *MetricType, resolverInstance resolver.Resolver, u *unstructured.Unstructured, element map[string]interface{}, level int, keys, values []string, expanded map[string][]string, logger klog.Logger) {
+	if level == len(metric.Expand) {
+		value, found := resolveValue(resolverInstance, metric.Value, element)
+		if !found {
+			logger.V(1).Error(fmt.Errorf("error resolving metric value %q", metric.Value), "skipping")
+			f.drop(droppedReasonUnresolved)
+
+			return
+		}
+		value, err := metric.mapValue(value)
+		if err != nil {
+			logger.V(1).Error(err, "skipping")
+			f.drop(droppedReasonInvalidValue)
+
+			return
+		}
+		// Errors are logged by the writers, and only skip the element's samples.
+		_ = f.writeMetricSamples(builder, u, keys, values, maps.Clone(expanded), value, logger)
+
+		return
+	}
+
+	path := metric.Expand[level].Path
+	field, found, err := unstructured.NestedFieldNoCopy(element, strings.Split(strings.TrimPrefix(path, "."), ".")...)
+	if !found || err != nil {
+		return
+	}
+	list, ok := field.([]interface{})
+	if !ok {
+		logger.V(1).Error(fmt.Errorf("error expanding %q: not an array", path), "skipping")
+		f.drop(droppedReasonInvalidValue)
+
+		return
+	}
+	levelMetric := &MetricType{LabelKeys: metric.Expand[level].LabelKeys, LabelValues: metric.Expand[level].LabelValues}
+	for _, item := range list {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		levelKeys, levelValues, levelExpanded := resolveLabels(levelMetric, resolverInstance, itemMap)
+		itemKeys, itemValues := append(slices.Clone(keys), levelKeys...), append(slices.Clone(values), levelValues...)
+		sortLabels(itemKeys, itemValues)
+		itemExpanded := make(map[string][]string, len(expanded)+len(levelExpanded))
+		maps.Copy(itemExpanded, expanded)
+		maps.Copy(itemExpanded, levelExpanded)
+		f.expandLevel(builder, metric, resolverInstance, u, itemMap, level+1, itemKeys, itemValues, itemExpanded, logger)
+	}
+}
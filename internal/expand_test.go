@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/klog/v2"
+)
+
+func TestFamilyType_expand(t *testing.T) {
+	t.Parallel()
+	object := newSyntheticObjects(1)[0]
+	object.Object["status"].(map[string]interface{})["nodePools"] = []interface{}{
+		map[string]interface{}{
+			"name": "pool-a",
+			"machines": []interface{}{
+				map[string]interface{}{"name": "machine-0", "ready": true},
+				map[string]interface{}{"name": "machine-1", "ready": false},
+			},
+		},
+		map[string]interface{}{
+			"name":     "pool-b",
+			"machines": []interface{}{map[string]interface{}{"name": "machine-2", "ready": true}, "bogus"},
+		},
+		map[string]interface{}{"name": "pool-c"},
+	}
+	family := &FamilyType{
+		logger: klog.Background(),
+		Name:   "machine_ready",
+		Metrics: []*MetricType{{
+			LabelKeys:   []string{"name"},
+			LabelValues: []string{"metadata.name"},
+			Value:       "ready",
+			Expand: []*ExpandLevelType{
+				{Path: "status.nodePools", LabelKeys: []string{"pool"}, LabelValues: []string{"name"}},
+				{Path: "machines", LabelKeys: []string{"machine"}, LabelValues: []string{"name"}},
+			},
+		}},
+	}
+
+	expected := "kube_customresource_machine_ready{machine=\"machine-0\",name=\"bar-0\",pool=\"pool-a\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 1\n" +
+		"kube_customresource_machine_ready{machine=\"machine-1\",name=\"bar-0\",pool=\"pool-a\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 0\n" +
+		"kube_customresource_machine_ready{machine=\"machine-2\",name=\"bar-0\",pool=\"pool-b\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 1\n"
+	if diff := cmp.Diff(expected, family.buildMetricString(object)); diff != "" {
+		t.Errorf("unexpected exposition (-want +got):\n%s", diff)
+	}
+}
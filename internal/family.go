@@ -18,8 +18,11 @@ package internal
 
 import (
 	"fmt"
+	"maps"
+	"math"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -28,13 +31,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rexagod/resource-state-metrics/pkg/resolver"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 )
 
 const (
 	// metricTypeGauge represents the type of metric. This is pinned to `gauge` to avoid ingestion issues with different backends
 	// (Prometheus primarily) that may not recognize all metrics under the OpenMetrics spec. This also helps upkeep a more
-	// consistent configuration. Refer https://github.com/kubernetes/kube-state-metrics/pull/2270 for more details.
+	// consistent configuration. Histograms, which Prometheus does recognize, are the only opt-in exception. Refer https://github.com/kubernetes/kube-state-metrics/pull/2270 for more details.
 	metricTypeGauge = "gauge"
 	// In convention with kube-state-metrics, we prefix all metrics with `kube_customresource_` to explicitly denote
 	// that these are custom resource user-generated metrics (and have no stability).
@@ -78,6 +82,45 @@ const (
 	ResolverTypeNone ResolverType = ""
 )
 
+// AggregateType represents the function a family's series are aggregated with, across all objects in a store.
+type AggregateType string
+
+const (
+	// AggregateTypeCount counts the objects each series is generated for.
+	AggregateTypeCount AggregateType = "count"
+	// AggregateTypeSum sums the values of each series across objects.
+	AggregateTypeSum AggregateType = "sum"
+	// AggregateTypeAvg averages the values of each series across objects.
+	AggregateTypeAvg AggregateType = "avg"
+	// AggregateTypeNone represents the absence of an aggregation, i.e., series are exposed per object.
+	AggregateTypeNone AggregateType = ""
+)
+
+// NaNPolicy represents how a family's samples with NaN values are handled.
+type NaNPolicy string
+
+const (
+	// NaNPolicyEmit exposes samples with NaN values as is.
+	NaNPolicyEmit NaNPolicy = "emit"
+	// NaNPolicySkip leaves samples with NaN values out.
+	NaNPolicySkip NaNPolicy = "skip"
+	// NaNPolicyNone represents the absence of a policy, and defaults to NaNPolicyEmit.
+	NaNPolicyNone NaNPolicy = ""
+)
+
+// EvaluationPolicy represents when a family's series are rendered.
+type EvaluationPolicy string
+
+const (
+	// EvaluationPolicyOnEvent renders the family's series as its objects' events are processed, and serves them as is.
+	EvaluationPolicyOnEvent EvaluationPolicy = "onEvent"
+	// EvaluationPolicyOnScrape renders the family's series at scrape time, against the objects as last observed, e.g.,
+	// for time-based values.
+	EvaluationPolicyOnScrape EvaluationPolicy = "onScrape"
+	// EvaluationPolicyNone represents the absence of a policy, and defaults to EvaluationPolicyOnEvent.
+	EvaluationPolicyNone EvaluationPolicy = ""
+)
+
 // FamilyType represents a metric family (a group of metrics with the same name).
 type FamilyType struct {
 	logger              klog.Logger
@@ -86,27 +129,59 @@ type FamilyType struct {
 	celEvaluations      *prometheus.CounterVec
 	managedRMMNamespace string
 	managedRMMName      string
+	exposition          ExpositionMode
+	fixedPointValues    bool
+	sinceTimestamp      bool
+	celVariables        map[string]interface{}
+	resolutions         *resolutionCache
+	lastErrors          *lastErrors
+	droppedSamples      *prometheus.CounterVec
 	Name                string        `yaml:"name"`
 	Help                string        `yaml:"help"`
+	Type                FamilyKind    `yaml:"type,omitempty"`
 	Metrics             []*MetricType `yaml:"metrics"`
 	Resolver            ResolverType  `yaml:"resolver,omitempty"`
 	LabelKeys           []string      `yaml:"labelKeys,omitempty"`
 	LabelValues         []string      `yaml:"labelValues,omitempty"`
+	// Aggregate, if set, exposes the family's series aggregated across all objects by their labelsets instead, e.g.,
+	// counting objects by their phase, or summing a field's value by namespace.
+	Aggregate AggregateType `yaml:"aggregate,omitempty"`
+	// Filter, if set, is a CEL expression evaluated against each object, and only objects it holds true for produce
+	// samples for the family.
+	Filter string `yaml:"filter,omitempty"`
+	// NaNPolicy sets whether samples resolving to NaN are exposed (emit), or left out (skip).
+	NaNPolicy NaNPolicy `yaml:"nanPolicy,omitempty"`
+	// Evaluate sets whether the family's series are rendered as its objects' events are processed (onEvent), or
+	// re-resolved against them at scrape time (onScrape), at the cost of resolving them on each scrape.
+	Evaluate EvaluationPolicy `yaml:"evaluate,omitempty"`
+	// MaxSeriesPerObject, if set, caps the series each object generates for the family, e.g., off expanded arrays,
+	// dropping the ones beyond it.
+	MaxSeriesPerObject int `yaml:"maxSeriesPerObject,omitempty"`
+	// CustomMetric, if set, serves the family's series on the custom metrics API, as metrics of the objects they are
+	// generated for, e.g., for HorizontalPodAutoscalers to scale on.
+	CustomMetric bool `yaml:"customMetric,omitempty"`
+}
+
+// onScrape reports whether the family is rendered at scrape time.
+func (f *FamilyType) onScrape() bool {
+	return f.Evaluate == EvaluationPolicyOnScrape
 }
 
 // buildMetricString returns the given family in its byte representation.
 func (f *FamilyType) buildMetricString(unstructured *unstructured.Unstructured) string {
 	logger := f.logger.WithValues("family", f.Name)
+	if !f.matches(unstructured) {
+		return ""
+	}
 	familyRawBuilder := getBuilder()
 	defer putBuilder(familyRawBuilder)
 
 	for _, metric := range f.Metrics {
 		metricRawBuilder := getBuilder()
 
-		inheritMetricAttributes(f, metric)
-		resolverInstance, err := f.resolver(metric.Resolver)
+		resolverInstance, err := f.metricResolver(metric)
 		if err != nil {
-			logger.V(1).Error(fmt.Errorf("error resolving metric: %w", err), "skipping")
+			f.skip(logger, metric.Value, unstructured, droppedReasonUnresolved, fmt.Errorf("error resolving metric: %w", err))
 			putBuilder(metricRawBuilder)
 
 			continue
@@ -114,31 +189,112 @@ func (f *FamilyType) buildMetricString(unstructured *unstructured.Unstructured)
 
 		resolvedLabelKeys, resolvedLabelValues, resolvedExpandedLabelSet := resolveLabels(metric, resolverInstance, unstructured.Object)
 
-		resolvedValue, found := resolverInstance.Resolve(metric.Value, unstructured.Object)[metric.Value]
+		if len(metric.Expand) > 0 {
+			f.buildExpandedString(metricRawBuilder, metric, resolverInstance, unstructured, resolvedLabelKeys, resolvedLabelValues, resolvedExpandedLabelSet, logger)
+			familyRawBuilder.WriteString(metricRawBuilder.String())
+			putBuilder(metricRawBuilder)
+
+			continue
+		}
+
+		if metric.EachMap != nil {
+			if err = f.buildEachMapString(metricRawBuilder, metric, unstructured, resolvedLabelKeys, resolvedLabelValues, resolvedExpandedLabelSet, logger); err != nil {
+				f.skip(logger, metric.Value, unstructured, droppedReasonInvalidValue, err)
+			} else {
+				familyRawBuilder.WriteString(metricRawBuilder.String())
+			}
+			putBuilder(metricRawBuilder)
+
+			continue
+		}
+
+		resolvedValue, found := resolveValue(resolverInstance, metric.Value, unstructured.Object)
 		if !found {
-			logger.V(1).Error(fmt.Errorf("error resolving metric value %q", metric.Value), "skipping")
+			f.skip(logger, metric.Value, unstructured, droppedReasonUnresolved, fmt.Errorf("error resolving metric value %q", metric.Value))
+			putBuilder(metricRawBuilder)
+
+			continue
+		}
+
+		resolvedLabelKeys, resolvedLabelValues, resolvedValue, err = metric.Regex.extract(resolverInstance, unstructured.Object, metric.Value, resolvedLabelKeys, resolvedLabelValues, resolvedValue)
+		if err != nil {
+			f.skip(logger, metric.Value, unstructured, droppedReasonInvalidValue, err)
+			putBuilder(metricRawBuilder)
+
+			continue
+		}
+
+		resolvedValue, err = metric.mapValue(resolvedValue)
+		if err != nil {
+			f.skip(logger, metric.Value, unstructured, droppedReasonInvalidValue, err)
 			putBuilder(metricRawBuilder)
 
 			continue
 		}
 
-		err = writeMetricSamples(metricRawBuilder, f.Name, unstructured, resolvedLabelKeys, resolvedLabelValues, resolvedExpandedLabelSet, resolvedValue, logger)
+		if f.sinceTimestamp {
+			resolvedValue, err = secondsSince(resolvedValue, time.Now())
+			if err != nil {
+				f.skip(logger, metric.Value, unstructured, droppedReasonInvalidValue, err)
+				putBuilder(metricRawBuilder)
+
+				continue
+			}
+		}
+
+		if f.Type == FamilyKindHistogram {
+			err = f.buildHistogramString(metricRawBuilder, metric, resolverInstance, unstructured, resolvedLabelKeys, resolvedLabelValues, resolvedExpandedLabelSet, resolvedValue, logger)
+		} else {
+			err = f.writeMetricSamples(metricRawBuilder, unstructured, resolvedLabelKeys, resolvedLabelValues, resolvedExpandedLabelSet, resolvedValue, logger)
+		}
 		if err != nil {
 			putBuilder(metricRawBuilder)
 
 			continue
 		}
-		familyRawBuilder.WriteString(metricRawBuilder.String())
+		if metric.Exemplar != nil {
+			familyRawBuilder.WriteString(attachExemplar(metricRawBuilder.String(), metric.Exemplar.resolve(resolverInstance, unstructured.Object)))
+		} else {
+			familyRawBuilder.WriteString(metricRawBuilder.String())
+		}
 		putBuilder(metricRawBuilder)
 	}
 
-	return familyRawBuilder.String()
+	return f.admit(familyRawBuilder.String())
 }
 
-// inheritMetricAttributes applies family-level labels and resolver to the metric.
-func inheritMetricAttributes(f *FamilyType, metric *MetricType) {
-	metric.LabelKeys = append(metric.LabelKeys, f.LabelKeys...)
-	metric.LabelValues = append(metric.LabelValues, f.LabelValues...)
+// resolveValue resolves the given value query against the given object, reporting whether it resolved. Resolvers map
+// the queries they fail to resolve onto themselves, which are only taken as values if they are literals, e.g., "1".
+func resolveValue(resolverInstance resolver.Resolver, query string, obj map[string]interface{}) (string, bool) {
+	value, found := resolverInstance.Resolve(query, obj)[query]
+	if found && value == query {
+		_, err := parseValue(query)
+		found = err == nil
+	}
+
+	return value, found
+}
+
+// skip logs the given error resolving the given expression against the given object, for which a metric is skipped,
+// unless it was logged recently, records it to be reported in the monitor's status, and counts the metric's sample as
+// dropped for the given reason.
+func (f *FamilyType) skip(logger klog.Logger, expression string, object *unstructured.Unstructured, reason droppedReason, err error) {
+	if logger, ok := resolver.LimitLog(logger, expression, err); ok {
+		logger.V(1).Error(err, "skipping")
+	}
+	f.lastErrors.record(expression, object, err)
+	f.drop(reason)
+}
+
+// matches reports whether the given object passes the family's filter, if any. Objects the filter fails to evaluate
+// for do not.
+func (f *FamilyType) matches(unstructured *unstructured.Unstructured) bool {
+	if f.Filter == "" {
+		return true
+	}
+	celResolver := f.cached(resolver.NewCELResolver(f.logger, f.celCostLimit, f.celTimeout, f.celEvaluations, f.managedRMMNamespace, f.managedRMMName, f.Name).WithVariables(f.celVariables), ResolverTypeCEL)
+
+	return celResolver.Resolve(f.Filter, unstructured.Object)[f.Filter] == "true"
 }
 
 // resolveLabels resolves label keys and values including handling of composite map/list structures.
@@ -157,6 +313,17 @@ func resolveLabels(metric *MetricType, resolverInstance resolver.Resolver, obj m
 	}
 
 	for queryIndex, query := range metric.LabelValues {
+		// Templated label values are rendered as is, regardless of the resolver, and empty if they fail to.
+		if isLabelTemplate(query) {
+			val, err := renderLabelTemplate(query, obj)
+			if err != nil {
+				klog.V(1).ErrorS(err, "ignoring label value")
+			}
+			resolvedLabelValues = append(resolvedLabelValues, val)
+			resolvedLabelKeys = append(resolvedLabelKeys, sanitizeKey(metric.LabelKeys[queryIndex]))
+
+			continue
+		}
 		resolvedLabelset := resolverInstance.Resolve(query, obj)
 		// If the query is found in the resolved labelset, it means we are dealing with non-composite value(s).
 		// For e.g., consider:
@@ -172,8 +339,14 @@ func resolveLabels(metric *MetricType, resolverInstance resolver.Resolver, obj m
 				if regexp.MustCompile(`.+#\d+`).MatchString(k) {
 					key := k[:strings.LastIndex(k, "#")]
 					// If `o.spec.tags` is a list, the labelset will look like `metric_name{tags="tagX"}`,
-					// where the number of generated samples will be same as the length of the list.
-					resolvedExpandedLabelSet[key] = append(resolvedExpandedLabelSet[key], v)
+					// where the number of generated samples will be same as the length of the list. Values are
+					// placed by their index, so the lists resolved alongside each other, e.g., the elements' values
+					// and their indices, line up, and the ones resolved more than once are not duplicated.
+					index, _ := strconv.Atoi(k[strings.LastIndex(k, "#")+1:])
+					if len(resolvedExpandedLabelSet[key]) <= index {
+						resolvedExpandedLabelSet[key] = append(resolvedExpandedLabelSet[key], make([]string, index+1-len(resolvedExpandedLabelSet[key]))...)
+					}
+					resolvedExpandedLabelSet[key][index] = v
 
 					continue
 				}
@@ -183,27 +356,35 @@ func resolveLabels(metric *MetricType, resolverInstance resolver.Resolver, obj m
 		}
 	}
 
+	sortLabels(resolvedLabelKeys, resolvedLabelValues)
+
 	return resolvedLabelKeys, resolvedLabelValues, resolvedExpandedLabelSet
 }
 
+// sortLabels sorts the label pairs by key, so the exposition is stable regardless of the configured (or resolved map) order.
+func sortLabels(keys, values []string) {
+	indices := make([]int, len(keys))
+	for i := range indices {
+		indices[i] = i
+	}
+	slices.SortStableFunc(indices, func(a, b int) int { return strings.Compare(keys[a], keys[b]) })
+	sortedKeys, sortedValues := make([]string, len(keys)), make([]string, len(values))
+	for i, j := range indices {
+		sortedKeys[i], sortedValues[i] = keys[j], values[j]
+	}
+	copy(keys, sortedKeys)
+	copy(values, sortedValues)
+}
+
 // sanitizeKey converts a label key to snake_case and strips non-alphanumeric characters.
 func sanitizeKey(s string) string {
 	return strcase.ToSnake(regexp.MustCompile(`\W`).ReplaceAllString(s, "_"))
 }
 
 // writeMetricSamples writes single or expanded metric values based on label structure.
-func writeMetricSamples(builder *strings.Builder, name string, u *unstructured.Unstructured, keys, values []string, expanded map[string][]string, value string, logger klog.Logger) error {
+func (f *FamilyType) writeMetricSamples(builder *strings.Builder, u *unstructured.Unstructured, keys, values []string, expanded map[string][]string, value string, logger klog.Logger) error {
 	writeMetric := func(k, v []string) error {
-		builder.WriteString(kubeCustomResourcePrefix + name)
-
-		return writeMetricTo(
-			builder,
-			u.GroupVersionKind().Group,
-			u.GroupVersionKind().Version,
-			u.GroupVersionKind().Kind,
-			value,
-			k, v,
-		)
+		return f.writeSeries(builder, kubeCustomResourcePrefix+f.Name, u.GroupVersionKind(), value, k, v)
 	}
 	if len(expanded) == 0 {
 		return writeSingleSample(writeMetric, keys, values, logger)
@@ -212,6 +393,43 @@ func writeMetricSamples(builder *strings.Builder, name string, u *unstructured.U
 	return writeExpandedSamples(writeMetric, keys, values, expanded, logger)
 }
 
+// writeSeries writes a single series with the given name, as per the family's exposition mode and NaN policy.
+func (f *FamilyType) writeSeries(builder *strings.Builder, name string, gvk schema.GroupVersionKind, value string, keys, values []string) error {
+	parsedValue, err := parseValue(value)
+	if err != nil {
+		f.drop(droppedReasonInvalidValue)
+
+		return err
+	}
+	if math.IsNaN(parsedValue) && f.NaNPolicy == NaNPolicySkip {
+		f.drop(droppedReasonNaN)
+
+		return nil
+	}
+	// Validate the labels ahead of writing the series' name, so mismatching ones do not leave a partial series behind.
+	if err = validateLabelLengths(keys, values); err != nil {
+		f.drop(droppedReasonLabelMismatch)
+
+		return err
+	}
+	if err = validateLabelKeys(keys); err != nil {
+		f.drop(droppedReasonSanitization)
+
+		return err
+	}
+	if f.exposition == ExpositionModeStrict {
+		err = writeStrictMetricTo(builder, name, gvk.Group, gvk.Version, gvk.Kind, parsedValue, keys, values)
+	} else {
+		builder.WriteString(name)
+		err = writeMetricTo(builder, gvk.Group, gvk.Version, gvk.Kind, parsedValue, keys, values, f.fixedPointValues)
+	}
+	if err != nil {
+		f.drop(droppedReasonInvalidLabel)
+	}
+
+	return err
+}
+
 // writeSingleSample writes a single metric sample.
 func writeSingleSample(writeFunc func([]string, []string) error, keys, values []string, logger klog.Logger) error {
 	if err := writeFunc(keys, values); err != nil {
@@ -227,12 +445,12 @@ func writeSingleSample(writeFunc func([]string, []string) error, keys, values []
 func writeExpandedSamples(writeFunc func([]string, []string) error, labelKeys, labelValues []string, expanded map[string][]string, logger klog.Logger) error {
 	var seriesToGenerate int
 
-	for k := range expanded {
+	// Expanded labels follow the others, sorted, so the exposition is stable regardless of the map's order.
+	for _, k := range slices.Sorted(maps.Keys(expanded)) {
 		labelKeys = append(labelKeys, k)
 		if len(expanded[k]) > seriesToGenerate {
 			seriesToGenerate = len(expanded[k])
 		}
-		slices.Sort(expanded[k])
 	}
 
 	for range seriesToGenerate {
@@ -268,20 +486,34 @@ func (f *FamilyType) resolver(inheritedResolver ResolverType) (resolver.Resolver
 	case ResolverTypeNone:
 		fallthrough // Default to Unstructured resolver.
 	case ResolverTypeUnstructured:
-		return resolver.NewUnstructuredResolver(f.logger), nil
+		return f.cached(resolver.NewUnstructuredResolver(f.logger), ResolverTypeUnstructured), nil
 	case ResolverTypeCEL:
-		return resolver.NewCELResolver(f.logger, f.celCostLimit, f.celTimeout, f.celEvaluations, f.managedRMMNamespace, f.managedRMMName, f.Name), nil
+		return f.cached(resolver.NewCELResolver(f.logger, f.celCostLimit, f.celTimeout, f.celEvaluations, f.managedRMMNamespace, f.managedRMMName, f.Name).WithVariables(f.celVariables), ResolverTypeCEL), nil
 	default:
 		return nil, fmt.Errorf("error resolving metric: unknown resolver %q", inheritedResolver)
 	}
 }
 
+// cached returns the given resolver, resolving through the family's resolution cache, if any. Families rendered at
+// scrape time re-resolve their queries every time.
+func (f *FamilyType) cached(resolverInstance resolver.Resolver, resolverType ResolverType) resolver.Resolver {
+	if f.onScrape() {
+		return resolverInstance
+	}
+
+	return f.resolutions.wrap(resolverInstance, resolverType)
+}
+
 // buildHeaders generates the header for the given family.
 func (f *FamilyType) buildHeaders() string {
 	header := strings.Builder{}
 	header.WriteString("# HELP " + kubeCustomResourcePrefix + f.Name + " " + f.Help)
 	header.WriteString("\n")
-	header.WriteString("# TYPE " + kubeCustomResourcePrefix + f.Name + " " + metricTypeGauge)
+	metricType := metricTypeGauge
+	if f.Type == FamilyKindHistogram {
+		metricType = string(FamilyKindHistogram)
+	}
+	header.WriteString("# TYPE " + kubeCustomResourcePrefix + f.Name + " " + metricType)
 
 	return header.String()
 }
@@ -43,7 +43,7 @@ func TestFamilyType_rawFrom(t *testing.T) {
 					},
 				},
 			},
-			expected: "kube_customresource_test_family{name=\"test-pod\",namespace=\"test-namespace\",group=\"\",version=\"v1\",kind=\"Pod\"} 42.000000\n",
+			expected: "kube_customresource_test_family{name=\"test-pod\",namespace=\"test-namespace\",group=\"\",version=\"v1\",kind=\"Pod\"} 42\n",
 		},
 		{
 			name: "non-empty family with unstructured resolver",
@@ -59,7 +59,7 @@ func TestFamilyType_rawFrom(t *testing.T) {
 					},
 				},
 			},
-			expected: "kube_customresource_test_family{name=\"test-pod\",namespace=\"test-namespace\",group=\"\",version=\"v1\",kind=\"Pod\"} 42.000000\n",
+			expected: "kube_customresource_test_family{name=\"test-pod\",namespace=\"test-namespace\",group=\"\",version=\"v1\",kind=\"Pod\"} 42\n",
 		},
 		{
 			name: "non-empty family with default (unstructured) resolver",
@@ -75,7 +75,7 @@ func TestFamilyType_rawFrom(t *testing.T) {
 					},
 				},
 			},
-			expected: "kube_customresource_test_family{name=\"test-pod\",namespace=\"test-namespace\",group=\"\",version=\"v1\",kind=\"Pod\"} 42.000000\n",
+			expected: "kube_customresource_test_family{name=\"test-pod\",namespace=\"test-namespace\",group=\"\",version=\"v1\",kind=\"Pod\"} 42\n",
 		},
 		{
 			name: "non-empty family with no resolver (should default to unstructured)",
@@ -90,7 +90,67 @@ func TestFamilyType_rawFrom(t *testing.T) {
 					},
 				},
 			},
-			expected: "kube_customresource_test_family{name=\"test-pod\",namespace=\"test-namespace\",group=\"\",version=\"v1\",kind=\"Pod\"} 42.000000\n",
+			expected: "kube_customresource_test_family{name=\"test-pod\",namespace=\"test-namespace\",group=\"\",version=\"v1\",kind=\"Pod\"} 42\n",
+		},
+		{
+			name: "non-empty family with a matching filter",
+			family: &FamilyType{
+				Name:   "test_family",
+				Help:   "test_help",
+				Filter: "o.metadata.namespace == 'test-namespace'",
+				Metrics: []*MetricType{
+					{
+						Value: "42",
+					},
+				},
+			},
+			expected: "kube_customresource_test_family{group=\"\",version=\"v1\",kind=\"Pod\"} 42\n",
+		},
+		{
+			name: "non-empty family with a non-matching filter",
+			family: &FamilyType{
+				Name:   "test_family",
+				Help:   "test_help",
+				Filter: "has(o.spec) && o.spec.paused",
+				Metrics: []*MetricType{
+					{
+						Value: "42",
+					},
+				},
+			},
+			expected: ``,
+		},
+		{
+			name: "non-empty family with special and scientific notation values",
+			family: &FamilyType{
+				Name: "test_family",
+				Help: "test_help",
+				Metrics: []*MetricType{
+					{LabelKeys: []string{"case"}, LabelValues: []string{"nan"}, Value: "NaN"},
+					{LabelKeys: []string{"case"}, LabelValues: []string{"scientific"}, Value: "-1.5e3"},
+					{LabelKeys: []string{"case"}, LabelValues: []string{"overflow"}, Value: "1e400"},
+					{LabelKeys: []string{"case"}, LabelValues: []string{"boolean"}, Value: "true"},
+					{LabelKeys: []string{"case"}, LabelValues: []string{"cel"}, Value: "o.metadata.name == 'test-pod'", Resolver: ResolverTypeCEL},
+				},
+			},
+			expected: "kube_customresource_test_family{case=\"nan\",group=\"\",version=\"v1\",kind=\"Pod\"} NaN\n" +
+				"kube_customresource_test_family{case=\"scientific\",group=\"\",version=\"v1\",kind=\"Pod\"} -1500\n" +
+				"kube_customresource_test_family{case=\"overflow\",group=\"\",version=\"v1\",kind=\"Pod\"} +Inf\n" +
+				"kube_customresource_test_family{case=\"boolean\",group=\"\",version=\"v1\",kind=\"Pod\"} 1\n" +
+				"kube_customresource_test_family{case=\"cel\",group=\"\",version=\"v1\",kind=\"Pod\"} 1\n",
+		},
+		{
+			name: "non-empty family skipping NaN values",
+			family: &FamilyType{
+				Name:      "test_family",
+				Help:      "test_help",
+				NaNPolicy: NaNPolicySkip,
+				Metrics: []*MetricType{
+					{Value: "NaN"},
+					{Value: "42"},
+				},
+			},
+			expected: "kube_customresource_test_family{group=\"\",version=\"v1\",kind=\"Pod\"} 42\n",
 		},
 	}
 
@@ -104,3 +164,33 @@ func TestFamilyType_rawFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestFamilyType_buildMetricString_wildcard(t *testing.T) {
+	t.Parallel()
+	pod := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"name": "test-pod"},
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"image": "nginx"},
+					map[string]interface{}{"image": "nginx"},
+				},
+			},
+		},
+	}
+	family := &FamilyType{
+		Name: "test_family",
+		Help: "test_help",
+		Metrics: []*MetricType{
+			{LabelKeys: []string{"image"}, LabelValues: []string{"spec.containers[*].image"}, Value: "1"},
+		},
+	}
+	// Elements resolving to the same value are told apart by their index.
+	expected := "kube_customresource_test_family{containers_index=\"0\",image=\"nginx\",group=\"\",version=\"v1\",kind=\"Pod\"} 1\n" +
+		"kube_customresource_test_family{containers_index=\"1\",image=\"nginx\",group=\"\",version=\"v1\",kind=\"Pod\"} 1\n"
+	if actual := family.buildMetricString(pod); actual != expected {
+		t.Errorf("%s\n%s", actual, cmp.Diff(actual, expected))
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// clusterLabelKey is the label federated stores set to the name of the cluster each sample comes from.
+const clusterLabelKey = "cluster"
+
+// localCluster is the name the cluster the controller connects to is known by outside of federation mode. Its samples
+// are not labeled.
+const localCluster = ""
+
+// parseClusters parses the given (comma-separated) name=path cluster entries into kubeconfig paths by cluster names.
+// An empty path stands for the cluster the controller connects to.
+func parseClusters(entries []string) (map[string]string, error) {
+	clusters := map[string]string{}
+	for _, entry := range entries {
+		for _, pair := range strings.Split(entry, ",") {
+			name, path, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("cluster %q must be of the form <name>=<kubeconfig>", pair)
+			}
+			if name == localCluster {
+				return nil, fmt.Errorf("cluster %q must be named", pair)
+			}
+			if errs := validation.IsValidLabelValue(name); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid cluster name %q: %s", name, strings.Join(errs, ", "))
+			}
+			if _, ok := clusters[name]; ok {
+				return nil, fmt.Errorf("duplicate cluster name %q", name)
+			}
+			clusters[name] = path
+		}
+	}
+
+	return clusters, nil
+}
+
+// NewClusterClientsets returns a dynamic client-set for each of the given (comma-separated) name=path cluster entries,
+// by their names.
+// Entries with an empty path use the given configuration, i.e., that of the cluster the controller connects to.
+func NewClusterClientsets(entries *[]string, local *rest.Config) (map[string]dynamic.Interface, error) {
+	if entries == nil || len(*entries) == 0 {
+		return nil, nil
+	}
+	clusters, err := parseClusters(*entries)
+	if err != nil {
+		return nil, err
+	}
+	clientsets := make(map[string]dynamic.Interface, len(clusters))
+	for name, path := range clusters {
+		cfg := local
+		if path != "" {
+			cfg, _, err = BuildConfig("", path)
+			if err != nil {
+				return nil, fmt.Errorf("error building kubeconfig for cluster %q: %w", name, err)
+			}
+		}
+		clientsets[name], err = dynamic.NewForConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("error building dynamic clientset for cluster %q: %w", name, err)
+		}
+	}
+
+	return clientsets, nil
+}
+
+// clusterStore feeds a store with the objects of a single federated cluster, labeling their samples with its name.
+type clusterStore struct {
+	*StoreType
+	cluster string
+}
+
+// Ensure clusterStore implements cache.Store.
+var _ cache.Store = &clusterStore{}
+
+// forCluster returns the cache.Store feeding the store with the given cluster's objects.
+func (s *StoreType) forCluster(cluster string) cache.Store {
+	if cluster == localCluster {
+		return s
+	}
+
+	return &clusterStore{StoreType: s, cluster: cluster}
+}
+
+// Add generates the metrics for the given object, labeled with the cluster's name.
+func (c *clusterStore) Add(objectI interface{}) error {
+	c.watched(objectI)
+
+	return c.add(objectI, c.cluster, false)
+}
+
+// Update regenerates the metrics for the given object, labeled with the cluster's name.
+func (c *clusterStore) Update(objectI interface{}) error {
+	c.watched(objectI)
+
+	return c.add(objectI, c.cluster, false)
+}
+
+// Replace generates the metrics for all listed objects, labeled with the cluster's name.
+func (c *clusterStore) Replace(items []interface{}, resourceVersion string) error {
+	return c.replace(items, resourceVersion, c.cluster)
+}
+
+// withClusterLabel labels each series in the given family with the given cluster, if any.
+func withClusterLabel(metricFamily, cluster string) string {
+	if cluster == localCluster {
+		return metricFamily
+	}
+
+	return withLabel(metricFamily, clusterLabelKey+`="`+cluster+`"`)
+}
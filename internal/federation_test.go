@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestParseClusters(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		entries  []string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "repeated and comma-separated entries",
+			entries:  []string{"east=/tmp/east,west=/tmp/west", "hub="},
+			expected: map[string]string{"east": "/tmp/east", "west": "/tmp/west", "hub": ""},
+		},
+		{
+			name:    "missing path",
+			entries: []string{"east"},
+			wantErr: true,
+		},
+		{
+			name:    "missing name",
+			entries: []string{"=/tmp/east"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			entries: []string{"east coast=/tmp/east"},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate name",
+			entries: []string{"east=/tmp/east", "east=/tmp/west"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseClusters(tt.entries)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %t, got %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(got, tt.expected); !tt.wantErr && diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithClusterLabel(t *testing.T) {
+	t.Parallel()
+	series := "foo{name=\"a\",kind=\"Foo\"} 1 # {trace_id=\"abc\"} 1\n"
+	if got := withClusterLabel(series, localCluster); got != series {
+		t.Errorf("expected the local cluster's series to be left as is, got %q", got)
+	}
+
+	// The cluster label is added to the series' labels, rather than the exemplar's, so it survives stripping exemplars.
+	got := withClusterLabel(series, "east")
+	expected := "foo{name=\"a\",kind=\"Foo\",cluster=\"east\"} 1 # {trace_id=\"abc\"} 1\n"
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected series (-want +got):\n%s", diff)
+	}
+	expectedStripped := "foo{name=\"a\",kind=\"Foo\",cluster=\"east\"} 1\n"
+	if diff := cmp.Diff(expectedStripped, stripExemplars(got)); diff != "" {
+		t.Errorf("unexpected stripped series (-want +got):\n%s", diff)
+	}
+}
+
+func TestConfigurer_build_federated(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gvr := schema.GroupVersionResource{Group: "contoso.com", Version: "v1", Resource: "foos"}
+	newClient := func(name string) dynamic.Interface {
+		object := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}}}
+		object.SetGroupVersionKind(schema.GroupVersionKind{Group: "contoso.com", Version: "v1", Kind: "Foo"})
+		object.SetNamespace("default")
+		object.SetName(name)
+		object.SetUID(types.UID("uid-" + name))
+
+		return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "FooList"}, object)
+	}
+
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "federated", Namespace: "default"}}
+	c := newConfigurer(nil, resource, 0, 0, nil)
+	c.clusters = map[string]dynamic.Interface{"east": newClient("foo"), "west": newClient("bar")}
+	if err := c.parse(`stores:
+  - group: "contoso.com"
+    version: "v1"
+    kind: "Foo"
+    resource: "foos"
+    families:
+      - name: "replicas"
+        help: "Replicas"
+        metrics:
+          - labelKeys: ["name"]
+            labelValues: ["metadata.name"]
+            value: "spec.replicas"
+`); err != nil {
+		t.Fatal(err)
+	}
+	stores := &sync.Map{}
+	c.build(ctx, stores)
+	value, _ := stores.Load(storesKey(resource))
+	builtStores, _ := value.([]*StoreType)
+
+	// Both clusters' objects are written out under a single set of headers, labeled with their cluster.
+	expected := `# HELP kube_customresource_replicas Replicas
+# TYPE kube_customresource_replicas gauge
+kube_customresource_replicas{name="bar",group="contoso.com",version="v1",kind="Foo",cluster="west"} 1
+kube_customresource_replicas{name="foo",group="contoso.com",version="v1",kind="Foo",cluster="east"} 1
+`
+	var got string
+	err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		buffer := &bytes.Buffer{}
+		if err := newMetricsWriter(builtStores...).writeStores(buffer); err != nil {
+			return false, err
+		}
+		got = sortSeries(buffer.String())
+
+		return got == expected, nil
+	})
+	if err != nil {
+		t.Fatalf("expected exposition %q, got %q: %v", expected, got, err)
+	}
+}
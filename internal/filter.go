@@ -0,0 +1,99 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// storeFilter narrows down the objects a store caches metrics for, client-side. This complements the store's
+// selectors, as the API server supports field selectors on few fields of custom resources.
+type storeFilter struct {
+	// expression is the CEL expression objects must hold true for, if set.
+	expression string
+	resolver   *resolver.CELResolver
+	// fieldSelector is the field selector objects must match, once the API server has rejected it.
+	fieldSelector atomic.Pointer[fields.Selector]
+}
+
+// newStoreFilter returns a filter for the given CEL expression, evaluated with the given resolver.
+func newStoreFilter(expression string, celResolver *resolver.CELResolver) *storeFilter {
+	return &storeFilter{
+		expression: expression,
+		resolver:   celResolver,
+	}
+}
+
+// forTarget returns a filter sharing the given one's expression, for another target, whose API server may support
+// the field selector.
+func (f *storeFilter) forTarget() *storeFilter {
+	return newStoreFilter(f.expression, f.resolver)
+}
+
+// fallBack applies the given field selector client-side from now on, and reports whether it could be parsed.
+func (f *storeFilter) fallBack(fieldSelector string) bool {
+	selector, err := fields.ParseSelector(fieldSelector)
+	if err != nil {
+		return false
+	}
+	f.fieldSelector.Store(&selector)
+
+	return true
+}
+
+// matches reports whether the given object passes the filter. Objects the expression fails to evaluate for do not.
+func (f *storeFilter) matches(object *unstructured.Unstructured) bool {
+	if f == nil {
+		return true
+	}
+	if selector := f.fieldSelector.Load(); selector != nil && !(*selector).Matches(objectFields(object, *selector)) {
+		return false
+	}
+	if f.expression == "" {
+		return true
+	}
+
+	return f.resolver.Resolve(f.expression, object.Object)[f.expression] == "true"
+}
+
+// objectFields returns the (dot-separated) fields of the given object the given selector requires, with absent or
+// composite ones left empty.
+func objectFields(object *unstructured.Unstructured, selector fields.Selector) fields.Set {
+	set := fields.Set{}
+	for _, requirement := range selector.Requirements() {
+		value, found, err := unstructured.NestedFieldNoCopy(object.Object, strings.Split(requirement.Field, ".")...)
+		if !found || err != nil {
+			set[requirement.Field] = ""
+
+			continue
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			set[requirement.Field] = ""
+		default:
+			set[requirement.Field] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/klog/v2"
+)
+
+func TestStoreFilter_matches(t *testing.T) {
+	t.Parallel()
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "foo"},
+		"spec":     map[string]interface{}{"paused": true, "tier": "gold"},
+	}}
+	tests := []struct {
+		name          string
+		expression    string
+		fieldSelector string
+		expected      bool
+	}{
+		{name: "no filter", expected: true},
+		{name: "matching expression", expression: "o.spec.paused", expected: true},
+		{name: "non-matching expression", expression: "o.spec.tier == 'silver'"},
+		{name: "non-boolean expression", expression: "o.spec.tier"},
+		{name: "matching field selector", fieldSelector: "spec.tier=gold,metadata.name=foo", expected: true},
+		{name: "non-matching field selector", fieldSelector: "spec.tier!=gold"},
+		{name: "absent field", fieldSelector: "spec.missing=gold"},
+		{name: "matching field selector and non-matching expression", expression: "!o.spec.paused", fieldSelector: "spec.tier=gold"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			filter := newStoreFilter(tt.expression, newCELResolver(klog.Background(), 0, 0, nil, nil, "", ""))
+			if tt.fieldSelector != "" && !filter.fallBack(tt.fieldSelector) {
+				t.Fatalf("failed to parse field selector %q", tt.fieldSelector)
+			}
+			if got := filter.matches(object); got != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildLW_fieldSelectorFallback(t *testing.T) {
+	t.Parallel()
+	gvr := schema.GroupVersionResource{Group: "contoso.com", Version: "v1alpha1", Resource: "bars"}
+	var objects []runtime.Object
+	for _, object := range newSyntheticObjects(4) {
+		objects = append(objects, object)
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{gvr: "BarList"}, objects...)
+	// Reject field selectors, as the API server does for non-selectable custom resource fields.
+	client.PrependReactor("list", "bars", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		listAction, ok := action.(clienttesting.ListAction)
+		if !ok || listAction.GetListRestrictions().Fields.Empty() {
+			return false, nil, nil
+		}
+
+		return true, nil, apierrors.NewBadRequest("field label not supported: metadata.uid")
+	})
+
+	filter := newStoreFilter("", nil)
+	list, err := buildLW(context.Background(), client, metav1.NamespaceAll, "", "metadata.uid=uid-1", gvr, &StoreType{filter: filter}).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var matching int
+	for _, item := range list.(*unstructured.UnstructuredList).Items {
+		if filter.matches(&item) {
+			matching++
+		}
+	}
+	if matching != 1 {
+		t.Errorf("expected 1 object to match client-side, got %d", matching)
+	}
+
+	// Without a filter to fall back to, the error is surfaced.
+	if _, err = buildLW(context.Background(), client, metav1.NamespaceAll, "", "metadata.uid=uid-1", gvr, nil).List(metav1.ListOptions{}); !apierrors.IsBadRequest(err) {
+		t.Errorf("expected a bad request error, got %v", err)
+	}
+}
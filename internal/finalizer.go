@@ -0,0 +1,74 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// The controller sets a finalizer on the monitors it processes, so that everything derived from them, i.e., their
+// stores, the series persisted in their storages, their dedicated endpoints, and their ServiceMonitors, is cleaned up
+// before their deletion completes, instead of relying on the delete event alone, which is missed if the controller is
+// down at the time.
+
+// addFinalizer sets the finalizer on the given resource, unless it is being deleted, which the caller updates.
+func addFinalizer(resource *v1alpha1.ResourceMetricsMonitor) {
+	if resource.GetDeletionTimestamp() != nil || slices.Contains(resource.GetFinalizers(), v1alpha1.Finalizer) {
+		return
+	}
+	resource.SetFinalizers(append(resource.GetFinalizers(), v1alpha1.Finalizer))
+}
+
+// finalize cleans up everything derived from the given resource, which is being deleted, and removes its finalizer,
+// so that its deletion completes. Errors are returned so that the resource is requeued, keeping the finalizer.
+func (c *Controller) finalize(ctx context.Context, stores *sync.Map, resource *v1alpha1.ResourceMetricsMonitor) error {
+	if !slices.Contains(resource.GetFinalizers(), v1alpha1.Finalizer) {
+		return nil
+	}
+	if err := c.deleteServiceMonitor(ctx, resource); err != nil {
+		return err
+	}
+	_ = c.processDelete(stores, resource)
+
+	kObj := klog.KObj(resource).String()
+	gotResource, err := c.monitors(resource.GetNamespace()).Get(ctx, resource.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", kObj, err)
+	}
+	resource = gotResource.DeepCopy()
+	resource.SetFinalizers(slices.DeleteFunc(resource.GetFinalizers(), func(finalizer string) bool {
+		return finalizer == v1alpha1.Finalizer
+	}))
+	_, err = c.monitors(resource.GetNamespace()).Update(ctx, resource, metav1.UpdateOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to remove the finalizer from %s: %w", kObj, err)
+	}
+	klog.FromContext(ctx).V(1).Info("Finalized", "resource", kObj)
+
+	return nil
+}
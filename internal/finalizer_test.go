@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestAddFinalizer(t *testing.T) {
+	t.Parallel()
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", Finalizers: []string{"other"}}}
+
+	// Adding the finalizer twice sets it once.
+	addFinalizer(resource)
+	addFinalizer(resource)
+	if got := resource.GetFinalizers(); len(got) != 2 || got[1] != v1alpha1.Finalizer {
+		t.Errorf("expected the finalizer to be added once, got %v", got)
+	}
+
+	// Finalizers may not be added to resources being deleted.
+	resource = &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", DeletionTimestamp: ptr.To(metav1.Now())}}
+	addFinalizer(resource)
+	if got := resource.GetFinalizers(); len(got) != 0 {
+		t.Errorf("expected no finalizer on a resource being deleted, got %v", got)
+	}
+}
+
+func TestController_finalize(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{
+		Namespace:         "default",
+		Name:              "foo",
+		DeletionTimestamp: ptr.To(metav1.Now()),
+		Finalizers:        []string{v1alpha1.Finalizer, "other"},
+	}}
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVR.GroupVersion().WithKind("ServiceMonitor"))
+	serviceMonitor.SetNamespace("default")
+	serviceMonitor.SetName("foo")
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		serviceMonitorGVR: "ServiceMonitorList",
+	}, serviceMonitor)
+	labelKeys := []string{"namespace", "name"}
+	c := &Controller{
+		rsmClientset:     fake.NewSimpleClientset(resource),
+		dynamicClientset: dynamicClient,
+		options:          &Options{ServiceMonitorService: ptr.To("monitoring/rsm")},
+		metrics: metrics{
+			resourcesMonitored: prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "resources_monitored_info"}, labelKeys),
+			monitorConditions:  prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "monitor_condition"}, append(labelKeys, "type")),
+			lastReconcile:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "monitor_last_reconcile_timestamp_seconds"}, labelKeys),
+			monitorStores:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "monitor_stores"}, labelKeys),
+			droppedSamples:     prometheus.NewCounterVec(prometheus.CounterOpts{Name: "dropped_samples_total"}, append(labelKeys, "reason")),
+			ksmCollisions:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "ksm_collisions"}, labelKeys),
+		},
+	}
+	s := &StoreType{metrics: memoryStorage{"uid": {"foo 1"}}}
+	c.stores.Store(storesKey(resource), []*StoreType{s})
+
+	if err := c.finalize(ctx, &c.stores, resource); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dynamicClient.Resource(serviceMonitorGVR).Namespace("default").Get(ctx, "foo", metav1.GetOptions{}); err == nil {
+		t.Error("expected the ServiceMonitor to be deleted")
+	}
+	if _, ok := c.stores.Load(storesKey(resource)); ok {
+		t.Error("expected the stores to be dropped")
+	}
+	if _, ok := s.metrics.get("uid"); ok {
+		t.Error("expected the stores' series to be purged")
+	}
+	got, err := c.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors("default").Get(ctx, "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if finalizers := got.GetFinalizers(); len(finalizers) != 1 || finalizers[0] != "other" {
+		t.Errorf("expected only the controller's finalizer to be removed, got %v", finalizers)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// GeneratorType represents a built-in set of families generated for a store's targets, sparing their configuration.
+type GeneratorType string
+
+const (
+	// GeneratorTypeScale generates the spec_replicas and status_replicas families, off the paths the targets' CRD
+	// defines for their scale subresource, if any.
+	GeneratorTypeScale GeneratorType = "scale"
+	// GeneratorTypeObservedGeneration generates the observed_generation_lag family, i.e., how many generations the
+	// targets' status lags behind their spec, and the observed_generation_caught_up stateset.
+	GeneratorTypeObservedGeneration GeneratorType = "observedGeneration"
+	// GeneratorTypeAgeSeconds, given as ageSeconds(path), generates the <path>_age_seconds family, i.e., the seconds
+	// elapsed since the timestamp at the targets' path, or age_seconds for their metadata.creationTimestamp. It is
+	// evaluated at scrape time, so it stays accurate between the targets' updates.
+	GeneratorTypeAgeSeconds GeneratorType = "ageSeconds"
+)
+
+// generatorArgumentRegex matches generators taking an argument, e.g., ageSeconds(metadata.creationTimestamp).
+var generatorArgumentRegex = regexp.MustCompile(`^(\w+)\((.+)\)$`)
+
+// parseGenerator returns the type of the given generator, along with its argument, if any.
+func parseGenerator(generator GeneratorType) (GeneratorType, string) {
+	matches := generatorArgumentRegex.FindStringSubmatch(string(generator))
+	if matches == nil {
+		return generator, ""
+	}
+
+	return GeneratorType(matches[1]), strings.TrimSpace(matches[2])
+}
+
+// generateFamilies returns the families generated for the given store's generators. Generators that cannot be
+// applied to the store's targets are skipped.
+func generateFamilies(ctx context.Context, dynamicClientset dynamic.Interface, cfg *StoreType) []*FamilyType {
+	logger := klog.FromContext(ctx)
+	var families []*FamilyType
+	for _, generator := range cfg.Generators {
+		generatorType, argument := parseGenerator(generator)
+		switch generatorType {
+		case GeneratorTypeScale:
+			scaleFamilies, err := generateScaleFamilies(ctx, dynamicClientset, cfg)
+			if err != nil {
+				logger.Error(err, "skipping generator", "generator", generator, "gvr", buildGVKR(cfg).GroupVersionResource.String())
+
+				continue
+			}
+			families = append(families, scaleFamilies...)
+		case GeneratorTypeObservedGeneration:
+			families = append(families, generateObservedGenerationFamilies()...)
+		case GeneratorTypeAgeSeconds:
+			families = append(families, generateAgeSecondsFamily(argument))
+		}
+	}
+
+	return families
+}
+
+// generateScaleFamilies returns the families reporting the replicas of the given store's targets, as read off the
+// paths their CRD defines for the scale subresource.
+func generateScaleFamilies(ctx context.Context, dynamicClientset dynamic.Interface, cfg *StoreType) ([]*FamilyType, error) {
+	if isNativeGroup(cfg.Group) {
+		return nil, fmt.Errorf("scale subresource paths are only known for custom resources, not %q", cfg.Group)
+	}
+	crdName := cfg.Resource + "." + cfg.Group
+	crd, err := dynamicClientset.Resource(crdGVKR.GroupVersionResource).Get(ctx, crdName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting CRD %s: %w", crdName, err)
+	}
+	scale, err := scaleSubresource(crd, cfg.Version)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the scale subresource of CRD %s: %w", crdName, err)
+	}
+
+	statusReplicas := &MetricType{Value: scale.statusReplicasPath}
+	if scale.labelSelectorPath != "" {
+		statusReplicas.LabelKeys = []string{"selector"}
+		statusReplicas.LabelValues = []string{scale.labelSelectorPath}
+	}
+
+	return []*FamilyType{
+		{
+			Name:     "spec_replicas",
+			Help:     "Desired number of replicas, as per the scale subresource.",
+			Resolver: ResolverTypeUnstructured,
+			Metrics:  []*MetricType{{Value: scale.specReplicasPath}},
+		},
+		{
+			Name:     "status_replicas",
+			Help:     "Observed number of replicas, as per the scale subresource.",
+			Resolver: ResolverTypeUnstructured,
+			Metrics:  []*MetricType{statusReplicas},
+		},
+	}, nil
+}
+
+// generateObservedGenerationFamilies returns the families reporting the reconciliation lag of the targets, as per
+// their status.observedGeneration. Targets not reporting one produce no samples.
+func generateObservedGenerationFamilies() []*FamilyType {
+	caughtUp := "o.metadata.generation == o.status.observedGeneration"
+
+	return []*FamilyType{
+		{
+			Name:     "observed_generation_lag",
+			Help:     "Number of generations the status lags behind the spec, as per status.observedGeneration.",
+			Resolver: ResolverTypeCEL,
+			Metrics:  []*MetricType{{Value: "o.metadata.generation - o.status.observedGeneration"}},
+		},
+		{
+			Name:     "observed_generation_caught_up",
+			Help:     "Whether the status caught up with the spec, as per status.observedGeneration.",
+			Resolver: ResolverTypeCEL,
+			Metrics: []*MetricType{
+				{Value: caughtUp, LabelKeys: []string{"caught_up"}, LabelValues: []string{"'true'"}},
+				{Value: "!(" + caughtUp + ")", LabelKeys: []string{"caught_up"}, LabelValues: []string{"'false'"}},
+			},
+		},
+	}
+}
+
+// generateAgeSecondsFamily returns the family reporting the seconds elapsed since the timestamp at the given path of
+// the targets. Targets not carrying a timestamp there produce no samples.
+func generateAgeSecondsFamily(path string) *FamilyType {
+	path = strings.TrimPrefix(path, ".")
+	name := "age_seconds"
+	if path != "metadata.creationTimestamp" {
+		name = sanitizeKey(path) + "_" + name
+	}
+
+	return &FamilyType{
+		Name:           name,
+		Help:           fmt.Sprintf("Seconds elapsed since %s.", path),
+		Resolver:       ResolverTypeUnstructured,
+		Metrics:        []*MetricType{{Value: path}},
+		Evaluate:       EvaluationPolicyOnScrape,
+		sinceTimestamp: true,
+	}
+}
+
+// scalePaths holds the paths a CRD defines for its scale subresource, as dot-separated paths.
+type scalePaths struct {
+	specReplicasPath   string
+	statusReplicasPath string
+	labelSelectorPath  string
+}
+
+// scaleSubresource returns the paths the given CRD defines for the scale subresource of the given version.
+func scaleSubresource(crd *unstructured.Unstructured, version string) (scalePaths, error) {
+	versions, _, err := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if err != nil {
+		return scalePaths{}, err
+	}
+	for _, v := range versions {
+		versionMap, ok := v.(map[string]interface{})
+		if !ok || versionMap["name"] != version {
+			continue
+		}
+		scale, found, err := unstructured.NestedStringMap(versionMap, "subresources", "scale")
+		if err != nil {
+			return scalePaths{}, err
+		}
+		if !found {
+			return scalePaths{}, fmt.Errorf("version %s defines no scale subresource", version)
+		}
+
+		// JSON paths are given as .spec.replicas, while the unstructured resolver expects spec.replicas.
+		return scalePaths{
+			specReplicasPath:   strings.TrimPrefix(scale["specReplicasPath"], "."),
+			statusReplicasPath: strings.TrimPrefix(scale["statusReplicasPath"], "."),
+			labelSelectorPath:  strings.TrimPrefix(scale["labelSelectorPath"], "."),
+		}, nil
+	}
+
+	return scalePaths{}, fmt.Errorf("version %s is not defined", version)
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/klog/v2"
+)
+
+func TestGenerateScaleFamilies(t *testing.T) {
+	t.Parallel()
+	crd := newTestCRD("foos.contoso.com", "contoso.com", "Foo", "foos", nil,
+		map[string]interface{}{
+			"name":    "v1",
+			"served":  true,
+			"storage": true,
+			"subresources": map[string]interface{}{
+				"scale": map[string]interface{}{
+					"specReplicasPath":   ".spec.replicas",
+					"statusReplicasPath": ".status.replicas",
+					"labelSelectorPath":  ".status.selector",
+				},
+			},
+		},
+		map[string]interface{}{"name": "v1alpha1", "served": true, "storage": false},
+	)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crdGVKR.GroupVersionResource: "CustomResourceDefinitionList",
+	}, crd)
+	cfg := &StoreType{Group: "contoso.com", Version: "v1", Kind: "Foo", Resource: "foos", Generators: []GeneratorType{GeneratorTypeScale}}
+
+	families := generateFamilies(context.Background(), client, cfg)
+	got := map[string][]*MetricType{}
+	for _, family := range families {
+		got[family.Name] = family.Metrics
+	}
+	expected := map[string][]*MetricType{
+		"spec_replicas":   {{Value: "spec.replicas"}},
+		"status_replicas": {{Value: "status.replicas", LabelKeys: []string{"selector"}, LabelValues: []string{"status.selector"}}},
+	}
+	if diff := cmp.Diff(expected, got, cmp.AllowUnexported(MetricType{})); diff != "" {
+		t.Errorf("unexpected families (-want +got):\n%s", diff)
+	}
+
+	// Versions without a scale subresource, and missing CRDs, generate nothing.
+	for _, cfg := range []*StoreType{
+		{Group: "contoso.com", Version: "v1alpha1", Kind: "Foo", Resource: "foos", Generators: []GeneratorType{GeneratorTypeScale}},
+		{Group: "contoso.com", Version: "v1", Kind: "Bar", Resource: "bars", Generators: []GeneratorType{GeneratorTypeScale}},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Resource: "deployments", Generators: []GeneratorType{GeneratorTypeScale}},
+	} {
+		if families := generateFamilies(context.Background(), client, cfg); len(families) != 0 {
+			t.Errorf("expected no families for %s, got %d", cfg.Resource, len(families))
+		}
+	}
+}
+
+func TestGenerateObservedGenerationFamilies(t *testing.T) {
+	t.Parallel()
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "foo", "generation": int64(3)},
+		"status":   map[string]interface{}{"observedGeneration": int64(2)},
+	}}
+	object.SetGroupVersionKind(schema.GroupVersionKind{Group: "contoso.com", Version: "v1", Kind: "Foo"})
+	unreported := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "bar", "generation": int64(1)},
+	}}
+	unreported.SetGroupVersionKind(object.GroupVersionKind())
+
+	var got, gotUnreported string
+	for _, family := range generateFamilies(context.Background(), nil, &StoreType{Generators: []GeneratorType{GeneratorTypeObservedGeneration}}) {
+		got += family.buildMetricString(object)
+		gotUnreported += family.buildMetricString(unreported)
+	}
+	expected := "kube_customresource_observed_generation_lag{group=\"contoso.com\",version=\"v1\",kind=\"Foo\"} 1\n" +
+		"kube_customresource_observed_generation_caught_up{caught_up=\"true\",group=\"contoso.com\",version=\"v1\",kind=\"Foo\"} 0\n" +
+		"kube_customresource_observed_generation_caught_up{caught_up=\"false\",group=\"contoso.com\",version=\"v1\",kind=\"Foo\"} 1\n"
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected exposition (-want +got):\n%s", diff)
+	}
+	if gotUnreported != "" {
+		t.Errorf("expected no samples for objects not reporting an observed generation, got %q", gotUnreported)
+	}
+}
+
+func TestGenerateAgeSecondsFamily(t *testing.T) {
+	t.Parallel()
+	for generator, expected := range map[GeneratorType]string{
+		"ageSeconds(metadata.creationTimestamp)": "age_seconds",
+		"ageSeconds(.status.lastScheduleTime)":   "status_last_schedule_time_age_seconds",
+	} {
+		families := generateFamilies(context.Background(), nil, &StoreType{Generators: []GeneratorType{generator}})
+		if len(families) != 1 || families[0].Name != expected {
+			t.Errorf("expected a single %s family for %s, got %v", expected, generator, families)
+		}
+	}
+
+	families := generateFamilies(context.Background(), nil, &StoreType{Generators: []GeneratorType{"ageSeconds(metadata.creationTimestamp)"}})
+	s := newStore(klog.Background(), []string{"# HELP kube_customresource_age_seconds"}, families, ResolverTypeUnstructured, nil, nil, 0, 0)
+	object := newSyntheticObjects(1)[0]
+	object.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-time.Hour)))
+	if err := s.Add(object); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.metrics.get(object.GetUID()); got[0] != "" {
+		t.Errorf("expected the family not to be rendered until scraped, got %q", got[0])
+	}
+
+	// The age is evaluated when rendered, not when the object was added.
+	got := s.renderOnScrape(object.GetUID(), 0)
+	prefix := "kube_customresource_age_seconds{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} "
+	if !strings.HasPrefix(got, prefix) {
+		t.Fatalf("expected a series prefixed with %q, got %q", prefix, got)
+	}
+	age, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(got, prefix)), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if age < time.Hour.Seconds() {
+		t.Errorf("expected an age of at least an hour, got %vs", age)
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const genGoldenSubcommandName = "gen-golden"
+
+// goldenRule mirrors the golden rule format consumed by the e2e tests (see tests/golden).
+type goldenRule struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	In          map[string]interface{} `json:"in"`
+	Out         struct {
+		Metrics []string `json:"metrics"`
+	} `json:"out"`
+}
+
+// genGolden runs the given ResourceMetricsMonitor against the given custom resources, and writes (or updates) the
+// golden rule asserting the resulting exposition.
+func genGolden(ctx context.Context, args []string) error {
+	var crPaths stringSliceFlag
+	flags := flag.NewFlagSet(genGoldenSubcommandName, flag.ContinueOnError)
+	rmmPath := flags.String("rmm", "", "Path to the ResourceMetricsMonitor manifest.")
+	flags.Var(&crPaths, "cr", "Path to a custom resource manifest (may contain multiple documents). Can be repeated.")
+	outPath := flags.String("out", "", "Path to the golden rule to write, e.g., tests/golden/unstructured/<suite>/<name>.yaml.")
+	name := flags.String("name", "", "Name of the golden rule. Defaults to the existing rule's name, or the file name.")
+	description := flags.String("description", "", "Description of the golden rule. Defaults to the existing rule's description.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *rmmPath == "" || *outPath == "" || len(crPaths) == 0 {
+		flags.Usage()
+
+		return errors.New("-rmm, -out, and at least one -cr are required")
+	}
+
+	rmmObjects, err := decodeManifests(*rmmPath)
+	if err != nil {
+		return err
+	}
+	if len(rmmObjects) != 1 {
+		return fmt.Errorf("expected exactly one ResourceMetricsMonitor in %s, got %d objects", *rmmPath, len(rmmObjects))
+	}
+	rmm := &v1alpha1.ResourceMetricsMonitor{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(rmmObjects[0].Object, rmm); err != nil {
+		return fmt.Errorf("error decoding ResourceMetricsMonitor from %s: %w", *rmmPath, err)
+	}
+
+	var objects []*unstructured.Unstructured
+	for _, crPath := range crPaths {
+		crObjects, err := decodeManifests(crPath)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, crObjects...)
+	}
+
+	exposition, err := renderExposition(ctx, rmm, objects)
+	if err != nil {
+		return err
+	}
+
+	rule := goldenRule{}
+	if existing, err := os.ReadFile(*outPath); err == nil {
+		if err = yaml.Unmarshal(existing, &rule); err != nil {
+			return fmt.Errorf("error unmarshalling existing golden rule %s: %w", *outPath, err)
+		}
+	}
+	if *name != "" {
+		rule.Name = *name
+	}
+	if rule.Name == "" {
+		rule.Name = strings.TrimSuffix(filepath.Base(*outPath), filepath.Ext(*outPath))
+	}
+	if *description != "" {
+		rule.Description = *description
+	}
+	rule.In = rmmObjects[0].Object
+	rule.Out.Metrics = strings.Split(strings.TrimSuffix(exposition, "\n"), "\n")
+
+	return writeGoldenRule(*outPath, &rule)
+}
+
+// renderExposition generates the exposition for the given ResourceMetricsMonitor, as if the given objects were the only
+// ones present on the cluster. Selectors are evaluated client-side.
+func renderExposition(ctx context.Context, rmm *v1alpha1.ResourceMetricsMonitor, objects []*unstructured.Unstructured) (string, error) {
+	c := newConfigurer(nil, rmm, resolver.DefaultCostLimit, resolver.DefaultTimeout, nil)
+	if err := c.parseMonitor(); err != nil {
+		return "", err
+	}
+
+	stores := make([]*StoreType, 0, len(c.configuration.Stores))
+	for _, cfg := range c.configuration.Stores {
+		for _, family := range cfg.Families {
+			family.celVariables = celVariables(rmm, nil)
+		}
+		celCostLimit, celTimeout := c.celLimits(cfg)
+		s := newConfiguredStore(
+			klog.FromContext(ctx),
+			cfg.Families,
+			cfg.Resolver,
+			cfg.LabelKeys, cfg.LabelValues,
+			celCostLimit,
+			celTimeout,
+			c.celEvaluations,
+			rmm.GetNamespace(),
+			rmm.GetName(),
+		)
+		for _, object := range objects {
+			matches, err := storeMatches(cfg, object)
+			if err != nil {
+				return "", err
+			}
+			if !matches {
+				continue
+			}
+			// Stores are keyed by UID, which manifests on disk usually lack.
+			if object.GetUID() == "" {
+				object = object.DeepCopy()
+				object.SetUID(types.UID(cache.MetaObjectToName(object).String()))
+			}
+			if err = s.Add(object); err != nil {
+				return "", fmt.Errorf("error adding %s to store: %w", klog.KObj(object), err)
+			}
+		}
+		stores = append(stores, s)
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := newMetricsWriter(stores...).writeStores(buffer); err != nil {
+		return "", fmt.Errorf("error writing stores: %w", err)
+	}
+
+	return sortSeries(buffer.String()), nil
+}
+
+// sortSeries sorts the series within each family of the given exposition, as stores write them out in map order.
+func sortSeries(exposition string) string {
+	lines := strings.SplitAfter(exposition, "\n")
+	for start := 0; start < len(lines); {
+		if strings.HasPrefix(lines[start], "#") {
+			start++
+
+			continue
+		}
+		end := start
+		for end < len(lines) && !strings.HasPrefix(lines[end], "#") {
+			end++
+		}
+		slices.Sort(lines[start:end])
+		start = end
+	}
+
+	return strings.Join(lines, "")
+}
+
+// storeMatches reports whether the given object would be listed by the given store's reflector.
+func storeMatches(cfg *StoreType, object *unstructured.Unstructured) (bool, error) {
+	if cfg.Selectors.CRD != "" {
+		return false, errors.New("stores selecting their targets by CRD labels cannot be rendered without a cluster")
+	}
+	gvk := object.GroupVersionKind()
+	if gvk.Group != cfg.Group || gvk.Version != cfg.Version || gvk.Kind != cfg.Kind {
+		return false, nil
+	}
+
+	labelSelector, err := labels.Parse(cfg.Selectors.Label)
+	if err != nil {
+		return false, fmt.Errorf("error parsing label selector %q: %w", cfg.Selectors.Label, err)
+	}
+	fieldSelector, err := fields.ParseSelector(cfg.Selectors.Field)
+	if err != nil {
+		return false, fmt.Errorf("error parsing field selector %q: %w", cfg.Selectors.Field, err)
+	}
+
+	return labelSelector.Matches(labels.Set(object.GetLabels())) && fieldSelector.Matches(fields.Set{
+		"metadata.name":      object.GetName(),
+		"metadata.namespace": object.GetNamespace(),
+	}), nil
+}
+
+// decodeManifests decodes all YAML (or JSON) documents in the given file.
+func decodeManifests(path string) ([]*unstructured.Unstructured, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	return decodeObjects(path, data)
+}
+
+// decodeObjects decodes all objects in the given (multi-document) manifest, skipping empty documents. The name is only
+// used to annotate errors.
+func decodeObjects(name string, data []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		object := &unstructured.Unstructured{}
+		if err := decoder.Decode(&object.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("error decoding %s: %w", name, err)
+		}
+		if len(object.Object) == 0 {
+			continue
+		}
+		objects = append(objects, object)
+	}
+
+	return objects, nil
+}
+
+// writeGoldenRule writes the given golden rule to the given path, creating any missing parent directories.
+func writeGoldenRule(path string, rule *goldenRule) error {
+	data, err := yaml.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("error marshalling golden rule: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("error creating parent directories for %s: %w", path, err)
+	}
+	//nolint:gosec // Golden rules are checked into the repository, and are not sensitive.
+	if err = os.WriteFile(path, append([]byte("---\n"), data...), 0o644); err != nil {
+		return fmt.Errorf("error writing golden rule to %s: %w", path, err)
+	}
+
+	return nil
+}
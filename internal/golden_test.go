@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRenderExposition(t *testing.T) {
+	t.Parallel()
+	newBar := func(name, namespace string, replicas int64) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "contoso.com/v1alpha1",
+				"kind":       "Bar",
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": namespace,
+				},
+				"spec": map[string]interface{}{
+					"replicas": replicas,
+				},
+			},
+		}
+	}
+	objects := []*unstructured.Unstructured{
+		newBar("a", "default", 1),
+		newBar("b", "other", 2),
+	}
+	tests := []struct {
+		name          string
+		configuration string
+		expected      string
+	}{
+		{
+			name: "all objects",
+			configuration: `stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    families:
+      - name: "bar_replicas"
+        help: "Replicas"
+        metrics:
+          - labelKeys: ["name"]
+            labelValues: ["metadata.name"]
+            value: "spec.replicas"
+`,
+			expected: "# HELP kube_customresource_bar_replicas Replicas\n" +
+				"# TYPE kube_customresource_bar_replicas gauge\n" +
+				"kube_customresource_bar_replicas{name=\"a\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 1\n" +
+				"kube_customresource_bar_replicas{name=\"b\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 2\n",
+		},
+		{
+			name: "field selector and mismatching GVK",
+			configuration: `stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    selectors:
+      field: "metadata.namespace=other"
+    families:
+      - name: "bar_replicas"
+        help: "Replicas"
+        metrics:
+          - labelKeys: ["name"]
+            labelValues: ["metadata.name"]
+            value: "spec.replicas"
+  - group: "contoso.com"
+    version: "v1beta1"
+    kind: "Bar"
+    resource: "bars"
+    families:
+      - name: "bar_v1beta1"
+        help: "No objects"
+        metrics:
+          - value: "1"
+`,
+			expected: "# HELP kube_customresource_bar_replicas Replicas\n" +
+				"# TYPE kube_customresource_bar_replicas gauge\n" +
+				"kube_customresource_bar_replicas{name=\"b\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 2\n" +
+				"# HELP kube_customresource_bar_v1beta1 No objects\n" +
+				"# TYPE kube_customresource_bar_v1beta1 gauge\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rmm := &v1alpha1.ResourceMetricsMonitor{Spec: v1alpha1.ResourceMetricsMonitorSpec{Configuration: tt.configuration}}
+			got, err := renderExposition(context.Background(), rmm, objects)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("%s", cmp.Diff(got, tt.expected))
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+// goroutineRunner is implemented by the stores, and the stores' watchers, the goroutines run on behalf of which are
+// attributed to the stores.
+type goroutineRunner interface {
+	// goroutine runs the given function in a goroutine attributed to the store.
+	goroutine(fn func())
+}
+
+// Ensure the stores, and the stores' watchers, implement goroutineRunner.
+var (
+	_ goroutineRunner = &StoreType{}
+	_ goroutineRunner = &clusterStore{}
+	_ goroutineRunner = &crdGate{}
+)
+
+// goroutine runs the given function in a goroutine, attributed to the store, if any.
+func (s *StoreType) goroutine(fn func()) {
+	if s == nil {
+		go fn()
+
+		return
+	}
+	s.goroutines.Add(1)
+	go func() {
+		defer s.goroutines.Add(-1)
+		fn()
+	}()
+}
+
+// goroutine runs the given function in a goroutine attributed to the gated store.
+func (g *crdGate) goroutine(fn func()) {
+	g.store.goroutine(fn)
+}
+
+// runGoroutine runs the given function in a goroutine, attributed to the given store, if it is a goroutineRunner. The
+// CRD watches of CRD-selected stores are not attributed, as such stores have no target of their own to report them by.
+func runGoroutine(s any, fn func()) {
+	if runner, ok := s.(goroutineRunner); ok {
+		runner.goroutine(fn)
+
+		return
+	}
+	go fn()
+}
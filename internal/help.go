@@ -0,0 +1,75 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxHelpLength is the maximum length of a family's help text, in bytes.
+const maxHelpLength = 1024
+
+// validateHelp rejects help text that would break the exposition's HELP header, or be flagged by OpenMetrics validators.
+func validateHelp(help string) error {
+	if len(help) > maxHelpLength {
+		return fmt.Errorf("must not be longer than %d bytes", maxHelpLength)
+	}
+	if !utf8.ValidString(help) {
+		return errors.New("must be valid UTF-8")
+	}
+	if i := strings.IndexFunc(help, unicode.IsControl); i >= 0 {
+		return fmt.Errorf("must not contain control characters, found %q", help[i])
+	}
+
+	return nil
+}
+
+// defaults sets the help text of the families configured without any, so no empty HELP headers are exposed.
+func (c configuration) defaults() {
+	for _, store := range c.Stores {
+		for _, family := range store.Families {
+			if strings.TrimSpace(family.Help) == "" {
+				family.Help = defaultHelp(store, family)
+			}
+		}
+	}
+}
+
+// defaultHelp returns the help text for the given family of the given store, describing the source of its values,
+// i.e., the store's target and the family's path, if any.
+func defaultHelp(store *StoreType, family *FamilyType) string {
+	target := schema.GroupVersion{Group: store.Group, Version: store.Version}.String() + " " + store.Kind
+	if store.Selectors.CRD != "" {
+		target = fmt.Sprintf("CRDs matching %q", store.Selectors.CRD)
+	}
+	for _, metric := range family.Metrics {
+		switch {
+		case metric.EachMap != nil:
+			return fmt.Sprintf("Entries of %s of %s objects.", metric.EachMap.Path, target)
+		case metric.Value != "":
+			return fmt.Sprintf("Value of %s of %s objects.", metric.Value, target)
+		}
+	}
+
+	return fmt.Sprintf("The %s family of %s objects.", family.Name, target)
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+// FamilyKind represents the Prometheus type of a family.
+type FamilyKind string
+
+const (
+	// FamilyKindGauge exposes each metric as a single gauge series.
+	FamilyKindGauge FamilyKind = "gauge"
+	// FamilyKindHistogram exposes each metric as a histogram, i.e., as _bucket, _sum, and _count series.
+	FamilyKindHistogram FamilyKind = "histogram"
+	// FamilyKindNone represents the absence of a type, and defaults to FamilyKindGauge.
+	FamilyKindNone FamilyKind = ""
+)
+
+// histogramBucketLabel is the label holding a bucket's upper bound.
+const histogramBucketLabel = "le"
+
+// HistogramType configures where a histogram family's metric is fed from. The metric's value is used as the sum.
+type HistogramType struct {
+	// Buckets is the dot-separated path to the field holding the cumulative bucket counts, i.e., either a map of upper
+	// bounds to counts, or an array of counts for the upper bounds in Bounds.
+	Buckets string    `yaml:"buckets"`
+	Bounds  []float64 `yaml:"bounds,omitempty"`
+	// Count, if set, is resolved like the metric's value for the total count, which otherwise defaults to the +Inf
+	// bucket's.
+	Count string `yaml:"count,omitempty"`
+}
+
+// histogramBucket is a single, cumulative, bucket of a histogram.
+type histogramBucket struct {
+	upperBound float64
+	count      float64
+}
+
+// validate checks the histogram configuration for the given family type.
+func (h *HistogramType) validate(kind FamilyKind) error {
+	switch {
+	case kind != FamilyKindHistogram && h != nil:
+		return errors.New("histogram can only be set for histogram families")
+	case kind == FamilyKindHistogram && (h == nil || h.Buckets == ""):
+		return errors.New("histogram.buckets is required for histogram families")
+	case kind == FamilyKindHistogram && !slices.IsSorted(h.Bounds):
+		return errors.New("histogram.bounds must be sorted")
+	}
+
+	return nil
+}
+
+// resolveBuckets reads the buckets at the configured path from the given object, sorted by their upper bounds and
+// terminated by a +Inf bucket.
+func (h *HistogramType) resolveBuckets(object map[string]interface{}, count string) ([]histogramBucket, error) {
+	field, found, err := unstructured.NestedFieldNoCopy(object, strings.Split(h.Buckets, ".")...)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving histogram buckets %q: %w", h.Buckets, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("error resolving histogram buckets %q: not found", h.Buckets)
+	}
+
+	var buckets []histogramBucket
+	switch field := field.(type) {
+	case map[string]interface{}:
+		for bound, value := range field {
+			upperBound, err := strconv.ParseFloat(bound, 64)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing histogram bucket bound %q: %w", bound, err)
+			}
+			bucketCount, err := parseValue(fmt.Sprintf("%v", value))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing histogram bucket %q count: %w", bound, err)
+			}
+			buckets = append(buckets, histogramBucket{upperBound: upperBound, count: bucketCount})
+		}
+		slices.SortFunc(buckets, func(a, b histogramBucket) int { return cmp.Compare(a.upperBound, b.upperBound) })
+	case []interface{}:
+		if len(field) != len(h.Bounds) {
+			return nil, fmt.Errorf("expected histogram buckets %q to be of same length (%d) as the bounds (%d)", h.Buckets, len(field), len(h.Bounds))
+		}
+		for i, value := range field {
+			bucketCount, err := parseValue(fmt.Sprintf("%v", value))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing histogram bucket %d count: %w", i, err)
+			}
+			buckets = append(buckets, histogramBucket{upperBound: h.Bounds[i], count: bucketCount})
+		}
+	default:
+		return nil, fmt.Errorf("expected histogram buckets %q to be a map or an array, got %T", h.Buckets, field)
+	}
+
+	if len(buckets) == 0 || !math.IsInf(buckets[len(buckets)-1].upperBound, 1) {
+		infCount := math.NaN()
+		if count != "" {
+			infCount, err = parseValue(count)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing histogram count %q: %w", count, err)
+			}
+		} else if len(buckets) > 0 {
+			infCount = buckets[len(buckets)-1].count
+		}
+		if math.IsNaN(infCount) {
+			return nil, fmt.Errorf("histogram buckets %q are empty, and no count is set", h.Buckets)
+		}
+		buckets = append(buckets, histogramBucket{upperBound: math.Inf(1), count: infCount})
+	}
+
+	return buckets, nil
+}
+
+// buildHistogramString returns the _bucket, _sum, and _count series for the given histogram metric.
+func (f *FamilyType) buildHistogramString(
+	builder *strings.Builder,
+	metric *MetricType,
+	resolverInstance resolver.Resolver,
+	u *unstructured.Unstructured,
+	keys, values []string,
+	expanded map[string][]string,
+	sum string,
+	logger klog.Logger,
+) error {
+	var count string
+	if metric.Histogram.Count != "" {
+		resolvedCount, found := resolveValue(resolverInstance, metric.Histogram.Count, u.Object)
+		if !found {
+			err := fmt.Errorf("error resolving histogram count %q", metric.Histogram.Count)
+			logger.V(1).Error(err, "skipping")
+			f.drop(droppedReasonUnresolved)
+
+			return err
+		}
+		count = resolvedCount
+	}
+	buckets, err := metric.Histogram.resolveBuckets(u.Object, count)
+	if err != nil {
+		logger.V(1).Error(err, "skipping")
+		f.drop(droppedReasonInvalidValue)
+
+		return err
+	}
+	if count == "" {
+		count = strconv.FormatFloat(buckets[len(buckets)-1].count, 'f', -1, 64)
+	}
+
+	writeSeries := func(suffix, value string, k, v []string) error {
+		return f.writeSeries(builder, kubeCustomResourcePrefix+f.Name+suffix, u.GroupVersionKind(), value, k, v)
+	}
+	writeHistogram := func(k, v []string) error {
+		for _, bucket := range buckets {
+			bucketKeys := append(slices.Clone(k), histogramBucketLabel)
+			bucketValues := append(slices.Clone(v), strconv.FormatFloat(bucket.upperBound, 'g', -1, 64))
+			if err := writeSeries("_bucket", strconv.FormatFloat(bucket.count, 'f', -1, 64), bucketKeys, bucketValues); err != nil {
+				return err
+			}
+		}
+		if err := writeSeries("_sum", sum, k, v); err != nil {
+			return err
+		}
+
+		return writeSeries("_count", count, k, v)
+	}
+	if len(expanded) == 0 {
+		return writeSingleSample(writeHistogram, keys, values, logger)
+	}
+
+	return writeExpandedSamples(writeHistogram, keys, values, expanded, logger)
+}
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+func TestFamilyType_buildHistogramString(t *testing.T) {
+	t.Parallel()
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "contoso.com/v1",
+		"kind":       "Foo",
+		"metadata":   map[string]interface{}{"name": "foo"},
+		"status": map[string]interface{}{
+			"latency": map[string]interface{}{
+				"buckets": map[string]interface{}{"1": int64(4), "0.5": int64(2), "+Inf": int64(5)},
+				"counts":  []interface{}{int64(2), int64(4)},
+				"sum":     2.5,
+				"count":   int64(6),
+			},
+		},
+	}}
+	tests := []struct {
+		name      string
+		histogram *HistogramType
+		expected  string
+	}{
+		{
+			name:      "map of bounds to counts",
+			histogram: &HistogramType{Buckets: "status.latency.buckets"},
+			expected: `kube_customresource_latency_seconds_bucket{name="foo",le="0.5",group="contoso.com",version="v1",kind="Foo"} 2
+kube_customresource_latency_seconds_bucket{name="foo",le="1",group="contoso.com",version="v1",kind="Foo"} 4
+kube_customresource_latency_seconds_bucket{name="foo",le="+Inf",group="contoso.com",version="v1",kind="Foo"} 5
+kube_customresource_latency_seconds_sum{name="foo",group="contoso.com",version="v1",kind="Foo"} 2.5
+kube_customresource_latency_seconds_count{name="foo",group="contoso.com",version="v1",kind="Foo"} 5
+`,
+		},
+		{
+			name:      "array of counts with a count",
+			histogram: &HistogramType{Buckets: "status.latency.counts", Bounds: []float64{0.5, 1}, Count: "status.latency.count"},
+			expected: `kube_customresource_latency_seconds_bucket{name="foo",le="0.5",group="contoso.com",version="v1",kind="Foo"} 2
+kube_customresource_latency_seconds_bucket{name="foo",le="1",group="contoso.com",version="v1",kind="Foo"} 4
+kube_customresource_latency_seconds_bucket{name="foo",le="+Inf",group="contoso.com",version="v1",kind="Foo"} 6
+kube_customresource_latency_seconds_sum{name="foo",group="contoso.com",version="v1",kind="Foo"} 2.5
+kube_customresource_latency_seconds_count{name="foo",group="contoso.com",version="v1",kind="Foo"} 6
+`,
+		},
+		{
+			name:      "array of counts with mismatched bounds",
+			histogram: &HistogramType{Buckets: "status.latency.counts", Bounds: []float64{1}},
+		},
+		{
+			name:      "missing buckets",
+			histogram: &HistogramType{Buckets: "status.missing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			family := &FamilyType{
+				logger: klog.Background(),
+				Name:   "latency_seconds",
+				Type:   FamilyKindHistogram,
+				Metrics: []*MetricType{{
+					LabelKeys:   []string{"name"},
+					LabelValues: []string{"metadata.name"},
+					Value:       "status.latency.sum",
+					Histogram:   tt.histogram,
+				}},
+			}
+			if diff := cmp.Diff(family.buildMetricString(object), tt.expected); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestHistogramType_validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		kind      FamilyKind
+		histogram *HistogramType
+		wantErr   bool
+	}{
+		{name: "gauge", kind: FamilyKindNone},
+		{name: "histogram", kind: FamilyKindHistogram, histogram: &HistogramType{Buckets: "status.buckets"}},
+		{name: "histogram on a gauge", kind: FamilyKindGauge, histogram: &HistogramType{Buckets: "status.buckets"}, wantErr: true},
+		{name: "histogram without buckets", kind: FamilyKindHistogram, histogram: &HistogramType{}, wantErr: true},
+		{name: "unsorted bounds", kind: FamilyKindHistogram, histogram: &HistogramType{Buckets: "status.buckets", Bounds: []float64{1, 0.5}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := tt.histogram.validate(tt.kind); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,543 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/internal/version"
+	"github.com/rexagod/resource-state-metrics/manifests"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	installSubcommandName   = "install"
+	uninstallSubcommandName = "uninstall"
+
+	installDefaultMainPort = 9999
+	installDefaultSelfPort = 9998
+
+	// crdEstablishTimeout is the time the CRDs installed on startup are waited on to be established for.
+	crdEstablishTimeout = time.Minute
+)
+
+// installResources maps the kinds rendered by the installer to their resources, and whether they are namespaced.
+var installResources = map[schema.GroupKind]struct {
+	resource   string
+	namespaced bool
+}{
+	{Group: "", Kind: "Namespace"}:                                                    {resource: "namespaces"},
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:                 {resource: "customresourcedefinitions"},
+	{Group: "", Kind: "ServiceAccount"}:                                               {resource: "serviceaccounts", namespaced: true},
+	{Group: rbacv1.GroupName, Kind: "ClusterRole"}:                                    {resource: "clusterroles"},
+	{Group: rbacv1.GroupName, Kind: "ClusterRoleBinding"}:                             {resource: "clusterrolebindings"},
+	{Group: rbacv1.GroupName, Kind: "Role"}:                                           {resource: "roles", namespaced: true},
+	{Group: rbacv1.GroupName, Kind: "RoleBinding"}:                                    {resource: "rolebindings", namespaced: true},
+	{Group: appsv1.GroupName, Kind: "Deployment"}:                                     {resource: "deployments", namespaced: true},
+	{Group: "", Kind: "Service"}:                                                      {resource: "services", namespaced: true},
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicy"}:        {resource: "validatingadmissionpolicies"},
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicyBinding"}: {resource: "validatingadmissionpolicybindings"},
+}
+
+// installOptions configures the rendered installation.
+type installOptions struct {
+	namespace string
+	image     string
+	// monitors are the (Cluster)ResourceMetricsMonitors the controller is granted access to the targets of.
+	monitors []*v1alpha1.ResourceMetricsMonitor
+	// serviceMonitors enables ServiceMonitor generation, with the given labels set on each.
+	serviceMonitors      bool
+	serviceMonitorLabels string
+	// watchNamespaces restricts the namespaces ResourceMetricsMonitors are watched in.
+	watchNamespaces []string
+	// nativeResources allow-lists the native resources stores may target.
+	nativeResources []string
+	// namespacedStores scopes stores to their ResourceMetricsMonitor's namespace, and installs the admission policy
+	// restricting cluster-scoped ones.
+	namespacedStores bool
+}
+
+// install renders the CRD, RBAC, Deployment, and Service manifests, and applies them to the cluster (or prints them,
+// with -dry-run).
+func install(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet(installSubcommandName, flag.ContinueOnError)
+	opts := installOptions{}
+	flags.StringVar(&opts.namespace, "namespace", "resource-state-metrics", "Namespace to install the controller in. Created if it does not exist.")
+	flags.StringVar(&opts.image, "image", version.ControllerName.String()+":latest", "Controller image.")
+	var monitorPaths stringSliceFlag
+	flags.Var(&monitorPaths, "monitor", "Path to the (Cluster)ResourceMetricsMonitors to grant the controller access to the targets of, as the rbac command does. Can be repeated. Stores targeting anything else are forbidden from listing it.")
+	flags.BoolVar(&opts.serviceMonitors, "service-monitors", false, "Generate a Prometheus Operator ServiceMonitor for each ResourceMetricsMonitor.")
+	flags.StringVar(&opts.serviceMonitorLabels, serviceMonitorLabelsFlagName, "", "Comma-separated key=value labels to set on generated ServiceMonitors.")
+	flags.Var((*stringSliceFlag)(&opts.watchNamespaces), watchNamespaceFlagName, "Namespace to watch ResourceMetricsMonitors in. Can be repeated. Defaults to all namespaces.")
+	flags.BoolVar(&opts.namespacedStores, namespacedStoresFlagName, false, "Scope stores to their ResourceMetricsMonitor's namespace, and install the admission policy guarding cluster-scoped ones.")
+	flags.Var((*stringSliceFlag)(&opts.nativeResources), nativeResourcesFlagName, "Native resources stores may target, e.g., pods,deployments.apps, or \"*\" for all. Can be repeated. Defaults to none.")
+	kubeconfig := flags.String(kubeconfigFlagName, os.Getenv("KUBECONFIG"), "Path to a kubeconfig. Defaults to the standard kubeconfig loading rules.")
+	dryRun := flags.Bool("dry-run", false, "Print the rendered manifests instead of applying them.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, err := labels.ConvertSelectorToLabelsMap(opts.serviceMonitorLabels); err != nil {
+		return fmt.Errorf("invalid -%s: %w", serviceMonitorLabelsFlagName, err)
+	}
+	for _, path := range monitorPaths {
+		monitors, err := decodeMonitors(path)
+		if err != nil {
+			return err
+		}
+		opts.monitors = append(opts.monitors, monitors...)
+	}
+
+	objects, notes, err := renderInstallManifests(opts)
+	if err != nil {
+		return err
+	}
+	for _, note := range notes {
+		klog.FromContext(ctx).Info("Not granted", "note", note)
+	}
+	if *dryRun {
+		return writeInstallManifests(os.Stdout, objects)
+	}
+
+	client, err := newInstallClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	return applyInstallManifests(ctx, client, objects)
+}
+
+// uninstall deletes everything install applied, except for the namespace, and the CRDs (unless -delete-crd is set),
+// since deleting the latter deletes all (Cluster)ResourceMetricsMonitors as well.
+func uninstall(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet(uninstallSubcommandName, flag.ContinueOnError)
+	namespace := flags.String("namespace", "resource-state-metrics", "Namespace the controller was installed in.")
+	kubeconfig := flags.String(kubeconfigFlagName, os.Getenv("KUBECONFIG"), "Path to a kubeconfig. Defaults to the standard kubeconfig loading rules.")
+	deleteCRD := flags.Bool("delete-crd", false, "Delete the CRDs as well, along with all (Cluster)ResourceMetricsMonitors.")
+	var monitorPaths stringSliceFlag
+	flags.Var(&monitorPaths, "monitor", "Path to the (Cluster)ResourceMetricsMonitors install was given, to delete the roles granting access to their targets as well. Can be repeated.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	opts := installOptions{namespace: *namespace, namespacedStores: true}
+	for _, path := range monitorPaths {
+		monitors, err := decodeMonitors(path)
+		if err != nil {
+			return err
+		}
+		opts.monitors = append(opts.monitors, monitors...)
+	}
+
+	// Render the optional objects as well, so they are deleted if present.
+	objects, _, err := renderInstallManifests(opts)
+	if err != nil {
+		return err
+	}
+	client, err := newInstallClient(*kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	return deleteInstallManifests(ctx, client, objects, *deleteCRD)
+}
+
+// newInstallClient returns a dynamic client for the given kubeconfig, falling back to the standard loading rules.
+func newInstallClient(kubeconfig string) (dynamic.Interface, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error building kubeconfig: %w", err)
+	}
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error building dynamic clientset: %w", err)
+	}
+
+	return client, nil
+}
+
+// renderInstallManifests returns all objects required to run the controller, in the order they should be applied, along
+// with notes on the targets of the given monitors the controller is not granted access to.
+func renderInstallManifests(opts installOptions) ([]*unstructured.Unstructured, []string, error) {
+	name := version.ControllerName.String()
+	commonLabels := map[string]string{
+		"app.kubernetes.io/name":    name,
+		"app.kubernetes.io/part-of": "instrumentation.k8s-sigs.io",
+	}
+	objectMeta := metav1.ObjectMeta{Name: name, Namespace: opts.namespace, Labels: commonLabels}
+	clusterObjectMeta := metav1.ObjectMeta{Name: name, Labels: commonLabels}
+
+	crds, err := decodeObjects("embedded custom resource definition", manifests.CustomResourceDefinition)
+	if err != nil {
+		return nil, nil, err
+	}
+	clusterCRDs, err := decodeObjects("embedded cluster custom resource definition", manifests.ClusterCustomResourceDefinition)
+	if err != nil {
+		return nil, nil, err
+	}
+	crds = append(crds, clusterCRDs...)
+	roles, err := decodeObjects("embedded cluster role", manifests.ClusterRole)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The generated RBAC holds the cluster role, followed by the roles of the namespaces specific permissions are
+	// required in, if any.
+	clusterRoles := slices.DeleteFunc(slices.Clone(roles), func(role *unstructured.Unstructured) bool { return role.GetKind() != "ClusterRole" })
+	if len(crds) != 2 || len(clusterRoles) != 1 {
+		return nil, nil, fmt.Errorf("expected exactly two embedded CRDs and one cluster role, got %d and %d", len(crds), len(clusterRoles))
+	}
+	clusterRole := &rbacv1.ClusterRole{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(clusterRoles[0].Object, clusterRole); err != nil {
+		return nil, nil, fmt.Errorf("error decoding embedded cluster role: %w", err)
+	}
+	clusterRole.ObjectMeta = clusterObjectMeta
+	// Stores are only granted access to the targets of the given monitors, through the roles the rbac command generates
+	// for them, bound below.
+
+	podLabels := map[string]string{"app.kubernetes.io/name": name}
+	var args []string
+	for _, namespace := range opts.watchNamespaces {
+		args = append(args, fmt.Sprintf("-%s=%s", watchNamespaceFlagName, namespace))
+	}
+	if opts.namespacedStores {
+		args = append(args, "-"+namespacedStoresFlagName)
+	}
+	for _, resource := range opts.nativeResources {
+		args = append(args, fmt.Sprintf("-%s=%s", nativeResourcesFlagName, resource))
+	}
+	if opts.serviceMonitors {
+		args = append(args, fmt.Sprintf("-%s=%s/%s", serviceMonitorServiceFlagName, opts.namespace, name))
+		if opts.serviceMonitorLabels != "" {
+			args = append(args, fmt.Sprintf("-%s=%s", serviceMonitorLabelsFlagName, opts.serviceMonitorLabels))
+		}
+	}
+	typed := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: opts.namespace}},
+		&corev1.ServiceAccount{ObjectMeta: objectMeta},
+		clusterRole,
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: clusterObjectMeta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: opts.namespace}},
+		},
+	}
+	for _, unstructuredRole := range roles {
+		if unstructuredRole.GetKind() != "Role" {
+			continue
+		}
+		role := &rbacv1.Role{}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredRole.Object, role); err != nil {
+			return nil, nil, fmt.Errorf("error decoding embedded role: %w", err)
+		}
+		roleObjectMeta := metav1.ObjectMeta{Name: name, Namespace: role.GetNamespace(), Labels: commonLabels}
+		role.ObjectMeta = roleObjectMeta
+		typed = append(typed, role, &rbacv1.RoleBinding{
+			ObjectMeta: roleObjectMeta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: opts.namespace}},
+		})
+	}
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: opts.namespace}
+	var notes []string
+	for _, monitor := range opts.monitors {
+		roles, monitorNotes, err := monitorRoles(monitor, opts.namespacedStores, &subject)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", klog.KObj(monitor), err)
+		}
+		for _, note := range monitorNotes {
+			notes = append(notes, fmt.Sprintf("%s: %s", klog.KObj(monitor), note))
+		}
+		typed = append(typed, roles...)
+	}
+	typed = append(typed,
+		&appsv1.Deployment{
+			ObjectMeta: objectMeta,
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: podLabels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+					Spec: corev1.PodSpec{
+						ServiceAccountName: name,
+						Containers: []corev1.Container{{
+							Name:  name,
+							Image: opts.image,
+							Args:  args,
+							Ports: []corev1.ContainerPort{
+								{Name: "main", ContainerPort: installDefaultMainPort},
+								{Name: "self", ContainerPort: installDefaultSelfPort},
+							},
+							LivenessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{Path: "/livez", Port: intstr.FromString("main")},
+							}},
+							ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{Path: "/readyz", Port: intstr.FromString("self")},
+							}},
+							SecurityContext: &corev1.SecurityContext{
+								AllowPrivilegeEscalation: ptr.To(false),
+								ReadOnlyRootFilesystem:   ptr.To(true),
+								RunAsNonRoot:             ptr.To(true),
+								RunAsUser:                ptr.To(int64(65534)),
+							},
+						}},
+					},
+				},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: objectMeta,
+			Spec: corev1.ServiceSpec{
+				Selector: podLabels,
+				Ports: []corev1.ServicePort{
+					{Name: "main", Port: installDefaultMainPort, TargetPort: intstr.FromString("main")},
+					{Name: "self", Port: installDefaultSelfPort, TargetPort: intstr.FromString("self")},
+				},
+			},
+		},
+	)
+
+	objects := make([]*unstructured.Unstructured, 0, len(typed)+len(crds))
+	for i, object := range typed {
+		u, err := toInstallUnstructured(object)
+		if err != nil {
+			return nil, nil, err
+		}
+		objects = append(objects, u)
+		// The CRDs go right after the namespace, so that they are established by the time the controller starts.
+		if i == 0 {
+			objects = append(objects, crds...)
+		}
+	}
+	if opts.namespacedStores {
+		policies, err := decodeObjects("embedded validating admission policy", manifests.ValidatingAdmissionPolicy)
+		if err != nil {
+			return nil, nil, err
+		}
+		objects = append(objects, policies...)
+	}
+
+	return objects, notes, nil
+}
+
+// toInstallUnstructured converts the given typed object to an unstructured one, with its apiVersion and kind set.
+func toInstallUnstructured(object runtime.Object) (*unstructured.Unstructured, error) {
+	gvks, _, err := scheme.Scheme.ObjectKinds(object)
+	if err != nil {
+		return nil, fmt.Errorf("error getting kind for %T: %w", object, err)
+	}
+	data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return nil, fmt.Errorf("error converting %T to unstructured: %w", object, err)
+	}
+	u := &unstructured.Unstructured{Object: data}
+	u.SetGroupVersionKind(gvks[0])
+	// Drop the zero-valued fields the converter fills in, as they are rejected by server-side apply.
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u.Object, "status")
+	unstructured.RemoveNestedField(u.Object, "spec", "template", "metadata", "creationTimestamp")
+
+	return u, nil
+}
+
+// writeInstallManifests writes the given objects as a multi-document YAML.
+func writeInstallManifests(w io.Writer, objects []*unstructured.Unstructured) error {
+	for _, object := range objects {
+		data, err := yaml.Marshal(object.Object)
+		if err != nil {
+			return fmt.Errorf("error marshalling %s: %w", klog.KObj(object), err)
+		}
+		if _, err = fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return fmt.Errorf("error writing %s: %w", klog.KObj(object), err)
+		}
+	}
+
+	return nil
+}
+
+// installResourceInterface returns the client for the given object's resource.
+func installResourceInterface(client dynamic.Interface, object *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := object.GroupVersionKind()
+	resource, ok := installResources[gvk.GroupKind()]
+	if !ok {
+		return nil, fmt.Errorf("unknown installer kind %s", gvk)
+	}
+	resourceClient := client.Resource(gvk.GroupVersion().WithResource(resource.resource))
+	if resource.namespaced {
+		return resourceClient.Namespace(object.GetNamespace()), nil
+	}
+
+	return resourceClient, nil
+}
+
+// releaseMonitors removes the controller's finalizer from all monitors of the given CRD.
+func releaseMonitors(ctx context.Context, client dynamic.Interface, crd *unstructured.Unstructured) error {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	var served string
+	for _, v := range versions {
+		if v, ok := v.(map[string]interface{}); ok && v["served"] == true {
+			served, _ = v["name"].(string)
+
+			break
+		}
+	}
+	if served == "" {
+		return fmt.Errorf("CustomResourceDefinition %s serves no versions", crd.GetName())
+	}
+
+	resourceClient := client.Resource(schema.GroupVersionResource{Group: group, Version: served, Resource: plural})
+	monitors, err := resourceClient.List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error listing %s: %w", plural, err)
+	}
+	var errs []error
+	for _, monitor := range monitors.Items {
+		if !slices.Contains(monitor.GetFinalizers(), v1alpha1.Finalizer) {
+			continue
+		}
+		monitor.SetFinalizers(slices.DeleteFunc(monitor.GetFinalizers(), func(finalizer string) bool {
+			return finalizer == v1alpha1.Finalizer
+		}))
+		if _, err = resourceClient.Namespace(monitor.GetNamespace()).Update(ctx, &monitor, metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("error removing the finalizer from %s %s: %w", monitor.GetKind(), klog.KObj(&monitor), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// installCRDs server-side applies the embedded (Cluster)ResourceMetricsMonitor CRDs, and waits for them to be
+// established.
+func installCRDs(ctx context.Context, client dynamic.Interface) error {
+	crds, err := decodeObjects("embedded custom resource definition", manifests.CustomResourceDefinition)
+	if err != nil {
+		return err
+	}
+	clusterCRDs, err := decodeObjects("embedded cluster custom resource definition", manifests.ClusterCustomResourceDefinition)
+	if err != nil {
+		return err
+	}
+	crds = append(crds, clusterCRDs...)
+	if err = applyInstallManifests(ctx, client, crds); err != nil {
+		return err
+	}
+
+	for _, crd := range crds {
+		resourceClient, err := installResourceInterface(client, crd)
+		if err != nil {
+			return err
+		}
+		err = wait.PollUntilContextTimeout(ctx, time.Second, crdEstablishTimeout, true, func(ctx context.Context) (bool, error) {
+			applied, err := resourceClient.Get(ctx, crd.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+
+			return crdEstablished(applied), nil
+		})
+		if err != nil {
+			return fmt.Errorf("error waiting for %s to be established: %w", crd.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// applyInstallManifests server-side applies the given objects, in order.
+func applyInstallManifests(ctx context.Context, client dynamic.Interface, objects []*unstructured.Unstructured) error {
+	logger := klog.FromContext(ctx)
+	for _, object := range objects {
+		resourceClient, err := installResourceInterface(client, object)
+		if err != nil {
+			return err
+		}
+		_, err = resourceClient.Apply(ctx, object.GetName(), object, metav1.ApplyOptions{
+			FieldManager: version.ControllerName.String(),
+			Force:        true,
+		})
+		if err != nil {
+			return fmt.Errorf("error applying %s %s: %w", object.GetKind(), klog.KObj(object), err)
+		}
+		logger.Info("Applied", "kind", object.GetKind(), "object", klog.KObj(object))
+	}
+
+	return nil
+}
+
+// deleteInstallManifests deletes the given objects in reverse order, skipping the namespace, and the CRD unless
+// deleteCRD is set. Objects that do not exist are ignored.
+func deleteInstallManifests(ctx context.Context, client dynamic.Interface, objects []*unstructured.Unstructured, deleteCRD bool) error {
+	logger := klog.FromContext(ctx)
+	var errs []error
+	for _, object := range slices.Backward(objects) {
+		switch object.GetKind() {
+		case "Namespace":
+			continue
+		case "CustomResourceDefinition":
+			if !deleteCRD {
+				continue
+			}
+			// The controller is gone by now, so the monitors' finalizers are removed for their deletion to complete.
+			if err := releaseMonitors(ctx, client, object); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		resourceClient, err := installResourceInterface(client, object)
+		if err != nil {
+			return err
+		}
+		err = resourceClient.Delete(ctx, object.GetName(), metav1.DeleteOptions{PropagationPolicy: ptr.To(metav1.DeletePropagationBackground)})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error deleting %s %s: %w", object.GetKind(), klog.KObj(object), err))
+
+			continue
+		}
+		logger.Info("Deleted", "kind", object.GetKind(), "object", klog.KObj(object))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestRenderInstallManifests(t *testing.T) {
+	t.Parallel()
+	monitor := &v1alpha1.ResourceMetricsMonitor{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "monitor"},
+		Spec: v1alpha1.ResourceMetricsMonitorSpec{Configuration: `stores:
+  - version: "v1"
+    kind: "Pod"
+    resource: "pods"
+    families:
+      - name: "pod_info"
+        metrics:
+          - value: "1"
+`},
+	}
+	objects, notes, err := renderInstallManifests(installOptions{namespace: "foo", image: "bar:baz", monitors: []*v1alpha1.ResourceMetricsMonitor{monitor}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) > 0 {
+		t.Errorf("expected no notes, got %v", notes)
+	}
+
+	var kinds []string
+	for _, object := range objects {
+		kinds = append(kinds, object.GetKind())
+		if _, ok := installResources[object.GroupVersionKind().GroupKind()]; !ok {
+			t.Errorf("no resource known for %s", object.GroupVersionKind())
+		}
+	}
+	expectedKinds := []string{"Namespace", "CustomResourceDefinition", "CustomResourceDefinition", "ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Role", "RoleBinding", "ClusterRole", "ClusterRoleBinding", "Deployment", "Service"}
+	if !cmp.Equal(kinds, expectedKinds) {
+		t.Fatalf("%s", cmp.Diff(kinds, expectedKinds))
+	}
+
+	// The aggregation layer's configuration is only read in kube-system, through a role bound there.
+	for _, object := range objects[6:8] {
+		if got := object.GetNamespace(); got != metav1.NamespaceSystem {
+			t.Errorf("expected %s in namespace %s, got %q", object.GetKind(), metav1.NamespaceSystem, got)
+		}
+	}
+
+	// The controller is only granted access to the monitors' targets, through the roles generated for them.
+	rules, _, _ := unstructured.NestedSlice(objects[4].Object, "rules")
+	for _, rule := range rules {
+		if resources, _, _ := unstructured.NestedStringSlice(rule.(map[string]interface{}), "resources"); slices.Contains(resources, "*") {
+			t.Errorf("expected no wildcard rules, got %v", rule)
+		}
+	}
+	if got := objects[8].GetName(); got != "resource-state-metrics-tenant-monitor" {
+		t.Errorf("expected the monitor's cluster role, got %q", got)
+	}
+	monitorSubjects, _, _ := unstructured.NestedSlice(objects[9].Object, "subjects")
+	if got := monitorSubjects[0].(map[string]interface{})["name"]; got != "resource-state-metrics" {
+		t.Errorf("expected the monitor's cluster role bound to the controller, got %v", got)
+	}
+
+	deployment := objects[10]
+	for _, tt := range []struct {
+		path     []string
+		expected interface{}
+	}{
+		{path: []string{"metadata", "namespace"}, expected: "foo"},
+		{path: []string{"spec", "template", "spec", "serviceAccountName"}, expected: "resource-state-metrics"},
+	} {
+		got, _, err := unstructured.NestedFieldNoCopy(deployment.Object, tt.path...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(got, tt.expected) {
+			t.Errorf("%v: %s", tt.path, cmp.Diff(got, tt.expected))
+		}
+	}
+	containers, _, _ := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+	if got := containers[0].(map[string]interface{})["image"]; got != "bar:baz" {
+		t.Errorf("expected image bar:baz, got %v", got)
+	}
+	subjects, _, _ := unstructured.NestedSlice(objects[5].Object, "subjects")
+	if got := subjects[0].(map[string]interface{})["namespace"]; got != "foo" {
+		t.Errorf("expected the binding subject in namespace foo, got %v", got)
+	}
+}
+
+func TestDeleteInstallManifests(t *testing.T) {
+	t.Parallel()
+	objects, _, err := renderInstallManifests(installOptions{namespace: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listKinds := map[schema.GroupVersionResource]string{}
+	existing := make([]runtime.Object, 0, len(objects))
+	for _, object := range objects {
+		gvk := object.GroupVersionKind()
+		listKinds[gvk.GroupVersion().WithResource(installResources[gvk.GroupKind()].resource)] = gvk.Kind + "List"
+		// Leave out the service, as uninstalling must tolerate missing objects.
+		if object.GetKind() != "Service" {
+			existing = append(existing, object)
+		}
+	}
+	monitorsGVR := v1alpha1.SchemeGroupVersion.WithResource("resourcemetricsmonitors")
+	listKinds[monitorsGVR] = "ResourceMetricsMonitorList"
+	listKinds[v1alpha1.SchemeGroupVersion.WithResource("clusterresourcemetricsmonitors")] = "ClusterResourceMetricsMonitorList"
+	monitor := &unstructured.Unstructured{}
+	monitor.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("ResourceMetricsMonitor"))
+	monitor.SetNamespace("default")
+	monitor.SetName("monitor")
+	monitor.SetFinalizers([]string{v1alpha1.Finalizer})
+	existing = append(existing, monitor)
+
+	for _, tt := range []struct {
+		name      string
+		deleteCRD bool
+		remaining []string
+	}{
+		{name: "keep CRD", remaining: []string{"Namespace", "CustomResourceDefinition", "CustomResourceDefinition"}},
+		{name: "delete CRD", deleteCRD: true, remaining: []string{"Namespace"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, existing...)
+			if err := deleteInstallManifests(context.Background(), client, objects, tt.deleteCRD); err != nil {
+				t.Fatal(err)
+			}
+			var remaining []string
+			for _, object := range objects {
+				resourceClient, err := installResourceInterface(client, object)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err = resourceClient.Get(context.Background(), object.GetName(), metav1.GetOptions{}); err == nil {
+					remaining = append(remaining, object.GetKind())
+				}
+			}
+			if !cmp.Equal(remaining, tt.remaining) {
+				t.Errorf("%s", cmp.Diff(remaining, tt.remaining))
+			}
+			// Monitors are released only if their CRD is deleted.
+			got, err := client.Resource(monitorsGVR).Namespace("default").Get(context.Background(), "monitor", metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if released := len(got.GetFinalizers()) == 0; released != tt.deleteCRD {
+				t.Errorf("expected the monitor to be released: %t, got finalizers %v", tt.deleteCRD, got.GetFinalizers())
+			}
+		})
+	}
+}
+
+func TestInstallCRDs(t *testing.T) {
+	t.Parallel()
+	crdsGVR := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		crdsGVR: "CustomResourceDefinitionList",
+	})
+	established := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata":   map[string]interface{}{"name": name},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Established", "status": "True"}},
+			},
+		}}
+	}
+	var applied []string
+	client.PrependReactor("patch", "customresourcedefinitions", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patch, _ := action.(clienttesting.PatchAction)
+		if patch.GetPatchType() == types.ApplyPatchType {
+			applied = append(applied, patch.GetName())
+		}
+
+		return true, established(patch.GetName()), nil
+	})
+	client.PrependReactor("get", "customresourcedefinitions", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		get, _ := action.(clienttesting.GetAction)
+
+		return true, established(get.GetName()), nil
+	})
+
+	if err := installCRDs(context.Background(), client); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"resourcemetricsmonitors." + v1alpha1.SchemeGroupVersion.Group,
+		"clusterresourcemetricsmonitors." + v1alpha1.SchemeGroupVersion.Group,
+	}
+	if !cmp.Equal(applied, expected) {
+		t.Errorf("%s", cmp.Diff(applied, expected))
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+// ksmDefaultMetricNamePrefix prefixes kube-state-metrics' custom resource metrics, unless their resources set another.
+const ksmDefaultMetricNamePrefix = "kube_customresource"
+
+// ksmCustomResourceStateMetrics is the part of kube-state-metrics' CustomResourceStateMetrics configuration that names
+// its metrics.
+type ksmCustomResourceStateMetrics struct {
+	Spec struct {
+		Resources []struct {
+			// MetricNamePrefix prefixes the resource's metrics, followed by an underscore, unless empty. Defaults to
+			// ksmDefaultMetricNamePrefix.
+			MetricNamePrefix *string `json:"metricNamePrefix,omitempty"`
+			Metrics          []struct {
+				Name string `json:"name"`
+			} `json:"metrics"`
+		} `json:"resources"`
+	} `json:"spec"`
+}
+
+// loadKSMMetricNames returns the names of the metrics the given kube-state-metrics' CustomResourceStateMetrics
+// configuration exposes, or none if no path is given. These are named alike the families' (e.g.,
+// kube_customresource_replicas), but carry kube-state-metrics' own labels, and semantics.
+func loadKSMMetricNames(path string) (sets.Set[string], error) {
+	names := sets.New[string]()
+	if path == "" {
+		return names, nil
+	}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("error reading kube-state-metrics' configuration: %w", err)
+	}
+	config := ksmCustomResourceStateMetrics{}
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error unmarshalling kube-state-metrics' configuration: %w", err)
+	}
+	for _, resource := range config.Spec.Resources {
+		prefix := ksmDefaultMetricNamePrefix
+		if resource.MetricNamePrefix != nil {
+			prefix = *resource.MetricNamePrefix
+		}
+		for _, metric := range resource.Metrics {
+			if prefix == "" {
+				names.Insert(metric.Name)
+
+				continue
+			}
+			names.Insert(prefix + "_" + metric.Name)
+		}
+	}
+
+	return names, nil
+}
+
+// ksmCollision is a family of a monitor's configuration exposing a metric named alike one of kube-state-metrics'.
+type ksmCollision struct {
+	family string
+	metric string
+	// renamed is the name the family was renamed to, if any.
+	renamed string
+}
+
+func (c ksmCollision) String() string {
+	if c.renamed != "" {
+		return fmt.Sprintf("family %q collides with kube-state-metrics' %s, renamed to %q", c.family, c.metric, c.renamed)
+	}
+
+	return fmt.Sprintf("family %q collides with kube-state-metrics' %s", c.family, c.metric)
+}
+
+// collidingKSMMetric returns the kube-state-metrics' metric, among the given ones, the given family's exposed metrics
+// collide with, if any, e.g., kube_customresource_replicas, for the replicas family.
+func collidingKSMMetric(ksmMetricNames sets.Set[string], family *FamilyType) (string, bool) {
+	suffixes := []string{""}
+	if family.Type == FamilyKindHistogram {
+		suffixes = append(suffixes, "_bucket", "_sum", "_count")
+	}
+	for _, suffix := range suffixes {
+		if metric := kubeCustomResourcePrefix + family.Name + suffix; ksmMetricNames.Has(metric) {
+			return metric, true
+		}
+	}
+
+	return "", false
+}
+
+// guardKSMCollisions returns the families of the configuration whose exposed metrics collide with the given ones of
+// kube-state-metrics, and, given a prefix, renames them after it, e.g., rsm_replicas for replicas, along with the
+// thresholds referencing them, so they are exposed apart from those. Families served as custom metrics are not
+// renamed, as their consumers refer to them by name. The resources' tests are left as is, since they render the
+// families as configured.
+func (c configuration) guardKSMCollisions(ksmMetricNames sets.Set[string], prefix string) []ksmCollision {
+	var collisions []ksmCollision
+	for _, store := range c.Stores {
+		renamed := map[string]string{}
+		for _, family := range store.Families {
+			metric, ok := collidingKSMMetric(ksmMetricNames, family)
+			if !ok {
+				continue
+			}
+			collision := ksmCollision{family: family.Name, metric: metric}
+			if prefix != "" && !family.CustomMetric {
+				collision.renamed = prefix + family.Name
+				renamed[family.Name] = collision.renamed
+				family.Name = collision.renamed
+			}
+			collisions = append(collisions, collision)
+		}
+		for i, threshold := range store.Thresholds {
+			if renamed[threshold.Family] != "" {
+				store.Thresholds[i].Family = renamed[threshold.Family]
+			}
+		}
+	}
+
+	return collisions
+}
+
+// ksmCollisionMessage returns the message reporting the given collisions, or an empty one if there are none.
+func ksmCollisionMessage(collisions []ksmCollision) string {
+	if len(collisions) == 0 {
+		return ""
+	}
+	messages := make([]string, len(collisions))
+	for i, collision := range collisions {
+		messages[i] = collision.String()
+	}
+
+	return "Families collide with kube-state-metrics' metrics: " + strings.Join(messages, "; ")
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestLoadKSMMetricNames(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(`kind: CustomResourceStateMetrics
+spec:
+  resources:
+    - groupVersionKind:
+        group: "contoso.com"
+        kind: "Bar"
+        version: "v1alpha1"
+      metrics:
+        - name: "bar_replicas"
+    - groupVersionKind:
+        group: "contoso.com"
+        kind: "Baz"
+        version: "v1alpha1"
+      metricNamePrefix: "contoso"
+      metrics:
+        - name: "baz_info"
+    - groupVersionKind:
+        group: "contoso.com"
+        kind: "Qux"
+        version: "v1alpha1"
+      metricNamePrefix: ""
+      metrics:
+        - name: "qux_info"
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadKSMMetricNames(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"contoso_baz_info", "kube_customresource_bar_replicas", "qux_info"}
+	if !cmp.Equal(sets.List(got), expected) {
+		t.Errorf("%s", cmp.Diff(sets.List(got), expected))
+	}
+
+	if got, err = loadKSMMetricNames(""); err != nil || got.Len() != 0 {
+		t.Errorf("expected no names without a configuration, got %v (%v)", got, err)
+	}
+}
+
+func TestCollidingKSMMetric(t *testing.T) {
+	t.Parallel()
+	ksmMetricNames := sets.New("kube_customresource_bar_replicas", "kube_customresource_bar_duration_bucket", "kube_pod_info")
+	for _, tt := range []struct {
+		family   *FamilyType
+		expected string
+	}{
+		{family: &FamilyType{Name: "bar_replicas"}, expected: "kube_customresource_bar_replicas"},
+		{family: &FamilyType{Name: "bar_duration", Type: FamilyKindHistogram}, expected: "kube_customresource_bar_duration_bucket"},
+		{family: &FamilyType{Name: "bar_duration"}},
+		// The families' exposed metrics are always prefixed, unlike kube-state-metrics' native ones.
+		{family: &FamilyType{Name: "pod_info"}},
+	} {
+		got, ok := collidingKSMMetric(ksmMetricNames, tt.family)
+		if ok != (tt.expected != "") || got != tt.expected {
+			t.Errorf("expected %q to collide with %q, got %q (%t)", tt.family.Name, tt.expected, got, ok)
+		}
+	}
+}
+
+func TestConfiguration_guardKSMCollisions(t *testing.T) {
+	t.Parallel()
+	ksmMetricNames := sets.New("kube_customresource_bar_replicas", "kube_customresource_bar_info")
+	newConfiguration := func() configuration {
+		return configuration{Stores: []*StoreType{{
+			Families: []*FamilyType{{Name: "bar_replicas"}, {Name: "bar_info", CustomMetric: true}, {Name: "bar_ready"}},
+			Thresholds: []ThresholdType{
+				{Name: "replicas", Family: "bar_replicas"},
+				{Name: "ready", Family: "bar_ready"},
+			},
+		}}}
+	}
+
+	// Collisions are only reported, without a prefix.
+	c := newConfiguration()
+	expected := []ksmCollision{
+		{family: "bar_replicas", metric: "kube_customresource_bar_replicas"},
+		{family: "bar_info", metric: "kube_customresource_bar_info"},
+	}
+	if got := c.guardKSMCollisions(ksmMetricNames, ""); !cmp.Equal(got, expected, cmp.AllowUnexported(ksmCollision{})) {
+		t.Errorf("%s", cmp.Diff(got, expected, cmp.AllowUnexported(ksmCollision{})))
+	}
+	if got := c.Stores[0].Families[0].Name; got != "bar_replicas" {
+		t.Errorf("expected the family not to be renamed, got %q", got)
+	}
+
+	// Colliding families are renamed, given a prefix, along with the thresholds referencing them, unless served as
+	// custom metrics.
+	c = newConfiguration()
+	expected[0].renamed = "rsm_bar_replicas"
+	if got := c.guardKSMCollisions(ksmMetricNames, "rsm_"); !cmp.Equal(got, expected, cmp.AllowUnexported(ksmCollision{})) {
+		t.Errorf("%s", cmp.Diff(got, expected, cmp.AllowUnexported(ksmCollision{})))
+	}
+	got := []string{c.Stores[0].Families[0].Name, c.Stores[0].Families[1].Name, c.Stores[0].Families[2].Name}
+	if expected := []string{"rsm_bar_replicas", "bar_info", "bar_ready"}; !cmp.Equal(got, expected) {
+		t.Errorf("%s", cmp.Diff(got, expected))
+	}
+	got = []string{c.Stores[0].Thresholds[0].Family, c.Stores[0].Thresholds[1].Family}
+	if expected := []string{"rsm_bar_replicas", "bar_ready"}; !cmp.Equal(got, expected) {
+		t.Errorf("%s", cmp.Diff(got, expected))
+	}
+	if got := ksmCollisionMessage(nil); got != "" {
+		t.Errorf("expected no message without collisions, got %q", got)
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// configSourceKubeconfig is the source of configurations loaded off the kubeconfig(s) set explicitly.
+	configSourceKubeconfig = "kubeconfig"
+	// configSourceInCluster is the source of configurations loaded off the pod's service account.
+	configSourceInCluster = "in-cluster"
+	// configSourceDefault is the source of configurations loaded off the default kubeconfig, i.e., ~/.kube/config.
+	configSourceDefault = "default kubeconfig"
+)
+
+// BuildConfig returns the client configuration for the API server, along with the source it was loaded from. It is
+// loaded off the given kubeconfig, if set (or, if it is a list of paths, as $KUBECONFIG may be, off them merged), or
+// the pod's service account, if in-cluster, or the default kubeconfig otherwise, in that order. The given API server
+// address, if set, overrides the loaded one, whatever the source. Credential plugins (exec, and OIDC, if its provider
+// is linked in) are honored as set in the kubeconfig, and are only run once the configuration is used.
+func BuildConfig(masterURL, kubeconfig string) (*rest.Config, string, error) {
+	overrides := &clientcmd.ConfigOverrides{}
+	overrides.ClusterInfo.Server = masterURL
+
+	if kubeconfig != "" {
+		loadingRules := &clientcmd.ClientConfigLoadingRules{}
+		if paths := filepath.SplitList(kubeconfig); len(paths) > 1 {
+			loadingRules.Precedence = paths
+		} else {
+			loadingRules.ExplicitPath = kubeconfig
+		}
+		cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+		if err != nil {
+			return nil, configSourceKubeconfig, fmt.Errorf("error loading kubeconfig %q: %w", kubeconfig, err)
+		}
+
+		return cfg, configSourceKubeconfig, nil
+	}
+
+	cfg, err := rest.InClusterConfig()
+	switch {
+	case err == nil:
+		if masterURL != "" {
+			cfg.Host = masterURL
+		}
+
+		return cfg, configSourceInCluster, nil
+	case !errors.Is(err, rest.ErrNotInCluster):
+		return nil, configSourceInCluster, fmt.Errorf("error loading in-cluster configuration: %w", err)
+	}
+
+	cfg, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), overrides).ClientConfig()
+	if err != nil {
+		if clientcmd.IsEmptyConfig(err) {
+			return nil, configSourceDefault, fmt.Errorf("no kubeconfig found, and not running in-cluster: set --%s, or --%s", kubeconfigFlagName, masterURLFlagName)
+		}
+
+		return nil, configSourceDefault, fmt.Errorf("error loading the default kubeconfig: %w", err)
+	}
+
+	return cfg, configSourceDefault, nil
+}
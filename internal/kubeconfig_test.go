@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKubeconfigTemplate = `apiVersion: v1
+kind: Config
+clusters:
+- name: {{name}}
+  cluster:
+    server: https://{{name}}.example.com
+contexts:
+- name: {{name}}
+  context:
+    cluster: {{name}}
+    user: {{name}}
+current-context: {{name}}
+users:
+- name: {{name}}
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1
+      command: {{name}}-credentials
+      interactiveMode: Never
+`
+
+func writeTestKubeconfig(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(testKubeconfigTemplate, "{{name}}", name)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestBuildConfig(t *testing.T) {
+	t.Parallel()
+	east := writeTestKubeconfig(t, "east")
+	west := writeTestKubeconfig(t, "west")
+	tests := []struct {
+		name         string
+		masterURL    string
+		kubeconfig   string
+		expectedHost string
+		expectedExec string
+		wantErr      string
+	}{
+		{
+			name:         "explicit kubeconfig with an exec plugin",
+			kubeconfig:   east,
+			expectedHost: "https://east.example.com",
+			expectedExec: "east-credentials",
+		},
+		{
+			name:         "master overrides the kubeconfig's server",
+			masterURL:    "https://master.example.com",
+			kubeconfig:   east,
+			expectedHost: "https://master.example.com",
+			expectedExec: "east-credentials",
+		},
+		{
+			name:         "list of kubeconfigs, the first one taking precedence",
+			kubeconfig:   west + string(filepath.ListSeparator) + east,
+			expectedHost: "https://west.example.com",
+			expectedExec: "west-credentials",
+		},
+		{
+			name:       "missing kubeconfig",
+			kubeconfig: filepath.Join(t.TempDir(), "missing"),
+			wantErr:    "missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			cfg, source, err := BuildConfig(tt.masterURL, tt.kubeconfig)
+			if source != configSourceKubeconfig {
+				t.Errorf("expected source %q, got %q", configSourceKubeconfig, source)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error mentioning %q, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Host != tt.expectedHost {
+				t.Errorf("expected host %q, got %q", tt.expectedHost, cfg.Host)
+			}
+			if cfg.ExecProvider == nil || cfg.ExecProvider.Command != tt.expectedExec {
+				t.Errorf("expected the %q exec plugin, got %+v", tt.expectedExec, cfg.ExecProvider)
+			}
+		})
+	}
+}
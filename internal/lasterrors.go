@@ -0,0 +1,155 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// lastErrorsReportInterval is the interval the monitors' last errors are reported in their status at, so that
+	// errors resolving expressions against frequently updated objects do not update the monitors' status as often.
+	lastErrorsReportInterval = 30 * time.Second
+	// lastErrorMaxLength bounds the length of the expressions and errors reported, so a handful of long ones do not
+	// bloat the monitors' status.
+	lastErrorMaxLength = 256
+)
+
+// lastErrors holds the most recent errors resolving a monitor's expressions, to report them in its status.
+type lastErrors struct {
+	mutex   sync.Mutex
+	limit   int
+	samples []v1alpha1.ResolutionError
+	changed bool
+}
+
+// newLastErrors returns a lastErrors holding up to the given number of errors, or nil if none are to be held.
+func newLastErrors(limit int) *lastErrors {
+	if limit <= 0 {
+		return nil
+	}
+
+	// Report the (lack of) errors once, so errors of the monitor's previous configuration are cleared.
+	return &lastErrors{limit: limit, changed: true}
+}
+
+// record records the error resolving the given expression against the given object, evicting the oldest error held, if
+// at the limit. Errors already held are not recorded again, so repeatedly failing expressions do not crowd the rest out.
+func (e *lastErrors) record(expression string, object metav1.Object, err error) {
+	if e == nil {
+		return
+	}
+	sample := v1alpha1.ResolutionError{
+		Expression: truncate(expression, lastErrorMaxLength),
+		Object:     klog.KObj(object).String(),
+		Error:      truncate(err.Error(), lastErrorMaxLength),
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if slices.ContainsFunc(e.samples, func(held v1alpha1.ResolutionError) bool {
+		return held.Expression == sample.Expression && held.Object == sample.Object && held.Error == sample.Error
+	}) {
+		return
+	}
+	sample.Timestamp = metav1.Now()
+	e.samples = append(e.samples, sample)
+	if len(e.samples) > e.limit {
+		e.samples = e.samples[len(e.samples)-e.limit:]
+	}
+	e.changed = true
+}
+
+// flush returns the errors held, most recent first, and whether they changed since they were last flushed.
+func (e *lastErrors) flush() ([]v1alpha1.ResolutionError, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if !e.changed {
+		return nil, false
+	}
+	e.changed = false
+	samples := slices.Clone(e.samples)
+	slices.Reverse(samples)
+
+	return samples, true
+}
+
+// requeue marks the errors held as changed, for them to be flushed again, e.g., if reporting them failed.
+func (e *lastErrors) requeue() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.changed = true
+}
+
+// truncate returns the given string, cut down to the given length in bytes, if longer.
+func truncate(s string, length int) string {
+	if len(s) <= length {
+		return s
+	}
+
+	return strings.ToValidUTF8(s[:length-len("...")], "") + "..."
+}
+
+// reportLastErrors reports the errors held for each monitor in its status, if they changed since they were last
+// reported.
+func (c *Controller) reportLastErrors(ctx context.Context) {
+	c.lastErrors.Range(func(key, value any) bool {
+		held := value.(*lastErrors)
+		samples, changed := held.flush()
+		if !changed {
+			return true
+		}
+		namespace, name, err := cache.SplitMetaNamespaceKey(key.(string))
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to parse key %q: %w", key, err))
+
+			return true
+		}
+		resource, err := c.monitors(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			held.requeue()
+			utilruntime.HandleError(fmt.Errorf("failed to get %s: %w", key, err))
+
+			return true
+		}
+		if equality.Semantic.DeepEqual(resource.Status.LastErrors, samples) {
+			return true
+		}
+		resource.Status.LastErrors = samples
+		if _, err = c.monitors(namespace).UpdateStatus(ctx, resource, metav1.UpdateOptions{}); err != nil {
+			held.requeue()
+			utilruntime.HandleError(fmt.Errorf("failed to report the last errors of %s: %w", key, err))
+		}
+
+		return true
+	})
+}
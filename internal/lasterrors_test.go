@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLastErrors(t *testing.T) {
+	t.Parallel()
+	object := &metav1.ObjectMeta{Namespace: "default", Name: "foo"}
+	e := newLastErrors(2)
+
+	// The initial flush reports the lack of errors, to clear the ones of previous configurations.
+	if samples, changed := e.flush(); !changed || len(samples) != 0 {
+		t.Fatalf("expected no errors to be flushed initially, got %v (changed: %t)", samples, changed)
+	}
+	e.record("o.spec.a", object, errors.New("a"))
+	e.record("o.spec.a", object, errors.New("a"))
+	e.record("o.spec.b", object, errors.New("b"))
+	e.record("o.spec.c", object, errors.New(strings.Repeat("c", 2*lastErrorMaxLength)))
+
+	samples, changed := e.flush()
+	if !changed {
+		t.Fatal("expected the errors to have changed")
+	}
+	if len(samples) != 2 || samples[0].Expression != "o.spec.c" || samples[1].Expression != "o.spec.b" {
+		t.Fatalf("expected the two most recent errors, most recent first, got %v", samples)
+	}
+	if samples[1].Object != "default/foo" {
+		t.Errorf("expected the object to be default/foo, got %q", samples[1].Object)
+	}
+	if len(samples[0].Error) != lastErrorMaxLength || !strings.HasSuffix(samples[0].Error, "...") {
+		t.Errorf("expected the error to be truncated to %d bytes, got %q", lastErrorMaxLength, samples[0].Error)
+	}
+	if _, changed = e.flush(); changed {
+		t.Error("expected the errors not to have changed since the last flush")
+	}
+	e.record("o.spec.b", object, errors.New("b"))
+	if _, changed = e.flush(); changed {
+		t.Error("expected errors already held not to be recorded again")
+	}
+
+	// Monitors not reporting errors record none.
+	(*lastErrors)(nil).record("o.spec.a", object, errors.New("a"))
+}
+
+func TestController_reportLastErrors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	c := &Controller{
+		rsmClientset: fake.NewSimpleClientset(&v1alpha1.ResourceMetricsMonitor{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		}),
+	}
+	e := newLastErrors(5)
+	e.record("o.spec.a", &metav1.ObjectMeta{Namespace: "default", Name: "bar"}, errors.New("no such key: a"))
+	c.lastErrors.Store("default/foo", e)
+	// Errors of monitors that no longer exist are not reported.
+	c.lastErrors.Store("default/baz", newLastErrors(5))
+
+	c.reportLastErrors(ctx)
+
+	got, err := c.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors("default").Get(ctx, "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Status.LastErrors) != 1 {
+		t.Fatalf("expected a single error to be reported, got %v", got.Status.LastErrors)
+	}
+	if sample := got.Status.LastErrors[0]; sample.Expression != "o.spec.a" || sample.Object != "default/bar" || sample.Error != "no such key: a" {
+		t.Errorf("unexpected error reported: %+v", sample)
+	}
+}
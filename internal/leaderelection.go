@@ -0,0 +1,75 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/internal/version"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	// leaderElectionLeaseDuration, leaderElectionRenewDeadline, and leaderElectionRetryPeriod are the leader election
+	// timings, as in controller-runtime, i.e., the time a lease is held for without being renewed, the time the leader
+	// retries renewing it for before giving it up, and the time candidates wait between attempts.
+	leaderElectionLeaseDuration = 15 * time.Second
+	leaderElectionRenewDeadline = 10 * time.Second
+	leaderElectionRetryPeriod   = 2 * time.Second
+
+	// inClusterNamespaceFile holds the namespace of the pod's service account, which the lease defaults to.
+	inClusterNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// leaderElection is the lease the manager campaigns for, running its leader-elected runnables only while holding it.
+type leaderElection struct {
+	lock                                      resourcelock.Interface
+	leaseDuration, renewDeadline, retryPeriod time.Duration
+}
+
+// newLeaderElection returns the leader election over the controller's lease in the given namespace, or, if empty, in
+// the controller's own namespace, if running in-cluster, or the default one otherwise, identified by the host's name.
+func newLeaderElection(kubeClientset kubernetes.Interface, namespace string) (*leaderElection, error) {
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+		if raw, err := os.ReadFile(inClusterNamespaceFile); err == nil && strings.TrimSpace(string(raw)) != "" {
+			namespace = strings.TrimSpace(string(raw))
+		}
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the leader election identity: %w", err)
+	}
+
+	return &leaderElection{
+		lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{Namespace: namespace, Name: version.ControllerName.String()},
+			Client:    kubeClientset.CoordinationV1(),
+			// Replicas on the same host, e.g., when run locally, are told apart by a random suffix.
+			LockConfig: resourcelock.ResourceLockConfig{Identity: hostname + "_" + string(uuid.NewUUID())},
+		},
+		leaseDuration: leaderElectionLeaseDuration,
+		renewDeadline: leaderElectionRenewDeadline,
+		retryPeriod:   leaderElectionRetryPeriod,
+	}, nil
+}
@@ -0,0 +1,385 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const lintSubcommandName = "lint"
+
+// highCardinalityFields are object fields that are unique per object (or per object revision), and thus make for
+// unbounded label values.
+var highCardinalityFields = []string{
+	"metadata.uid",
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.creationTimestamp",
+	"metadata.managedFields",
+}
+
+// lintSeverity denotes whether a finding fails the lint.
+type lintSeverity string
+
+const (
+	lintSeverityError   lintSeverity = "error"
+	lintSeverityWarning lintSeverity = "warning"
+)
+
+// lintFinding is a single problem found in a ResourceMetricsMonitor.
+type lintFinding struct {
+	severity lintSeverity
+	// field is the path to the offending field within the RMM's configuration, if any.
+	field   string
+	message string
+}
+
+func (f lintFinding) String() string {
+	if f.field == "" {
+		return fmt.Sprintf("[%s] %s", f.severity, f.message)
+	}
+
+	return fmt.Sprintf("[%s] %s: %s", f.severity, f.field, f.message)
+}
+
+// linter statically validates ResourceMetricsMonitors.
+type linter struct {
+	maxSeriesPerObject int
+	// familyNames tracks the families seen across all linted RMMs, as they share the same exposition.
+	familyNames map[string]string
+	celResolver *resolver.CELResolver
+	// ksmMetricNames holds the names of kube-state-metrics' metrics the families' exposed ones may collide with.
+	ksmMetricNames sets.Set[string]
+}
+
+func newLinter(maxSeriesPerObject int) *linter {
+	return &linter{
+		maxSeriesPerObject: maxSeriesPerObject,
+		familyNames:        map[string]string{},
+		celResolver:        resolver.NewCELResolver(klog.Background(), 0, 0, nil, "", "", ""),
+	}
+}
+
+// lint statically validates the ResourceMetricsMonitors in the given files, and fails if any errors were found.
+func lint(_ context.Context, args []string) error {
+	flags := flag.NewFlagSet(lintSubcommandName, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] <files...>\n", lintSubcommandName)
+		flags.PrintDefaults()
+	}
+	maxSeriesPerObject := flags.Int("max-series-per-object", 50, "Warn if a store is estimated to generate more series than this per object.")
+	warningsAsErrors := flags.Bool("warnings-as-errors", false, "Fail on warnings as well as errors.")
+	ksmCRSConfig := flags.String(ksmCRSConfigFlagName, "", "Path to kube-state-metrics' CustomResourceStateMetrics configuration, to warn about families exposing metrics named alike its ones.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return errors.New("at least one file is required")
+	}
+	l := newLinter(*maxSeriesPerObject)
+	var err error
+	if l.ksmMetricNames, err = loadKSMMetricNames(*ksmCRSConfig); err != nil {
+		return err
+	}
+
+	return lintFiles(os.Stdout, l, *warningsAsErrors, flags.Args()...)
+}
+
+// lintFiles lints the given files, and writes all findings to the given writer.
+func lintFiles(w io.Writer, l *linter, warningsAsErrors bool, paths ...string) error {
+	var failures int
+	for _, path := range paths {
+		objects, err := decodeManifests(path)
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			for _, finding := range l.lintObject(object) {
+				fmt.Fprintf(w, "%s: %s: %s\n", path, klog.KObj(object), finding)
+				if finding.severity == lintSeverityError || warningsAsErrors {
+					failures++
+				}
+			}
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("found %d problem(s)", failures)
+	}
+
+	return nil
+}
+
+// lintObject lints the given object, which is expected to be a (Cluster)ResourceMetricsMonitor. Both share the same
+// schema, so they are decoded alike.
+func (l *linter) lintObject(object *unstructured.Unstructured) []lintFinding {
+	if gvk := object.GroupVersionKind(); gvk != v1alpha1.SchemeGroupVersion.WithKind("ResourceMetricsMonitor") &&
+		gvk != v1alpha1.SchemeGroupVersion.WithKind("ClusterResourceMetricsMonitor") {
+		return []lintFinding{{severity: lintSeverityError, message: fmt.Sprintf("expected a (Cluster)ResourceMetricsMonitor, got %s", gvk)}}
+	}
+	rmm := &v1alpha1.ResourceMetricsMonitor{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(object.Object, rmm, true); err != nil {
+		return []lintFinding{{severity: lintSeverityError, message: fmt.Sprintf("error decoding ResourceMetricsMonitor: %v", err)}}
+	}
+
+	// Presets' stores come first, so that the configuration's are linted at the indices they are processed at.
+	raw, err := monitorConfiguration(rmm.Spec)
+	if err != nil {
+		return []lintFinding{{severity: lintSeverityError, field: "spec.preset", message: err.Error()}}
+	}
+	findings := l.lintConfiguration(raw)
+	failures := runTests(context.Background(), rmm)
+	for i := range rmm.Spec.Tests {
+		if err, ok := failures[i]; ok {
+			findings = append(findings, lintFinding{severity: lintSeverityError, field: fmt.Sprintf("spec.tests[%d]", i), message: err.Error()})
+		}
+	}
+
+	return findings
+}
+
+// lintConfiguration lints the given raw configuration.
+func (l *linter) lintConfiguration(raw string) []lintFinding {
+	if strings.TrimSpace(raw) == "" {
+		return []lintFinding{{severity: lintSeverityError, field: "spec.configuration", message: "configuration is empty"}}
+	}
+	var findings []lintFinding
+	c := configuration{}
+	// Report unknown fields, but carry on with the lenient decoding (the one the controller uses) to lint the rest.
+	if err := yaml.UnmarshalStrict([]byte(raw), &c); err != nil {
+		findings = append(findings, lintFinding{severity: lintSeverityError, field: "spec.configuration", message: err.Error()})
+		c = configuration{}
+		if err = yaml.Unmarshal([]byte(raw), &c); err != nil {
+			return findings
+		}
+	}
+	if err := c.validate(); err != nil {
+		return append(findings, lintFinding{severity: lintSeverityError, field: "spec.configuration", message: err.Error()})
+	}
+	if len(c.Stores) == 0 {
+		return append(findings, lintFinding{severity: lintSeverityWarning, field: "stores", message: "no stores are configured"})
+	}
+
+	for i, store := range c.Stores {
+		findings = append(findings, l.lintStore(fmt.Sprintf("stores[%d]", i), store)...)
+	}
+
+	return findings
+}
+
+func (l *linter) lintStore(field string, store *StoreType) []lintFinding {
+	var findings []lintFinding
+	errorf := func(field, format string, args ...any) {
+		findings = append(findings, lintFinding{severity: lintSeverityError, field: field, message: fmt.Sprintf(format, args...)})
+	}
+	warnf := func(field, format string, args ...any) {
+		findings = append(findings, lintFinding{severity: lintSeverityWarning, field: field, message: fmt.Sprintf(format, args...)})
+	}
+
+	// Stores selecting their targets by CRD labels take the group, version, kind, and resource from the CRDs.
+	for _, target := range []struct{ name, value string }{
+		{"group", store.Group},
+		{"version", store.Version},
+		{"kind", store.Kind},
+		{"resource", store.Resource},
+	} {
+		switch {
+		case store.Selectors.CRD != "" && target.value != "":
+			errorf(field+"."+target.name, "%s cannot be set along with selectors.crd", target.name)
+		case store.Selectors.CRD == "" && target.value == "" && target.name != "group":
+			errorf(field+"."+target.name, "%s is required", target.name)
+		}
+	}
+	if _, err := labels.Parse(store.Selectors.CRD); err != nil {
+		errorf(field+".selectors.crd", "invalid CRD selector: %v", err)
+	}
+	if resource, ok := nativeResource(store); ok && store.Resource != "" {
+		warnf(field, "targets the native resource %q, which must be allow-listed by the controller (see --%s)", resource, nativeResourcesFlagName)
+	}
+	if _, err := labels.Parse(store.Selectors.Label); err != nil {
+		errorf(field+".selectors.label", "invalid label selector: %v", err)
+	}
+	if _, err := fields.ParseSelector(store.Selectors.Field); err != nil {
+		errorf(field+".selectors.field", "invalid field selector: %v", err)
+	}
+	if store.Selectors.Filter != "" {
+		if err := l.celResolver.Compile(store.Selectors.Filter); err != nil {
+			errorf(field+".selectors.filter", "%v", err)
+		}
+	}
+	for j, threshold := range store.Thresholds {
+		if err := l.celResolver.Compile(threshold.Expression); err != nil {
+			errorf(fmt.Sprintf("%s.thresholds[%d].expression", field, j), "%v", err)
+		}
+	}
+	if err := validateResolver(store.Resolver); err != nil {
+		errorf(field+".resolver", "%v", err)
+	}
+	if err := validateLabelLengths(store.LabelKeys, store.LabelValues); err != nil {
+		errorf(field, "%v", err)
+	}
+	if len(store.Families) == 0 {
+		warnf(field+".families", "no families are configured")
+	}
+
+	var seriesPerObject int
+	for j, family := range store.Families {
+		familyField := fmt.Sprintf("%s.families[%d]", field, j)
+		if family.Name == "" {
+			errorf(familyField+".name", "name is required")
+		} else if name := kubeCustomResourcePrefix + family.Name; !model.IsValidLegacyMetricName(name) {
+			errorf(familyField+".name", "%q is not a valid metric name", name)
+		} else if previous, ok := l.familyNames[family.Name]; ok {
+			errorf(familyField+".name", "duplicate family name %q, previously defined at %s", family.Name, previous)
+		} else {
+			l.familyNames[family.Name] = familyField
+		}
+		if metric, ok := collidingKSMMetric(l.ksmMetricNames, family); ok {
+			warnf(familyField+".name", "collides with kube-state-metrics' %s, unless prefixed (see --%s)", metric, ksmCollisionPrefixFlagName)
+		}
+		if family.Help == "" {
+			warnf(familyField+".help", "help is empty")
+		}
+		if err := validateResolver(family.Resolver); err != nil {
+			errorf(familyField+".resolver", "%v", err)
+		}
+		if err := validateLabelLengths(family.LabelKeys, family.LabelValues); err != nil {
+			errorf(familyField, "%v", err)
+		}
+		if family.Filter != "" {
+			if err := l.celResolver.Compile(family.Filter); err != nil {
+				errorf(familyField+".filter", "%v", err)
+			}
+		}
+		if len(family.Metrics) == 0 {
+			warnf(familyField+".metrics", "no metrics are configured")
+		}
+		seriesPerObject += len(family.Metrics)
+
+		for k, metric := range family.Metrics {
+			metricField := fmt.Sprintf("%s.metrics[%d]", familyField, k)
+			findings = append(findings, l.lintMetric(metricField, store, family, metric)...)
+		}
+	}
+	if seriesPerObject > l.maxSeriesPerObject {
+		warnf(field, "estimated to generate at least %d series per object, more than %d", seriesPerObject, l.maxSeriesPerObject)
+	}
+
+	return findings
+}
+
+func (l *linter) lintMetric(field string, store *StoreType, family *FamilyType, metric *MetricType) []lintFinding {
+	var findings []lintFinding
+	errorf := func(format string, args ...any) {
+		findings = append(findings, lintFinding{severity: lintSeverityError, field: field, message: fmt.Sprintf(format, args...)})
+	}
+	warnf := func(format string, args ...any) {
+		findings = append(findings, lintFinding{severity: lintSeverityWarning, field: field, message: fmt.Sprintf(format, args...)})
+	}
+
+	if err := validateResolver(metric.Resolver); err != nil {
+		errorf("%v", err)
+	}
+	if err := validateLabelLengths(metric.LabelKeys, metric.LabelValues); err != nil {
+		errorf("%v", err)
+	}
+	if metric.Value == "" {
+		errorf("value is required")
+	}
+
+	// Lint the effective labelset, i.e., including the inherited labels.
+	labelKeys := slices.Concat(metric.LabelKeys, family.LabelKeys, store.LabelKeys)
+	labelValues := slices.Concat(metric.LabelValues, family.LabelValues, store.LabelValues)
+	seen, reserved := map[string]struct{}{"group": {}, "version": {}, "kind": {}}, "group, version, and kind"
+	if family.Type == FamilyKindHistogram {
+		seen[histogramBucketLabel], reserved = struct{}{}, "group, version, kind, and "+histogramBucketLabel
+	}
+	for _, key := range labelKeys {
+		if !model.LabelName(key).IsValidLegacy() {
+			warnf("label key %q is not a valid label name, and will be sanitized to %q", key, sanitizeKey(key))
+		}
+		if _, ok := seen[sanitizeKey(key)]; ok {
+			errorf("duplicate label key %q (%s are reserved)", sanitizeKey(key), reserved)
+		}
+		seen[sanitizeKey(key)] = struct{}{}
+	}
+	for _, value := range labelValues {
+		for _, highCardinalityField := range highCardinalityFields {
+			if strings.Contains(value, highCardinalityField) {
+				warnf("label value %q references %s, which is unbounded in cardinality", value, highCardinalityField)
+			}
+		}
+	}
+
+	if effectiveResolver(store, family, metric) != ResolverTypeCEL {
+		return findings
+	}
+	queries := append(labelValues, metric.Value)
+	if metric.Histogram != nil {
+		queries = append(queries, metric.Histogram.Count)
+	}
+	for _, query := range queries {
+		if query == "" {
+			continue
+		}
+		if err := l.celResolver.Compile(query); err != nil {
+			errorf("%v", err)
+		}
+	}
+
+	return findings
+}
+
+// effectiveResolver returns the resolver the given metric is evaluated with, following the store, family, and metric
+// resolver inheritance.
+func effectiveResolver(store *StoreType, family *FamilyType, metric *MetricType) ResolverType {
+	for _, r := range []ResolverType{metric.Resolver, family.Resolver, store.Resolver} {
+		if r != ResolverTypeNone {
+			return r
+		}
+	}
+
+	return ResolverTypeUnstructured
+}
+
+func validateResolver(r ResolverType) error {
+	switch r {
+	case ResolverTypeNone, ResolverTypeUnstructured, ResolverTypeCEL:
+		return nil
+	default:
+		return fmt.Errorf("unknown resolver %q", r)
+	}
+}
@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestLinter_lintConfiguration(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		configuration string
+		expected      []string
+	}{
+		{
+			name: "valid configuration",
+			configuration: `stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    resolver: "cel"
+    families:
+      - name: "bar_replicas"
+        help: "Replicas"
+        metrics:
+          - labelKeys: ["name"]
+            labelValues: ["o.metadata.name"]
+            value: "o.spec.replicas"
+`,
+		},
+		{
+			name:          "empty configuration",
+			configuration: " ",
+			expected:      []string{"[error] spec.configuration: configuration is empty"},
+		},
+		{
+			name: "missing fields, unknown fields, and invalid selectors",
+			configuration: `stores:
+  - group: "contoso.com"
+    kind: "Bar"
+    selector: {}
+    selectors:
+      label: "a in (b"
+    families:
+      - name: "bar_info"
+        help: "Information"
+        metrics:
+          - value: "1"
+`,
+			expected: []string{
+				`[error] spec.configuration: error unmarshaling JSON: while decoding JSON: json: unknown field "selector"`,
+				"[error] stores[0].version: version is required",
+				"[error] stores[0].resource: resource is required",
+				"[error] stores[0].selectors.label: invalid label selector: unable to parse requirement: found '', expected: ',' or ')'",
+			},
+		},
+		{
+			name: "CRD selector along with a target",
+			configuration: `stores:
+  - kind: "Bar"
+    selectors:
+      crd: "a in (b"
+    families:
+      - name: "bar_info"
+        help: "Information"
+        metrics:
+          - value: "1"
+`,
+			expected: []string{
+				"[error] stores[0].kind: kind cannot be set along with selectors.crd",
+				"[error] stores[0].selectors.crd: invalid CRD selector: unable to parse requirement: found '', expected: ',' or ')'",
+			},
+		},
+		{
+			name: "native resource",
+			configuration: `stores:
+  - version: "v1"
+    kind: "Pod"
+    resource: "pods"
+    families:
+      - name: "pod_info"
+        help: "Information"
+        metrics:
+          - value: "1"
+`,
+			expected: []string{
+				`[warning] stores[0]: targets the native resource "pods", which must be allow-listed by the controller (see --native-resources)`,
+				`[warning] stores[0].families[0].name: collides with kube-state-metrics' kube_customresource_pod_info, unless prefixed (see --ksm-collision-prefix)`,
+			},
+		},
+		{
+			name: "unknown aggregation",
+			configuration: `stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    families:
+      - name: "bars_by_phase"
+        help: "Bars by phase"
+        aggregate: "max"
+        metrics:
+          - labelKeys: ["phase"]
+            labelValues: ["status.phase"]
+            value: "1"
+`,
+			expected: []string{
+				`[error] spec.configuration: error validating configuration: stores[0].families[0].aggregate: unknown aggregation "max"`,
+			},
+		},
+		{
+			name: "invalid and duplicate names",
+			configuration: `stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    labelKeys: ["name"]
+    labelValues: ["metadata.name"]
+    families:
+      - name: "bar-info"
+        help: "Information"
+        metrics:
+          - labelKeys: ["kind", "name", "my.uid"]
+            labelValues: ["kind", "metadata.name", "metadata.uid"]
+            value: "1"
+      - name: "bar_replicas"
+        metrics:
+          - value: "spec.replicas"
+      - name: "bar_replicas"
+        help: "Replicas"
+        metrics:
+          - value: "spec.replicas"
+`,
+			expected: []string{
+				`[error] stores[0].families[0].name: "kube_customresource_bar-info" is not a valid metric name`,
+				`[error] stores[0].families[0].metrics[0]: duplicate label key "kind" (group, version, and kind are reserved)`,
+				`[warning] stores[0].families[0].metrics[0]: label key "my.uid" is not a valid label name, and will be sanitized to "my_uid"`,
+				`[error] stores[0].families[0].metrics[0]: duplicate label key "name" (group, version, and kind are reserved)`,
+				`[warning] stores[0].families[0].metrics[0]: label value "metadata.uid" references metadata.uid, which is unbounded in cardinality`,
+				"[warning] stores[0].families[1].help: help is empty",
+				`[error] stores[0].families[2].name: duplicate family name "bar_replicas", previously defined at stores[0].families[1]`,
+				"[warning] stores[0]: estimated to generate at least 3 series per object, more than 1",
+			},
+		},
+		{
+			name: "CEL compilation and cardinality",
+			configuration: `stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    families:
+      - name: "bar_replicas"
+        help: "Replicas"
+        resolver: "cel"
+        filter: "o.spec.(("
+        metrics:
+          - value: "o.spec.(("
+          - value: "o.spec.replicas"
+            resolver: "foo"
+`,
+			expected: []string{
+				"[error] stores[0].families[0].filter: error parsing CEL query: ERROR: <input>:1:8: Syntax error: no viable alternative at input '.('\n | o.spec.((\n | .......^",
+				"[error] stores[0].families[0].metrics[0]: error parsing CEL query: ERROR: <input>:1:8: Syntax error: no viable alternative at input '.('\n | o.spec.((\n | .......^",
+				`[error] stores[0].families[0].metrics[1]: unknown resolver "foo"`,
+				"[warning] stores[0]: estimated to generate at least 2 series per object, more than 1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			l := newLinter(1)
+			l.ksmMetricNames = sets.New("kube_customresource_pod_info")
+			var got []string
+			for _, finding := range l.lintConfiguration(tt.configuration) {
+				got = append(got, finding.String())
+			}
+			if !cmp.Equal(got, tt.expected) {
+				t.Errorf("%s", cmp.Diff(got, tt.expected))
+			}
+		})
+	}
+}
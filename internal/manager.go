@@ -0,0 +1,246 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/klog/v2"
+)
+
+// errLeadershipLost is returned by managers that lost their leadership while running.
+var errLeadershipLost = errors.New("leader election lost")
+
+// gracefulShutdownTimeout bounds the time the manager waits for its runnables to stop, e.g., for the servers to drain
+// their in-flight requests, once it is stopped.
+const gracefulShutdownTimeout = 30 * time.Second
+
+// runnable is a component the manager runs until the given context is cancelled, or it fails, e.g., a server, or a
+// periodic task. Runnables are expected to return once the context is cancelled.
+type runnable interface {
+	start(ctx context.Context) error
+}
+
+// runnableFunc adapts a function to the runnable interface.
+type runnableFunc func(ctx context.Context) error
+
+// start runs the function.
+func (f runnableFunc) start(ctx context.Context) error {
+	return f(ctx)
+}
+
+// every returns a runnable calling the given function every interval, until the context is cancelled.
+func every(f func(ctx context.Context), interval time.Duration) runnable {
+	return runnableFunc(func(ctx context.Context) error {
+		wait.UntilWithContext(ctx, f, interval)
+
+		return nil
+	})
+}
+
+// namedRunnable is a runnable along with the name it is logged by.
+type namedRunnable struct {
+	name string
+	runnable
+}
+
+// manager runs the controller's runnables alongside each other, and collects the checks its telemetry server reports
+// readiness by, in place of orchestrating goroutines and servers by hand. All runnables are stopped once the context is
+// cancelled, or any of them fails, or the manager loses its leadership, and its shutdown hooks are run once they have.
+type manager struct {
+	logger    klog.Logger
+	runnables []namedRunnable
+	// leaderRunnables are only run while the manager holds its leader election's lease, if any.
+	leaderRunnables []namedRunnable
+	// leaderElection, if set, is the lease the manager campaigns for before running its leader-elected runnables.
+	leaderElection *leaderElection
+	// readyzChecks hold the checks that must pass for the controller to report ready.
+	readyzChecks []func() error
+	// shutdownHooks are run, in order, once all runnables have stopped, e.g., to write out state.
+	shutdownHooks []func() error
+	// shutdownTimeout bounds the time runnables are waited on to stop.
+	shutdownTimeout time.Duration
+}
+
+// newManager returns a new manager.
+func newManager(logger klog.Logger) *manager {
+	return &manager{logger: logger, shutdownTimeout: gracefulShutdownTimeout}
+}
+
+// add registers the given runnable to be run under the given name.
+func (m *manager) add(name string, r runnable) {
+	m.runnables = append(m.runnables, namedRunnable{name: name, runnable: r})
+}
+
+// addLeaderElected registers the given runnable to be run under the given name, only while the manager is the leader,
+// e.g., as it writes to the API, which replicas would race on otherwise.
+func (m *manager) addLeaderElected(name string, r runnable) {
+	m.leaderRunnables = append(m.leaderRunnables, namedRunnable{name: name, runnable: r})
+}
+
+// addReadyzCheck registers the given check the controller's readiness depends on.
+func (m *manager) addReadyzCheck(check func() error) {
+	m.readyzChecks = append(m.readyzChecks, check)
+}
+
+// addShutdownHook registers the given hook to be run once all runnables have stopped.
+func (m *manager) addShutdownHook(hook func() error) {
+	m.shutdownHooks = append(m.shutdownHooks, hook)
+}
+
+// start runs all runnables, and blocks until the given context is cancelled, or any runnable fails, returning the first
+// runnable's error, if any.
+func (m *manager) start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() { firstErr = err })
+		cancel()
+	}
+	run := func(ctx context.Context, runnables []namedRunnable) {
+		for _, r := range runnables {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				m.logger.V(1).Info("Starting runnable", "runnable", r.name)
+				if err := r.start(ctx); err != nil {
+					fail(fmt.Errorf("error running %s: %w", r.name, err))
+				}
+			}()
+		}
+	}
+	run(ctx, m.runnables)
+	if m.leaderElection == nil {
+		run(ctx, m.leaderRunnables)
+	} else if err := m.campaign(ctx, &wg, run, fail); err != nil {
+		fail(err)
+	}
+
+	<-ctx.Done()
+	m.logger.V(1).Info("Stopping runnables")
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(m.shutdownTimeout):
+		m.logger.Error(fmt.Errorf("runnables did not stop within %v", m.shutdownTimeout), "continuing shutdown")
+	}
+	for _, hook := range m.shutdownHooks {
+		if err := hook(); err != nil {
+			m.logger.Error(err, "error running shutdown hook")
+		}
+	}
+
+	return firstErr
+}
+
+// campaign campaigns for the manager's leader election's lease in the background, running the manager's leader-elected
+// runnables once it is acquired, and failing the manager if it is lost, so the replica restarts, rather than keep
+// running them alongside the new leader's. The lease is released once the manager stops.
+func (m *manager) campaign(ctx context.Context, wg *sync.WaitGroup, run func(context.Context, []namedRunnable), fail func(error)) error {
+	// The elector starts leading in a goroutine of its own, which may only be scheduled once it stopped, so runnables
+	// are only started while it has not, lest they be started past the manager waiting on them.
+	var (
+		mu      sync.Mutex
+		stopped bool
+	)
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            m.leaderElection.lock,
+		LeaseDuration:   m.leaderElection.leaseDuration,
+		RenewDeadline:   m.leaderElection.renewDeadline,
+		RetryPeriod:     m.leaderElection.retryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				mu.Lock()
+				defer mu.Unlock()
+				if stopped {
+					return
+				}
+				m.logger.Info("Acquired leadership", "identity", m.leaderElection.lock.Identity())
+				run(ctx, m.leaderRunnables)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() == nil {
+					fail(errLeadershipLost)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error setting up leader election: %w", err)
+	}
+	m.logger.Info("Campaigning for leadership", "lease", m.leaderElection.lock.Describe())
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		elector.Run(ctx)
+		mu.Lock()
+		stopped = true
+		mu.Unlock()
+	}()
+
+	return nil
+}
+
+// serverRunnable serves the given server on the given listeners, and shuts it down gracefully, within the manager's
+// shutdown timeout, once the context is cancelled.
+func serverRunnable(logger klog.Logger, name string, server *http.Server, listeners []net.Listener, shutdownTimeout time.Duration) runnable {
+	return runnableFunc(func(ctx context.Context) error {
+		serveErrs := make(chan error, len(listeners))
+		for _, listener := range listeners {
+			go func() {
+				logger.V(1).Info("Starting server on", "server", name, "address", listener.Addr().String())
+				if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					serveErrs <- err
+				}
+			}()
+		}
+
+		var err error
+		select {
+		case <-ctx.Done():
+		case err = <-serveErrs:
+		}
+		logger.V(1).Info("Shutting down server", "server", name)
+		// The context is cancelled by now, so the server is given its own to drain in-flight requests within.
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+		if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil {
+			logger.Error(shutdownErr, "error shutting down server", "server", name)
+		}
+
+		return err
+	})
+}
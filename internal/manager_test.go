@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+)
+
+func TestManager_start(t *testing.T) {
+	t.Parallel()
+	errRunnable := errors.New("failed")
+	tests := []struct {
+		name     string
+		fail     bool
+		expected error
+	}{
+		{name: "cancelled"},
+		{name: "runnable failed", fail: true, expected: errRunnable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			mgr := newManager(klog.Background())
+			stopped := make(chan struct{})
+			mgr.add("blocking", runnableFunc(func(ctx context.Context) error {
+				<-ctx.Done()
+				close(stopped)
+
+				return nil
+			}))
+			mgr.add("failing", runnableFunc(func(context.Context) error {
+				if tt.fail {
+					return errRunnable
+				}
+				cancel()
+
+				return nil
+			}))
+			var hooked bool
+			mgr.addShutdownHook(func() error {
+				select {
+				case <-stopped:
+					hooked = true
+				default:
+					t.Error("expected shutdown hooks to run once all runnables stopped")
+				}
+
+				return nil
+			})
+
+			if err := mgr.start(ctx); !errors.Is(err, tt.expected) {
+				t.Errorf("expected error %v, got %v", tt.expected, err)
+			}
+			if !hooked {
+				t.Error("expected shutdown hook to run")
+			}
+		})
+	}
+}
+
+func TestServerRunnable(t *testing.T) {
+	t.Parallel()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		Handler:           http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }),
+		ReadHeaderTimeout: time.Second,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serverRunnable(klog.Background(), "test", server, []net.Listener{listener}, time.Second).start(ctx)
+	}()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+listener.Addr().String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("expected server to serve, got %v", err)
+	}
+	_ = response.Body.Close()
+
+	cancel()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Errorf("expected server to shut down cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected server to shut down")
+	}
+}
+
+func TestManager_leaderElection(t *testing.T) {
+	t.Parallel()
+	kubeClientset := kubefake.NewClientset()
+	newLeaderElectedManager := func(identity string, leading chan<- string) *manager {
+		mgr := newManager(klog.Background())
+		mgr.leaderElection = &leaderElection{
+			lock: &resourcelock.LeaseLock{
+				LeaseMeta:  metav1.ObjectMeta{Namespace: "default", Name: "resource-state-metrics"},
+				Client:     kubeClientset.CoordinationV1(),
+				LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+			},
+			leaseDuration: time.Second,
+			renewDeadline: 500 * time.Millisecond,
+			retryPeriod:   100 * time.Millisecond,
+		}
+		mgr.add("server", runnableFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+
+			return nil
+		}))
+		mgr.addLeaderElected("worker", runnableFunc(func(ctx context.Context) error {
+			leading <- identity
+			<-ctx.Done()
+
+			return nil
+		}))
+
+		return mgr
+	}
+
+	leading := make(chan string, 2)
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstDone := make(chan error, 1)
+	go func() { firstDone <- newLeaderElectedManager("first", leading).start(firstCtx) }()
+	select {
+	case identity := <-leading:
+		if identity != "first" {
+			t.Fatalf("expected first to lead, got %s", identity)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected first to lead")
+	}
+
+	// The second manager only runs its leader-elected runnables once the first stopped, and released the lease.
+	secondCtx, cancelSecond := context.WithCancel(context.Background())
+	defer cancelSecond()
+	secondDone := make(chan error, 1)
+	go func() { secondDone <- newLeaderElectedManager("second", leading).start(secondCtx) }()
+	select {
+	case identity := <-leading:
+		t.Fatalf("expected no other leader while first leads, got %s", identity)
+	case <-time.After(300 * time.Millisecond):
+	}
+	cancelFirst()
+	if err := <-firstDone; err != nil {
+		t.Errorf("expected first to stop cleanly, got %v", err)
+	}
+	select {
+	case identity := <-leading:
+		if identity != "second" {
+			t.Fatalf("expected second to lead, got %s", identity)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected second to lead once first released the lease")
+	}
+	cancelSecond()
+	if err := <-secondDone; err != nil {
+		t.Errorf("expected second to stop cleanly, got %v", err)
+	}
+}
@@ -17,9 +17,29 @@ limitations under the License.
 package internal
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+)
+
+// ExpositionMode represents how samples are rendered into the exposition.
+type ExpositionMode string
+
+const (
+	// ExpositionModeFast renders samples by hand, straight into the exposition.
+	ExpositionModeFast ExpositionMode = "fast"
+	// ExpositionModeStrict renders samples through the Prometheus client's data model and text encoder, guaranteeing
+	// escaping, float formatting, and label ordering, at the cost of the intermediate allocations.
+	ExpositionModeStrict ExpositionMode = "strict"
 )
 
 // MetricType represents a single time series.
@@ -28,15 +48,79 @@ type MetricType struct {
 	LabelValues []string     `yaml:"labelValues"`
 	Value       string       `yaml:"value"`
 	Resolver    ResolverType `yaml:"resolver,omitempty"`
+	// Histogram configures the buckets of metrics in histogram families.
+	Histogram *HistogramType `yaml:"histogram,omitempty"`
+	// ValueMap, if set, maps the resolved values to the ones exposed, e.g., {"True": 1, "False": 0, "Unknown": -1}, so
+	// enumerated string fields may be exposed as numbers. Values it does not map produce no samples.
+	ValueMap map[string]float64 `yaml:"valueMap,omitempty"`
+	// Regex, if set, extracts labels, and optionally the value, off a resolved field.
+	Regex *RegexType `yaml:"regex,omitempty"`
+	// EachMap, if set, exposes a sample for each entry of a map field, in place of the metric's value.
+	EachMap *EachMapType `yaml:"eachMap,omitempty"`
+	// Expand, if set, exposes a sample for each element of the nested arrays at its levels, e.g., status.nodePools,
+	// and then machines, labeled by each level, with the value resolved against the innermost element.
+	Expand []*ExpandLevelType `yaml:"expand,omitempty"`
+	// Exemplar, if set, attaches an exemplar, e.g., a trace ID off an annotation, to the metric's samples.
+	Exemplar *ExemplarType `yaml:"exemplar,omitempty"`
+
+	// boundResolver is the resolver the metric is resolved by, as bound on its family's compilation.
+	boundResolver resolver.Resolver
 }
 
-func writeMetricTo(writer *strings.Builder, g, v, k, resolvedValue string, resolvedLabelKeys, resolvedLabelValues []string) error {
+// mapValue returns the value the metric's value map maps the given resolved value to, if any.
+func (m *MetricType) mapValue(resolvedValue string) (string, error) {
+	if m.ValueMap == nil {
+		return resolvedValue, nil
+	}
+	mapped, ok := m.ValueMap[resolvedValue]
+	if !ok {
+		return "", fmt.Errorf("error mapping value %q: not in the value map", resolvedValue)
+	}
+
+	return strconv.FormatFloat(mapped, 'g', -1, 64), nil
+}
+
+func writeMetricTo(writer *strings.Builder, g, v, k string, value float64, resolvedLabelKeys, resolvedLabelValues []string, fixedPoint bool) error {
+	if err := validateLabelLengths(resolvedLabelKeys, resolvedLabelValues); err != nil {
+		return err
+	}
 	resolvedLabelKeys, resolvedLabelValues = appendGVKLabels(resolvedLabelKeys, resolvedLabelValues, g, v, k)
 	if err := writeLabels(writer, resolvedLabelKeys, resolvedLabelValues); err != nil {
 		return err
 	}
 
-	return writeValue(writer, resolvedValue)
+	return writeValue(writer, value, fixedPoint)
+}
+
+// writeStrictMetricTo writes the given series, much like writeMetricTo, albeit through the Prometheus client's data model
+// and text encoder, which validate the labels, and take care of escaping, float formatting, and label ordering.
+func writeStrictMetricTo(writer *strings.Builder, name, g, v, k string, value float64, resolvedLabelKeys, resolvedLabelValues []string) error {
+	if err := validateLabelLengths(resolvedLabelKeys, resolvedLabelValues); err != nil {
+		return err
+	}
+	resolvedLabelKeys, resolvedLabelValues = appendGVKLabels(resolvedLabelKeys, resolvedLabelValues, g, v, k)
+	metric, err := prometheus.NewConstMetric(prometheus.NewDesc(name, "", resolvedLabelKeys, nil), prometheus.UntypedValue, value, resolvedLabelValues...)
+	if err != nil {
+		return fmt.Errorf("error building metric %q: %w", name, err)
+	}
+	dtoMetric := &dto.Metric{}
+	if err = metric.Write(dtoMetric); err != nil {
+		return fmt.Errorf("error building metric %q: %w", name, err)
+	}
+	buffer := &bytes.Buffer{}
+	n, err := expfmt.MetricFamilyToText(buffer, &dto.MetricFamily{
+		Name:   &name,
+		Type:   dto.MetricType_UNTYPED.Enum(),
+		Metric: []*dto.Metric{dtoMetric},
+	})
+	if err != nil {
+		return fmt.Errorf("error writing metric after %d bytes: %w", n, err)
+	}
+	// Headers are written once per family, so only the series is kept.
+	_, series, _ := strings.Cut(buffer.String(), "\n")
+	writer.WriteString(series)
+
+	return nil
 }
 
 func validateLabelLengths(keys, values []string) error {
@@ -50,6 +134,21 @@ func validateLabelLengths(keys, values []string) error {
 	return nil
 }
 
+// validateLabelKeys checks that the given (sanitized) label keys are valid label names, distinct from one another, and
+// from the group, version, and kind labels appended to them.
+func validateLabelKeys(keys []string) error {
+	for i, key := range keys {
+		if !model.LabelName(key).IsValidLegacy() {
+			return fmt.Errorf("label key %q is not a valid label name", key)
+		}
+		if key == "group" || key == "version" || key == "kind" || slices.Contains(keys[:i], key) {
+			return fmt.Errorf("duplicate label key %q", key)
+		}
+	}
+
+	return nil
+}
+
 func appendGVKLabels(keys, values []string, g, v, k string) ([]string, []string) {
 	keys = append(keys, "group", "version", "kind")
 	values = append(values, g, v, k)
@@ -79,13 +178,9 @@ func writeLabels(writer *strings.Builder, keys, values []string) error {
 	return nil
 }
 
-func writeValue(writer *strings.Builder, value string) error {
+func writeValue(writer *strings.Builder, value float64, fixedPoint bool) error {
 	writer.WriteByte(' ')
-	floatVal, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return fmt.Errorf("error parsing metric value %q as float64: %w", value, err)
-	}
-	n, err := fmt.Fprintf(writer, "%f", floatVal)
+	n, err := writer.WriteString(formatValue(value, fixedPoint))
 	if err != nil {
 		return fmt.Errorf("error writing (float64) metric value after %d bytes: %w", n, err)
 	}
@@ -93,3 +188,33 @@ func writeValue(writer *strings.Builder, value string) error {
 
 	return nil
 }
+
+// parseValue parses the given resolved value as a sample value. Besides numbers, in decimal or scientific notation, and
+// the special values NaN, +Inf, and -Inf, booleans are accepted as 1 and 0, and numbers too large for a float64 are
+// clamped to +Inf or -Inf.
+func parseValue(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "true":
+		return 1, nil
+	case "false":
+		return 0, nil
+	}
+	parsedFloat, err := strconv.ParseFloat(value, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("error parsing metric value %q as float64: %w", value, err)
+	}
+
+	return parsedFloat, nil
+}
+
+// formatValue formats the given sample value in its shortest representation that round-trips, as kube-state-metrics
+// and the Prometheus client do (e.g., 1 and 0.5), or, if fixedPoint is set, in fixed-point notation with six decimals
+// (e.g., 1.000000 and 0.500000), as done before.
+func formatValue(value float64, fixedPoint bool) string {
+	if fixedPoint {
+		return strconv.FormatFloat(value, 'f', 6, 64)
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"math"
 	"strings"
 	"testing"
 
@@ -20,19 +21,19 @@ func TestWriteMetricTo(t *testing.T) {
 			name:                "empty label keys and values",
 			resolvedLabelKeys:   []string{},
 			resolvedLabelValues: []string{},
-			expected:            "{group=\"group\",version=\"version\",kind=\"kind\"} 42.000000\n",
+			expected:            "{group=\"group\",version=\"version\",kind=\"kind\"} 42\n",
 		},
 		{
 			name:                "multiple label keys and values",
 			resolvedLabelKeys:   []string{"key1", "key2"},
 			resolvedLabelValues: []string{"value1", "value2"},
-			expected:            "{key1=\"value1\",key2=\"value2\",group=\"group\",version=\"version\",kind=\"kind\"} 42.000000\n",
+			expected:            "{key1=\"value1\",key2=\"value2\",group=\"group\",version=\"version\",kind=\"kind\"} 42\n",
 		},
 		{
 			name:                "escaped label values",
 			resolvedLabelKeys:   []string{"key1"},
 			resolvedLabelValues: []string{"value1\nvalue2"},
-			expected:            "{key1=\"value1\\nvalue2\",group=\"group\",version=\"version\",kind=\"kind\"} 42.000000\n",
+			expected:            "{key1=\"value1\\nvalue2\",group=\"group\",version=\"version\",kind=\"kind\"} 42\n",
 		},
 		{
 			name:                "len(keys) < len(values)",
@@ -54,7 +55,7 @@ func TestWriteMetricTo(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			var writer strings.Builder
-			if err := writeMetricTo(&writer, "group", "version", "kind", "42", tt.resolvedLabelKeys, tt.resolvedLabelValues); err != nil && !tt.wantErr {
+			if err := writeMetricTo(&writer, "group", "version", "kind", 42, tt.resolvedLabelKeys, tt.resolvedLabelValues, false); err != nil && !tt.wantErr {
 				t.Fatal(err)
 			}
 			if got := writer.String(); got != tt.expected {
@@ -63,3 +64,155 @@ func TestWriteMetricTo(t *testing.T) {
 		})
 	}
 }
+
+func TestParseValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value    string
+		expected float64
+		wantErr  bool
+	}{
+		{value: "42", expected: 42},
+		{value: " -1.5 ", expected: -1.5},
+		{value: "1.5e-3", expected: 0.0015},
+		{value: "1e400", expected: math.Inf(1)},
+		{value: "-1e400", expected: math.Inf(-1)},
+		{value: "+Inf", expected: math.Inf(1)},
+		{value: "-Inf", expected: math.Inf(-1)},
+		{value: "NaN", expected: math.NaN()},
+		{value: "true", expected: 1},
+		{value: "False", expected: 0},
+		{value: "foo", wantErr: true},
+		{value: "<nil>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseValue(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseValue(%q): expected error: %t, got %v", tt.value, tt.wantErr, err)
+
+			continue
+		}
+		if got != tt.expected && !(math.IsNaN(got) && math.IsNaN(tt.expected)) {
+			t.Errorf("parseValue(%q): expected %v, got %v", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestMetricType_mapValue(t *testing.T) {
+	t.Parallel()
+	metric := &MetricType{ValueMap: map[string]float64{"True": 1, "False": 0, "Unknown": -1}}
+	tests := []struct {
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{value: "True", expected: "1"},
+		{value: "Unknown", expected: "-1"},
+		{value: "true", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := metric.mapValue(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("mapValue(%q): expected error: %t, got %v", tt.value, tt.wantErr, err)
+
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("mapValue(%q): expected %q, got %q", tt.value, tt.expected, got)
+		}
+	}
+	if got, err := (&MetricType{}).mapValue("Running"); err != nil || got != "Running" {
+		t.Errorf("expected values to pass through without a value map, got %q, %v", got, err)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value      float64
+		fixedPoint bool
+		expected   string
+	}{
+		{value: 1, expected: "1"},
+		{value: 0, expected: "0"},
+		{value: -1.5, expected: "-1.5"},
+		{value: 0.000000001, expected: "1e-09"},
+		{value: 1234567, expected: "1.234567e+06"},
+		{value: math.Inf(1), expected: "+Inf"},
+		{value: math.Inf(-1), expected: "-Inf"},
+		{value: math.NaN(), expected: "NaN"},
+		{value: 1, fixedPoint: true, expected: "1.000000"},
+		{value: -1.5, fixedPoint: true, expected: "-1.500000"},
+		{value: 0.000000001, fixedPoint: true, expected: "0.000000"},
+	}
+
+	for _, tt := range tests {
+		if got := formatValue(tt.value, tt.fixedPoint); got != tt.expected {
+			t.Errorf("formatValue(%v, %t): expected %q, got %q", tt.value, tt.fixedPoint, tt.expected, got)
+		}
+	}
+}
+
+func TestWriteStrictMetricTo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name                string
+		resolvedLabelKeys   []string
+		resolvedLabelValues []string
+		resolvedValue       float64
+		expected            string
+		wantErr             bool
+	}{
+		{
+			name:                "labels are sorted, and the value is formatted as per the text format",
+			resolvedLabelKeys:   []string{"key2", "key1"},
+			resolvedLabelValues: []string{"value2", "value1"},
+			resolvedValue:       42,
+			expected:            "foo{group=\"group\",key1=\"value1\",key2=\"value2\",kind=\"kind\",version=\"version\"} 42\n",
+		},
+		{
+			name:                "escaped label values",
+			resolvedLabelKeys:   []string{"key1"},
+			resolvedLabelValues: []string{"value1\n\"value2\"\\"},
+			resolvedValue:       0.000000001,
+			expected:            "foo{group=\"group\",key1=\"value1\\n\\\"value2\\\"\\\\\",kind=\"kind\",version=\"version\"} 1e-09\n",
+		},
+		{
+			name:                "duplicate label keys",
+			resolvedLabelKeys:   []string{"group"},
+			resolvedLabelValues: []string{"value1"},
+			resolvedValue:       42,
+			wantErr:             true,
+		},
+		{
+			name:                "reserved label keys",
+			resolvedLabelKeys:   []string{"__key1"},
+			resolvedLabelValues: []string{"value1"},
+			resolvedValue:       42,
+			wantErr:             true,
+		},
+		{
+			name:                "len(keys) != len(values)",
+			resolvedLabelKeys:   []string{"key1", "key2"},
+			resolvedLabelValues: []string{"value1"},
+			resolvedValue:       42,
+			wantErr:             true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var writer strings.Builder
+			err := writeStrictMetricTo(&writer, "foo", "group", "version", "kind", tt.resolvedValue, tt.resolvedLabelKeys, tt.resolvedLabelValues)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %t, got %v", tt.wantErr, err)
+			}
+			if got := writer.String(); got != tt.expected {
+				t.Errorf("%s", cmp.Diff(got, tt.expected))
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// nativeResourcesWildcard allow-lists all native resources.
+const nativeResourcesWildcard = "*"
+
+// nativeQualifiedGroups are the built-in API groups qualified by a domain. Groups without one (e.g., core, or apps)
+// are always built-in, as CRD groups must contain a dot.
+var nativeQualifiedGroups = sets.New(
+	"admissionregistration.k8s.io",
+	"apiextensions.k8s.io",
+	"apiregistration.k8s.io",
+	"authentication.k8s.io",
+	"authorization.k8s.io",
+	"certificates.k8s.io",
+	"coordination.k8s.io",
+	"discovery.k8s.io",
+	"events.k8s.io",
+	"flowcontrol.apiserver.k8s.io",
+	"internal.apiserver.k8s.io",
+	"networking.k8s.io",
+	"node.k8s.io",
+	"rbac.authorization.k8s.io",
+	"resource.k8s.io",
+	"scheduling.k8s.io",
+	"storage.k8s.io",
+	"storagemigration.k8s.io",
+)
+
+// isNativeGroup reports whether the given API group is served by Kubernetes itself (e.g., core, apps, or
+// networking.k8s.io), as opposed to through CRDs.
+func isNativeGroup(group string) bool {
+	return !strings.Contains(group, ".") || nativeQualifiedGroups.Has(group)
+}
+
+// nativeResource returns the group-qualified resource the given store targets, if it is a native one.
+func nativeResource(store *StoreType) (string, bool) {
+	if store.Selectors.CRD != "" || !isNativeGroup(store.Group) {
+		return "", false
+	}
+
+	return schema.GroupResource{Group: store.Group, Resource: store.Resource}.String(), true
+}
+
+// checkNativeResources returns an error for the first store targeting a native resource missing from the given
+// allow-list, whose entries are (comma-separated) group-qualified resources, e.g., "pods" or "deployments.apps".
+func checkNativeResources(c configuration, allowed *[]string) error {
+	allowList := sets.New[string]()
+	if allowed != nil {
+		for _, entry := range *allowed {
+			allowList.Insert(strings.Split(entry, ",")...)
+		}
+	}
+	for i, store := range c.Stores {
+		resource, ok := nativeResource(store)
+		if !ok || allowList.Has(nativeResourcesWildcard) || allowList.Has(resource) {
+			continue
+		}
+
+		return fmt.Errorf("stores[%d] targets the native resource %q, which is not allow-listed (see --%s)", i, resource, nativeResourcesFlagName)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckNativeResources(t *testing.T) {
+	t.Parallel()
+	newConfiguration := func(groupResources ...[2]string) configuration {
+		c := configuration{}
+		for _, groupResource := range groupResources {
+			c.Stores = append(c.Stores, &StoreType{Group: groupResource[0], Resource: groupResource[1]})
+		}
+
+		return c
+	}
+
+	tests := []struct {
+		name          string
+		configuration configuration
+		allowed       []string
+		expectedError string
+	}{
+		{
+			name:          "custom resources are always allowed",
+			configuration: newConfiguration([2]string{"contoso.com", "bars"}, [2]string{"samplecontroller.k8s.io", "foos"}),
+		},
+		{
+			name:          "native resources are denied by default",
+			configuration: newConfiguration([2]string{"contoso.com", "bars"}, [2]string{"", "pods"}),
+			expectedError: `stores[1] targets the native resource "pods"`,
+		},
+		{
+			name:          "allow-listed native resources",
+			configuration: newConfiguration([2]string{"", "pods"}, [2]string{"apps", "deployments"}, [2]string{"networking.k8s.io", "ingresses"}),
+			allowed:       []string{"pods,deployments.apps", "ingresses.networking.k8s.io"},
+		},
+		{
+			name:          "native resources missing from the allow-list",
+			configuration: newConfiguration([2]string{"", "pods"}, [2]string{"apps", "deployments"}),
+			allowed:       []string{"pods"},
+			expectedError: `stores[1] targets the native resource "deployments.apps"`,
+		},
+		{
+			name:          "wildcard",
+			configuration: newConfiguration([2]string{"", "nodes"}, [2]string{"apps", "daemonsets"}),
+			allowed:       []string{nativeResourcesWildcard},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := checkNativeResources(tt.configuration, &tt.allowed)
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+// schemaWarnings returns warnings for the paths the given resource's unstructured queries reference, that the OpenAPI
+// schemas of their stores' targeted CRDs do not define, or define as composite values where scalars are expected.
+// Stores targeting native resources, or selecting their targets by CRD labels, and CEL expressions, are not validated.
+func (c *Controller) schemaWarnings(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor) []string {
+	if c.dynamicClientset == nil || c.crds == nil || !c.crds.HasSynced() {
+		return nil
+	}
+	configurerInstance := newConfigurer(c.dynamicClientset, resource, 0, 0, nil)
+	if err := configurerInstance.parseMonitor(); err != nil {
+		return nil
+	}
+
+	return configurationSchemaWarnings(ctx, c.crds.GetStore(), configurerInstance.configuration)
+}
+
+// configurationSchemaWarnings returns warnings for the paths the given configuration's unstructured queries reference,
+// validated against the schemas of the CRDs held in the given store.
+func configurationSchemaWarnings(ctx context.Context, crds cache.Store, cfg configuration) []string {
+	logger := klog.FromContext(ctx)
+	var warnings []string
+	for i, store := range cfg.Stores {
+		if store.Selectors.CRD != "" || isNativeGroup(store.Group) {
+			continue
+		}
+		storeSchema, err := crdSchema(crds, store)
+		if err != nil {
+			logger.V(1).Info("Not validating queries against the target's schema", "gvr", store.gvr().String(), "reason", err.Error())
+
+			continue
+		}
+		warn := func(field, query string, scalar bool) {
+			if query == "" || isLabelTemplate(query) {
+				return
+			}
+			// Numeric queries resolve to themselves, and are commonly used as constant values.
+			if _, err := strconv.ParseFloat(query, 64); err == nil {
+				return
+			}
+			if err := validateQuerySchema(storeSchema, query, scalar); err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s: %q: %v", field, query, err))
+			}
+		}
+		for j, family := range store.Families {
+			for k, metric := range family.Metrics {
+				if metric == nil || effectiveResolver(store, family, metric) != ResolverTypeUnstructured {
+					continue
+				}
+				field := fmt.Sprintf("stores[%d].families[%d].metrics[%d]", i, j, k)
+				for l, query := range metric.LabelValues {
+					warn(fmt.Sprintf("%s.labelValues[%d]", field, l), query, true)
+				}
+				switch {
+				case len(metric.Expand) > 0:
+					// The values of expanded metrics are relative to the innermost level's elements.
+					warn(field+".expand[0].path", metric.Expand[0].Path+"[*]", false)
+				case metric.EachMap != nil:
+					warn(field+".eachMap.path", metric.EachMap.Path, false)
+				default:
+					warn(field+".value", metric.Value, true)
+				}
+			}
+		}
+	}
+
+	return warnings
+}
+
+// crdSchema returns the OpenAPI schema of the version of the CRD the given store targets, as held in the given store of
+// CRDs.
+func crdSchema(crds cache.Store, store *StoreType) (*apiextensionsv1.JSONSchemaProps, error) {
+	name := store.gvr().GroupResource().String()
+	item, exists, err := crds.GetByKey(name)
+	if err != nil {
+		return nil, fmt.Errorf("error getting CRD: %w", err)
+	}
+	object, ok := item.(*unstructured.Unstructured)
+	if !exists || !ok {
+		return nil, fmt.Errorf("CRD %q not found", name)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, crd); err != nil {
+		return nil, fmt.Errorf("error decoding CRD: %w", err)
+	}
+	for _, version := range crd.Spec.Versions {
+		if version.Name == store.Version && version.Schema != nil && version.Schema.OpenAPIV3Schema != nil {
+			return version.Schema.OpenAPIV3Schema, nil
+		}
+	}
+
+	return nil, fmt.Errorf("CRD defines no schema for version %q", store.Version)
+}
+
+// validateQuerySchema reports whether the given schema defines the field the given unstructured query references, and,
+// if a scalar is expected, whether the field is one. Fields the schema does not constrain, e.g., ones under fields
+// preserving unknown fields, or metadata, are not validated.
+func validateQuerySchema(props *apiextensionsv1.JSONSchemaProps, query string, scalar bool) error {
+	for i, path := range strings.Split(query, "[*]") {
+		if i > 0 {
+			if props.Type != "array" {
+				return errors.New("wildcard does not expand an array")
+			}
+			if props.Items == nil || props.Items.Schema == nil {
+				return nil
+			}
+			props = props.Items.Schema
+		}
+		for _, segment := range strings.Split(strings.Trim(path, "."), ".") {
+			if segment == "" {
+				continue
+			}
+			if (i == 0 && props.Type == "object" && segment == "metadata") || ptr.Deref(props.XPreserveUnknownFields, false) || props.XEmbeddedResource {
+				return nil
+			}
+			child, ok := props.Properties[segment]
+			switch {
+			case ok:
+				props = &child
+			case props.AdditionalProperties != nil && props.AdditionalProperties.Schema != nil:
+				props = props.AdditionalProperties.Schema
+			case props.AdditionalProperties != nil && props.AdditionalProperties.Allows:
+				return nil
+			default:
+				return fmt.Errorf("field %q is not defined in the schema", segment)
+			}
+		}
+	}
+	if scalar && !props.XIntOrString && (props.Type == "object" || props.Type == "array") {
+		return fmt.Errorf("field is a composite (%s) value", props.Type)
+	}
+
+	return nil
+}
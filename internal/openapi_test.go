@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+)
+
+func TestValidateQuerySchema(t *testing.T) {
+	t.Parallel()
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"metadata": {Type: "object"},
+			"spec": {
+				Type: "object",
+				Properties: map[string]apiextensionsv1.JSONSchemaProps{
+					"replicas": {Type: "integer"},
+					"containers": {
+						Type: "array",
+						Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: &apiextensionsv1.JSONSchemaProps{
+							Type:       "object",
+							Properties: map[string]apiextensionsv1.JSONSchemaProps{"name": {Type: "string"}},
+						}},
+					},
+					"selector": {
+						Type:                 "object",
+						AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Schema: &apiextensionsv1.JSONSchemaProps{Type: "string"}},
+					},
+					"extra": {Type: "object", XPreserveUnknownFields: ptr.To(true)},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name    string
+		query   string
+		scalar  bool
+		wantErr bool
+	}{
+		{name: "defined scalar", query: "spec.replicas", scalar: true},
+		{name: "unknown field", query: "spec.replica", scalar: true, wantErr: true},
+		{name: "composite", query: "spec.containers", scalar: true, wantErr: true},
+		{name: "composite map", query: "spec.selector", scalar: false},
+		{name: "expanded", query: "spec.containers[*].name", scalar: true},
+		{name: "expanded unknown field", query: "spec.containers[*].image", scalar: true, wantErr: true},
+		{name: "wildcard over a non-array", query: "spec.replicas[*]", scalar: false, wantErr: true},
+		{name: "additional properties", query: "spec.selector.app", scalar: true},
+		{name: "metadata", query: "metadata.labels.app", scalar: true},
+		{name: "preserved unknown fields", query: "spec.extra.foo.bar", scalar: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateQuerySchema(schema, tt.query, tt.scalar)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCRDSchema(t *testing.T) {
+	t.Parallel()
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "bars.contoso.com"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "contoso.com",
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha1", Schema: &apiextensionsv1.CustomResourceValidation{OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{Type: "object"}}},
+				{Name: "v1alpha2"},
+			},
+		},
+	}
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(crd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crds := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	if err = crds.Add(&unstructured.Unstructured{Object: object}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		store   *StoreType
+		wantErr bool
+	}{
+		{name: "cached", store: &StoreType{Group: "contoso.com", Version: "v1alpha1", Resource: "bars"}},
+		{name: "version without schema", store: &StoreType{Group: "contoso.com", Version: "v1alpha2", Resource: "bars"}, wantErr: true},
+		{name: "not cached", store: &StoreType{Group: "contoso.com", Version: "v1alpha1", Resource: "foos"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := crdSchema(crds, tt.store)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %t, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && got.Type != "object" {
+				t.Errorf("expected the cached version's schema, got %v", got)
+			}
+		})
+	}
+}
@@ -17,46 +17,169 @@ limitations under the License.
 package internal
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/prometheus/common/model"
+	"github.com/rexagod/resource-state-metrics/internal/version"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 )
 
 const (
-	autoGOMAXPROCSFlagName  = "auto-gomaxprocs"
-	celCostLimitFlagName    = "cel-cost-limit"
-	celTimeoutFlagName      = "cel-timeout-seconds"
-	kubeconfigFlagName      = "kubeconfig"
-	mainHostFlagName        = "main-host"
-	mainPortFlagName        = "main-port"
-	masterURLFlagName       = "master"
-	ratioGOMEMLIMITFlagName = "ratio-gomemlimit"
-	selfHostFlagName        = "self-host"
-	selfPortFlagName        = "self-port"
-	versionFlagName         = "version"
-	workersFlagName         = "workers"
+	accessLogFlagName                     = "access-log"
+	autoConditionsFlagName                = "auto-conditions"
+	autoConditionsLabelSelectorFlagName   = "auto-conditions-label-selector"
+	autoConditionsNamespaceFlagName       = "auto-conditions-namespace"
+	autoGOMAXPROCSFlagName                = "auto-gomaxprocs"
+	celCostLimitFlagName                  = "cel-cost-limit"
+	celEnvironmentFlagName                = "cel-environment"
+	celTimeoutFlagName                    = "cel-timeout-seconds"
+	clusterFlagName                       = "cluster"
+	conditionHoldFlagName                 = "condition-hold-seconds"
+	configFileFlagName                    = "config-file"
+	credentialsAllowedServersFlagName     = "credentials-allowed-servers"
+	customMetricsPortFlagName             = "custom-metrics-port"
+	customMetricsTLSCertFlagName          = "custom-metrics-tls-cert-file"
+	customMetricsTLSKeyFlagName           = "custom-metrics-tls-key-file"
+	defaultingWebhookFamilyPrefixFlagName = "defaulting-webhook-family-prefix"
+	defaultingWebhookLabelsFlagName       = "defaulting-webhook-labels"
+	defaultingWebhookPortFlagName         = "defaulting-webhook-port"
+	defaultingWebhookTLSCertFlagName      = "defaulting-webhook-tls-cert-file"
+	defaultingWebhookTLSKeyFlagName       = "defaulting-webhook-tls-key-file"
+	eventNamespaceFlagName                = "event-namespace"
+	expositionCheckFlagName               = "exposition-check-interval-seconds"
+	expositionModeFlagName                = "exposition-mode"
+	fixedPointValuesFlagName              = "fixed-point-values"
+	installCRDsFlagName                   = "install-crds"
+	ksmCollisionPrefixFlagName            = "ksm-collision-prefix"
+	ksmCRSConfigFlagName                  = "ksm-custom-resource-state-config"
+	kubeconfigFlagName                    = "kubeconfig"
+	leaderElectionFlagName                = "leader-election"
+	leaderElectionNamespaceFlagName       = "leader-election-namespace"
+	listPageSizeFlagName                  = "list-page-size"
+	mainHostFlagName                      = "main-host"
+	mainPortFlagName                      = "main-port"
+	masterURLFlagName                     = "master"
+	memoryBudgetFlagName                  = "memory-budget-bytes"
+	monitorsQuotaFlagName                 = "max-monitors-per-namespace"
+	namespacedStoresFlagName              = "namespaced-stores"
+	nativeResourcesFlagName               = "native-resources"
+	ratioGOMEMLIMITFlagName               = "ratio-gomemlimit"
+	readOnlyFlagName                      = "read-only"
+	recordEventsFlagName                  = "record-events"
+	resolutionLogIntervalFlagName         = "resolution-log-interval-seconds"
+	scrapeAllowedCIDRsFlagName            = "scrape-allowed-cidrs"
+	scrapeBearerTokenFileFlagName         = "scrape-bearer-token-file"
+	selfHostFlagName                      = "self-host"
+	selfPortFlagName                      = "self-port"
+	serviceMonitorLabelsFlagName          = "service-monitor-labels"
+	serviceMonitorServiceFlagName         = "service-monitor-service"
+	slowReconcileThresholdFlagName        = "slow-reconcile-threshold-seconds"
+	slowScrapeThresholdFlagName           = "slow-scrape-threshold-seconds"
+	snapshotPathFlagName                  = "snapshot-path"
+	statusLastErrorsFlagName              = "status-last-errors"
+	storageFlagName                       = "storage"
+	storageMaxObjectsFlagName             = "storage-max-objects"
+	storagePathFlagName                   = "storage-path"
+	storesQuotaFlagName                   = "max-stores-per-namespace"
+	thresholdCheckFlagName                = "threshold-check-interval-seconds"
+	thresholdWebhooksFlagName             = "threshold-webhooks"
+	versionFlagName                       = "version"
+	warmUpMaxWaitFlagName                 = "warm-up-max-wait-seconds"
+	watchListFlagName                     = "watch-list"
+	watchNamespaceFlagName                = "watch-namespace"
+	workersFlagName                       = "workers"
 )
 
+// maxCELTimeout bounds the time CEL expressions may be evaluated for, whether set through the flags, or per store.
+const maxCELTimeout = 300 * time.Second
+
 // Options represents the command-line Options.
 type Options struct {
-	AutoGOMAXPROCS  *bool
-	CELCostLimit    *uint64
-	CELTimeout      *int
-	Kubeconfig      *string
-	MainHost        *string
-	MainPort        *int
-	MasterURL       *string
-	RatioGOMEMLIMIT *float64
-	SelfHost        *string
-	SelfPort        *int
-	Version         *bool
-	Workers         *int
+	AccessLog                     *bool
+	AutoConditions                *bool
+	AutoConditionsLabelSelector   *string
+	AutoConditionsNamespace       *string
+	AutoGOMAXPROCS                *bool
+	CELCostLimit                  *uint64
+	CELEnvironment                *[]string
+	CELTimeout                    *int
+	Clusters                      *[]string
+	ConditionHold                 *int
+	ConfigFile                    *string
+	CredentialsAllowedServers     *[]string
+	CustomMetricsPort             *int
+	CustomMetricsTLSCert          *string
+	CustomMetricsTLSKey           *string
+	DefaultingWebhookFamilyPrefix *string
+	DefaultingWebhookLabels       *string
+	DefaultingWebhookPort         *int
+	DefaultingWebhookTLSCert      *string
+	DefaultingWebhookTLSKey       *string
+	EventNamespace                *string
+	ExpositionCheck               *int
+	ExpositionMode                *string
+	FixedPointValues              *bool
+	InstallCRDs                   *bool
+	KSMCollisionPrefix            *string
+	KSMCRSConfig                  *string
+	Kubeconfig                    *string
+	LeaderElection                *bool
+	LeaderElectionNamespace       *string
+	ListPageSize                  *int64
+	MainHosts                     *[]string
+	MainPort                      *int
+	MasterURL                     *string
+	MemoryBudget                  *int64
+	MonitorsQuota                 *int
+	NamespacedStores              *bool
+	NativeResources               *[]string
+	RatioGOMEMLIMIT               *float64
+	ReadOnly                      *bool
+	RecordEvents                  *bool
+	ResolutionLogInterval         *int
+	ScrapeAllowedCIDRs            *[]string
+	ScrapeBearerTokenFile         *string
+	SelfHosts                     *[]string
+	SelfPort                      *int
+	ServiceMonitorLabels          *string
+	ServiceMonitorService         *string
+	SlowReconcileThreshold        *float64
+	SlowScrapeThreshold           *float64
+	SnapshotPath                  *string
+	StatusLastErrors              *int
+	Storage                       *string
+	StorageMaxObjects             *int
+	StoragePath                   *string
+	StoresQuota                   *int
+	ThresholdCheck                *int
+	ThresholdWebhooks             *bool
+	Version                       *bool
+	WarmUpMaxWait                 *int
+	WatchList                     *bool
+	WatchNamespaces               *[]string
+	Workers                       *int
 
 	logger klog.Logger
+	// flags holds the flags the options were read from.
+	flags *flag.FlagSet
+	// sources holds the source each option was set from, by its name, unless defaulted.
+	sources map[string]string
 }
 
 // NewOptions returns a new Options.
@@ -66,55 +189,414 @@ func NewOptions(logger klog.Logger) *Options {
 	}
 }
 
-// Read reads the command-line flags and applies overrides, if any.
-func (o *Options) Read() {
-	o.AutoGOMAXPROCS = flag.Bool(autoGOMAXPROCSFlagName, true, "Automatically set GOMAXPROCS to match CPU quota.")
-	//nolint:lll
-	o.CELCostLimit = flag.Uint64(celCostLimitFlagName, 10e5, "Maximum cost budget for CEL expression evaluation. CEL cost represents computational complexity: traversing an object field costs 1, invoking a function varies by complexity. This limit prevents runaway expressions from consuming excessive resources. Typical queries cost 100-10000; increase if legitimate queries hit the limit.")
-	//nolint:lll
-	o.CELTimeout = flag.Int(celTimeoutFlagName, 5, "Maximum time in seconds for CEL expression evaluation. This timeout enforces a wall-clock limit on query execution to prevent slow expressions from blocking metric generation. Increase if complex legitimate queries timeout.")
-	o.Kubeconfig = flag.String(kubeconfigFlagName, os.Getenv("KUBECONFIG"), "Path to a kubeconfig. Only required if out-of-cluster.")
-	o.MainHost = flag.String(mainHostFlagName, "::", "Host to expose main metrics on.")
-	o.MainPort = flag.Int(mainPortFlagName, 9999, "Port to expose main metrics on.")
-	o.MasterURL = flag.String(masterURLFlagName, os.Getenv("KUBERNETES_MASTER"), "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
-	o.RatioGOMEMLIMIT = flag.Float64(ratioGOMEMLIMITFlagName, 0.9, "GOMEMLIMIT to memory quota ratio.")
-	o.SelfHost = flag.String(selfHostFlagName, "::", "Host to expose self (telemetry) metrics on.")
-	o.SelfPort = flag.Int(selfPortFlagName, 9998, "Port to expose self (telemetry) metrics on.")
-	o.Version = flag.Bool(versionFlagName, false, "Print version information and quit")
-	o.Workers = flag.Int(workersFlagName, 2, "Number of workers processing managed resources in the workqueue.")
+// Read reads the command-line flags and applies overrides, if any, through the environment, and the config file, in
+// that order of precedence, returning the errors of all invalid options, if any.
+func (o *Options) Read() error {
+	o.register(flag.CommandLine)
 	flag.Parse()
+	flag.Visit(func(f *flag.Flag) {
+		o.sources[f.Name] = optionSourceFlag
+	})
 
 	// Respect overrides, this also helps in testing without setting the same defaults in a bunch of places.
+	var errs []error
 	flag.VisitAll(func(f *flag.Flag) {
 		// Don't override flags that have been set. Environment variables do not take precedence over command-line flags.
 		if f.Value.String() != f.DefValue {
-			err := o.validateFlag(f.Name, f.Value.String())
-			if err != nil {
-				panic(fmt.Sprintf("Invalid value for flag %s: %v", f.Name, err))
-			}
-
 			return
 		}
 		name := f.Name
 		overriderForOptionName := `RSM_` + strings.ReplaceAll(strings.ToUpper(name), "-", "_")
 		if value, ok := os.LookupEnv(overriderForOptionName); ok {
 			o.logger.V(1).Info(fmt.Sprintf("Overriding flag %s with %s=%s", name, overriderForOptionName, value))
-			err := flag.Set(name, value)
-			if err != nil {
-				panic(fmt.Sprintf("Failed to set flag %s to %s: %v", name, value, err))
+			if err := flag.Set(name, value); err != nil {
+				errs = append(errs, fmt.Errorf("invalid value %q for %s through %s: %w", value, name, overriderForOptionName, err))
+
+				return
 			}
+			o.sources[name] = optionSourceEnvironment
+		}
+	})
+	if err := o.readConfigFile(flag.CommandLine); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(append(errs, o.validate())...)
+}
+
+// Parse parses the given arguments as the command-line flags, defaulting the ones not given, and applies the config
+// file, if any, but not the environment, e.g., for the controller to be embedded in other binaries.
+func (o *Options) Parse(args []string) error {
+	fs := flag.NewFlagSet(version.ControllerName.String(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	o.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		o.sources[f.Name] = optionSourceFlag
+	})
+	if err := o.readConfigFile(fs); err != nil {
+		return err
+	}
+
+	return o.validate()
+}
+
+// validate returns the errors of all options set to invalid values, if any, so they are reported at once. Options
+// left at their defaults are not validated, as some of them, e.g., the ones of repeatable flags, are unset then.
+func (o *Options) validate() error {
+	var errs []error
+	o.flags.VisitAll(func(f *flag.Flag) {
+		if f.Value.String() == f.DefValue {
+			return
+		}
+		if err := o.validateFlag(f.Name, f.Value.String()); err != nil {
+			errs = append(errs, err)
 		}
 	})
+	if StorageType(*o.Storage) == StorageTypeDisk && *o.StoragePath == "" {
+		errs = append(errs, fmt.Errorf("%s is required for the %q storage", storagePathFlagName, StorageTypeDisk))
+	}
+	// Leader election writes to the cluster's leases, which read-only credentials may not.
+	if *o.LeaderElection && *o.ReadOnly {
+		errs = append(errs, fmt.Errorf("%s cannot be used with %s", leaderElectionFlagName, readOnlyFlagName))
+	}
+
+	return errors.Join(errs...)
+}
+
+// register defines the command-line flags on the given flag set.
+func (o *Options) register(fs *flag.FlagSet) {
+	o.flags = fs
+	o.sources = map[string]string{}
+	o.AccessLog = fs.Bool(accessLogFlagName, false, "Log each request served by the main server, structured.")
+	//nolint:lll
+	o.AutoConditions = fs.Bool(autoConditionsFlagName, false, "Expose the status conditions of the objects of every CRD whose schema defines status.conditions, as kube_customresource_<kind>_status_condition, without any monitor configuring them. The CRDs' objects are exposed under the _auto-conditions key.")
+	o.AutoConditionsLabelSelector = fs.String(autoConditionsLabelSelectorFlagName, "", "Label selector the objects whose status conditions are exposed by --auto-conditions must match. Defaults to all objects.")
+	o.AutoConditionsNamespace = fs.String(autoConditionsNamespaceFlagName, "", "Namespace of the objects whose status conditions are exposed by --auto-conditions. Defaults to all namespaces.")
+	o.AutoGOMAXPROCS = fs.Bool(autoGOMAXPROCSFlagName, true, "Automatically set GOMAXPROCS to match CPU quota.")
+	//nolint:lll
+	o.CELCostLimit = fs.Uint64(celCostLimitFlagName, 10e5, "Maximum cost budget for CEL expression evaluation. CEL cost represents computational complexity: traversing an object field costs 1, invoking a function varies by complexity. This limit prevents runaway expressions from consuming excessive resources. Typical queries cost 100-10000; increase if legitimate queries hit the limit.")
+	o.CELEnvironment = &[]string{}
+	//nolint:lll
+	fs.Var((*stringSliceFlag)(o.CELEnvironment), celEnvironmentFlagName, "Environment variables CEL expressions may read through env, e.g., env.SHARD, as comma-separated names. Can be repeated. Defaults to none, as the environment may hold secrets.")
+	//nolint:lll
+	o.CELTimeout = fs.Int(celTimeoutFlagName, 5, "Maximum time in seconds for CEL expression evaluation. This timeout enforces a wall-clock limit on query execution to prevent slow expressions from blocking metric generation. Increase if complex legitimate queries timeout.")
+	o.Clusters = &[]string{}
+	//nolint:lll
+	fs.Var((*stringSliceFlag)(o.Clusters), clusterFlagName, fmt.Sprintf("Federated clusters to build stores for, as comma-separated <name>=<kubeconfig> pairs, labeling its samples with %s=<name>. An empty kubeconfig stands for the cluster the controller connects to. Can be repeated. Defaults to none, i.e., stores are built for the cluster the controller connects to, and samples are not labeled.", clusterLabelKey))
+	//nolint:lll
+	o.ConditionHold = fs.Int(conditionHoldFlagName, 10, "Minimum time in seconds ResourceMetricsMonitors' Processed condition holds its status for before transitioning to False again, so that transient blips while reconciling do not churn their status. Failures are reported regardless. Set to 0 to disable.")
+	//nolint:lll
+	o.ConfigFile = fs.String(configFileFlagName, "", "Path to a YAML file mapping option names, i.e., the flags' names, to their values, or lists thereof for repeatable flags, e.g., \"main-port: 9999\". Options set through the command-line flags, or the environment, take precedence over the ones in the file.")
+	//nolint:lll
+	o.CredentialsAllowedServers = &[]string{}
+	//nolint:lll
+	fs.Var((*stringSliceFlag)(o.CredentialsAllowedServers), credentialsAllowedServersFlagName, "API servers stores' kubeconfig secrets may connect to, besides the one the controller connects to, as comma-separated URLs, e.g., https://tenant.example.com:6443. Can be repeated. Defaults to none, i.e., kubeconfig secrets may only connect to the controller's own API server, so that tenants cannot have the controller send requests to arbitrary hosts.")
+	//nolint:lll
+	o.CustomMetricsPort = fs.Int(customMetricsPortFlagName, 0, "Port to serve the families opting into it on, over the custom metrics API (custom.metrics.k8s.io/v1beta2), on the main server's hosts, to register as an APIService for HorizontalPodAutoscalers to scale on. Set to 0 to disable.")
+	//nolint:lll
+	o.CustomMetricsTLSCert = fs.String(customMetricsTLSCertFlagName, "", fmt.Sprintf("Path to the TLS certificate to serve the custom metrics API with. Defaults to a self-signed one, generated on startup, if neither this, nor --%s, is set.", customMetricsTLSKeyFlagName))
+	o.CustomMetricsTLSKey = fs.String(customMetricsTLSKeyFlagName, "", fmt.Sprintf("Path to the TLS key to serve the custom metrics API with, along with --%s.", customMetricsTLSCertFlagName))
+	//nolint:lll
+	o.DefaultingWebhookFamilyPrefix = fs.String(defaultingWebhookFamilyPrefixFlagName, "", "Prefix the defaulting webhook prepends to the names of the families lacking it, e.g., acme_, along with the references to them by thresholds, and tests. Defaults to none, i.e., families are not renamed.")
+	//nolint:lll
+	o.DefaultingWebhookLabels = fs.String(defaultingWebhookLabelsFlagName, "", "Comma-separated key=path labels the defaulting webhook adds to the stores not setting them, e.g., team=metadata.labels.team, resolved off the stores' objects' fields, whichever the stores' resolver. Defaults to none.")
+	//nolint:lll
+	o.DefaultingWebhookPort = fs.Int(defaultingWebhookPortFlagName, 0, "Port to serve the mutating admission webhook filling in the defaults of the ResourceMetricsMonitors' configurations on, on the main server's hosts, at /default. Set to 0 to disable.")
+	//nolint:lll
+	o.DefaultingWebhookTLSCert = fs.String(defaultingWebhookTLSCertFlagName, "", fmt.Sprintf("Path to the TLS certificate to serve the defaulting webhook with, which the API server must trust, along with --%s, reloaded once either is modified. Required if the webhook is enabled.", defaultingWebhookTLSKeyFlagName))
+	o.DefaultingWebhookTLSKey = fs.String(defaultingWebhookTLSKeyFlagName, "", fmt.Sprintf("Path to the TLS key to serve the defaulting webhook with, along with --%s.", defaultingWebhookTLSCertFlagName))
+	//nolint:lll
+	o.EventNamespace = fs.String(eventNamespaceFlagName, "", "Namespace to record events about ResourceMetricsMonitors in. Defaults to each monitor's own namespace, or the default namespace for cluster-scoped ones.")
+	//nolint:lll
+	o.ExpositionCheck = fs.Int(expositionCheckFlagName, 30, "Interval in seconds to render and parse each ResourceMetricsMonitor's exposition at, reporting unready on the telemetry server if any would fail to be scraped. Set to 0 to disable.")
+	//nolint:lll
+	o.ExpositionMode = fs.String(expositionModeFlagName, string(ExpositionModeFast), fmt.Sprintf("Mode to render samples in, either %q, building them by hand, or %q, building them through the Prometheus client's data model and text encoder, guaranteeing escaping, float formatting, and label ordering, at some CPU cost.", ExpositionModeFast, ExpositionModeStrict))
+	//nolint:lll
+	o.FixedPointValues = fs.Bool(fixedPointValuesFlagName, false, "Format sample values in fixed-point notation with six decimals (e.g., 1.000000), instead of in their shortest representation that round-trips (e.g., 1), as kube-state-metrics does. Has no effect in the strict exposition mode.")
+	o.InstallCRDs = fs.Bool(installCRDsFlagName, false, "Server-side apply the (Cluster)ResourceMetricsMonitor CRDs embedded in the binary on startup, and wait for them to be established, so the API schema is installed, and upgraded, along with the controller. Requires permissions to patch them, on top of the ones to read CRDs the controller is granted, e.g., through examples/cluster-role-install-crds.yaml. Has no effect with --read-only.")
+	//nolint:lll
+	o.KSMCollisionPrefix = fs.String(ksmCollisionPrefixFlagName, "", "Prefix to rename the families exposing metrics named alike kube-state-metrics' ones (see --"+ksmCRSConfigFlagName+") with, e.g., rsm_, along with the thresholds referencing them, so that their series are told apart downstream. Families served as custom metrics are not renamed. Such families are reported either way. Defaults to none, i.e., they are not renamed.")
+	//nolint:lll
+	o.KSMCRSConfig = fs.String(ksmCRSConfigFlagName, "", "Path to kube-state-metrics' CustomResourceStateMetrics configuration, whose metrics (e.g., kube_customresource_replicas) families are reported colliding with if they expose metrics named alike. Defaults to none, i.e., collisions are not checked for.")
+	o.Kubeconfig = fs.String(kubeconfigFlagName, os.Getenv("KUBECONFIG"), "Path to a kubeconfig, or a list of them, merged, as in $KUBECONFIG. Takes precedence over the in-cluster configuration. Defaults to the in-cluster configuration, if in-cluster, or ~/.kube/config otherwise.")
+	//nolint:lll
+	o.LeaderElection = fs.Bool(leaderElectionFlagName, false, "Only process ResourceMetricsMonitors, and report on them, while holding the controller's leader election lease, so that replicas may be run for availability without racing on the monitors' status. Replicas not holding it serve no monitors' metrics until they acquire it, and replicas losing it exit. Requires permissions to get, create, and update leases, and hence cannot be used with --"+readOnlyFlagName+". Replicas not holding it report ready regardless of --"+warmUpMaxWaitFlagName+", as they have no monitors to warm up.")
+	o.LeaderElectionNamespace = fs.String(leaderElectionNamespaceFlagName, "", "Namespace of the leader election lease. Defaults to the controller's own namespace, if in-cluster, or the default namespace otherwise.")
+	o.ListPageSize = fs.Int64(listPageSizeFlagName, 500, "Number of objects stores list per page, following continue tokens, so large initial lists are not transferred in a single response. Set to 0 to list all objects at once.")
+	o.MainHosts = &[]string{}
+	//nolint:lll
+	fs.Var((*stringSliceFlag)(o.MainHosts), mainHostFlagName, fmt.Sprintf("Hosts to expose main metrics on, as comma-separated addresses. Can be repeated, e.g., to listen on IPv4 and IPv6 addresses explicitly, each in its own family. Defaults to %s.", defaultListenHost))
+	o.MainPort = fs.Int(mainPortFlagName, 9999, "Port to expose main metrics on.")
+	o.MasterURL = fs.String(masterURLFlagName, os.Getenv("KUBERNETES_MASTER"), "The address of the Kubernetes API server. Overrides any value in kubeconfig, or the in-cluster configuration.")
+	//nolint:lll
+	o.MemoryBudget = fs.Int64(memoryBudgetFlagName, 0, "Estimated memory, in bytes, the series of all stores may hold before the stores of further ResourceMetricsMonitors are no longer built, marking them as Degraded until the usage drops, instead of risking the controller being OOM-killed. Set to 0 to disable.")
+	//nolint:lll
+	o.MonitorsQuota = fs.Int(monitorsQuotaFlagName, 0, "Maximum number of ResourceMetricsMonitors whose stores are built per namespace, marking further ones as Failed, so a single tenant cannot exhaust the exporter's resources. Cluster-scoped monitors are not accounted for. Set to 0 to disable.")
+	//nolint:lll
+	o.NamespacedStores = fs.Bool(namespacedStoresFlagName, false, fmt.Sprintf("Scope each ResourceMetricsMonitor's stores to its own namespace, unless it is annotated with %s=true.", v1alpha1.ClusterScopedAnnotation))
+	o.NativeResources = &[]string{}
+	//nolint:lll
+	fs.Var((*stringSliceFlag)(o.NativeResources), nativeResourcesFlagName, fmt.Sprintf("Native (built-in) resources stores may target, as comma-separated group-qualified resources, e.g., pods,deployments.apps, or %q for all. Can be repeated. Defaults to none.", nativeResourcesWildcard))
+	o.RatioGOMEMLIMIT = fs.Float64(ratioGOMEMLIMITFlagName, 0.9, "GOMEMLIMIT to memory quota ratio.")
+	//nolint:lll
+	o.ReadOnly = fs.Bool(readOnlyFlagName, false, "Never write to the cluster, e.g., when running with view-only credentials. ResourceMetricsMonitors' labels and status are not updated, events are only logged, and ServiceMonitors are not generated. The monitors' conditions are reported through the telemetry metrics and logs instead.")
+	o.RecordEvents = fs.Bool(recordEventsFlagName, true, "Record events about ResourceMetricsMonitors. If disabled, events are only logged.")
+	//nolint:lll
+	o.ResolutionLogInterval = fs.Int(resolutionLogIntervalFlagName, int(resolver.DefaultLogInterval.Seconds()), "Interval in seconds identical failures resolving expressions, by expression and error, are logged at most once per, along with the number of times they were suppressed since. Set to 0 to log every failure.")
+	o.ScrapeAllowedCIDRs = &[]string{}
+	//nolint:lll
+	fs.Var((*stringSliceFlag)(o.ScrapeAllowedCIDRs), scrapeAllowedCIDRsFlagName, "CIDRs of the clients allowed to scrape the main server's metrics, and adjust the telemetry server's GC percent, as comma-separated values, e.g., 10.0.0.0/8. Can be repeated. Forwarding headers are not trusted. Defaults to none, i.e., clients are allowed from anywhere.")
+	//nolint:lll
+	o.ScrapeBearerTokenFile = fs.String(scrapeBearerTokenFileFlagName, "", "Path to a file holding the static bearer token clients must present to scrape the main server's metrics, and adjust the telemetry server's GC percent, e.g., mounted off a Secret. Defaults to none, i.e., scrapes are not authenticated.")
+	o.SelfHosts = &[]string{}
+	//nolint:lll
+	fs.Var((*stringSliceFlag)(o.SelfHosts), selfHostFlagName, fmt.Sprintf("Hosts to expose self (telemetry) metrics on, as comma-separated addresses. Can be repeated, e.g., to listen on IPv4 and IPv6 addresses explicitly, each in its own family. Defaults to %s.", defaultListenHost))
+	o.SelfPort = fs.Int(selfPortFlagName, 9998, "Port to expose self (telemetry) metrics on.")
+	o.ServiceMonitorLabels = fs.String(serviceMonitorLabelsFlagName, "", "Comma-separated key=value labels to set on generated ServiceMonitors, e.g., to match a Prometheus' serviceMonitorSelector.")
+	//nolint:lll
+	o.ServiceMonitorService = fs.String(serviceMonitorServiceFlagName, "", "Namespace-qualified name (<namespace>/<name>) of the Service exposing the main server. If set, a Prometheus Operator ServiceMonitor scraping each ResourceMetricsMonitor's dedicated endpoint is generated alongside it.")
+	//nolint:lll
+	o.SlowReconcileThreshold = fs.Float64(slowReconcileThresholdFlagName, 10, "Duration in seconds past which reconciling a ResourceMetricsMonitor is logged, at verbosity level 2 or higher, along with the time each of its steps, e.g., building its stores, took. Set to 0 to disable.")
+	//nolint:lll
+	o.SlowScrapeThreshold = fs.Float64(slowScrapeThresholdFlagName, 5, "Duration in seconds past which rendering an exposition on the main server is logged as slow, and counted, e.g., to diagnose scrape timeouts. Set to 0 to disable.")
+	//nolint:lll
+	o.SnapshotPath = fs.String(snapshotPathFlagName, "", fmt.Sprintf("Directory to write the stores' series out to on shutdown, and restore them from on startup, so they are served until the stores' initial lists replace them, e.g., a volume outliving the pod across rolling upgrades. Ignored with the %q storage, which holds them across restarts as is.", StorageTypeDisk))
+	//nolint:lll
+	o.StatusLastErrors = fs.Int(statusLastErrorsFlagName, 5, "Number of the most recent errors resolving each ResourceMetricsMonitor's expressions to report, truncated, in its status.lastErrors, for users without access to the controller's logs to debug them. Set to 0 to disable.")
+	//nolint:lll
+	o.Storage = fs.String(storageFlagName, string(StorageTypeMemory), fmt.Sprintf("Backend to hold the stores' rendered series in, either %q, holding all of them in memory, %q, holding the ones of up to --%s objects per store in memory, evicting the least recently updated ones, or %q, holding them on disk, under --%s, so they are served across restarts until the stores' initial lists replace them.", StorageTypeMemory, StorageTypeBounded, storageMaxObjectsFlagName, StorageTypeDisk, storagePathFlagName))
+	o.StorageMaxObjects = fs.Int(storageMaxObjectsFlagName, 100000, "Number of objects per store the bounded storage holds the series of.")
+	o.StoragePath = fs.String(storagePathFlagName, "", "Directory the disk storage keeps its database in. Required for the disk storage.")
+	//nolint:lll
+	o.StoresQuota = fs.Int(storesQuotaFlagName, 0, "Maximum number of stores the ResourceMetricsMonitors of a namespace may build in total, marking the monitors that would exceed it as Failed. Cluster-scoped monitors are not accounted for. Set to 0 to disable.")
+	//nolint:lll
+	o.ThresholdCheck = fs.Int(thresholdCheckFlagName, 30, "Interval in seconds to evaluate the thresholds declared by ResourceMetricsMonitors against their series at, recording an event on a monitor whenever any of its thresholds is crossed, or resolved. Set to 0 to disable.")
+	//nolint:lll
+	o.ThresholdWebhooks = fs.Bool(thresholdWebhooksFlagName, false, "Notify the webhooks thresholds declare, if any, whenever they are crossed, or resolved, in addition to recording events. Disabled by default, since monitors' authors would otherwise have the controller send requests to arbitrary URLs.")
+	o.Version = fs.Bool(versionFlagName, false, "Print version information and quit")
+	//nolint:lll
+	o.WarmUpMaxWait = fs.Int(warmUpMaxWaitFlagName, 300, "Maximum time in seconds to report unready for on startup, until the ResourceMetricsMonitors observed on startup have been processed, and their stores have processed their initial lists, so that an empty exposition is not scraped right after a restart. Set to 0 to disable.")
+	//nolint:lll
+	o.WatchList = fs.Bool(watchListFlagName, false, "Stream the stores' initial lists through watches, where the API server supports it (WatchList), falling back to paginated lists otherwise, to reduce the memory spent on large lists.")
+	o.WatchNamespaces = &[]string{}
+	fs.Var((*stringSliceFlag)(o.WatchNamespaces), watchNamespaceFlagName, "Namespace to watch ResourceMetricsMonitors in. Can be repeated. Defaults to all namespaces.")
+	o.Workers = fs.Int(workersFlagName, 2, "Number of workers processing managed resources in the workqueue.")
 }
 
 func (o *Options) validateFlag(name, value string) error {
-	if name == celTimeoutFlagName {
+	switch name {
+	case celCostLimitFlagName:
+		valueUint, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if valueUint == 0 {
+			return fmt.Errorf("%s must be positive", name)
+		}
+	case celEnvironmentFlagName:
+		for _, variable := range strings.Split(value, ",") {
+			if errs := validation.IsEnvVarName(variable); len(errs) > 0 {
+				return fmt.Errorf("invalid environment variable %q for %s: %s", variable, name, strings.Join(errs, ", "))
+			}
+		}
+	case celTimeoutFlagName:
+		valueInt, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if valueInt <= 0 || valueInt > int(maxCELTimeout.Seconds()) {
+			return fmt.Errorf("%s must be between 1 and %d seconds", name, int(maxCELTimeout.Seconds()))
+		}
+	case conditionHoldFlagName, expositionCheckFlagName, resolutionLogIntervalFlagName, thresholdCheckFlagName, warmUpMaxWaitFlagName:
+		valueInt, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if valueInt < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	case expositionModeFlagName:
+		switch ExpositionMode(value) {
+		case ExpositionModeFast, ExpositionModeStrict:
+		default:
+			return fmt.Errorf("%s must be either %q or %q", name, ExpositionModeFast, ExpositionModeStrict)
+		}
+	case listPageSizeFlagName, memoryBudgetFlagName, monitorsQuotaFlagName, statusLastErrorsFlagName, storesQuotaFlagName:
+		valueInt, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if valueInt < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	case clusterFlagName:
+		if _, err := parseClusters([]string{value}); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	case defaultingWebhookFamilyPrefixFlagName, ksmCollisionPrefixFlagName:
+		if value != "" && !model.IsValidLegacyMetricName(value) {
+			return fmt.Errorf("invalid prefix %q for %s: must be a valid metric name", value, name)
+		}
+	case autoConditionsLabelSelectorFlagName:
+		if _, err := labels.Parse(value); err != nil {
+			return fmt.Errorf("invalid label selector for %s: %w", name, err)
+		}
+	case autoConditionsNamespaceFlagName, eventNamespaceFlagName, leaderElectionNamespaceFlagName:
+		// An empty namespace stands for all namespaces, or, for events, each monitor's own one, or, for the lease, the controller's.
+		if errs := validation.IsDNS1123Label(value); value != "" && len(errs) > 0 {
+			return fmt.Errorf("invalid namespace %q for %s: %s", value, name, strings.Join(errs, ", "))
+		}
+	case watchNamespaceFlagName:
+		for _, namespace := range strings.Split(value, ",") {
+			if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+				return fmt.Errorf("invalid namespace %q for %s: %s", namespace, name, strings.Join(errs, ", "))
+			}
+		}
+	case nativeResourcesFlagName:
+		for _, resource := range strings.Split(value, ",") {
+			if resource == nativeResourcesWildcard {
+				continue
+			}
+			groupResource := schema.ParseGroupResource(resource)
+			if groupResource.Resource == "" || !isNativeGroup(groupResource.Group) {
+				return fmt.Errorf("invalid native resource %q for %s", resource, name)
+			}
+		}
+	case storageFlagName:
+		switch StorageType(value) {
+		case StorageTypeMemory, StorageTypeBounded, StorageTypeDisk:
+		default:
+			return fmt.Errorf("%s must be one of %q, %q, or %q", name, StorageTypeMemory, StorageTypeBounded, StorageTypeDisk)
+		}
+	case storageMaxObjectsFlagName:
+		valueInt, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if valueInt <= 0 {
+			return fmt.Errorf("%s must be positive", name)
+		}
+	case mainHostFlagName, selfHostFlagName:
+		for _, host := range strings.Split(value, ",") {
+			// Empty hosts listen on all interfaces.
+			if host == "" || net.ParseIP(host) != nil {
+				continue
+			}
+			if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+				return fmt.Errorf("invalid host %q for %s: %s", host, name, strings.Join(errs, ", "))
+			}
+		}
+	case customMetricsPortFlagName, defaultingWebhookPortFlagName:
+		valueInt, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if errs := validation.IsValidPortNum(valueInt); valueInt != 0 && len(errs) > 0 {
+			return fmt.Errorf("invalid port %d for %s: %s", valueInt, name, strings.Join(errs, ", "))
+		}
+	case mainPortFlagName, selfPortFlagName:
+		valueInt, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if errs := validation.IsValidPortNum(valueInt); len(errs) > 0 {
+			return fmt.Errorf("invalid port %d for %s: %s", valueInt, name, strings.Join(errs, ", "))
+		}
+	case masterURLFlagName:
+		if value == "" {
+			break
+		}
+		masterURL, err := url.Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if masterURL.Host == "" {
+			return fmt.Errorf("%s must be an absolute URL, e.g., https://127.0.0.1:6443", name)
+		}
+	case credentialsAllowedServersFlagName:
+		for _, server := range strings.Split(value, ",") {
+			if _, err := serverOrigin(strings.TrimSpace(server)); err != nil {
+				return fmt.Errorf("invalid server %q for %s: %w", server, name, err)
+			}
+		}
+	case scrapeAllowedCIDRsFlagName:
+		for _, cidr := range strings.Split(value, ",") {
+			if _, _, err := net.ParseCIDR(strings.TrimSpace(cidr)); err != nil {
+				return fmt.Errorf("invalid CIDR %q for %s: %w", cidr, name, err)
+			}
+		}
+	case kubeconfigFlagName:
+		for _, path := range filepath.SplitList(value) {
+			if info, err := os.Stat(path); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", name, err)
+			} else if info.IsDir() {
+				return fmt.Errorf("%s must be a file, got directory %q", name, path)
+			}
+		}
+	case ksmCRSConfigFlagName:
+		if _, err := loadKSMMetricNames(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	case configFileFlagName, customMetricsTLSCertFlagName, customMetricsTLSKeyFlagName, defaultingWebhookTLSCertFlagName, defaultingWebhookTLSKeyFlagName, scrapeBearerTokenFileFlagName:
+		if value == "" {
+			break
+		}
+		if info, err := os.Stat(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		} else if info.IsDir() {
+			return fmt.Errorf("%s must be a file, got directory %q", name, value)
+		}
+	case snapshotPathFlagName, storagePathFlagName:
+		if value == "" {
+			break
+		}
+		if info, err := os.Stat(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		} else if !info.IsDir() {
+			return fmt.Errorf("%s must be a directory, got file %q", name, value)
+		}
+	case slowReconcileThresholdFlagName, slowScrapeThresholdFlagName:
+		valueFloat, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if valueFloat < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+	case ratioGOMEMLIMITFlagName:
+		valueFloat, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if valueFloat <= 0 || valueFloat > 1 {
+			return fmt.Errorf("%s must be greater than 0, and at most 1", name)
+		}
+	case workersFlagName:
 		valueInt, err := strconv.Atoi(value)
 		if err != nil {
 			return fmt.Errorf("invalid value for %s: %w", name, err)
 		}
-		if valueInt <= 0 || valueInt > 300 {
-			return fmt.Errorf("%s must be between 1 and 300 seconds", name)
+		if valueInt <= 0 {
+			return fmt.Errorf("%s must be positive", name)
+		}
+	case serviceMonitorLabelsFlagName:
+		if _, err := labels.ConvertSelectorToLabelsMap(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	case defaultingWebhookLabelsFlagName:
+		if _, _, err := parseDefaultLabels(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	case serviceMonitorServiceFlagName:
+		namespace, _, err := cache.SplitMetaNamespaceKey(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		if namespace == "" {
+			return fmt.Errorf("%s must be of the form <namespace>/<name>", name)
 		}
 	}
 
@@ -2,7 +2,9 @@ package internal
 
 import (
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"testing"
 
 	"k8s.io/klog/v2"
@@ -15,6 +17,11 @@ func TestOptions_Read(t *testing.T) {
 	os.Args = []string{
 		"cmd",
 		"--main-port", strconv.Itoa(originalMainPortNumber), // This will *not* be overridden as it was explicitly set.
+		"--watch-namespace", "foo",
+		"--watch-namespace", "bar",
+		"--native-resources", "pods,deployments.apps",
+		"--cluster", "east=/tmp/east",
+		"--cluster", "west=",
 	}
 
 	// Override the --self-port flag with the RSM_SELF_PORT environment variable.
@@ -27,11 +34,100 @@ func TestOptions_Read(t *testing.T) {
 
 	// Check if the flags were overridden by their corresponding environment variables.
 	o := NewOptions(klog.NewKlogr())
-	o.Read()
+	if err := o.Read(); err != nil {
+		t.Fatal(err)
+	}
 	if *o.SelfPort != overriddenSelfPortNumber {
 		t.Fatalf("expected %d, got %d", overriddenSelfPortNumber, *o.SelfPort)
 	}
 	if *o.MainPort != originalMainPortNumber {
 		t.Fatalf("expected %d, got %d", originalMainPortNumber, *o.MainPort)
 	}
+
+	// Repeatable flags accumulate.
+	if expected := []string{"foo", "bar"}; !slices.Equal(*o.WatchNamespaces, expected) {
+		t.Fatalf("expected %v, got %v", expected, *o.WatchNamespaces)
+	}
+	if expected := []string{"pods,deployments.apps"}; !slices.Equal(*o.NativeResources, expected) {
+		t.Fatalf("expected %v, got %v", expected, *o.NativeResources)
+	}
+	if expected := []string{"east=/tmp/east", "west="}; !slices.Equal(*o.Clusters, expected) {
+		t.Fatalf("expected %v, got %v", expected, *o.Clusters)
+	}
+}
+
+func TestOptions_Parse(t *testing.T) {
+	t.Parallel()
+	o := NewOptions(klog.Background())
+	if err := o.Parse([]string{"--main-port=4242", "--watch-namespace=foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if *o.MainPort != 4242 {
+		t.Errorf("expected 4242, got %d", *o.MainPort)
+	}
+	if *o.Workers != 2 {
+		t.Errorf("expected the default number of workers, got %d", *o.Workers)
+	}
+	if expected := []string{"foo"}; !slices.Equal(*o.WatchNamespaces, expected) {
+		t.Errorf("expected %v, got %v", expected, *o.WatchNamespaces)
+	}
+
+	if err := NewOptions(klog.Background()).Parse([]string{"--cel-timeout-seconds=0"}); err == nil {
+		t.Error("expected an error for an invalid flag")
+	}
+}
+
+func TestOptions_validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		args    []string
+		invalid []string
+	}{
+		{
+			name: "valid",
+			args: []string{"--main-host=localhost", "--self-host=127.0.0.1", "--main-port=8080", "--ratio-gomemlimit=0.5", "--cel-environment=SHARD", "--master=https://127.0.0.1:6443", "--credentials-allowed-servers=https://tenant.example.com:6443"},
+		},
+		{
+			name:    "invalid values are reported together",
+			args:    []string{"--main-port=70000", "--self-host=foo_bar", "--workers=0", "--ratio-gomemlimit=1.5", "--cel-cost-limit=0", "--cel-environment=1FOO", "--master=127.0.0.1", "--credentials-allowed-servers=ftp://tenant.example.com"},
+			invalid: []string{mainPortFlagName, selfHostFlagName, workersFlagName, ratioGOMEMLIMITFlagName, celCostLimitFlagName, celEnvironmentFlagName, masterURLFlagName, credentialsAllowedServersFlagName},
+		},
+		{
+			name:    "missing paths",
+			args:    []string{"--kubeconfig=/nonexistent", "--snapshot-path=/nonexistent"},
+			invalid: []string{kubeconfigFlagName, snapshotPathFlagName},
+		},
+		{
+			name:    "disk storage without a path",
+			args:    []string{"--storage=disk"},
+			invalid: []string{storagePathFlagName},
+		},
+		{
+			name:    "leader election while read-only",
+			args:    []string{"--leader-election", "--read-only"},
+			invalid: []string{leaderElectionFlagName},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := NewOptions(klog.Background()).Parse(tt.args)
+			if len(tt.invalid) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, name := range tt.invalid {
+				if !strings.Contains(err.Error(), name) {
+					t.Errorf("expected %s to be reported, got %v", name, err)
+				}
+			}
+		})
+	}
 }
@@ -0,0 +1,129 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+)
+
+// optionsPath is the self server's path to view the options in effect on, along with where each was set.
+const optionsPath = "/debug/options"
+
+// Sources options may be set from, by their precedence, highest first.
+const (
+	optionSourceFlag        = "flag"
+	optionSourceEnvironment = "env"
+	optionSourceConfigFile  = "config-file"
+	optionSourceDefault     = "default"
+)
+
+// readConfigFile sets the options in the config file, if any, that were not set through the command-line flags, or
+// the environment. The config file maps option names, i.e., the flags' names, to their values, or lists thereof for
+// repeatable flags.
+func (o *Options) readConfigFile(fs *flag.FlagSet) error {
+	if o.ConfigFile == nil || *o.ConfigFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(*o.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return fmt.Errorf("error parsing config file: %w", err)
+	}
+	// Decode numbers as is, so large, or unsigned, integers are not rounded through floats.
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var options map[string]interface{}
+	if err := decoder.Decode(&options); err != nil {
+		return fmt.Errorf("error parsing config file: %w", err)
+	}
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown option %q in config file", name)
+		}
+		if name == configFileFlagName {
+			return fmt.Errorf("option %q may not be set in the config file", name)
+		}
+		if _, ok := o.sources[name]; ok {
+			o.logger.V(1).Info("Ignoring option in config file, as it was already set", "option", name, "source", o.sources[name])
+
+			continue
+		}
+		values, ok := options[name].([]interface{})
+		if !ok {
+			values = []interface{}{options[name]}
+		}
+		for _, value := range values {
+			if value == nil {
+				continue
+			}
+			switch value.(type) {
+			case map[string]interface{}, []interface{}:
+				return fmt.Errorf("invalid value for option %q in config file: expected a scalar, or a list thereof", name)
+			}
+			if err := fs.Set(name, fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("invalid value for option %q in config file: %w", name, err)
+			}
+		}
+		o.sources[name] = optionSourceConfigFile
+	}
+
+	return nil
+}
+
+// optionsHandler serves the options in effect, along with the source each was set from.
+func optionsHandler(o *Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+		if o == nil || o.flags == nil {
+			http.Error(w, "options are not available", http.StatusNotFound)
+
+			return
+		}
+		// Options are visited in lexicographical order.
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		o.flags.VisitAll(func(f *flag.Flag) {
+			source, ok := o.sources[f.Name]
+			if !ok {
+				source = optionSourceDefault
+			}
+			_, _ = fmt.Fprintf(w, "%s=%s (%s)\n", f.Name, f.Value.String(), source)
+		})
+	}
+}
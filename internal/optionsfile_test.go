@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"k8s.io/klog/v2"
+)
+
+func TestOptions_readConfigFile(t *testing.T) {
+	t.Parallel()
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte(`main-port: 1234
+self-port: 5678
+cel-cost-limit: 10000000000
+watch-namespace:
+  - foo
+  - bar
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	o := NewOptions(klog.Background())
+	if err := o.Parse([]string{"--config-file", configFile, "--main-port=4242"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags take precedence over the config file.
+	if *o.MainPort != 4242 {
+		t.Errorf("expected 4242, got %d", *o.MainPort)
+	}
+	if *o.SelfPort != 5678 {
+		t.Errorf("expected 5678, got %d", *o.SelfPort)
+	}
+	if *o.CELCostLimit != 10000000000 {
+		t.Errorf("expected 10000000000, got %d", *o.CELCostLimit)
+	}
+	if expected := []string{"foo", "bar"}; !slices.Equal(*o.WatchNamespaces, expected) {
+		t.Errorf("expected %v, got %v", expected, *o.WatchNamespaces)
+	}
+
+	recorder := httptest.NewRecorder()
+	optionsHandler(o).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, optionsPath, nil))
+	for _, expected := range []string{"main-port=4242 (flag)\n", "self-port=5678 (config-file)\n", "workers=2 (default)\n"} {
+		if !strings.Contains(recorder.Body.String(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, recorder.Body.String())
+		}
+	}
+}
+
+func TestOptions_readConfigFile_invalid(t *testing.T) {
+	t.Parallel()
+	for name, content := range map[string]string{
+		"unknown option": "foo: bar\n",
+		"invalid value":  "workers: foo\n",
+		"failed check":   "cel-timeout-seconds: 0\n",
+		"nested value":   "main-host:\n  foo: bar\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configFile, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := NewOptions(klog.Background()).Parse([]string{"--config-file", configFile}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/rexagod/resource-state-metrics/internal/version"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	clientset "github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	pluginStatusCommandName = "status"
+	pluginRenderCommandName = "render"
+	pluginTopCommandName    = "top"
+	pluginTopFamiliesTarget = "families"
+	pluginTopFlagName       = "top"
+)
+
+var errPluginUsage = errors.New("usage: kubectl rsm <status [name] | render <name> | top families [-top N]> [flags]")
+
+// pluginClients holds the clients the plugin talks to the cluster, and the controller, through.
+type pluginClients struct {
+	kubeClientset kubernetes.Interface
+	rsmClientset  clientset.Interface
+	// namespace is the namespace monitors are looked up in, or empty for cluster-scoped ones.
+	namespace string
+	// allNamespaces lists the monitors of all namespaces, along with the cluster-scoped ones.
+	allNamespaces bool
+	// service is the namespace-qualified name of the Service exposing the controller's main server, proxied to through
+	// the API server.
+	service     types.NamespacedName
+	servicePort int
+}
+
+// Plugin runs the kubectl plugin, invoked as `kubectl rsm <command> [args...]`, to inspect monitors, and the series
+// they generate, off the RMM API, and the controller's main server, proxied to through the API server.
+func Plugin(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) == 0 {
+		return errPluginUsage
+	}
+	command, args := args[0], args[1:]
+	flags := flag.NewFlagSet("kubectl-rsm "+command, flag.ContinueOnError)
+	kubeconfig := flags.String(kubeconfigFlagName, os.Getenv("KUBECONFIG"), "Path to a kubeconfig. Defaults to the standard kubeconfig loading rules.")
+	namespace := flags.String("namespace", "", "Namespace of the ResourceMetricsMonitors. Defaults to the kubeconfig's.")
+	cluster := flags.Bool("cluster", false, "Inspect ClusterResourceMetricsMonitors instead.")
+	allNamespaces := flags.Bool("all-namespaces", false, "List the ResourceMetricsMonitors of all namespaces, along with ClusterResourceMetricsMonitors.")
+	service := flags.String("service", "resource-state-metrics/"+version.ControllerName.String(), "Namespace-qualified name (<namespace>/<name>) of the Service exposing the controller's main server.")
+	servicePort := flags.Int("service-port", installDefaultMainPort, "Port of the Service exposing the controller's main server.")
+	count := flags.Int(pluginTopFlagName, 10, "Number of families to list, for top.")
+
+	// Positional arguments may precede the flags, e.g., `kubectl rsm render foo -namespace bar`.
+	var positional []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		positional, args = append(positional, args[0]), args[1:]
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	positional = append(positional, flags.Args()...)
+
+	clients, err := newPluginClients(*kubeconfig, *namespace)
+	if err != nil {
+		return err
+	}
+	if *cluster {
+		clients.namespace = metav1.NamespaceNone
+	}
+	clients.allNamespaces = *allNamespaces
+	serviceNamespace, serviceName, ok := strings.Cut(*service, "/")
+	if !ok || serviceNamespace == "" || serviceName == "" {
+		return fmt.Errorf("-service must be a namespace-qualified name, got %q", *service)
+	}
+	clients.service = types.NamespacedName{Namespace: serviceNamespace, Name: serviceName}
+	clients.servicePort = *servicePort
+
+	switch {
+	case command == pluginStatusCommandName && len(positional) <= 1:
+		return clients.status(ctx, out, positional)
+	case command == pluginRenderCommandName && len(positional) == 1:
+		return clients.render(ctx, out, positional[0])
+	case command == pluginTopCommandName && len(positional) == 1 && positional[0] == pluginTopFamiliesTarget:
+		return clients.topFamilies(ctx, out, *count)
+	default:
+		return errPluginUsage
+	}
+}
+
+// newPluginClients returns the plugin's clients for the given kubeconfig, falling back to the standard loading rules,
+// looking monitors up in the given namespace, or the kubeconfig's.
+func newPluginClients(kubeconfig, namespace string) (*pluginClients, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
+	cfg, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error building kubeconfig: %w", err)
+	}
+	if namespace == "" {
+		if namespace, _, err = clientConfig.Namespace(); err != nil {
+			return nil, fmt.Errorf("error reading the kubeconfig's namespace: %w", err)
+		}
+	}
+	kubeClientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error building kubernetes clientset: %w", err)
+	}
+	rsmClientset, err := clientset.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error building %s clientset: %w", version.ControllerName.String(), err)
+	}
+
+	return &pluginClients{kubeClientset: kubeClientset, rsmClientset: rsmClientset, namespace: namespace}, nil
+}
+
+// monitors returns the monitors to inspect, optionally narrowed down to the given name.
+func (p *pluginClients) monitors(ctx context.Context, names []string) ([]*v1alpha1.ResourceMetricsMonitor, error) {
+	var monitors []*v1alpha1.ResourceMetricsMonitor
+	if p.allNamespaces || p.namespace != metav1.NamespaceNone {
+		namespace := p.namespace
+		if p.allNamespaces {
+			namespace = metav1.NamespaceAll
+		}
+		list, err := p.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error listing ResourceMetricsMonitors: %w", err)
+		}
+		for i := range list.Items {
+			monitors = append(monitors, &list.Items[i])
+		}
+	}
+	if p.allNamespaces || p.namespace == metav1.NamespaceNone {
+		list, err := p.rsmClientset.ResourceStateMetricsV1alpha1().ClusterResourceMetricsMonitors().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error listing ClusterResourceMetricsMonitors: %w", err)
+		}
+		for i := range list.Items {
+			monitors = append(monitors, fromClusterMonitor(&list.Items[i]))
+		}
+	}
+	if len(names) > 0 {
+		monitors = slices.DeleteFunc(monitors, func(monitor *v1alpha1.ResourceMetricsMonitor) bool {
+			return monitor.GetName() != names[0]
+		})
+		if len(monitors) == 0 {
+			return nil, fmt.Errorf("no monitor named %q found", names[0])
+		}
+	}
+
+	return monitors, nil
+}
+
+// status lists the monitors, along with their true conditions, and the number of errors reported in their status.
+func (p *pluginClients) status(ctx context.Context, out io.Writer, names []string) error {
+	monitors, err := p.monitors(ctx, names)
+	if err != nil {
+		return err
+	}
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "NAMESPACE\tNAME\tCONDITIONS\tLAST ERRORS")
+	for _, monitor := range monitors {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%d\n",
+			cmp.Or(monitor.GetNamespace(), "<cluster>"),
+			monitor.GetName(),
+			cmp.Or(strings.Join(trueConditions(monitor.Status.Conditions), ","), "<none>"),
+			len(monitor.Status.LastErrors),
+		)
+	}
+
+	return writer.Flush()
+}
+
+// trueConditions returns the types of the given conditions whose status is true, in their defined order.
+func trueConditions(conditions []metav1.Condition) []string {
+	var trueTypes []string
+	for _, conditionType := range v1alpha1.ConditionType {
+		if meta.IsStatusConditionTrue(conditions, conditionType) {
+			trueTypes = append(trueTypes, conditionType)
+		}
+	}
+
+	return trueTypes
+}
+
+// render writes out the series the given monitor generates, as served on its dedicated path.
+func (p *pluginClients) render(ctx context.Context, out io.Writer, name string) error {
+	path := "/metrics/" + name
+	if p.namespace != metav1.NamespaceNone {
+		path = "/metrics/" + p.namespace + "/" + name
+	}
+	body, err := p.proxyGet(ctx, path)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(body)
+
+	return err
+}
+
+// topFamilies lists the families with the most series across all monitors, as served by the controller.
+func (p *pluginClients) topFamilies(ctx context.Context, out io.Writer, count int) error {
+	body, err := p.proxyGet(ctx, "/metrics")
+	if err != nil {
+		return err
+	}
+	parser := expfmt.TextParser{}
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error parsing exposition: %w", err)
+	}
+	type familySize struct {
+		name   string
+		series int
+	}
+	sizes := make([]familySize, 0, len(families))
+	for name, family := range families {
+		sizes = append(sizes, familySize{name: name, series: len(family.GetMetric())})
+	}
+	slices.SortFunc(sizes, func(a, b familySize) int {
+		return cmp.Or(cmp.Compare(b.series, a.series), strings.Compare(a.name, b.name))
+	})
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(writer, "FAMILY\tSERIES")
+	for _, size := range sizes[:min(count, len(sizes))] {
+		_, _ = fmt.Fprintf(writer, "%s\t%d\n", size.name, size.series)
+	}
+
+	return writer.Flush()
+}
+
+// proxyGet returns the response to a GET request for the given path on the controller's main server, proxied to
+// through the API server.
+func (p *pluginClients) proxyGet(ctx context.Context, path string) ([]byte, error) {
+	body, err := p.kubeClientset.CoreV1().Services(p.service.Namespace).
+		ProxyGet("http", p.service.Name, strconv.Itoa(p.servicePort), path, nil).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s off %s: %w", path, p.service, err)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,145 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestPluginClients_status(t *testing.T) {
+	t.Parallel()
+	rsmClientset := fake.NewSimpleClientset(
+		&v1alpha1.ResourceMetricsMonitor{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+			Status: v1alpha1.ResourceMetricsMonitorStatus{
+				Conditions: []metav1.Condition{
+					{Type: v1alpha1.ConditionType[v1alpha1.ConditionTypeProcessed], Status: metav1.ConditionTrue},
+					{Type: v1alpha1.ConditionType[v1alpha1.ConditionTypeFailed], Status: metav1.ConditionFalse},
+					{Type: v1alpha1.ConditionType[v1alpha1.ConditionTypeTested], Status: metav1.ConditionTrue},
+				},
+				LastErrors: []v1alpha1.ResolutionError{{Expression: "spec.foo"}},
+			},
+		},
+		&v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "other"}},
+		&v1alpha1.ClusterResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "baz"}},
+	)
+	tests := []struct {
+		name     string
+		clients  pluginClients
+		names    []string
+		expected string
+	}{
+		{
+			name:    "namespace",
+			clients: pluginClients{rsmClientset: rsmClientset, namespace: "default"},
+			expected: "NAMESPACE  NAME  CONDITIONS        LAST ERRORS\n" +
+				"default    foo   Processed,Tested  1\n",
+		},
+		{
+			name:    "all namespaces",
+			clients: pluginClients{rsmClientset: rsmClientset, allNamespaces: true},
+			names:   []string{"baz"},
+			expected: "NAMESPACE  NAME  CONDITIONS  LAST ERRORS\n" +
+				"<cluster>  baz   <none>      0\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			out := &bytes.Buffer{}
+			if err := tt.clients.status(context.Background(), out, tt.names); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(out.String(), tt.expected); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
+
+// testProxyResponse is the response to the requests proxied to through a fake client-set.
+type testProxyResponse []byte
+
+// DoRaw implements rest.ResponseWrapper.
+func (r testProxyResponse) DoRaw(context.Context) ([]byte, error) {
+	return r, nil
+}
+
+// Stream implements rest.ResponseWrapper.
+func (r testProxyResponse) Stream(context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(r)), nil
+}
+
+func TestPluginClients_topFamilies(t *testing.T) {
+	t.Parallel()
+	kubeClientset := kubefake.NewClientset()
+	kubeClientset.PrependProxyReactor("services", func(action clienttesting.Action) (bool, rest.ResponseWrapper, error) {
+		proxyAction, ok := action.(clienttesting.ProxyGetAction)
+		if !ok || proxyAction.GetNamespace() != "resource-state-metrics" || proxyAction.GetName() != "resource-state-metrics" || proxyAction.GetPath() != "/metrics" {
+			return false, nil, nil
+		}
+
+		return true, testProxyResponse(`# HELP kube_customresource_replicas Replicas
+# TYPE kube_customresource_replicas gauge
+kube_customresource_replicas{name="foo"} 1
+kube_customresource_replicas{name="bar"} 1
+kube_customresource_replicas{name="baz"} 1
+# HELP kube_customresource_ready Ready
+# TYPE kube_customresource_ready gauge
+kube_customresource_ready{name="foo"} 1
+kube_customresource_ready{name="bar"} 1
+# HELP kube_customresource_info Info
+# TYPE kube_customresource_info gauge
+kube_customresource_info{name="foo"} 1
+kube_customresource_info{name="bar"} 1
+`), nil
+	})
+	clients := pluginClients{
+		kubeClientset: kubeClientset,
+		service:       types.NamespacedName{Namespace: "resource-state-metrics", Name: "resource-state-metrics"},
+		servicePort:   installDefaultMainPort,
+	}
+	tests := []struct {
+		name     string
+		count    int
+		expected string
+	}{
+		{
+			name:  "top families, ties broken by name",
+			count: 2,
+			expected: "FAMILY                        SERIES\n" +
+				"kube_customresource_replicas  3\n" +
+				"kube_customresource_info      2\n",
+		},
+		{
+			name:  "more than there are",
+			count: 10,
+			expected: "FAMILY                        SERIES\n" +
+				"kube_customresource_replicas  3\n" +
+				"kube_customresource_info      2\n" +
+				"kube_customresource_ready     2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			out := &bytes.Buffer{}
+			if err := clients.topFamilies(context.Background(), out, tt.count); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(out.String(), tt.expected); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+// presetsDir is the directory, embedded in presetsFS, holding a configuration for each preset, at <name>.yaml.
+const presetsDir = "presets"
+
+// presetsFS embeds the built-in presets, i.e., ready-made configurations for popular CRDs.
+//
+//go:embed presets
+var presetsFS embed.FS
+
+// presetNames returns the names of the built-in presets, e.g., cert-manager/certificates, in order.
+func presetNames() []string {
+	var names []string
+	_ = fs.WalkDir(presetsFS, presetsDir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || path.Ext(p) != ".yaml" {
+			return err
+		}
+		names = append(names, strings.TrimSuffix(strings.TrimPrefix(p, presetsDir+"/"), ".yaml"))
+
+		return nil
+	})
+	slices.Sort(names)
+
+	return names
+}
+
+// presetConfiguration returns the configuration of the preset with the given name.
+func presetConfiguration(name string) (string, error) {
+	if !slices.Contains(presetNames(), name) {
+		return "", fmt.Errorf("unknown preset %q, expected one of: %s", name, strings.Join(presetNames(), ", "))
+	}
+	raw, err := presetsFS.ReadFile(path.Join(presetsDir, name+".yaml"))
+	if err != nil {
+		return "", fmt.Errorf("error reading preset %q: %w", name, err)
+	}
+
+	return string(raw), nil
+}
+
+// monitorConfiguration returns the configuration of the given monitor, i.e., its preset's stores, if any, followed by
+// the ones of its configuration.
+func monitorConfiguration(spec v1alpha1.ResourceMetricsMonitorSpec) (string, error) {
+	if spec.Preset == "" {
+		return spec.Configuration, nil
+	}
+	preset, err := presetConfiguration(spec.Preset)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(spec.Configuration) == "" {
+		return preset, nil
+	}
+
+	presetGeneric := map[string]interface{}{}
+	if err = yaml.Unmarshal([]byte(preset), &presetGeneric); err != nil {
+		return "", fmt.Errorf("error unmarshalling preset %q: %w", spec.Preset, err)
+	}
+	generic := map[string]interface{}{}
+	if err = yaml.Unmarshal([]byte(spec.Configuration), &generic); err != nil {
+		return "", fmt.Errorf("error unmarshalling configuration: %w", err)
+	}
+	presetStores, _ := presetGeneric["stores"].([]interface{})
+	stores, ok := generic["stores"].([]interface{})
+	if !ok && generic["stores"] != nil {
+		return "", errors.New("error unmarshalling configuration: stores must be a list")
+	}
+	generic["stores"] = append(presetStores, stores...)
+	merged, err := yaml.Marshal(generic)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling configuration: %w", err)
+	}
+
+	return string(merged), nil
+}
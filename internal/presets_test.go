@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPresets(t *testing.T) {
+	t.Parallel()
+	names := presetNames()
+	for _, expected := range []string{"argo-rollouts/rollouts", "cert-manager/certificates", "crossplane/claims", "flux/kustomizations"} {
+		found := false
+		for _, name := range names {
+			found = found || name == expected
+		}
+		if !found {
+			t.Errorf("expected preset %q, got %v", expected, names)
+		}
+	}
+	for _, name := range names {
+		raw, err := presetConfiguration(name)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		c := newConfigurer(nil, &v1alpha1.ResourceMetricsMonitor{}, resolver.DefaultCostLimit, resolver.DefaultTimeout, nil)
+		if err = c.parse(raw); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		for _, finding := range newLinter(1).lintConfiguration(raw) {
+			if finding.severity == lintSeverityError {
+				t.Errorf("%s: unexpected finding: %s", name, finding)
+			}
+		}
+	}
+}
+
+func TestMonitorConfiguration(t *testing.T) {
+	t.Parallel()
+	configuration := `stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    families:
+      - name: "bar_replicas"
+        metrics:
+          - value: "spec.replicas"
+`
+	raw, err := monitorConfiguration(v1alpha1.ResourceMetricsMonitorSpec{Configuration: configuration})
+	if err != nil || raw != configuration {
+		t.Errorf("expected the configuration as is without a preset, got %q (%v)", raw, err)
+	}
+
+	raw, err = monitorConfiguration(v1alpha1.ResourceMetricsMonitorSpec{Configuration: configuration, Preset: "cert-manager/certificates"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c := newConfigurer(nil, &v1alpha1.ResourceMetricsMonitor{}, resolver.DefaultCostLimit, resolver.DefaultTimeout, nil)
+	if err = c.parse(raw); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.configuration.Stores) != 2 || c.configuration.Stores[0].Kind != "Certificate" || c.configuration.Stores[1].Kind != "Bar" {
+		t.Errorf("expected the preset's store followed by the configuration's, got %+v", c.configuration.Stores)
+	}
+
+	if _, err = monitorConfiguration(v1alpha1.ResourceMetricsMonitorSpec{Preset: "unknown/presets"}); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
+
+func TestRenderExpositionPreset(t *testing.T) {
+	t.Parallel()
+	rmm := &v1alpha1.ResourceMetricsMonitor{Spec: v1alpha1.ResourceMetricsMonitorSpec{Preset: "cert-manager/certificates"}}
+	certificate := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "cert-manager.io/v1",
+			"kind":       "Certificate",
+			"metadata": map[string]interface{}{
+				"name":      "example",
+				"namespace": "default",
+			},
+			"spec": map[string]interface{}{
+				"issuerRef": map[string]interface{}{
+					"name": "letsencrypt",
+					"kind": "ClusterIssuer",
+				},
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": "True"},
+				},
+				"notAfter": "2026-01-01T00:00:00Z",
+			},
+		},
+	}
+	exposition, err := renderExposition(context.Background(), rmm, []*unstructured.Unstructured{certificate})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for family, value := range map[string]string{
+		"kube_customresource_certmanager_certificate_ready":                        "1",
+		"kube_customresource_certmanager_certificate_expiration_timestamp_seconds": "1.7672256e+09",
+	} {
+		found := false
+		for _, line := range strings.Split(exposition, "\n") {
+			found = found || (strings.HasPrefix(line, family+"{") && strings.Contains(line, `name="example"`) && strings.HasSuffix(line, " "+value))
+		}
+		if !found {
+			t.Errorf("expected a %s series of %s, got:\n%s", family, value, exposition)
+		}
+	}
+	// Certificates not due for renewal yet have no renewal time, which is left out.
+	if strings.Contains(exposition, "kube_customresource_certmanager_certificate_renewal_timestamp_seconds{") {
+		t.Errorf("expected no renewal time series, got:\n%s", exposition)
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// startupQueue holds the monitors observed through the informers' initial lists, so that they are enqueued by their
+// priorities, rather than in the arbitrary order they were listed in, once all of them have been observed.
+type startupQueue struct {
+	mutex   sync.Mutex
+	pending []metav1.Object
+}
+
+// add defers enqueuing the given monitor until the queue is released.
+func (q *startupQueue) add(obj interface{}) {
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.pending = append(q.pending, object)
+}
+
+// release enqueues the deferred monitors, highest priority first, and by their keys otherwise.
+func (q *startupQueue) release(enqueue func(obj interface{})) {
+	q.mutex.Lock()
+	pending := q.pending
+	q.pending = nil
+	q.mutex.Unlock()
+
+	slices.SortStableFunc(pending, func(a, b metav1.Object) int {
+		if priorityA, priorityB := monitorPriority(a), monitorPriority(b); priorityA != priorityB {
+			return cmp.Compare(priorityB, priorityA)
+		}
+
+		return cmp.Compare(cache.MetaObjectToName(a).String(), cache.MetaObjectToName(b).String())
+	})
+	for _, object := range pending {
+		enqueue(object)
+	}
+}
+
+// monitorPriority returns the priority the given monitor is annotated with, or 0 if it is unset or invalid.
+func monitorPriority(object metav1.Object) int {
+	priority, err := strconv.Atoi(object.GetAnnotations()[v1alpha1.PriorityAnnotation])
+	if err != nil {
+		return 0
+	}
+
+	return priority
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStartupQueue(t *testing.T) {
+	t.Parallel()
+	monitor := func(namespace, name, priority string) *v1alpha1.ResourceMetricsMonitor {
+		resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+		if priority != "" {
+			resource.Annotations = map[string]string{v1alpha1.PriorityAnnotation: priority}
+		}
+
+		return resource
+	}
+	queue := &startupQueue{}
+	for _, resource := range []*v1alpha1.ResourceMetricsMonitor{
+		monitor("foo", "unimportant", "-1"),
+		monitor("foo", "b", ""),
+		monitor("bar", "invalid", "high"),
+		monitor("foo", "critical", "100"),
+		monitor("foo", "a", "0"),
+		monitor("bar", "important", "10"),
+	} {
+		queue.add(resource)
+	}
+
+	var got []string
+	queue.release(func(obj interface{}) {
+		got = append(got, obj.(metav1.Object).GetNamespace()+"/"+obj.(metav1.Object).GetName())
+	})
+	expected := []string{"foo/critical", "bar/important", "bar/invalid", "foo/a", "foo/b", "foo/unimportant"}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("unexpected order (-want +got):\n%s", diff)
+	}
+
+	// Releasing again enqueues nothing.
+	queue.release(func(interface{}) { t.Error("expected no monitors to be enqueued") })
+}
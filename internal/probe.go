@@ -87,13 +87,16 @@ func (l livez) probe(ctx context.Context, logger klog.Logger, client kubernetes.
 type readyz struct {
 	source   string
 	asString string
+	// gates hold the checks that must pass, besides the Kubernetes API's readiness, for the probe to succeed.
+	gates []func() error
 }
 
-// newReadyz returns a new readyz probe.
-func newReadyz(source string) probe {
+// newReadyz returns a new readyz probe, gated by the given checks.
+func newReadyz(source string, gates ...func() error) probe {
 	return readyz{
 		source:   source,
 		asString: "/readyz",
+		gates:    gates,
 	}
 }
 
@@ -106,7 +109,23 @@ func (r readyz) text() string {
 }
 
 func (r readyz) probe(ctx context.Context, logger klog.Logger, client kubernetes.Interface) http.Handler {
-	return genericProbe(ctx, r, logger, client)
+	next := genericProbe(ctx, r, logger, client)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		for _, gate := range r.gates {
+			if gateErr := gate(); gateErr != nil {
+				logger.V(1).Info("Readiness gate failed", "reason", gateErr.Error(), "probeType", r.text(), "source", r.server())
+				w.WriteHeader(http.StatusServiceUnavailable)
+				n, err := w.Write([]byte(http.StatusText(http.StatusServiceUnavailable)))
+				if err != nil {
+					logger.Error(err, fmt.Sprintf("error writing response after %d bytes", n), "probeType", r.text(), "source", r.server())
+				}
+
+				return
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
 }
 
 // genericProbe returns an http.Handler that delegates probes to the Kubernetes API.
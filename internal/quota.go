@@ -0,0 +1,55 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// checkQuota returns an error if building the given configuration for the monitor with the given key would exceed the
+// given number of monitors, or stores, its namespace may build, with 0 standing for no limit. Cluster-scoped monitors,
+// as well as the monitor's own stores, which are rebuilt, are not accounted for.
+func checkQuota(stores *sync.Map, key string, c configuration, maxMonitors, maxStores int) error {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil || namespace == "" || (maxMonitors <= 0 && maxStores <= 0) {
+		return nil
+	}
+	monitors, namespaceStores := 0, 0
+	stores.Range(func(otherKey, value any) bool {
+		otherKeyString, _ := otherKey.(string)
+		otherNamespace, _, err := cache.SplitMetaNamespaceKey(otherKeyString)
+		if err != nil || otherNamespace != namespace || otherKeyString == key {
+			return true
+		}
+		builtStores, _ := value.([]*StoreType)
+		monitors++
+		namespaceStores += len(builtStores)
+
+		return true
+	})
+	if maxMonitors > 0 && monitors+1 > maxMonitors {
+		return fmt.Errorf("namespace %q already has %d monitor(s) built, at most %d are allowed (see --%s)", namespace, monitors, maxMonitors, monitorsQuotaFlagName)
+	}
+	if maxStores > 0 && namespaceStores+len(c.Stores) > maxStores {
+		return fmt.Errorf("namespace %q already has %d store(s) built, and %d more would exceed the %d allowed (see --%s)", namespace, namespaceStores, len(c.Stores), maxStores, storesQuotaFlagName)
+	}
+
+	return nil
+}
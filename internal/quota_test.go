@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCheckQuota(t *testing.T) {
+	t.Parallel()
+	stores := &sync.Map{}
+	stores.Store("foo/bar", []*StoreType{{}, {}})
+	stores.Store("foo/baz", []*StoreType{{}})
+	stores.Store("qux/bar", []*StoreType{{}, {}, {}})
+	stores.Store("cluster", []*StoreType{{}, {}, {}})
+	twoStores := configuration{Stores: []*StoreType{{}, {}}}
+
+	tests := []struct {
+		name        string
+		key         string
+		maxMonitors int
+		maxStores   int
+		wantErr     bool
+	}{
+		{name: "no quota", key: "foo/new", wantErr: false},
+		{name: "monitors within quota", key: "foo/new", maxMonitors: 3, wantErr: false},
+		{name: "monitors exceeding quota", key: "foo/new", maxMonitors: 2, wantErr: true},
+		{name: "rebuilt monitor within quota", key: "foo/bar", maxMonitors: 2, wantErr: false},
+		{name: "stores within quota", key: "foo/new", maxStores: 5, wantErr: false},
+		{name: "stores exceeding quota", key: "foo/new", maxStores: 4, wantErr: true},
+		{name: "rebuilt stores within quota", key: "foo/bar", maxStores: 3, wantErr: false},
+		{name: "cluster-scoped monitor", key: "new", maxMonitors: 1, maxStores: 1, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := checkQuota(stores, tt.key, twoStores, tt.maxMonitors, tt.maxStores); (err != nil) != tt.wantErr {
+				t.Errorf("expected error: %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
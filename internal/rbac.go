@@ -0,0 +1,221 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/rexagod/resource-state-metrics/internal/version"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+const rbacSubcommandName = "rbac"
+
+// rbacTargetVerbs are the verbs the reflectors backing stores need on their targets.
+var rbacTargetVerbs = []string{"list", "watch"}
+
+// rbacCRDVerbs are the verbs stores need on their targets' CRDs, which they watch until established, and get to
+// generate families off.
+var rbacCRDVerbs = []string{"get", "list", "watch"}
+
+// rbac prints the least-privileged roles each of the monitors in the given files needs for its stores, for platform
+// teams to review and bind, or for the install command to bind to the controller.
+func rbac(_ context.Context, args []string) error {
+	flags := flag.NewFlagSet(rbacSubcommandName, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] <files...>\n", rbacSubcommandName)
+		flags.PrintDefaults()
+	}
+	namespacedStores := flags.Bool(namespacedStoresFlagName, false, "Generate Roles in the monitors' namespaces, for controllers scoping stores to them, unless the monitors are annotated cluster-scoped. CRD access is granted through ClusterRoles regardless.")
+	serviceAccount := flags.String("service-account", "", "Service account to bind the generated roles to, as <namespace>/<name>, e.g., the controller's, or the one stores impersonate. Defaults to none, i.e., no bindings are generated.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+
+		return errors.New("at least one file is required")
+	}
+	var subject *rbacv1.Subject
+	if *serviceAccount != "" {
+		namespace, name, ok := strings.Cut(*serviceAccount, "/")
+		if !ok || namespace == "" || name == "" {
+			return fmt.Errorf("invalid -service-account %q: expected <namespace>/<name>", *serviceAccount)
+		}
+		subject = &rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name}
+	}
+
+	return rbacFiles(os.Stdout, *namespacedStores, subject, flags.Args()...)
+}
+
+// rbacFiles writes the roles the monitors in the given files need, preceded by the notes on what they do not cover.
+func rbacFiles(w io.Writer, namespacedStores bool, subject *rbacv1.Subject, paths ...string) error {
+	for _, path := range paths {
+		monitors, err := decodeMonitors(path)
+		if err != nil {
+			return err
+		}
+		for _, rmm := range monitors {
+			roles, notes, err := monitorRoles(rmm, namespacedStores, subject)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %w", path, klog.KObj(rmm), err)
+			}
+			for _, note := range notes {
+				if _, err = fmt.Fprintf(w, "# %s: %s: %s\n", path, klog.KObj(rmm), note); err != nil {
+					return fmt.Errorf("error writing notes: %w", err)
+				}
+			}
+			converted := make([]*unstructured.Unstructured, 0, len(roles))
+			for _, role := range roles {
+				u, err := toInstallUnstructured(role)
+				if err != nil {
+					return err
+				}
+				converted = append(converted, u)
+			}
+			if err = writeInstallManifests(w, converted); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeMonitors decodes the (Cluster)ResourceMetricsMonitors in the given file, which may not hold any other objects.
+func decodeMonitors(path string) ([]*v1alpha1.ResourceMetricsMonitor, error) {
+	objects, err := decodeManifests(path)
+	if err != nil {
+		return nil, err
+	}
+	monitors := make([]*v1alpha1.ResourceMetricsMonitor, 0, len(objects))
+	for _, object := range objects {
+		if gvk := object.GroupVersionKind(); gvk != v1alpha1.SchemeGroupVersion.WithKind("ResourceMetricsMonitor") &&
+			gvk != v1alpha1.SchemeGroupVersion.WithKind("ClusterResourceMetricsMonitor") {
+			return nil, fmt.Errorf("%s: %s: expected a (Cluster)ResourceMetricsMonitor, got %s", path, klog.KObj(object), gvk)
+		}
+		rmm := &v1alpha1.ResourceMetricsMonitor{}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(object.Object, rmm, true); err != nil {
+			return nil, fmt.Errorf("%s: %s: error decoding ResourceMetricsMonitor: %w", path, klog.KObj(object), err)
+		}
+		monitors = append(monitors, rmm)
+	}
+
+	return monitors, nil
+}
+
+// monitorRoles returns the roles, and their bindings to the given subject, if any, the given monitor's stores need to
+// list and watch their targets, along with notes on the targets they do not cover. The targets are granted through a
+// Role in the monitor's namespace if stores are scoped to it, or a ClusterRole otherwise. Their CRDs, being
+// cluster-scoped, are granted through a ClusterRole either way.
+func monitorRoles(rmm *v1alpha1.ResourceMetricsMonitor, namespacedStores bool, subject *rbacv1.Subject) ([]runtime.Object, []string, error) {
+	c := newConfigurer(nil, rmm, 0, 0, nil)
+	if err := c.parseMonitor(); err != nil {
+		return nil, nil, err
+	}
+
+	var notes []string
+	targets := map[string]sets.Set[string]{}
+	crds := sets.New[string]()
+	allCRDs := false
+	for i, store := range c.configuration.Stores {
+		if store.Credentials != nil {
+			notes = append(notes, fmt.Sprintf("stores[%d]: lists and watches its targets with its own credentials, which its rules are to be bound to instead", i))
+		}
+		if store.Selectors.CRD != "" {
+			notes = append(notes, fmt.Sprintf("stores[%d]: the targets of the CRDs matching %q cannot be known ahead of time, and are not covered", i, store.Selectors.CRD))
+			allCRDs = true
+
+			continue
+		}
+		for _, target := range sourceGVKRs(store) {
+			resource := target.GroupVersionResource.GroupResource()
+			if resource.Resource == "" {
+				notes = append(notes, fmt.Sprintf("stores[%d]: %s has no resource set, and is not covered", i, target.GroupVersionKind))
+
+				continue
+			}
+			if targets[resource.Group] == nil {
+				targets[resource.Group] = sets.New[string]()
+			}
+			targets[resource.Group].Insert(resource.Resource)
+			if !isNativeGroup(resource.Group) {
+				crds.Insert(resource.String())
+			}
+		}
+	}
+
+	var targetRules []rbacv1.PolicyRule
+	for _, group := range sets.List(sets.KeySet(targets)) {
+		targetRules = append(targetRules, rbacv1.PolicyRule{APIGroups: []string{group}, Resources: sets.List(targets[group]), Verbs: rbacTargetVerbs})
+	}
+	var crdRules []rbacv1.PolicyRule
+	switch {
+	case allCRDs:
+		crdRules = append(crdRules, rbacv1.PolicyRule{APIGroups: []string{crdGVKR.GroupVersionResource.Group}, Resources: []string{crdGVKR.GroupVersionResource.Resource}, Verbs: rbacCRDVerbs})
+	case crds.Len() > 0:
+		crdRules = append(crdRules, rbacv1.PolicyRule{APIGroups: []string{crdGVKR.GroupVersionResource.Group}, Resources: []string{crdGVKR.GroupVersionResource.Resource}, ResourceNames: sets.List(crds), Verbs: rbacCRDVerbs})
+	}
+
+	labels := map[string]string{"app.kubernetes.io/part-of": version.ControllerName.String()}
+	name := version.ControllerName.String() + "-" + rmm.GetName()
+	clusterName := name
+	if rmm.GetNamespace() != "" {
+		clusterName = version.ControllerName.String() + "-" + rmm.GetNamespace() + "-" + rmm.GetName()
+	}
+	var roles []runtime.Object
+	clusterRules := crdRules
+	if namespacedStores && rmm.GetNamespace() != "" && rmm.GetAnnotations()[v1alpha1.ClusterScopedAnnotation] != "true" {
+		if len(targetRules) > 0 {
+			roles = append(roles, &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: rmm.GetNamespace(), Labels: labels}, Rules: targetRules})
+			if subject != nil {
+				roles = append(roles, &rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: rmm.GetNamespace(), Labels: labels},
+					RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+					Subjects:   []rbacv1.Subject{*subject},
+				})
+			}
+		}
+	} else {
+		clusterRules = slices.Concat(targetRules, crdRules)
+	}
+	if len(clusterRules) > 0 {
+		roles = append(roles, &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: clusterName, Labels: labels}, Rules: clusterRules})
+		if subject != nil {
+			roles = append(roles, &rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: clusterName, Labels: labels},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterName},
+				Subjects:   []rbacv1.Subject{*subject},
+			})
+		}
+	}
+
+	return roles, notes, nil
+}
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestMonitorRoles(t *testing.T) {
+	t.Parallel()
+	const configuration = `stores:
+  - version: "v1"
+    kind: "Pod"
+    resource: "pods"
+    families:
+      - name: "pod_info"
+        metrics:
+          - value: "1"
+  - group: "contoso.com"
+    version: "v1"
+    kind: "Bar"
+    resource: "bars"
+    sources:
+      - version: "v1alpha1"
+    families:
+      - name: "bar_info"
+        metrics:
+          - value: "1"
+  - group: "apps"
+    version: "v1"
+    kind: "Deployment"
+    resource: "deployments"
+    families:
+      - name: "deployment_info"
+        metrics:
+          - value: "1"
+  - selectors:
+      crd: "monitored=true"
+    families:
+      - name: "selected_info"
+        metrics:
+          - value: "1"
+`
+	targetRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list", "watch"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, Verbs: []string{"list", "watch"}},
+		{APIGroups: []string{"contoso.com"}, Resources: []string{"bars"}, Verbs: []string{"list", "watch"}},
+	}
+	crdRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{"apiextensions.k8s.io"}, Resources: []string{"customresourcedefinitions"}, Verbs: []string{"get", "list", "watch"}},
+	}
+	labels := map[string]string{"app.kubernetes.io/part-of": "resource-state-metrics"}
+	subject := &rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "tenant", Name: "reader"}
+	tests := []struct {
+		name             string
+		configuration    string
+		namespacedStores bool
+		subject          *rbacv1.Subject
+		expectedRoles    []runtime.Object
+		expectedNotes    []string
+	}{
+		{
+			name:          "cluster-wide stores",
+			configuration: configuration,
+			expectedRoles: []runtime.Object{
+				&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "resource-state-metrics-tenant-monitor", Labels: labels}, Rules: append(append([]rbacv1.PolicyRule{}, targetRules...), crdRules...)},
+			},
+			expectedNotes: []string{`stores[3]: the targets of the CRDs matching "monitored=true" cannot be known ahead of time, and are not covered`},
+		},
+		{
+			name:             "namespaced stores, bound to a service account",
+			configuration:    configuration,
+			namespacedStores: true,
+			subject:          subject,
+			expectedRoles: []runtime.Object{
+				&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "resource-state-metrics-monitor", Namespace: "tenant", Labels: labels}, Rules: targetRules},
+				&rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "resource-state-metrics-monitor", Namespace: "tenant", Labels: labels},
+					RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "resource-state-metrics-monitor"},
+					Subjects:   []rbacv1.Subject{*subject},
+				},
+				&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "resource-state-metrics-tenant-monitor", Labels: labels}, Rules: crdRules},
+				&rbacv1.ClusterRoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "resource-state-metrics-tenant-monitor", Labels: labels},
+					RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "resource-state-metrics-tenant-monitor"},
+					Subjects:   []rbacv1.Subject{*subject},
+				},
+			},
+			expectedNotes: []string{`stores[3]: the targets of the CRDs matching "monitored=true" cannot be known ahead of time, and are not covered`},
+		},
+		{
+			name: "custom resource CRDs only",
+			configuration: `stores:
+  - group: "contoso.com"
+    version: "v1"
+    kind: "Bar"
+    resource: "bars"
+    credentials:
+      serviceAccount: "reader"
+    families:
+      - name: "bar_info"
+        metrics:
+          - value: "1"
+`,
+			namespacedStores: true,
+			expectedRoles: []runtime.Object{
+				&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "resource-state-metrics-monitor", Namespace: "tenant", Labels: labels}, Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{"contoso.com"}, Resources: []string{"bars"}, Verbs: []string{"list", "watch"}},
+				}},
+				&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "resource-state-metrics-tenant-monitor", Labels: labels}, Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{"apiextensions.k8s.io"}, Resources: []string{"customresourcedefinitions"}, ResourceNames: []string{"bars.contoso.com"}, Verbs: []string{"get", "list", "watch"}},
+				}},
+			},
+			expectedNotes: []string{"stores[0]: lists and watches its targets with its own credentials, which its rules are to be bound to instead"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rmm := &v1alpha1.ResourceMetricsMonitor{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "monitor"},
+				Spec:       v1alpha1.ResourceMetricsMonitorSpec{Configuration: tt.configuration},
+			}
+			roles, notes, err := monitorRoles(rmm, tt.namespacedStores, tt.subject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.expectedRoles, roles); diff != "" {
+				t.Errorf("unexpected roles (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedNotes, notes); diff != "" {
+				t.Errorf("unexpected notes (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
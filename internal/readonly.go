@@ -0,0 +1,67 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// In read-only mode, the controller never writes to the cluster, e.g., when running with view-only credentials. The
+// monitors' labels and status are left as is, events are only logged, and ServiceMonitors are not generated, with the
+// monitors' conditions being reported through the telemetry registry instead.
+
+// readOnlyMonitors adapts monitorInterface so that updates are dropped, reporting the monitors' conditions instead.
+type readOnlyMonitors struct {
+	monitorInterface
+	// conditions reports the status of the monitors' conditions, by their namespaces, names, and types.
+	conditions *prometheus.GaugeVec
+	logger     klog.Logger
+}
+
+// Ensure readOnlyMonitors implements monitorInterface.
+var _ monitorInterface = readOnlyMonitors{}
+
+// Update returns the given resource as is, without updating it.
+func (m readOnlyMonitors) Update(_ context.Context, resource *v1alpha1.ResourceMetricsMonitor, _ metav1.UpdateOptions) (*v1alpha1.ResourceMetricsMonitor, error) {
+	return resource, nil
+}
+
+// UpdateStatus reports the given resource's conditions, and returns it as is, without updating its status.
+func (m readOnlyMonitors) UpdateStatus(_ context.Context, resource *v1alpha1.ResourceMetricsMonitor, _ metav1.UpdateOptions) (*v1alpha1.ResourceMetricsMonitor, error) {
+	for _, condition := range resource.Status.Conditions {
+		value := 0.
+		if condition.Status == metav1.ConditionTrue {
+			value = 1
+		}
+		if m.conditions != nil {
+			m.conditions.WithLabelValues(resource.GetNamespace(), resource.GetName(), condition.Type).Set(value)
+		}
+		m.logger.V(1).Info("Condition not written in read-only mode", "resource", klog.KObj(resource), "type", condition.Type, "status", condition.Status, "reason", condition.Reason, "message", condition.Message)
+	}
+
+	return resource, nil
+}
+
+// readOnly reports whether the controller may not write to the cluster.
+func (c *Controller) readOnly() bool {
+	return c.options != nil && c.options.ReadOnly != nil && *c.options.ReadOnly
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestController_monitors_readOnly(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	conditions := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "monitor_condition", Help: "help"}, []string{"namespace", "name", "type"})
+	c := &Controller{
+		rsmClientset: fake.NewSimpleClientset(&v1alpha1.ResourceMetricsMonitor{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		}),
+		options: &Options{ReadOnly: ptr.To(true)},
+		metrics: metrics{monitorConditions: conditions},
+	}
+
+	resource, err := c.monitors("default").Get(ctx, "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resource.SetLabels(map[string]string{"foo": "bar"})
+	if _, err = c.monitors("default").Update(ctx, resource, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	resource.Status.Set(resource, metav1.Condition{Type: v1alpha1.ConditionType[v1alpha1.ConditionTypeFailed], Status: metav1.ConditionTrue})
+	if _, err = c.monitors("default").UpdateStatus(ctx, resource, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.rsmClientset.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors("default").Get(ctx, "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.GetLabels()) != 0 || len(got.Status.Conditions) != 0 {
+		t.Errorf("expected the monitor to be left as is, got labels %v and conditions %v", got.GetLabels(), got.Status.Conditions)
+	}
+	expected := `# HELP monitor_condition help
+# TYPE monitor_condition gauge
+monitor_condition{name="foo",namespace="default",type="Failed"} 1
+`
+	if err = testutil.CollectAndCompare(conditions, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// referencedFields holds the fields of the objects a store's families reference, so that objects updated in none of
+// them, e.g., in noisy status fields no family exposes, are not rendered again.
+type referencedFields [][]string
+
+// referencedFieldsOf returns the fields the given compiled families reference, or nil if they cannot all be told apart.
+// That is only the case for families resolved through the unstructured resolver, whose queries are paths, and that are
+// neither filtered, nor templated, nor rendered relative to the time they are rendered at.
+func referencedFieldsOf(families []*FamilyType) referencedFields {
+	// The objects' group, version, kind, and namespace label, or key, all of their series.
+	fields := referencedFields{{"apiVersion"}, {"kind"}, {"metadata", "namespace"}}
+	for _, family := range families {
+		if family.Filter != "" || family.sinceTimestamp {
+			return nil
+		}
+		for _, metric := range family.Metrics {
+			resolverType := metric.Resolver
+			if resolverType == ResolverTypeNone {
+				resolverType = family.Resolver
+			}
+			if ensureResolver(resolverType) != ResolverTypeUnstructured {
+				return nil
+			}
+			queries := append([]string{metric.Value}, metric.LabelValues...)
+			if metric.Histogram != nil {
+				queries = append(queries, metric.Histogram.Buckets, metric.Histogram.Count)
+			}
+			if metric.Regex != nil {
+				queries = append(queries, metric.Regex.Query)
+			}
+			if metric.EachMap != nil {
+				queries = append(queries, metric.EachMap.Path)
+			}
+			// Inner levels, and the value of expanded metrics, are relative to the outermost level's elements.
+			if len(metric.Expand) > 0 {
+				queries = append(queries, metric.Expand[0].Path)
+			}
+			if metric.Exemplar != nil {
+				queries = append(queries, metric.Exemplar.TraceID, metric.Exemplar.SpanID)
+			}
+			for _, query := range queries {
+				if isLabelTemplate(query) {
+					return nil
+				}
+				if query != "" {
+					fields = append(fields, queryField(query))
+				}
+			}
+		}
+	}
+
+	return fields
+}
+
+// queryField returns the field the given unstructured query resolves, i.e., the array it expands, for wildcard paths.
+func queryField(query string) []string {
+	path, _, _ := strings.Cut(query, "[*]")
+
+	return strings.Split(strings.Trim(path, "."), ".")
+}
+
+// digest returns a digest of the given object's referenced fields, and whether it could be computed.
+func (r referencedFields) digest(object *unstructured.Unstructured) (uint64, bool) {
+	if r == nil {
+		return 0, false
+	}
+	hash := fnv.New64a()
+	encoder := json.NewEncoder(hash)
+	for _, field := range r {
+		value, found, _ := unstructured.NestedFieldNoCopy(object.Object, field...)
+		// Tell unset fields apart from null ones.
+		if err := encoder.Encode([]interface{}{found, value}); err != nil {
+			return 0, false
+		}
+	}
+
+	return hash.Sum64(), true
+}
+
+// contentDigest returns a digest of the given object's whole content, and whether it could be computed.
+func contentDigest(object *unstructured.Unstructured) (uint64, bool) {
+	hash := fnv.New64a()
+	// Maps are encoded in their keys' order.
+	if err := json.NewEncoder(hash).Encode(object.Object); err != nil {
+		return 0, false
+	}
+
+	return hash.Sum64(), true
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+func TestReferencedFieldsOf(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		family   *FamilyType
+		expected []string
+	}{
+		{
+			name: "unstructured",
+			family: &FamilyType{
+				Metrics: []*MetricType{{Value: "spec.replicas", LabelValues: []string{"spec.containers[*].name"}}},
+			},
+			expected: []string{"apiVersion", "kind", "metadata.namespace", "spec.replicas", "spec.containers"},
+		},
+		{
+			name: "CEL",
+			family: &FamilyType{
+				Resolver: ResolverTypeCEL,
+				Metrics:  []*MetricType{{Value: "o.spec.replicas"}},
+			},
+		},
+		{
+			name: "templated label",
+			family: &FamilyType{
+				Metrics: []*MetricType{{Value: "spec.replicas", LabelValues: []string{"{{ .metadata.name }}"}}},
+			},
+		},
+		{
+			name: "filtered",
+			family: &FamilyType{
+				Filter:  "o.spec.replicas > 0",
+				Metrics: []*MetricType{{Value: "spec.replicas"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fields := referencedFieldsOf([]*FamilyType{tt.family})
+			var got []string
+			for _, field := range fields {
+				got = append(got, strings.Join(field, "."))
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStoreType_Update_unchanged(t *testing.T) {
+	t.Parallel()
+	s := newStore(klog.Background(), []string{"# HELP kube_customresource_replicas"}, []*FamilyType{
+		{
+			Name:    "replicas",
+			Metrics: []*MetricType{{Value: "spec.replicas"}},
+		},
+	}, ResolverTypeUnstructured, nil, nil, 0, 0)
+	object := newSyntheticObjects(2)[1]
+	if err := s.Add(object); err != nil {
+		t.Fatal(err)
+	}
+	// Stand in for the rendered series, to tell whether the object is rendered again.
+	s.setMetrics(object.GetUID(), []string{"sentinel"})
+
+	// Updates to fields no family references do not render the object again.
+	updated := object.DeepCopy()
+	if err := unstructured.SetNestedField(updated.Object, "True", "status", "ready"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(updated); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.metrics.get(object.GetUID()); !slices.Equal(got, []string{"sentinel"}) {
+		t.Errorf("expected the object not to be rendered again, got %q", got)
+	}
+
+	// Updates to referenced fields do.
+	if err := unstructured.SetNestedField(updated.Object, int64(3), "spec", "replicas"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Update(updated); err != nil {
+		t.Fatal(err)
+	}
+	expected := "kube_customresource_replicas{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 3\n"
+	if got, _ := s.metrics.get(object.GetUID()); len(got) != 1 || got[0] != expected {
+		t.Errorf("expected the object to be rendered again as %q, got %q", expected, got)
+	}
+}
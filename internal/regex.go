@@ -0,0 +1,113 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+)
+
+// regexPatterns caches the compiled extraction patterns, by their text, as they are matched for each object.
+var regexPatterns sync.Map
+
+// RegexType extracts labels, and optionally the value, of a metric off a resolved field, e.g., the major and minor
+// versions off "v1.28.3-gke.100".
+type RegexType struct {
+	// Query is resolved like the metric's label values, and defaults to the metric's value.
+	Query string `yaml:"query,omitempty"`
+	// Pattern is matched against the resolved query, and its named capture groups are exposed as labels, named after
+	// them. Objects it does not match produce no samples.
+	Pattern string `yaml:"pattern"`
+	// Value, if set, names the capture group exposed as the metric's value, instead of as a label.
+	Value string `yaml:"value,omitempty"`
+}
+
+// compile returns the compiled pattern.
+func (r *RegexType) compile() (*regexp.Regexp, error) {
+	if cached, ok := regexPatterns.Load(r.Pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	compiled, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling pattern %q: %w", r.Pattern, err)
+	}
+	regexPatterns.Store(r.Pattern, compiled)
+
+	return compiled, nil
+}
+
+// validate checks that the pattern compiles, and captures the named groups.
+func (r *RegexType) validate() error {
+	if r == nil {
+		return nil
+	}
+	compiled, err := r.compile()
+	if err != nil {
+		return err
+	}
+	names := slices.DeleteFunc(compiled.SubexpNames(), func(name string) bool { return name == "" })
+	if len(names) == 0 {
+		return fmt.Errorf("pattern %q has no named capture groups", r.Pattern)
+	}
+	if r.Value != "" && !slices.Contains(names, r.Value) {
+		return fmt.Errorf("value: pattern %q has no capture group named %q", r.Pattern, r.Value)
+	}
+
+	return nil
+}
+
+// extract matches the pattern against the resolved query, and returns the given labels and value, along with the
+// ones captured.
+func (r *RegexType) extract(resolverInstance resolver.Resolver, obj map[string]interface{}, valueQuery string, keys, values []string, value string) ([]string, []string, string, error) {
+	if r == nil {
+		return keys, values, value, nil
+	}
+	compiled, err := r.compile()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	query := r.Query
+	if query == "" {
+		query = valueQuery
+	}
+	resolved, found := resolverInstance.Resolve(query, obj)[query]
+	if !found {
+		return nil, nil, "", fmt.Errorf("error resolving regex query %q", query)
+	}
+	matches := compiled.FindStringSubmatch(resolved)
+	if matches == nil {
+		return nil, nil, "", fmt.Errorf("error matching pattern %q against %q", r.Pattern, resolved)
+	}
+	keys, values = slices.Clone(keys), slices.Clone(values)
+	for i, name := range compiled.SubexpNames() {
+		switch name {
+		case "":
+		case r.Value:
+			value = matches[i]
+		default:
+			keys = append(keys, sanitizeKey(name))
+			values = append(values, matches[i])
+		}
+	}
+	sortLabels(keys, values)
+
+	return keys, values, value, nil
+}
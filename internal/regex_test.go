@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/klog/v2"
+)
+
+func TestRegexType_validate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		regex   *RegexType
+		wantErr bool
+	}{
+		{regex: nil},
+		{regex: &RegexType{Pattern: `^v(?P<major>\d+)\.(?P<minor>\d+)`}},
+		{regex: &RegexType{Pattern: `^v(?P<major>\d+)\.(?P<minor>\d+)`, Value: "minor"}},
+		{regex: &RegexType{Pattern: `^v(?P<major>\d+)`, Value: "minor"}, wantErr: true},
+		{regex: &RegexType{Pattern: `^v(\d+)`}, wantErr: true},
+		{regex: &RegexType{Pattern: `^v(?P<major>\d+`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.regex.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: expected error: %t, got %v", tt.regex, tt.wantErr, err)
+		}
+	}
+}
+
+func TestFamilyType_regex(t *testing.T) {
+	t.Parallel()
+	object := newSyntheticObjects(1)[0]
+	object.Object["status"].(map[string]interface{})["version"] = "v1.28.3-gke.100"
+	family := &FamilyType{
+		logger: klog.Background(),
+		Name:   "version",
+		Metrics: []*MetricType{
+			{
+				Value: "1",
+				Regex: &RegexType{Query: "status.version", Pattern: `^v(?P<major>\d+)\.(?P<minor>\d+)`},
+			},
+			{
+				LabelKeys:   []string{"part"},
+				LabelValues: []string{"patch"},
+				Value:       "status.version",
+				Regex:       &RegexType{Pattern: `^v\d+\.\d+\.(?P<patch>\d+)`, Value: "patch"},
+			},
+			{
+				Value: "1",
+				Regex: &RegexType{Query: "status.version", Pattern: `^(?P<major>\d+)`},
+			},
+		},
+	}
+
+	expected := "kube_customresource_version{major=\"1\",minor=\"28\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 1\n" +
+		"kube_customresource_version{part=\"patch\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 3\n"
+	if diff := cmp.Diff(expected, family.buildMetricString(object)); diff != "" {
+		t.Errorf("unexpected exposition (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// extensionAPIServerAuthentication is the ConfigMap, in kube-system, the API server publishes the request header
+	// configuration of the aggregation layer in.
+	extensionAPIServerAuthentication = "extension-apiserver-authentication"
+
+	requestHeaderClientCAKey        = "requestheader-client-ca-file"
+	requestHeaderAllowedNamesKey    = "requestheader-allowed-names"
+	requestHeaderUsernameHeadersKey = "requestheader-username-headers"
+	requestHeaderGroupHeadersKey    = "requestheader-group-headers"
+)
+
+// requestHeaderAuthenticator authenticates the requests the aggregator proxies to an APIService on behalf of users, as
+// the API server's request header configuration prescribes, i.e., by the aggregator's client certificate, signed by
+// the request header CA, and issued to one of the allowed names, if any, and the user, and groups, set in the request
+// headers, which are only trusted off such clients.
+type requestHeaderAuthenticator struct {
+	clientCAs                     *x509.CertPool
+	allowedNames                  sets.Set[string]
+	usernameHeaders, groupHeaders []string
+}
+
+// newRequestHeaderAuthenticator returns a requestHeaderAuthenticator following the request header configuration the
+// API server published.
+func newRequestHeaderAuthenticator(ctx context.Context, kubeClientset kubernetes.Interface) (*requestHeaderAuthenticator, error) {
+	configMap, err := kubeClientset.CoreV1().ConfigMaps(metav1.NamespaceSystem).Get(ctx, extensionAPIServerAuthentication, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting the request header configuration: %w", err)
+	}
+	clientCA := configMap.Data[requestHeaderClientCAKey]
+	if clientCA == "" {
+		return nil, fmt.Errorf("no %q in %s/%s, is the aggregation layer enabled?", requestHeaderClientCAKey, metav1.NamespaceSystem, extensionAPIServerAuthentication)
+	}
+	a := &requestHeaderAuthenticator{clientCAs: x509.NewCertPool()}
+	if !a.clientCAs.AppendCertsFromPEM([]byte(clientCA)) {
+		return nil, fmt.Errorf("no certificates in %q", requestHeaderClientCAKey)
+	}
+	var allowedNames []string
+	for key, values := range map[string]*[]string{
+		requestHeaderAllowedNamesKey:    &allowedNames,
+		requestHeaderUsernameHeadersKey: &a.usernameHeaders,
+		requestHeaderGroupHeadersKey:    &a.groupHeaders,
+	} {
+		if raw := configMap.Data[key]; raw != "" {
+			if err = json.Unmarshal([]byte(raw), values); err != nil {
+				return nil, fmt.Errorf("error parsing %q: %w", key, err)
+			}
+		}
+	}
+	if len(a.usernameHeaders) == 0 {
+		return nil, fmt.Errorf("no %q in %s/%s", requestHeaderUsernameHeadersKey, metav1.NamespaceSystem, extensionAPIServerAuthentication)
+	}
+	a.allowedNames = sets.New(allowedNames...)
+
+	return a, nil
+}
+
+// authenticate returns the user, and groups, the given request was proxied on behalf of, or an error if it was not
+// proxied by the aggregator. The request's client certificate must have been verified against the client CAs.
+func (a *requestHeaderAuthenticator) authenticate(r *http.Request) (string, []string, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.PeerCertificates) == 0 {
+		return "", nil, errors.New("no verified client certificate")
+	}
+	if name := r.TLS.PeerCertificates[0].Subject.CommonName; a.allowedNames.Len() > 0 && !a.allowedNames.Has(name) {
+		return "", nil, fmt.Errorf("client certificate issued to %q, which is not allowed to proxy requests", name)
+	}
+	var user string
+	for _, header := range a.usernameHeaders {
+		if user = strings.TrimSpace(r.Header.Get(header)); user != "" {
+			break
+		}
+	}
+	if user == "" {
+		return "", nil, errors.New("no user in the request headers")
+	}
+	var groups []string
+	for _, header := range a.groupHeaders {
+		groups = append(groups, r.Header.Values(header)...)
+	}
+
+	return user, groups, nil
+}
+
+// accessReviewer authorizes users' requests through SubjectAccessReviews, as the API server would, so APIServices are
+// subject to the cluster's RBAC.
+type accessReviewer struct {
+	kubeClientset kubernetes.Interface
+}
+
+// allowed reports whether the given user, in the given groups, is allowed the given resource, or non-resource,
+// attributes.
+func (a *accessReviewer) allowed(ctx context.Context, user string, groups []string, resource *authorizationv1.ResourceAttributes, nonResource *authorizationv1.NonResourceAttributes) (bool, error) {
+	review, err := a.kubeClientset.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:                  user,
+			Groups:                groups,
+			ResourceAttributes:    resource,
+			NonResourceAttributes: nonResource,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("error reviewing access: %w", err)
+	}
+
+	return review.Status.Allowed, nil
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// resolutionCache caches the resolutions of a store's queries, per object, for as long as the object's resource version
+// stays the same, so relists and resyncs delivering unchanged objects skip re-evaluating them.
+type resolutionCache struct {
+	mutex   sync.Mutex
+	objects map[types.UID]*objectResolutions
+	// lookups counts the cache's lookups by result, i.e., hit or miss, if set.
+	lookups *prometheus.CounterVec
+}
+
+// objectResolutions holds the resolutions of an object's queries, as of its resource version.
+type objectResolutions struct {
+	resourceVersion string
+	results         map[resolutionKey]map[string]string
+}
+
+// resolutionKey identifies a query, along with the resolver it is resolved by.
+type resolutionKey struct {
+	resolver ResolverType
+	query    string
+}
+
+// newResolutionCache returns an empty resolution cache, counting its lookups with the given counter, if set.
+func newResolutionCache(lookups *prometheus.CounterVec) *resolutionCache {
+	return &resolutionCache{
+		objects: map[types.UID]*objectResolutions{},
+		lookups: lookups,
+	}
+}
+
+// wrap returns the given resolver, resolving through the cache. A nil cache returns the resolver as is.
+func (c *resolutionCache) wrap(resolverInstance resolver.Resolver, resolverType ResolverType) resolver.Resolver {
+	if c == nil {
+		return resolverInstance
+	}
+
+	return &cachingResolver{resolver: resolverInstance, resolverType: resolverType, cache: c}
+}
+
+// resolve returns the cached resolution of the given query against the given object, resolving it with the given
+// resolver on a miss. Queries against anything but objects (e.g., elements of their arrays) are not cached.
+func (c *resolutionCache) resolve(resolverInstance resolver.Resolver, resolverType ResolverType, query string, obj map[string]interface{}) map[string]string {
+	uid, _, _ := unstructured.NestedString(obj, "metadata", "uid")
+	resourceVersion, _, _ := unstructured.NestedString(obj, "metadata", "resourceVersion")
+	if uid == "" || resourceVersion == "" {
+		return resolverInstance.Resolve(query, obj)
+	}
+	key := resolutionKey{resolver: resolverType, query: query}
+
+	c.mutex.Lock()
+	resolutions, ok := c.objects[types.UID(uid)]
+	if ok && resolutions.resourceVersion == resourceVersion {
+		if result, ok := resolutions.results[key]; ok {
+			c.mutex.Unlock()
+			c.observe("hit")
+
+			return result
+		}
+	}
+	c.mutex.Unlock()
+	c.observe("miss")
+
+	// Resolve outside the lock, as (CEL) resolutions may take a while.
+	result := resolverInstance.Resolve(query, obj)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	resolutions, ok = c.objects[types.UID(uid)]
+	if !ok || resolutions.resourceVersion != resourceVersion {
+		resolutions = &objectResolutions{resourceVersion: resourceVersion, results: map[resolutionKey]map[string]string{}}
+		c.objects[types.UID(uid)] = resolutions
+	}
+	resolutions.results[key] = result
+
+	return result
+}
+
+// forget drops the resolutions cached for the given object.
+func (c *resolutionCache) forget(uid types.UID) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.objects, uid)
+}
+
+// observe counts a lookup with the given result.
+func (c *resolutionCache) observe(result string) {
+	if c.lookups != nil {
+		c.lookups.WithLabelValues(result).Inc()
+	}
+}
+
+// cachingResolver resolves queries through a resolution cache.
+type cachingResolver struct {
+	resolver     resolver.Resolver
+	resolverType ResolverType
+	cache        *resolutionCache
+}
+
+// cachingResolver implements the Resolver interface.
+var _ resolver.Resolver = &cachingResolver{}
+
+// Resolve resolves the given query against the given object, through the cache.
+func (r *cachingResolver) Resolve(query string, unstructuredObjectMap map[string]interface{}) map[string]string {
+	return r.cache.resolve(r.resolver, r.resolverType, query, unstructuredObjectMap)
+}
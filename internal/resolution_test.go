@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// countingResolver resolves every query to itself, counting the resolutions.
+type countingResolver struct {
+	resolutions int
+}
+
+func (r *countingResolver) Resolve(query string, _ map[string]interface{}) map[string]string {
+	r.resolutions++
+
+	return map[string]string{query: query}
+}
+
+func TestResolutionCache(t *testing.T) {
+	t.Parallel()
+	lookups := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "lookups"}, []string{"result"})
+	c := newResolutionCache(lookups)
+	counting := &countingResolver{}
+	cached := c.wrap(counting, ResolverTypeUnstructured)
+	object := map[string]interface{}{"metadata": map[string]interface{}{"uid": "uid-0", "resourceVersion": "1"}}
+
+	for range 3 {
+		cached.Resolve("spec.replicas", object)
+	}
+	if counting.resolutions != 1 {
+		t.Errorf("expected a single resolution for an unchanged object, got %d", counting.resolutions)
+	}
+
+	// Other resolvers, new resource versions, and forgotten objects are resolved anew.
+	c.wrap(counting, ResolverTypeCEL).Resolve("spec.replicas", object)
+	object["metadata"].(map[string]interface{})["resourceVersion"] = "2"
+	cached.Resolve("spec.replicas", object)
+	c.forget(types.UID("uid-0"))
+	cached.Resolve("spec.replicas", object)
+	if counting.resolutions != 4 {
+		t.Errorf("expected 4 resolutions, got %d", counting.resolutions)
+	}
+
+	// Queries against anything but objects are not cached.
+	element := map[string]interface{}{"name": "foo"}
+	cached.Resolve("name", element)
+	cached.Resolve("name", element)
+	if counting.resolutions != 6 {
+		t.Errorf("expected 6 resolutions, got %d", counting.resolutions)
+	}
+
+	if hits, misses := testutil.ToFloat64(lookups.WithLabelValues("hit")), testutil.ToFloat64(lookups.WithLabelValues("miss")); hits != 2 || misses != 4 {
+		t.Errorf("expected 2 hits and 4 misses, got %v and %v", hits, misses)
+	}
+	if (*resolutionCache)(nil).wrap(counting, ResolverTypeCEL) != counting {
+		t.Error("expected a nil cache to return the resolver as is")
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	runtimemetrics "runtime/metrics"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"k8s.io/klog/v2"
+)
+
+// gcPath is the self server's path to view, and adjust, the runtime's GC settings on.
+const gcPath = "/debug/gc"
+
+// registerRuntimeMetrics registers gauges reporting the runtime settings in effect, as applied through the
+// auto-gomaxprocs and ratio-gomemlimit flags, or adjusted on the gcPath since.
+func registerRuntimeMetrics(registerer prometheus.Registerer, namespace string) {
+	promauto.With(registerer).NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "gomaxprocs",
+		Help:      "The GOMAXPROCS in effect.",
+	}, func() float64 {
+		return float64(runtime.GOMAXPROCS(0))
+	})
+	promauto.With(registerer).NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "gomemlimit_bytes",
+		Help:      "The GOMEMLIMIT in effect, in bytes.",
+	}, func() float64 {
+		return float64(debug.SetMemoryLimit(-1))
+	})
+	promauto.With(registerer).NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "gc_percent",
+		Help:      "The GOGC in effect, or -1 if the GC is disabled.",
+	}, func() float64 {
+		return float64(gcPercent())
+	})
+}
+
+// gcPercentMetric is the runtime metric reporting the GOGC in effect.
+const gcPercentMetric = "/gc/gogc:percent"
+
+// gcPercent returns the GOGC in effect, read off the runtime's metrics, as setting it to read it would race with
+// adjustments.
+func gcPercent() int {
+	sample := []runtimemetrics.Sample{{Name: gcPercentMetric}}
+	runtimemetrics.Read(sample)
+	if sample[0].Value.Kind() != runtimemetrics.KindUint64 {
+		return 100
+	}
+
+	// A disabled GC is reported as -1, wrapped around.
+	return int(int64(sample[0].Value.Uint64())) //nolint:gosec // Wraps around intentionally.
+}
+
+// gcHandler reports the runtime settings in effect, and sets the GOGC to the "percent" form value on PUTs the given
+// authorizer allows. PUTs are rejected if there is no authorizer, as anyone reaching the telemetry server could
+// disable the GC otherwise.
+func gcHandler(logger klog.Logger, authorizer *scrapeAuthorizer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			if authorizer == nil {
+				http.Error(w, "adjusting the GC percent requires scrapes to be authorized", http.StatusForbidden)
+
+				return
+			}
+			if status := authorizer.authorize(r); status != 0 {
+				if status == http.StatusUnauthorized {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				}
+				http.Error(w, http.StatusText(status), status)
+
+				return
+			}
+			percent, err := strconv.Atoi(r.FormValue("percent"))
+			if err != nil || percent < -1 {
+				http.Error(w, fmt.Sprintf("invalid GC percent %q, expected an integer no less than -1", r.FormValue("percent")), http.StatusBadRequest)
+
+				return
+			}
+			previous := debug.SetGCPercent(percent)
+			logger.Info("GC percent adjusted", "previous", previous, "current", percent)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		memoryLimit := strconv.FormatInt(debug.SetMemoryLimit(-1), 10)
+		if debug.SetMemoryLimit(-1) == math.MaxInt64 {
+			memoryLimit = "off"
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = fmt.Fprintf(w, "GOMAXPROCS=%d\nGOMEMLIMIT=%s\nGOGC=%d\n", runtime.GOMAXPROCS(0), memoryLimit, gcPercent())
+	}
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"k8s.io/klog/v2"
+)
+
+// Tests adjusting the process-wide GC percent cannot be run in t.Parallel().
+//
+//nolint:paralleltest
+func TestGCHandler(t *testing.T) {
+	defer debug.SetGCPercent(debug.SetGCPercent(100))
+	handler := gcHandler(klog.Background(), &scrapeAuthorizer{token: []byte("foo")})
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, gcPath, nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "GOGC=100\n") {
+		t.Errorf("unexpected response: %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	// Adjustments are only allowed to authorized clients, and never without an authorizer.
+	for _, tt := range []struct {
+		handler       http.HandlerFunc
+		authorization string
+		expected      int
+	}{
+		{handler: gcHandler(klog.Background(), nil), authorization: "Bearer foo", expected: http.StatusForbidden},
+		{handler: handler, expected: http.StatusUnauthorized},
+		{handler: handler, authorization: "Bearer bar", expected: http.StatusUnauthorized},
+	} {
+		recorder = httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPut, gcPath+"?percent=-1", nil)
+		request.Header.Set("Authorization", tt.authorization)
+		tt.handler(recorder, request)
+		if recorder.Code != tt.expected {
+			t.Errorf("expected %d for authorization %q, got %d", tt.expected, tt.authorization, recorder.Code)
+		}
+	}
+	if got := gcPercent(); got != 100 {
+		t.Errorf("expected the GC percent to remain 100, got %d", got)
+	}
+
+	request := httptest.NewRequest(http.MethodPut, gcPath, strings.NewReader(url.Values{"percent": {"50"}}.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "Bearer foo")
+	recorder = httptest.NewRecorder()
+	handler(recorder, request)
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "GOGC=50\n") {
+		t.Errorf("unexpected response: %d %q", recorder.Code, recorder.Body.String())
+	}
+	if got := gcPercent(); got != 50 {
+		t.Errorf("expected a GC percent of 50, got %d", got)
+	}
+
+	for _, percent := range []string{"", "foo", "-2"} {
+		recorder = httptest.NewRecorder()
+		request = httptest.NewRequest(http.MethodPut, gcPath+"?percent="+url.QueryEscape(percent), nil)
+		request.Header.Set("Authorization", "Bearer foo")
+		handler(recorder, request)
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("expected percent %q to be rejected, got %d", percent, recorder.Code)
+		}
+	}
+	if got := gcPercent(); got != 50 {
+		t.Errorf("expected the GC percent to remain 50, got %d", got)
+	}
+
+	debug.SetGCPercent(-1)
+	if got := gcPercent(); got != -1 {
+		t.Errorf("expected a disabled GC to be reported as -1, got %d", got)
+	}
+}
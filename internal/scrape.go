@@ -0,0 +1,74 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// scrapedObject is an object cached for the families of a store that are rendered at scrape time, rather than when
+// the object's events are processed.
+type scrapedObject struct {
+	object *unstructured.Unstructured
+	// cluster is the (federated) cluster the object comes from.
+	cluster string
+}
+
+// rendersOnScrape reports whether any of the store's families are rendered at scrape time.
+func (s *StoreType) rendersOnScrape() bool {
+	for _, family := range s.Families {
+		if family.onScrape() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renderOnScrape renders the given family for the given object, as cached when its last event was processed. The
+// caller must hold the store's (read) lock.
+func (s *StoreType) renderOnScrape(uid types.UID, family int) string {
+	cached, ok := s.objects[uid]
+	if !ok {
+		return ""
+	}
+	metricFamily := withSourceVersionLabel(withClusterLabel(s.Families[family].buildMetricString(cached.object), cached.cluster), s.sourceVersion)
+	if _, ok := s.tombstones[uid]; ok {
+		metricFamily = withLabel(metricFamily, tombstoneLabel)
+	}
+
+	return metricFamily
+}
+
+// secondsSince returns the seconds elapsed since the given timestamp, either in RFC 3339, or in seconds since the
+// epoch.
+func secondsSince(timestamp string, now time.Time) (string, error) {
+	if seconds, err := strconv.ParseFloat(timestamp, 64); err == nil {
+		return strconv.FormatFloat(float64(now.UnixNano())/1e9-seconds, 'f', -1, 64), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return "", fmt.Errorf("error parsing timestamp %q: %w", timestamp, err)
+	}
+
+	return strconv.FormatFloat(now.Sub(t).Seconds(), 'f', -1, 64), nil
+}
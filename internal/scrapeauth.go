@@ -0,0 +1,122 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// scrapeAuthorizer restricts scrapes of the main server's metrics to the clients in the allowed networks, if any,
+// presenting the shared bearer token, if any, as a lighter alternative to authenticating clients through the API
+// server, e.g., for clusters without network policies.
+type scrapeAuthorizer struct {
+	// networks are the networks scrapes are allowed from, or all if none.
+	networks []*net.IPNet
+	// token is the bearer token scrapes must present, if any.
+	token []byte
+}
+
+// newScrapeAuthorizer returns a scrapeAuthorizer allowing scrapes from the given (repeated, or comma-separated) CIDRs,
+// presenting the bearer token in the given file, if any, or nil if neither restricts scrapes.
+func newScrapeAuthorizer(cidrs []string, tokenFile string) (*scrapeAuthorizer, error) {
+	a := &scrapeAuthorizer{}
+	for _, value := range cidrs {
+		for _, cidr := range strings.Split(value, ",") {
+			_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+			}
+			a.networks = append(a.networks, network)
+		}
+	}
+	if tokenFile != "" {
+		token, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading bearer token: %w", err)
+		}
+		a.token = bytes.TrimSpace(token)
+		if len(a.token) == 0 {
+			return nil, errors.New("bearer token file is empty")
+		}
+	}
+	if len(a.networks) == 0 && len(a.token) == 0 {
+		return nil, nil
+	}
+
+	return a, nil
+}
+
+// authorize returns the status to reject the given request with, or 0 if it is allowed.
+func (a *scrapeAuthorizer) authorize(r *http.Request) int {
+	if len(a.networks) > 0 && !a.allowed(r.RemoteAddr) {
+		return http.StatusForbidden
+	}
+	if len(a.token) > 0 {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), a.token) != 1 {
+			return http.StatusUnauthorized
+		}
+	}
+
+	return 0
+}
+
+// allowed returns whether the given remote address is in any of the allowed networks. Forwarding headers are not
+// trusted, as clients may set them freely.
+func (a *scrapeAuthorizer) allowed(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range a.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wrap returns a handler serving the requests the authorizer allows through the given handler, rejecting the others.
+func (a *scrapeAuthorizer) wrap(next http.HandlerFunc) http.HandlerFunc {
+	if a == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch status := a.authorize(r); status {
+		case 0:
+			next(w, r)
+		case http.StatusUnauthorized:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, http.StatusText(status), status)
+		default:
+			http.Error(w, http.StatusText(status), status)
+		}
+	}
+}
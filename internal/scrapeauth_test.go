@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScrapeAuthorizer(t *testing.T) {
+	t.Parallel()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	authorizer, err := newScrapeAuthorizer([]string{"10.0.0.0/8,fd00::/8"}, tokenFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := authorizer.wrap(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		authorization string
+		expected      int
+	}{
+		{
+			name:          "allowed IPv4 client",
+			remoteAddr:    "10.1.2.3:4567",
+			authorization: "Bearer secret",
+			expected:      http.StatusOK,
+		},
+		{
+			name:          "allowed IPv6 client",
+			remoteAddr:    "[fd00::1]:4567",
+			authorization: "Bearer secret",
+			expected:      http.StatusOK,
+		},
+		{
+			name:          "disallowed client",
+			remoteAddr:    "192.168.1.1:4567",
+			authorization: "Bearer secret",
+			expected:      http.StatusForbidden,
+		},
+		{
+			name:          "invalid token",
+			remoteAddr:    "10.1.2.3:4567",
+			authorization: "Bearer foo",
+			expected:      http.StatusUnauthorized,
+		},
+		{
+			name:       "missing token",
+			remoteAddr: "10.1.2.3:4567",
+			expected:   http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			request.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			handler(recorder, request)
+			if recorder.Code != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestNewScrapeAuthorizer_unrestricted(t *testing.T) {
+	t.Parallel()
+	authorizer, err := newScrapeAuthorizer(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorizer != nil {
+		t.Error("expected no authorizer if neither CIDRs nor a token are given")
+	}
+}
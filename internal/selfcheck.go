@@ -0,0 +1,115 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// expositionCheck periodically renders each monitor's stores, as served on its dedicated endpoint, and parses the
+// exposition, so that unparseable expositions (e.g., due to broken label escaping) surface on the controller, rather
+// than only in Prometheus' logs at scrape time.
+type expositionCheck struct {
+	stores *sync.Map
+	// valid reports whether each monitor's exposition was parseable, as of the last check.
+	valid *prometheus.GaugeVec
+
+	mutex sync.RWMutex
+	// invalid holds the monitors whose exposition failed to parse, as of the last check, by their keys.
+	invalid sets.Set[string]
+}
+
+// newExpositionCheck returns an expositionCheck for the given stores, reporting to the given gauge.
+func newExpositionCheck(stores *sync.Map, valid *prometheus.GaugeVec) *expositionCheck {
+	return &expositionCheck{
+		stores:  stores,
+		valid:   valid,
+		invalid: sets.New[string](),
+	}
+}
+
+// run checks the exposition of all monitors once.
+func (e *expositionCheck) run(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	results := map[string]error{}
+	e.stores.Range(func(key, value any) bool {
+		keyString, _ := key.(string)
+		builtStores, ok := value.([]*StoreType)
+		if !ok {
+			return true
+		}
+		results[keyString] = checkExposition(builtStores)
+
+		return true
+	})
+
+	invalid := sets.New[string]()
+	e.valid.Reset()
+	for key, err := range results {
+		objectName, parseErr := cache.ParseObjectName(key)
+		if parseErr != nil {
+			continue
+		}
+		if err != nil {
+			logger.Error(err, "exposition would fail to be scraped", "key", key)
+			invalid.Insert(key)
+			e.valid.WithLabelValues(objectName.Namespace, objectName.Name).Set(0)
+
+			continue
+		}
+		e.valid.WithLabelValues(objectName.Namespace, objectName.Name).Set(1)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.invalid = invalid
+}
+
+// ready returns an error if the exposition of any monitor failed to parse, as of the last check.
+func (e *expositionCheck) ready() error {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.invalid.Len() == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("exposition of %v failed to parse", sets.List(e.invalid))
+}
+
+// checkExposition renders the given stores and parses their exposition.
+func checkExposition(stores []*StoreType) error {
+	buffer := &bytes.Buffer{}
+	if err := newMetricsWriter(stores...).writeStores(buffer); err != nil {
+		return fmt.Errorf("error writing metrics: %w", err)
+	}
+	parser := expfmt.TextParser{}
+	if _, err := parser.TextToMetricFamilies(buffer); err != nil {
+		return fmt.Errorf("error parsing exposition: %w", err)
+	}
+
+	return nil
+}
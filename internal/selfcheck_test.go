@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestExpositionCheck(t *testing.T) {
+	t.Parallel()
+	valid := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "exposition_valid"}, []string{"namespace", "name"})
+	stores := &sync.Map{}
+	stores.Store("default/valid", []*StoreType{
+		{
+			headers: []string{"# HELP kube_customresource_foo foo\n# TYPE kube_customresource_foo gauge"},
+			metrics: memoryStorage{
+				"uid1": {"kube_customresource_foo{bar=\"baz\\\"qux\"} 1.000000\n"},
+			},
+		},
+	})
+	check := newExpositionCheck(stores, valid)
+
+	check.run(context.Background())
+	if err := check.ready(); err != nil {
+		t.Errorf("expected ready, got %v", err)
+	}
+	if got := testutil.ToFloat64(valid.WithLabelValues("default", "valid")); got != 1 {
+		t.Errorf("expected default/valid to be valid, got %v", got)
+	}
+
+	// Unescaped quotes in label values break the exposition.
+	stores.Store("default/invalid", []*StoreType{
+		{
+			headers: []string{"# HELP kube_customresource_foo foo\n# TYPE kube_customresource_foo gauge"},
+			metrics: memoryStorage{
+				"uid1": {"kube_customresource_foo{bar=\"baz\"qux\"} 1.000000\n"},
+			},
+		},
+	})
+	check.run(context.Background())
+	if err := check.ready(); err == nil {
+		t.Error("expected not ready")
+	}
+	if got := testutil.ToFloat64(valid.WithLabelValues("default", "invalid")); got != 0 {
+		t.Errorf("expected default/invalid to be invalid, got %v", got)
+	}
+	if got := testutil.ToFloat64(valid.WithLabelValues("default", "valid")); got != 1 {
+		t.Errorf("expected default/valid to be valid, got %v", got)
+	}
+
+	// Dropping the monitor recovers readiness.
+	stores.Delete("default/invalid")
+	check.run(context.Background())
+	if err := check.ready(); err != nil {
+		t.Errorf("expected ready, got %v", err)
+	}
+	if got := testutil.CollectAndCount(valid); got != 1 {
+		t.Errorf("expected 1 series, got %d", got)
+	}
+}
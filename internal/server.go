@@ -20,10 +20,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/http/pprof"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,8 +35,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/expfmt"
 	"github.com/rexagod/resource-state-metrics/external"
+	"github.com/rexagod/resource-state-metrics/internal/version"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
+	utiltrace "k8s.io/utils/trace"
 )
 
 // server defines behaviours for a Prometheus-based exposition server.
@@ -46,6 +57,12 @@ type selfServer struct {
 	promHTTPLogger
 	// addr is the http.Server address to listen on.
 	addr string
+	// readinessGates hold the checks that must pass for the server to report ready.
+	readinessGates []func() error
+	// options are the options in effect, served for debugging.
+	options *Options
+	// authorizer, if set, restricts the clients allowed to adjust the runtime settings.
+	authorizer *scrapeAuthorizer
 }
 
 // mainServer implements the server interface, and exposes resource metrics.
@@ -59,8 +76,18 @@ type mainServer struct {
 	// registered in the telemetry registry, and will be available along with all other main metrics, to not pollute the
 	// resource metrics.
 	requestsDurationVec prometheus.ObserverVec
+	// buildInfo gathers the build_info metric, exposed along with the resource metrics.
+	buildInfo prometheus.Gatherer
+	// readinessGates hold the checks that must pass for the server to report ready.
+	readinessGates []func() error
+	// accessLog logs each request served, if set.
+	accessLog bool
+	// slowScrapes, if set, reports the stores' expositions rendered for too long.
+	slowScrapes *slowScrapes
+	// authorizer, if set, restricts the clients allowed to scrape the metrics.
+	authorizer *scrapeAuthorizer
 	// Cluster configuration (needed for LW clients).
-	kubeconfig string
+	cfg *rest.Config
 }
 
 // Ensure that selfServer implements the server interface.
@@ -69,22 +96,27 @@ var _ server = &selfServer{}
 // Ensure that mainServer implements the server interface.
 var _ server = &mainServer{}
 
-// newSelfServer returns a new selfServer.
-func newSelfServer(addr string) *selfServer {
+// newSelfServer returns a new selfServer, serving the given options, and reporting ready only while the given checks
+// pass.
+func newSelfServer(addr string, options *Options, readinessGates ...func() error) *selfServer {
 	return &selfServer{
 		promHTTPLogger: promHTTPLogger{"self"},
 		addr:           addr,
+		readinessGates: readinessGates,
+		options:        options,
 	}
 }
 
-// newMainServer returns a new mainServer.
-func newMainServer(addr, kubeconfig string, stores *sync.Map, requestsDurationVec prometheus.ObserverVec) *mainServer {
+// newMainServer returns a new mainServer, reporting ready only while the given checks pass.
+func newMainServer(addr string, cfg *rest.Config, stores *sync.Map, requestsDurationVec prometheus.ObserverVec, readinessGates ...func() error) *mainServer {
 	return &mainServer{
 		promHTTPLogger:      promHTTPLogger{"main"},
 		addr:                addr,
-		kubeconfig:          kubeconfig,
+		cfg:                 cfg,
 		stores:              stores,
 		requestsDurationVec: requestsDurationVec,
+		buildInfo:           newBuildInfoGatherer(version.ControllerName.ToSnakeCase()),
+		readinessGates:      readinessGates,
 	}
 }
 
@@ -100,6 +132,12 @@ func (s *selfServer) build(ctx context.Context, client kubernetes.Interface, gat
 	mux.Handle("/debug/pprof/symbol", http.HandlerFunc(pprof.Symbol))
 	mux.Handle("/debug/pprof/trace", http.HandlerFunc(pprof.Trace))
 
+	// Handle the runtime settings path.
+	mux.Handle(gcPath, gcHandler(logger, s.authorizer))
+
+	// Handle the options path.
+	mux.Handle(optionsPath, optionsHandler(s.options))
+
 	// Handle the metrics path.
 	registry, ok := gatherer.(*prometheus.Registry)
 	if !ok {
@@ -115,7 +153,7 @@ func (s *selfServer) build(ctx context.Context, client kubernetes.Interface, gat
 	mux.Handle("/metrics", metricsHandler)
 
 	// Handle the readyz path.
-	readyzProber := newReadyz(s.source)
+	readyzProber := newReadyz(s.source, s.readinessGates...)
 	mux.Handle(readyzProber.text(), readyzProber.probe(ctx, logger, client))
 
 	return &http.Server{
@@ -133,28 +171,134 @@ func (s *mainServer) build(ctx context.Context, client kubernetes.Interface, _ p
 
 	// Handle the metrics path.
 	var binarySemaphore sync.RWMutex
-	metricsHandler := func(generator func(w http.ResponseWriter)) http.HandlerFunc {
-		return func(w http.ResponseWriter, _ *http.Request) {
+	metricsHandler := func(generator func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+		return s.authorizer.wrap(func(w http.ResponseWriter, r *http.Request) {
 			binarySemaphore.RLock()
 			defer binarySemaphore.RUnlock()
 
-			// OpenMetrics is experimental at the moment.
+			// OpenMetrics is only negotiated for the stores' metrics, see below.
 			w.Header().Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
 
 			// Generate metrics.
-			generator(w)
+			generator(w, r)
+		})
+	}
+	// Stores' metrics are exposed as OpenMetrics, exemplars included, to scrapes negotiating it, or re-encoded for
+	// non-Prometheus consumers, to scrapes asking for another format by the path's extension, or their Accept header.
+	storesHandler := func(generator func(w http.ResponseWriter, r *http.Request, format scrapeFormat)) http.Handler {
+		return promhttp.InstrumentHandlerDuration(s.requestsDurationVec, metricsHandler(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			format := scrapeFormat{encoder: negotiateEncoder(r)}
+			if format.encoder != nil {
+				w.Header().Set("Content-Type", format.encoder.contentType())
+			} else {
+				format.openMetrics = expfmt.NegotiateIncludingOpenMetrics(r.Header).FormatType() == expfmt.TypeOpenMetrics
+			}
+			if format.openMetrics {
+				w.Header().Set("Content-Type", string(expfmt.NewFormat(expfmt.TypeOpenMetrics)))
+			}
+			generator(w, r, format)
+			if format.openMetrics {
+				if _, err := w.Write([]byte("# EOF\n")); err != nil {
+					logger.Error(err, "error writing metrics", "source", s.source)
+				}
+			}
+			s.slowScrapes.observe(logger, r, time.Since(start))
+		}))
+	}
+	writeStores := func(w http.ResponseWriter, key, value any, overrides map[schema.GroupVersionResource]sets.Set[string], format scrapeFormat) {
+		stores, ok := value.([]*StoreType)
+		if !ok {
+			logger.Error(errors.New("invalid store type in map"), "error writing metrics", "source", s.source)
+
+			return
+		}
+		writer := newMetricsWriter(stores...)
+		writer.skip = skipOverridden(key, overrides)
+		writer.openMetrics = format.openMetrics
+		// Monitors rendered for longer than slow scrapes are logged, along with the time each of their stores took.
+		if s.slowScrapes != nil && s.slowScrapes.threshold > 0 {
+			writer.trace = utiltrace.New("Render", utiltrace.Field{Key: "monitor", Value: key})
+			defer logTraceIfLong(writer.trace, s.slowScrapes.threshold)
+		}
+		var err error
+		if format.encoder != nil {
+			// Monitors' expositions are re-encoded one at a time, as their families may be named alike.
+			err = encodeWith(w, format.encoder, writer.writeStores)
+		} else {
+			err = writer.writeStores(w)
+		}
+		if err != nil {
+			logger.Error(err, "error writing metrics", "source", s.source)
+		}
+	}
+	allStores := func(w http.ResponseWriter, _ *http.Request, format scrapeFormat) {
+		var err error
+		switch {
+		case format.encoder != nil:
+			err = encodeWith(w, format.encoder, func(writer io.Writer) error {
+				return writeBuildInfo(writer, s.buildInfo, expfmt.NewFormat(expfmt.TypeTextPlain))
+			})
+		case format.openMetrics:
+			err = writeBuildInfo(w, s.buildInfo, expfmt.NewFormat(expfmt.TypeOpenMetrics))
+		default:
+			err = writeBuildInfo(w, s.buildInfo, expfmt.NewFormat(expfmt.TypeTextPlain))
 		}
+		if err != nil {
+			logger.Error(err, "error writing metrics", "source", s.source)
+		}
+		overrides := clusterMonitorOverrides(s.stores)
+		s.stores.Range(func(key, value any) bool {
+			writeStores(w, key, value, overrides, format)
+
+			return true
+		})
 	}
-	mux.Handle("/metrics", promhttp.InstrumentHandlerDuration(s.requestsDurationVec, metricsHandler(func(w http.ResponseWriter) {
-		s.stores.Range(func(_, value any) bool {
-			stores, ok := value.([]*StoreType)
-			if !ok {
-				logger.Error(errors.New("invalid store type in map"), "error writing metrics", "source", s.source)
+	mux.Handle("/metrics", storesHandler(allStores))
+	for extension := range encoders {
+		mux.Handle("/metrics."+extension, storesHandler(allStores))
+	}
+
+	// Handle the per-tier metrics paths, which only expose the metrics generated by the resources of the given tier. These
+	// take precedence over the paths of cluster-scoped resources named alike.
+	for _, tier := range scrapeTiers {
+		mux.Handle("/metrics/"+string(tier), storesHandler(func(w http.ResponseWriter, _ *http.Request, format scrapeFormat) {
+			overrides := clusterMonitorOverrides(s.stores)
+			s.stores.Range(func(key, value any) bool {
+				if builtStores, _ := value.([]*StoreType); storesTier(builtStores) == tier {
+					writeStores(w, key, value, overrides, format)
+				}
 
 				return true
-			}
-			err := newMetricsWriter(stores...).writeStores(w)
-			if err != nil {
+			})
+		}))
+	}
+
+	// Handle the delta path, which only exposes the series changed, or deleted, since the given revision, for pollers
+	// that cannot afford full expositions. This takes precedence over the path of a cluster-scoped resource named alike.
+	mux.Handle(deltaPath, promhttp.InstrumentHandlerDuration(s.requestsDurationVec, metricsHandler(func(w http.ResponseWriter, r *http.Request) {
+		since, revision, err := deltaSince(r, s.stores)
+		switch {
+		case errors.Is(err, errDeltaUnavailable):
+			http.Error(w, err.Error(), http.StatusGone)
+
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+		w.Header().Set(deltaRevisionHeader, strconv.FormatUint(revision, 10))
+		if err = dropped.writeDeletions(w, since); err != nil {
+			logger.Error(err, "error writing metrics", "source", s.source)
+		}
+		overrides := clusterMonitorOverrides(s.stores)
+		s.stores.Range(func(key, value any) bool {
+			builtStores, _ := value.([]*StoreType)
+			writer := newMetricsWriter(builtStores...)
+			writer.skip = skipOverridden(key, overrides)
+			writer.since = since
+			if err := writer.writeStores(w); err != nil {
 				logger.Error(err, "error writing metrics", "source", s.source)
 			}
 
@@ -162,13 +306,30 @@ func (s *mainServer) build(ctx context.Context, client kubernetes.Interface, _ p
 		})
 	})))
 
+	// Handle the per-resource metrics paths, which only expose the metrics generated by the given resource.
+	mux.Handle("/metrics/{namespace}/{name}", storesHandler(func(w http.ResponseWriter, r *http.Request, format scrapeFormat) {
+		key := cache.NewObjectName(r.PathValue("namespace"), r.PathValue("name")).String()
+		if value, ok := s.stores.Load(key); ok {
+			writeStores(w, key, value, nil, format)
+		}
+	}))
+	mux.Handle("/metrics/{name}", storesHandler(func(w http.ResponseWriter, r *http.Request, format scrapeFormat) {
+		key := cache.NewObjectName(metav1.NamespaceNone, r.PathValue("name")).String()
+		if value, ok := s.stores.Load(key); ok {
+			writeStores(w, key, value, clusterMonitorOverrides(s.stores), format)
+		}
+	}))
+
 	// Handle the external path.
-	externalCollectors := external.CollectorsGetter().SetKubeConfig(s.kubeconfig)
+	externalCollectors := external.CollectorsGetter().SetConfig(s.cfg)
 	externalCollectors.Build(ctx)
-	mux.Handle("/external", promhttp.InstrumentHandlerDuration(s.requestsDurationVec, metricsHandler(func(w http.ResponseWriter) {
+	mux.Handle("/external", promhttp.InstrumentHandlerDuration(s.requestsDurationVec, metricsHandler(func(w http.ResponseWriter, _ *http.Request) {
 		externalCollectors.Write(w)
 	})))
 
+	// Handle the version path.
+	mux.Handle(versionPath, versionHandler(logger))
+
 	// Handle the healthz path.
 	healthzProber := newHealthz(s.source)
 	mux.Handle(healthzProber.text(), healthzProber.probe(ctx, logger, client))
@@ -177,9 +338,18 @@ func (s *mainServer) build(ctx context.Context, client kubernetes.Interface, _ p
 	livezProber := newLivez(s.source)
 	mux.Handle(livezProber.text(), livezProber.probe(ctx, logger, client))
 
+	// Handle the readyz path.
+	readyzProber := newReadyz(s.source, s.readinessGates...)
+	mux.Handle(readyzProber.text(), readyzProber.probe(ctx, logger, client))
+
+	var handler http.Handler = mux
+	if s.accessLog {
+		handler = accessLogHandler(logger.WithName("access"), mux)
+	}
+
 	return &http.Server{
 		ErrorLog:          log.New(os.Stdout, s.source, log.LstdFlags|log.Lshortfile),
-		Handler:           mux,
+		Handler:           handler,
 		ReadHeaderTimeout: 5 * time.Second,
 		Addr:              s.addr,
 	}
@@ -195,3 +365,57 @@ type promHTTPLogger struct {
 func (l promHTTPLogger) Println(v ...interface{}) {
 	klog.ErrorS(fmt.Errorf("%s", v), "err", "source", l.source)
 }
+
+// defaultListenHost is the host the servers listen on if none were given, i.e., all interfaces, in both families where
+// dual-stack sockets are supported.
+const defaultListenHost = "::"
+
+// listenHosts returns the hosts in the given (repeated, or comma-separated) values, or the default one if none were
+// given.
+func listenHosts(values *[]string) []string {
+	var hosts []string
+	if values != nil {
+		for _, value := range *values {
+			for _, host := range strings.Split(value, ",") {
+				hosts = append(hosts, strings.TrimSpace(host))
+			}
+		}
+	}
+	if len(hosts) == 0 {
+		return []string{defaultListenHost}
+	}
+
+	return hosts
+}
+
+// listen returns listeners on the given port of each of the given hosts. When listening on several hosts, IP addresses
+// are bound in their own families only, so that, e.g., 0.0.0.0 and :: may be listened on at once. Listeners opened
+// before one fails are closed.
+func listen(hosts []string, port int) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(hosts))
+	for _, host := range hosts {
+		network := "tcp"
+		if ip := net.ParseIP(host); ip != nil && len(hosts) > 1 {
+			network = "tcp6"
+			if ip.To4() != nil {
+				network = "tcp4"
+			}
+		}
+		listener, err := net.Listen(network, net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			closeListeners(listeners)
+
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// closeListeners closes the given listeners, e.g., if the servers fail to start.
+func closeListeners(listeners []net.Listener) {
+	for _, listener := range listeners {
+		_ = listener.Close()
+	}
+}
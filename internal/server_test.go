@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestListenHosts(t *testing.T) {
+	t.Parallel()
+	if got := listenHosts(&[]string{}); !slices.Equal(got, []string{defaultListenHost}) {
+		t.Errorf("expected the default host, got %v", got)
+	}
+	if got, expected := listenHosts(&[]string{"0.0.0.0, ::", "localhost"}), []string{"0.0.0.0", "::", "localhost"}; !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestListen(t *testing.T) {
+	t.Parallel()
+	listeners, err := listen([]string{"127.0.0.1", "localhost"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeListeners(listeners)
+	if len(listeners) != 2 {
+		t.Fatalf("expected 2 listeners, got %d", len(listeners))
+	}
+	if network := listeners[0].Addr().Network(); network != "tcp" {
+		t.Errorf("expected a TCP listener, got %s", network)
+	}
+
+	// Hosts are listened on all or none.
+	if _, err := listen([]string{"127.0.0.1", "foo.invalid"}, 0); err == nil {
+		t.Error("expected an error for an unresolvable host")
+	}
+}
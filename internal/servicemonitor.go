@@ -0,0 +1,197 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/rexagod/resource-state-metrics/internal/version"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+)
+
+// serviceMonitorGVR is the Prometheus Operator's ServiceMonitor resource.
+var serviceMonitorGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"}
+
+// resourceMetricsPath returns the path of the given resource's dedicated endpoint on the main server, which only
+// exposes the metrics generated by that resource.
+func resourceMetricsPath(resource *v1alpha1.ResourceMetricsMonitor) string {
+	if isClusterMonitor(resource) {
+		return "/metrics/" + resource.GetName()
+	}
+
+	return fmt.Sprintf("/metrics/%s/%s", resource.GetNamespace(), resource.GetName())
+}
+
+// reconcileServiceMonitor creates (or updates) the ServiceMonitor scraping the given resource's dedicated endpoint,
+// if ServiceMonitor generation is enabled, and the controller is not read-only. The ServiceMonitor is owned by the
+// resource, and is garbage collected along with it.
+func (c *Controller) reconcileServiceMonitor(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor) error {
+	if c.options.ServiceMonitorService == nil || *c.options.ServiceMonitorService == "" || c.readOnly() {
+		return nil
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(*c.options.ServiceMonitorService)
+	if err != nil {
+		return fmt.Errorf("invalid service %q: %w", *c.options.ServiceMonitorService, err)
+	}
+	service, err := c.kubeclientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get service %s: %w", klog.KRef(namespace, name), err)
+	}
+	extraLabels, err := labels.ConvertSelectorToLabelsMap(*c.options.ServiceMonitorLabels)
+	if err != nil {
+		return fmt.Errorf("invalid ServiceMonitor labels %q: %w", *c.options.ServiceMonitorLabels, err)
+	}
+	desired, err := newServiceMonitor(resource, service, *c.options.MainPort, extraLabels)
+	if err != nil {
+		return err
+	}
+
+	client := c.dynamicClientset.Resource(serviceMonitorGVR).Namespace(desired.GetNamespace())
+	existing, err := client.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err = client.Create(ctx, desired, metav1.CreateOptions{FieldManager: version.ControllerName.String()}); err != nil {
+			return fmt.Errorf("failed to create ServiceMonitor %s: %w", klog.KObj(desired), err)
+		}
+
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get ServiceMonitor %s: %w", klog.KObj(desired), err)
+	}
+	desired.SetResourceVersion(existing.GetResourceVersion())
+	if _, err = client.Update(ctx, desired, metav1.UpdateOptions{FieldManager: version.ControllerName.String()}); err != nil {
+		return fmt.Errorf("failed to update ServiceMonitor %s: %w", klog.KObj(desired), err)
+	}
+
+	return nil
+}
+
+// newServiceMonitor returns the ServiceMonitor scraping the given resource's dedicated endpoint through the given
+// service, which must expose the main server's port under a name. ServiceMonitors for cluster-scoped monitors go in
+// the service's namespace, prefixed to not collide with the ones for namespaced monitors there.
+func newServiceMonitor(resource *v1alpha1.ResourceMetricsMonitor, service *corev1.Service, mainPort int, extraLabels map[string]string) (*unstructured.Unstructured, error) {
+	if len(service.GetLabels()) == 0 {
+		return nil, fmt.Errorf("service %s has no labels to select it by", klog.KObj(service))
+	}
+	var port string
+	for _, servicePort := range service.Spec.Ports {
+		if servicePort.Name == "" {
+			continue
+		}
+		if servicePort.Name == "main" || int(servicePort.Port) == mainPort || servicePort.TargetPort.IntValue() == mainPort {
+			port = servicePort.Name
+
+			break
+		}
+	}
+	if port == "" {
+		return nil, fmt.Errorf("service %s does not expose the main port (%d) under a name", klog.KObj(service), mainPort)
+	}
+
+	serviceMonitorLabels := maps.Clone(extraLabels)
+	if serviceMonitorLabels == nil {
+		serviceMonitorLabels = map[string]string{}
+	}
+	serviceMonitorLabels["app.kubernetes.io/managed-by"] = version.ControllerName.String()
+
+	serviceMonitor := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"namespaceSelector": map[string]interface{}{
+				"matchNames": []interface{}{service.GetNamespace()},
+			},
+			"selector": map[string]interface{}{
+				"matchLabels": toInterfaceMap(service.GetLabels()),
+			},
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"port": port,
+					"path": resourceMetricsPath(resource),
+				},
+			},
+		},
+	}}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVR.GroupVersion().WithKind("ServiceMonitor"))
+	namespace, name := serviceMonitorKey(resource, service.GetNamespace())
+	kind := "ResourceMetricsMonitor"
+	if isClusterMonitor(resource) {
+		kind = "ClusterResourceMetricsMonitor"
+	}
+	serviceMonitor.SetNamespace(namespace)
+	serviceMonitor.SetName(name)
+	serviceMonitor.SetLabels(serviceMonitorLabels)
+	serviceMonitor.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion:         v1alpha1.SchemeGroupVersion.String(),
+		Kind:               kind,
+		Name:               resource.GetName(),
+		UID:                resource.GetUID(),
+		Controller:         ptr.To(true),
+		BlockOwnerDeletion: ptr.To(true),
+	}})
+
+	return serviceMonitor, nil
+}
+
+// serviceMonitorKey returns the namespace and name of the given resource's ServiceMonitor, given the namespace of the
+// service it scrapes through.
+func serviceMonitorKey(resource *v1alpha1.ResourceMetricsMonitor, serviceNamespace string) (string, string) {
+	if isClusterMonitor(resource) {
+		return serviceNamespace, "cluster-" + resource.GetName()
+	}
+
+	return resource.GetNamespace(), resource.GetName()
+}
+
+// deleteServiceMonitor deletes the ServiceMonitor generated for the given resource, if any. Garbage collection does so
+// as well, but only eventually, once the resource is gone, during which Prometheus keeps scraping an emptied endpoint.
+func (c *Controller) deleteServiceMonitor(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor) error {
+	if c.options.ServiceMonitorService == nil || *c.options.ServiceMonitorService == "" || c.readOnly() {
+		return nil
+	}
+
+	serviceNamespace, _, err := cache.SplitMetaNamespaceKey(*c.options.ServiceMonitorService)
+	if err != nil {
+		return fmt.Errorf("invalid service %q: %w", *c.options.ServiceMonitorService, err)
+	}
+	namespace, name := serviceMonitorKey(resource, serviceNamespace)
+	err = c.dynamicClientset.Resource(serviceMonitorGVR).Namespace(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete ServiceMonitor %s: %w", klog.KRef(namespace, name), err)
+	}
+
+	return nil
+}
+
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+
+	return out
+}
@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestNewServiceMonitor(t *testing.T) {
+	t.Parallel()
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar", UID: "uid"}}
+	newService := func(labels map[string]string, ports ...corev1.ServicePort) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "rsm", Namespace: "monitoring", Labels: labels},
+			Spec:       corev1.ServiceSpec{Ports: ports},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		service       *corev1.Service
+		expectedPort  string
+		expectedError string
+	}{
+		{
+			name:          "unlabeled service",
+			service:       newService(nil, corev1.ServicePort{Name: "main", Port: 9999}),
+			expectedError: "has no labels",
+		},
+		{
+			name:          "unnamed port",
+			service:       newService(map[string]string{"app": "rsm"}, corev1.ServicePort{Port: 9999}),
+			expectedError: "does not expose the main port",
+		},
+		{
+			name: "port matched by target port",
+			service: newService(map[string]string{"app": "rsm"},
+				corev1.ServicePort{Name: "self", Port: 9998},
+				corev1.ServicePort{Name: "http", Port: 80, TargetPort: intstr.FromInt32(9999)},
+			),
+			expectedPort: "http",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := newServiceMonitor(resource, tt.service, 9999, map[string]string{"release": "prometheus"})
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := map[string]interface{}{
+				"namespaceSelector": map[string]interface{}{"matchNames": []interface{}{"monitoring"}},
+				"selector":          map[string]interface{}{"matchLabels": map[string]interface{}{"app": "rsm"}},
+				"endpoints":         []interface{}{map[string]interface{}{"port": tt.expectedPort, "path": "/metrics/bar/foo"}},
+			}
+			if diff := cmp.Diff(got.Object["spec"], expected); diff != "" {
+				t.Errorf("%s", diff)
+			}
+			if got.GetNamespace() != "bar" || got.GetName() != "foo" {
+				t.Errorf("expected ServiceMonitor bar/foo, got %s/%s", got.GetNamespace(), got.GetName())
+			}
+			if got.GetLabels()["release"] != "prometheus" {
+				t.Errorf("expected the configured labels to be set, got %v", got.GetLabels())
+			}
+			if owners := got.GetOwnerReferences(); len(owners) != 1 || owners[0].UID != "uid" || !ptr.Deref(owners[0].Controller, false) {
+				t.Errorf("expected the resource to control the ServiceMonitor, got %v", owners)
+			}
+		})
+	}
+}
+
+func TestNewServiceMonitor_clusterMonitor(t *testing.T) {
+	t.Parallel()
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "foo", UID: "uid"}}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "rsm", Namespace: "monitoring", Labels: map[string]string{"app": "rsm"}},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "main", Port: 9999}}},
+	}
+
+	got, err := newServiceMonitor(resource, service, 9999, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetNamespace() != "monitoring" || got.GetName() != "cluster-foo" {
+		t.Errorf("expected ServiceMonitor monitoring/cluster-foo, got %s/%s", got.GetNamespace(), got.GetName())
+	}
+	endpoints, _, _ := unstructured.NestedSlice(got.Object, "spec", "endpoints")
+	if path := endpoints[0].(map[string]interface{})["path"]; path != "/metrics/foo" {
+		t.Errorf("expected the cluster-scoped monitor's endpoint, got %v", path)
+	}
+	if owners := got.GetOwnerReferences(); len(owners) != 1 || owners[0].Kind != "ClusterResourceMetricsMonitor" {
+		t.Errorf("expected the cluster-scoped monitor to control the ServiceMonitor, got %v", owners)
+	}
+}
+
+func TestController_reconcileServiceMonitor(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "rsm", Namespace: "monitoring", Labels: map[string]string{"app": "rsm"}},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "main", Port: 9999}}},
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		serviceMonitorGVR: "ServiceMonitorList",
+	})
+	c := &Controller{
+		kubeclientset:    kubefake.NewClientset(service),
+		dynamicClientset: dynamicClient,
+		options: &Options{
+			MainPort:              ptr.To(9999),
+			ServiceMonitorLabels:  ptr.To("release=prometheus"),
+			ServiceMonitorService: ptr.To("monitoring/rsm"),
+		},
+	}
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"}}
+
+	// Reconciling twice exercises both the create and the update paths.
+	for range 2 {
+		if err := c.reconcileServiceMonitor(ctx, resource); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := dynamicClient.Resource(serviceMonitorGVR).Namespace("bar").Get(ctx, "foo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetLabels()["release"] != "prometheus" {
+		t.Errorf("expected the configured labels to be set, got %v", got.GetLabels())
+	}
+
+	// Generation is opt-in.
+	c.options.ServiceMonitorService = ptr.To("")
+	resource.SetName("baz")
+	if err = c.reconcileServiceMonitor(ctx, resource); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = dynamicClient.Resource(serviceMonitorGVR).Namespace("bar").Get(ctx, "baz", metav1.GetOptions{}); err == nil {
+		t.Error("expected no ServiceMonitor to be generated")
+	}
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// snapshotFile is the name of the file the snapshot is written to, in its directory.
+const snapshotFile = "snapshot.json.gz"
+
+// snapshot holds the storages handed out to the stores, to write their series out on shutdown, and restores the ones
+// written out by the last run into them, so they are served until the stores' initial lists replace them.
+type snapshot struct {
+	logger    klog.Logger
+	directory string
+	mutex     sync.Mutex
+	// restored holds the series read off the last snapshot, by their storages' keys, until they are handed out.
+	restored map[string]map[types.UID]diskEntry
+	// storages holds the storages handed out, by their keys.
+	storages map[string]*snapshotStorage
+}
+
+// readSnapshot reads the snapshot written out by the last run into the given directory, if any.
+func readSnapshot(logger klog.Logger, directory string) (*snapshot, error) {
+	s := &snapshot{
+		logger:    logger,
+		directory: directory,
+		restored:  map[string]map[types.UID]diskEntry{},
+		storages:  map[string]*snapshotStorage{},
+	}
+	file, err := os.Open(filepath.Join(directory, snapshotFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("error opening snapshot: %w", err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return s, fmt.Errorf("error reading snapshot: %w", err)
+	}
+	defer reader.Close()
+	if err := json.NewDecoder(reader).Decode(&s.restored); err != nil {
+		s.restored = map[string]map[types.UID]diskEntry{}
+
+		return s, fmt.Errorf("error decoding snapshot: %w", err)
+	}
+
+	return s, nil
+}
+
+// wrap returns a factory of the given factory's storages, restoring the series held for them by the snapshot, if
+// any, and tracking them to be written out.
+func (s *snapshot) wrap(factory storageFactory) storageFactory {
+	return func(key string) seriesStorage {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		storage := &snapshotStorage{seriesStorage: factory(key), namespaces: map[types.UID]string{}}
+		// Series are only restored into the first storage for each key, as later ones are built for monitors updated
+		// since startup.
+		for uid, entry := range s.restored[key] {
+			storage.set(uid, entry.Namespace, entry.Series)
+		}
+		delete(s.restored, key)
+		s.storages[key] = storage
+
+		return storage
+	}
+}
+
+// write writes the series of the storages handed out to the snapshot, replacing the one written out by the last run.
+func (s *snapshot) write() error {
+	s.mutex.Lock()
+	entries := make(map[string]map[types.UID]diskEntry, len(s.storages))
+	for key, storage := range s.storages {
+		if storageEntries := storage.entries(); len(storageEntries) > 0 {
+			entries[key] = storageEntries
+		}
+	}
+	s.mutex.Unlock()
+
+	// Write to a temporary file first, so that an interrupted write does not corrupt the last snapshot.
+	file, err := os.CreateTemp(s.directory, snapshotFile+".*")
+	if err != nil {
+		return fmt.Errorf("error creating snapshot: %w", err)
+	}
+	defer os.Remove(file.Name())
+	writer := gzip.NewWriter(file)
+	if err := json.NewEncoder(writer).Encode(entries); err != nil {
+		file.Close()
+
+		return fmt.Errorf("error encoding snapshot: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		file.Close()
+
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+	if err := os.Rename(file.Name(), filepath.Join(s.directory, snapshotFile)); err != nil {
+		return fmt.Errorf("error replacing snapshot: %w", err)
+	}
+	s.logger.V(1).Info("Wrote snapshot", "stores", len(entries))
+
+	return nil
+}
+
+// snapshotStorage wraps a storage to track its objects' namespaces, so its series may be written out to the snapshot
+// while the store is still running.
+type snapshotStorage struct {
+	seriesStorage
+	mutex      sync.Mutex
+	namespaces map[types.UID]string
+}
+
+// Ensure snapshotStorage implements seriesStorage and seriesRestorer.
+var (
+	_ seriesStorage  = &snapshotStorage{}
+	_ seriesRestorer = &snapshotStorage{}
+)
+
+func (s *snapshotStorage) get(uid types.UID) ([]string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.seriesStorage.get(uid)
+}
+
+func (s *snapshotStorage) set(uid types.UID, namespace string, series []string) map[types.UID][]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	evicted := s.seriesStorage.set(uid, namespace, series)
+	for evictedUID := range evicted {
+		delete(s.namespaces, evictedUID)
+	}
+	s.namespaces[uid] = namespace
+
+	return evicted
+}
+
+func (s *snapshotStorage) remove(uid types.UID) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seriesStorage.remove(uid)
+	delete(s.namespaces, uid)
+}
+
+func (s *snapshotStorage) forEach(fn func(uid types.UID, series []string) error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.seriesStorage.forEach(fn)
+}
+
+func (s *snapshotStorage) purge() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seriesStorage.purge()
+	clear(s.namespaces)
+}
+
+// restore returns the namespaces of the objects whose series were restored off the snapshot, by their UIDs, along
+// with the size of their series, in bytes. It is only called as the storage is handed out, before any other series are
+// stored in it.
+func (s *snapshotStorage) restore() (map[types.UID]string, int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	restored := make(map[types.UID]string, len(s.namespaces))
+	var size int64
+	_ = s.seriesStorage.forEach(func(uid types.UID, series []string) error {
+		restored[uid] = s.namespaces[uid]
+		size += seriesSize(series)
+
+		return nil
+	})
+
+	return restored, size
+}
+
+// entries returns the storage's series, along with their objects' namespaces, by the objects' UIDs.
+func (s *snapshotStorage) entries() map[types.UID]diskEntry {
+	entries := map[types.UID]diskEntry{}
+	_ = s.forEach(func(uid types.UID, series []string) error {
+		entries[uid] = diskEntry{Namespace: s.namespaces[uid], Series: series}
+
+		return nil
+	})
+
+	return entries
+}
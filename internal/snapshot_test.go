@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+	directory := t.TempDir()
+	memory := newStorageFactory(klog.Background(), StorageTypeMemory, 0, nil)
+
+	// No snapshot is restored on the first run.
+	s, err := readSnapshot(klog.Background(), directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := s.wrap(memory)("default/foo/0")
+	storage.set("uid1", "foo", []string{"foo 1\n", ""})
+	storage.set("uid2", "bar", []string{"foo 2\n", ""})
+	storage.remove("uid2")
+	s.wrap(memory)("default/bar/0").set("uid3", "bar", []string{"bar 1\n"})
+	purged := s.wrap(memory)("default/baz/0")
+	purged.set("uid4", "baz", []string{"baz 1\n"})
+	purged.purge()
+	if err := s.write(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = readSnapshot(klog.Background(), directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(s.restored, map[string]map[types.UID]diskEntry{
+		"default/foo/0": {"uid1": {Namespace: "foo", Series: []string{"foo 1\n", ""}}},
+		"default/bar/0": {"uid3": {Namespace: "bar", Series: []string{"bar 1\n"}}},
+	}); diff != "" {
+		t.Errorf("%s", diff)
+	}
+
+	storage = s.wrap(memory)("default/foo/0")
+	restored, size := storage.(seriesRestorer).restore()
+	if diff := cmp.Diff(restored, map[types.UID]string{"uid1": "foo"}); diff != "" {
+		t.Errorf("%s", diff)
+	}
+	if size != seriesSize([]string{"foo 1\n", ""}) {
+		t.Errorf("expected the size of the restored series, got %d", size)
+	}
+
+	// Series are only restored into the first storage built for each key.
+	if got, _ := s.wrap(memory)("default/foo/0").(seriesRestorer).restore(); len(got) != 0 {
+		t.Errorf("expected no series to be restored again, got %v", got)
+	}
+}
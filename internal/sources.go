@@ -0,0 +1,153 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// sourceVersionLabelKey is the label stores sourcing objects from several versions of their target set to the version
+// each sample comes from.
+const sourceVersionLabelKey = "sourceVersion"
+
+// SourceType is another version of a store's target the store sources objects from, e.g., while its API is migrated.
+// The group, kind, and resource default to the store's, so that only the version needs to be set for rotating APIs.
+type SourceType struct {
+	Group    string `yaml:"group,omitempty"`
+	Version  string `yaml:"version"`
+	Kind     string `yaml:"kind,omitempty"`
+	Resource string `yaml:"resource,omitempty"`
+}
+
+// validateSources rejects sources that are not told apart by their targets, or stores their targets cannot be sourced
+// for.
+func validateSources(store *StoreType) error {
+	if len(store.Sources) == 0 {
+		return nil
+	}
+	if store.Selectors.CRD != "" {
+		return errors.New("sources: cannot be used with a CRD selector")
+	}
+	for i, source := range store.Sources {
+		if source.Version == "" {
+			return fmt.Errorf("sources[%d].version must be set", i)
+		}
+	}
+	targets := sourceGVKRs(store)
+	for i, target := range targets[1:] {
+		if slices.ContainsFunc(targets[:i+1], func(sourced gvkr) bool { return sourced.GroupVersionResource == target.GroupVersionResource }) {
+			return fmt.Errorf("sources[%d]: %q is sourced more than once", i, target.GroupVersionResource.String())
+		}
+	}
+
+	return nil
+}
+
+// sourceGVKRs returns the targets of the given store's sources, including the store's own, first.
+func sourceGVKRs(cfg *StoreType) []gvkr {
+	gvkrs := []gvkr{buildGVKR(cfg)}
+	for _, source := range cfg.Sources {
+		group, kind, resource := cfg.Group, cfg.Kind, cfg.Resource
+		if source.Group != "" {
+			group = source.Group
+		}
+		if source.Kind != "" {
+			kind = source.Kind
+		}
+		if source.Resource != "" {
+			resource = source.Resource
+		}
+		gvkrs = append(gvkrs, gvkr{
+			GroupVersionKind:     schema.GroupVersionKind{Group: group, Version: source.Version, Kind: kind},
+			GroupVersionResource: schema.GroupVersionResource{Group: group, Version: source.Version, Resource: resource},
+		})
+	}
+
+	return gvkrs
+}
+
+// buildMultiSourceStore builds a store sourcing objects from all of the given targets, instead of a single one. A store
+// is built for each target, in each of the given clusters, backed by its own reflector, and the selected stores' metrics
+// are written out along with the returned one's headers, labeled with their targets' versions, qualified by their
+// groups, if other than the first target's. As the same objects may be served at several versions, or groups, objects
+// sharing their UIDs are only written out off the first target serving them. Targets that are not served are retried
+// until they are.
+func buildMultiSourceStore(
+	ctx context.Context,
+	clientsets map[string]dynamic.Interface,
+	targets []gvkr,
+	watchNamespace string,
+	metricFamilies []*FamilyType,
+	labelSelector, fieldSelector, filter string,
+	tombstoneRetention, ttl time.Duration,
+	listPageSize int64,
+	concurrency ConcurrencyType,
+	storage storageFactory,
+	resolver ResolverType,
+	labelKeys, labelValues []string,
+	celCostLimit uint64,
+	celTimeout time.Duration,
+	celEvaluations *prometheus.CounterVec,
+	celVariables map[string]interface{},
+	namespace, name string,
+) *StoreType {
+	s := newConfiguredStore(klog.FromContext(ctx), metricFamilies, resolver, labelKeys, labelValues, celCostLimit, celTimeout, celEvaluations, namespace, name)
+	s.selected = map[types.UID]*StoreType{}
+	s.sourced = true
+	s.filter = newStoreFilter(filter, newCELResolver(s.logger, celCostLimit, celTimeout, celEvaluations, celVariables, namespace, name))
+	s.TombstoneRetention = metav1.Duration{Duration: tombstoneRetention}
+	s.TTL = metav1.Duration{Duration: ttl}
+	s.listPageSize = listPageSize
+	s.Concurrency = concurrency
+	s.storage = storage
+	s.Group, s.Version, s.Kind, s.Resource = targets[0].GroupVersionKind.Group, targets[0].GroupVersionKind.Version, targets[0].Kind, targets[0].Resource
+	for cluster, dynamicClientset := range clientsets {
+		for i, gvkWithR := range targets {
+			selectedStore := s.newSelectedStore(gvkWithR, cluster)
+			selectedStore.sourceVersion = gvkWithR.GroupVersionKind.Version
+			if gvkWithR.GroupVersionKind.Group != s.Group {
+				selectedStore.sourceVersion = gvkWithR.GroupVersionKind.GroupVersion().String()
+			}
+			selectedStore.sourcePriority = i
+			s.selected[types.UID(path.Join(cluster, gvkWithR.GroupVersionResource.String()))] = selectedStore
+			startReflector(ctx, buildLW(ctx, dynamicClientset, watchNamespace, labelSelector, fieldSelector, gvkWithR.GroupVersionResource, selectedStore), gvkWithR, selectedStore)
+		}
+	}
+
+	return s
+}
+
+// withSourceVersionLabel labels each series in the given family with the given source version, if any.
+func withSourceVersionLabel(metricFamily, version string) string {
+	if version == "" {
+		return metricFamily
+	}
+
+	return withLabel(metricFamily, sourceVersionLabelKey+`="`+version+`"`)
+}
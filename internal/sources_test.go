@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestValidateSources(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		store         *StoreType
+		expectedError string
+	}{
+		{
+			name:  "distinct versions",
+			store: &StoreType{Version: "v1", Sources: []SourceType{{Version: "v1beta1"}, {Group: "contoso.com", Version: "v2"}}},
+		},
+		{
+			name:          "missing version",
+			store:         &StoreType{Version: "v1", Sources: []SourceType{{Kind: "Foo"}}},
+			expectedError: "sources[0].version must be set",
+		},
+		{
+			name:          "duplicate version",
+			store:         &StoreType{Group: "contoso.com", Version: "v1", Resource: "foos", Sources: []SourceType{{Version: "v1beta1"}, {Version: "v1"}}},
+			expectedError: `sources[1]: "contoso.com/v1, Resource=foos" is sourced more than once`,
+		},
+		{
+			name:  "same version, in another group",
+			store: &StoreType{Group: "extensions", Version: "v1", Resource: "ingresses", Sources: []SourceType{{Group: "networking.k8s.io", Version: "v1"}}},
+		},
+		{
+			name:          "duplicate group and version",
+			store:         &StoreType{Group: "extensions", Version: "v1", Resource: "ingresses", Sources: []SourceType{{Group: "networking.k8s.io", Version: "v1"}, {Group: "networking.k8s.io", Version: "v1"}}},
+			expectedError: `sources[1]: "networking.k8s.io/v1, Resource=ingresses" is sourced more than once`,
+		},
+		{
+			name: "CRD selector",
+			store: func() *StoreType {
+				s := &StoreType{Sources: []SourceType{{Version: "v1"}}}
+				s.Selectors.CRD = "operator=contoso"
+
+				return s
+			}(),
+			expectedError: "cannot be used with a CRD selector",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateSources(tt.store)
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestConfigurer_buildMultiSourceStore(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newObject := func(group, version, name string) *unstructured.Unstructured {
+		object := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}}}
+		object.SetGroupVersionKind(schema.GroupVersionKind{Group: group, Version: version, Kind: "Foo"})
+		object.SetNamespace("default")
+		object.SetName(name)
+		object.SetUID(types.UID("uid-" + name))
+
+		return object
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: "contoso.com", Version: "v1", Resource: "foos"}:      "FooList",
+		{Group: "contoso.com", Version: "v1beta1", Resource: "foos"}: "FooList",
+		{Group: "legacy.com", Version: "v1", Resource: "foos"}:       "FooList",
+	},
+		// The same object, served at all targets, along with ones only served by the sources.
+		newObject("contoso.com", "v1", "foo"), newObject("contoso.com", "v1beta1", "foo"), newObject("legacy.com", "v1", "foo"),
+		newObject("contoso.com", "v1beta1", "bar"), newObject("legacy.com", "v1", "baz"),
+	)
+
+	resource := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Name: "sources", Namespace: "default"}}
+	c := newConfigurer(client, resource, 0, 0, nil)
+	if err := c.parse(`stores:
+  - group: contoso.com
+    version: v1
+    kind: Foo
+    resource: foos
+    sources:
+      - version: v1beta1
+      - group: legacy.com
+        version: v1
+    families:
+      - name: "replicas"
+        help: "Replicas"
+        metrics:
+          - labelKeys: ["name"]
+            labelValues: ["metadata.name"]
+            value: "spec.replicas"
+`); err != nil {
+		t.Fatal(err)
+	}
+	stores := &sync.Map{}
+	c.build(ctx, stores)
+	value, _ := stores.Load(storesKey(resource))
+	builtStores, _ := value.([]*StoreType)
+
+	// All targets' samples are exposed under the same family, told apart by their source versions, and objects served
+	// by several targets only off the first one.
+	expected := `# HELP kube_customresource_replicas Replicas
+# TYPE kube_customresource_replicas gauge
+kube_customresource_replicas{name="foo",group="contoso.com",version="v1",kind="Foo",sourceVersion="v1"} 1
+kube_customresource_replicas{name="bar",group="contoso.com",version="v1beta1",kind="Foo",sourceVersion="v1beta1"} 1
+kube_customresource_replicas{name="baz",group="legacy.com",version="v1",kind="Foo",sourceVersion="legacy.com/v1"} 1
+`
+	var got string
+	err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		buffer := &bytes.Buffer{}
+		if err := newMetricsWriter(builtStores...).writeStores(buffer); err != nil {
+			return false, err
+		}
+		got = buffer.String()
+
+		return got == expected, nil
+	})
+	if err != nil {
+		t.Fatalf("expected exposition %q, got %q: %v", expected, got, err)
+	}
+}
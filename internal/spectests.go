@@ -0,0 +1,82 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// testsMessageMaxLength bounds the length of the summary of a resource's test failures, as reported in its status.
+const testsMessageMaxLength = 4096
+
+// runTests runs the given resource's tests against its configuration, returning the failure of each failing test, by
+// the test's index.
+func runTests(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor) map[int]error {
+	failures := map[int]error{}
+	for i, test := range resource.Spec.Tests {
+		if err := runTest(ctx, resource, test); err != nil {
+			failures[i] = err
+		}
+	}
+
+	return failures
+}
+
+// runTest renders the exposition of the given resource's configuration for the test's objects, and reports the lines
+// expected of it, but not found in it, if any.
+func runTest(ctx context.Context, resource *v1alpha1.ResourceMetricsMonitor, test v1alpha1.ResourceMetricsMonitorTest) error {
+	objects, err := decodeObjects(test.Name, []byte(test.Objects))
+	if err != nil {
+		return err
+	}
+	exposition, err := renderExposition(ctx, resource, objects)
+	if err != nil {
+		return fmt.Errorf("error rendering exposition: %w", err)
+	}
+	lines := sets.New(strings.Split(exposition, "\n")...)
+	var missing []string
+	for _, expected := range test.Expected {
+		if !lines.Has(strings.TrimSpace(expected)) {
+			missing = append(missing, fmt.Sprintf("%q", expected))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("expected lines not found in the exposition: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// testsMessage returns a human-readable summary of the given test failures of the given resource.
+func testsMessage(resource *v1alpha1.ResourceMetricsMonitor, failures map[int]error) string {
+	if len(failures) == 0 {
+		return fmt.Sprintf("All %d tests passed", len(resource.Spec.Tests))
+	}
+	messages := make([]string, 0, len(failures))
+	for i, test := range resource.Spec.Tests {
+		if err, ok := failures[i]; ok {
+			messages = append(messages, fmt.Sprintf("%s: %v", test.Name, err))
+		}
+	}
+
+	return truncate(fmt.Sprintf("%d of %d tests failed: %s", len(failures), len(resource.Spec.Tests), strings.Join(messages, "; ")), testsMessageMaxLength)
+}
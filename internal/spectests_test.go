@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunTests(t *testing.T) {
+	t.Parallel()
+	objects := `apiVersion: contoso.com/v1alpha1
+kind: Bar
+metadata:
+  name: bar
+  namespace: default
+spec:
+  replicas: 3
+`
+	resource := &v1alpha1.ResourceMetricsMonitor{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo"},
+		Spec: v1alpha1.ResourceMetricsMonitorSpec{
+			Configuration: `stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    families:
+      - name: "replicas"
+        help: "Replicas"
+        metrics:
+          - labelKeys: ["name"]
+            labelValues: ["metadata.name"]
+            value: "spec.replicas"
+`,
+			Tests: []v1alpha1.ResourceMetricsMonitorTest{
+				{
+					Name:    "passing",
+					Objects: objects,
+					Expected: []string{
+						"# TYPE kube_customresource_replicas gauge",
+						`kube_customresource_replicas{name="bar",group="contoso.com",version="v1alpha1",kind="Bar"} 3`,
+					},
+				},
+				{
+					Name:     "failing",
+					Objects:  objects,
+					Expected: []string{`kube_customresource_replicas{name="bar",group="contoso.com",version="v1alpha1",kind="Bar"} 2`},
+				},
+			},
+		},
+	}
+
+	failures := runTests(context.Background(), resource)
+	if len(failures) != 1 || failures[1] == nil {
+		t.Fatalf("expected only the second test to fail, got %v", failures)
+	}
+	expected := `1 of 2 tests failed: failing: expected lines not found in the exposition: "kube_customresource_replicas{name=\"bar\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 2"`
+	if got := testsMessage(resource, failures); got != expected {
+		t.Errorf("expected message %q, got %q", expected, got)
+	}
+	if got := testsMessage(resource, nil); got != "All 2 tests passed" {
+		t.Errorf("expected all tests to pass, got %q", got)
+	}
+}
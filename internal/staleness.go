@@ -0,0 +1,273 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+// observe records that the given object was seen, as well as the store's watch being healthy. The caller must hold
+// the store's lock.
+func (s *StoreType) observe(uid types.UID) {
+	now := clockNow()
+	s.lastEvent = now
+	s.watchLostAt = time.Time{}
+	s.forbidden = 0
+	if uid != "" {
+		s.observed[uid] = now
+	}
+}
+
+// watched records the resource version of the given object, delivered through a watch event, along with the event's
+// estimated lag.
+func (s *StoreType) watched(objectI interface{}) {
+	object, err := meta.Accessor(objectI)
+	if err != nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.recordEvent(object)
+}
+
+// recordEvent records the resource version of the given object, delivered through a watch event, along with the
+// event's estimated lag, i.e., the time since the object was last written. The caller must hold the store's lock.
+func (s *StoreType) recordEvent(object metav1.Object) {
+	if resourceVersion := object.GetResourceVersion(); resourceVersion != "" {
+		s.lastResourceVersion = resourceVersion
+	}
+	if written := lastWritten(object); !written.IsZero() {
+		s.lag = max(time.Since(written), 0)
+	}
+}
+
+// lastWritten returns the time the given object was last written, as far as its metadata tells, i.e., the latest of
+// its creation, deletion, and managed fields' timestamps. These have a resolution of a second.
+func lastWritten(object metav1.Object) time.Time {
+	written := object.GetCreationTimestamp().Time
+	if deletion := object.GetDeletionTimestamp(); deletion != nil && deletion.After(written) {
+		written = deletion.Time
+	}
+	for _, entry := range object.GetManagedFields() {
+		if entry.Time != nil && entry.Time.After(written) {
+			written = entry.Time.Time
+		}
+	}
+
+	return written
+}
+
+// loseWatch records that the reflector backing the store, if any, failed to list or watch its target, unless it
+// already had.
+func (s *StoreType) loseWatch() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.watchLostAt.IsZero() {
+		s.watchLostAt = clockNow()
+	}
+}
+
+// stale reports whether the given object's series outlived the store's TTL, i.e., the object has not been observed
+// since the reflector lost its watch, longer than the TTL ago. The caller must hold the store's (read) lock.
+func (s *StoreType) stale(uid types.UID) bool {
+	if s.TTL.Duration <= 0 || s.watchLostAt.IsZero() || clockSince(s.watchLostAt) <= s.TTL.Duration {
+		return false
+	}
+
+	return s.observed[uid].Before(s.watchLostAt)
+}
+
+// storesCollector exposes the time each store last processed an event for, along with how far behind its target it
+// may be falling, and the resources it holds, across all monitors.
+type storesCollector struct {
+	stores                  *sync.Map
+	lastEventDesc           *prometheus.Desc
+	watchLostDesc           *prometheus.Desc
+	lastResourceVersionDesc *prometheus.Desc
+	lagDesc                 *prometheus.Desc
+	pendingAddsDesc         *prometheus.Desc
+	watchErrorsDesc         *prometheus.Desc
+	objectsDesc             *prometheus.Desc
+	bytesDesc               *prometheus.Desc
+	goroutinesDesc          *prometheus.Desc
+}
+
+// Ensure storesCollector implements prometheus.Collector.
+var _ prometheus.Collector = &storesCollector{}
+
+// newStoresCollector returns a collector for the given stores, with its metrics under the given namespace.
+func newStoresCollector(stores *sync.Map, namespace string) *storesCollector {
+	labelKeys := []string{"namespace", "name", "group", "version", "resource"}
+
+	return &storesCollector{
+		stores: stores,
+		lastEventDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "store", "last_event_timestamp_seconds"),
+			"The time a store last processed an event for its target, in seconds since the epoch.",
+			labelKeys, nil,
+		),
+		watchLostDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "store", "watch_lost_timestamp_seconds"),
+			"The time a store's reflector lost its watch, in seconds since the epoch, if it has not recovered since.",
+			labelKeys, nil,
+		),
+		lastResourceVersionDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "reflector", "last_resource_version"),
+			"The resource version of the last object, or list, a store's reflector delivered, if numeric.",
+			labelKeys, nil,
+		),
+		lagDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "store", "lag_seconds"),
+			"The estimated time the last watch event a store processed took to be delivered, since its object was last written.",
+			labelKeys, nil,
+		),
+		pendingAddsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "store", "pending_adds"),
+			"The number of objects waiting to be added to a store, e.g., through an ongoing (re)list.",
+			labelKeys, nil,
+		),
+		watchErrorsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "reflector", "watch_errors_total"),
+			"The number of times a store's reflector failed to list or watch its target, by cause, i.e., expired, forbidden, timeout, conversion, or other.",
+			append(slices.Clone(labelKeys), "cause"), nil,
+		),
+		objectsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "store", "objects"),
+			"The number of objects a store holds series for, including tombstoned ones.",
+			labelKeys, nil,
+		),
+		bytesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "store", "bytes"),
+			"The estimated memory a store's rendered series hold, in bytes.",
+			labelKeys, nil,
+		),
+		goroutinesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "store", "goroutines"),
+			"The number of goroutines running on behalf of a store, e.g., its reflectors, and their buffered watches.",
+			labelKeys, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *storesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lastEventDesc
+	ch <- c.watchLostDesc
+	ch <- c.lastResourceVersionDesc
+	ch <- c.lagDesc
+	ch <- c.pendingAddsDesc
+	ch <- c.watchErrorsDesc
+	ch <- c.objectsDesc
+	ch <- c.bytesDesc
+	ch <- c.goroutinesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *storesCollector) Collect(ch chan<- prometheus.Metric) {
+	c.stores.Range(func(key, value any) bool {
+		keyString, _ := key.(string)
+		objectName, err := cache.ParseObjectName(keyString)
+		if err != nil {
+			return true
+		}
+		builtStores, _ := value.([]*StoreType)
+		for _, s := range builtStores {
+			s.mutex.RLock()
+			targets := append([]*StoreType{s}, s.selectedStores()...)
+			s.mutex.RUnlock()
+			for _, target := range targets {
+				// Stores selecting their targets by CRD labels have none of their own.
+				if target.Resource == "" {
+					continue
+				}
+				target.mutex.RLock()
+				lastEvent, watchLostAt := target.lastEvent, target.watchLostAt
+				lastResourceVersion, lag := target.lastResourceVersion, target.lag
+				watchErrors := maps.Clone(target.watchErrors)
+				objects, size := len(target.namespaces), target.size
+				target.mutex.RUnlock()
+				labelValues := []string{objectName.Namespace, objectName.Name, target.Group, target.Version, target.Resource}
+				if !lastEvent.IsZero() {
+					ch <- prometheus.MustNewConstMetric(c.lastEventDesc, prometheus.GaugeValue, float64(lastEvent.UnixNano())/1e9, labelValues...)
+					ch <- prometheus.MustNewConstMetric(c.lagDesc, prometheus.GaugeValue, lag.Seconds(), labelValues...)
+					ch <- prometheus.MustNewConstMetric(c.pendingAddsDesc, prometheus.GaugeValue, float64(target.pendingAdds.Load()), labelValues...)
+				}
+				// The resources a store holds are reported from the start, e.g., the goroutines of a reflector failing
+				// to list its target.
+				ch <- prometheus.MustNewConstMetric(c.objectsDesc, prometheus.GaugeValue, float64(objects), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.bytesDesc, prometheus.GaugeValue, float64(size), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.goroutinesDesc, prometheus.GaugeValue, float64(target.goroutines.Load()), labelValues...)
+				if !watchLostAt.IsZero() {
+					ch <- prometheus.MustNewConstMetric(c.watchLostDesc, prometheus.GaugeValue, float64(watchLostAt.UnixNano())/1e9, labelValues...)
+				}
+				// Resource versions are opaque, but etcd-backed API servers serve them as integers.
+				if resourceVersion, err := strconv.ParseUint(lastResourceVersion, 10, 64); err == nil {
+					ch <- prometheus.MustNewConstMetric(c.lastResourceVersionDesc, prometheus.GaugeValue, float64(resourceVersion), labelValues...)
+				}
+				for cause, count := range watchErrors {
+					ch <- prometheus.MustNewConstMetric(c.watchErrorsDesc, prometheus.CounterValue, float64(count), append(slices.Clone(labelValues), cause)...)
+				}
+			}
+		}
+
+		return true
+	})
+}
+
+// storesSynced returns the number of stores, across all monitors, that have processed their initial lists, as well as
+// the total number of stores, to track the progress of building them (e.g., on controller start).
+func storesSynced(stores *sync.Map) (synced, total int) {
+	stores.Range(func(_, value any) bool {
+		builtStores, _ := value.([]*StoreType)
+		for _, s := range builtStores {
+			s.mutex.RLock()
+			targets := append([]*StoreType{s}, s.selectedStores()...)
+			s.mutex.RUnlock()
+			for _, target := range targets {
+				// Stores selecting their targets by CRD labels have none of their own.
+				if target.Resource == "" {
+					continue
+				}
+				total++
+				target.mutex.RLock()
+				if !target.lastEvent.IsZero() {
+					synced++
+				}
+				target.mutex.RUnlock()
+			}
+		}
+
+		return true
+	})
+
+	return synced, total
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+func TestStoreType_stale(t *testing.T) {
+	t.Parallel()
+	s := newStore(klog.Background(), []string{"# HELP kube_customresource_replicas"}, []*FamilyType{
+		{
+			Name:    "replicas",
+			Metrics: []*MetricType{{Value: "spec.replicas"}},
+		},
+	}, ResolverTypeUnstructured, nil, nil, 0, 0)
+	s.TTL = metav1.Duration{Duration: time.Minute}
+	object := newSyntheticObjects(1)[0]
+	if err := s.Add(object); err != nil {
+		t.Fatal(err)
+	}
+
+	exposition := func() string {
+		t.Helper()
+		buffer := &bytes.Buffer{}
+		if err := newMetricsWriter(s).writeStores(buffer); err != nil {
+			t.Fatal(err)
+		}
+
+		return buffer.String()
+	}
+	fresh := "# HELP kube_customresource_replicas\n" +
+		"kube_customresource_replicas{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 0\n"
+
+	// Series outlive a lost watch for the TTL.
+	s.loseWatch()
+	if diff := cmp.Diff(exposition(), fresh); diff != "" {
+		t.Errorf("%s", diff)
+	}
+
+	// Series are dropped once the watch has been lost for longer than the TTL.
+	s.observed[object.GetUID()] = time.Now().Add(-3 * time.Minute)
+	s.watchLostAt = time.Now().Add(-2 * time.Minute)
+	if diff := cmp.Diff(exposition(), "# HELP kube_customresource_replicas\n"); diff != "" {
+		t.Errorf("%s", diff)
+	}
+
+	// Relisting recovers the watch, and the series of the listed objects.
+	if err := s.Replace([]interface{}{object}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(exposition(), fresh); diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+func TestStoresCollector(t *testing.T) {
+	t.Parallel()
+	s := newStore(klog.Background(), []string{"# HELP kube_customresource_replicas"}, []*FamilyType{
+		{
+			Name:    "replicas",
+			Metrics: []*MetricType{{Value: "spec.replicas"}},
+		},
+	}, ResolverTypeUnstructured, nil, nil, 0, 0)
+	s.Group, s.Version, s.Resource = "contoso.com", "v1alpha1", "bars"
+	stores := &sync.Map{}
+	stores.Store("default/foo", []*StoreType{s})
+	collector := newStoresCollector(stores, "resource_state_metrics")
+
+	// Stores that have not processed any events yet only report the resources they hold.
+	if got := testutil.CollectAndCount(collector); got != 3 {
+		t.Errorf("expected 3 series, got %d", got)
+	}
+	if got := testutil.CollectAndCount(collector, "resource_state_metrics_store_last_event_timestamp_seconds"); got != 0 {
+		t.Errorf("expected no last event series, got %d", got)
+	}
+
+	if err := s.Add(newSyntheticObjects(1)[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.CollectAndCount(collector, "resource_state_metrics_store_last_event_timestamp_seconds"); got != 1 {
+		t.Errorf("expected 1 last event series, got %d", got)
+	}
+	s.loseWatch()
+	if got := testutil.CollectAndCount(collector, "resource_state_metrics_store_watch_lost_timestamp_seconds"); got != 1 {
+		t.Errorf("expected 1 watch lost series, got %d", got)
+	}
+
+	// Stores report the resource version they last saw, along with how far behind they may be falling.
+	object := newSyntheticObjects(1)[0]
+	object.SetResourceVersion("42")
+	object.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-time.Minute)))
+	if err := s.Update(object); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP resource_state_metrics_reflector_last_resource_version The resource version of the last object, or list, a store's reflector delivered, if numeric.
+# TYPE resource_state_metrics_reflector_last_resource_version gauge
+resource_state_metrics_reflector_last_resource_version{group="contoso.com",name="foo",namespace="default",resource="bars",version="v1alpha1"} 42
+# HELP resource_state_metrics_store_pending_adds The number of objects waiting to be added to a store, e.g., through an ongoing (re)list.
+# TYPE resource_state_metrics_store_pending_adds gauge
+resource_state_metrics_store_pending_adds{group="contoso.com",name="foo",namespace="default",resource="bars",version="v1alpha1"} 0
+# HELP resource_state_metrics_store_objects The number of objects a store holds series for, including tombstoned ones.
+# TYPE resource_state_metrics_store_objects gauge
+resource_state_metrics_store_objects{group="contoso.com",name="foo",namespace="default",resource="bars",version="v1alpha1"} 1
+# HELP resource_state_metrics_store_goroutines The number of goroutines running on behalf of a store, e.g., its reflectors, and their buffered watches.
+# TYPE resource_state_metrics_store_goroutines gauge
+resource_state_metrics_store_goroutines{group="contoso.com",name="foo",namespace="default",resource="bars",version="v1alpha1"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "resource_state_metrics_reflector_last_resource_version", "resource_state_metrics_store_pending_adds", "resource_state_metrics_store_objects", "resource_state_metrics_store_goroutines"); err != nil {
+		t.Error(err)
+	}
+	s.mutex.RLock()
+	lag, size := s.lag, s.size
+	s.mutex.RUnlock()
+	if size == 0 {
+		t.Error("expected the store's series to hold a non-zero estimated size")
+	}
+	expected = fmt.Sprintf(`# HELP resource_state_metrics_store_bytes The estimated memory a store's rendered series hold, in bytes.
+# TYPE resource_state_metrics_store_bytes gauge
+resource_state_metrics_store_bytes{group="contoso.com",name="foo",namespace="default",resource="bars",version="v1alpha1"} %d
+`, size)
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "resource_state_metrics_store_bytes"); err != nil {
+		t.Error(err)
+	}
+	if lag < time.Minute {
+		t.Errorf("expected a lag of at least a minute, got %s", lag)
+	}
+}
+
+func TestStoresSynced(t *testing.T) {
+	t.Parallel()
+	newTarget := func() *StoreType {
+		s := newStore(klog.Background(), nil, nil, ResolverTypeUnstructured, nil, nil, 0, 0)
+		s.Group, s.Version, s.Resource = "contoso.com", "v1alpha1", "bars"
+
+		return s
+	}
+	synced, pending := newTarget(), newTarget()
+	stores := &sync.Map{}
+	stores.Store("default/foo", []*StoreType{synced})
+	stores.Store("default/bar", []*StoreType{pending})
+
+	if err := synced.Replace(nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if gotSynced, gotTotal := storesSynced(stores); gotSynced != 1 || gotTotal != 2 {
+		t.Errorf("expected 1/2 stores to be synced, got %d/%d", gotSynced, gotTotal)
+	}
+}
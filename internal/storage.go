@@ -0,0 +1,401 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// StorageType represents the backend the stores' rendered series are held in.
+type StorageType string
+
+const (
+	// StorageTypeMemory holds the series of all objects in memory.
+	StorageTypeMemory StorageType = "memory"
+	// StorageTypeBounded holds the series of up to a fixed number of objects per store in memory, evicting the ones of
+	// the least recently updated objects to make room for new ones.
+	StorageTypeBounded StorageType = "bounded"
+	// StorageTypeDisk holds the series on disk, in a bbolt database, so they are served across restarts, until the
+	// stores' initial lists replace them.
+	StorageTypeDisk StorageType = "disk"
+)
+
+// diskStorageFile is the name of the database file the disk storage keeps in its directory.
+const diskStorageFile = "series.db"
+
+// seriesStorage holds the rendered series of a store's objects, by their UIDs. Implementations need not be safe for
+// concurrent use, as the store's lock guards them.
+type seriesStorage interface {
+	// get returns the series of the given object, if any.
+	get(uid types.UID) ([]string, bool)
+	// set stores the series of the given object, in the given namespace, returning the ones evicted to make room for
+	// them, if any, by their objects' UIDs.
+	set(uid types.UID, namespace string, series []string) map[types.UID][]string
+	// remove drops the series of the given object.
+	remove(uid types.UID)
+	// forEach calls fn with the series of each object, until it returns an error.
+	forEach(fn func(uid types.UID, series []string) error) error
+	// purge drops the series of all objects, e.g., once the monitor the store is built for is deleted.
+	purge()
+}
+
+// seriesRestorer is implemented by storages that may hold series as of the last run, to restore them.
+type seriesRestorer interface {
+	// restore returns the namespaces of the objects series are held for, by their UIDs, along with the size of their
+	// series, in bytes.
+	restore() (map[types.UID]string, int64)
+}
+
+// storageFactory returns the storage for the series of the store with the given key, unique to each store across
+// restarts.
+type storageFactory func(key string) seriesStorage
+
+// memoryStorage holds the series of all objects in memory.
+type memoryStorage map[types.UID][]string
+
+// Ensure memoryStorage implements seriesStorage.
+var _ seriesStorage = memoryStorage{}
+
+func (m memoryStorage) get(uid types.UID) ([]string, bool) {
+	series, ok := m[uid]
+
+	return series, ok
+}
+
+func (m memoryStorage) set(uid types.UID, _ string, series []string) map[types.UID][]string {
+	m[uid] = series
+
+	return nil
+}
+
+func (m memoryStorage) remove(uid types.UID) {
+	delete(m, uid)
+}
+
+func (m memoryStorage) forEach(fn func(uid types.UID, series []string) error) error {
+	for uid, series := range m {
+		if err := fn(uid, series); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m memoryStorage) purge() {
+	clear(m)
+}
+
+// boundedStorage holds the series of up to a fixed number of objects in memory, evicting the ones of the least recently
+// updated objects first.
+type boundedStorage struct {
+	// maxObjects is the number of objects series are held for.
+	maxObjects int
+	// elements holds the element of each object in the order, by its UID.
+	elements map[types.UID]*list.Element
+	// order holds the objects' series, from the least to the most recently updated.
+	order *list.List
+}
+
+// boundedEntry is an object's series, as held in the order of a boundedStorage.
+type boundedEntry struct {
+	uid    types.UID
+	series []string
+}
+
+// Ensure boundedStorage implements seriesStorage.
+var _ seriesStorage = &boundedStorage{}
+
+// newBoundedStorage returns a boundedStorage holding the series of up to the given number of objects.
+func newBoundedStorage(maxObjects int) *boundedStorage {
+	return &boundedStorage{
+		maxObjects: max(maxObjects, 1),
+		elements:   map[types.UID]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+func (b *boundedStorage) get(uid types.UID) ([]string, bool) {
+	element, ok := b.elements[uid]
+	if !ok {
+		return nil, false
+	}
+
+	return element.Value.(*boundedEntry).series, true
+}
+
+func (b *boundedStorage) set(uid types.UID, _ string, series []string) map[types.UID][]string {
+	if element, ok := b.elements[uid]; ok {
+		element.Value.(*boundedEntry).series = series
+		b.order.MoveToBack(element)
+
+		return nil
+	}
+	var evicted map[types.UID][]string
+	for b.order.Len() >= b.maxObjects {
+		oldest := b.order.Remove(b.order.Front()).(*boundedEntry)
+		delete(b.elements, oldest.uid)
+		if evicted == nil {
+			evicted = map[types.UID][]string{}
+		}
+		evicted[oldest.uid] = oldest.series
+	}
+	b.elements[uid] = b.order.PushBack(&boundedEntry{uid: uid, series: series})
+
+	return evicted
+}
+
+func (b *boundedStorage) remove(uid types.UID) {
+	if element, ok := b.elements[uid]; ok {
+		b.order.Remove(element)
+		delete(b.elements, uid)
+	}
+}
+
+func (b *boundedStorage) forEach(fn func(uid types.UID, series []string) error) error {
+	for element := b.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*boundedEntry)
+		if err := fn(entry.uid, entry.series); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *boundedStorage) purge() {
+	clear(b.elements)
+	b.order.Init()
+}
+
+// diskStorage holds the series of all objects in a bucket of a bbolt database, shared by all stores.
+type diskStorage struct {
+	logger klog.Logger
+	db     *bbolt.DB
+	bucket []byte
+}
+
+// diskEntry is an object's series, as encoded in a diskStorage's bucket.
+type diskEntry struct {
+	Namespace string   `json:"namespace"`
+	Series    []string `json:"series"`
+}
+
+// Ensure diskStorage implements seriesStorage and seriesRestorer.
+var (
+	_ seriesStorage  = &diskStorage{}
+	_ seriesRestorer = &diskStorage{}
+)
+
+// openDiskStorage opens the database the disk storage keeps in the given directory. The database is not synced on
+// each write, since the series are re-rendered off the stores' initial lists anyway, so only the ones written since
+// the last sync are lost on an abrupt shutdown of the host.
+func openDiskStorage(directory string) (*bbolt.DB, error) {
+	if directory == "" {
+		return nil, errors.New("a directory is required for the disk storage")
+	}
+	db, err := bbolt.Open(filepath.Join(directory, diskStorageFile), 0o600, &bbolt.Options{Timeout: 10 * time.Second, NoSync: true})
+	if err != nil {
+		return nil, fmt.Errorf("error opening the disk storage: %w", err)
+	}
+
+	return db, nil
+}
+
+// newDiskStorage returns a diskStorage holding the series in the bucket named after the given key.
+func newDiskStorage(logger klog.Logger, db *bbolt.DB, key string) *diskStorage {
+	return &diskStorage{logger: logger.WithValues("bucket", key), db: db, bucket: []byte(key)}
+}
+
+func (d *diskStorage) get(uid types.UID) ([]string, bool) {
+	var entry *diskEntry
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(d.bucket)
+		if bucket == nil {
+			return nil
+		}
+		value := bucket.Get([]byte(uid))
+		if value == nil {
+			return nil
+		}
+		entry = &diskEntry{}
+
+		return json.Unmarshal(value, entry)
+	})
+	if err != nil {
+		d.logger.Error(err, "error reading series", "uid", uid)
+
+		return nil, false
+	}
+	if entry == nil {
+		return nil, false
+	}
+
+	return entry.Series, true
+}
+
+func (d *diskStorage) set(uid types.UID, namespace string, series []string) map[types.UID][]string {
+	value, err := json.Marshal(diskEntry{Namespace: namespace, Series: series})
+	if err == nil {
+		err = d.db.Update(func(tx *bbolt.Tx) error {
+			bucket, err := tx.CreateBucketIfNotExists(d.bucket)
+			if err != nil {
+				return err
+			}
+
+			return bucket.Put([]byte(uid), value)
+		})
+	}
+	if err != nil {
+		d.logger.Error(err, "error writing series", "uid", uid)
+	}
+
+	return nil
+}
+
+func (d *diskStorage) remove(uid types.UID) {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(d.bucket)
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.Delete([]byte(uid))
+	})
+	if err != nil {
+		d.logger.Error(err, "error deleting series", "uid", uid)
+	}
+}
+
+func (d *diskStorage) forEach(fn func(uid types.UID, series []string) error) error {
+	return d.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(d.bucket)
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(key, value []byte) error {
+			entry := diskEntry{}
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return fmt.Errorf("error reading series of %s: %w", key, err)
+			}
+
+			return fn(types.UID(key), entry.Series)
+		})
+	})
+}
+
+func (d *diskStorage) purge() {
+	err := d.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(d.bucket) == nil {
+			return nil
+		}
+
+		return tx.DeleteBucket(d.bucket)
+	})
+	if err != nil {
+		d.logger.Error(err, "error deleting series")
+	}
+}
+
+// restore returns the namespaces of the objects series were held for as of the last run, by their UIDs, along with the
+// size of their series, in bytes.
+func (d *diskStorage) restore() (map[types.UID]string, int64) {
+	restored := map[types.UID]string{}
+	var size int64
+	err := d.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(d.bucket)
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(key, value []byte) error {
+			entry := diskEntry{}
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return fmt.Errorf("error reading series of %s: %w", key, err)
+			}
+			restored[types.UID(key)] = entry.Namespace
+			size += seriesSize(entry.Series)
+
+			return nil
+		})
+	})
+	if err != nil {
+		d.logger.Error(err, "error restoring series")
+	}
+
+	return restored, size
+}
+
+// newStorageFactory returns the factory of the storage of the given type, holding the series of up to the given number
+// of objects per store, for the bounded storage, or in the given database, for the disk storage.
+func newStorageFactory(logger klog.Logger, storageType StorageType, maxObjects int, db *bbolt.DB) storageFactory {
+	switch storageType {
+	case StorageTypeBounded:
+		return func(string) seriesStorage { return newBoundedStorage(maxObjects) }
+	case StorageTypeDisk:
+		return func(key string) seriesStorage { return newDiskStorage(logger, db, key) }
+	default:
+		return func(string) seriesStorage { return memoryStorage{} }
+	}
+}
+
+// useStorage sets the storage the store's series are held in, serving the series it restores, if any, until the
+// store's initial list replaces them. The storage must be set before the store processes any events.
+func (s *StoreType) useStorage(storage seriesStorage) {
+	s.metrics = storage
+	if restorer, ok := storage.(seriesRestorer); ok {
+		var size int64
+		s.restored, size = restorer.restore()
+		for uid, namespace := range s.restored {
+			s.namespaces[uid] = namespace
+		}
+		s.size += size
+	}
+}
+
+// dropRestored drops the series restored off the storage for objects the store's initial list did not include, i.e.,
+// ones deleted since. The caller must hold the store's lock.
+func (s *StoreType) dropRestored() {
+	for uid := range s.restored {
+		s.deleteMetrics(uid)
+		delete(s.namespaces, uid)
+	}
+	s.restored = nil
+}
+
+// purge drops the series of all objects off the store's storage, and the ones of its selected stores, e.g., once the
+// monitor it is built for is deleted, so storages outliving the controller do not retain them.
+func (s *StoreType) purge() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.metrics.purge()
+	s.size = 0
+	s.resetDeltas()
+	for _, selectedStore := range s.selected {
+		selectedStore.purge()
+	}
+}
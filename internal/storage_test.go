@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+func TestBoundedStorage(t *testing.T) {
+	t.Parallel()
+	storage := newBoundedStorage(2)
+	storage.set("uid1", "default", []string{"foo 1\n"})
+	storage.set("uid2", "default", []string{"foo 2\n"})
+
+	// Updating an object makes it the most recently updated one.
+	if evicted := storage.set("uid1", "default", []string{"foo 3\n"}); evicted != nil {
+		t.Fatalf("expected no evictions when updating an object, got %v", evicted)
+	}
+	evicted := storage.set("uid3", "default", []string{"foo 4\n"})
+	if diff := cmp.Diff(evicted, map[types.UID][]string{"uid2": {"foo 2\n"}}); diff != "" {
+		t.Errorf("%s", diff)
+	}
+
+	got := map[types.UID][]string{}
+	if err := storage.forEach(func(uid types.UID, series []string) error {
+		got[uid] = series
+
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got, map[types.UID][]string{"uid1": {"foo 3\n"}, "uid3": {"foo 4\n"}}); diff != "" {
+		t.Errorf("%s", diff)
+	}
+
+	storage.purge()
+	if _, ok := storage.get("uid1"); ok {
+		t.Error("expected no series once purged")
+	}
+}
+
+func TestDiskStorage(t *testing.T) {
+	t.Parallel()
+	directory := t.TempDir()
+	db, err := openDiskStorage(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := newDiskStorage(klog.Background(), db, "default/foo/0")
+	storage.set("uid1", "foo", []string{"foo 1\n", ""})
+	storage.set("uid2", "bar", []string{"foo 2\n", ""})
+	storage.remove("uid2")
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Series outlive the database being closed, and are restored once it is reopened.
+	db, err = openDiskStorage(directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	storage = newDiskStorage(klog.Background(), db, "default/foo/0")
+	restored, size := storage.restore()
+	if diff := cmp.Diff(restored, map[types.UID]string{"uid1": "foo"}); diff != "" {
+		t.Errorf("%s", diff)
+	}
+	if size != seriesSize([]string{"foo 1\n", ""}) {
+		t.Errorf("expected the size of the restored series, got %d", size)
+	}
+	if got, _ := storage.get("uid1"); !cmp.Equal(got, []string{"foo 1\n", ""}) {
+		t.Errorf("expected the restored series, got %q", got)
+	}
+
+	// Buckets are independent of each other.
+	if _, ok := newDiskStorage(klog.Background(), db, "default/bar/0").get("uid1"); ok {
+		t.Error("expected no series in another bucket")
+	}
+
+	storage.purge()
+	if restored, _ := storage.restore(); len(restored) != 0 {
+		t.Errorf("expected no series once purged, got %v", restored)
+	}
+}
@@ -1,14 +1,20 @@
 package internal
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 )
 
@@ -17,10 +23,80 @@ import (
 type StoreType struct {
 	logger       klog.Logger
 	mutex        sync.RWMutex
-	metrics      map[types.UID][]string
+	metrics      seriesStorage
 	headers      []string
 	celCostLimit uint64
 	celTimeout   time.Duration
+	// namespaces holds the namespace of each object metrics are stored for.
+	namespaces map[types.UID]string
+	// selected holds the stores built for each CRD matching the CRD selector, if set, keyed by the CRD's UID, or for
+	// each of the store's sources, if any.
+	selected map[types.UID]*StoreType
+	// stop stops the reflector backing the store, if any.
+	stop context.CancelFunc
+	// filter narrows down the objects the store generates metrics for, client-side.
+	filter *storeFilter
+	// cluster is the federated cluster the store's objects come from, if it is fed by a single one.
+	cluster string
+	// tombstones holds the time the series of each deleted object are retained until.
+	tombstones map[types.UID]time.Time
+	// observed holds the time each object was last seen, through either a list or a watch event.
+	observed map[types.UID]time.Time
+	// lastEvent is the time the store last processed an event.
+	lastEvent time.Time
+	// watchLostAt is the time the reflector backing the store failed to list or watch, if it has not recovered since.
+	watchLostAt time.Time
+	// watchErrors holds the number of times the reflector backing the store failed to list or watch, by cause.
+	watchErrors map[string]uint64
+	// forbidden is the number of consecutive times the reflector backing the store was forbidden from listing or
+	// watching.
+	forbidden int
+	// listPageSize is the number of objects to list per page, or 0 to list all objects at once.
+	listPageSize int64
+	// size is the estimated memory held by the store's series, in bytes.
+	size int64
+	// objects holds the objects cached for the families rendered at scrape time, if any.
+	objects map[types.UID]scrapedObject
+	// lastResourceVersion is the resource version of the last object, or list, the reflector delivered.
+	lastResourceVersion string
+	// lag is the estimated time the last watch event took to be delivered, since its object was written.
+	lag time.Duration
+	// goroutines is the number of goroutines running on behalf of the store, e.g., its reflectors.
+	goroutines atomic.Int64
+	// pendingAdds is the number of objects waiting to be added, e.g., through an ongoing (re)list.
+	pendingAdds atomic.Int64
+	// monitorCreated is the time the monitor the store is built for was created.
+	monitorCreated time.Time
+	// tier is the scrape tier of the monitor the store is built for.
+	tier scrapeTier
+	// restored holds the namespaces of the objects whose series were restored off the storage, until the store's
+	// initial list replaces them.
+	restored map[types.UID]string
+	// storage, if set, returns the storage for the series of the stores built for each CRD-selected target.
+	storage storageFactory
+	// referenced holds the fields of the objects the store's families reference, if they can all be told apart.
+	referenced referencedFields
+	// digests holds the digest of the referenced fields of each object, as of its series' last rendering.
+	digests map[types.UID]uint64
+	// revisions holds the revision each object's series last changed at.
+	revisions map[types.UID]uint64
+	// deletions holds the series of the objects last deleted, to serve deltas with.
+	deletions []deletion
+	// deltasSince is the earliest revision the store serves deltas since.
+	deltasSince uint64
+	// clusters holds the (federated) cluster each object was last seen in, to tell the objects a relist of the cluster
+	// no longer includes.
+	clusters map[types.UID]string
+	// names holds the name of each object metrics are stored for, to serve them on the custom metrics API.
+	names map[types.UID]string
+	// sourceVersion is the version the store's objects come from, if the store is built for one of several sources,
+	// qualified by its group, if other than the store's.
+	sourceVersion string
+	// sourcePriority orders the stores built for each of a store's sources, the store's own target first.
+	sourcePriority int
+	// sourced reports whether the store's selected stores are built for each of its sources, in which case objects
+	// served by several of them, i.e., sharing their UIDs, are only exposed off the first one.
+	sourced bool
 
 	// Configuration fields unmarshalled from YAML
 	Group     string `yaml:"group"`
@@ -30,11 +106,39 @@ type StoreType struct {
 	Selectors struct {
 		Label string `yaml:"label,omitempty"`
 		Field string `yaml:"field,omitempty"`
+		// CRD selects the targets by the labels of their CRDs, in place of the group, version, kind, and resource.
+		CRD string `yaml:"crd,omitempty"`
+		// Filter is a CEL expression objects must hold true for to be cached, evaluated client-side.
+		Filter string `yaml:"filter,omitempty"`
 	} `yaml:"selectors,omitempty"`
-	Families    []*FamilyType `yaml:"families"`
-	Resolver    ResolverType  `yaml:"resolver,omitempty"`
-	LabelKeys   []string      `yaml:"labelKeys,omitempty"`
-	LabelValues []string      `yaml:"labelValues,omitempty"`
+	// Sources, if set, are other versions of the target, e.g., of an API being migrated, the store sources objects from
+	// as well, labeling each sample with the version it comes from.
+	Sources []SourceType `yaml:"sources,omitempty"`
+	// TombstoneRetention, if set, retains the last series of deleted objects, labeled with deleted="true", for as long.
+	TombstoneRetention metav1.Duration `yaml:"tombstoneRetention,omitempty"`
+	// TTL, if set, drops the series of objects not seen since the reflector lost its watch, once it has been lost for as
+	// long, instead of serving them as if fresh.
+	TTL metav1.Duration `yaml:"ttl,omitempty"`
+	// Concurrency, if set, tunes how the store processes its events, for targets seeing many of them per second.
+	Concurrency ConcurrencyType `yaml:"concurrency,omitempty"`
+	// CEL, if set, overrides the limits the store's CEL expressions are evaluated within, as set through the flags.
+	CEL struct {
+		CostLimit uint64          `yaml:"costLimit,omitempty"`
+		Timeout   metav1.Duration `yaml:"timeout,omitempty"`
+	} `yaml:"cel,omitempty"`
+	// Credentials, if set, are the credentials the store lists and watches its targets with, in place of the
+	// controller's.
+	Credentials *CredentialsType `yaml:"credentials,omitempty"`
+	// clientset, if set, is the client-set built off the store's credentials.
+	clientset dynamic.Interface
+	Families  []*FamilyType `yaml:"families"`
+	// Generators, if set, generate built-in families for the store's targets, in addition to the configured ones.
+	Generators  []GeneratorType `yaml:"generators,omitempty"`
+	Resolver    ResolverType    `yaml:"resolver,omitempty"`
+	LabelKeys   []string        `yaml:"labelKeys,omitempty"`
+	LabelValues []string        `yaml:"labelValues,omitempty"`
+	// Thresholds, if set, are evaluated against the store's series periodically, notifying whenever any is crossed.
+	Thresholds []ThresholdType `yaml:"thresholds,omitempty"`
 }
 
 func newStore(
@@ -46,31 +150,106 @@ func newStore(
 	celCostLimit uint64,
 	celTimeout time.Duration,
 ) *StoreType {
-	return &StoreType{
+	s := &StoreType{
 		logger:       logger,
-		metrics:      map[types.UID][]string{},
+		metrics:      memoryStorage{},
+		namespaces:   map[types.UID]string{},
+		tombstones:   map[types.UID]time.Time{},
+		observed:     map[types.UID]time.Time{},
+		objects:      map[types.UID]scrapedObject{},
+		digests:      map[types.UID]uint64{},
+		revisions:    map[types.UID]uint64{},
+		names:        map[types.UID]string{},
+		clusters:     map[types.UID]string{},
 		headers:      headers,
-		Families:     families,
+		Families:     compileFamilies(logger, families, resolver, labelKeys, labelValues),
 		Resolver:     resolver,
 		LabelKeys:    labelKeys,
 		LabelValues:  labelValues,
 		celCostLimit: celCostLimit,
 		celTimeout:   celTimeout,
 	}
+	s.referenced = referencedFieldsOf(s.Families)
+
+	return s
 }
 
 // Add is called when a new object is added, and it generates the associated metrics for the object and stores them in the store.metrics map.
 func (s *StoreType) Add(objectI interface{}) error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	s.watched(objectI)
+
+	return s.add(objectI, s.cluster, false)
+}
+
+// add generates the metrics for the given object, labeled with the given (federated) cluster, if any, relisted or
+// otherwise.
+func (s *StoreType) add(objectI interface{}, cluster string, relisted bool) error {
+	s.pendingAdds.Add(1)
+	defer s.pendingAdds.Add(-1)
 
 	unstructuredObject, err := convertToUnstructured(objectI)
 	if err != nil {
 		return err
 	}
 
-	metrics := s.generateMetricsForObject(unstructuredObject)
-	s.metrics[unstructuredObject.GetUID()] = metrics
+	// Objects are rendered off the lock, as compiled families are never mutated, so several may be rendered at once.
+	// Objects none of whose referenced fields changed since they were last rendered are not rendered again.
+	matches := s.filter.matches(unstructuredObject)
+	// Relisted objects whose referenced fields cannot be told apart are not rendered again either if their content did not
+	// change at all, which, unlike watch events, relists mostly deliver.
+	digest, digested := s.referenced.digest(unstructuredObject)
+	if !digested && relisted {
+		digest, digested = contentDigest(unstructuredObject)
+	}
+	var metrics []string
+	if matches && !s.rendered(unstructuredObject.GetUID(), digest, digested) {
+		metrics = s.generateMetricsForObject(unstructuredObject)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pruneTombstones()
+	s.observe(unstructuredObject.GetUID())
+
+	if !matches {
+		s.deleteMetrics(unstructuredObject.GetUID())
+		delete(s.namespaces, unstructuredObject.GetUID())
+		delete(s.observed, unstructuredObject.GetUID())
+		s.logger.V(4).Info("Filtered", "key", klog.KObj(unstructuredObject))
+
+		return nil
+	}
+
+	s.clusters[unstructuredObject.GetUID()] = cluster
+
+	if metrics == nil {
+		if previous, ok := s.digests[unstructuredObject.GetUID()]; ok && previous == digest {
+			if s.rendersOnScrape() {
+				s.objects[unstructuredObject.GetUID()] = scrapedObject{object: unstructuredObject, cluster: cluster}
+			}
+			s.logger.V(4).Info("Unchanged", "key", klog.KObj(unstructuredObject))
+
+			return nil
+		}
+		// The object's series were dropped in the meantime, e.g., evicted off the storage.
+		metrics = s.generateMetricsForObject(unstructuredObject)
+	}
+	if digested {
+		s.digests[unstructuredObject.GetUID()] = digest
+	} else {
+		delete(s.digests, unstructuredObject.GetUID())
+	}
+
+	for i := range metrics {
+		metrics[i] = withSourceVersionLabel(withClusterLabel(metrics[i], cluster), s.sourceVersion)
+	}
+	s.namespaces[unstructuredObject.GetUID()] = unstructuredObject.GetNamespace()
+	s.names[unstructuredObject.GetUID()] = unstructuredObject.GetName()
+	delete(s.restored, unstructuredObject.GetUID())
+	s.setMetrics(unstructuredObject.GetUID(), metrics)
+	if s.rendersOnScrape() {
+		s.objects[unstructuredObject.GetUID()] = scrapedObject{object: unstructuredObject, cluster: cluster}
+	}
 	s.logger.V(2).Info("Add", "key", klog.KObj(unstructuredObject))
 
 	return nil
@@ -95,40 +274,163 @@ func (s *StoreType) Delete(objectI interface{}) error {
 	}
 
 	s.logger.V(2).Info("Delete", "key", klog.KObj(object))
-	s.logger.V(4).Info("Delete", "metrics", s.metrics[object.GetUID()])
-	delete(s.metrics, object.GetUID())
+	if s.logger.V(4).Enabled() {
+		metrics, _ := s.metrics.get(object.GetUID())
+		s.logger.V(4).Info("Delete", "metrics", metrics)
+	}
+	s.pruneTombstones()
+	s.observe("")
+	s.recordEvent(object)
+	s.remove(object.GetUID())
 
 	return nil
 }
 
+// remove drops the metrics of the deleted object with the given UID, or retains them until its tombstone expires. The
+// caller must hold the lock.
+func (s *StoreType) remove(uid types.UID) {
+	delete(s.observed, uid)
+	delete(s.digests, uid)
+	delete(s.clusters, uid)
+	if s.TombstoneRetention.Duration > 0 {
+		s.tombstone(uid)
+
+		return
+	}
+	s.deleteMetrics(uid)
+	delete(s.namespaces, uid)
+}
+
 // Replace is called when the reflector does a resync or starts up and lists all existing objects.
-func (s *StoreType) Replace(items []interface{}, _ string) error {
-	for _, item := range items {
-		if err := s.Add(item); err != nil {
+func (s *StoreType) Replace(items []interface{}, resourceVersion string) error {
+	return s.replace(items, resourceVersion, s.cluster)
+}
+
+// replace generates the metrics for all given objects, labeled with the given (federated) cluster, if any.
+func (s *StoreType) replace(items []interface{}, resourceVersion, cluster string) error {
+	// A successful (re)list recovers the watch, even for targets with no objects.
+	s.mutex.Lock()
+	s.observe("")
+	if resourceVersion != "" {
+		s.lastResourceVersion = resourceVersion
+	}
+	s.mutex.Unlock()
+
+	s.pendingAdds.Add(int64(len(items)))
+	forEachConcurrently(len(items), s.Concurrency.MaxConcurrentAdds, func(i int) {
+		s.pendingAdds.Add(-1)
+		if err := s.add(items[i], cluster, true); err != nil {
 			s.logger.Error(err, "failed to add item during replace")
 		}
-	}
+	})
+
+	// Series restored off the storage for objects the list did not include are no longer served, and neither are the ones
+	// of the objects of the cluster deleted while the watch was down.
+	s.mutex.Lock()
+	s.dropRestored()
+	s.dropUnlisted(items, cluster)
+	s.mutex.Unlock()
 
 	return nil
 }
 
-// Stub implementations for interface compatibility.
+// dropUnlisted removes the objects last seen in the given (federated) cluster that the given list of it does not
+// include. The caller must hold the lock.
+func (s *StoreType) dropUnlisted(items []interface{}, cluster string) {
+	listed := make(map[types.UID]struct{}, len(items))
+	for _, item := range items {
+		if object, err := meta.Accessor(item); err == nil {
+			listed[object.GetUID()] = struct{}{}
+		}
+	}
+	for uid, objectCluster := range s.clusters {
+		if _, ok := listed[uid]; ok || objectCluster != cluster {
+			continue
+		}
+		s.logger.V(2).Info("Delete", "uid", uid, "reason", "unlisted")
+		s.remove(uid)
+	}
+}
+
+// List returns the metadata of the objects metrics are stored for, e.g., to debug the store with.
+func (s *StoreType) List() []interface{} {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	objects := make([]interface{}, 0, len(s.names))
+	for uid := range s.names {
+		if object, ok := s.objectMetadata(uid); ok {
+			objects = append(objects, object)
+		}
+	}
+
+	return objects
+}
+
+// ListKeys returns the keys of the objects metrics are stored for, e.g., to debug the store with.
+func (s *StoreType) ListKeys() []string {
+	objects := s.List()
+	keys := make([]string, 0, len(objects))
+	for _, object := range objects {
+		key, err := cache.MetaNamespaceKeyFunc(object)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
 
-// List is not needed for our use case, so it returns nil.
-func (s *StoreType) List() []interface{} { return nil }
+	return keys
+}
+
+// Get returns the metadata of the given object, if metrics are stored for it.
+func (s *StoreType) Get(objectI interface{}) (interface{}, bool, error) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(objectI)
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting object key: %w", err)
+	}
 
-// ListKeys is not needed for our use case, so it returns nil.
-func (s *StoreType) ListKeys() []string { return nil }
+	return s.GetByKey(key)
+}
 
-// Get is not needed for our use case, so it returns nil and false.
-func (s *StoreType) Get(_ interface{}) (interface{}, bool, error) { return nil, false, nil }
+// GetByKey returns the metadata of the object with the given key, if metrics are stored for it. Objects are not indexed
+// by their keys, so this is meant for debugging only.
+func (s *StoreType) GetByKey(key string) (interface{}, bool, error) {
+	for _, object := range s.List() {
+		if objectKey, err := cache.MetaNamespaceKeyFunc(object); err == nil && objectKey == key {
+			return object, true, nil
+		}
+	}
 
-// GetByKey is not needed for our use case, so it returns nil and false.
-func (s *StoreType) GetByKey(_ string) (interface{}, bool, error) { return nil, false, nil }
+	return nil, false, nil
+}
 
-// Resync is not needed for our use case, so it does nothing and returns nil.
+// Resync does nothing and returns nil, as there is nothing downstream of the store to deliver its objects to again.
 func (s *StoreType) Resync() error { return nil }
 
+// objectMetadata returns the metadata of the object with the given UID, if metrics are stored for it, and it is not
+// tombstoned. The caller must hold the lock.
+func (s *StoreType) objectMetadata(uid types.UID) (*metav1.PartialObjectMetadata, bool) {
+	name, ok := s.names[uid]
+	if !ok {
+		return nil, false
+	}
+	if _, tombstoned := s.tombstones[uid]; tombstoned {
+		return nil, false
+	}
+
+	return &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: schema.GroupVersion{Group: s.Group, Version: s.Version}.String(),
+			Kind:       s.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: s.namespaces[uid], UID: uid},
+	}, true
+}
+
+// gvr returns the resource the store is configured for.
+func (s *StoreType) gvr() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: s.Group, Version: s.Version, Resource: s.Resource}
+}
+
 func convertToUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
 	unstructuredMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
 	if err != nil {
@@ -138,26 +440,36 @@ func convertToUnstructured(obj interface{}) (*unstructured.Unstructured, error)
 	return &unstructured.Unstructured{Object: unstructuredMap}, nil
 }
 
-func (s *StoreType) generateMetricsForObject(obj *unstructured.Unstructured) []string {
-	metrics := make([]string, len(s.Families))
-
-	for i, family := range s.Families {
-		inheritFamilyConfiguration(family, s)
-
-		family.logger = s.logger
-		metrics[i] = family.buildMetricString(obj)
-
-		s.logger.V(4).Info("Add", "family", family.Name, "metrics", metrics[i])
+// rendered reports whether the series of the object with the given UID were last rendered off the given digest of its
+// referenced fields, if computed.
+func (s *StoreType) rendered(uid types.UID, digest uint64, digested bool) bool {
+	if !digested {
+		return false
 	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	previous, ok := s.digests[uid]
 
-	return metrics
+	return ok && previous == digest
 }
 
-func inheritFamilyConfiguration(f *FamilyType, s *StoreType) {
-	if f.Resolver == ResolverTypeNone {
-		f.Resolver = s.Resolver
-	}
+func (s *StoreType) generateMetricsForObject(obj *unstructured.Unstructured) []string {
+	metrics := make([]string, len(s.Families))
+
+	// Families are split into the configured number of shards, each rendering its own families.
+	shards := max(s.Concurrency.RenderShards, 1)
+	forEachConcurrently(shards, shards, func(shard int) {
+		for i := shard; i < len(s.Families); i += shards {
+			family := s.Families[i]
+			// Families rendered at scrape time are left empty until then.
+			if family.onScrape() {
+				continue
+			}
+			metrics[i] = family.buildMetricString(obj)
+
+			s.logger.V(4).Info("Add", "family", family.Name, "metrics", metrics[i])
+		}
+	})
 
-	f.LabelKeys = append(f.LabelKeys, s.LabelKeys...)
-	f.LabelValues = append(f.LabelValues, s.LabelValues...)
+	return metrics
 }
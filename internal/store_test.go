@@ -0,0 +1,256 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+// benchmarkObjectCounts are the synthetic custom resource set sizes benchmarks are run against.
+var benchmarkObjectCounts = []int{100, 1000, 10000}
+
+// newSyntheticObjects returns count synthetic custom resources, each with a distinct UID, labels, and status.
+func newSyntheticObjects(count int) []*unstructured.Unstructured {
+	objects := make([]*unstructured.Unstructured, count)
+	for i := range objects {
+		objects[i] = &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "contoso.com/v1alpha1",
+				"kind":       "Bar",
+				"metadata": map[string]interface{}{
+					"name":      fmt.Sprintf("bar-%d", i),
+					"namespace": fmt.Sprintf("namespace-%d", i%10),
+					"uid":       fmt.Sprintf("uid-%d", i),
+					"labels": map[string]interface{}{
+						"app": "bar",
+					},
+				},
+				"spec": map[string]interface{}{
+					"replicas": int64(i % 5),
+				},
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Ready", "status": "True"},
+						map[string]interface{}{"type": "Progressing", "status": "False"},
+					},
+				},
+			},
+		}
+	}
+
+	return objects
+}
+
+// newBenchmarkStore returns a store without a reflector, configured with representative families for the given resolver.
+func newBenchmarkStore(b *testing.B, resolver ResolverType) *StoreType {
+	b.Helper()
+	query := func(unstructuredQuery, celQuery string) string {
+		if resolver == ResolverTypeCEL {
+			return celQuery
+		}
+
+		return unstructuredQuery
+	}
+	configuration := fmt.Sprintf(`stores:
+  - group: "contoso.com"
+    version: "v1alpha1"
+    kind: "Bar"
+    resource: "bars"
+    resolver: %q
+    labelKeys: ["name", "namespace"]
+    labelValues: [%q, %q]
+    families:
+      - name: "bar_replicas"
+        help: "Replicas"
+        metrics:
+          - labelKeys: ["app"]
+            labelValues: [%q]
+            value: %q
+      - name: "bar_info"
+        help: "Information"
+        metrics:
+          - value: "1"
+`,
+		resolver,
+		query("metadata.name", "o.metadata.name"), query("metadata.namespace", "o.metadata.namespace"),
+		query("metadata.labels.app", "o.metadata.labels.app"),
+		query("spec.replicas", "o.spec.replicas"),
+	)
+
+	c := newConfigurer(nil, nil, 0, 0, nil)
+	if err := c.parse(configuration); err != nil {
+		b.Fatal(err)
+	}
+	cfg := c.configuration.Stores[0]
+
+	return newConfiguredStore(klog.Background(), cfg.Families, cfg.Resolver, cfg.LabelKeys, cfg.LabelValues, 0, 0, nil, "", "")
+}
+
+func TestStoreType_Add(t *testing.T) {
+	t.Parallel()
+	s := newStore(klog.Background(), nil, []*FamilyType{
+		{
+			Name:        "test_family",
+			LabelKeys:   []string{"family"},
+			LabelValues: []string{"metadata.namespace"},
+			Metrics: []*MetricType{
+				{
+					Value: "spec.replicas",
+				},
+			},
+		},
+	}, ResolverTypeUnstructured, []string{"store"}, []string{"metadata.name"}, 0, 0)
+	object := newSyntheticObjects(1)[0]
+	expected := "kube_customresource_test_family{family=\"namespace-0\",store=\"bar-0\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 0\n"
+
+	// Inherited labelsets must not accumulate across events for the same object.
+	for range 3 {
+		if err := s.Add(object); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := s.metrics.get(object.GetUID()); got[0] != expected {
+			t.Errorf("%s", cmp.Diff(got[0], expected))
+		}
+	}
+}
+
+func TestStoreType_Add_filter(t *testing.T) {
+	t.Parallel()
+	s := newStore(klog.Background(), nil, []*FamilyType{
+		{
+			Name:    "test_family",
+			Metrics: []*MetricType{{Value: "spec.replicas"}},
+		},
+	}, ResolverTypeUnstructured, nil, nil, 0, 0)
+	s.filter = newStoreFilter("o.metadata.labels.app == 'bar'", newCELResolver(klog.Background(), 0, 0, nil, nil, "", ""))
+	object := newSyntheticObjects(1)[0]
+
+	if err := s.Add(object); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.metrics.get(object.GetUID()); !ok {
+		t.Fatal("expected metrics for the matching object")
+	}
+
+	// Objects that stop matching are dropped.
+	object.SetLabels(map[string]string{"app": "baz"})
+	if err := s.Update(object); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.metrics.get(object.GetUID()); ok {
+		t.Error("expected no metrics for the filtered out object")
+	}
+}
+
+func TestStoreType_Replace(t *testing.T) {
+	t.Parallel()
+	s := newStore(klog.Background(), nil, []*FamilyType{
+		{
+			Name:    "test_family",
+			Metrics: []*MetricType{{Value: "o.spec.replicas"}},
+		},
+	}, ResolverTypeCEL, nil, nil, 0, 0)
+	objects := newSyntheticObjects(2)
+	if err := s.Replace([]interface{}{objects[0], objects[1]}, "1"); err != nil {
+		t.Fatal(err)
+	}
+	// Stand in for the rendered series, to tell whether the object is rendered again.
+	s.setMetrics(objects[0].GetUID(), []string{"sentinel"})
+
+	// Relists skip the unchanged objects, and drop the ones they no longer include.
+	if err := s.Replace([]interface{}{objects[0]}, "2"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.metrics.get(objects[0].GetUID()); !slices.Equal(got, []string{"sentinel"}) {
+		t.Errorf("expected the unchanged object not to be rendered again, got %q", got)
+	}
+	if _, ok := s.metrics.get(objects[1].GetUID()); ok {
+		t.Error("expected no metrics for the object deleted between relists")
+	}
+
+	// Watch events still render the object again, as the CEL resolver may reference anything.
+	if err := s.Update(objects[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.metrics.get(objects[0].GetUID()); slices.Equal(got, []string{"sentinel"}) {
+		t.Error("expected the updated object to be rendered again")
+	}
+}
+
+func TestStoreType_List(t *testing.T) {
+	t.Parallel()
+	s := newStore(klog.Background(), nil, []*FamilyType{
+		{
+			Name:    "test_family",
+			Metrics: []*MetricType{{Value: "spec.replicas"}},
+		},
+	}, ResolverTypeUnstructured, nil, nil, 0, 0)
+	objects := newSyntheticObjects(3)
+	if err := s.Replace([]interface{}{objects[0], objects[1], objects[2]}, "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(objects[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := s.ListKeys()
+	slices.Sort(keys)
+	if expected := []string{"namespace-0/bar-0", "namespace-1/bar-1"}; !slices.Equal(keys, expected) {
+		t.Errorf("%s", cmp.Diff(expected, keys))
+	}
+	if _, ok, err := s.Get(objects[1]); err != nil || !ok {
+		t.Errorf("expected %s to be found, got %v (%v)", objects[1].GetName(), ok, err)
+	}
+	if _, ok, _ := s.GetByKey("namespace-2/bar-2"); ok {
+		t.Error("expected the deleted object not to be found")
+	}
+}
+
+func BenchmarkStoreType_Add(b *testing.B) {
+	for _, resolver := range []ResolverType{ResolverTypeUnstructured, ResolverTypeCEL} {
+		for _, count := range benchmarkObjectCounts {
+			b.Run(fmt.Sprintf("resolver=%s/objects=%d", resolver, count), func(b *testing.B) {
+				objects := newSyntheticObjects(count)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for range b.N {
+					b.StopTimer()
+					s := newBenchmarkStore(b, resolver)
+					b.StartTimer()
+					for _, object := range objects {
+						if err := s.Add(object); err != nil {
+							b.Fatal(err)
+						}
+					}
+				}
+				b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*count), "ns/object")
+			})
+		}
+	}
+}
+
+func BenchmarkStoreType_Update(b *testing.B) {
+	for _, resolver := range []ResolverType{ResolverTypeUnstructured, ResolverTypeCEL} {
+		b.Run(fmt.Sprintf("resolver=%s", resolver), func(b *testing.B) {
+			s := newBenchmarkStore(b, resolver)
+			object := newSyntheticObjects(1)[0]
+			if err := s.Add(object); err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				if err := unstructured.SetNestedField(object.Object, int64(i), "spec", "replicas"); err != nil {
+					b.Fatal(err)
+				}
+				if err := s.Update(object); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
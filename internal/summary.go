@@ -0,0 +1,140 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// summaryReportInterval is the interval the monitors' summaries, i.e., the number of their stores, families, and
+// series, are reported in their status at, for `kubectl get` to print.
+const summaryReportInterval = 30 * time.Second
+
+// summary is the number of stores, families, and series of a monitor, as reported in its status.
+type summary struct {
+	stores   int32
+	families int32
+	series   int64
+}
+
+// summaryOf returns the summary of the given stores.
+func summaryOf(builtStores []*StoreType) summary {
+	s := summary{stores: int32(len(builtStores))} //nolint:gosec // Bounded by the configuration's size.
+	for _, builtStore := range builtStores {
+		s.families += int32(len(builtStore.Families)) //nolint:gosec // Bounded by the configuration's size.
+		s.series += builtStore.seriesCount()
+	}
+
+	return s
+}
+
+// seriesCount returns the number of series held by the store, including the ones of its selected stores.
+func (s *StoreType) seriesCount() int64 {
+	s.mutex.RLock()
+	var count int64
+	_ = s.metrics.forEach(func(_ types.UID, metricFamilies []string) error {
+		for _, metricFamily := range metricFamilies {
+			count += int64(strings.Count(metricFamily, "\n"))
+		}
+
+		return nil
+	})
+	selected := s.selectedStores()
+	s.mutex.RUnlock()
+	for _, selectedStore := range selected {
+		count += selectedStore.seriesCount()
+	}
+
+	return count
+}
+
+// apply sets the summary in the given status, and reports whether it changed.
+func (s summary) apply(status *v1alpha1.ResourceMetricsMonitorStatus) bool {
+	if status.Stores == s.stores && status.Families == s.families && status.Series == s.series {
+		return false
+	}
+	status.Stores, status.Families, status.Series = s.stores, s.families, s.series
+
+	return true
+}
+
+// reportSummaries reports the summary of each monitor in its status, if changed. Monitors whose stores were dropped,
+// e.g., since they were paused, or failed to be processed, are reported to have none.
+func (c *Controller) reportSummaries(ctx context.Context) {
+	summaries := map[string]summary{}
+	c.stores.Range(func(key, value any) bool {
+		// The auto-conditions stores belong to no monitor.
+		if key == autoConditionsKey {
+			return true
+		}
+		builtStores, _ := value.([]*StoreType)
+		summaries[key.(string)] = summaryOf(builtStores)
+
+		return true
+	})
+	c.summarized.Range(func(key, _ any) bool {
+		if _, ok := summaries[key.(string)]; !ok {
+			summaries[key.(string)] = summary{}
+		}
+
+		return true
+	})
+
+	for key, s := range summaries {
+		err := c.reportSummary(ctx, key, s)
+		if err != nil && !apierrors.IsNotFound(err) {
+			utilruntime.HandleError(err)
+
+			continue
+		}
+		if s == (summary{}) || err != nil {
+			c.summarized.Delete(key)
+		} else {
+			c.summarized.Store(key, struct{}{})
+		}
+	}
+}
+
+// reportSummary reports the given summary in the status of the monitor of the given key, if changed.
+func (c *Controller) reportSummary(ctx context.Context, key string, s summary) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to parse key %q: %w", key, err)
+	}
+	resource, err := c.monitors(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	if !s.apply(&resource.Status) {
+		return nil
+	}
+	if _, err = c.monitors(namespace).UpdateStatus(ctx, resource, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to report the summary of %s: %w", key, err)
+	}
+
+	return nil
+}
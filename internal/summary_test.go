@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+func TestSummaryOf(t *testing.T) {
+	t.Parallel()
+	var c configuration
+	if err := yaml.UnmarshalStrict([]byte(`stores:
+  - families:
+      - name: "replicas"
+        metrics:
+          - value: "spec.replicas"
+      - name: "conditions"
+        metrics:
+          - value: "status.conditions[*].status"
+`), &c); err != nil {
+		t.Fatal(err)
+	}
+	cfg := c.Stores[0]
+	s := newStore(klog.Background(), []string{"", ""}, cfg.Families, ResolverTypeUnstructured, nil, nil, 0, 0)
+	for _, object := range newSyntheticObjects(3) {
+		if err := s.Add(object); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := summaryOf([]*StoreType{s})
+	if got.stores != 1 || got.families != 2 || got.series != s.seriesCount() || got.series < 3 {
+		t.Fatalf("unexpected summary: %+v", got)
+	}
+	status := v1alpha1.ResourceMetricsMonitorStatus{}
+	if !got.apply(&status) {
+		t.Fatal("expected the summary to change the status")
+	}
+	if status.Stores != 1 || status.Families != 2 || status.Series != got.series {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if got.apply(&status) {
+		t.Error("expected the summary not to change the status again")
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// labelTemplates caches the parsed label value templates, by their text, as they are rendered for each object.
+var labelTemplates sync.Map
+
+// isLabelTemplate reports whether the given label value is a Go template, e.g., "{{ .spec.region }}-{{ .spec.zone }}",
+// to be rendered against the object, rather than a query for the resolver.
+func isLabelTemplate(query string) bool {
+	return strings.Contains(query, "{{")
+}
+
+// parseLabelTemplate returns the parsed template for the given label value. Templates fail on missing keys, rather
+// than rendering "<no value>".
+func parseLabelTemplate(query string) (*template.Template, error) {
+	if cached, ok := labelTemplates.Load(query); ok {
+		return cached.(*template.Template), nil
+	}
+	parsed, err := template.New("labelValue").Option("missingkey=error").Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing label value template %q: %w", query, err)
+	}
+	labelTemplates.Store(query, parsed)
+
+	return parsed, nil
+}
+
+// renderLabelTemplate renders the given label value template against the given object.
+func renderLabelTemplate(query string, obj map[string]interface{}) (string, error) {
+	parsed, err := parseLabelTemplate(query)
+	if err != nil {
+		return "", err
+	}
+	builder := getBuilder()
+	defer putBuilder(builder)
+	if err = parsed.Execute(builder, obj); err != nil {
+		return "", fmt.Errorf("error rendering label value template %q: %w", query, err)
+	}
+
+	return builder.String(), nil
+}
+
+// validateLabelTemplates checks that the metric's templated label values, if any, parse.
+func (m *MetricType) validateLabelTemplates() error {
+	for i, query := range m.LabelValues {
+		if !isLabelTemplate(query) {
+			continue
+		}
+		if _, err := parseLabelTemplate(query); err != nil {
+			return fmt.Errorf("labelValues[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/klog/v2"
+)
+
+func TestRenderLabelTemplate(t *testing.T) {
+	t.Parallel()
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{"region": "eu", "zone": "a", "replicas": int64(3)},
+	}
+	tests := []struct {
+		query       string
+		expected    string
+		expectedErr bool
+	}{
+		{query: "{{ .spec.region }}-{{ .spec.zone }}", expected: "eu-a"},
+		{query: "{{ .spec.replicas }}x", expected: "3x"},
+		{query: "{{ .spec.missing }}", expectedErr: true},
+		{query: "{{ .status.phase }}", expectedErr: true},
+		{query: "{{ .spec.region", expectedErr: true},
+	}
+	for _, tt := range tests {
+		got, err := renderLabelTemplate(tt.query, obj)
+		if (err != nil) != tt.expectedErr {
+			t.Errorf("%s: unexpected error: %v", tt.query, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.query, tt.expected, got)
+		}
+	}
+}
+
+func TestFamilyType_labelTemplates(t *testing.T) {
+	t.Parallel()
+	object := newSyntheticObjects(1)[0]
+	object.Object["spec"].(map[string]interface{})["region"] = "eu"
+	family := &FamilyType{
+		logger:   klog.Background(),
+		Name:     "replicas",
+		Resolver: ResolverTypeCEL,
+		Metrics: []*MetricType{{
+			LabelKeys:   []string{"location", "name"},
+			LabelValues: []string{"{{ .spec.region }}/{{ .metadata.namespace }}", "o.metadata.name"},
+			Value:       "o.spec.replicas",
+		}},
+	}
+
+	expected := "kube_customresource_replicas{location=\"eu/namespace-0\",name=\"bar-0\",group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 0\n"
+	if diff := cmp.Diff(expected, family.buildMetricString(object)); diff != "" {
+		t.Errorf("unexpected exposition (-want +got):\n%s", diff)
+	}
+}
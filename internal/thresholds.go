@@ -0,0 +1,333 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// thresholdWebhookTimeout bounds the time notifying a threshold's webhook may take.
+	thresholdWebhookTimeout = 10 * time.Second
+	// maxThresholdExamples bounds the number of series crossing a threshold that are named in its events.
+	maxThresholdExamples = 3
+
+	thresholdStateCrossed  = "crossed"
+	thresholdStateResolved = "resolved"
+)
+
+// ThresholdType is a condition on the series of one of a store's families, e.g., to alert at the source in
+// environments without Prometheus, or Alertmanager.
+type ThresholdType struct {
+	// Name is the name of the threshold, unique within the store.
+	Name string `yaml:"name"`
+	// Family is the name of the family, among the store's, whose series the threshold is evaluated against.
+	Family string `yaml:"family"`
+	// Expression is a CEL expression series cross the threshold for if it holds true. It is evaluated against an
+	// object with the series' name, labels, and value, e.g., o.value > 3 && o.labels.phase == "Failed".
+	Expression string `yaml:"expression"`
+	// Webhook, if set, is the URL notified, along with the series, whenever the threshold is crossed, or resolved.
+	Webhook string `yaml:"webhook,omitempty"`
+}
+
+// validateThresholds rejects thresholds that are unnamed, or named alike, lack an expression, reference a family the
+// given store does not define, or a webhook URL that is not an absolute HTTP(S) one.
+func validateThresholds(store *StoreType) error {
+	families := sets.New[string]()
+	for _, family := range store.Families {
+		if family != nil {
+			families.Insert(family.Name)
+		}
+	}
+	names := sets.New[string]()
+	for i, threshold := range store.Thresholds {
+		switch {
+		case threshold.Name == "":
+			return fmt.Errorf("thresholds[%d].name: name is required", i)
+		case names.Has(threshold.Name):
+			return fmt.Errorf("thresholds[%d].name: duplicate threshold name %q", i, threshold.Name)
+		case threshold.Expression == "":
+			return fmt.Errorf("thresholds[%d].expression: expression is required", i)
+		case !families.Has(threshold.Family):
+			return fmt.Errorf("thresholds[%d].family: no family named %q is defined", i, threshold.Family)
+		}
+		names.Insert(threshold.Name)
+		if threshold.Webhook == "" {
+			continue
+		}
+		webhook, err := url.Parse(threshold.Webhook)
+		if err != nil {
+			return fmt.Errorf("thresholds[%d].webhook: %w", i, err)
+		}
+		if (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+			return fmt.Errorf("thresholds[%d].webhook: %q is not an absolute HTTP(S) URL", i, threshold.Webhook)
+		}
+	}
+
+	return nil
+}
+
+// thresholdKey identifies a threshold across monitors.
+type thresholdKey struct {
+	monitor   string
+	store     int
+	threshold string
+}
+
+// thresholdSeries is a series crossing a threshold, as notified to its webhook.
+type thresholdSeries struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// thresholdNotification is the payload a threshold's webhook is notified with.
+type thresholdNotification struct {
+	Monitor   string `json:"monitor"`
+	Threshold string `json:"threshold"`
+	// State is either "crossed", for series that started crossing the threshold, or "resolved", for ones that stopped.
+	State  string            `json:"state"`
+	Series []thresholdSeries `json:"series"`
+}
+
+// thresholdCheck periodically evaluates the thresholds declared by each monitor's stores against their series, and
+// records an event on the monitor, and notifies the threshold's webhook, if any, whenever series cross, or stop
+// crossing, any of them.
+type thresholdCheck struct {
+	stores   *sync.Map
+	recorder events.EventRecorder
+	// getMonitor returns the given monitor, to record events on.
+	getMonitor func(namespace, name string) (*v1alpha1.ResourceMetricsMonitor, error)
+	resolver   *resolver.CELResolver
+	// client, if set, notifies the thresholds' webhooks.
+	client *http.Client
+
+	// crossed holds the series crossing each threshold, as of the last check, by their label sets.
+	crossed map[thresholdKey]map[string]thresholdSeries
+}
+
+// newThresholdCheck returns a thresholdCheck for the given stores, recording events with the given recorder, and
+// notifying the thresholds' webhooks if enabled.
+func newThresholdCheck(
+	logger klog.Logger,
+	stores *sync.Map,
+	recorder events.EventRecorder,
+	getMonitor func(namespace, name string) (*v1alpha1.ResourceMetricsMonitor, error),
+	webhooks bool,
+) *thresholdCheck {
+	check := &thresholdCheck{
+		stores:     stores,
+		recorder:   recorder,
+		getMonitor: getMonitor,
+		resolver:   newCELResolver(logger, 0, 0, nil, nil, "", ""),
+		crossed:    map[thresholdKey]map[string]thresholdSeries{},
+	}
+	if webhooks {
+		check.client = &http.Client{Timeout: thresholdWebhookTimeout}
+	}
+
+	return check
+}
+
+// run evaluates the thresholds of all monitors once. It is not safe to call concurrently.
+func (t *thresholdCheck) run(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	evaluated := sets.New[thresholdKey]()
+	t.stores.Range(func(key, value any) bool {
+		keyString, _ := key.(string)
+		builtStores, _ := value.([]*StoreType)
+		for i, s := range builtStores {
+			if len(s.Thresholds) == 0 {
+				continue
+			}
+			families, err := storeFamilies(s)
+			if err != nil {
+				logger.Error(err, "error evaluating thresholds", "key", keyString)
+
+				continue
+			}
+			for _, threshold := range s.Thresholds {
+				thresholdKey := thresholdKey{monitor: keyString, store: i, threshold: threshold.Name}
+				evaluated.Insert(thresholdKey)
+				t.notify(ctx, thresholdKey, threshold, t.evaluate(threshold, families))
+			}
+		}
+
+		return true
+	})
+
+	// Thresholds of dropped, or reconfigured, monitors are forgotten without being resolved.
+	for key := range t.crossed {
+		if !evaluated.Has(key) {
+			delete(t.crossed, key)
+		}
+	}
+}
+
+// storeFamilies renders the given store's series, and returns them by their families' names.
+func storeFamilies(s *StoreType) (map[string][]sample, error) {
+	buffer := &bytes.Buffer{}
+	if err := newMetricsWriter(s).writeStores(buffer); err != nil {
+		return nil, fmt.Errorf("error writing metrics: %w", err)
+	}
+	parser := expfmt.TextParser{}
+	metricFamilies, err := parser.TextToMetricFamilies(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing exposition: %w", err)
+	}
+	families := make(map[string][]sample, len(metricFamilies))
+	for name, metricFamily := range metricFamilies {
+		families[name] = samplesOf(metricFamily)
+	}
+
+	return families, nil
+}
+
+// evaluate returns the series of the given families crossing the given threshold, by their label sets.
+func (t *thresholdCheck) evaluate(threshold ThresholdType, families map[string][]sample) map[string]thresholdSeries {
+	crossing := map[string]thresholdSeries{}
+	for _, s := range families[kubeCustomResourcePrefix+threshold.Family] {
+		series := thresholdSeries{Name: s.name, Labels: make(map[string]string, len(s.labels)), Value: s.value}
+		labels := make(map[string]interface{}, len(s.labels))
+		for _, label := range s.labels {
+			series.Labels[label.GetName()] = label.GetValue()
+			labels[label.GetName()] = label.GetValue()
+		}
+		object := map[string]interface{}{"name": s.name, "labels": labels, "value": s.value}
+		if t.resolver.Resolve(threshold.Expression, object)[threshold.Expression] == "true" {
+			crossing[series.String()] = series
+		}
+	}
+
+	return crossing
+}
+
+// notify records the series that started, or stopped, crossing the given threshold since the last check, if any.
+func (t *thresholdCheck) notify(ctx context.Context, key thresholdKey, threshold ThresholdType, crossing map[string]thresholdSeries) {
+	previous := t.crossed[key]
+	t.crossed[key] = crossing
+	var crossed, resolved []thresholdSeries
+	for id, series := range crossing {
+		if _, ok := previous[id]; !ok {
+			crossed = append(crossed, series)
+		}
+	}
+	for id, series := range previous {
+		if _, ok := crossing[id]; !ok {
+			resolved = append(resolved, series)
+		}
+	}
+	if len(crossed) > 0 {
+		t.record(ctx, key, threshold, thresholdStateCrossed, crossed)
+	}
+	if len(resolved) > 0 {
+		t.record(ctx, key, threshold, thresholdStateResolved, resolved)
+	}
+}
+
+// record records an event on the threshold's monitor, and notifies its webhook, if any and enabled, that the given
+// series crossed, or stopped crossing, it.
+func (t *thresholdCheck) record(ctx context.Context, key thresholdKey, threshold ThresholdType, state string, series []thresholdSeries) {
+	logger := klog.FromContext(ctx).WithValues("key", key.monitor, "threshold", threshold.Name)
+	slices.SortFunc(series, func(a, b thresholdSeries) int { return strings.Compare(a.String(), b.String()) })
+	examples := make([]string, 0, maxThresholdExamples)
+	for _, s := range series[:min(len(series), maxThresholdExamples)] {
+		examples = append(examples, s.String())
+	}
+	message := fmt.Sprintf("%d series %s threshold %q (%s), e.g., %s", len(series), state, threshold.Name, threshold.Expression, strings.Join(examples, ", "))
+	logger.V(2).Info("Threshold "+state, "series", len(series))
+
+	objectName, err := cache.ParseObjectName(key.monitor)
+	if err != nil {
+		logger.Error(err, "error parsing monitor key")
+
+		return
+	}
+	if monitor, err := t.getMonitor(objectName.Namespace, objectName.Name); err == nil {
+		eventType, reason := corev1.EventTypeWarning, "ThresholdCrossed"
+		if state == thresholdStateResolved {
+			eventType, reason = corev1.EventTypeNormal, "ThresholdResolved"
+		}
+		t.recorder.Eventf(monitor, nil, eventType, reason, "Evaluate", "%s", message)
+	} else {
+		logger.Error(err, "error getting monitor to record threshold event on")
+	}
+
+	if t.client == nil || threshold.Webhook == "" {
+		return
+	}
+	if err := t.post(ctx, threshold.Webhook, thresholdNotification{
+		Monitor:   key.monitor,
+		Threshold: threshold.Name,
+		State:     state,
+		Series:    series,
+	}); err != nil {
+		logger.Error(err, "error notifying threshold webhook")
+	}
+}
+
+// post sends the given notification to the given webhook.
+func (t *thresholdCheck) post(ctx context.Context, webhook string, notification thresholdNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("error marshalling notification: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := t.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", response.Status)
+	}
+
+	return nil
+}
+
+// String returns the series as in the text format, without its value.
+func (s thresholdSeries) String() string {
+	pairs := make([]string, 0, len(s.Labels))
+	for _, name := range slices.Sorted(maps.Keys(s.Labels)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", name, s.Labels[name]))
+	}
+
+	return s.Name + "{" + strings.Join(pairs, ",") + "}"
+}
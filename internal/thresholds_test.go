@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateThresholds(t *testing.T) {
+	t.Parallel()
+	families := []*FamilyType{{Name: "replicas"}}
+	tests := []struct {
+		name       string
+		thresholds []ThresholdType
+		err        string
+	}{
+		{
+			name:       "valid",
+			thresholds: []ThresholdType{{Name: "high", Family: "replicas", Expression: "o.value > 3", Webhook: "https://example.com/hook"}},
+		},
+		{
+			name:       "unnamed",
+			thresholds: []ThresholdType{{Family: "replicas", Expression: "o.value > 3"}},
+			err:        "thresholds[0].name: name is required",
+		},
+		{
+			name: "duplicate",
+			thresholds: []ThresholdType{
+				{Name: "high", Family: "replicas", Expression: "o.value > 3"},
+				{Name: "high", Family: "replicas", Expression: "o.value > 5"},
+			},
+			err: "thresholds[1].name: duplicate threshold name \"high\"",
+		},
+		{
+			name:       "unknown family",
+			thresholds: []ThresholdType{{Name: "high", Family: "foo", Expression: "o.value > 3"}},
+			err:        "thresholds[0].family: no family named \"foo\" is defined",
+		},
+		{
+			name:       "relative webhook",
+			thresholds: []ThresholdType{{Name: "high", Family: "replicas", Expression: "o.value > 3", Webhook: "/hook"}},
+			err:        "thresholds[0].webhook: \"/hook\" is not an absolute HTTP(S) URL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := validateThresholds(&StoreType{Families: families, Thresholds: tt.thresholds})
+			if tt.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Fatalf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestThresholdCheck(t *testing.T) {
+	t.Parallel()
+	var notifications []thresholdNotification
+	var mutex sync.Mutex
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notification := thresholdNotification{}
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		notifications = append(notifications, notification)
+	}))
+	defer webhook.Close()
+
+	var c configuration
+	if err := yaml.UnmarshalStrict([]byte(`stores:
+  - families:
+      - name: "replicas"
+        metrics:
+          - value: "spec.replicas"
+            labelKeys: ["name"]
+            labelValues: ["metadata.name"]
+    thresholds:
+      - name: "high"
+        family: "replicas"
+        expression: "o.value > 3"
+        webhook: "`+webhook.URL+`"
+`), &c); err != nil {
+		t.Fatal(err)
+	}
+	cfg := c.Stores[0]
+	s := newStore(klog.Background(), []string{"# HELP kube_customresource_replicas"}, cfg.Families, ResolverTypeUnstructured, nil, nil, 0, 0)
+	s.Thresholds = cfg.Thresholds
+	objects := newSyntheticObjects(5)
+	for _, object := range objects {
+		if err := s.Add(object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stores := &sync.Map{}
+	stores.Store("foo/bar", []*StoreType{s})
+	recorder := events.NewFakeRecorder(10)
+	getMonitor := func(namespace, name string) (*v1alpha1.ResourceMetricsMonitor, error) {
+		monitor := &v1alpha1.ResourceMetricsMonitor{}
+		monitor.SetNamespace(namespace)
+		monitor.SetName(name)
+
+		return monitor, nil
+	}
+	check := newThresholdCheck(klog.Background(), stores, recorder, getMonitor, true)
+
+	check.run(context.Background())
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning ThresholdCrossed 1 series crossed threshold \"high\"") {
+		t.Errorf("unexpected event: %s", event)
+	}
+	// Series still crossing the threshold are not notified again.
+	check.run(context.Background())
+	if err := s.Delete(objects[4]); err != nil {
+		t.Fatal(err)
+	}
+	check.run(context.Background())
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Normal ThresholdResolved 1 series resolved threshold \"high\"") {
+		t.Errorf("unexpected event: %s", event)
+	}
+	if len(recorder.Events) != 0 {
+		t.Errorf("unexpected events: %d", len(recorder.Events))
+	}
+
+	series := thresholdSeries{Name: "kube_customresource_replicas", Labels: map[string]string{"group": "contoso.com", "kind": "Bar", "name": "bar-4", "version": "v1alpha1"}, Value: 4}
+	expected := []thresholdNotification{
+		{Monitor: "foo/bar", Threshold: "high", State: thresholdStateCrossed, Series: []thresholdSeries{series}},
+		{Monitor: "foo/bar", Threshold: "high", State: thresholdStateResolved, Series: []thresholdSeries{series}},
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if diff := cmp.Diff(notifications, expected); diff != "" {
+		t.Errorf("unexpected notifications: %s", diff)
+	}
+}
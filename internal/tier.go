@@ -0,0 +1,57 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// scrapeTier is the tier a monitor's metrics are exposed under, in addition to the main metrics path, so that tiers may
+// be scraped at different intervals.
+type scrapeTier string
+
+const (
+	// scrapeTierCritical is for metrics scraped most frequently.
+	scrapeTierCritical scrapeTier = "critical"
+	// scrapeTierStandard is for metrics scraped at the usual interval, and the default.
+	scrapeTierStandard scrapeTier = "standard"
+	// scrapeTierBestEffort is for bulky metrics scraped least frequently.
+	scrapeTierBestEffort scrapeTier = "best-effort"
+)
+
+// scrapeTiers are all scrape tiers, each exposed under /metrics/<tier>.
+var scrapeTiers = []scrapeTier{scrapeTierCritical, scrapeTierStandard, scrapeTierBestEffort}
+
+// monitorTier returns the scrape tier the given monitor is labeled with, or the standard one if it is unset or invalid.
+func monitorTier(object metav1.Object) scrapeTier {
+	switch tier := scrapeTier(object.GetLabels()[v1alpha1.TierLabel]); tier {
+	case scrapeTierCritical, scrapeTierBestEffort:
+		return tier
+	default:
+		return scrapeTierStandard
+	}
+}
+
+// storesTier returns the scrape tier of the monitor the given stores are built for.
+func storesTier(stores []*StoreType) scrapeTier {
+	if len(stores) == 0 || stores[0].tier == "" {
+		return scrapeTierStandard
+	}
+
+	return stores[0].tier
+}
@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMonitorTier(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected scrapeTier
+	}{
+		{name: "unset", expected: scrapeTierStandard},
+		{name: "critical", labels: map[string]string{v1alpha1.TierLabel: "critical"}, expected: scrapeTierCritical},
+		{name: "best-effort", labels: map[string]string{v1alpha1.TierLabel: "best-effort"}, expected: scrapeTierBestEffort},
+		{name: "invalid", labels: map[string]string{v1alpha1.TierLabel: "urgent"}, expected: scrapeTierStandard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			monitor := &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Labels: tt.labels}}
+			if got := monitorTier(monitor); got != tt.expected {
+				t.Errorf("expected tier %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStoresTier(t *testing.T) {
+	t.Parallel()
+	if got := storesTier(nil); got != scrapeTierStandard {
+		t.Errorf("expected no stores to be of the standard tier, got %q", got)
+	}
+	if got := storesTier([]*StoreType{{tier: scrapeTierCritical}}); got != scrapeTierCritical {
+		t.Errorf("expected stores to be of their monitor's tier, got %q", got)
+	}
+}
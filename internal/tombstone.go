@@ -0,0 +1,88 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// tombstoneLabel marks the series retained for deleted objects.
+const tombstoneLabel = `deleted="true"`
+
+// tombstone retains the given object's last series, marked as deleted, until the store's tombstone retention elapses.
+// The caller must hold the store's lock.
+func (s *StoreType) tombstone(uid types.UID) {
+	metricFamilies, ok := s.metrics.get(uid)
+	if !ok {
+		return
+	}
+	tombstoned := make([]string, len(metricFamilies))
+	for i, metricFamily := range metricFamilies {
+		tombstoned[i] = withLabel(metricFamily, tombstoneLabel)
+	}
+	s.setMetrics(uid, tombstoned)
+	s.tombstones[uid] = clockNow().Add(s.TombstoneRetention.Duration)
+}
+
+// pruneTombstones drops the series of deleted objects whose retention has elapsed. The caller must hold the store's
+// lock.
+func (s *StoreType) pruneTombstones() {
+	now := clockNow()
+	for uid, expiry := range s.tombstones {
+		if now.Before(expiry) {
+			continue
+		}
+		s.deleteMetrics(uid)
+		delete(s.namespaces, uid)
+		delete(s.tombstones, uid)
+	}
+}
+
+// expired reports whether the given object was deleted, and its tombstone retention has elapsed since. The caller
+// must hold the store's (read) lock.
+func (s *StoreType) expired(uid types.UID) bool {
+	expiry, ok := s.tombstones[uid]
+
+	return ok && !clockNow().Before(expiry)
+}
+
+// withLabel adds the given (rendered) label to each series in the given family.
+func withLabel(metricFamily, label string) string {
+	lines := strings.Split(metricFamily, "\n")
+	for i, series := range lines {
+		// Label values may contain braces and spaces, and exemplars follow the sample value with label sets of their
+		// own, so the series' label set is only told apart by its braces preceding the name's end, outside quotes.
+		name := strings.IndexAny(series, "{ ")
+		if name < 0 {
+			continue
+		}
+		if series[name] == '{' {
+			if end := labelSetEnd(series) - 1; end > name+1 {
+				lines[i] = series[:end] + "," + label + series[end:]
+			} else if end == name+1 {
+				lines[i] = series[:end] + label + series[end:]
+			}
+
+			continue
+		}
+		lines[i] = series[:name] + "{" + label + "}" + series[name:]
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+func TestWithLabel(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		family   string
+		expected string
+	}{
+		{
+			name:     "empty family",
+			family:   "",
+			expected: "",
+		},
+		{
+			name:     "series with labels",
+			family:   "foo{name=\"a} b\",kind=\"Foo\"} 1.000000\nfoo{name=\"c\",kind=\"Foo\"} 2.000000\n",
+			expected: "foo{name=\"a} b\",kind=\"Foo\",deleted=\"true\"} 1.000000\nfoo{name=\"c\",kind=\"Foo\",deleted=\"true\"} 2.000000\n",
+		},
+		{
+			name:     "series without labels",
+			family:   "foo 1.000000\n",
+			expected: "foo{deleted=\"true\"} 1.000000\n",
+		},
+		{
+			name:     "series with empty labels",
+			family:   "foo{} 1.000000\n",
+			expected: "foo{deleted=\"true\"} 1.000000\n",
+		},
+		{
+			name:     "series with exemplars",
+			family:   "foo{name=\"a} b\",kind=\"Foo\"} 1 # {trace_id=\"abc\"} 1\nfoo 2 # {trace_id=\"def\"} 2\n",
+			expected: "foo{name=\"a} b\",kind=\"Foo\",deleted=\"true\"} 1 # {trace_id=\"abc\"} 1\nfoo{deleted=\"true\"} 2 # {trace_id=\"def\"} 2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if diff := cmp.Diff(withLabel(tt.family, tombstoneLabel), tt.expected); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestStoreType_Delete_tombstone(t *testing.T) {
+	t.Parallel()
+	var c configuration
+	if err := yaml.UnmarshalStrict([]byte(`stores:
+  - tombstoneRetention: 5m
+    families:
+      - name: "replicas"
+        metrics:
+          - value: "spec.replicas"
+`), &c); err != nil {
+		t.Fatal(err)
+	}
+	cfg := c.Stores[0]
+	s := newStore(klog.Background(), []string{"# HELP kube_customresource_replicas"}, cfg.Families, ResolverTypeUnstructured, nil, nil, 0, 0)
+	s.TombstoneRetention = cfg.TombstoneRetention
+	object := newSyntheticObjects(1)[0]
+	if err := s.Add(object); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(object); err != nil {
+		t.Fatal(err)
+	}
+
+	exposition := func() string {
+		t.Helper()
+		buffer := &bytes.Buffer{}
+		if err := newMetricsWriter(s).writeStores(buffer); err != nil {
+			t.Fatal(err)
+		}
+
+		return buffer.String()
+	}
+	expected := "# HELP kube_customresource_replicas\n" +
+		"kube_customresource_replicas{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\",deleted=\"true\"} 0\n"
+	if diff := cmp.Diff(exposition(), expected); diff != "" {
+		t.Errorf("%s", diff)
+	}
+
+	// Once the retention elapses, the tombstone is no longer written out, and is pruned on the next event.
+	s.tombstones[object.GetUID()] = time.Now().Add(-time.Second)
+	if diff := cmp.Diff(exposition(), "# HELP kube_customresource_replicas\n"); diff != "" {
+		t.Errorf("%s", diff)
+	}
+	if err := s.Add(newSyntheticObjects(2)[1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.metrics.get(object.GetUID()); ok {
+		t.Error("expected the expired tombstone to be pruned")
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"time"
+
+	utiltrace "k8s.io/utils/trace"
+)
+
+// traceStep records the given step of the trace in the given context, if any, e.g., of the reconciliation of a monitor,
+// so the steps taking long are told apart once it is logged.
+func traceStep(ctx context.Context, msg string, fields ...utiltrace.Field) {
+	if trace := utiltrace.FromContext(ctx); trace != nil {
+		trace.Step(msg, fields...)
+	}
+}
+
+// logTraceIfLong logs the given trace, along with its steps, if it took longer than the given threshold, or not at all
+// if the threshold is 0. Traces are logged at verbosity level 2, and all of their steps at level 4. They are not exported,
+// e.g., as OpenTelemetry spans, as the OpenTelemetry SDK is not a dependency.
+func logTraceIfLong(trace *utiltrace.Trace, threshold time.Duration) {
+	if trace == nil || threshold <= 0 {
+		return
+	}
+	trace.LogIfLong(threshold)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rexagod/resource-state-metrics/internal/version"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientfeatures "k8s.io/client-go/features"
+)
+
+// ListTransfers observes the size of the responses to list requests made through the transports it wraps, by the
+// listed resource, so the cost of (re)listing large targets is visible.
+type ListTransfers struct {
+	size *prometheus.HistogramVec
+}
+
+// NewListTransfers returns a ListTransfers, to be registered with the controller's telemetry registry.
+func NewListTransfers() *ListTransfers {
+	return &ListTransfers{
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: version.ControllerName.ToSnakeCase(),
+			Name:      "list_response_size_bytes",
+			Help:      "Size of the (decompressed) responses to list requests, per page, by the listed resource.",
+			Buckets:   prometheus.ExponentialBuckets(1024, 4, 10),
+		}, []string{"group", "version", "resource"}),
+	}
+}
+
+// WrapTransport wraps the given transport to observe the size of the responses to list requests, and is meant to be
+// set through rest.Config.Wrap.
+func (l *ListTransfers) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return &listTransferRoundTripper{delegate: rt, size: l.size}
+}
+
+// listTransferRoundTripper observes the size of the responses to list requests.
+type listTransferRoundTripper struct {
+	delegate http.RoundTripper
+	size     *prometheus.HistogramVec
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *listTransferRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := rt.delegate.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet || req.URL.Query().Get("watch") == "true" {
+		return resp, err
+	}
+	gvr, ok := listedResource(req.URL.Path)
+	if !ok {
+		return resp, err
+	}
+	resp.Body = &countingReadCloser{ReadCloser: resp.Body, observe: func(n int64) {
+		rt.size.WithLabelValues(gvr.Group, gvr.Version, gvr.Resource).Observe(float64(n))
+	}}
+
+	return resp, err
+}
+
+// listedResource returns the resource listed by a request to the given path, if it is a list request.
+func listedResource(path string) (schema.GroupVersionResource, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var gvr schema.GroupVersionResource
+	switch {
+	case len(segments) >= 3 && segments[0] == "api":
+		gvr.Version, segments = segments[1], segments[2:]
+	case len(segments) >= 4 && segments[0] == "apis":
+		gvr.Group, gvr.Version, segments = segments[1], segments[2], segments[3:]
+	default:
+		return gvr, false
+	}
+	if len(segments) >= 3 && segments[0] == "namespaces" {
+		segments = segments[2:]
+	}
+	if len(segments) != 1 {
+		return gvr, false
+	}
+	gvr.Resource = segments[0]
+
+	return gvr, true
+}
+
+// countingReadCloser counts the bytes read through it, and observes their count once closed.
+type countingReadCloser struct {
+	io.ReadCloser
+	count   int64
+	observe func(n int64)
+	closed  bool
+}
+
+// Read implements io.Reader.
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.count += int64(n)
+
+	return n, err
+}
+
+// Close implements io.Closer.
+func (c *countingReadCloser) Close() error {
+	if !c.closed {
+		c.closed = true
+		c.observe(c.count)
+	}
+
+	return c.ReadCloser.Close()
+}
+
+// watchListGates enables the client's WatchListClient feature on top of the given gates.
+type watchListGates struct {
+	clientfeatures.Gates
+}
+
+// Enabled implements clientfeatures.Gates.
+func (g watchListGates) Enabled(key clientfeatures.Feature) bool {
+	if key == clientfeatures.WatchListClient {
+		return true
+	}
+
+	return g.Gates.Enabled(key)
+}
+
+// EnableWatchList makes reflectors stream their initial lists through watches, where the API server supports it,
+// falling back to paginated lists otherwise. It must be called before any client is used.
+func EnableWatchList() {
+	clientfeatures.ReplaceFeatureGates(watchListGates{Gates: clientfeatures.FeatureGates()})
+}
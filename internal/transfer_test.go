@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestListedResource(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		path     string
+		expected schema.GroupVersionResource
+		ok       bool
+	}{
+		{path: "/api/v1/pods", expected: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, ok: true},
+		{path: "/api/v1/namespaces", expected: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, ok: true},
+		{path: "/api/v1/namespaces/foo/pods", expected: schema.GroupVersionResource{Version: "v1", Resource: "pods"}, ok: true},
+		{path: "/apis/contoso.com/v1alpha1/bars", expected: schema.GroupVersionResource{Group: "contoso.com", Version: "v1alpha1", Resource: "bars"}, ok: true},
+		{path: "/apis/contoso.com/v1alpha1/namespaces/foo/bars", expected: schema.GroupVersionResource{Group: "contoso.com", Version: "v1alpha1", Resource: "bars"}, ok: true},
+		{path: "/api/v1/namespaces/foo"},
+		{path: "/api/v1/namespaces/foo/pods/bar"},
+		{path: "/apis/contoso.com/v1alpha1/namespaces/foo/bars/baz/status"},
+		{path: "/version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			got, ok := listedResource(tt.path)
+			if ok != tt.ok {
+				t.Fatalf("expected %t, got %t", tt.ok, ok)
+			}
+			if ok && got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestListTransfers(t *testing.T) {
+	t.Parallel()
+	body := strings.Repeat("x", 2048)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, body)
+	}))
+	defer server.Close()
+
+	transfers := NewListTransfers()
+	client := &http.Client{Transport: transfers.WrapTransport(http.DefaultTransport)}
+	for _, path := range []string{"/apis/contoso.com/v1alpha1/bars", "/apis/contoso.com/v1alpha1/bars?watch=true", "/apis/contoso.com/v1alpha1/namespaces/foo/bars/baz"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}
+
+	// Only the list request is observed.
+	if got := testutil.CollectAndCount(transfers.size); got != 1 {
+		t.Errorf("expected 1 series, got %d", got)
+	}
+}
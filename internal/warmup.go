@@ -0,0 +1,104 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// warmUp gates readiness on the monitors observed on startup having been processed, and the stores built for them
+// having processed their initial lists, so that an empty exposition is not scraped right after a restart. The gate
+// passes regardless once its maximum wait elapses, and keeps passing once it has.
+type warmUp struct {
+	stores  *sync.Map
+	maxWait time.Duration
+	mutex   sync.Mutex
+	// deadline is the time the gate passes regardless at, guarded by mutex, as it is restarted on acquiring leadership.
+	deadline time.Time
+	// standby passes the gate while the controller does not lead, as it processes no monitors until it does.
+	standby atomic.Bool
+	// pending holds the keys of the monitors observed on startup that are yet to be processed.
+	pending sets.Set[string]
+	warm    atomic.Bool
+}
+
+// newWarmUp returns a warmUp gate over the given stores, waiting for up to the given duration.
+func newWarmUp(stores *sync.Map, maxWait time.Duration) *warmUp {
+	return &warmUp{
+		stores:   stores,
+		maxWait:  maxWait,
+		deadline: time.Now().Add(maxWait),
+		pending:  sets.New[string](),
+	}
+}
+
+// standBy passes the gate until lead is called, for controllers campaigning for leadership.
+func (w *warmUp) standBy() {
+	w.standby.Store(true)
+}
+
+// lead holds the gate again, for up to its maximum wait from now, once the controller acquired leadership, and hence
+// starts processing the monitors observed on startup.
+func (w *warmUp) lead() {
+	w.mutex.Lock()
+	w.deadline = time.Now().Add(w.maxWait)
+	w.mutex.Unlock()
+	w.standby.Store(false)
+}
+
+// expect holds the gate until the monitor of the given key is processed.
+func (w *warmUp) expect(key string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.pending.Insert(key)
+}
+
+// processed marks the monitor of the given key as processed.
+func (w *warmUp) processed(key string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.pending.Delete(key)
+}
+
+// ready returns an error while the monitors observed on startup, or their stores, are warming up.
+func (w *warmUp) ready() error {
+	if w.warm.Load() || w.standby.Load() {
+		return nil
+	}
+	w.mutex.Lock()
+	deadline, pending := w.deadline, w.pending.Len()
+	w.mutex.Unlock()
+	if time.Now().After(deadline) {
+		w.warm.Store(true)
+
+		return nil
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d monitor(s) observed on startup are yet to be processed", pending)
+	}
+	if synced, total := storesSynced(w.stores); synced < total {
+		return fmt.Errorf("%d of %d store(s) are yet to process their initial lists", total-synced, total)
+	}
+	w.warm.Store(true)
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+func TestWarmUp(t *testing.T) {
+	t.Parallel()
+	stores := &sync.Map{}
+	w := newWarmUp(stores, time.Hour)
+	w.expect("default/foo")
+	if err := w.ready(); err == nil {
+		t.Fatal("expected the gate to hold until the monitors observed on startup are processed")
+	}
+
+	s := newStore(klog.Background(), nil, nil, ResolverTypeUnstructured, nil, nil, 0, 0)
+	s.Group, s.Version, s.Resource = "contoso.com", "v1alpha1", "bars"
+	stores.Store("default/foo", []*StoreType{s})
+	w.processed("default/foo")
+	if err := w.ready(); err == nil {
+		t.Fatal("expected the gate to hold until the stores process their initial lists")
+	}
+
+	if err := s.Add(newSyntheticObjects(1)[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.ready(); err != nil {
+		t.Fatalf("expected the gate to pass, got %v", err)
+	}
+
+	// Once warm, the gate keeps passing, regardless of the monitors processed later.
+	w.expect("default/bar")
+	if err := w.ready(); err != nil {
+		t.Errorf("expected the gate to keep passing, got %v", err)
+	}
+}
+
+func TestWarmUp_maxWait(t *testing.T) {
+	t.Parallel()
+	w := newWarmUp(&sync.Map{}, 0)
+	w.expect("default/foo")
+	if err := w.ready(); err != nil {
+		t.Errorf("expected the gate to pass once its maximum wait elapses, got %v", err)
+	}
+}
+
+func TestWarmUp_standBy(t *testing.T) {
+	t.Parallel()
+	w := newWarmUp(&sync.Map{}, time.Hour)
+	w.expect("default/foo")
+	w.standBy()
+	if err := w.ready(); err != nil {
+		t.Fatalf("expected the gate to pass while standing by, got %v", err)
+	}
+
+	w.lead()
+	if err := w.ready(); err == nil {
+		t.Fatal("expected the gate to hold once leading, until the monitors observed on startup are processed")
+	}
+	w.processed("default/foo")
+	if err := w.ready(); err != nil {
+		t.Errorf("expected the gate to pass, got %v", err)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// watchErrorCauseExpired is the cause of the watch errors due to the requested resource version being compacted
+	// away, i.e., HTTP 410 (Gone).
+	watchErrorCauseExpired = "expired"
+	// watchErrorCauseForbidden is the cause of the watch errors due to missing RBAC permissions.
+	watchErrorCauseForbidden = "forbidden"
+	// watchErrorCauseTimeout is the cause of the watch errors due to either the client or the API server timing out.
+	watchErrorCauseTimeout = "timeout"
+	// watchErrorCauseConversion is the cause of the watch errors due to objects failing to be converted, e.g., by a
+	// CRD's conversion webhook, or decoded.
+	watchErrorCauseConversion = "conversion"
+	// watchErrorCauseOther is the cause of all other watch errors.
+	watchErrorCauseOther = "other"
+)
+
+// forbiddenWatchErrorsThreshold is the number of consecutive Forbidden errors past which a store's monitor reports them
+// through its Forbidden condition.
+const forbiddenWatchErrorsThreshold = 3
+
+// forbiddenCheckInterval is the interval monitors' stores are checked for repeated Forbidden errors at.
+const forbiddenCheckInterval = 30 * time.Second
+
+// watchErrorCause returns the cause of the given list or watch error.
+func watchErrorCause(err error) string {
+	switch {
+	case apierrors.IsResourceExpired(err) || apierrors.IsGone(err):
+		return watchErrorCauseExpired
+	case apierrors.IsForbidden(err):
+		return watchErrorCauseForbidden
+	case apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) || utilnet.IsTimeout(err) || errors.Is(err, context.DeadlineExceeded):
+		return watchErrorCauseTimeout
+	// Conversion webhook failures are served as internal errors, and objects that cannot be decoded are delivered as
+	// such too.
+	case strings.Contains(err.Error(), "conversion webhook") || strings.Contains(err.Error(), "unable to decode"):
+		return watchErrorCauseConversion
+	default:
+		return watchErrorCauseOther
+	}
+}
+
+// recordWatchError counts the given list or watch error of the reflector backing the store, if any, by its cause.
+func (s *StoreType) recordWatchError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	cause := watchErrorCause(err)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.watchErrors == nil {
+		s.watchErrors = map[string]uint64{}
+	}
+	s.watchErrors[cause]++
+	if cause == watchErrorCauseForbidden {
+		s.forbidden++
+	} else {
+		s.forbidden = 0
+	}
+	s.logger.V(1).Info("Watch error", "gvr", s.gvr().String(), "cause", cause, "err", err.Error())
+}
+
+// recordWatchErrors returns the given watch, counting the errors it delivers, e.g., once the resource version it
+// started off expires.
+func (s *StoreType) recordWatchErrors(w watch.Interface) watch.Interface {
+	if s == nil {
+		return w
+	}
+
+	return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+		if event.Type == watch.Error {
+			s.recordWatchError(apierrors.FromObject(event.Object))
+		}
+
+		return event, true
+	})
+}
+
+// forbiddenTargets returns the resources the store, or its selected stores, are repeatedly forbidden from listing or
+// watching.
+func (s *StoreType) forbiddenTargets() []string {
+	s.mutex.RLock()
+	targets := append([]*StoreType{s}, s.selectedStores()...)
+	s.mutex.RUnlock()
+	var forbidden []string
+	for _, target := range targets {
+		target.mutex.RLock()
+		if target.forbidden >= forbiddenWatchErrorsThreshold {
+			forbidden = append(forbidden, target.gvr().String())
+		}
+		target.mutex.RUnlock()
+	}
+
+	return forbidden
+}
+
+// forbiddenCheck periodically reports the monitors whose stores are repeatedly forbidden from listing or watching
+// their targets, or that recovered since, through their Forbidden condition.
+type forbiddenCheck struct {
+	stores *sync.Map
+	// getMonitor returns the given monitor, to report on.
+	getMonitor func(namespace, name string) (*v1alpha1.ResourceMetricsMonitor, error)
+	// emit reports whether the given monitor's stores are forbidden, with the given message, or not, if it is empty.
+	emit func(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, message string)
+	// reported holds the message last reported for each monitor, by its key.
+	reported map[string]string
+}
+
+// newForbiddenCheck returns a forbiddenCheck for the given stores.
+func newForbiddenCheck(
+	stores *sync.Map,
+	getMonitor func(namespace, name string) (*v1alpha1.ResourceMetricsMonitor, error),
+	emit func(ctx context.Context, monitor *v1alpha1.ResourceMetricsMonitor, message string),
+) *forbiddenCheck {
+	return &forbiddenCheck{
+		stores:     stores,
+		getMonitor: getMonitor,
+		emit:       emit,
+		reported:   map[string]string{},
+	}
+}
+
+// run checks the stores of all monitors once, and reports the ones whose state changed. It is not safe to call
+// concurrently.
+func (f *forbiddenCheck) run(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	checked := map[string]struct{}{}
+	f.stores.Range(func(key, value any) bool {
+		keyString, _ := key.(string)
+		checked[keyString] = struct{}{}
+		builtStores, _ := value.([]*StoreType)
+		var forbidden []string
+		for _, s := range builtStores {
+			forbidden = append(forbidden, s.forbiddenTargets()...)
+		}
+		message := ""
+		if len(forbidden) > 0 {
+			slices.Sort(forbidden)
+			forbidden = slices.Compact(forbidden)
+			message = fmt.Sprintf("Forbidden from listing or watching %s, at least %d times in a row", strings.Join(forbidden, ", "), forbiddenWatchErrorsThreshold)
+		}
+		// Monitors are only reported on once forbidden, and again whenever that changes.
+		if previous, reported := f.reported[keyString]; previous == message && (reported || message == "") {
+			return true
+		}
+		objectName, err := cache.ParseObjectName(keyString)
+		if err != nil {
+			return true
+		}
+		// Stores not built for monitors, e.g., the auto conditions' ones, have none to report on.
+		monitor, err := f.getMonitor(objectName.Namespace, objectName.Name)
+		if err != nil {
+			return true
+		}
+		if message != "" {
+			logger.Info("Stores are repeatedly forbidden from listing or watching their targets", "key", keyString, "targets", forbidden)
+		}
+		f.emit(ctx, monitor, message)
+		f.reported[keyString] = message
+
+		return true
+	})
+
+	// Dropped monitors are forgotten.
+	for key := range f.reported {
+		if _, ok := checked[key]; !ok {
+			delete(f.reported, key)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+func TestWatchErrorCause(t *testing.T) {
+	t.Parallel()
+	gr := schema.GroupResource{Group: "contoso.com", Resource: "bars"}
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "expired", err: apierrors.NewResourceExpired("too old resource version"), expected: watchErrorCauseExpired},
+		{name: "gone", err: apierrors.NewGone("gone"), expected: watchErrorCauseExpired},
+		{name: "forbidden", err: apierrors.NewForbidden(gr, "", errors.New("no RBAC")), expected: watchErrorCauseForbidden},
+		{name: "timeout", err: apierrors.NewTimeoutError("timed out", 1), expected: watchErrorCauseTimeout},
+		{name: "deadline", err: context.DeadlineExceeded, expected: watchErrorCauseTimeout},
+		{name: "conversion", err: apierrors.NewInternalError(errors.New("conversion webhook for contoso.com/v1, Kind=Bar failed")), expected: watchErrorCauseConversion},
+		{name: "other", err: errors.New("connection refused"), expected: watchErrorCauseOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := watchErrorCause(tt.err); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestForbiddenCheck_run(t *testing.T) {
+	t.Parallel()
+	s := newStore(klog.Background(), nil, nil, ResolverTypeUnstructured, nil, nil, 0, 0)
+	s.Group, s.Version, s.Resource = "contoso.com", "v1alpha1", "bars"
+	stores := &sync.Map{}
+	stores.Store("default/foo", []*StoreType{s})
+	var emitted []string
+	check := newForbiddenCheck(stores, func(namespace, name string) (*v1alpha1.ResourceMetricsMonitor, error) {
+		return &v1alpha1.ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}, nil
+	}, func(_ context.Context, _ *v1alpha1.ResourceMetricsMonitor, message string) {
+		emitted = append(emitted, message)
+	})
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Group: "contoso.com", Resource: "bars"}, "", errors.New("no RBAC"))
+
+	// Stores are not reported on until forbidden repeatedly.
+	s.recordWatchError(forbidden)
+	check.run(context.Background())
+	if len(emitted) != 0 {
+		t.Fatalf("expected no reports, got %q", emitted)
+	}
+	for range forbiddenWatchErrorsThreshold {
+		s.recordWatchError(forbidden)
+	}
+	check.run(context.Background())
+	check.run(context.Background())
+	if len(emitted) != 1 || !strings.Contains(emitted[0], "contoso.com/v1alpha1, Resource=bars") {
+		t.Fatalf("expected a single report on the forbidden target, got %q", emitted)
+	}
+	if got := s.watchErrors[watchErrorCauseForbidden]; got != forbiddenWatchErrorsThreshold+1 {
+		t.Errorf("expected %d forbidden errors, got %d", forbiddenWatchErrorsThreshold+1, got)
+	}
+
+	// Recovered stores are reported on as such.
+	s.mutex.Lock()
+	s.observe("")
+	s.mutex.Unlock()
+	check.run(context.Background())
+	if len(emitted) != 2 || emitted[1] != "" {
+		t.Errorf("expected the recovery to be reported, got %q", emitted)
+	}
+}
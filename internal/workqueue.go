@@ -0,0 +1,122 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// workqueueSubsystem is the subsystem the workqueue metrics are exposed under, in line with other Kubernetes
+// controllers, so existing dashboards and alerts apply as is.
+const workqueueSubsystem = "workqueue"
+
+// workqueueMetricsProvider implements workqueue.MetricsProvider, and exposes the standard workqueue metrics, labeled by
+// the queues' names, through the given registerer.
+type workqueueMetricsProvider struct {
+	depth                   *prometheus.GaugeVec
+	adds                    *prometheus.CounterVec
+	latency                 *prometheus.HistogramVec
+	workDuration            *prometheus.HistogramVec
+	unfinishedWork          *prometheus.GaugeVec
+	longestRunningProcessor *prometheus.GaugeVec
+	retries                 *prometheus.CounterVec
+}
+
+// Ensure workqueueMetricsProvider implements workqueue.MetricsProvider.
+var _ workqueue.MetricsProvider = &workqueueMetricsProvider{}
+
+// newWorkqueueMetricsProvider returns a workqueueMetricsProvider registering its metrics with the given registerer.
+func newWorkqueueMetricsProvider(registerer prometheus.Registerer) *workqueueMetricsProvider {
+	labelKeys := []string{"name"}
+
+	return &workqueueMetricsProvider{
+		depth: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Subsystem: workqueueSubsystem,
+			Name:      "depth",
+			Help:      "Current depth of the workqueue.",
+		}, labelKeys),
+		adds: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Subsystem: workqueueSubsystem,
+			Name:      "adds_total",
+			Help:      "Total number of adds handled by the workqueue.",
+		}, labelKeys),
+		latency: promauto.With(registerer).NewHistogramVec(prometheus.HistogramOpts{
+			Subsystem: workqueueSubsystem,
+			Name:      "queue_duration_seconds",
+			Help:      "How long in seconds an item stays in the workqueue before being requested.",
+			Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+		}, labelKeys),
+		workDuration: promauto.With(registerer).NewHistogramVec(prometheus.HistogramOpts{
+			Subsystem: workqueueSubsystem,
+			Name:      "work_duration_seconds",
+			Help:      "How long in seconds processing an item from the workqueue takes.",
+			Buckets:   prometheus.ExponentialBuckets(10e-9, 10, 10),
+		}, labelKeys),
+		unfinishedWork: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Subsystem: workqueueSubsystem,
+			Name:      "unfinished_work_seconds",
+			Help:      "How many seconds of work has been done that is in progress and has not been observed by work_duration. Large values indicate stuck threads.",
+		}, labelKeys),
+		longestRunningProcessor: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Subsystem: workqueueSubsystem,
+			Name:      "longest_running_processor_seconds",
+			Help:      "How many seconds the longest running processor for the workqueue has been running.",
+		}, labelKeys),
+		retries: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Subsystem: workqueueSubsystem,
+			Name:      "retries_total",
+			Help:      "Total number of retries handled by the workqueue.",
+		}, labelKeys),
+	}
+}
+
+// NewDepthMetric implements workqueue.MetricsProvider.
+func (p *workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return p.depth.WithLabelValues(name)
+}
+
+// NewAddsMetric implements workqueue.MetricsProvider.
+func (p *workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return p.adds.WithLabelValues(name)
+}
+
+// NewLatencyMetric implements workqueue.MetricsProvider.
+func (p *workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return p.latency.WithLabelValues(name)
+}
+
+// NewWorkDurationMetric implements workqueue.MetricsProvider.
+func (p *workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return p.workDuration.WithLabelValues(name)
+}
+
+// NewUnfinishedWorkSecondsMetric implements workqueue.MetricsProvider.
+func (p *workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return p.unfinishedWork.WithLabelValues(name)
+}
+
+// NewLongestRunningProcessorSecondsMetric implements workqueue.MetricsProvider.
+func (p *workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return p.longestRunningProcessor.WithLabelValues(name)
+}
+
+// NewRetriesMetric implements workqueue.MetricsProvider.
+func (p *workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return p.retries.WithLabelValues(name)
+}
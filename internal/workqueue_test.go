@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestWorkqueueMetricsProvider(t *testing.T) {
+	t.Parallel()
+	registry := prometheus.NewRegistry()
+	provider := newWorkqueueMetricsProvider(registry)
+	queue := workqueue.NewTypedRateLimitingQueueWithConfig[string](workqueue.DefaultTypedControllerRateLimiter[string](), workqueue.TypedRateLimitingQueueConfig[string]{
+		Name:            "foo",
+		MetricsProvider: provider,
+	})
+	defer queue.ShutDown()
+
+	queue.Add("bar")
+	if got := testutil.ToFloat64(provider.depth.WithLabelValues("foo")); got != 1 {
+		t.Errorf("expected a depth of 1, got %v", got)
+	}
+	if got := testutil.ToFloat64(provider.adds.WithLabelValues("foo")); got != 1 {
+		t.Errorf("expected 1 add, got %v", got)
+	}
+
+	item, _ := queue.Get()
+	queue.Done(item)
+	if got := testutil.ToFloat64(provider.depth.WithLabelValues("foo")); got != 0 {
+		t.Errorf("expected a depth of 0, got %v", got)
+	}
+	if got := testutil.CollectAndCount(registry, "workqueue_work_duration_seconds"); got != 1 {
+		t.Errorf("expected 1 work duration series, got %d", got)
+	}
+}
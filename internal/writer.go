@@ -3,11 +3,25 @@ package internal
 import (
 	"fmt"
 	"io"
+	"path"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utiltrace "k8s.io/utils/trace"
 )
 
 // metricsWriter writes metrics from a group of stores to an io.Writer.
 type metricsWriter struct {
 	stores []*StoreType
+	// skip, if set, reports whether the given store's series for objects in the given namespace should be left out.
+	skip func(store *StoreType, namespace string) bool
+	// openMetrics reports whether the exposition is OpenMetrics, which, unlike the text format, carries exemplars.
+	openMetrics bool
+	// trace, if set, records the time each store took to be written.
+	trace *utiltrace.Trace
+	// since, if set, narrows the exposition down to the series changed since the given revision, and the ones deleted
+	// since, as comments. Families are left out altogether if none of their series changed.
+	since uint64
 }
 
 // newMetricsWriter creates a new metricsWriter.
@@ -28,6 +42,9 @@ func (m *metricsWriter) writeStores(writer io.Writer) error {
 		store.mutex.RLock()
 		err := m.writeFromStore(writer, store)
 		store.mutex.RUnlock()
+		if m.trace != nil {
+			m.trace.Step("Wrote store", utiltrace.Field{Key: "resource", Value: store.gvr().String()})
+		}
 
 		if err != nil {
 			return err
@@ -38,24 +55,119 @@ func (m *metricsWriter) writeStores(writer io.Writer) error {
 }
 
 func (m *metricsWriter) writeFromStore(writer io.Writer, store *StoreType) error {
+	// Stores selecting their targets by CRD labels share their headers with the stores built for each target.
+	selected := store.selectedStores()
 	for i, header := range store.headers {
-		if err := writeHeader(writer, header); err != nil {
+		// Deltas only carry the headers of the families any series changed for.
+		familyWriter := writer
+		if m.since > 0 {
+			familyWriter = &deltaWriter{Writer: writer, header: header}
+		} else if err := writeHeader(writer, header); err != nil {
 			return fmt.Errorf("error writing header: %w", err)
 		}
 
-		for _, metricFamilies := range store.metrics {
-			if i >= len(metricFamilies) {
-				continue
+		// Aggregated families are written out once all objects' series have been accumulated.
+		write := func(metricFamily string) error { return writeMetricFamily(familyWriter, metricFamily) }
+		var aggregated *aggregation
+		if i < len(store.Families) && store.Families[i].Aggregate != AggregateTypeNone {
+			aggregated = newAggregation(store.Families[i].Aggregate)
+			aggregated.fixedPoint = store.Families[i].fixedPointValues
+			write = aggregated.add
+		}
+		if !m.openMetrics && i < len(store.Families) && store.Families[i].hasExemplars() {
+			write = func(metricFamily string) error { return writeMetricFamily(familyWriter, stripExemplars(metricFamily)) }
+		}
+
+		// Objects served by several of a store's sources are only written out off the first one.
+		var written sets.Set[string]
+		if store.sourced {
+			written = sets.New[string]()
+		}
+		if err := m.forEachSeries(store, i, written, write); err != nil {
+			return err
+		}
+		for _, selectedStore := range selected {
+			selectedStore.mutex.RLock()
+			err := m.forEachSeries(selectedStore, i, written, write)
+			selectedStore.mutex.RUnlock()
+
+			if err != nil {
+				return err
 			}
-			if err := writeMetricFamily(writer, metricFamilies[i]); err != nil {
+		}
+
+		if aggregated != nil {
+			if err := aggregated.writeTo(familyWriter); err != nil {
 				return err
 			}
+
+			continue
+		}
+		if m.since > 0 {
+			if err := m.writeDeletions(familyWriter, store, selected, i); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeDeletions writes out the series of the given family, for all objects deleted since the writer's revision, in the
+// given store and its selected ones.
+func (m *metricsWriter) writeDeletions(writer io.Writer, store *StoreType, selected []*StoreType, family int) error {
+	write := func(series string) error { return writeMetricFamily(writer, series) }
+	if err := store.writeDeletions(write, family, m.since); err != nil {
+		return err
+	}
+	for _, selectedStore := range selected {
+		selectedStore.mutex.RLock()
+		err := selectedStore.writeDeletions(write, family, m.since)
+		selectedStore.mutex.RUnlock()
+
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// forEachSeries calls fn with the series of the given family, for all objects in the given store, skipping the ones in
+// the given written set, if any, and adding the others to it, by their clusters and UIDs.
+func (m *metricsWriter) forEachSeries(store *StoreType, family int, written sets.Set[string], fn func(metricFamily string) error) error {
+	return store.metrics.forEach(func(uid types.UID, metricFamilies []string) error {
+		if m.skip != nil && m.skip(store, store.namespaces[uid]) {
+			return nil
+		}
+		if store.expired(uid) || store.stale(uid) {
+			return nil
+		}
+		if written != nil {
+			key := path.Join(store.cluster, string(uid))
+			if written.Has(key) {
+				return nil
+			}
+			written.Insert(key)
+		}
+		if family >= len(metricFamilies) {
+			return nil
+		}
+		// Families rendered at scrape time, or aggregated across objects, are written out in full in deltas too.
+		full := family < len(store.Families) && (store.Families[family].onScrape() ||
+			store.Families[family].Aggregate != AggregateTypeNone)
+		if m.since > 0 && !full && store.revisions[uid] <= m.since {
+			return nil
+		}
+		metricFamily := metricFamilies[family]
+		if family < len(store.Families) && store.Families[family].onScrape() {
+			metricFamily = store.renderOnScrape(uid, family)
+		}
+
+		return fn(metricFamily)
+	})
+}
+
 func writeHeader(writer io.Writer, header string) error {
 	if header != "" && header != "\n" {
 		header += "\n"
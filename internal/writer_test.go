@@ -2,10 +2,11 @@ package internal
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
 )
 
 func TestMetricsWriter_writeAllTo(t *testing.T) {
@@ -26,7 +27,7 @@ func TestMetricsWriter_writeAllTo(t *testing.T) {
 				stores: []*StoreType{
 					{
 						headers: []string{"header1", "header2"},
-						metrics: map[types.UID][]string{
+						metrics: memoryStorage{
 							"uid1": {"metric1", "metric2"},
 							"uid2": {"metric1", "metric2"},
 						},
@@ -41,7 +42,7 @@ func TestMetricsWriter_writeAllTo(t *testing.T) {
 				stores: []*StoreType{
 					{
 						headers: []string{"header1", "header2", "header3"},
-						metrics: map[types.UID][]string{
+						metrics: memoryStorage{
 							"uid1": {"metric1", "metric2"},
 							"uid2": {"metric1", "metric2", "metric3"},
 						},
@@ -56,7 +57,7 @@ func TestMetricsWriter_writeAllTo(t *testing.T) {
 				stores: []*StoreType{
 					{
 						headers: []string{"header1"},
-						metrics: map[types.UID][]string{
+						metrics: memoryStorage{
 							"uid1": {"metric1", "metric2"},
 							"uid2": {"metric1", "metric2"},
 						},
@@ -71,7 +72,7 @@ func TestMetricsWriter_writeAllTo(t *testing.T) {
 				stores: []*StoreType{
 					{
 						headers: []string{},
-						metrics: map[types.UID][]string{
+						metrics: memoryStorage{
 							"uid1": {"metric1", "metric1"},
 							"uid2": {"metric1"},
 						},
@@ -80,6 +81,22 @@ func TestMetricsWriter_writeAllTo(t *testing.T) {
 			},
 			expected: "",
 		},
+		{
+			name: "aggregated family",
+			m: metricsWriter{
+				stores: []*StoreType{
+					{
+						headers:  []string{"header1", "header2"},
+						Families: []*FamilyType{{}, {Aggregate: AggregateTypeSum}},
+						metrics: memoryStorage{
+							"uid1": {"metric1\n", "metric2{phase=\"Running\"} 1.000000\n"},
+							"uid2": {"metric1\n", "metric2{phase=\"Running\"} 2.000000\nmetric2{phase=\"Pending\"} 1.000000\n"},
+						},
+					},
+				},
+			},
+			expected: "header1\nmetric1\nmetric1\nheader2\nmetric2{phase=\"Pending\"} 1\nmetric2{phase=\"Running\"} 3\n",
+		},
 	}
 
 	for _, tt := range tests {
@@ -95,3 +112,65 @@ func TestMetricsWriter_writeAllTo(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkMetricsWriter_writeStores(b *testing.B) {
+	for _, count := range benchmarkObjectCounts {
+		b.Run(fmt.Sprintf("objects=%d", count), func(b *testing.B) {
+			s := newBenchmarkStore(b, ResolverTypeUnstructured)
+			for _, object := range newSyntheticObjects(count) {
+				if err := s.Add(object); err != nil {
+					b.Fatal(err)
+				}
+			}
+			m := newMetricsWriter(s)
+			buffer := &bytes.Buffer{}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				buffer.Reset()
+				if err := m.writeStores(buffer); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.SetBytes(int64(buffer.Len()))
+		})
+	}
+}
+
+func TestMetricsWriter_onScrape(t *testing.T) {
+	t.Parallel()
+	s := newStore(klog.Background(), []string{
+		"# HELP kube_customresource_on_event\n# TYPE kube_customresource_on_event gauge",
+		"# HELP kube_customresource_on_scrape\n# TYPE kube_customresource_on_scrape gauge",
+	}, []*FamilyType{
+		{Name: "on_event", Metrics: []*MetricType{{Value: "spec.replicas"}}},
+		{Name: "on_scrape", Metrics: []*MetricType{{Value: "spec.replicas"}}, Evaluate: EvaluationPolicyOnScrape},
+	}, ResolverTypeUnstructured, nil, nil, 0, 0)
+	object := newSyntheticObjects(2)[1]
+	if err := s.Add(object); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.metrics.get(object.GetUID()); got[0] == "" || got[1] != "" {
+		t.Errorf("expected only the onEvent family to be rendered when added, got %q", got)
+	}
+
+	w := &bytes.Buffer{}
+	if err := newMetricsWriter(s).writeStores(w); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# HELP kube_customresource_on_event\n# TYPE kube_customresource_on_event gauge\n" +
+		"kube_customresource_on_event{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 1\n" +
+		"# HELP kube_customresource_on_scrape\n# TYPE kube_customresource_on_scrape gauge\n" +
+		"kube_customresource_on_scrape{group=\"contoso.com\",version=\"v1alpha1\",kind=\"Bar\"} 1\n"
+	if diff := cmp.Diff(expected, w.String()); diff != "" {
+		t.Errorf("unexpected exposition (-want +got):\n%s", diff)
+	}
+
+	// Deleted objects are no longer rendered at scrape time.
+	if err := s.Delete(object); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.objects[object.GetUID()]; ok {
+		t.Error("expected the deleted object not to be cached")
+	}
+}
@@ -20,16 +20,18 @@ import (
 	"flag"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/KimMachineGun/automemlimit/memlimit"
 	"github.com/rexagod/resource-state-metrics/internal"
 	v "github.com/rexagod/resource-state-metrics/internal/version"
 	clientset "github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned"
+	"github.com/rexagod/resource-state-metrics/pkg/resolver"
 	"github.com/rexagod/resource-state-metrics/pkg/signals"
 	"go.uber.org/automaxprocs/maxprocs"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc" // Authenticate through OIDC auth-providers set in kubeconfigs.
 	"k8s.io/klog/v2"
 )
 
@@ -39,10 +41,24 @@ func main() {
 	ctx := klog.NewContext(signals.SetupSignalHandler(), klog.NewKlogr())
 	logger := klog.FromContext(ctx)
 
+	// Run the subcommand instead, if one was specified.
+	if len(os.Args) > 1 {
+		if subcommand, ok := internal.LookupSubcommand(os.Args[1]); ok {
+			if err := subcommand(ctx, os.Args[2:]); err != nil {
+				logger.Error(err, "Error running subcommand", "subcommand", os.Args[1])
+				klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+			}
+			os.Exit(0)
+		}
+	}
+
 	// Set up flags.
 	klog.InitFlags(flag.CommandLine)
 	options := internal.NewOptions(logger)
-	options.Read()
+	if err := options.Read(); err != nil {
+		logger.Error(err, "Invalid options")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
 
 	// Set GOMAXPROCS based on CPU quota.
 	if *options.AutoGOMAXPROCS {
@@ -71,17 +87,34 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Build client-sets.
-	cfg, err := clientcmd.BuildConfigFromFlags(*options.MasterURL, *options.Kubeconfig)
+	// Deduplicate the logs of repeated resolution failures.
+	resolver.SetLogInterval(time.Duration(*options.ResolutionLogInterval) * time.Second)
+
+	// Stream initial lists, if requested, before any client is used.
+	if *options.WatchList {
+		internal.EnableWatchList()
+	}
+
+	// Build client-sets, observing the size of their list responses.
+	cfg, source, err := internal.BuildConfig(*options.MasterURL, *options.Kubeconfig)
 	if err != nil {
-		logger.Error(err, "Error building kubeconfig", "kubeconfig", *options.Kubeconfig)
+		logger.Error(err, "Error building client configuration", "source", source)
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
+	logger.V(1).Info("Built client configuration", "source", source, "host", cfg.Host)
+	listTransfers := internal.NewListTransfers()
+	cfg.Wrap(listTransfers.WrapTransport)
 	kubeClientset, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		logger.Error(err, "Error building kubernetes clientset")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
+	// Reach the API server up front, so that credential plugins, and any other authentication issues, fail the startup
+	// clearly, rather than the informers retrying indefinitely.
+	if _, err = kubeClientset.Discovery().ServerVersion(); err != nil {
+		logger.Error(err, "Error reaching the API server", "source", source, "host", cfg.Host)
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
 	rsmClientset, err := clientset.NewForConfig(cfg)
 	if err != nil {
 		logger.Error(err, "Error building resource-state-metrics clientset")
@@ -93,8 +126,14 @@ func main() {
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 
+	clusterClientsets, err := internal.NewClusterClientsets(options.Clusters, cfg)
+	if err != nil {
+		logger.Error(err, "Error building federated cluster clientsets")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
 	// Start the controller.
-	c := internal.NewController(ctx, options, kubeClientset, rsmClientset, dynamicClientset)
+	c := internal.NewController(ctx, options, cfg, kubeClientset, rsmClientset, dynamicClientset, clusterClientsets, listTransfers)
 	if err = c.Run(ctx, *options.Workers); err != nil {
 		logger.Error(err, "Error running controller")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
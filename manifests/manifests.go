@@ -0,0 +1,41 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package manifests embeds the generated manifests, so they may be installed by the binary itself.
+package manifests
+
+import _ "embed"
+
+// CustomResourceDefinition is the ResourceMetricsMonitor CRD.
+//
+//go:embed custom-resource-definition.yaml
+var CustomResourceDefinition []byte
+
+// ClusterCustomResourceDefinition is the ClusterResourceMetricsMonitor CRD.
+//
+//go:embed custom-resource-definition-cluster.yaml
+var ClusterCustomResourceDefinition []byte
+
+// ClusterRole is the ClusterRole required to manage (Cluster)ResourceMetricsMonitors, along with the Roles required in
+// specific namespaces, e.g., to read the aggregation layer's configuration in kube-system.
+//
+//go:embed cluster-role.yaml
+var ClusterRole []byte
+
+// ValidatingAdmissionPolicy restricts annotating ResourceMetricsMonitors as cluster-scoped, along with its binding.
+//
+//go:embed validating-admission-policy.yaml
+var ValidatingAdmissionPolicy []byte
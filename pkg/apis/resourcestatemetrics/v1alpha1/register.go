@@ -49,6 +49,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&ResourceMetricsMonitor{},
 		&ResourceMetricsMonitorList{},
+		&ClusterResourceMetricsMonitor{},
+		&ClusterResourceMetricsMonitorList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -17,10 +17,34 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"time"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/strings/slices"
 )
 
+// ClusterScopedAnnotation, when set to "true" on a ResourceMetricsMonitor, lets its stores target resources outside of
+// its own namespace if the controller scopes stores by namespace. Setting it should be restricted to privileged users;
+// see the ValidatingAdmissionPolicy in manifests/.
+const ClusterScopedAnnotation = "resource-state-metrics.instrumentation.k8s-sigs.io/cluster-scoped"
+
+// PausedAnnotation, when set to "true" on a ResourceMetricsMonitor, tears down its stores without deleting it, e.g., to
+// mitigate an incident caused by a misbehaving monitor. Unsetting it rebuilds the stores.
+const PausedAnnotation = "resource-state-metrics.instrumentation.k8s-sigs.io/paused"
+
+// Finalizer is set on (Cluster)ResourceMetricsMonitors by the controller, so that their stores, storages, and
+// ServiceMonitors are cleaned up before their deletion completes.
+const Finalizer = "resource-state-metrics.instrumentation.k8s-sigs.io/cleanup"
+
+// PriorityAnnotation, set to an integer on a ResourceMetricsMonitor, orders the building of its stores on controller
+// start, relative to other monitors, with higher priorities built first. Monitors default to a priority of 0.
+const PriorityAnnotation = "resource-state-metrics.instrumentation.k8s-sigs.io/priority"
+
+// TierLabel, set to "critical", "standard", or "best-effort" on a ResourceMetricsMonitor, exposes its metrics on the
+// tier's own endpoint as well, e.g., /metrics/critical, so they may be scraped at different intervals. Monitors default
+// to the "standard" tier.
+const TierLabel = "resource-state-metrics.instrumentation.k8s-sigs.io/tier"
+
 const (
 
 	// ConditionTypeProcessed represents the condition type for a resource that has been processed successfully.
@@ -28,38 +52,89 @@ const (
 
 	// ConditionTypeFailed represents the condition type for resource that has failed to process further.
 	ConditionTypeFailed
+
+	// ConditionTypeEstablished represents the condition type for a resource whose stores' targeted CRDs are established.
+	ConditionTypeEstablished
+
+	// ConditionTypeDegraded represents the condition type for a resource that has been processed, but whose metrics are
+	// not being served or scraped as expected.
+	ConditionTypeDegraded
+
+	// ConditionTypePaused represents the condition type for a resource whose stores are torn down on request.
+	ConditionTypePaused
+
+	// ConditionTypeConflict represents the condition type for a resource defining families that conflict with the ones
+	// of older resources.
+	ConditionTypeConflict
+
+	// ConditionTypeTested represents the condition type for a resource whose tests, if any, have been run.
+	ConditionTypeTested
+
+	// ConditionTypeKSMCollision represents the condition type for a resource defining families exposing metrics named
+	// alike kube-state-metrics' ones.
+	ConditionTypeKSMCollision
+
+	// ConditionTypeForbidden represents the condition type for a resource whose stores are repeatedly forbidden from
+	// listing or watching their targets.
+	ConditionTypeForbidden
 )
 
 var (
 
 	// ConditionType is a slice of strings representing the condition types.
-	ConditionType = []string{"Processed", "Failed"}
+	ConditionType = []string{"Processed", "Failed", "Established", "Degraded", "Paused", "Conflict", "Tested", "KSMCollision", "Forbidden"}
 
 	// ConditionMessageTrue is a group of condition messages applicable when the associated condition status is true.
 	ConditionMessageTrue = []string{
 		"Resource configuration has been processed successfully",
 		"Resource failed to process",
+		"All targeted CRDs are established",
+		"Resource is degraded",
+		"Stores are torn down until the resource is unpaused",
+		"Resource defines families that conflict with the ones of older resources",
+		"All tests passed",
+		"Resource defines families exposing metrics named alike kube-state-metrics' ones",
+		"Stores are repeatedly forbidden from listing or watching their targets",
 	}
 
 	// ConditionMessageFalse is a group of condition messages applicable when the associated condition status is false.
 	ConditionMessageFalse = []string{
 		"Resource configuration is yet to be processed",
 		"N/A",
+		"Stores are deferred until their targeted CRDs are established",
+		"Resource is not degraded",
+		"Resource is not paused",
+		"Resource does not conflict with other resources",
+		"Some tests failed",
+		"Resource does not define families exposing metrics named alike kube-state-metrics' ones",
+		"Stores are not forbidden from listing or watching their targets",
 	}
 
 	// ConditionReasonTrue is a group of condition reasons applicable when the associated condition status is true.
-	ConditionReasonTrue = []string{"EventHandlerSucceeded", "EventHandlerFailed"}
+	ConditionReasonTrue = []string{"EventHandlerSucceeded", "EventHandlerFailed", "CRDsEstablished", "Degraded", "PausedByAnnotation", "ConflictingFamilies", "TestsPassed", "CollidingFamilies", "WatchForbidden"}
 
 	// ConditionReasonFalse is a group of condition reasons applicable when the associated condition status is false.
-	ConditionReasonFalse = []string{"EventHandlerRunning", "N/A"}
+	ConditionReasonFalse = []string{"EventHandlerRunning", "N/A", "CRDsNotEstablished", "NotDegraded", "NotPaused", "NoConflicts", "TestsFailed", "NoCollisions", "NotForbidden"}
 )
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:singular=resourcemetricsmonitor,scope=Namespaced,shortName=rmm
-// +kubebuilder:rbac:groups=resource-state-metrics.instrumentation.k8s-sigs.io,resources=resourcemetricsmonitors;resourcemetricsmonitors/status,verbs=*
+// +kubebuilder:rbac:groups=resource-state-metrics.instrumentation.k8s-sigs.io,resources=resourcemetricsmonitors;resourcemetricsmonitors/finalizers;resourcemetricsmonitors/status,verbs=*
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch;update
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;create;update;delete
+// +kubebuilder:rbac:groups="",resources=services,verbs=get
+// +kubebuilder:rbac:groups="",namespace=kube-system,resources=configmaps,resourceNames=extension-apiserver-authentication,verbs=get
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Stores",type=integer,JSONPath=`.status.stores`
+// +kubebuilder:printcolumn:name="Families",type=integer,JSONPath=`.status.families`
+// +kubebuilder:printcolumn:name="Series",type=integer,JSONPath=`.status.series`
+// +kubebuilder:printcolumn:name="Processed",type=string,JSONPath=`.status.conditions[?(@.type=="Processed")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ResourceMetricsMonitor is a specification for a ResourceMetricsMonitor resource.
 type ResourceMetricsMonitor struct {
@@ -73,11 +148,36 @@ type ResourceMetricsMonitor struct {
 type ResourceMetricsMonitorSpec struct {
 
 	// +kubebuilder:validation:Format=string
-	// +kubebuilder:validation:Required
-	// +required
+	// +optional
 
 	// Configuration is the RSM configuration that generates metrics.
-	Configuration string `json:"configuration"`
+	Configuration string `json:"configuration,omitempty"`
+
+	// +optional
+
+	// Preset is the name of a built-in configuration, e.g., cert-manager/certificates, whose stores are generated in
+	// addition to the configuration's ones.
+	Preset string `json:"preset,omitempty"`
+
+	// +optional
+
+	// Tests are run against the configuration whenever the resource is processed, or linted, with their failures
+	// reported through its Tested condition.
+	Tests []ResourceMetricsMonitorTest `json:"tests,omitempty"`
+}
+
+// ResourceMetricsMonitorTest asserts the series a resource's configuration generates for a set of sample objects.
+type ResourceMetricsMonitorTest struct {
+
+	// Name is the name of the test.
+	Name string `json:"name"`
+
+	// Objects are the sample objects, as (multi-document) YAML, the configuration is run against, as if they were the
+	// only ones present on the cluster. Selectors are evaluated client-side.
+	Objects string `json:"objects"`
+
+	// Expected are the lines the resulting exposition is expected to contain, e.g., series, or their headers.
+	Expected []string `json:"expected"`
 }
 
 // +kubebuilder:validation:Optional
@@ -93,34 +193,79 @@ type ResourceMetricsMonitorStatus struct {
 
 	// Conditions is an array of conditions associated with the resource.
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// +listType=atomic
+
+	// LastErrors is a truncated summary of the most recent errors resolving the resource's expressions, most recent
+	// first, for users without access to the controller's logs to debug them.
+	LastErrors []ResolutionError `json:"lastErrors,omitempty"`
+
+	// Stores is the number of stores built for the resource, as of the last report.
+	Stores int32 `json:"stores,omitempty"`
+
+	// Families is the number of families the resource's stores generate, as of the last report.
+	Families int32 `json:"families,omitempty"`
+
+	// Series is the number of series the resource's stores hold, as of the last report.
+	Series int64 `json:"series,omitempty"`
+
+	// TransitionCount is the number of times the resource's Processed condition transitioned, to help debug resources
+	// that genuinely flap.
+	TransitionCount int64 `json:"transitionCount,omitempty"`
 }
 
-// Set sets the given condition for the resource.
+// ResolutionError is an error resolving one of a resource's expressions against an object.
+type ResolutionError struct {
+
+	// Expression is the expression that failed to resolve, truncated if too long.
+	Expression string `json:"expression"`
+
+	// Object is the namespace-qualified name of the object the expression failed to resolve against.
+	Object string `json:"object"`
+
+	// Error is the error the expression failed to resolve with, truncated if too long.
+	Error string `json:"error"`
+
+	// Timestamp is the time the error was first observed at.
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// Set sets the given condition for the resource. The condition's reason and message, if unset, default to the ones
+// associated with its type and status, and its transition time, if unset, to now. The transition time is only bumped if
+// the condition's status changed.
 func (status *ResourceMetricsMonitorStatus) Set(
 	resource *ResourceMetricsMonitor,
 	condition metav1.Condition,
 ) {
-	// Prefix condition messages with consistent hints.
-	var message, reason string
-	conditionTypeNumeric := slices.Index(ConditionType, condition.Type)
-	if condition.Status == metav1.ConditionTrue {
-		reason = ConditionReasonTrue[conditionTypeNumeric]
-		message = ConditionMessageTrue[conditionTypeNumeric]
-	} else {
-		reason = ConditionReasonFalse[conditionTypeNumeric]
-		message = ConditionMessageFalse[conditionTypeNumeric]
+	// Default to consistent hints for known condition types.
+	if conditionTypeNumeric := slices.Index(ConditionType, condition.Type); conditionTypeNumeric != -1 {
+		reason, message := ConditionReasonFalse[conditionTypeNumeric], ConditionMessageFalse[conditionTypeNumeric]
+		if condition.Status == metav1.ConditionTrue {
+			reason, message = ConditionReasonTrue[conditionTypeNumeric], ConditionMessageTrue[conditionTypeNumeric]
+		}
+		if condition.Reason == "" {
+			condition.Reason = reason
+		}
+		if condition.Message == "" {
+			condition.Message = message
+		}
 	}
 
 	// Populate status fields.
-	condition.Reason = reason
-	condition.Message = message
-	condition.LastTransitionTime = metav1.Now()
+	if condition.LastTransitionTime.IsZero() {
+		condition.LastTransitionTime = metav1.Now()
+	}
 	condition.ObservedGeneration = resource.GetGeneration()
 
 	// Check if the condition already exists.
 	for i, existingCondition := range status.Conditions {
 		if existingCondition.Type == condition.Type {
-			// Update the existing condition.
+			// Update the existing condition, retaining its transition time if its status did not change.
+			if existingCondition.Status == condition.Status {
+				condition.LastTransitionTime = existingCondition.LastTransitionTime
+			} else if condition.Type == ConditionType[ConditionTypeProcessed] {
+				status.TransitionCount++
+			}
 			status.Conditions[i] = condition
 
 			return
@@ -131,6 +276,30 @@ func (status *ResourceMetricsMonitorStatus) Set(
 	status.Conditions = append(status.Conditions, condition)
 }
 
+// SetDebounced sets the given condition like Set, unless it would transition the existing condition of its type less
+// than the given hold time after that last transitioned, as of the condition's transition time, if set, or now, in
+// which case the existing condition is retained, so that transient blips do not churn the status. It reports whether
+// the condition was set.
+func (status *ResourceMetricsMonitorStatus) SetDebounced(
+	resource *ResourceMetricsMonitor,
+	condition metav1.Condition,
+	hold time.Duration,
+) bool {
+	now := condition.LastTransitionTime.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, existingCondition := range status.Conditions {
+		if existingCondition.Type == condition.Type && existingCondition.Status != condition.Status &&
+			now.Sub(existingCondition.LastTransitionTime.Time) < hold {
+			return false
+		}
+	}
+	status.Set(resource, condition)
+
+	return true
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:object:root=true
 
@@ -141,3 +310,36 @@ type ResourceMetricsMonitorList struct {
 
 	Items []ResourceMetricsMonitor `json:"items"`
 }
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:singular=clusterresourcemetricsmonitor,scope=Cluster,shortName=crmm
+// +kubebuilder:rbac:groups=resource-state-metrics.instrumentation.k8s-sigs.io,resources=clusterresourcemetricsmonitors;clusterresourcemetricsmonitors/finalizers;clusterresourcemetricsmonitors/status,verbs=*
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Stores",type=integer,JSONPath=`.status.stores`
+// +kubebuilder:printcolumn:name="Families",type=integer,JSONPath=`.status.families`
+// +kubebuilder:printcolumn:name="Series",type=integer,JSONPath=`.status.series`
+// +kubebuilder:printcolumn:name="Processed",type=string,JSONPath=`.status.conditions[?(@.type=="Processed")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterResourceMetricsMonitor is the cluster-scoped counterpart of a ResourceMetricsMonitor, for monitors defined
+// centrally. For resources targeted by both, ResourceMetricsMonitors take precedence in their own namespace.
+type ClusterResourceMetricsMonitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ResourceMetricsMonitorSpec   `json:"spec"`
+	Status            ResourceMetricsMonitorStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+
+// ClusterResourceMetricsMonitorList is a list of ClusterResourceMetricsMonitor resources.
+type ClusterResourceMetricsMonitorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ClusterResourceMetricsMonitor `json:"items"`
+}
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -82,6 +83,60 @@ func TestResourceMetricsMonitorStatus_Set(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Established condition with false status",
+			condition: metav1.Condition{
+				Type:   "Established",
+				Status: metav1.ConditionFalse,
+			},
+			want: ResourceMetricsMonitorStatus{
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Established",
+						Status:  metav1.ConditionFalse,
+						Reason:  "CRDsNotEstablished",
+						Message: "Stores are deferred until their targeted CRDs are established",
+					},
+				},
+			},
+		},
+		{
+			name: "Degraded condition with truthy status and a custom message",
+			condition: metav1.Condition{
+				Type:    "Degraded",
+				Status:  metav1.ConditionTrue,
+				Message: "Failed to reconcile ServiceMonitor: forbidden",
+			},
+			want: ResourceMetricsMonitorStatus{
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Degraded",
+						Status:  metav1.ConditionTrue,
+						Reason:  "Degraded",
+						Message: "Failed to reconcile ServiceMonitor: forbidden",
+					},
+				},
+			},
+		},
+		{
+			name: "Failed condition with truthy status and a custom reason and message",
+			condition: metav1.Condition{
+				Type:    "Failed",
+				Status:  metav1.ConditionTrue,
+				Reason:  "InvalidConfiguration",
+				Message: "Failed to parse configuration YAML",
+			},
+			want: ResourceMetricsMonitorStatus{
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Failed",
+						Status:  metav1.ConditionTrue,
+						Reason:  "InvalidConfiguration",
+						Message: "Failed to parse configuration YAML",
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -98,3 +153,76 @@ func TestResourceMetricsMonitorStatus_Set(t *testing.T) {
 		})
 	}
 }
+
+func TestResourceMetricsMonitorStatus_SetTransition(t *testing.T) {
+	t.Parallel()
+	resource := &ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+	status := ResourceMetricsMonitorStatus{}
+	status.Set(resource, metav1.Condition{Type: "Processed", Status: metav1.ConditionFalse})
+	transitionTime := metav1.NewTime(status.Conditions[0].LastTransitionTime.Add(-time.Hour))
+	status.Conditions[0].LastTransitionTime = transitionTime
+
+	// The transition time is retained as long as the status does not change, while the generation is always observed.
+	resource.Generation = 2
+	status.Set(resource, metav1.Condition{Type: "Processed", Status: metav1.ConditionFalse, Message: "Event handler received event: update"})
+	if got := status.Conditions[0]; !got.LastTransitionTime.Equal(&transitionTime) || got.ObservedGeneration != 2 || got.Message != "Event handler received event: update" {
+		t.Errorf("unexpected condition: %+v", got)
+	}
+
+	status.Set(resource, metav1.Condition{Type: "Processed", Status: metav1.ConditionTrue})
+	if got := status.Conditions[0]; got.LastTransitionTime.Equal(&transitionTime) {
+		t.Errorf("expected the transition time to be bumped: %+v", got)
+	}
+	if len(status.Conditions) != 1 {
+		t.Errorf("expected 1 condition, got %d", len(status.Conditions))
+	}
+}
+
+func TestResourceMetricsMonitorStatus_SetDebounced(t *testing.T) {
+	t.Parallel()
+	resource := &ResourceMetricsMonitor{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+	status := ResourceMetricsMonitorStatus{}
+	if !status.SetDebounced(resource, metav1.Condition{Type: "Processed", Status: metav1.ConditionTrue}, time.Minute) {
+		t.Fatal("expected the new condition to be set")
+	}
+
+	// Transitions within the hold time are held off, while updates that do not transition are not.
+	if status.SetDebounced(resource, metav1.Condition{Type: "Processed", Status: metav1.ConditionFalse}, time.Minute) {
+		t.Errorf("expected the transition to be held off: %+v", status.Conditions[0])
+	}
+	if !status.SetDebounced(resource, metav1.Condition{Type: "Processed", Status: metav1.ConditionTrue, Message: "updated"}, time.Minute) {
+		t.Errorf("expected the update to be set: %+v", status.Conditions[0])
+	}
+	if got := status.Conditions[0]; got.Status != metav1.ConditionTrue || got.Message != "updated" || status.TransitionCount != 0 {
+		t.Errorf("unexpected condition: %+v, with %d transitions", got, status.TransitionCount)
+	}
+
+	// Transitions past the hold time are set, and counted.
+	status.Conditions[0].LastTransitionTime = metav1.NewTime(time.Now().Add(-time.Hour))
+	if !status.SetDebounced(resource, metav1.Condition{Type: "Processed", Status: metav1.ConditionFalse}, time.Minute) {
+		t.Errorf("expected the transition to be set: %+v", status.Conditions[0])
+	}
+	if got := status.Conditions[0]; got.Status != metav1.ConditionFalse || status.TransitionCount != 1 {
+		t.Errorf("unexpected condition: %+v, with %d transitions", got, status.TransitionCount)
+	}
+
+	// Transitions are held off as of the condition's transition time, if set.
+	lastTransition := status.Conditions[0].LastTransitionTime.Time
+	if status.SetDebounced(resource, metav1.Condition{Type: "Processed", Status: metav1.ConditionTrue, LastTransitionTime: metav1.NewTime(lastTransition.Add(30 * time.Second))}, time.Minute) {
+		t.Errorf("expected the transition to be held off: %+v", status.Conditions[0])
+	}
+	transitioned := metav1.NewTime(lastTransition.Add(2 * time.Minute))
+	if !status.SetDebounced(resource, metav1.Condition{Type: "Processed", Status: metav1.ConditionTrue, LastTransitionTime: transitioned}, time.Minute) {
+		t.Errorf("expected the transition to be set: %+v", status.Conditions[0])
+	}
+	if got := status.Conditions[0]; !got.LastTransitionTime.Equal(&transitioned) || status.TransitionCount != 2 {
+		t.Errorf("unexpected condition: %+v, with %d transitions", got, status.TransitionCount)
+	}
+
+	// Only the Processed condition's transitions are counted.
+	status.Set(resource, metav1.Condition{Type: "Degraded", Status: metav1.ConditionTrue})
+	status.Set(resource, metav1.Condition{Type: "Degraded", Status: metav1.ConditionFalse})
+	if status.TransitionCount != 2 {
+		t.Errorf("expected 2 transitions, got %d", status.TransitionCount)
+	}
+}
@@ -26,12 +26,90 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterResourceMetricsMonitor) DeepCopyInto(out *ClusterResourceMetricsMonitor) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterResourceMetricsMonitor.
+func (in *ClusterResourceMetricsMonitor) DeepCopy() *ClusterResourceMetricsMonitor {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterResourceMetricsMonitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterResourceMetricsMonitor) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterResourceMetricsMonitorList) DeepCopyInto(out *ClusterResourceMetricsMonitorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterResourceMetricsMonitor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterResourceMetricsMonitorList.
+func (in *ClusterResourceMetricsMonitorList) DeepCopy() *ClusterResourceMetricsMonitorList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterResourceMetricsMonitorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterResourceMetricsMonitorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResolutionError) DeepCopyInto(out *ResolutionError) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResolutionError.
+func (in *ResolutionError) DeepCopy() *ResolutionError {
+	if in == nil {
+		return nil
+	}
+	out := new(ResolutionError)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceMetricsMonitor) DeepCopyInto(out *ResourceMetricsMonitor) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 	return
 }
@@ -90,6 +168,13 @@ func (in *ResourceMetricsMonitorList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceMetricsMonitorSpec) DeepCopyInto(out *ResourceMetricsMonitorSpec) {
 	*out = *in
+	if in.Tests != nil {
+		in, out := &in.Tests, &out.Tests
+		*out = make([]ResourceMetricsMonitorTest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -113,6 +198,13 @@ func (in *ResourceMetricsMonitorStatus) DeepCopyInto(out *ResourceMetricsMonitor
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.LastErrors != nil {
+		in, out := &in.LastErrors, &out.LastErrors
+		*out = make([]ResolutionError, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -125,3 +217,24 @@ func (in *ResourceMetricsMonitorStatus) DeepCopy() *ResourceMetricsMonitorStatus
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceMetricsMonitorTest) DeepCopyInto(out *ResourceMetricsMonitorTest) {
+	*out = *in
+	if in.Expected != nil {
+		in, out := &in.Expected, &out.Expected
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceMetricsMonitorTest.
+func (in *ResourceMetricsMonitorTest) DeepCopy() *ResourceMetricsMonitorTest {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceMetricsMonitorTest)
+	in.DeepCopyInto(out)
+	return out
+}
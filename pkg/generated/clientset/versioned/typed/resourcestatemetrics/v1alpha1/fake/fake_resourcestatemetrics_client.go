@@ -28,6 +28,10 @@ type FakeResourceStateMetricsV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeResourceStateMetricsV1alpha1) ClusterResourceMetricsMonitors() v1alpha1.ClusterResourceMetricsMonitorInterface {
+	return newFakeClusterResourceMetricsMonitors(c)
+}
+
 func (c *FakeResourceStateMetricsV1alpha1) ResourceMetricsMonitors(namespace string) v1alpha1.ResourceMetricsMonitorInterface {
 	return newFakeResourceMetricsMonitors(c, namespace)
 }
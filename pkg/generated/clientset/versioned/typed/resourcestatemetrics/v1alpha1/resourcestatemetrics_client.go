@@ -28,6 +28,7 @@ import (
 
 type ResourceStateMetricsV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	ClusterResourceMetricsMonitorsGetter
 	ResourceMetricsMonitorsGetter
 }
 
@@ -36,6 +37,10 @@ type ResourceStateMetricsV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *ResourceStateMetricsV1alpha1Client) ClusterResourceMetricsMonitors() ClusterResourceMetricsMonitorInterface {
+	return newClusterResourceMetricsMonitors(c)
+}
+
 func (c *ResourceStateMetricsV1alpha1Client) ResourceMetricsMonitors(namespace string) ResourceMetricsMonitorInterface {
 	return newResourceMetricsMonitors(c, namespace)
 }
@@ -53,6 +53,8 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=resource-state-metrics.instrumentation.k8s-sigs.io, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithResource("clusterresourcemetricsmonitors"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.ResourceStateMetrics().V1alpha1().ClusterResourceMetricsMonitors().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("resourcemetricsmonitors"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.ResourceStateMetrics().V1alpha1().ResourceMetricsMonitors().Informer()}, nil
 
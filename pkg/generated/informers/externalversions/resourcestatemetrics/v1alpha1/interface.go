@@ -24,6 +24,8 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ClusterResourceMetricsMonitors returns a ClusterResourceMetricsMonitorInformer.
+	ClusterResourceMetricsMonitors() ClusterResourceMetricsMonitorInformer
 	// ResourceMetricsMonitors returns a ResourceMetricsMonitorInformer.
 	ResourceMetricsMonitors() ResourceMetricsMonitorInformer
 }
@@ -39,6 +41,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ClusterResourceMetricsMonitors returns a ClusterResourceMetricsMonitorInformer.
+func (v *version) ClusterResourceMetricsMonitors() ClusterResourceMetricsMonitorInformer {
+	return &clusterResourceMetricsMonitorInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // ResourceMetricsMonitors returns a ResourceMetricsMonitorInformer.
 func (v *version) ResourceMetricsMonitors() ResourceMetricsMonitorInformer {
 	return &resourceMetricsMonitorInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -18,6 +18,10 @@ limitations under the License.
 
 package v1alpha1
 
+// ClusterResourceMetricsMonitorListerExpansion allows custom methods to be added to
+// ClusterResourceMetricsMonitorLister.
+type ClusterResourceMetricsMonitorListerExpansion interface{}
+
 // ResourceMetricsMonitorListerExpansion allows custom methods to be added to
 // ResourceMetricsMonitorLister.
 type ResourceMetricsMonitorListerExpansion interface{}
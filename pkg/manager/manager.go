@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package manager runs the resource-state-metrics controller as a library, so that other binaries, e.g., operators, may
+embed it, sharing their process, rather than deploying it separately.
+*/
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rexagod/resource-state-metrics/internal"
+	clientset "github.com/rexagod/resource-state-metrics/pkg/generated/clientset/versioned"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+// Options configures a Manager.
+type Options struct {
+	// Config is the configuration to connect to the cluster with.
+	Config *rest.Config
+	// Args are the controller's command-line flags, e.g., "--main-port=9999", defaulting the ones not given as the
+	// controller's binary does. Flags tuning the process as a whole, i.e., --auto-gomaxprocs and --ratio-gomemlimit, are
+	// ignored, as the process is the embedding binary's, as are --kubeconfig and --master, in favor of Config.
+	Args []string
+	// Logger is the logger the controller logs with, defaulting to klog's.
+	Logger klog.Logger
+}
+
+// Manager runs the resource-state-metrics controller.
+type Manager struct {
+	logger     klog.Logger
+	controller *internal.Controller
+	workers    int
+}
+
+// New returns a Manager for the given options.
+func New(options Options) (*Manager, error) {
+	if options.Config == nil {
+		return nil, errors.New("a configuration to connect to the cluster with is required")
+	}
+	logger := options.Logger
+	if logger.GetSink() == nil {
+		logger = klog.Background()
+	}
+	controllerOptions := internal.NewOptions(logger)
+	if err := controllerOptions.Parse(options.Args); err != nil {
+		return nil, fmt.Errorf("error parsing options: %w", err)
+	}
+
+	// Stream initial lists, if requested, before any client is used.
+	if *controllerOptions.WatchList {
+		internal.EnableWatchList()
+	}
+
+	// Build client-sets, observing the size of their list responses, off a copy of the configuration, so the
+	// embedding binary's is left as is.
+	cfg := rest.CopyConfig(options.Config)
+	listTransfers := internal.NewListTransfers()
+	cfg.Wrap(listTransfers.WrapTransport)
+	kubeClientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error building kubernetes clientset: %w", err)
+	}
+	rsmClientset, err := clientset.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error building resource-state-metrics clientset: %w", err)
+	}
+	dynamicClientset, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error building dynamic clientset: %w", err)
+	}
+	clusterClientsets, err := internal.NewClusterClientsets(controllerOptions.Clusters, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error building federated cluster clientsets: %w", err)
+	}
+
+	ctx := klog.NewContext(context.Background(), logger)
+
+	return &Manager{
+		logger:     logger,
+		controller: internal.NewController(ctx, controllerOptions, cfg, kubeClientset, rsmClientset, dynamicClientset, clusterClientsets, listTransfers),
+		workers:    *controllerOptions.Workers,
+	}, nil
+}
+
+// Run runs the controller until the given context is cancelled.
+func (m *Manager) Run(ctx context.Context) error {
+	return m.controller.Run(klog.NewContext(ctx, m.logger), m.workers)
+}
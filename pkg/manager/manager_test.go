@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		options Options
+		wantErr bool
+	}{
+		{
+			name:    "no configuration",
+			options: Options{},
+			wantErr: true,
+		},
+		{
+			name:    "unknown flag",
+			options: Options{Config: &rest.Config{Host: "http://127.0.0.1:0"}, Args: []string{"--foo"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid flag",
+			options: Options{Config: &rest.Config{Host: "http://127.0.0.1:0"}, Args: []string{"--storage=foo"}},
+			wantErr: true,
+		},
+		{
+			name:    "defaults",
+			options: Options{Config: &rest.Config{Host: "http://127.0.0.1:0"}, Args: []string{"--main-port=9999"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			manager, err := New(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error: %t, got %v", tt.wantErr, err)
+			}
+			if err == nil && manager.workers != 2 {
+				t.Errorf("expected the default number of workers, got %d", manager.workers)
+			}
+		})
+	}
+}
@@ -26,11 +26,19 @@ import (
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
 	"github.com/google/cel-go/interpreter"
 	"github.com/prometheus/client_golang/prometheus"
 	"k8s.io/klog/v2"
 )
 
+const (
+	// DefaultCostLimit is the cost limit used when none is specified.
+	DefaultCostLimit uint64 = 10e5
+	// DefaultTimeout is the evaluation timeout used when none is specified.
+	DefaultTimeout = 5 * time.Second
+)
+
 // CELResolver represents a resolver for CEL expressions.
 type CELResolver struct {
 	logger                     klog.Logger
@@ -40,13 +48,22 @@ type CELResolver struct {
 	managedRMMNamespace        string
 	managedRMMName             string
 	familyName                 string
+	// variables are made available to expressions besides the object (o), and the evaluation's timestamp (now).
+	variables map[string]interface{}
 }
 
 // CELResolver implements the Resolver interface.
 var _ Resolver = &CELResolver{}
 
-// NewCELResolver returns a new limits-aware CEL resolver.
+// NewCELResolver returns a new limits-aware CEL resolver. Zero limits fall back to their defaults.
 func NewCELResolver(logger klog.Logger, costLimit uint64, timeout time.Duration, celEvaluations *prometheus.CounterVec, rmmNamespace, rmmName, familyName string) *CELResolver {
+	if costLimit == 0 {
+		costLimit = DefaultCostLimit
+	}
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
 	return &CELResolver{
 		logger:                     logger,
 		costLimit:                  costLimit,
@@ -58,6 +75,14 @@ func NewCELResolver(logger klog.Logger, costLimit uint64, timeout time.Duration,
 	}
 }
 
+// WithVariables makes the given variables available to expressions, besides the object (o), and the evaluation's
+// timestamp (now), which they do not override.
+func (cr *CELResolver) WithVariables(variables map[string]interface{}) *CELResolver {
+	cr.variables = variables
+
+	return cr
+}
+
 // costEstimator helps estimate the runtime cost of CEL queries.
 type costEstimator struct{}
 
@@ -90,7 +115,9 @@ func (cr *CELResolver) Resolve(query string, unstructuredObjectMap map[string]in
 	select {
 	case res := <-resultChan:
 		if res.err != nil {
-			logger.V(1).Info("ignoring resolution for query", "info", res.err)
+			if logger, ok := LimitLog(logger, query, res.err); ok {
+				logger.V(1).Info("ignoring resolution for query", "info", res.err)
+			}
 			if cr.expressionEvaluationMetric != nil {
 				cr.expressionEvaluationMetric.WithLabelValues(cr.managedRMMNamespace, cr.managedRMMName, cr.familyName, "error").Inc()
 			}
@@ -103,7 +130,10 @@ func (cr *CELResolver) Resolve(query string, unstructuredObjectMap map[string]in
 
 		return res.output
 	case <-time.After(cr.timeout):
-		logger.Error(fmt.Errorf("CEL query exceeded timeout of %v", cr.timeout), "ignoring resolution for query")
+		err := fmt.Errorf("CEL query exceeded timeout of %v", cr.timeout)
+		if logger, ok := LimitLog(logger, query, err); ok {
+			logger.Error(err, "ignoring resolution for query")
+		}
 		if cr.expressionEvaluationMetric != nil {
 			cr.expressionEvaluationMetric.WithLabelValues(cr.managedRMMNamespace, cr.managedRMMName, cr.familyName, "timeout").Inc()
 		}
@@ -112,30 +142,39 @@ func (cr *CELResolver) Resolve(query string, unstructuredObjectMap map[string]in
 	}
 }
 
-func (cr *CELResolver) resolveWithTimeout(query string, unstructuredObjectMap map[string]interface{}, logger klog.Logger) (map[string]string, error) {
+// Compile parses the given query and builds its program, without evaluating it.
+func (cr *CELResolver) Compile(query string) error {
+	_, err := cr.compile(query)
+
+	return err
+}
+
+func (cr *CELResolver) compile(query string) (cel.Program, error) {
 	env, err := cr.createEnvironment()
 	if err != nil {
-		logger.Error(err, "ignoring resolution for query")
-
 		return nil, err
 	}
 
 	ast, iss := env.Parse(query)
 	if iss.Err() != nil {
-		logger.Error(fmt.Errorf("error parsing CEL query: %w", iss.Err()), "ignoring resolution for query")
-
-		return nil, iss.Err()
+		return nil, fmt.Errorf("error parsing CEL query: %w", iss.Err())
 	}
 
-	program, err := cr.compileProgram(env, ast)
+	return cr.compileProgram(env, ast)
+}
+
+func (cr *CELResolver) resolveWithTimeout(query string, unstructuredObjectMap map[string]interface{}, logger klog.Logger) (map[string]string, error) {
+	program, err := cr.compile(query)
 	if err != nil {
-		logger.Error(err, "ignoring resolution for query")
+		if logger, ok := LimitLog(logger, query, err); ok {
+			logger.Error(err, "ignoring resolution for query")
+		}
 
 		return nil, err
 	}
 
 	out, evalDetails, err := cr.evaluateProgram(program, unstructuredObjectMap)
-	cr.logger = cr.addCostLogging(logger, evalDetails)
+	cr.addCostLogging(logger, evalDetails)
 	if err != nil {
 		return nil, err
 	}
@@ -143,11 +182,19 @@ func (cr *CELResolver) resolveWithTimeout(query string, unstructuredObjectMap ma
 	return cr.processResult(query, out), nil
 }
 
+// createEnvironment returns the environment expressions are compiled in, along with the extension libraries users know
+// from Kubernetes' own CEL dialect, e.g., "foo".upperAscii(), base64.encode(b"foo"), math.greatest(1, 2), or o.?spec.foo.
 func (cr *CELResolver) createEnvironment() (*cel.Env, error) {
 	return cel.NewEnv(
 		cel.CrossTypeNumericComparisons(true),
 		cel.DefaultUTCTimeZone(true),
 		cel.EagerlyValidateDeclarations(true),
+		cel.OptionalTypes(),
+		ext.Strings(),
+		ext.Encoders(),
+		ext.Math(),
+		ext.Lists(),
+		ext.Sets(),
 	)
 }
 
@@ -160,17 +207,24 @@ func (cr *CELResolver) compileProgram(env *cel.Env, ast *cel.Ast) (cel.Program,
 }
 
 func (cr *CELResolver) evaluateProgram(program cel.Program, obj map[string]interface{}) (ref.Val, *cel.EvalDetails, error) {
-	return program.Eval(map[string]interface{}{"o": obj})
+	activation := make(map[string]interface{}, len(cr.variables)+2)
+	for name, variable := range cr.variables {
+		activation[name] = variable
+	}
+	activation["o"] = obj
+	activation["now"] = time.Now().UTC()
+
+	return program.Eval(activation)
 }
 
-func (cr *CELResolver) addCostLogging(logger klog.Logger, evalDetails *cel.EvalDetails) klog.Logger {
+// addCostLogging logs the runtime cost of a query, leaving the resolver's logger as is, since resolvers are shared by
+// the objects rendered concurrently.
+func (cr *CELResolver) addCostLogging(logger klog.Logger, evalDetails *cel.EvalDetails) {
 	logger = logger.WithValues("costLimit", cr.costLimit, "timeout", cr.timeout)
 	if evalDetails != nil {
 		logger = logger.WithValues("queryCost", *evalDetails.ActualCost())
 	}
 	logger.V(4).Info("CEL query runtime cost")
-
-	return logger
 }
 
 func (cr *CELResolver) processResult(query string, out ref.Val) map[string]string {
@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -139,3 +140,122 @@ func TestNewCELResolver_Resolve(t *testing.T) {
 		})
 	}
 }
+
+func TestCELResolver_Resolve_variables(t *testing.T) {
+	t.Parallel()
+	unstructuredObjectMap := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"creationTimestamp": time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+		},
+	}
+	cr := NewCELResolver(klog.NewKlogr(), 10e5, 5*time.Second, nil, "test-ns", "test-rmm", "test-family").WithVariables(map[string]interface{}{
+		"rmm": map[string]interface{}{"name": "test-rmm"},
+		"env": map[string]interface{}{"SHARD": "1"},
+		"o":   "ignored",
+	})
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "rmm.name + '/' + env.SHARD", want: "test-rmm/1"},
+		{query: "(now - timestamp(o.metadata.creationTimestamp)).getSeconds() >= 3600", want: "true"},
+	}
+	for _, tt := range tests {
+		if got := cr.Resolve(tt.query, unstructuredObjectMap)[tt.query]; got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestCELResolver_Resolve_extensions(t *testing.T) {
+	t.Parallel()
+	unstructuredObjectMap := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"name":  "Foo",
+			"sizes": []interface{}{int64(3), int64(1), int64(2)},
+		},
+	}
+	cr := NewCELResolver(klog.NewKlogr(), 10e5, 5*time.Second, nil, "test-ns", "test-rmm", "test-family")
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "o.spec.name.lowerAscii()", want: "foo"},
+		{query: "base64.encode(bytes(o.spec.name))", want: "Rm9v"},
+		{query: "math.greatest(o.spec.sizes)", want: "3"},
+		{query: "sets.contains(o.spec.sizes, [1, 2])", want: "true"},
+		{query: "o.?spec.missing.orValue('none')", want: "none"},
+	}
+	for _, tt := range tests {
+		if got := cr.Resolve(tt.query, unstructuredObjectMap)[tt.query]; got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.query, tt.want, got)
+		}
+	}
+}
+
+// BenchmarkCELResolver_Resolve measures how effectively compiled expressions are reused, by contrasting a hot query
+// against one that never repeats.
+func BenchmarkCELResolver_Resolve(b *testing.B) {
+	unstructuredObjectMap := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+		},
+	}
+	tests := []struct {
+		name  string
+		query func(i int) string
+	}{
+		{
+			name:  "repeated query",
+			query: func(int) string { return "o.spec.replicas" },
+		},
+		{
+			name:  "distinct queries",
+			query: func(i int) string { return fmt.Sprintf("o.spec.replicas + %d", i) },
+		},
+	}
+
+	cr := NewCELResolver(klog.NewKlogr(), 10e5, 5*time.Second, nil, "test-ns", "test-rmm", "test-family")
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := range b.N {
+				cr.Resolve(tt.query(i), unstructuredObjectMap)
+			}
+		})
+	}
+}
+
+func FuzzCELResolver_Resolve(f *testing.F) {
+	for _, seed := range []string{
+		"o.fields.string",
+		"o.fields.map",
+		"o.fields.slice",
+		"o.fields.nil.foo",
+		"size(o.fields.slice) > 1 ? o.fields.map : o.fields.slice",
+		"o.fields.",
+		"[[[[[[[[1]]]]]]]]",
+	} {
+		f.Add(seed)
+	}
+	unstructuredObjectMap := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"nil":     nil,
+			"integer": int64(1),
+			"string":  "bar",
+			"slice":   []interface{}{"a", "b", "c"},
+			"map": map[string]interface{}{
+				"foo": map[string]interface{}{
+					"bar": "baz",
+				},
+			},
+			"float":   1.1,
+			"boolean": true,
+		},
+	}
+
+	cr := NewCELResolver(klog.NewKlogr(), 10e5, 5*time.Second, nil, "test-ns", "test-rmm", "test-family")
+	f.Fuzz(func(_ *testing.T, query string) {
+		cr.Resolve(query, unstructuredObjectMap)
+	})
+}
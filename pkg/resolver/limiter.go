@@ -0,0 +1,119 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultLogInterval is the interval identical resolution failures are logged at most once per, by default.
+	DefaultLogInterval = time.Minute
+	// maxLimitedFailures bounds the number of distinct failures tracked, beyond which ones not logged within the
+	// interval are forgotten.
+	maxLimitedFailures = 4096
+)
+
+// failure identifies a resolution failure by its expression and error.
+type failure struct {
+	expression string
+	err        string
+}
+
+// failureLog represents when a failure was last logged, and how many times it was suppressed since.
+type failureLog struct {
+	logged     time.Time
+	suppressed uint64
+}
+
+// logLimiter deduplicates the logs of repeated resolution failures, e.g., of a broken expression resolved against
+// every object on every resync, logging each at most once per interval, along with the number of times it was
+// suppressed since it last was.
+type logLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	failures map[failure]*failureLog
+	now      func() time.Time
+}
+
+// failures deduplicates the logs of all resolvers' failures, as resolvers are built for each family, and resolution.
+var failures = newLogLimiter(DefaultLogInterval)
+
+// newLogLimiter returns a logLimiter logging identical failures at most once per the given interval.
+func newLogLimiter(interval time.Duration) *logLimiter {
+	return &logLimiter{interval: interval, failures: map[failure]*failureLog{}, now: time.Now}
+}
+
+// SetLogInterval sets the interval identical resolution failures, by expression and error, are logged at most once per.
+// A zero interval logs every failure.
+func SetLogInterval(interval time.Duration) {
+	failures.mutex.Lock()
+	defer failures.mutex.Unlock()
+	failures.interval = interval
+}
+
+// LimitLog reports whether the given failure resolving the given expression should be logged, and if so, returns the
+// given logger along with the number of times it was suppressed since it was last logged, if any.
+func LimitLog(logger klog.Logger, expression string, err error) (klog.Logger, bool) {
+	return failures.limit(logger, expression, err)
+}
+
+func (l *logLimiter) limit(logger klog.Logger, expression string, err error) (klog.Logger, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.interval <= 0 || err == nil {
+		return logger, true
+	}
+	key := failure{expression: expression, err: err.Error()}
+	now := l.now()
+	entry, ok := l.failures[key]
+	if ok && now.Sub(entry.logged) < l.interval {
+		entry.suppressed++
+
+		return logger, false
+	}
+	if !ok {
+		l.evict(now)
+		entry = &failureLog{}
+		l.failures[key] = entry
+	}
+	if entry.suppressed > 0 {
+		logger = logger.WithValues("suppressed", entry.suppressed)
+	}
+	entry.logged, entry.suppressed = now, 0
+
+	return logger, true
+}
+
+// evict forgets the failures not logged within the interval once too many are tracked, or all of them if none were,
+// so expressions failing with object-specific errors do not grow the limiter unbounded.
+func (l *logLimiter) evict(now time.Time) {
+	if len(l.failures) < maxLimitedFailures {
+		return
+	}
+	for key, entry := range l.failures {
+		if now.Sub(entry.logged) >= l.interval {
+			delete(l.failures, key)
+		}
+	}
+	if len(l.failures) >= maxLimitedFailures {
+		clear(l.failures)
+	}
+}
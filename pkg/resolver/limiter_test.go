@@ -0,0 +1,49 @@
+package resolver
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+func TestLogLimiter_limit(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(0, 0)
+	limiter := newLogLimiter(time.Minute)
+	limiter.now = func() time.Time { return now }
+	logger := klog.Background()
+	err := errors.New("no such key: foo")
+
+	steps := []struct {
+		name       string
+		expression string
+		err        error
+		after      time.Duration
+		logged     bool
+	}{
+		{name: "first failure", expression: "o.spec.foo", err: err, logged: true},
+		{name: "repeated failure", expression: "o.spec.foo", err: err, after: time.Second, logged: false},
+		{name: "repeated failure, again", expression: "o.spec.foo", err: err, after: time.Second, logged: false},
+		{name: "other error", expression: "o.spec.foo", err: errors.New("no such key: bar"), logged: true},
+		{name: "other expression", expression: "o.spec.bar", err: err, logged: true},
+		{name: "repeated failure, past the interval", expression: "o.spec.foo", err: err, after: time.Minute, logged: true},
+	}
+	for _, step := range steps {
+		now = now.Add(step.after)
+		if _, logged := limiter.limit(logger, step.expression, step.err); logged != step.logged {
+			t.Errorf("%s: expected logged: %t, got %t", step.name, step.logged, logged)
+		}
+	}
+	if suppressed := limiter.failures[failure{expression: "o.spec.foo", err: err.Error()}].suppressed; suppressed != 0 {
+		t.Errorf("expected suppressed failures to be reset once logged, got %d", suppressed)
+	}
+
+	limiter.interval = 0
+	for range 2 {
+		if _, logged := limiter.limit(logger, "o.spec.foo", err); !logged {
+			t.Error("expected every failure to be logged with a zero interval")
+		}
+	}
+}
@@ -18,12 +18,17 @@ package resolver
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/klog/v2"
 )
 
+// wildcard expands the array at the path preceding it, e.g., `spec.containers[*].name`.
+const wildcard = "[*]"
+
 // UnstructuredResolver represents a resolver for unstructured objects.
 type UnstructuredResolver struct {
 	logger klog.Logger
@@ -40,17 +45,139 @@ func NewUnstructuredResolver(logger klog.Logger) *UnstructuredResolver {
 // Resolve resolves the given query against the given unstructured object.
 // NOTE: Resolutions resulting in composite values for label keys and values are not supported, owing to upstream
 // limitations: https://github.com/kubernetes/apimachinery/blob/v0.31.0/pkg/apis/meta/v1/unstructured/helpers_test.go#L121.
+// Wildcard paths, e.g., `spec.containers[*].name`, are the exception, and resolve to a list of the elements' values.
 func (ur *UnstructuredResolver) Resolve(query string, unstructuredObjectMap map[string]interface{}) map[string]string {
 	logger := ur.logger.WithValues("query", query)
+	if strings.Contains(query, wildcard) {
+		return ur.resolveWildcard(query, unstructuredObjectMap)
+	}
 	gotResolved, found, err := unstructured.NestedFieldNoCopy(unstructuredObjectMap, strings.Split(query, ".")...)
 	if !found {
 		return map[string]string{query: query}
 	}
 	if err != nil {
-		logger.V(1).Info("ignoring resolution for query", "info", err)
+		if logger, ok := LimitLog(logger, query, err); ok {
+			logger.V(1).Info("ignoring resolution for query", "info", err)
+		}
 
 		return map[string]string{query: query}
 	}
 
 	return map[string]string{query: fmt.Sprintf("%v", gotResolved)}
 }
+
+// resolveWildcard resolves the given wildcard path against the given unstructured object, as a list named after the
+// path's last field, e.g., `name#0`, `name#1`, and so on for `spec.containers[*].name`, along with, for each wildcard,
+// the index of the element each value was resolved off, as a list named after the expanded array, e.g.,
+// `containers_index#0`, and so on, so elements resolving to the same value are told apart. Elements resolving to
+// composite values, or not resolving at all, are skipped.
+func (ur *UnstructuredResolver) resolveWildcard(query string, unstructuredObjectMap map[string]interface{}) map[string]string {
+	elements, ok := expandWildcard(query, unstructuredObjectMap, nil)
+	if !ok {
+		ur.logger.V(1).Info("ignoring resolution for query", "query", query, "info", "wildcard does not expand an array")
+
+		return map[string]string{query: query}
+	}
+	trimmedQuery := strings.TrimSuffix(query, wildcard)
+	listName := trimmedQuery[strings.LastIndex(trimmedQuery, ".")+1:]
+	indexNames := wildcardIndexNames(query)
+	resolved := make(map[string]string, len(elements)*(1+len(indexNames)))
+	for i, element := range elements {
+		resolved[listName+"#"+strconv.Itoa(i)] = element.value
+		for level, index := range element.indices {
+			resolved[indexNames[level]+"#"+strconv.Itoa(i)] = strconv.Itoa(index)
+		}
+	}
+
+	return resolved
+}
+
+// wildcardIndexNames returns the names of the lists holding the indices of the elements each wildcard of the given path
+// expands, named after the arrays expanded, e.g., `containers_index`, and `ports_index` for
+// `spec.containers[*].ports[*].containerPort`, or after their level, for arrays directly nested in others.
+func wildcardIndexNames(query string) []string {
+	paths := strings.Split(query, wildcard)
+	names := make([]string, 0, len(paths)-1)
+	for level, path := range paths[:len(paths)-1] {
+		field := path[strings.LastIndex(path, ".")+1:]
+		if field == "" {
+			field = "level_" + strconv.Itoa(level)
+		}
+		names = append(names, field+"_index")
+	}
+
+	return names
+}
+
+// wildcardElement is a scalar value a wildcard path resolves to, along with the index of the element it was resolved
+// off, for each wildcard.
+type wildcardElement struct {
+	value   string
+	indices []int
+}
+
+// expandWildcard returns the scalar values the given (possibly nested) wildcard path resolves to, in order, each along
+// with the given indices of the elements expanded so far, followed by its own, and whether the path preceding each
+// wildcard resolves to an array.
+func expandWildcard(query string, object interface{}, indices []int) ([]wildcardElement, bool) {
+	path, rest, expands := strings.Cut(query, wildcard)
+	if !expands {
+		if query == "" {
+			return scalarElement(object, indices), true
+		}
+		objectMap, ok := object.(map[string]interface{})
+		if !ok {
+			return nil, true
+		}
+		field, found, err := unstructured.NestedFieldNoCopy(objectMap, strings.Split(strings.TrimPrefix(query, "."), ".")...)
+		if !found || err != nil {
+			return nil, true
+		}
+
+		return scalarElement(field, indices), true
+	}
+	var elements interface{} = object
+	if path != "" {
+		objectMap, ok := object.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		field, found, err := unstructured.NestedFieldNoCopy(objectMap, strings.Split(strings.TrimPrefix(path, "."), ".")...)
+		if !found || err != nil {
+			return nil, false
+		}
+		elements = field
+	}
+	list, ok := elements.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	var values []wildcardElement
+	for i, element := range list {
+		// Nested wildcards not expanding arrays for some elements skip them, rather than the whole list.
+		elementValues, _ := expandWildcard(rest, element, append(slices.Clone(indices), i))
+		values = append(values, elementValues...)
+	}
+
+	return values, true
+}
+
+// scalarElement returns the given value, along with the given indices, as a single-element list, if it is not composite.
+func scalarElement(value interface{}, indices []int) []wildcardElement {
+	values := scalar(value)
+	if len(values) == 0 {
+		return nil
+	}
+
+	return []wildcardElement{{value: values[0], indices: indices}}
+}
+
+// scalar returns the given value, as a single-element list, if it is not composite.
+func scalar(value interface{}) []string {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return nil
+	default:
+		return []string{fmt.Sprintf("%v", value)}
+	}
+}
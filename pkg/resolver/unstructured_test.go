@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -28,6 +29,20 @@ func TestUnstructuredResolver_Resolve(t *testing.T) {
 			"float":   1.1,
 			"rune":    'a',
 			"boolean": true,
+			"list":    []interface{}{"a", "b"},
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name": "foo",
+					"ports": []interface{}{
+						map[string]interface{}{"containerPort": int64(80)},
+						map[string]interface{}{"containerPort": int64(443)},
+					},
+				},
+				map[string]interface{}{
+					"name":  "bar",
+					"ports": []interface{}{map[string]interface{}{"containerPort": int64(8080)}},
+				},
+			},
 		},
 	}
 	tests := []struct {
@@ -128,6 +143,53 @@ func TestUnstructuredResolver_Resolve(t *testing.T) {
 				"fields.slice[1]": "fields.slice[1]",
 			},
 		},
+		{
+			name:  "wildcard expands the elements of a list",
+			query: "fields.list[*]",
+			want: map[string]string{
+				"list#0":       "a",
+				"list#1":       "b",
+				"list_index#0": "0",
+				"list_index#1": "1",
+			},
+		},
+		{
+			name:  "wildcard expands the fields of a list's elements",
+			query: "fields.containers[*].name",
+			want: map[string]string{
+				"name#0":             "foo",
+				"name#1":             "bar",
+				"containers_index#0": "0",
+				"containers_index#1": "1",
+			},
+		},
+		{
+			name:  "nested wildcards expand the fields of nested lists' elements",
+			query: "fields.containers[*].ports[*].containerPort",
+			want: map[string]string{
+				"containerPort#0":    "80",
+				"containerPort#1":    "443",
+				"containerPort#2":    "8080",
+				"containers_index#0": "0",
+				"containers_index#1": "0",
+				"containers_index#2": "1",
+				"ports_index#0":      "0",
+				"ports_index#1":      "1",
+				"ports_index#2":      "0",
+			},
+		},
+		{
+			name:  "wildcard skips elements not resolving to scalars",
+			query: "fields.containers[*].ports",
+			want:  map[string]string{},
+		},
+		{
+			name:  "wildcard does not expand a non-list",
+			query: "fields.map[*].foo",
+			want: map[string]string{
+				"fields.map[*].foo": "fields.map[*].foo",
+			},
+		},
 	}
 
 	ur := NewUnstructuredResolver(klog.NewKlogr())
@@ -140,3 +202,54 @@ func TestUnstructuredResolver_Resolve(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkUnstructuredResolver_Resolve(b *testing.B) {
+	unstructuredObjectMap := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+		},
+	}
+
+	ur := NewUnstructuredResolver(klog.NewKlogr())
+	b.ReportAllocs()
+	for range b.N {
+		ur.Resolve("spec.replicas", unstructuredObjectMap)
+	}
+}
+
+func FuzzUnstructuredResolver_Resolve(f *testing.F) {
+	for _, seed := range []string{
+		"fields.string",
+		"fields.map.foo",
+		"fields.slice",
+		"fields.nil.foo",
+		"fields..string",
+		".",
+	} {
+		f.Add(seed)
+	}
+	unstructuredObjectMap := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"nil":    nil,
+			"string": "bar",
+			"slice":  []interface{}{"a", "b", "c"},
+			"map": map[string]interface{}{
+				"foo": map[string]interface{}{
+					"bar": "baz",
+				},
+			},
+		},
+	}
+
+	ur := NewUnstructuredResolver(klog.NewKlogr())
+	f.Fuzz(func(t *testing.T, query string) {
+		got := ur.Resolve(query, unstructuredObjectMap)
+		// Wildcard paths resolve to lists, of any length.
+		if strings.Contains(query, "[*]") {
+			return
+		}
+		if len(got) != 1 {
+			t.Errorf("expected a single resolution for %q, got %v", query, got)
+		}
+	})
+}
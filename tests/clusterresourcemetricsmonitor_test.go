@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/tests/framework"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const clusterScopedConfiguration = `stores:
+  - group: "samplecontroller.k8s.io"
+    version: "v1alpha1"
+    kind: "Foo"
+    resource: "foos"
+    families:
+      - name: "%s"
+        help: "Cluster-scoped test family"
+        metrics:
+          - labelKeys:
+              - "name"
+              - "namespace"
+            labelValues:
+              - "metadata.name"
+              - "metadata.namespace"
+            value: "spec.replicas"
+`
+
+// TestClusterResourceMetricsMonitor verifies that CRMMs are served alongside RMMs, and that RMMs targeting the same GVR
+// take precedence over them in their own namespace.
+func TestClusterResourceMetricsMonitor(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	f := setupFramework(t)
+
+	other := framework.NewCRBuilder("samplecontroller.k8s.io", "v1alpha1", "Foo", "test-sample-other", "other").
+		WithSpec("replicas", int64(2)).
+		Build()
+	if _, err := f.ApplyCRUnstructured(ctx, other); err != nil {
+		t.Fatalf("failed to apply CR: %v", err)
+	}
+
+	crmm := &v1alpha1.ClusterResourceMetricsMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-scoped", Generation: 1},
+		Spec:       v1alpha1.ResourceMetricsMonitorSpec{Configuration: fmt.Sprintf(clusterScopedConfiguration, "cluster_scoped_info")},
+	}
+	if _, err := f.ApplyCRMM(ctx, crmm); err != nil {
+		t.Fatalf("failed to apply CRMM: %v", err)
+	}
+	if _, err := f.WaitForCRMMProcessed(ctx, crmm.GetName(), 5*framework.LongTimeInterval); err != nil {
+		t.Fatalf("failed waiting for CRMM to be processed: %v", err)
+	}
+	all := `# HELP kube_customresource_cluster_scoped_info Cluster-scoped test family
+# TYPE kube_customresource_cluster_scoped_info gauge
+kube_customresource_cluster_scoped_info{name="test-sample",namespace="default",group="samplecontroller.k8s.io",version="v1alpha1",kind="Foo"} 1
+kube_customresource_cluster_scoped_info{name="test-sample-other",namespace="other",group="samplecontroller.k8s.io",version="v1alpha1",kind="Foo"} 2
+`
+	if err := eventuallyScrapeAndCompare(ctx, f, all); err != nil {
+		t.Fatalf("metric comparison failed: %v", err)
+	}
+	dedicatedURL := fmt.Sprintf("http://127.0.0.1:%d/metrics/%s", *f.Options.MainPort, crmm.GetName())
+	if err := testutil.ScrapeAndCompare(dedicatedURL, strings.NewReader(all)); err != nil {
+		t.Fatalf("dedicated endpoint comparison failed: %v", err)
+	}
+
+	// An RMM targeting the same GVR takes over the CRMM's series in its namespace.
+	rmm := &v1alpha1.ResourceMetricsMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "namespace-scoped", Namespace: "default", Generation: 1},
+		Spec:       v1alpha1.ResourceMetricsMonitorSpec{Configuration: fmt.Sprintf(clusterScopedConfiguration, "namespace_scoped_info")},
+	}
+	if _, err := f.ApplyRMM(ctx, rmm); err != nil {
+		t.Fatalf("failed to apply RMM: %v", err)
+	}
+	if _, err := f.WaitForRMMProcessed(ctx, rmm.GetNamespace(), rmm.GetName(), 5*framework.LongTimeInterval); err != nil {
+		t.Fatalf("failed waiting for RMM to be processed: %v", err)
+	}
+	overridden := `# HELP kube_customresource_cluster_scoped_info Cluster-scoped test family
+# TYPE kube_customresource_cluster_scoped_info gauge
+kube_customresource_cluster_scoped_info{name="test-sample-other",namespace="other",group="samplecontroller.k8s.io",version="v1alpha1",kind="Foo"} 2
+`
+	if err := eventuallyScrapeAndCompare(ctx, f, overridden); err != nil {
+		t.Fatalf("overridden metric comparison failed: %v", err)
+	}
+	if err := testutil.ScrapeAndCompare(dedicatedURL, strings.NewReader(overridden)); err != nil {
+		t.Fatalf("overridden dedicated endpoint comparison failed: %v", err)
+	}
+
+	// Once the RMM is gone, the CRMM's series are back.
+	if err := f.DeleteRMM(ctx, rmm.GetNamespace(), rmm.GetName()); err != nil {
+		t.Fatalf("delete RMM: %v", err)
+	}
+	if err := eventuallyScrapeAndCompare(ctx, f, all); err != nil {
+		t.Fatalf("metric comparison after deleting the RMM failed: %v", err)
+	}
+
+	if err := f.DeleteCRMM(ctx, crmm.GetName()); err != nil {
+		t.Fatalf("delete CRMM: %v", err)
+	}
+	f.ExpectNoMetric(t, framework.Metric("kube_customresource_cluster_scoped_info"))
+}
@@ -36,75 +36,19 @@ package tests
 
 import (
 	"context"
-	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"testing"
-	"time"
 
-	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rexagod/resource-state-metrics/internal"
 	"github.com/rexagod/resource-state-metrics/tests/framework"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // TestCustomResourceStateMetricsConformance tests all golden rules for all resolvers.
 func TestCustomResourceStateMetricsConformance(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
-
-	// Pre-load RMMs from golden rules to work around the fact that fake clients
-	// don't emit watch events for objects created after informers start, so RMMs
-	// must be pre-populated before the controller's informer initializes.
-	initialRMMs, err := framework.LoadRMMsFromGoldenRules(ctx)
-	if err != nil {
-		t.Fatalf("Failed to load RMMs from golden rules: %v", err)
-	}
-
-	f := framework.NewInforming(ctx, initialRMMs...)
-
-	if err := applyCRDManifests(ctx, t, f); err != nil {
-		t.Fatalf("Failed to apply CRD manifests: %v", err)
-	}
-
-	gvrToKindListMap := make(map[schema.GroupVersionResource]string)
-	indexedCRDs := f.GetIndexedCRDs()
-
-	for _, crd := range indexedCRDs {
-		for _, version := range crd.Spec.Versions {
-			gv := schema.GroupVersion{Group: crd.Spec.Group, Version: version.Name}
-
-			f.AddToScheme(func(scheme *runtime.Scheme) {
-				scheme.AddKnownTypes(gv, &unstructured.Unstructured{}, &unstructured.UnstructuredList{})
-			})
-
-			// The dynamic client needs to know the List kind for each GVR to
-			// properly handle list operations. This is typically the singular Kind
-			// with "List" appended. This is also the reason why we aren't just
-			// passing the updated scheme to the dynamic client, as it doesn't have
-			// the necessary type information to derive the List kinds on its own.
-			// Regardless, we still update the scheme for other clients that may need it.
-			gvr := schema.GroupVersionResource{
-				Group:    crd.Spec.Group,
-				Version:  version.Name,
-				Resource: crd.Spec.Names.Plural,
-			}
-			gvrToKindListMap[gvr] = crd.Spec.Names.Kind + "List"
-		}
-	}
-
-	f.WithDynamicClient(gvrToKindListMap)
-
-	if err := applyCRManifests(ctx, t, f); err != nil {
-		t.Fatalf("Failed to apply CR manifests: %v", err)
-	}
-
-	if err := f.Start(ctx, 1); err != nil {
-		t.Fatalf("Failed to start controller: %v", err)
-	}
+	f := setupFramework(t)
 
 	for _, resolverType := range []internal.ResolverType{
 		internal.ResolverTypeUnstructured,
@@ -116,95 +60,6 @@ func TestCustomResourceStateMetricsConformance(t *testing.T) {
 	}
 }
 
-// getCRDandNonCRDManifests retrieves all CRD and non-CRD manifest file paths from the specified directories.
-func getCRDandNonCRDManifests(t *testing.T) ([]string, []string, error) {
-	t.Helper()
-	manifestDirs := []string{
-		"manifests",
-		"../manifests",
-	}
-
-	// Fake client does not support certain resources OOTB.
-	ignoredManifestsByPrefix := map[string]struct{}{
-		"cluster-role": {},
-	}
-
-	var (
-		crdFiles   []string
-		otherFiles []string
-	)
-
-	for _, manifestsDir := range manifestDirs {
-		if _, err := os.Stat(manifestsDir); os.IsNotExist(err) {
-			t.Fatalf("Manifests directory does not exist: %s", manifestsDir)
-		}
-
-		err := filepath.Walk(manifestsDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-
-			for ignoredPrefix := range ignoredManifestsByPrefix {
-				if strings.HasPrefix(filepath.Base(path), ignoredPrefix) {
-					return nil
-				}
-			}
-
-			if info.IsDir() || !strings.HasSuffix(path, ".yaml") {
-				return nil
-			}
-
-			// Assume all CRD manifests are prefixed with "custom-resource-definition"
-			if strings.HasPrefix(filepath.Base(path), "custom-resource-definition") {
-				crdFiles = append(crdFiles, path)
-			} else {
-				otherFiles = append(otherFiles, path)
-			}
-
-			return nil
-		})
-		if err != nil {
-			return nil, nil, err
-		}
-	}
-
-	return crdFiles, otherFiles, nil
-}
-
-// applyCRDManifests applies only CRD manifests from the manifest directories.
-func applyCRDManifests(ctx context.Context, t *testing.T, f *framework.Framework) error {
-	t.Helper()
-	crdFiles, _, err := getCRDandNonCRDManifests(t)
-	if err != nil {
-		return fmt.Errorf("failed to get manifest files: %w", err)
-	}
-
-	for _, path := range crdFiles {
-		if _, err := f.CreateCRDFromYAML(ctx, path); err != nil {
-			return fmt.Errorf("failed to create CRD from %s: %w", path, err)
-		}
-	}
-
-	return nil
-}
-
-// applyCRManifests applies only CR manifests (non-CRD) from the manifest directories.
-func applyCRManifests(ctx context.Context, t *testing.T, f *framework.Framework) error {
-	t.Helper()
-	_, otherFiles, err := getCRDandNonCRDManifests(t)
-	if err != nil {
-		return fmt.Errorf("failed to get manifest files: %w", err)
-	}
-
-	for _, path := range otherFiles {
-		if _, err := f.ApplyCRFromYAML(ctx, path); err != nil {
-			return fmt.Errorf("failed to apply CR from %s: %w", path, err)
-		}
-	}
-
-	return nil
-}
-
 // testResolverConformance tests all golden rules for a specific resolver.
 func testResolverConformance(ctx context.Context, t *testing.T, f *framework.Framework, resolverType internal.ResolverType) {
 	t.Helper()
@@ -238,29 +93,24 @@ func testGoldenRule(ctx context.Context, t *testing.T, f *framework.Framework, f
 		return
 	}
 
-	// RMMs are pre-loaded when creating the framework, so only apply non-RMM resources
-	if goldenRule.In != nil && goldenRule.In.GetKind() != framework.ResourceMetricsMonitorKind {
-		_, err := f.ApplyCRUnstructured(ctx, goldenRule.In)
+	if goldenRule.In.GetKind() == framework.ResourceMetricsMonitorKind {
+		rmm, err := f.ApplyRMMUnstructured(ctx, goldenRule.In)
 		if err != nil {
-			t.Fatalf("Failed to apply input resource: %v", err)
+			t.Fatalf("Failed to apply input RMM: %v", err)
 		}
+		if _, err = f.WaitForRMMProcessed(ctx, rmm.GetNamespace(), rmm.GetName(), 5*framework.LongTimeInterval); err != nil {
+			t.Fatalf("Failed waiting for input RMM to be processed: %v", err)
+		}
+	} else if _, err := f.ApplyCRUnstructured(ctx, goldenRule.In); err != nil {
+		t.Fatalf("Failed to apply input resource: %v", err)
 	}
 
-	// Wait for controller to process resources and reflectors to sync
-	time.Sleep(5 * framework.LongTimeInterval)
-
 	goldenRuleOutMetrics := goldenRule.Out.Metrics
 	if len(goldenRuleOutMetrics) == 0 {
 		panic("Golden rule has no expected output metrics defined")
 	}
 
-	expectedMetrics := strings.Join(goldenRuleOutMetrics, "\n") + "\n"
-	port := *f.Options.MainPort
-	url := fmt.Sprintf("http://127.0.0.1:%d/metrics", port)
-
-	if err := testutil.ScrapeAndCompare(url, strings.NewReader(expectedMetrics)); err != nil {
+	if err := eventuallyScrapeAndCompare(ctx, f, strings.Join(goldenRuleOutMetrics, "\n")+"\n"); err != nil {
 		t.Errorf("Metric comparison failed: %v", err)
-
-		return
 	}
 }
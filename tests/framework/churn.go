@@ -0,0 +1,187 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ChurnOptions configures a churn scenario.
+type ChurnOptions struct {
+	// RMMs is the number of ResourceMetricsMonitors to churn.
+	RMMs int
+	// CRs is the number of custom resources to churn.
+	CRs int
+	// Rounds is the number of apply rounds. Every other round deletes half of the objects, to be recreated in the next.
+	Rounds int
+	// NewRMM returns the i-th ResourceMetricsMonitor for the given round.
+	NewRMM func(i, round int) *v1alpha1.ResourceMetricsMonitor
+	// NewCR returns the i-th custom resource for the given round.
+	NewCR func(i, round int) *unstructured.Unstructured
+	// Concurrency bounds the number of in-flight writes. Defaults to 10.
+	Concurrency int
+}
+
+// Churn rapidly creates, updates, and deletes ResourceMetricsMonitors and custom resources, as configured by the given
+// options. All objects are written concurrently within a round, and the objects of the last round are left in place;
+// see CleanUpChurn.
+func (f *Framework) Churn(ctx context.Context, opts ChurnOptions) error {
+	for round := range opts.Rounds {
+		if err := f.churnConcurrently(opts, func(i int) error {
+			if i < opts.CRs {
+				if _, err := f.ApplyCRUnstructured(ctx, opts.NewCR(i, round)); err != nil {
+					return err
+				}
+			}
+			if i < opts.RMMs {
+				if _, err := f.ApplyRMM(ctx, opts.NewRMM(i, round)); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}); err != nil {
+			return fmt.Errorf("round %d: failed to apply objects: %w", round, err)
+		}
+
+		if round%2 == 0 && round != opts.Rounds-1 {
+			if err := f.churnConcurrently(opts, func(i int) error {
+				if i%2 == 0 {
+					return nil
+				}
+
+				return f.deleteChurned(ctx, opts, i, round)
+			}); err != nil {
+				return fmt.Errorf("round %d: failed to delete objects: %w", round, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CleanUpChurn deletes all objects left in place by Churn, skipping the ones already gone, so it may be registered as a
+// cleanup before churning, whether or not churning completed, or the objects were cleaned up already.
+func (f *Framework) CleanUpChurn(ctx context.Context, opts ChurnOptions) error {
+	if err := f.churnConcurrently(opts, func(i int) error {
+		return f.deleteChurned(ctx, opts, i, opts.Rounds-1)
+	}); err != nil {
+		return fmt.Errorf("failed to clean up objects: %w", err)
+	}
+
+	return nil
+}
+
+// BurstUpdates updates the given custom resource the given number of times in a row, as fast as possible, each time
+// mutated by the given function, e.g., to exercise debouncing. It returns the custom resource as last updated.
+func (f *Framework) BurstUpdates(
+	ctx context.Context,
+	cr *unstructured.Unstructured,
+	updates int,
+	mutate func(cr *unstructured.Unstructured, i int),
+) (*unstructured.Unstructured, error) {
+	updated := cr
+	for i := range updates {
+		next := updated.DeepCopy()
+		mutate(next, i)
+		var err error
+		if updated, err = f.ApplyCRUnstructured(ctx, next); err != nil {
+			return nil, fmt.Errorf("update %d: %w", i, err)
+		}
+	}
+
+	return updated, nil
+}
+
+// BurstDeletes deletes the given custom resources all at once, with at most the given number of deletions in flight
+// (defaults to 10).
+func (f *Framework) BurstDeletes(ctx context.Context, crs []*unstructured.Unstructured, concurrency int) error {
+	opts := ChurnOptions{CRs: len(crs), Rounds: 1, Concurrency: concurrency, NewCR: func(i, _ int) *unstructured.Unstructured {
+		return crs[i]
+	}}
+	if err := f.churnConcurrently(opts, func(i int) error {
+		return f.deleteChurned(ctx, opts, i, 0)
+	}); err != nil {
+		return fmt.Errorf("failed to delete objects: %w", err)
+	}
+
+	return nil
+}
+
+// churnConcurrently calls fn for every churned object index, concurrently, and joins the resulting errors.
+func (f *Framework) churnConcurrently(opts ChurnOptions, fn func(i int) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 10
+	}
+	semaphore := make(chan struct{}, concurrency)
+	for i := range max(opts.RMMs, opts.CRs) {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			if err := fn(i); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// deleteChurned deletes the i-th churned ResourceMetricsMonitor and custom resource.
+func (f *Framework) deleteChurned(ctx context.Context, opts ChurnOptions, i, round int) error {
+	if i < opts.CRs {
+		cr := opts.NewCR(i, round)
+		gvk := cr.GroupVersionKind()
+		resource, err := f.GetResourcePluralNameForGVK(gvk)
+		if err != nil {
+			return fmt.Errorf("failed to get resource for %s: %w", gvk, err)
+		}
+		gvr := schema.GroupVersionResource{Group: gvk.Group, Version: gvk.Version, Resource: resource}
+		if err = f.DeleteCR(ctx, gvr, cr.GetNamespace(), cr.GetName()); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	if i < opts.RMMs {
+		rmm := opts.NewRMM(i, round)
+		if err := f.DeleteRMM(ctx, rmm.GetNamespace(), rmm.GetName()); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/internal"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// WithFakeClock has the controller measure stores' TTLs and tombstones, and the Processed condition's hold, against
+// a fake clock, set to the current time, that only advances through AdvanceClock, until the given test completes. As
+// the clock is process-wide, tests using it must not run in parallel with others.
+func (f *Framework) WithFakeClock(tb testing.TB) *clocktesting.FakeClock {
+	tb.Helper()
+	f.clock = clocktesting.NewFakeClock(time.Now())
+	internal.SetClock(f.clock)
+	tb.Cleanup(func() {
+		internal.SetClock(nil)
+		f.clock = nil
+	})
+
+	return f.clock
+}
+
+// AdvanceClock advances the fake clock the controller tells the time by, e.g., to expire the series of stores' stale or
+// deleted objects, or to let held off condition transitions through, deterministically.
+func (f *Framework) AdvanceClock(d time.Duration) {
+	if f.clock == nil {
+		panic("fake clock is not initialized; call WithFakeClock() to initialize it before advancing it")
+	}
+	f.clock.Step(d)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApplyCRMM creates the given ClusterResourceMetricsMonitor, or updates it if it already exists (see ApplyRMM).
+func (f *Framework) ApplyCRMM(ctx context.Context, crmm *v1alpha1.ClusterResourceMetricsMonitor) (*v1alpha1.ClusterResourceMetricsMonitor, error) {
+	crmmClient := f.RSMClient.ResourceStateMetricsV1alpha1().ClusterResourceMetricsMonitors()
+	created, err := crmmClient.Create(ctx, crmm, metav1.CreateOptions{})
+	if err == nil {
+		return created, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create CRMM %s: %w", crmm.GetName(), err)
+	}
+	existing, err := crmmClient.Get(ctx, crmm.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get existing CRMM %s: %w", crmm.GetName(), err)
+	}
+
+	existing = existing.DeepCopy()
+	existing.Spec = crmm.Spec
+	existing.SetLabels(crmm.GetLabels())
+	existing.SetAnnotations(crmm.GetAnnotations())
+	existing.SetGeneration(existing.GetGeneration() + 1)
+	resourceVersion, _ := strconv.Atoi(existing.GetResourceVersion())
+	existing.SetResourceVersion(strconv.Itoa(resourceVersion + 1))
+	updated, err := crmmClient.Update(ctx, existing, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update CRMM %s: %w", crmm.GetName(), err)
+	}
+
+	return updated, nil
+}
+
+// WaitForCRMMProcessed waits for the current generation of a CRMM to be processed successfully.
+func (f *Framework) WaitForCRMMProcessed(ctx context.Context, name string, timeout time.Duration) (*v1alpha1.ClusterResourceMetricsMonitor, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(ShortTimeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+			crmm, err := f.RSMClient.ResourceStateMetricsV1alpha1().ClusterResourceMetricsMonitors().Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			for _, cond := range crmm.Status.Conditions {
+				if cond.Type == v1alpha1.ConditionType[v1alpha1.ConditionTypeProcessed] &&
+					cond.Status == metav1.ConditionTrue &&
+					cond.ObservedGeneration == crmm.GetGeneration() {
+					return crmm, nil
+				}
+			}
+		}
+	}
+}
+
+// DeleteCRMM deletes a ClusterResourceMetricsMonitor.
+func (f *Framework) DeleteCRMM(ctx context.Context, name string) error {
+	err := f.RSMClient.ResourceStateMetricsV1alpha1().ClusterResourceMetricsMonitors().Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete CRMM %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -20,10 +20,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rexagod/resource-state-metrics/internal"
@@ -35,21 +37,29 @@ import (
 	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	apiextensionsinformers "k8s.io/apiextensions-apiserver/pkg/client/informers/externalversions"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/watch"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
+	clocktesting "k8s.io/utils/clock/testing"
 	"sigs.k8s.io/yaml"
 )
 
 const (
 	gvkIndexName = "gvk"
 
+	fakeWatchChanSize = 1 << 10
+
 	ShortTimeInterval = 100 * time.Millisecond
 	LongTimeInterval  = time.Second
 )
@@ -60,6 +70,11 @@ var (
 		Version:  v1alpha1.SchemeGroupVersion.Version,
 		Resource: "resourcemetricsmonitors",
 	}
+	crmmGVR = schema.GroupVersionResource{
+		Group:    v1alpha1.SchemeGroupVersion.Group,
+		Version:  v1alpha1.SchemeGroupVersion.Version,
+		Resource: "clusterresourcemetricsmonitors",
+	}
 )
 
 // Framework provides utilities for e2e testing with mock clientsets.
@@ -68,17 +83,22 @@ type Framework struct {
 	RSMClient rsmclientset.Interface
 
 	apiExtensionsClient apiextensionsclientset.Interface
+	clock               *clocktesting.FakeClock
 	controller          *internal.Controller
 	crdInformer         cache.SharedIndexInformer
 	crdInformerFactory  apiextensionsinformers.SharedInformerFactory
 	dynamicClient       *dynamicfake.FakeDynamicClient
 	kubeClient          kubernetes.Interface
+	rmmWatchStarted     chan struct{}
 	scheme              *runtime.Scheme
 }
 
 // NewInforming creates a new test framework with mock clientsets, and starts the CRD informer to keep it populated for test operations.
-// Optional initial RMMs can be provided to pre-populate the fake RSM client before the controller starts.
-func NewInforming(ctx context.Context, initialObjects ...runtime.Object) *Framework {
+func NewInforming(ctx context.Context) *Framework {
+	// The fake object tracker panics once a watcher's buffer is full, whereas the API server would close the watch, and
+	// have the reflector relist. Buffer generously, so bursts of writes (see Churn) don't bring the test binary down.
+	watch.DefaultChanSize = fakeWatchChanSize
+
 	apiExtensionsClient := apiextensionsfake.NewSimpleClientset()
 	crdInformerFactory := apiextensionsinformers.NewSharedInformerFactory(apiExtensionsClient, 0)
 	crdInformer := crdInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Informer()
@@ -102,10 +122,12 @@ func NewInforming(ctx context.Context, initialObjects ...runtime.Object) *Framew
 		},
 	})
 
+	rsmClient, rmmWatchStarted := newWatchSignallingRSMClient()
 	f := &Framework{
 		kubeClient:          kubefake.NewClientset(),
-		RSMClient:           rsmfake.NewSimpleClientset(initialObjects...),
+		RSMClient:           rsmClient,
 		apiExtensionsClient: apiExtensionsClient,
+		rmmWatchStarted:     rmmWatchStarted,
 		scheme:              runtime.NewScheme(), // use f.AddToScheme to inject types into the scheme
 		crdInformer:         crdInformer,
 		crdInformerFactory:  crdInformerFactory,
@@ -117,6 +139,35 @@ func NewInforming(ctx context.Context, initialObjects ...runtime.Object) *Framew
 	return f
 }
 
+// newWatchSignallingRSMClient returns a fake RSM clientset whose watch reactors signal the returned channel once the
+// controller's informers have established their (Cluster)ResourceMetricsMonitor watches. The fake object tracker only
+// delivers events to watchers that exist at the time of the write, so objects created in the window between an
+// informer's initial list and its watch are otherwise silently dropped.
+func newWatchSignallingRSMClient() (*rsmfake.Clientset, chan struct{}) {
+	client := rsmfake.NewSimpleClientset()
+	watchStarted := make(chan struct{})
+	var watches sync.WaitGroup
+	for _, resource := range []string{rmmGVR.Resource, crmmGVR.Resource} {
+		watches.Add(1)
+		var once sync.Once
+		client.PrependWatchReactor(resource, func(action clienttesting.Action) (bool, watch.Interface, error) {
+			w, err := client.Tracker().Watch(action.GetResource(), action.GetNamespace())
+			if err != nil {
+				return false, nil, err
+			}
+			once.Do(watches.Done)
+
+			return true, w, nil
+		})
+	}
+	go func() {
+		watches.Wait()
+		close(watchStarted)
+	}()
+
+	return client, watchStarted
+}
+
 // AddToScheme adds types to the framework's scheme. Panics if any adder returns an error.
 func (f *Framework) AddToScheme(adder func(*runtime.Scheme)) *runtime.Scheme {
 	adder(f.scheme)
@@ -128,13 +179,48 @@ func (f *Framework) AddToScheme(adder func(*runtime.Scheme)) *runtime.Scheme {
 // GVR to ListKind mapping. Panics if the scheme is not initialized or has no
 // known types, as the dynamic client relies on the scheme for object mapping.
 // The caller must ensure that the mapping is consistent with the types added
-// to the scheme via AddToScheme().
+// to the scheme via AddToScheme(). CRDs are always listable, as the controller
+// caches them.
 func (f *Framework) WithDynamicClient(injectedCustomGVRToListKind map[schema.GroupVersionResource]string) {
 	if f.scheme == nil {
 		panic("scheme is not initialized; call AddToScheme() to initialize the scheme before setting up the dynamic client")
 	}
+	gvrToListKind := map[schema.GroupVersionResource]string{
+		apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions"): "CustomResourceDefinitionList",
+	}
+	maps.Copy(gvrToListKind, injectedCustomGVRToListKind)
+
+	f.dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(f.scheme, gvrToListKind)
+	f.dynamicClient.PrependWatchReactor("*", newLabelFilteringWatchReactor(f.dynamicClient.Tracker()))
+}
+
+// newLabelFilteringWatchReactor returns a watch reactor that, like the API server, only delivers events for objects
+// matching the watch's label selector. The fake dynamic client honors label selectors when listing, but not when
+// watching, which would otherwise leak unselected objects into stores.
+func newLabelFilteringWatchReactor(tracker clienttesting.ObjectTracker) clienttesting.WatchReactionFunc {
+	return func(action clienttesting.Action) (bool, watch.Interface, error) {
+		w, err := tracker.Watch(action.GetResource(), action.GetNamespace())
+		if err != nil {
+			return false, nil, err
+		}
+		watchAction, ok := action.(clienttesting.WatchAction)
+		if !ok {
+			return true, w, nil
+		}
+		selector := watchAction.GetWatchRestrictions().Labels
+		if selector == nil || selector.Empty() {
+			return true, w, nil
+		}
+
+		return true, watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+			object, err := meta.Accessor(event.Object)
+			if err != nil {
+				return event, true
+			}
 
-	f.dynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(f.scheme, injectedCustomGVRToListKind)
+			return event, selector.Matches(labels.Set(object.GetLabels()))
+		}), nil
+	}
 }
 
 // Start starts the RSM controller with the mock clients.
@@ -152,9 +238,11 @@ func (f *Framework) Start(ctx context.Context, workers int) error {
 	}
 
 	f.Options = &internal.Options{Workers: &workers}
-	f.Options.Read()
+	if err := f.Options.Read(); err != nil {
+		return fmt.Errorf("invalid options: %w", err)
+	}
 
-	f.controller = internal.NewController(ctx, f.Options, f.kubeClient, f.RSMClient, f.dynamicClient)
+	f.controller = internal.NewController(ctx, f.Options, nil, f.kubeClient, f.RSMClient, f.dynamicClient, nil, nil)
 
 	// Start controller in background
 	go func() {
@@ -166,6 +254,9 @@ func (f *Framework) Start(ctx context.Context, workers int) error {
 	if err := f.waitForControllerReady(ctx); err != nil {
 		return fmt.Errorf("controller failed to become ready: %w", err)
 	}
+	if err := f.waitForRMMWatch(ctx); err != nil {
+		return fmt.Errorf("controller failed to watch ResourceMetricsMonitors: %w", err)
+	}
 
 	return nil
 }
@@ -242,6 +333,11 @@ func (f *Framework) ApplyCRUnstructured(ctx context.Context, customresource *uns
 	}
 
 	resourceClient := f.dynamicClient.Resource(gvr).Namespace(customresource.GetNamespace())
+	// The fake object tracker does not assign UIDs, but stores key objects by them, so emulate the API server here.
+	if customresource.GetUID() == "" {
+		customresource = customresource.DeepCopy()
+		customresource.SetUID(uuid.NewUUID())
+	}
 	created, err := resourceClient.Create(ctx, customresource, metav1.CreateOptions{})
 	if err == nil {
 		return created, nil
@@ -254,6 +350,7 @@ func (f *Framework) ApplyCRUnstructured(ctx context.Context, customresource *uns
 		return nil, fmt.Errorf("failed to get existing CR %s/%s: %w", customresource.GetNamespace(), customresource.GetName(), err)
 	}
 
+	customresource.SetUID(existing.GetUID())
 	customresource.SetResourceVersion(existing.GetResourceVersion())
 	updated, err := resourceClient.Update(ctx, customresource, metav1.UpdateOptions{})
 	if err != nil {
@@ -403,6 +500,19 @@ func (f *Framework) waitForControllerReady(ctx context.Context) error {
 	}
 }
 
+// waitForRMMWatch waits for the controller's (Cluster)ResourceMetricsMonitor informers to establish their watches, after
+// which all (Cluster)ResourceMetricsMonitor writes are guaranteed to be observed by the controller.
+func (f *Framework) waitForRMMWatch(ctx context.Context) error {
+	select {
+	case <-f.rmmWatchStarted:
+		return nil
+	case <-time.After(10 * time.Second):
+		return errors.New("timed out waiting for the (Cluster)ResourceMetricsMonitor watches to be established")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // waitForCRDIndexed waits for a CRD to appear in the informer index.
 func (f *Framework) waitForCRDIndexed(crd *apiextensionsv1.CustomResourceDefinition) error {
 	timeout := time.After(LongTimeInterval)
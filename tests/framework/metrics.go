@@ -0,0 +1,285 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// MatchType is the way a LabelMatcher matches a label's value.
+type MatchType string
+
+const (
+	MatchEqual    MatchType = "="
+	MatchNotEqual MatchType = "!="
+	MatchRegexp   MatchType = "=~"
+)
+
+// LabelMatcher matches a series' label against a value, or a regular expression. Unset labels match as empty ones, as
+// in PromQL.
+type LabelMatcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+	re    *regexp.Regexp
+}
+
+// LabelEqual matches series whose given label is set to the given value.
+func LabelEqual(name, value string) LabelMatcher {
+	return LabelMatcher{Name: name, Type: MatchEqual, Value: value}
+}
+
+// LabelNotEqual matches series whose given label is not set to the given value.
+func LabelNotEqual(name, value string) LabelMatcher {
+	return LabelMatcher{Name: name, Type: MatchNotEqual, Value: value}
+}
+
+// LabelRegexp matches series whose given label fully matches the given regular expression. It panics if the
+// expression does not compile.
+func LabelRegexp(name, expression string) LabelMatcher {
+	return LabelMatcher{Name: name, Type: MatchRegexp, Value: expression, re: regexp.MustCompile("^(?:" + expression + ")$")}
+}
+
+// matches reports whether the given labels match.
+func (m LabelMatcher) matches(labels map[string]string) bool {
+	value := labels[m.Name]
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	default:
+		return false
+	}
+}
+
+// String returns the matcher in PromQL notation.
+func (m LabelMatcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+// MetricSelector selects the series of a family, by their labels.
+type MetricSelector struct {
+	Name     string
+	Matchers []LabelMatcher
+}
+
+// Metric returns a selector for the series of the given family matching all given matchers.
+func Metric(name string, matchers ...LabelMatcher) MetricSelector {
+	return MetricSelector{Name: name, Matchers: matchers}
+}
+
+// MetricWithLabels returns a selector for the series of the given family whose labels include the given ones.
+func MetricWithLabels(name string, labels map[string]string) MetricSelector {
+	selector := MetricSelector{Name: name}
+	for _, labelName := range slices.Sorted(maps.Keys(labels)) {
+		selector.Matchers = append(selector.Matchers, LabelEqual(labelName, labels[labelName]))
+	}
+
+	return selector
+}
+
+// matches reports whether the given series is selected.
+func (s MetricSelector) matches(series Series) bool {
+	if series.Name != s.Name {
+		return false
+	}
+	for _, matcher := range s.Matchers {
+		if !matcher.matches(series.Labels) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns the selector in PromQL notation.
+func (s MetricSelector) String() string {
+	matchers := make([]string, len(s.Matchers))
+	for i, matcher := range s.Matchers {
+		matchers[i] = matcher.String()
+	}
+
+	return s.Name + "{" + strings.Join(matchers, ",") + "}"
+}
+
+// Series is a single scraped sample, along with its family's name and its labels.
+type Series struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// Scrape scrapes the controller's main server, and returns its series. Only the series of gauge, counter, and untyped
+// families are returned.
+func (f *Framework) Scrape(ctx context.Context) ([]Series, error) {
+	url := fmt.Sprintf("http://127.0.0.1:%d/metrics", *f.Options.MainPort)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scrape %s: %w", url, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to scrape %s: unexpected status %s", url, response.Status)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse exposition: %w", err)
+	}
+	var series []Series
+	for name, family := range families {
+		for _, metric := range family.GetMetric() {
+			var value float64
+			switch family.GetType() {
+			case dto.MetricType_GAUGE:
+				value = metric.GetGauge().GetValue()
+			case dto.MetricType_COUNTER:
+				value = metric.GetCounter().GetValue()
+			case dto.MetricType_UNTYPED:
+				value = metric.GetUntyped().GetValue()
+			default:
+				continue
+			}
+			labels := make(map[string]string, len(metric.GetLabel()))
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			series = append(series, Series{Name: name, Labels: labels, Value: value})
+		}
+	}
+
+	return series, nil
+}
+
+// ExpectMetric scrapes the controller's main server until a series matching the given selector is exposed with the
+// given value, or fails the test with the difference to the family's series, once the timeout is hit.
+func (f *Framework) ExpectMetric(t testing.TB, selector MetricSelector, value float64) {
+	t.Helper()
+	err := f.eventually(t.Context(), func(series []Series) error {
+		var candidates []string
+		for _, s := range series {
+			if !selector.matches(s) {
+				if s.Name == selector.Name {
+					candidates = append(candidates, s.mismatch(selector))
+				}
+
+				continue
+			}
+			if s.Value == value {
+				return nil
+			}
+			candidates = append(candidates, fmt.Sprintf("value (-want +got):\n  - %v\n  + %v", value, s.Value))
+		}
+		if len(candidates) == 0 {
+			return fmt.Errorf("no series of %s exposed", selector.Name)
+		}
+
+		return fmt.Errorf("no series of %s matching %s with value %v, closest ones differ as (-want +got):\n%s",
+			selector.Name, selector, value, strings.Join(candidates, "\n"))
+	})
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
+// ExpectNoMetric scrapes the controller's main server until no series matching the given selector is exposed, or
+// fails the test with the ones still exposed, once the timeout is hit.
+func (f *Framework) ExpectNoMetric(t testing.TB, selector MetricSelector) {
+	t.Helper()
+	err := f.eventually(t.Context(), func(series []Series) error {
+		var exposed []string
+		for _, s := range series {
+			if selector.matches(s) {
+				exposed = append(exposed, fmt.Sprintf("%s%v %v", s.Name, s.Labels, s.Value))
+			}
+		}
+		if len(exposed) > 0 {
+			slices.Sort(exposed)
+
+			return fmt.Errorf("series matching %s still exposed:\n%s", selector, strings.Join(exposed, "\n"))
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
+// eventually scrapes the controller's main server until the given check passes, or returns its last error once the
+// timeout is hit.
+func (f *Framework) eventually(ctx context.Context, check func(series []Series) error) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*LongTimeInterval)
+	defer cancel()
+	ticker := time.NewTicker(ShortTimeInterval)
+	defer ticker.Stop()
+
+	for {
+		series, err := f.Scrape(ctx)
+		if err == nil {
+			err = check(series)
+		}
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+		}
+	}
+}
+
+// mismatch describes how the series' labels differ from the ones the given selector matches.
+func (s Series) mismatch(selector MetricSelector) string {
+	expected, got := map[string]string{}, map[string]string{}
+	var mismatches []string
+	for _, matcher := range selector.Matchers {
+		switch {
+		case matcher.Type == MatchEqual:
+			expected[matcher.Name], got[matcher.Name] = matcher.Value, s.Labels[matcher.Name]
+		case !matcher.matches(s.Labels):
+			mismatches = append(mismatches, fmt.Sprintf("%s does not match %q", matcher, s.Labels[matcher.Name]))
+		}
+	}
+
+	if diff := cmp.Diff(expected, got); diff != "" {
+		mismatches = append([]string{diff}, mismatches...)
+	}
+
+	return strings.Join(mismatches, "\n")
+}
@@ -19,89 +19,84 @@ package framework
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
 	"time"
 
-	"github.com/rexagod/resource-state-metrics/internal"
 	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
 )
 
 const (
 	ResourceMetricsMonitorKind = "ResourceMetricsMonitor"
 )
 
-// LoadRMMsFromGoldenRules extracts all RMMs from golden rule files.
-// This is needed because fake Kubernetes clients don't emit watch events for objects
-// created after informers start; RMMs must exist when the controller's informer initializes.
-func LoadRMMsFromGoldenRules(ctx context.Context) ([]runtime.Object, error) {
-	var rmms []runtime.Object
-
-	files := GetConformanceGoldenRuleFiles([]internal.ResolverType{
-		internal.ResolverTypeUnstructured,
-	})
-
-	for _, file := range files {
-		goldenRule, err := GoldenRuleFromYAML(ctx, file)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load golden rule from %s: %w", file, err)
-		}
-		if goldenRule.In == nil {
-			return nil, fmt.Errorf("golden rule %s has no input resource defined", file)
-		}
-		if goldenRule.In.GetKind() != ResourceMetricsMonitorKind {
-			return nil, fmt.Errorf("golden rule %s input resource is not a ResourceMetricsMonitor", file)
-		}
-
-		var rmm v1alpha1.ResourceMetricsMonitor
-		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(goldenRule.In.Object, &rmm); err != nil {
-			return nil, fmt.Errorf("failed to convert unstructured to RMM for golden rule %s: %w", file, err)
-		}
-
-		rmms = append(rmms, &rmm)
-	}
-
-	return rmms, nil
-}
-
-// ApplyRMM applies a ResourceMetricsMonitor resource using ApplyCR.
+// ApplyRMM creates the given ResourceMetricsMonitor, or updates it if it already exists.
+// RMMs are written through the RSM clientset (as opposed to the dynamic one) since that is what the controller watches.
 func (f *Framework) ApplyRMM(ctx context.Context, rmm *v1alpha1.ResourceMetricsMonitor) (*v1alpha1.ResourceMetricsMonitor, error) {
-	cr, err := f.ToUnstructured(rmm)
+	rmmClient := f.RSMClient.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors(rmm.GetNamespace())
+	created, err := rmmClient.Create(ctx, rmm, metav1.CreateOptions{})
+	if err == nil {
+		return created, nil
+	}
+	if !apierrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create RMM %s/%s: %w", rmm.GetNamespace(), rmm.GetName(), err)
+	}
+	existing, err := rmmClient.Get(ctx, rmm.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert RMM to unstructured: %w", err)
+		return nil, fmt.Errorf("failed to get existing RMM %s/%s: %w", rmm.GetNamespace(), rmm.GetName(), err)
 	}
 
-	appliedCR, err := f.ApplyCRUnstructured(ctx, cr)
+	existing = existing.DeepCopy()
+	existing.Spec = rmm.Spec
+	existing.SetLabels(rmm.GetLabels())
+	existing.SetAnnotations(rmm.GetAnnotations())
+	// The fake object tracker does not manage generations or resource versions, but the controller relies on both to
+	// tell spec changes apart and to report the observed generation, so emulate the API server here.
+	existing.SetGeneration(existing.GetGeneration() + 1)
+	resourceVersion, _ := strconv.Atoi(existing.GetResourceVersion())
+	existing.SetResourceVersion(strconv.Itoa(resourceVersion + 1))
+	updated, err := rmmClient.Update(ctx, existing, metav1.UpdateOptions{})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to update RMM %s/%s: %w", rmm.GetNamespace(), rmm.GetName(), err)
 	}
 
-	obj := &v1alpha1.ResourceMetricsMonitor{}
-	err = f.FromUnstructured(appliedCR, obj)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert applied CR to RMM: %w", err)
+	return updated, nil
+}
+
+// ApplyRMMUnstructured applies a ResourceMetricsMonitor resource from an unstructured object.
+func (f *Framework) ApplyRMMUnstructured(ctx context.Context, u *unstructured.Unstructured) (*v1alpha1.ResourceMetricsMonitor, error) {
+	if u.GetKind() != ResourceMetricsMonitorKind {
+		return nil, fmt.Errorf("expected kind %s, got %s", ResourceMetricsMonitorKind, u.GetKind())
 	}
 
-	return obj, nil
+	rmm := &v1alpha1.ResourceMetricsMonitor{}
+	if err := f.FromUnstructured(u, rmm); err != nil {
+		return nil, fmt.Errorf("failed to convert unstructured to RMM: %w", err)
+	}
+
+	return f.ApplyRMM(ctx, rmm)
 }
 
-// ApplyRMMFromYAML applies a ResourceMetricsMonitor resource from a YAML file using ApplyCRFromYAML.
+// ApplyRMMFromYAML applies a ResourceMetricsMonitor resource from a YAML file.
 func (f *Framework) ApplyRMMFromYAML(ctx context.Context, path string) (*v1alpha1.ResourceMetricsMonitor, error) {
-	appliedCR, err := f.ApplyCRFromYAML(ctx, path)
+	data, err := os.ReadFile(ensureSafePath(path))
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to read YAML file %s: %w", path, err)
 	}
 
-	obj := &v1alpha1.ResourceMetricsMonitor{}
-	err = f.FromUnstructured(appliedCR, obj)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert applied CR to RMM: %w", err)
+	rmm := &v1alpha1.ResourceMetricsMonitor{}
+	if err := yaml.Unmarshal(data, rmm); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
 	}
 
-	return obj, nil
+	return f.ApplyRMM(ctx, rmm)
 }
 
-// WaitForRMMProcessed waits for an RMM to be processed (status condition set).
+// WaitForRMMProcessed waits for the current generation of an RMM to be processed successfully.
 func (f *Framework) WaitForRMMProcessed(ctx context.Context, namespace, name string, timeout time.Duration) (*v1alpha1.ResourceMetricsMonitor, error) {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -119,7 +114,9 @@ func (f *Framework) WaitForRMMProcessed(ctx context.Context, namespace, name str
 				continue
 			}
 			for _, cond := range rmm.Status.Conditions {
-				if cond.Type == v1alpha1.ConditionType[v1alpha1.ConditionTypeProcessed] {
+				if cond.Type == v1alpha1.ConditionType[v1alpha1.ConditionTypeProcessed] &&
+					cond.Status == metav1.ConditionTrue &&
+					cond.ObservedGeneration == rmm.GetGeneration() {
 					return rmm, nil
 				}
 			}
@@ -127,7 +124,12 @@ func (f *Framework) WaitForRMMProcessed(ctx context.Context, namespace, name str
 	}
 }
 
-// DeleteRMM deletes a ResourceMetricsMonitor using DeleteCR.
+// DeleteRMM deletes a ResourceMetricsMonitor.
 func (f *Framework) DeleteRMM(ctx context.Context, namespace, name string) error {
-	return f.DeleteCR(ctx, rmmGVR, namespace, name)
+	err := f.RSMClient.ResourceStateMetricsV1alpha1().ResourceMetricsMonitors(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete RMM %s/%s: %w", namespace, name, err)
+	}
+
+	return nil
 }
@@ -0,0 +1,238 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/tests/framework"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	churnFamilyPrefix = "kube_customresource_churn_"
+	churnObjects      = 200
+	churnRounds       = 5
+
+	churnConfiguration = `stores:
+  - group: "samplecontroller.k8s.io"
+    version: "v1beta1"
+    kind: "Bar"
+    resource: "bars"
+    selectors:
+      label: "churn=%[1]d"
+    families:
+      - name: "churn_%[1]d"
+        help: "Churn test family"
+        metrics:
+          - labelKeys:
+              - "name"
+              - "round"
+            labelValues:
+              - "metadata.name"
+              - "metadata.labels.round"
+            value: "spec.replicas"
+`
+
+	// churnGoroutineSlack accounts for goroutines that are not owned by the churned objects, e.g., idle HTTP connections.
+	churnGoroutineSlack = 20
+	// churnHeapSlack bounds the heap growth retained after all churned objects are gone.
+	churnHeapSlack = 64 << 20
+)
+
+// TestResourceMetricsMonitorChurn rapidly creates, updates, and deletes RMMs and the CRs they target while scraping
+// continuously, and verifies that the exposition stays well-formed and that nothing outlives the churned objects.
+// This test does not run in parallel, as it asserts on process-wide goroutine and heap usage.
+func TestResourceMetricsMonitorChurn(t *testing.T) {
+	ctx := context.Background()
+	f := setupFramework(t)
+
+	baselineGoroutines, baselineHeap := settledUsage()
+
+	opts := framework.ChurnOptions{
+		RMMs:   churnObjects,
+		CRs:    churnObjects,
+		Rounds: churnRounds,
+		NewRMM: func(i, round int) *v1alpha1.ResourceMetricsMonitor {
+			return &v1alpha1.ResourceMetricsMonitor{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("churn-%d", i),
+					Namespace: "default",
+					Labels:    map[string]string{"round": fmt.Sprint(round)},
+				},
+				Spec: v1alpha1.ResourceMetricsMonitorSpec{Configuration: fmt.Sprintf(churnConfiguration, i)},
+			}
+		},
+		NewCR: func(i, round int) *unstructured.Unstructured {
+			return framework.NewCRBuilder("samplecontroller.k8s.io", "v1beta1", "Bar", fmt.Sprintf("churn-%d", i), "default").
+				WithLabel("churn", fmt.Sprint(i)).
+				WithLabel("round", fmt.Sprint(round)).
+				WithSpec("replicas", round).
+				Build()
+		},
+	}
+	// Churned objects are cleaned up even if the test fails midway, lest they leak into the exposition other tests
+	// assert on.
+	t.Cleanup(func() {
+		if err := f.CleanUpChurn(ctx, opts); err != nil {
+			t.Error(err)
+		}
+	})
+	scrapeCtx, stopScraping := context.WithCancel(ctx)
+	t.Cleanup(stopScraping)
+	scrapeErrs := make(chan error, 1)
+	go func() {
+		scrapeErrs <- scrapeContinuously(scrapeCtx, f)
+	}()
+	if err := f.Churn(ctx, opts); err != nil {
+		t.Fatalf("churn failed: %v", err)
+	}
+
+	// Wait for the controller to catch up with the last round, so the churn is guaranteed to have been observed.
+	lastRound := fmt.Sprintf("round=%q", fmt.Sprint(churnRounds-1))
+	err := eventuallyScrape(ctx, f, func(exposition string) bool {
+		return strings.Count(exposition, lastRound) == churnObjects
+	})
+	if err != nil {
+		t.Fatalf("last round was not observed: %v", err)
+	}
+
+	if err = f.CleanUpChurn(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	err = eventuallyScrape(ctx, f, func(exposition string) bool {
+		return !strings.Contains(exposition, churnFamilyPrefix)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopScraping()
+	if err = <-scrapeErrs; err != nil {
+		t.Fatalf("malformed exposition during churn: %v", err)
+	}
+
+	if err = eventuallySettle(ctx, baselineGoroutines+churnGoroutineSlack, baselineHeap+churnHeapSlack); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// settledUsage returns the number of goroutines and the heap size, after a garbage collection.
+func settledUsage() (int, uint64) {
+	http.DefaultClient.CloseIdleConnections()
+	runtime.GC()
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	return runtime.NumGoroutine(), memStats.HeapAlloc
+}
+
+// eventuallySettle waits for the number of goroutines and the heap size to drop to the given bounds, or the timeout
+// to be hit.
+func eventuallySettle(ctx context.Context, maxGoroutines int, maxHeap uint64) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*framework.LongTimeInterval)
+	defer cancel()
+	ticker := time.NewTicker(framework.ShortTimeInterval)
+	defer ticker.Stop()
+
+	for {
+		goroutines, heap := settledUsage()
+		if goroutines <= maxGoroutines && heap <= maxHeap {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("usage did not settle: %d goroutines (want <= %d), %d heap bytes (want <= %d)", goroutines, maxGoroutines, heap, maxHeap)
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrapeContinuously scrapes the main server until the context is cancelled, and returns the first malformed
+// exposition encountered, if any.
+func scrapeContinuously(ctx context.Context, f *framework.Framework) error {
+	for ctx.Err() == nil {
+		exposition, err := scrape(ctx, f)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+		headers := map[string]struct{}{}
+		for _, line := range strings.Split(exposition, "\n") {
+			if !strings.HasPrefix(line, "# HELP ") && !strings.HasPrefix(line, "# TYPE ") {
+				continue
+			}
+			if _, ok := headers[line]; ok {
+				return fmt.Errorf("duplicate header %q", line)
+			}
+			headers[line] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// eventuallyScrape scrapes the main server until the exposition satisfies the given condition, or the timeout is hit.
+func eventuallyScrape(ctx context.Context, f *framework.Framework, condition func(exposition string) bool) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*framework.LongTimeInterval)
+	defer cancel()
+	ticker := time.NewTicker(framework.ShortTimeInterval)
+	defer ticker.Stop()
+
+	for {
+		exposition, err := scrape(ctx, f)
+		if err == nil && condition(exposition) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("exposition did not satisfy the condition (last error: %v)", err)
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrape returns the main server's exposition.
+func scrape(ctx context.Context, f *framework.Framework) (string, error) {
+	url := fmt.Sprintf("http://127.0.0.1:%d/metrics", *f.Options.MainPort)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to scrape %s: %w", url, err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return string(body), nil
+}
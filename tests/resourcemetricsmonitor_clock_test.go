@@ -0,0 +1,214 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/tests/framework"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	tombstoneObjects   = 10
+	tombstoneRetention = 5 * time.Minute
+
+	tombstoneConfiguration = `stores:
+  - group: "samplecontroller.k8s.io"
+    version: "v1beta1"
+    kind: "Bar"
+    resource: "bars"
+    tombstoneRetention: "%s"
+    selectors:
+      label: "tombstone=true"
+    families:
+      - name: "tombstone_info"
+        help: "Tombstone test family"
+        metrics:
+          - labelKeys:
+              - "name"
+            labelValues:
+              - "metadata.name"
+            value: "spec.replicas"
+`
+
+	debounceUpdates = 20
+
+	debounceConfiguration = `stores:
+  - group: "samplecontroller.k8s.io"
+    version: "v1beta1"
+    kind: "Bar"
+    resource: "bars"
+    selectors:
+      label: "debounce=true"
+    families:
+      - name: "debounce_info"
+        help: "%s"
+        metrics:
+          - labelKeys:
+              - "name"
+            labelValues:
+              - "metadata.name"
+            value: "spec.replicas"
+`
+)
+
+// TestResourceMetricsMonitorTombstones deletes the CRs an RMM targets all at once, and verifies that their series are
+// retained as tombstones until the store's retention elapses, as told by the fake clock. This test does not run in
+// parallel, as the clock is process-wide.
+func TestResourceMetricsMonitorTombstones(t *testing.T) {
+	ctx := context.Background()
+	f := setupFramework(t)
+	f.WithFakeClock(t)
+
+	rmm := &v1alpha1.ResourceMetricsMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "tombstone", Namespace: "default"},
+		Spec:       v1alpha1.ResourceMetricsMonitorSpec{Configuration: fmt.Sprintf(tombstoneConfiguration, tombstoneRetention)},
+	}
+	crs := make([]*unstructured.Unstructured, tombstoneObjects)
+	for i := range crs {
+		crs[i] = framework.NewCRBuilder("samplecontroller.k8s.io", "v1beta1", "Bar", fmt.Sprintf("tombstone-%d", i), "default").
+			WithLabel("tombstone", "true").
+			WithSpec("replicas", i).
+			Build()
+	}
+	t.Cleanup(func() {
+		_ = f.DeleteRMM(ctx, rmm.GetNamespace(), rmm.GetName())
+	})
+	for _, cr := range crs {
+		if _, err := f.ApplyCRUnstructured(ctx, cr); err != nil {
+			t.Fatalf("failed to apply CR: %v", err)
+		}
+	}
+	if _, err := f.ApplyRMM(ctx, rmm); err != nil {
+		t.Fatalf("failed to apply RMM: %v", err)
+	}
+	if _, err := f.WaitForRMMProcessed(ctx, rmm.GetNamespace(), rmm.GetName(), 5*framework.LongTimeInterval); err != nil {
+		t.Fatalf("failed waiting for RMM to be processed: %v", err)
+	}
+	if err := eventuallyScrape(ctx, f, func(exposition string) bool {
+		return countSeries(exposition, "kube_customresource_tombstone_info", "") == tombstoneObjects
+	}); err != nil {
+		t.Fatalf("series were not exposed: %v", err)
+	}
+
+	if err := f.BurstDeletes(ctx, crs, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := eventuallyScrape(ctx, f, func(exposition string) bool {
+		return countSeries(exposition, "kube_customresource_tombstone_info", `deleted="true"`) == tombstoneObjects
+	}); err != nil {
+		t.Fatalf("deleted objects' series were not retained as tombstones: %v", err)
+	}
+
+	f.AdvanceClock(tombstoneRetention + time.Second)
+	if err := eventuallyScrape(ctx, f, func(exposition string) bool {
+		return countSeries(exposition, "kube_customresource_tombstone_info", "") == 0
+	}); err != nil {
+		t.Fatalf("tombstones outlived their retention: %v", err)
+	}
+}
+
+// TestResourceMetricsMonitorDebounce updates an RMM, and the CR it targets, in bursts, and verifies that the RMM's
+// Processed condition only transitions once its hold elapsed, as told by the fake clock, while the exposition keeps up
+// with the CR's last update. This test does not run in parallel, as the clock is process-wide.
+func TestResourceMetricsMonitorDebounce(t *testing.T) {
+	ctx := context.Background()
+	f := setupFramework(t)
+	f.WithFakeClock(t)
+	hold := time.Duration(*f.Options.ConditionHold) * time.Second
+
+	rmm := &v1alpha1.ResourceMetricsMonitor{
+		ObjectMeta: metav1.ObjectMeta{Name: "debounce", Namespace: "default"},
+	}
+	cr := framework.NewCRBuilder("samplecontroller.k8s.io", "v1beta1", "Bar", "debounce", "default").
+		WithLabel("debounce", "true").
+		WithSpec("replicas", 0).
+		Build()
+	barsGVR := schema.GroupVersionResource{Group: "samplecontroller.k8s.io", Version: "v1beta1", Resource: "bars"}
+	t.Cleanup(func() {
+		_ = f.DeleteRMM(ctx, rmm.GetNamespace(), rmm.GetName())
+		_ = f.DeleteCR(ctx, barsGVR, cr.GetNamespace(), cr.GetName())
+	})
+
+	// applyAndWait applies the RMM with the given help text, and returns its Processed condition's transition count
+	// once the generation applied is processed.
+	applyAndWait := func(help string) int64 {
+		t.Helper()
+		rmm.Spec.Configuration = fmt.Sprintf(debounceConfiguration, help)
+		if _, err := f.ApplyRMM(ctx, rmm); err != nil {
+			t.Fatalf("failed to apply RMM: %v", err)
+		}
+		processed, err := f.WaitForRMMProcessed(ctx, rmm.GetNamespace(), rmm.GetName(), 5*framework.LongTimeInterval)
+		if err != nil {
+			t.Fatalf("failed waiting for RMM to be processed: %v", err)
+		}
+
+		return processed.Status.TransitionCount
+	}
+
+	applied, err := f.ApplyCRUnstructured(ctx, cr)
+	if err != nil {
+		t.Fatalf("failed to apply CR: %v", err)
+	}
+	transitions := applyAndWait("Debounce test family")
+
+	// The clock does not advance past the hold, so the transitions to False reconciling the updates sets are held off.
+	f.AdvanceClock(time.Second)
+	for i := range 3 {
+		if got := applyAndWait(fmt.Sprintf("Debounce test family, update %d", i)); got != transitions {
+			t.Fatalf("expected the Processed condition to be held at %d transitions, got %d", transitions, got)
+		}
+	}
+	// Once the hold elapsed, the next update transitions the condition to False, and back to True.
+	f.AdvanceClock(hold)
+	if got := applyAndWait("Debounce test family, held off"); got != transitions+2 {
+		t.Fatalf("expected the Processed condition to transition twice past its hold, from %d, got %d", transitions, got)
+	}
+
+	if _, err = f.BurstUpdates(ctx, applied, debounceUpdates, func(cr *unstructured.Unstructured, i int) {
+		_ = unstructured.SetNestedField(cr.Object, int64(i+1), "spec", "replicas")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expected := fmt.Sprintf(`kube_customresource_debounce_info{name="debounce",group="samplecontroller.k8s.io",version="v1beta1",kind="Bar"} %d`, debounceUpdates)
+	if err = eventuallyScrape(ctx, f, func(exposition string) bool {
+		return strings.Contains(exposition, expected)
+	}); err != nil {
+		t.Fatalf("exposition did not catch up with the last update: %v", err)
+	}
+}
+
+// countSeries returns the number of series of the given family in the given exposition, carrying the given rendered
+// label, if any.
+func countSeries(exposition, family, label string) int {
+	count := 0
+	for _, line := range strings.Split(exposition, "\n") {
+		if strings.HasPrefix(line, family+"{") && strings.Contains(line, label) {
+			count++
+		}
+	}
+
+	return count
+}
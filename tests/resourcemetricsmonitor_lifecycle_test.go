@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rexagod/resource-state-metrics/pkg/apis/resourcestatemetrics/v1alpha1"
+	"github.com/rexagod/resource-state-metrics/tests/framework"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const lifecycleConfiguration = `stores:
+  - group: "samplecontroller.k8s.io"
+    version: "v1beta1"
+    kind: "Bar"
+    resource: "bars"
+    selectors:
+      label: "foo=1"
+    families:
+      - name: "lifecycle_info"
+        help: "Lifecycle test family"
+        metrics:
+          - labelKeys:
+              - "name"
+            labelValues:
+              - "metadata.name"
+            value: "%s"
+`
+
+// TestResourceMetricsMonitorLifecycle verifies that RMMs created, updated, and deleted after the controller has started
+// are reflected in the exposition.
+func TestResourceMetricsMonitorLifecycle(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	f := setupFramework(t)
+
+	rmm := &v1alpha1.ResourceMetricsMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "lifecycle",
+			Namespace:  "default",
+			Generation: 1,
+		},
+	}
+
+	for _, step := range []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			name:  "add",
+			value: "spec.replicas",
+			expected: `# HELP kube_customresource_lifecycle_info Lifecycle test family
+# TYPE kube_customresource_lifecycle_info gauge
+kube_customresource_lifecycle_info{name="test-sample",group="samplecontroller.k8s.io",version="v1beta1",kind="Bar"} 3
+`,
+		},
+		{
+			name:  "update",
+			value: "metadata.labels.foo",
+			expected: `# HELP kube_customresource_lifecycle_info Lifecycle test family
+# TYPE kube_customresource_lifecycle_info gauge
+kube_customresource_lifecycle_info{name="test-sample",group="samplecontroller.k8s.io",version="v1beta1",kind="Bar"} 1
+`,
+		},
+	} {
+		rmm.Spec.Configuration = fmt.Sprintf(lifecycleConfiguration, step.value)
+		applied, err := f.ApplyRMM(ctx, rmm)
+		if err != nil {
+			t.Fatalf("%s: failed to apply RMM: %v", step.name, err)
+		}
+		if _, err = f.WaitForRMMProcessed(ctx, applied.GetNamespace(), applied.GetName(), 5*framework.LongTimeInterval); err != nil {
+			t.Fatalf("%s: failed waiting for RMM to be processed: %v", step.name, err)
+		}
+		if err = eventuallyScrapeAndCompare(ctx, f, step.expected); err != nil {
+			t.Fatalf("%s: metric comparison failed: %v", step.name, err)
+		}
+		// The dedicated endpoint exposes this RMM's metrics, and nothing else.
+		dedicatedURL := fmt.Sprintf("http://127.0.0.1:%d/metrics/%s/%s", *f.Options.MainPort, rmm.GetNamespace(), rmm.GetName())
+		if err = testutil.ScrapeAndCompare(dedicatedURL, strings.NewReader(step.expected)); err != nil {
+			t.Fatalf("%s: dedicated endpoint comparison failed: %v", step.name, err)
+		}
+	}
+
+	if err := f.DeleteRMM(ctx, rmm.GetNamespace(), rmm.GetName()); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	f.ExpectNoMetric(t, framework.Metric("kube_customresource_lifecycle_info"))
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2025 The Kubernetes resource-state-metrics Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rexagod/resource-state-metrics/tests/framework"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// sharedFramework starts a single controller for the whole package, since the controller's options are registered on
+// the global flag set and cannot be read more than once per process. Tests must therefore only assert on the metric
+// families they own.
+var sharedFramework = sync.OnceValues(func() (*framework.Framework, error) {
+	ctx := context.Background()
+	f := framework.NewInforming(ctx)
+
+	if err := applyCRDManifests(ctx, f); err != nil {
+		return nil, fmt.Errorf("failed to apply CRD manifests: %w", err)
+	}
+
+	gvrToKindListMap := make(map[schema.GroupVersionResource]string)
+	for _, crd := range f.GetIndexedCRDs() {
+		for _, version := range crd.Spec.Versions {
+			gv := schema.GroupVersion{Group: crd.Spec.Group, Version: version.Name}
+
+			f.AddToScheme(func(scheme *runtime.Scheme) {
+				scheme.AddKnownTypes(gv, &unstructured.Unstructured{}, &unstructured.UnstructuredList{})
+			})
+
+			// The dynamic client needs to know the List kind for each GVR to
+			// properly handle list operations. This is typically the singular Kind
+			// with "List" appended. This is also the reason why we aren't just
+			// passing the updated scheme to the dynamic client, as it doesn't have
+			// the necessary type information to derive the List kinds on its own.
+			// Regardless, we still update the scheme for other clients that may need it.
+			gvr := schema.GroupVersionResource{
+				Group:    crd.Spec.Group,
+				Version:  version.Name,
+				Resource: crd.Spec.Names.Plural,
+			}
+			gvrToKindListMap[gvr] = crd.Spec.Names.Kind + "List"
+		}
+	}
+
+	f.WithDynamicClient(gvrToKindListMap)
+
+	if err := applyCRManifests(ctx, f); err != nil {
+		return nil, fmt.Errorf("failed to apply CR manifests: %w", err)
+	}
+
+	if err := f.Start(ctx, 1); err != nil {
+		return nil, fmt.Errorf("failed to start controller: %w", err)
+	}
+
+	return f, nil
+})
+
+// setupFramework returns the package-wide framework, starting it on first use.
+func setupFramework(t *testing.T) *framework.Framework {
+	t.Helper()
+	f, err := sharedFramework()
+	if err != nil {
+		t.Fatalf("Failed to set up framework: %v", err)
+	}
+
+	return f
+}
+
+// getCRDandNonCRDManifests retrieves all CRD and non-CRD manifest file paths from the specified directories.
+func getCRDandNonCRDManifests() ([]string, []string, error) {
+	manifestDirs := []string{
+		"manifests",
+		"../manifests",
+	}
+
+	// Fake client does not support certain resources OOTB.
+	ignoredManifestsByPrefix := map[string]struct{}{
+		"cluster-role":                {},
+		"validating-admission-policy": {},
+	}
+
+	var (
+		crdFiles   []string
+		otherFiles []string
+	)
+
+	for _, manifestsDir := range manifestDirs {
+		if _, err := os.Stat(manifestsDir); os.IsNotExist(err) {
+			return nil, nil, fmt.Errorf("manifests directory does not exist: %s", manifestsDir)
+		}
+
+		err := filepath.Walk(manifestsDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			for ignoredPrefix := range ignoredManifestsByPrefix {
+				if strings.HasPrefix(filepath.Base(path), ignoredPrefix) {
+					return nil
+				}
+			}
+
+			if info.IsDir() || !strings.HasSuffix(path, ".yaml") {
+				return nil
+			}
+
+			// Assume all CRD manifests are prefixed with "custom-resource-definition"
+			if strings.HasPrefix(filepath.Base(path), "custom-resource-definition") {
+				crdFiles = append(crdFiles, path)
+			} else {
+				otherFiles = append(otherFiles, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return crdFiles, otherFiles, nil
+}
+
+// applyCRDManifests applies only CRD manifests from the manifest directories.
+func applyCRDManifests(ctx context.Context, f *framework.Framework) error {
+	crdFiles, _, err := getCRDandNonCRDManifests()
+	if err != nil {
+		return fmt.Errorf("failed to get manifest files: %w", err)
+	}
+
+	for _, path := range crdFiles {
+		if _, err := f.CreateCRDFromYAML(ctx, path); err != nil {
+			return fmt.Errorf("failed to create CRD from %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// applyCRManifests applies only CR manifests (non-CRD) from the manifest directories.
+func applyCRManifests(ctx context.Context, f *framework.Framework) error {
+	_, otherFiles, err := getCRDandNonCRDManifests()
+	if err != nil {
+		return fmt.Errorf("failed to get manifest files: %w", err)
+	}
+
+	for _, path := range otherFiles {
+		if _, err := f.ApplyCRFromYAML(ctx, path); err != nil {
+			return fmt.Errorf("failed to apply CR from %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// eventuallyScrapeAndCompare scrapes the main server until the families present in the expected exposition match it,
+// or the timeout is hit. Only the families named in the expected exposition's HELP lines are compared.
+func eventuallyScrapeAndCompare(ctx context.Context, f *framework.Framework, expected string) error {
+	var metricNames []string
+	for _, line := range strings.Split(expected, "\n") {
+		if fields := strings.Fields(line); len(fields) > 2 && fields[0] == "#" && fields[1] == "HELP" {
+			metricNames = append(metricNames, fields[2])
+		}
+	}
+	if len(metricNames) == 0 {
+		return errors.New("expected exposition has no HELP lines to select families with")
+	}
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/metrics", *f.Options.MainPort)
+	ctx, cancel := context.WithTimeout(ctx, 10*framework.LongTimeInterval)
+	defer cancel()
+	ticker := time.NewTicker(framework.ShortTimeInterval)
+	defer ticker.Stop()
+
+	var err error
+	for {
+		if err = testutil.ScrapeAndCompare(url, strings.NewReader(expected), metricNames...); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-ticker.C:
+		}
+	}
+}